@@ -0,0 +1,81 @@
+package auditing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// AnonymizeConfig configures the anonymization of a user.
+type AnonymizeConfig struct {
+	// DryRun only counts the entries that would be anonymized without modifying them.
+	DryRun bool
+	// Salt is prepended to the personal data before hashing, which prevents restoring the personal data
+	// by hashing known identifiers. The same salt results in the same pseudonyms, such that the entries of an
+	// anonymized user can still be correlated.
+	Salt string
+}
+
+// AnonymizeResult reports the entries of an anonymized user.
+type AnonymizeResult struct {
+	// Pseudonym replaces the user in the anonymized entries.
+	Pseudonym string `json:"pseudonym"`
+	// Indexes contains the amount of affected entries per index of the backend.
+	Indexes map[string]int64 `json:"indexes"`
+	// Total is the amount of affected entries of all indexes.
+	Total int64 `json:"total"`
+	// DryRun is true if the entries were only counted.
+	DryRun bool `json:"dry_run"`
+}
+
+// Anonymizer is implemented by auditing backends that can remove personal data from stored entries.
+type Anonymizer interface {
+	// AnonymizeUser replaces the user, subject, email, forwarded-for and remote address of all entries whose user,
+	// subject or email equals the given user id with pseudonyms, see Pseudonym. All other fields are kept, such that
+	// the audit trail stays intact.
+	AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error)
+}
+
+// AnonymizeUser removes the personal data of the given user from all stored entries, e.g. for a deletion request
+// according to the GDPR, see Anonymizer. Entries that were not indexed yet, e.g. because they are spooled, are not
+// anonymized.
+func AnonymizeUser(ctx context.Context, a Auditing, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user id must be specified")
+	}
+
+	anonymizer, ok := a.(Anonymizer)
+	if !ok {
+		return nil, fmt.Errorf("auditing backend %T does not support anonymization", a)
+	}
+
+	return anonymizer.AnonymizeUser(ctx, userID, c)
+}
+
+// Pseudonym returns the replacement of the given personal data, which is a salted hash. Empty values stay empty.
+func Pseudonym(salt, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(salt + value))
+
+	return "anonymized-" + hex.EncodeToString(sum[:8])
+}
+
+// anonymizeDocument returns a partial document of a stored entry that replaces its personal data with pseudonyms.
+func anonymizeDocument(doc map[string]any, salt string) map[string]any {
+	res := map[string]any{"id": doc["id"]}
+
+	for _, field := range []string{"user", "subject", "email", "forwarded-for", "remote-addr"} {
+		value, ok := doc[field].(string)
+		if !ok || value == "" {
+			continue
+		}
+
+		res[field] = Pseudonym(salt, value)
+	}
+
+	return res
+}
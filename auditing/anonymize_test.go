@@ -0,0 +1,82 @@
+package auditing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+type anonymizingAuditing struct {
+	memoryAuditing
+}
+
+func (a *anonymizingAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	result := &AnonymizeResult{Pseudonym: Pseudonym(c.Salt, userID), Indexes: map[string]int64{}, DryRun: c.DryRun}
+
+	for i, e := range a.entries {
+		if e.User != userID {
+			continue
+		}
+
+		result.Indexes["memory"]++
+		result.Total++
+
+		if !c.DryRun {
+			a.entries[i].User = Pseudonym(c.Salt, e.User)
+		}
+	}
+
+	return result, nil
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	backend := &anonymizingAuditing{}
+	backend.entries = []Entry{{Id: "1", User: "alice"}, {Id: "2", User: "bob"}, {Id: "3", User: "alice"}}
+
+	metrics, err := NewMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+	a, err := NewWithMetrics(backend, metrics, "test")
+	require.NoError(t, err)
+
+	result, err := AnonymizeUser(context.Background(), a, "alice", AnonymizeConfig{Salt: "salt", DryRun: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Total)
+	require.Equal(t, "alice", backend.entries[0].User, "dry run must not modify entries")
+
+	result, err = AnonymizeUser(context.Background(), a, "alice", AnonymizeConfig{Salt: "salt"})
+	require.NoError(t, err)
+	require.Equal(t, &AnonymizeResult{Pseudonym: "anonymized-3baa379b47fbc36e", Indexes: map[string]int64{"memory": 2}, Total: 2}, result)
+	require.Equal(t, result.Pseudonym, backend.entries[2].User)
+
+	_, err = AnonymizeUser(context.Background(), &memoryAuditing{}, "alice", AnonymizeConfig{})
+	require.EqualError(t, err, "auditing backend *auditing.memoryAuditing does not support anonymization")
+
+	_, err = AnonymizeUser(context.Background(), a, "", AnonymizeConfig{})
+	require.EqualError(t, err, "user id must be specified")
+}
+
+func TestAnonymizeDocument(t *testing.T) {
+	got := anonymizeDocument(map[string]any{
+		"id":            "1",
+		"user":          "alice",
+		"email":         "alice@example.com",
+		"remote-addr":   "10.0.0.1",
+		"forwarded-for": "",
+	}, "salt")
+
+	want := map[string]any{
+		"id":          "1",
+		"user":        Pseudonym("salt", "alice"),
+		"email":       Pseudonym("salt", "alice@example.com"),
+		"remote-addr": Pseudonym("salt", "10.0.0.1"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	require.NotEqual(t, Pseudonym("salt", "alice"), Pseudonym("other", "alice"))
+	require.Empty(t, Pseudonym("salt", ""))
+}
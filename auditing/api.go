@@ -0,0 +1,365 @@
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/metal-lib/httperrors"
+)
+
+const (
+	// APISearchPath is the path of the search endpoint relative to the mount point of the api handler.
+	APISearchPath = "/search"
+	// APIStatsPath is the path of the stats endpoint relative to the mount point of the api handler.
+	APIStatsPath = "/stats"
+	// APIEntriesPath is the path of the entry endpoint relative to the mount point of the api handler, it is followed by the id.
+	APIEntriesPath = "/entries"
+
+	defaultAPIMaxLimit   int64 = 1000
+	defaultClientTimeout       = 30 * time.Second
+)
+
+// CounterSource provides the request and error rates of users and tenants, e.g. the CountingAuditing.
+type CounterSource interface {
+	Counters() ([]CounterStat, error)
+}
+
+type APIConfig struct {
+	// Auditing is the backend that is queried by the search endpoint.
+	Auditing Auditing
+	// Counters is served by the stats endpoint, defaults to the auditing backend if it is a CounterSource.
+	// Without counters, the stats endpoint responds with 501 not implemented.
+	Counters CounterSource
+	// MaxLimit is the maximum amount of entries a single search may return, defaults to 1000.
+	MaxLimit int64
+	Log      *slog.Logger
+}
+
+// APIEntry is the representation of an entry in the api, which carries the error as string.
+type APIEntry struct {
+	Id             string            `json:"id"`
+	Component      string            `json:"component"`
+	RequestId      string            `json:"rqid"`
+	Type           EntryType         `json:"type"`
+	Timestamp      time.Time         `json:"timestamp"`
+	User           string            `json:"user"`
+	Subject        string            `json:"subject,omitempty"`
+	EMail          string            `json:"email,omitempty"`
+	Tenant         string            `json:"tenant"`
+	Detail         EntryDetail       `json:"detail"`
+	Phase          EntryPhase        `json:"phase"`
+	Sequence       uint64            `json:"sequence"`
+	Path           string            `json:"path"`
+	ForwardedFor   string            `json:"forwarded_for"`
+	RemoteAddr     string            `json:"remote_addr"`
+	Classification Classification    `json:"classification,omitempty"`
+	Body           any               `json:"body"`
+	StatusCode     int               `json:"status_code"`
+	Error          string            `json:"error,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Integrity      *EntryIntegrity   `json:"integrity,omitempty"`
+}
+
+func toAPIEntry(e Entry) APIEntry {
+	res := APIEntry{
+		Id:             e.Id,
+		Component:      e.Component,
+		RequestId:      e.RequestId,
+		Type:           e.Type,
+		Timestamp:      e.Timestamp,
+		User:           e.User,
+		Subject:        e.Subject,
+		EMail:          e.EMail,
+		Tenant:         e.Tenant,
+		Detail:         e.Detail,
+		Phase:          e.Phase,
+		Sequence:       e.Sequence,
+		Path:           e.Path,
+		ForwardedFor:   e.ForwardedFor,
+		RemoteAddr:     e.RemoteAddr,
+		Classification: e.Classification,
+		Body:           e.Body,
+		StatusCode:     e.StatusCode,
+		Labels:         e.Labels,
+		Integrity:      e.Integrity,
+	}
+	if e.Error != nil {
+		res.Error = e.Error.Error()
+	}
+	return res
+}
+
+func (e APIEntry) toEntry() Entry {
+	res := Entry{
+		Id:             e.Id,
+		Component:      e.Component,
+		RequestId:      e.RequestId,
+		Type:           e.Type,
+		Timestamp:      e.Timestamp,
+		User:           e.User,
+		Subject:        e.Subject,
+		EMail:          e.EMail,
+		Tenant:         e.Tenant,
+		Detail:         e.Detail,
+		Phase:          e.Phase,
+		Sequence:       e.Sequence,
+		Path:           e.Path,
+		ForwardedFor:   e.ForwardedFor,
+		RemoteAddr:     e.RemoteAddr,
+		Classification: e.Classification,
+		Body:           e.Body,
+		StatusCode:     e.StatusCode,
+		Labels:         e.Labels,
+		Integrity:      e.Integrity,
+	}
+	if e.Error != "" {
+		res.Error = errors.New(e.Error)
+	}
+	return res
+}
+
+type apiHandler struct {
+	auditing Auditing
+	counters CounterSource
+	maxLimit int64
+	log      *slog.Logger
+}
+
+// NewAPIHandler returns an http handler which exposes the search of the given auditing backend and its stats over REST,
+// such that central tooling can query the audit data of many services uniformly. It serves:
+//
+//	POST /search with an EntryFilter as body, responds with the matching entries
+//	GET  /entries/{id} responds with the entry of the given id
+//	GET  /stats responds with the counters of users and tenants
+//
+// The handler can be mounted in any service, e.g. with http.StripPrefix("/audit", handler). It does not authenticate
+// requests, so it must be protected by the middleware of the service.
+func NewAPIHandler(c APIConfig) (http.Handler, error) {
+	if c.Auditing == nil {
+		return nil, fmt.Errorf("auditing must not be nil")
+	}
+	if c.Counters == nil {
+		if counters, ok := c.Auditing.(CounterSource); ok {
+			c.Counters = counters
+		}
+	}
+	if c.MaxLimit <= 0 {
+		c.MaxLimit = defaultAPIMaxLimit
+	}
+	if c.Log == nil {
+		c.Log = slog.Default()
+	}
+
+	h := &apiHandler{
+		auditing: c.Auditing,
+		counters: c.Counters,
+		maxLimit: c.MaxLimit,
+		log:      c.Log,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+APISearchPath, h.search)
+	mux.HandleFunc("GET "+APIEntriesPath+"/{id}", h.get)
+	mux.HandleFunc("GET "+APIStatsPath, h.stats)
+
+	return mux, nil
+}
+
+func (h *apiHandler) search(w http.ResponseWriter, r *http.Request) {
+	var filter EntryFilter
+	err := json.NewDecoder(r.Body).Decode(&filter)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid entry filter: %w", err))
+		return
+	}
+
+	if filter.Limit > h.maxLimit {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("limit must not exceed %d", h.maxLimit))
+		return
+	}
+
+	entries, err := h.auditing.Search(filter)
+	if err != nil {
+		h.log.Error("unable to search audit entries", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := make([]APIEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, toAPIEntry(e))
+	}
+
+	writeAPIResponse(w, res)
+}
+
+func (h *apiHandler) get(w http.ResponseWriter, r *http.Request) {
+	entry, err := Get(r.Context(), h.auditing, r.PathValue("id"))
+	if errors.Is(err, ErrEntryNotFound) {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		h.log.Error("unable to get audit entry", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeAPIResponse(w, toAPIEntry(*entry))
+}
+
+func (h *apiHandler) stats(w http.ResponseWriter, _ *http.Request) {
+	if h.counters == nil {
+		writeAPIError(w, http.StatusNotImplemented, fmt.Errorf("auditing backend does not provide stats"))
+		return
+	}
+
+	counters, err := h.counters.Counters()
+	if err != nil {
+		h.log.Error("unable to get audit counters", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if counters == nil {
+		counters = []CounterStat{}
+	}
+
+	writeAPIResponse(w, counters)
+}
+
+func writeAPIResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(httperrors.NewHTTPError(code, err))
+}
+
+type ClientConfig struct {
+	// URL is the url the api handler is mounted at, e.g. https://metal-api/audit.
+	URL string
+	// Token is sent as bearer token if set.
+	Token string
+	// Client is the http client used for the requests, defaults to a client with a timeout of 30 seconds.
+	Client *http.Client
+}
+
+// Client queries the audit entries of a service through the api served by NewAPIHandler.
+type Client struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewClient returns a client for the api served by NewAPIHandler.
+func NewClient(c ClientConfig) (*Client, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("url must be specified")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{Timeout: defaultClientTimeout}
+	}
+
+	return &Client{
+		url:    strings.TrimSuffix(c.URL, "/"),
+		token:  c.Token,
+		client: c.Client,
+	}, nil
+}
+
+// Search returns the entries matching the given filter, sorted by timestamp in descending order.
+func (c *Client) Search(ctx context.Context, filter EntryFilter) ([]Entry, error) {
+	body, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []APIEntry
+	err = c.do(ctx, http.MethodPost, APISearchPath, body, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(res))
+	for _, e := range res {
+		entries = append(entries, e.toEntry())
+	}
+
+	return entries, nil
+}
+
+// Get returns the entry with the given id.
+func (c *Client) Get(ctx context.Context, id string) (*Entry, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id must be specified")
+	}
+
+	var res APIEntry
+	err := c.do(ctx, http.MethodGet, APIEntriesPath+"/"+url.PathEscape(id), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := res.toEntry()
+
+	return &entry, nil
+}
+
+// Stats returns the request and error rates of users and tenants of the service.
+func (c *Client) Stats(ctx context.Context) ([]CounterStat, error) {
+	var res []CounterStat
+	err := c.do(ctx, http.MethodGet, APIStatsPath, nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte, into any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var httpErr httperrors.HTTPErrorResponse
+		err = json.NewDecoder(resp.Body).Decode(&httpErr)
+		if err != nil || httpErr.Message == "" {
+			return httperrors.NewHTTPError(resp.StatusCode, fmt.Errorf("unexpected response from %s", path))
+		}
+
+		return &httpErr
+	}
+
+	return json.NewDecoder(resp.Body).Decode(into)
+}
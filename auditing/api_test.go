@@ -0,0 +1,125 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	backend := &memoryAuditing{
+		entries: []Entry{
+			{
+				Id:             "1",
+				Component:      "metal-api",
+				RequestId:      "abc",
+				Type:           EntryTypeHTTP,
+				Timestamp:      now,
+				User:           "alice",
+				Phase:          EntryPhaseResponse,
+				Path:           "/v1/machine",
+				Classification: ClassificationWrite,
+				Body:           map[string]any{"id": "m1"},
+				StatusCode:     http.StatusOK,
+				Labels:         map[string]string{"pod": "metal-api-0"},
+			},
+			{
+				Id:        "2",
+				Component: "metal-api",
+				Type:      EntryTypeGRPC,
+				Timestamp: now,
+				Phase:     EntryPhaseError,
+				Error:     errors.New("connection refused"),
+			},
+		},
+	}
+
+	handler, err := NewAPIHandler(APIConfig{Auditing: backend, MaxLimit: 10})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/audit/", http.StripPrefix("/audit", handler))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{URL: server.URL + "/audit/"})
+	require.NoError(t, err)
+
+	t.Run("search", func(t *testing.T) {
+		got, err := c.Search(ctx, EntryFilter{Component: "metal-api"})
+		require.NoError(t, err)
+
+		if diff := cmp.Diff(backend.entries, got, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		got, err := c.Get(ctx, "2")
+		require.NoError(t, err)
+
+		if diff := cmp.Diff(&backend.entries[1], got, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := c.Get(ctx, "3")
+		if diff := cmp.Diff(httperrors.NewHTTPError(http.StatusNotFound, errors.New("audit entry not found: 3")), err, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("error diff (+got -want):\n %s", diff)
+		}
+	})
+
+	t.Run("limit exceeded", func(t *testing.T) {
+		_, err := c.Search(ctx, EntryFilter{Limit: 100})
+		if diff := cmp.Diff(httperrors.NewHTTPError(http.StatusBadRequest, errors.New("limit must not exceed 10")), err, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("error diff (+got -want):\n %s", diff)
+		}
+	})
+
+	t.Run("stats not implemented", func(t *testing.T) {
+		_, err := c.Stats(ctx)
+		if diff := cmp.Diff(httperrors.NewHTTPError(http.StatusNotImplemented, errors.New("auditing backend does not provide stats")), err, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("error diff (+got -want):\n %s", diff)
+		}
+	})
+}
+
+func TestAPIStats(t *testing.T) {
+	counting, err := NewCounting(&memoryAuditing{}, CounterConfig{Window: time.Minute})
+	require.NoError(t, err)
+
+	require.NoError(t, counting.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseRequest, User: "alice"}))
+
+	handler, err := NewAPIHandler(APIConfig{Auditing: counting})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c, err := NewClient(ClientConfig{URL: server.URL})
+	require.NoError(t, err)
+
+	got, err := c.Stats(context.Background())
+	require.NoError(t, err)
+
+	want := []CounterStat{
+		{Kind: CounterKindUser, Name: "alice", Requests: 1, RequestRate: 1.0 / 60},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
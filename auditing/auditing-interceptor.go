@@ -8,10 +8,10 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/netip"
 
 	"connectrpc.com/connect"
 	"github.com/emicklei/go-restful/v3"
-	"github.com/google/uuid"
 	"github.com/metal-stack/metal-lib/rest"
 	"github.com/metal-stack/security"
 	"google.golang.org/grpc"
@@ -24,25 +24,25 @@ const (
 	Include string = "include-to-auditing"
 	// Exclude explicitly excludes the request to the auditing backend even if the request method would audit the request (only applies for the http filter)
 	Exclude string = "exclude-from-auditing"
+	// ClassificationKey is the metadata key for annotating a route with a Classification, which is recorded on the entries of the route (only applies for the http filter)
+	ClassificationKey string = "auditing-classification"
 )
 
-func UnaryServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool) (grpc.UnaryServerInterceptor, error) {
+func UnaryServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool, opts ...InterceptorOption) (grpc.UnaryServerInterceptor, error) {
 	if a == nil {
 		return nil, fmt.Errorf("cannot use nil auditing to create unary server interceptor")
 	}
+	c := newInterceptorConfig(SubjectIdentity, opts...)
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 		if !shouldAudit(info.FullMethod) {
 			return handler(ctx, req)
 		}
-		var requestID string
-		if str, ok := ctx.Value(rest.RequestIDKey).(string); ok {
-			requestID = str
-		}
-		if requestID == "" {
-			requestID = uuid.NewString()
+		requestID, ok := rest.RequestIDFromContext(ctx)
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
 
-		childCtx := context.WithValue(ctx, rest.RequestIDKey, requestID)
+		childCtx := rest.ContextWithRequestID(ctx, requestID)
 
 		auditReqContext := Entry{
 			RequestId: requestID,
@@ -52,11 +52,7 @@ func UnaryServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fu
 			Phase:     EntryPhaseRequest,
 		}
 
-		user := security.GetUserFromContext(ctx)
-		if user != nil {
-			auditReqContext.User = user.Subject
-			auditReqContext.Tenant = user.Tenant
-		}
+		auditReqContext.setUser(security.GetUserFromContext(ctx), c.identity)
 
 		err = a.Index(auditReqContext)
 		if err != nil {
@@ -84,22 +80,20 @@ func UnaryServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fu
 	}, nil
 }
 
-func StreamServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool) (grpc.StreamServerInterceptor, error) {
+func StreamServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool, opts ...InterceptorOption) (grpc.StreamServerInterceptor, error) {
 	if a == nil {
 		return nil, fmt.Errorf("cannot use nil auditing to create stream server interceptor")
 	}
+	c := newInterceptorConfig(SubjectIdentity, opts...)
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		if !shouldAudit(info.FullMethod) {
 			return handler(srv, ss)
 		}
-		var requestID string
-		if str, ok := ss.Context().Value(rest.RequestIDKey).(string); ok {
-			requestID = str
+		requestID, ok := rest.RequestIDFromContext(ss.Context())
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
-		if requestID == "" {
-			requestID = uuid.NewString()
-		}
-		childCtx := context.WithValue(ss.Context(), rest.RequestIDKey, requestID)
+		childCtx := rest.ContextWithRequestID(ss.Context(), requestID)
 		childSS := grpcServerStreamWithContext{
 			ServerStream: ss,
 			ctx:          childCtx,
@@ -113,11 +107,7 @@ func StreamServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(f
 			Type:      EntryTypeGRPC,
 		}
 
-		user := security.GetUserFromContext(ss.Context())
-		if user != nil {
-			auditReqContext.User = user.Subject
-			auditReqContext.Tenant = user.Tenant
-		}
+		auditReqContext.setUser(security.GetUserFromContext(ss.Context()), c.identity)
 
 		err := a.Index(auditReqContext)
 		if err != nil {
@@ -144,10 +134,106 @@ func StreamServerInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(f
 	}, nil
 }
 
+// InterceptorOption func for specifying options of the connect interceptor and the http filter
+type InterceptorOption func(c *interceptorConfig)
+
+type interceptorConfig struct {
+	trustedProxies  []netip.Prefix
+	classifications map[string]Classification
+	identity        IdentityExtractor
+}
+
+// IdentityExtractor returns the identity of the given user, which is recorded as the user of the entries.
+type IdentityExtractor func(user *security.User) string
+
+// SubjectIdentity records the subject of the user, this is the default of the grpc and connect interceptors.
+func SubjectIdentity(user *security.User) string {
+	return user.Subject
+}
+
+// EMailIdentity records the email of the user, this is the default of the http filter.
+func EMailIdentity(user *security.User) string {
+	return user.EMail
+}
+
+// WithIdentityExtractor sets how the user of an entry is determined, defaults to SubjectIdentity for the grpc and
+// connect interceptors and to EMailIdentity for the http filter.
+// The same extractor should be used for all interceptors of a component, such that entries of the same user
+// can be queried regardless of the protocol. The subject and the email are recorded in separate fields anyway.
+func WithIdentityExtractor(fn IdentityExtractor) InterceptorOption {
+	return func(c *interceptorConfig) {
+		c.identity = fn
+	}
+}
+
+// WithTrustedProxies sets the proxies whose forwarding headers are respected to determine the client ip, see rest.ClientIP.
+//
+// Without trusted proxies the remote address is recorded as before: the connect interceptor records the X-Real-Ip header
+// and falls back to the address of the peer, the http filter records the remote address of the request including
+// the port. As these headers can be set by any client, services should configure their trusted proxies.
+func WithTrustedProxies(proxies ...netip.Prefix) InterceptorOption {
+	return func(c *interceptorConfig) {
+		c.trustedProxies = proxies
+	}
+}
+
+// remoteAddr returns the remote address that is recorded in the entries of the connect interceptor.
+func remoteAddr(header http.Header, peer string, trustedProxies []netip.Prefix) string {
+	if len(trustedProxies) == 0 {
+		if realIP := header.Get("X-Real-Ip"); realIP != "" {
+			return realIP
+		}
+		return peer
+	}
+
+	return rest.ClientIPFromHeader(header, peer, trustedProxies)
+}
+
+// requestRemoteAddr returns the remote address that is recorded in the entries of the http filter.
+func requestRemoteAddr(r *http.Request, trustedProxies []netip.Prefix) string {
+	if len(trustedProxies) == 0 {
+		return r.RemoteAddr
+	}
+
+	return rest.ClientIP(r, trustedProxies)
+}
+
+// WithClassifications sets the classifications of procedures by their full name, e.g. /api.v1.MachineService/Get,
+// which are recorded on the entries of the connect interceptor. Routes of the http filter are classified through
+// their metadata, see ClassificationKey.
+func WithClassifications(classifications map[string]Classification) InterceptorOption {
+	return func(c *interceptorConfig) {
+		c.classifications = classifications
+	}
+}
+
+func newInterceptorConfig(identity IdentityExtractor, opts ...InterceptorOption) *interceptorConfig {
+	c := &interceptorConfig{
+		identity: identity,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (e *Entry) setUser(user *security.User, identity IdentityExtractor) {
+	if user == nil {
+		return
+	}
+	e.User = identity(user)
+	e.Subject = user.Subject
+	e.EMail = user.EMail
+	e.Tenant = user.Tenant
+}
+
 type auditingConnectInterceptor struct {
-	auditing    Auditing
-	logger      *slog.Logger
-	shouldAudit func(fullMethod string) bool
+	auditing        Auditing
+	logger          *slog.Logger
+	shouldAudit     func(fullMethod string) bool
+	trustedProxies  []netip.Prefix
+	classifications map[string]Classification
+	identity        IdentityExtractor
 }
 
 // WrapStreamingClient implements connect.Interceptor
@@ -156,28 +242,22 @@ func (a auditingConnectInterceptor) WrapStreamingClient(next connect.StreamingCl
 		if !a.shouldAudit(s.Procedure) {
 			return next(ctx, s)
 		}
-		var requestID string
-		if str, ok := ctx.Value(rest.RequestIDKey).(string); ok {
-			requestID = str
+		requestID, ok := rest.RequestIDFromContext(ctx)
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
-		if requestID == "" {
-			requestID = uuid.NewString()
-		}
-		childCtx := context.WithValue(ctx, rest.RequestIDKey, requestID)
+		childCtx := rest.ContextWithRequestID(ctx, requestID)
 
 		auditReqContext := Entry{
-			RequestId: requestID,
-			Detail:    EntryDetailGRPCStream,
-			Path:      s.Procedure,
-			Phase:     EntryPhaseOpened,
-			Type:      EntryTypeGRPC,
+			RequestId:      requestID,
+			Detail:         EntryDetailGRPCStream,
+			Path:           s.Procedure,
+			Phase:          EntryPhaseOpened,
+			Type:           EntryTypeGRPC,
+			Classification: a.classifications[s.Procedure],
 		}
 
-		user := security.GetUserFromContext(ctx)
-		if user != nil {
-			auditReqContext.User = user.Subject
-			auditReqContext.Tenant = user.Tenant
-		}
+		auditReqContext.setUser(security.GetUserFromContext(ctx), a.identity)
 
 		err := a.auditing.Index(auditReqContext)
 		if err != nil {
@@ -205,34 +285,24 @@ func (a auditingConnectInterceptor) WrapStreamingHandler(next connect.StreamingH
 		if !a.shouldAudit(shc.Spec().Procedure) {
 			return next(ctx, shc)
 		}
-		var requestID string
-		if str, ok := ctx.Value(rest.RequestIDKey).(string); ok {
-			requestID = str
-		}
-		if requestID == "" {
-			requestID = uuid.NewString()
+		requestID, ok := rest.RequestIDFromContext(ctx)
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
-		childCtx := context.WithValue(ctx, rest.RequestIDKey, requestID)
+		childCtx := rest.ContextWithRequestID(ctx, requestID)
 
 		auditReqContext := Entry{
-			RequestId:    requestID,
-			Detail:       EntryDetailGRPCStream,
-			Path:         shc.Spec().Procedure,
-			Phase:        EntryPhaseOpened,
-			Type:         EntryTypeGRPC,
-			RemoteAddr:   shc.RequestHeader().Get("X-Real-Ip"),
-			ForwardedFor: shc.RequestHeader().Get("X-Forwarded-For"),
+			RequestId:      requestID,
+			Detail:         EntryDetailGRPCStream,
+			Path:           shc.Spec().Procedure,
+			Phase:          EntryPhaseOpened,
+			Type:           EntryTypeGRPC,
+			RemoteAddr:     remoteAddr(shc.RequestHeader(), shc.Peer().Addr, a.trustedProxies),
+			ForwardedFor:   shc.RequestHeader().Get("X-Forwarded-For"),
+			Classification: a.classifications[shc.Spec().Procedure],
 		}
 
-		if auditReqContext.RemoteAddr == "" {
-			auditReqContext.RemoteAddr = shc.Peer().Addr
-		}
-
-		user := security.GetUserFromContext(ctx)
-		if user != nil {
-			auditReqContext.User = user.Subject
-			auditReqContext.Tenant = user.Tenant
-		}
+		auditReqContext.setUser(security.GetUserFromContext(ctx), a.identity)
 
 		err := a.auditing.Index(auditReqContext)
 		if err != nil {
@@ -268,35 +338,25 @@ func (i auditingConnectInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 		if !i.shouldAudit(ar.Spec().Procedure) {
 			return next(ctx, ar)
 		}
-		var requestID string
-		if str, ok := ctx.Value(rest.RequestIDKey).(string); ok {
-			requestID = str
-		}
-		if requestID == "" {
-			requestID = uuid.NewString()
+		requestID, ok := rest.RequestIDFromContext(ctx)
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
-		childCtx := context.WithValue(ctx, rest.RequestIDKey, requestID)
+		childCtx := rest.ContextWithRequestID(ctx, requestID)
 
 		auditReqContext := Entry{
-			RequestId:    requestID,
-			Detail:       EntryDetailGRPCUnary,
-			Path:         ar.Spec().Procedure,
-			Phase:        EntryPhaseRequest,
-			Type:         EntryTypeGRPC,
-			Body:         ar.Any(),
-			RemoteAddr:   ar.Header().Get("X-Real-Ip"),
-			ForwardedFor: ar.Header().Get("X-Forwarded-For"),
-		}
-
-		if auditReqContext.RemoteAddr == "" {
-			auditReqContext.RemoteAddr = ar.Peer().Addr
-		}
-
-		user := security.GetUserFromContext(ctx)
-		if user != nil {
-			auditReqContext.User = user.Subject
-			auditReqContext.Tenant = user.Tenant
-		}
+			RequestId:      requestID,
+			Detail:         EntryDetailGRPCUnary,
+			Path:           ar.Spec().Procedure,
+			Phase:          EntryPhaseRequest,
+			Type:           EntryTypeGRPC,
+			Body:           ar.Any(),
+			RemoteAddr:     remoteAddr(ar.Header(), ar.Peer().Addr, i.trustedProxies),
+			ForwardedFor:   ar.Header().Get("X-Forwarded-For"),
+			Classification: i.classifications[ar.Spec().Procedure],
+		}
+
+		auditReqContext.setUser(security.GetUserFromContext(ctx), i.identity)
 		err := i.auditing.Index(auditReqContext)
 		if err != nil {
 			return nil, err
@@ -324,21 +384,26 @@ func (i auditingConnectInterceptor) WrapUnary(next connect.UnaryFunc) connect.Un
 	}
 }
 
-func NewConnectInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool) (connect.Interceptor, error) {
+func NewConnectInterceptor(a Auditing, logger *slog.Logger, shouldAudit func(fullMethod string) bool, opts ...InterceptorOption) (connect.Interceptor, error) {
 	if a == nil {
 		return nil, fmt.Errorf("cannot use nil auditing to create connect interceptor")
 	}
+	c := newInterceptorConfig(SubjectIdentity, opts...)
 	return auditingConnectInterceptor{
-		auditing:    a,
-		logger:      logger,
-		shouldAudit: shouldAudit,
+		auditing:        a,
+		logger:          logger,
+		shouldAudit:     shouldAudit,
+		trustedProxies:  c.trustedProxies,
+		classifications: c.classifications,
+		identity:        c.identity,
 	}, nil
 }
 
-func HttpFilter(a Auditing, logger *slog.Logger) (restful.FilterFunction, error) {
+func HttpFilter(a Auditing, logger *slog.Logger, opts ...InterceptorOption) (restful.FilterFunction, error) {
 	if a == nil {
 		return nil, fmt.Errorf("cannot use nil auditing to create http middleware")
 	}
+	c := newInterceptorConfig(EMailIdentity, opts...)
 	return func(request *restful.Request, response *restful.Response, chain *restful.FilterChain) {
 		r := request.Request
 
@@ -373,27 +438,21 @@ func HttpFilter(a Auditing, logger *slog.Logger) (restful.FilterFunction, error)
 			return
 		}
 
-		var requestID string
-		if str, ok := r.Context().Value(rest.RequestIDKey).(string); ok {
-			requestID = str
-		}
-		if requestID == "" {
-			requestID = uuid.NewString()
+		requestID, ok := rest.RequestIDFromContext(r.Context())
+		if !ok {
+			requestID = rest.NewRequestID()
 		}
 		auditReqContext := Entry{
-			RequestId:    requestID,
-			Type:         EntryTypeHTTP,
-			Detail:       EntryDetail(r.Method),
-			Path:         r.URL.Path,
-			Phase:        EntryPhaseRequest,
-			ForwardedFor: request.HeaderParameter("x-forwarded-for"),
-			RemoteAddr:   r.RemoteAddr,
-		}
-		user := security.GetUserFromContext(r.Context())
-		if user != nil {
-			auditReqContext.User = user.EMail
-			auditReqContext.Tenant = user.Tenant
+			RequestId:      requestID,
+			Type:           EntryTypeHTTP,
+			Detail:         EntryDetail(r.Method),
+			Path:           r.URL.Path,
+			Phase:          EntryPhaseRequest,
+			ForwardedFor:   request.HeaderParameter("x-forwarded-for"),
+			RemoteAddr:     requestRemoteAddr(r, c.trustedProxies),
+			Classification: routeClassification(request.SelectedRoute()),
 		}
+		auditReqContext.setUser(security.GetUserFromContext(r.Context()), c.identity)
 
 		if r.Method != http.MethodGet && r.Body != nil {
 			bodyReader := r.Body
@@ -487,3 +546,15 @@ func statusCodeFromGrpc(err error) int {
 
 	return int(s.Code())
 }
+
+// routeClassification returns the classification the route is annotated with through its metadata, see ClassificationKey.
+func routeClassification(route restful.RouteReader) Classification {
+	switch classification := route.Metadata()[ClassificationKey].(type) {
+	case Classification:
+		return classification
+	case string:
+		return Classification(classification)
+	default:
+		return ""
+	}
+}
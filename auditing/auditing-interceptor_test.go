@@ -0,0 +1,181 @@
+package auditing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorsRecordConsistentIdentity(t *testing.T) {
+	user := &security.User{
+		Subject: "1234",
+		EMail:   "achim@metal-stack.io",
+		Tenant:  "tnnt",
+	}
+
+	tests := []struct {
+		name     string
+		opts     []InterceptorOption
+		wantUser map[EntryType]string
+	}{
+		{
+			name: "defaults",
+			wantUser: map[EntryType]string{
+				EntryTypeHTTP: "achim@metal-stack.io",
+				EntryTypeGRPC: "1234",
+			},
+		},
+		{
+			name: "subject extractor",
+			opts: []InterceptorOption{WithIdentityExtractor(SubjectIdentity)},
+			wantUser: map[EntryType]string{
+				EntryTypeHTTP: "1234",
+				EntryTypeGRPC: "1234",
+			},
+		},
+		{
+			name: "email extractor",
+			opts: []InterceptorOption{WithIdentityExtractor(EMailIdentity)},
+			wantUser: map[EntryType]string{
+				EntryTypeHTTP: "achim@metal-stack.io",
+				EntryTypeGRPC: "achim@metal-stack.io",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := &memoryAuditing{}
+
+			filter, err := HttpFilter(a, slog.Default(), tt.opts...)
+			require.NoError(t, err)
+
+			ws := new(restful.WebService).Path("/v1")
+			ws.Route(ws.POST("/machine").
+				Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+					req.Request = req.Request.WithContext(security.PutUserInContext(req.Request.Context(), user))
+					chain.ProcessFilter(req, resp)
+				}).
+				Filter(filter).
+				To(func(req *restful.Request, resp *restful.Response) {
+					resp.WriteHeader(http.StatusOK)
+				}))
+
+			container := restful.NewContainer()
+			container.Add(ws)
+			container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/machine", strings.NewReader("{}")))
+
+			interceptor, err := NewConnectInterceptor(a, slog.Default(), func(string) bool { return true }, tt.opts...)
+			require.NoError(t, err)
+
+			unary := interceptor.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})
+			_, err = unary(security.PutUserInContext(context.Background(), user), connect.NewRequest(&struct{}{}))
+			require.NoError(t, err)
+
+			require.Len(t, a.entries, 4)
+			for _, e := range a.entries {
+				require.Equal(t, tt.wantUser[e.Type], e.User, "%s %s", e.Type, e.Phase)
+				require.Equal(t, "1234", e.Subject)
+				require.Equal(t, "achim@metal-stack.io", e.EMail)
+				require.Equal(t, "tnnt", e.Tenant)
+			}
+		})
+	}
+}
+
+func TestInterceptorsRecordSequence(t *testing.T) {
+	a := &memoryAuditing{}
+
+	interceptor, err := NewConnectInterceptor(a, slog.Default(), func(string) bool { return true })
+	require.NoError(t, err)
+
+	unary := interceptor.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	for range 2 {
+		_, err = unary(context.Background(), connect.NewRequest(&struct{}{}))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, a.entries, 4)
+	for i, e := range a.entries {
+		require.Equal(t, uint64(i%2), e.Sequence, "%s %s", e.RequestId, e.Phase)
+	}
+	require.Equal(t, a.entries[0].RequestId, a.entries[1].RequestId)
+	require.NotEqual(t, a.entries[1].RequestId, a.entries[2].RequestId)
+}
+
+func TestInterceptorsRecordRemoteAddr(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []InterceptorOption
+		wantHTTPAddr    string
+		wantConnectAddr string
+	}{
+		{
+			name:            "without trusted proxies",
+			wantHTTPAddr:    "192.0.2.1:1234",
+			wantConnectAddr: "10.1.1.1",
+		},
+		{
+			name:            "with trusted proxies",
+			opts:            []InterceptorOption{WithTrustedProxies(netip.MustParsePrefix("192.0.2.0/24"))},
+			wantHTTPAddr:    "10.2.2.2",
+			wantConnectAddr: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a := &memoryAuditing{}
+
+			filter, err := HttpFilter(a, slog.Default(), tt.opts...)
+			require.NoError(t, err)
+
+			ws := new(restful.WebService).Path("/v1")
+			ws.Route(ws.POST("/machine").Filter(filter).To(func(req *restful.Request, resp *restful.Response) {
+				resp.WriteHeader(http.StatusOK)
+			}))
+
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/machine", strings.NewReader("{}"))
+			r.RemoteAddr = "192.0.2.1:1234"
+			r.Header.Set("X-Forwarded-For", "10.2.2.2")
+			container.ServeHTTP(httptest.NewRecorder(), r)
+
+			interceptor, err := NewConnectInterceptor(a, slog.Default(), func(string) bool { return true }, tt.opts...)
+			require.NoError(t, err)
+
+			unary := interceptor.WrapUnary(func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
+				return connect.NewResponse(&struct{}{}), nil
+			})
+			req := connect.NewRequest(&struct{}{})
+			req.Header().Set("X-Real-Ip", "10.1.1.1")
+			_, err = unary(context.Background(), req)
+			require.NoError(t, err)
+
+			require.Len(t, a.entries, 4)
+			for _, e := range a.entries {
+				want := tt.wantConnectAddr
+				if e.Type == EntryTypeHTTP {
+					want = tt.wantHTTPAddr
+				}
+				require.Equal(t, want, e.RemoteAddr, "%s %s", e.Type, e.Phase)
+			}
+		})
+	}
+}
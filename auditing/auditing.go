@@ -1,12 +1,33 @@
 package auditing
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type Config struct {
-	Component        string
+	// Component is the component of all entries, which can be overridden by the ComponentEnvVar.
+	// If empty, the component is detected from the pod labels and falls back to the name of the executable.
+	Component string
+	// ComponentEnvVar is the environment variable that overrides the component, defaults to AUDITING_COMPONENT.
+	ComponentEnvVar string
+	// PodLabelsFile is the path of the pod labels provided through a kubernetes downward API volume, defaults to /etc/podinfo/labels.
+	PodLabelsFile string
+	// CaptureLabels are the pod labels that are added to the labels of every entry, e.g. app.kubernetes.io/version.
+	CaptureLabels []string
+	// CapturePodMetadata adds the pod, namespace and node from the POD_NAME, POD_NAMESPACE and NODE_NAME environment variables
+	// to the labels of every entry, which makes replicas of a component distinguishable.
+	CapturePodMetadata bool
+	// MetricsRegisterer registers the metrics of the indexed entries, index errors and backend latency if set, see NewMetrics.
+	MetricsRegisterer prometheus.Registerer
+	// Integrity signs and chains the indexed entries if set, see NewWithIntegrity.
+	Integrity *IntegrityConfig
+
 	URL              string
 	APIKey           string
 	IndexPrefix      string
@@ -49,6 +70,15 @@ const (
 	EntryPhaseClosed   EntryPhase = "closed"
 )
 
+// Classification classifies a route or procedure by the kind of access it performs.
+type Classification string
+
+const (
+	ClassificationRead  Classification = "read"
+	ClassificationWrite Classification = "write"
+	ClassificationAdmin Classification = "admin"
+)
+
 const EntryFilterDefaultLimit int64 = 100
 
 type Entry struct {
@@ -58,25 +88,40 @@ type Entry struct {
 	Type      EntryType
 	Timestamp time.Time
 
-	User   string
-	Tenant string
+	// User is the identity of the user as determined by the identity extractor of the interceptors, see WithIdentityExtractor
+	User string
+	// Subject and EMail of the user are recorded independently of the identity extractor for cross-protocol queries
+	Subject string
+	EMail   string
+	Tenant  string
 
 	// For `EntryDetailHTTP` the HTTP method get, post, put, delete, ...
 	// For `EntryDetailGRPC` unary, stream
 	Detail EntryDetail
 	// e.g. Request, Response, Error, Opened, Close
 	Phase EntryPhase
+	// Sequence orders the entries of the same request, it is zero for the first entry and incremented for every
+	// following phase, such that entries with equal timestamps are ordered independently of their phase
+	Sequence uint64
 	// For `EntryDetailHTTP` /api/v1/...
 	// For `EntryDetailGRPC` /api.v1/... (the method name)
 	Path         string
 	ForwardedFor string
 	RemoteAddr   string
+	// The classification of the route or procedure if annotated, see ClassificationKey and WithClassifications
+	Classification Classification
 
 	Body       any // JSON, string or numbers
 	StatusCode int // for `EntryDetailHTTP` the HTTP status code, for EntryDetailGRPC` the grpc status code
 
 	// Internal errors
 	Error error
+
+	// Labels describe the origin of the entry, e.g. the pod and node of the component
+	Labels map[string]string
+
+	// Integrity protects the entry against modifications if enabled, see NewWithIntegrity
+	Integrity *EntryIntegrity
 }
 
 func (e *Entry) prepareForNextPhase() {
@@ -84,6 +129,7 @@ func (e *Entry) prepareForNextPhase() {
 	e.Timestamp = time.Now()
 	e.Body = nil
 	e.Error = nil
+	e.Sequence++
 
 	switch e.Phase {
 	case EntryPhaseRequest:
@@ -109,8 +155,10 @@ type EntryFilter struct {
 	RequestId string    `json:"rqid" optional:"true"`      // starts with
 	Type      EntryType `json:"type" optional:"true"`      // exact match
 
-	User   string `json:"user" optional:"true"`   // exact match
-	Tenant string `json:"tenant" optional:"true"` // exact match
+	User    string `json:"user" optional:"true"`    // exact match
+	Subject string `json:"subject" optional:"true"` // exact match
+	EMail   string `json:"email" optional:"true"`   // exact match
+	Tenant  string `json:"tenant" optional:"true"`  // exact match
 
 	Detail EntryDetail `json:"detail" optional:"true"` // exact match
 	Phase  EntryPhase  `json:"phase" optional:"true"`  // exact match
@@ -119,10 +167,14 @@ type EntryFilter struct {
 	ForwardedFor string `json:"forwarded_for" optional:"true"` // free text
 	RemoteAddr   string `json:"remote_addr" optional:"true"`   // free text
 
+	Classification Classification `json:"classification" optional:"true"` // exact match
+
 	Body       string `json:"body" optional:"true"`        // free text
 	StatusCode int    `json:"status_code" optional:"true"` // exact match
 
 	Error string `json:"error" optional:"true"` // free text
+
+	Labels map[string]string `json:"labels" optional:"true"` // exact match
 }
 
 type Auditing interface {
@@ -137,3 +189,95 @@ type Auditing interface {
 	// The returned entries will be sorted by timestamp in descending order.
 	Search(EntryFilter) ([]Entry, error)
 }
+
+// SearchStreamer is implemented by auditing backends that can count and stream search results without holding
+// all matching entries in memory.
+type SearchStreamer interface {
+	// Count returns the amount of entries matching the given filter, the limit of the filter is ignored.
+	Count(ctx context.Context, filter EntryFilter) (int64, error)
+	// SearchStream pages through the entries matching the given filter and calls fn for every entry in the order of Search.
+	// In contrast to Search, all matching entries are streamed if the limit of the filter is zero.
+	// Streaming stops with the error returned by fn or when the context is canceled.
+	SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error
+}
+
+// ErrEntryNotFound is returned by Get if no entry with the given id exists.
+var ErrEntryNotFound = errors.New("audit entry not found")
+
+// EntryGetter is implemented by auditing backends that can look up a single entry by its id.
+type EntryGetter interface {
+	// Get returns the entry with the given id, ErrEntryNotFound if it does not exist.
+	Get(ctx context.Context, id string) (*Entry, error)
+}
+
+// Get returns the entry with the given id, e.g. for deep links to an entry or for verifying writes in tests.
+// If the backend does not implement EntryGetter, the recent entries are searched, which is limited by the default limit of Search.
+func Get(ctx context.Context, a Auditing, id string) (*Entry, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id must be specified")
+	}
+
+	if g, ok := a.(EntryGetter); ok {
+		return g.Get(ctx, id)
+	}
+
+	var found *Entry
+	errFound := errors.New("found")
+
+	err := SearchStream(ctx, a, EntryFilter{}, func(e Entry) error {
+		if e.Id != id {
+			return nil
+		}
+		found = &e
+		return errFound
+	})
+	if err != nil && !errors.Is(err, errFound) {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, id)
+	}
+
+	return found, nil
+}
+
+// Count returns the amount of entries matching the given filter. If the backend does not implement SearchStreamer,
+// the entries are searched and counted, which is limited by the limit of the filter.
+func Count(ctx context.Context, a Auditing, filter EntryFilter) (int64, error) {
+	if s, ok := a.(SearchStreamer); ok {
+		return s.Count(ctx, filter)
+	}
+
+	entries, err := a.Search(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(entries)), nil
+}
+
+// SearchStream calls fn for every entry matching the given filter, see SearchStreamer. If the backend does not implement
+// SearchStreamer, the entries are searched first, which is limited by the limit of the filter.
+func SearchStream(ctx context.Context, a Auditing, filter EntryFilter, fn func(Entry) error) error {
+	if s, ok := a.(SearchStreamer); ok {
+		return s.SearchStream(ctx, filter, fn)
+	}
+
+	entries, err := a.Search(filter)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
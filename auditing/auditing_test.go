@@ -0,0 +1,57 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchStreamFallback(t *testing.T) {
+	backend := &memoryAuditing{
+		entries: []Entry{
+			{RequestId: "1"},
+			{RequestId: "2"},
+			{RequestId: "3"},
+		},
+	}
+
+	count, err := Count(context.Background(), backend, EntryFilter{})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	var got []string
+	err = SearchStream(context.Background(), backend, EntryFilter{}, func(e Entry) error {
+		got = append(got, e.RequestId)
+		return nil
+	})
+	require.NoError(t, err)
+
+	if diff := cmp.Diff(got, []string{"1", "2", "3"}); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	stop := errors.New("stop")
+	got = nil
+	err = SearchStream(context.Background(), backend, EntryFilter{}, func(e Entry) error {
+		got = append(got, e.RequestId)
+		if len(got) == 2 {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Len(t, got, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got = nil
+	err = SearchStream(ctx, backend, EntryFilter{}, func(e Entry) error {
+		got = append(got, e.RequestId)
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, got, 1)
+}
@@ -0,0 +1,81 @@
+package auditing
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/metal-stack/metal-lib/rest"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultAuthFailureLimit rate.Limit = 10
+)
+
+type AuthFailureHookConfig struct {
+	// Limit is the maximum rate of authentication failures per second that are written to the auditing backend, defaults to 10.
+	// Failures exceeding this rate are dropped such that brute-force attempts do not flood the backend.
+	Limit rate.Limit
+	// Burst is the maximum amount of authentication failures that are written at once, defaults to the limit.
+	Burst int
+	// TrustedProxies are the proxies whose forwarding headers are respected to determine the client ip, see rest.ClientIP.
+	TrustedProxies []netip.Prefix
+}
+
+// AuthFailureHook returns a hook for the rest.UserAuth filter that writes failed authentications to the auditing backend.
+func AuthFailureHook(a Auditing, logger *slog.Logger, c *AuthFailureHookConfig) (rest.AuthFailureHook, error) {
+	if a == nil {
+		return nil, fmt.Errorf("cannot use nil auditing to create auth failure hook")
+	}
+	if c == nil {
+		c = &AuthFailureHookConfig{}
+	}
+	if c.Limit <= 0 {
+		c.Limit = defaultAuthFailureLimit
+	}
+	if c.Burst <= 0 {
+		c.Burst = max(int(c.Limit), 1)
+	}
+
+	var (
+		limiter    = rate.NewLimiter(c.Limit, c.Burst)
+		suppressed atomic.Int64
+	)
+
+	return func(request *restful.Request, authErr error) {
+		if !limiter.Allow() {
+			suppressed.Add(1)
+			return
+		}
+
+		if count := suppressed.Swap(0); count > 0 {
+			logger.Warn("suppressed auditing of authentication failures due to rate limiting", "count", count)
+		}
+
+		r := request.Request
+
+		requestID, ok := rest.RequestIDFromContext(r.Context())
+		if !ok {
+			requestID = rest.NewRequestID()
+		}
+
+		err := a.Index(Entry{
+			RequestId:    requestID,
+			Type:         EntryTypeHTTP,
+			Detail:       EntryDetail(r.Method),
+			Path:         r.URL.Path,
+			Phase:        EntryPhaseError,
+			ForwardedFor: request.HeaderParameter("x-forwarded-for"),
+			RemoteAddr:   rest.ClientIP(r, c.TrustedProxies),
+			StatusCode:   http.StatusForbidden,
+			Error:        authErr,
+		})
+		if err != nil {
+			logger.Error("unable to index", "error", err)
+		}
+	}, nil
+}
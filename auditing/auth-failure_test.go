@@ -0,0 +1,71 @@
+package auditing
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+type memoryAuditing struct {
+	entries []Entry
+}
+
+func (m *memoryAuditing) Flush() error { return nil }
+
+func (m *memoryAuditing) Index(e Entry) error {
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memoryAuditing) Search(EntryFilter) ([]Entry, error) {
+	return m.entries, nil
+}
+
+func TestAuthFailureHook(t *testing.T) {
+	a := &memoryAuditing{}
+
+	hook, err := AuthFailureHook(a, slog.Default(), &AuthFailureHookConfig{Limit: 0.0001, Burst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authErr := errors.New("invalid token")
+
+	for range 5 {
+		r := httptest.NewRequest(http.MethodPost, "/v1/machine", nil)
+		r.RemoteAddr = "1.2.3.4:1234"
+		hook(restful.NewRequest(r), authErr)
+	}
+
+	want := []Entry{
+		{
+			Type:       EntryTypeHTTP,
+			Detail:     EntryDetail(http.MethodPost),
+			Path:       "/v1/machine",
+			Phase:      EntryPhaseError,
+			RemoteAddr: "1.2.3.4",
+			StatusCode: http.StatusForbidden,
+			Error:      authErr,
+		},
+		{
+			Type:       EntryTypeHTTP,
+			Detail:     EntryDetail(http.MethodPost),
+			Path:       "/v1/machine",
+			Phase:      EntryPhaseError,
+			RemoteAddr: "1.2.3.4",
+			StatusCode: http.StatusForbidden,
+			Error:      authErr,
+		},
+	}
+
+	if diff := cmp.Diff(want, a.entries, cmpopts.IgnoreFields(Entry{}, "RequestId"), testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
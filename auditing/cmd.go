@@ -0,0 +1,236 @@
+package auditing
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AuditCmdConfig contains the configuration for the audit command.
+type AuditCmdConfig struct {
+	// Search returns the entries matching the given filter, e.g. Client.Search for the api served by NewAPIHandler.
+	Search func(ctx context.Context, filter EntryFilter) ([]Entry, error)
+	// Clock is used for resolving relative times of the --from and --to flags, defaults to the system clock.
+	Clock genericcli.Clock
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+}
+
+// NewAuditCmd returns an audit command with list and describe sub commands, which search the audit backend.
+// The flags of the list command map to the fields of the EntryFilter, the output format is configured by the flags
+// added by genericcli.AddPrinterFlags.
+func NewAuditCmd(c *AuditCmdConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "search the audit trail",
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "list audit entries",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := c.filterFromViper()
+			if err != nil {
+				return err
+			}
+
+			return c.searchAndPrint(cmd.Context(), filter)
+		},
+	}
+
+	listCmd.Flags().String("from", "", "only entries after the given time, either RFC3339, a date like 2006-01-02 or a duration relative to now like 1h")
+	listCmd.Flags().String("to", "", "only entries before the given time, either RFC3339, a date like 2006-01-02 or a duration relative to now like 1h")
+	listCmd.Flags().String("component", "", "only entries of the given component")
+	listCmd.Flags().String("request-id", "", "only entries whose request id starts with the given value")
+	listCmd.Flags().String("type", "", "only entries of the given type, can be one of: http|grpc|event")
+	listCmd.Flags().String("user", "", "only entries of the given user")
+	listCmd.Flags().String("tenant", "", "only entries of the given tenant")
+	listCmd.Flags().String("phase", "", "only entries of the given phase")
+	listCmd.Flags().String("path", "", "only entries whose path matches the given value")
+	listCmd.Flags().Int("status-code", 0, "only entries with the given status code")
+	listCmd.Flags().String("body", "", "only entries whose body matches the given text")
+	listCmd.Flags().Int64("limit", EntryFilterDefaultLimit, "the maximum amount of entries to return")
+
+	phases := []string{
+		string(EntryPhaseRequest), string(EntryPhaseResponse), string(EntryPhaseSingle),
+		string(EntryPhaseError), string(EntryPhaseOpened), string(EntryPhaseClosed),
+	}
+	genericcli.Must(listCmd.RegisterFlagCompletionFunc("phase", cobra.FixedCompletions(phases, cobra.ShellCompDirectiveNoFileComp)))
+	genericcli.Must(listCmd.RegisterFlagCompletionFunc("type", cobra.FixedCompletions([]string{string(EntryTypeHTTP), string(EntryTypeGRPC), string(EntryTypeEvent)}, cobra.ShellCompDirectiveNoFileComp)))
+
+	describeCmd := &cobra.Command{
+		Use:   "describe <request-id>",
+		Short: "describes all audit entries of a request in chronological order",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.describeAndPrint(cmd.Context(), args[0])
+		},
+	}
+
+	cmd.AddCommand(listCmd, describeCmd)
+
+	return cmd
+}
+
+func (c *AuditCmdConfig) filterFromViper() (EntryFilter, error) {
+	now := c.now()
+
+	from, err := parseTimeFlag(viper.GetString("from"), now)
+	if err != nil {
+		return EntryFilter{}, fmt.Errorf("invalid --from: %w", err)
+	}
+	to, err := parseTimeFlag(viper.GetString("to"), now)
+	if err != nil {
+		return EntryFilter{}, fmt.Errorf("invalid --to: %w", err)
+	}
+
+	return EntryFilter{
+		Limit:      viper.GetInt64("limit"),
+		From:       from,
+		To:         to,
+		Component:  viper.GetString("component"),
+		RequestId:  viper.GetString("request-id"),
+		Type:       EntryType(viper.GetString("type")),
+		User:       viper.GetString("user"),
+		Tenant:     viper.GetString("tenant"),
+		Phase:      EntryPhase(viper.GetString("phase")),
+		Path:       viper.GetString("path"),
+		StatusCode: viper.GetInt("status-code"),
+		Body:       viper.GetString("body"),
+	}, nil
+}
+
+func (c *AuditCmdConfig) searchAndPrint(ctx context.Context, filter EntryFilter) error {
+	if c.Search == nil {
+		return fmt.Errorf("no search function configured")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entries, err := c.Search(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.print(entries)
+}
+
+func (c *AuditCmdConfig) describeAndPrint(ctx context.Context, requestID string) error {
+	if c.Search == nil {
+		return fmt.Errorf("no search function configured")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	entries, err := c.Search(ctx, EntryFilter{RequestId: requestID})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return genericcli.NewError(genericcli.ErrorKindNotFound, fmt.Errorf("no audit entries found for request id %q", requestID))
+	}
+
+	// search returns the most recent entries first, a request is easier to follow in chronological order
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		if c := a.Timestamp.Compare(b.Timestamp); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+
+	return c.print(entries)
+}
+
+func (c *AuditCmdConfig) print(entries []Entry) error {
+	p, err := genericcli.PrinterFromViper(&genericcli.PrinterConfig{
+		ToHeaderAndRows: auditTable,
+		Out:             c.Out,
+	})
+	if err != nil {
+		return err
+	}
+
+	res := make([]APIEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, toAPIEntry(e))
+	}
+
+	return p.Print(res)
+}
+
+func (c *AuditCmdConfig) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
+}
+
+// parseTimeFlag parses an absolute time or a duration, which is subtracted from now.
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is neither a time nor a duration", value)
+}
+
+func auditTable(data any, wide bool) ([]string, [][]string, error) {
+	entries, ok := data.([]APIEntry)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported type for audit table: %T", data)
+	}
+
+	header := []string{"Time", "Request ID", "Component", "Phase", "User", "Tenant", "Path", "Code"}
+	if wide {
+		header = append(header, "Type", "Detail", "Remote Addr", "Forwarded For", "Error")
+	}
+
+	var rows [][]string
+	for _, e := range entries {
+		code := ""
+		if e.StatusCode != 0 {
+			code = strconv.Itoa(e.StatusCode)
+		}
+
+		row := []string{
+			e.Timestamp.Format(time.RFC3339),
+			e.RequestId,
+			e.Component,
+			string(e.Phase),
+			e.User,
+			e.Tenant,
+			e.Path,
+			code,
+		}
+		if wide {
+			row = append(row, string(e.Type), string(e.Detail), e.RemoteAddr, e.ForwardedFor, strings.TrimSpace(e.Error))
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
@@ -0,0 +1,115 @@
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditCmdList(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var got EntryFilter
+	c := &AuditCmdConfig{
+		Search: func(ctx context.Context, filter EntryFilter) ([]Entry, error) {
+			got = filter
+			return []Entry{
+				{RequestId: "rq1", Component: "metal-api", Timestamp: now, Phase: EntryPhaseResponse, User: "alice", Tenant: "t1", Path: "/v1/machine", StatusCode: 200},
+			}, nil
+		},
+		Clock: genericcli.NewFakeClock(now, 0),
+		Out:   &bytes.Buffer{},
+	}
+
+	viper.Set("from", "1h")
+	viper.Set("to", "2024-01-01T11:30:00Z")
+	viper.Set("user", "alice")
+	viper.Set("phase", "response")
+	viper.Set("status-code", 200)
+	viper.Set("limit", 10)
+	defer viper.Reset()
+
+	cmd := NewAuditCmd(c)
+	cmd.SetArgs([]string{"list"})
+	require.NoError(t, cmd.Execute())
+
+	want := EntryFilter{
+		Limit:      10,
+		From:       now.Add(-time.Hour),
+		To:         time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC),
+		User:       "alice",
+		Phase:      EntryPhaseResponse,
+		StatusCode: 200,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	require.Contains(t, c.Out.(*bytes.Buffer).String(), "rq1")
+}
+
+func TestAuditCmdDescribe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var printed bytes.Buffer
+	c := &AuditCmdConfig{
+		Search: func(ctx context.Context, filter EntryFilter) ([]Entry, error) {
+			require.Equal(t, EntryFilter{RequestId: "rq1"}, filter)
+			return []Entry{
+				{RequestId: "rq1", Timestamp: now, Phase: EntryPhaseResponse, Sequence: 1},
+				{RequestId: "rq1", Timestamp: now, Phase: EntryPhaseRequest, Sequence: 0},
+			}, nil
+		},
+		Out: &printed,
+	}
+
+	viper.Set("output-format", "json")
+	defer viper.Reset()
+
+	require.NoError(t, c.describeAndPrint(context.Background(), "rq1"))
+	require.Less(t, bytes.Index(printed.Bytes(), []byte(`"request"`)), bytes.Index(printed.Bytes(), []byte(`"response"`)))
+
+	c.Search = func(ctx context.Context, filter EntryFilter) ([]Entry, error) {
+		return nil, nil
+	}
+	err := c.describeAndPrint(context.Background(), "rq2")
+	require.EqualError(t, err, `no audit entries found for request id "rq2"`)
+
+	var cliErr *genericcli.Error
+	require.True(t, errors.As(err, &cliErr))
+}
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr string
+	}{
+		{value: ""},
+		{value: "30m", want: now.Add(-30 * time.Minute)},
+		{value: "2023-12-24", want: time.Date(2023, 12, 24, 0, 0, 0, 0, time.UTC)},
+		{value: "2023-12-24T18:00:00Z", want: time.Date(2023, 12, 24, 18, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: `"yesterday" is neither a time nor a duration`},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeFlag(tt.value, now)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
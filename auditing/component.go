@@ -0,0 +1,136 @@
+package auditing
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultComponentEnvVar is the environment variable that overrides the component of all entries.
+	DefaultComponentEnvVar = "AUDITING_COMPONENT"
+	// DefaultPodLabelsFile is the default path of the pod labels provided through a kubernetes downward API volume.
+	DefaultPodLabelsFile = "/etc/podinfo/labels"
+
+	// the labels that are consulted for the component in the pod labels file, in this order
+	componentLabelName     = "app.kubernetes.io/component"
+	componentLabelInstance = "app.kubernetes.io/name"
+	componentLabelApp      = "app"
+)
+
+// podMetadataEnvVars are the environment variables that are typically populated through the kubernetes downward API
+// and the label they are captured in.
+var podMetadataEnvVars = map[string]string{
+	"POD_NAME":      "pod",
+	"POD_NAMESPACE": "namespace",
+	"NODE_NAME":     "node",
+}
+
+// componentEnv abstracts the environment that is used to detect the component.
+type componentEnv struct {
+	getenv     func(string) string
+	readFile   func(string) ([]byte, error)
+	executable func() (string, error)
+}
+
+func defaultComponentEnv() componentEnv {
+	return componentEnv{
+		getenv:     os.Getenv,
+		readFile:   os.ReadFile,
+		executable: os.Executable,
+	}
+}
+
+// detectComponent returns the component and the labels that are added to every entry.
+//
+// The component is taken from the first of:
+//   - the component environment variable
+//   - the component configured in the config
+//   - the pod labels app.kubernetes.io/component, app.kubernetes.io/name or app from the pod labels file
+//   - the name of the executable
+func (e componentEnv) detectComponent(c Config) (string, map[string]string, error) {
+	envVar := c.ComponentEnvVar
+	if envVar == "" {
+		envVar = DefaultComponentEnvVar
+	}
+
+	labelsFile := c.PodLabelsFile
+	if labelsFile == "" {
+		labelsFile = DefaultPodLabelsFile
+	}
+
+	podLabels, err := e.podLabels(labelsFile)
+	if err != nil {
+		return "", nil, err
+	}
+
+	labels := map[string]string{}
+	for _, name := range c.CaptureLabels {
+		if value, ok := podLabels[name]; ok {
+			labels[name] = value
+		}
+	}
+	if c.CapturePodMetadata {
+		for env, name := range podMetadataEnvVars {
+			if value := e.getenv(env); value != "" {
+				labels[name] = value
+			}
+		}
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+
+	if component := e.getenv(envVar); component != "" {
+		return component, labels, nil
+	}
+	if c.Component != "" {
+		return c.Component, labels, nil
+	}
+	for _, name := range []string{componentLabelName, componentLabelInstance, componentLabelApp} {
+		if component := podLabels[name]; component != "" {
+			return component, labels, nil
+		}
+	}
+
+	ex, err := e.executable()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return filepath.Base(ex), labels, nil
+}
+
+// podLabels parses the pod labels file of a downward API volume, which contains one key="value" pair per line.
+// A missing file results in no labels as the application is not necessarily running in a pod.
+func (e componentEnv) podLabels(path string) (map[string]string, error) {
+	raw, err := e.readFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	labels := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !found || key == "" {
+			continue
+		}
+
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+
+		labels[key] = value
+	}
+
+	return labels, scanner.Err()
+}
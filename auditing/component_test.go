@@ -0,0 +1,105 @@
+package auditing
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectComponent(t *testing.T) {
+	const podLabels = `app.kubernetes.io/name="metal-api"
+app.kubernetes.io/version="v0.30.0"
+pod-template-hash="7d4b9c8f6"
+`
+
+	tests := []struct {
+		name          string
+		config        Config
+		env           map[string]string
+		podLabels     string
+		wantComponent string
+		wantLabels    map[string]string
+	}{
+		{
+			name:          "falls back to executable",
+			wantComponent: "server",
+		},
+		{
+			name:          "config",
+			config:        Config{Component: "metal-api"},
+			wantComponent: "metal-api",
+		},
+		{
+			name:          "env var overrides config",
+			config:        Config{Component: "metal-api"},
+			env:           map[string]string{DefaultComponentEnvVar: "metal-api-grpc"},
+			wantComponent: "metal-api-grpc",
+		},
+		{
+			name:          "custom env var",
+			config:        Config{ComponentEnvVar: "COMPONENT"},
+			env:           map[string]string{"COMPONENT": "masterdata-api"},
+			wantComponent: "masterdata-api",
+		},
+		{
+			name:          "pod labels",
+			podLabels:     podLabels,
+			wantComponent: "metal-api",
+		},
+		{
+			name: "captured labels and pod metadata",
+			config: Config{
+				Component:          "metal-api",
+				CaptureLabels:      []string{"app.kubernetes.io/version", "app.kubernetes.io/part-of"},
+				CapturePodMetadata: true,
+			},
+			env: map[string]string{
+				"POD_NAME":  "metal-api-7d4b9c8f6-x2v9k",
+				"NODE_NAME": "worker-1",
+			},
+			podLabels:     podLabels,
+			wantComponent: "metal-api",
+			wantLabels: map[string]string{
+				"app.kubernetes.io/version": "v0.30.0",
+				"pod":                       "metal-api-7d4b9c8f6-x2v9k",
+				"node":                      "worker-1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			env := componentEnv{
+				getenv: func(key string) string {
+					return tt.env[key]
+				},
+				readFile: func(path string) ([]byte, error) {
+					if tt.podLabels == "" || path != DefaultPodLabelsFile {
+						return nil, os.ErrNotExist
+					}
+					return []byte(tt.podLabels), nil
+				},
+				executable: func() (string, error) {
+					return "/usr/local/bin/server", nil
+				},
+			}
+
+			gotComponent, gotLabels, err := env.detectComponent(tt.config)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.wantComponent, gotComponent)
+			if diff := cmp.Diff(tt.wantLabels, gotLabels); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeLabels(t *testing.T) {
+	got := mergeLabels(map[string]string{"pod": "a", "node": "b"}, map[string]string{"pod": "c"})
+	require.Equal(t, map[string]string{"pod": "c", "node": "b"}, got)
+
+	require.Nil(t, mergeLabels(nil, nil))
+}
@@ -0,0 +1,397 @@
+package auditing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultCounterWindow       = 5 * time.Minute
+	defaultCounterResolution   = 10 * time.Second
+	defaultCounterMetricsLimit = 10
+)
+
+type CounterKind string
+
+const (
+	CounterKindUser   CounterKind = "user"
+	CounterKindTenant CounterKind = "tenant"
+)
+
+// CounterKey identifies a user or a tenant whose requests are counted.
+type CounterKey struct {
+	Kind CounterKind
+	Name string
+}
+
+// Counts are the number of requests and errors of a counter key.
+type Counts struct {
+	Requests int64
+	Errors   int64
+}
+
+// CounterStore stores the counts of the counting auditing in time buckets, e.g. in memory or in a shared store
+// like redis when multiple replicas of an application need to be aggregated.
+type CounterStore interface {
+	// Add adds the given counts to the bucket of the given key starting at the given time.
+	Add(key CounterKey, bucket time.Time, counts Counts) error
+	// Sum returns the sum of the counts of all keys in buckets starting at or after since.
+	// Buckets before since are not queried anymore and can be discarded by the store.
+	Sum(since time.Time) (map[CounterKey]Counts, error)
+}
+
+type CounterConfig struct {
+	// Window is the duration of the sliding window in which requests and errors are counted, defaults to 5 minutes.
+	Window time.Duration
+	// Resolution is the size of the time buckets of the sliding window, defaults to 10 seconds.
+	Resolution time.Duration
+	// Store holds the counts, defaults to an in-memory store.
+	Store CounterStore
+	// MetricsRegisterer registers the counters as prometheus gauges if set.
+	MetricsRegisterer prometheus.Registerer
+	// MetricsLimit is the maximum amount of users and of tenants that are reported as prometheus gauges, those with
+	// the most requests within the window are reported. Every reported user and tenant is a separate time series,
+	// so the amount is limited, defaults to 10. The counters handler reports all users and tenants.
+	MetricsLimit int
+}
+
+// CounterStat is the request and error rate of a user or tenant within the sliding window.
+type CounterStat struct {
+	Kind     CounterKind `json:"kind"`
+	Name     string      `json:"name"`
+	Requests int64       `json:"requests"`
+	Errors   int64       `json:"errors"`
+	// RequestRate is the average amount of requests per second within the window.
+	RequestRate float64 `json:"request_rate"`
+	// ErrorRate is the ratio of errors to requests within the window.
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// CountingAuditing wraps an auditing backend and counts requests and errors per user and tenant from the indexed entries,
+// such that suspicious activity like token abuse can be detected and alerted on close to the audit source.
+type CountingAuditing struct {
+	Auditing
+
+	store      CounterStore
+	window     time.Duration
+	resolution time.Duration
+	now        func() time.Time
+	descs      map[counterDescKey]*prometheus.Desc
+	limit      int
+}
+
+// NewCounting returns a counting auditing, which passes all calls through to the given backend.
+func NewCounting(backend Auditing, c CounterConfig) (*CountingAuditing, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("cannot count entries for nil auditing")
+	}
+	if c.Window <= 0 {
+		c.Window = defaultCounterWindow
+	}
+	if c.Resolution <= 0 {
+		c.Resolution = defaultCounterResolution
+	}
+	if c.Resolution > c.Window {
+		return nil, fmt.Errorf("counter resolution must not be greater than the window")
+	}
+	if c.Store == nil {
+		// the window start is truncated to the resolution, so one more bucket is needed
+		c.Store = NewMemoryCounterStore(c.Window + c.Resolution)
+	}
+	if c.MetricsLimit <= 0 {
+		c.MetricsLimit = defaultCounterMetricsLimit
+	}
+
+	a := &CountingAuditing{
+		Auditing:   backend,
+		store:      c.Store,
+		window:     c.Window,
+		resolution: c.Resolution,
+		now:        time.Now,
+		descs:      newCounterDescs(c.Window),
+		limit:      c.MetricsLimit,
+	}
+
+	if c.MetricsRegisterer != nil {
+		err := c.MetricsRegisterer.Register(a)
+		if err != nil {
+			return nil, fmt.Errorf("unable to register auditing counters: %w", err)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *CountingAuditing) Index(e Entry) error {
+	err := a.Auditing.Index(e)
+
+	var counts Counts
+	if isRequestEntry(e) {
+		counts.Requests = 1
+	}
+	if isErrorEntry(e) {
+		counts.Errors = 1
+	}
+
+	if counts.Requests == 0 && counts.Errors == 0 {
+		return err
+	}
+
+	bucket := a.now().Truncate(a.resolution)
+
+	for _, key := range []CounterKey{
+		{Kind: CounterKindUser, Name: e.User},
+		{Kind: CounterKindTenant, Name: e.Tenant},
+	} {
+		if key.Name == "" {
+			continue
+		}
+
+		// counting is best effort and must not fail the request
+		_ = a.store.Add(key, bucket, counts)
+	}
+
+	return err
+}
+
+func (a *CountingAuditing) Count(ctx context.Context, filter EntryFilter) (int64, error) {
+	return Count(ctx, a.Auditing, filter)
+}
+
+func (a *CountingAuditing) SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error {
+	return SearchStream(ctx, a.Auditing, filter, fn)
+}
+
+func (a *CountingAuditing) Get(ctx context.Context, id string) (*Entry, error) {
+	return Get(ctx, a.Auditing, id)
+}
+
+func (a *CountingAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	return AnonymizeUser(ctx, a.Auditing, userID, c)
+}
+
+// Counters returns the request and error rates of all users and tenants within the sliding window,
+// sorted by the amount of requests in descending order.
+func (a *CountingAuditing) Counters() ([]CounterStat, error) {
+	since := a.now().Add(-a.window).Truncate(a.resolution)
+
+	sums, err := a.store.Sum(since)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CounterStat
+	for key, counts := range sums {
+		stat := CounterStat{
+			Kind:        key.Kind,
+			Name:        key.Name,
+			Requests:    counts.Requests,
+			Errors:      counts.Errors,
+			RequestRate: float64(counts.Requests) / a.window.Seconds(),
+		}
+		if counts.Requests > 0 {
+			stat.ErrorRate = float64(counts.Errors) / float64(counts.Requests)
+		}
+
+		result = append(result, stat)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// CountersHandler returns an http handler that serves the counters as json.
+func (a *CountingAuditing) CountersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counters, err := a.Counters()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if counters == nil {
+			counters = []CounterStat{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(counters)
+	})
+}
+
+var _ prometheus.Collector = &CountingAuditing{}
+
+// Describe implements prometheus.Collector, such that the counters can be registered at a prometheus registry.
+func (a *CountingAuditing) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range a.descs {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector and reports the counters of the users and tenants with the most requests
+// within the sliding window, see CounterConfig.MetricsLimit.
+func (a *CountingAuditing) Collect(ch chan<- prometheus.Metric) {
+	counters, err := a.Counters()
+	if err != nil {
+		for _, desc := range a.descs {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+		}
+		return
+	}
+
+	reported := map[CounterKind]int{}
+	for _, s := range counters {
+		if reported[s.Kind] >= a.limit {
+			continue
+		}
+		reported[s.Kind]++
+
+		ch <- prometheus.MustNewConstMetric(a.descs[counterDescKey{kind: s.Kind, metric: "requests"}], prometheus.GaugeValue, float64(s.Requests), s.Name)
+		ch <- prometheus.MustNewConstMetric(a.descs[counterDescKey{kind: s.Kind, metric: "errors"}], prometheus.GaugeValue, float64(s.Errors), s.Name)
+	}
+}
+
+type counterDescKey struct {
+	kind   CounterKind
+	metric string
+}
+
+func newCounterDescs(window time.Duration) map[counterDescKey]*prometheus.Desc {
+	descs := map[counterDescKey]*prometheus.Desc{}
+
+	for _, kind := range []CounterKind{CounterKindUser, CounterKindTenant} {
+		descs[counterDescKey{kind: kind, metric: "requests"}] = prometheus.NewDesc(
+			fmt.Sprintf("auditing_%s_requests", kind),
+			fmt.Sprintf("number of audited requests per %s within the last %s.", kind, window),
+			[]string{string(kind)}, nil,
+		)
+		descs[counterDescKey{kind: kind, metric: "errors"}] = prometheus.NewDesc(
+			fmt.Sprintf("auditing_%s_errors", kind),
+			fmt.Sprintf("number of audited requests that failed per %s within the last %s.", kind, window),
+			[]string{string(kind)}, nil,
+		)
+	}
+
+	return descs
+}
+
+func isRequestEntry(e Entry) bool {
+	switch e.Phase {
+	case EntryPhaseRequest, EntryPhaseOpened, EntryPhaseSingle:
+		return true
+	default:
+		return false
+	}
+}
+
+func isErrorEntry(e Entry) bool {
+	if e.Phase == EntryPhaseError || e.Error != nil {
+		return true
+	}
+
+	if e.Phase != EntryPhaseResponse && e.Phase != EntryPhaseClosed && e.Phase != EntryPhaseSingle {
+		return false
+	}
+
+	switch e.Type {
+	case EntryTypeHTTP:
+		return e.StatusCode >= http.StatusBadRequest
+	case EntryTypeGRPC:
+		// non-zero grpc status codes are errors
+		return e.StatusCode != 0
+	default:
+		return false
+	}
+}
+
+type memoryCounterStore struct {
+	lock      sync.Mutex
+	buckets   map[CounterKey]map[time.Time]Counts
+	retention time.Duration
+	pruned    time.Time
+}
+
+// NewMemoryCounterStore returns a counter store that holds the counts in memory. Buckets older than the given
+// retention are discarded when counts are added, such that keys that are not counted anymore do not accumulate.
+// The retention must not be shorter than the window of the counting auditing.
+func NewMemoryCounterStore(retention time.Duration) CounterStore {
+	return &memoryCounterStore{
+		buckets:   map[CounterKey]map[time.Time]Counts{},
+		retention: retention,
+	}
+}
+
+func (m *memoryCounterStore) Add(key CounterKey, bucket time.Time, counts Counts) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	buckets, ok := m.buckets[key]
+	if !ok {
+		buckets = map[time.Time]Counts{}
+		m.buckets[key] = buckets
+	}
+
+	current := buckets[bucket]
+	current.Requests += counts.Requests
+	current.Errors += counts.Errors
+	buckets[bucket] = current
+
+	// pruning is only needed once per bucket
+	if m.retention > 0 && bucket.After(m.pruned) {
+		m.prune(bucket.Add(-m.retention))
+		m.pruned = bucket
+	}
+
+	return nil
+}
+
+func (m *memoryCounterStore) Sum(since time.Time) (map[CounterKey]Counts, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.prune(since)
+
+	result := map[CounterKey]Counts{}
+
+	for key, buckets := range m.buckets {
+		var sum Counts
+		for _, counts := range buckets {
+			sum.Requests += counts.Requests
+			sum.Errors += counts.Errors
+		}
+
+		result[key] = sum
+	}
+
+	return result, nil
+}
+
+// prune discards all buckets before the given time and the keys without buckets.
+func (m *memoryCounterStore) prune(before time.Time) {
+	for key, buckets := range m.buckets {
+		for bucket := range buckets {
+			if bucket.Before(before) {
+				delete(buckets, bucket)
+			}
+		}
+
+		if len(buckets) == 0 {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package auditing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountingAuditing(t *testing.T) {
+	backend := &memoryAuditing{}
+	reg := prometheus.NewPedanticRegistry()
+
+	a, err := NewCounting(backend, CounterConfig{
+		Window:            time.Minute,
+		Resolution:        10 * time.Second,
+		MetricsRegisterer: reg,
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	index := func(user, tenant string, statusCode int) {
+		require.NoError(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseRequest, User: user, Tenant: tenant}))
+		require.NoError(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseResponse, User: user, Tenant: tenant, StatusCode: statusCode}))
+	}
+
+	// outside of the window after moving the clock
+	index("old", "t1", http.StatusOK)
+
+	now = now.Add(2 * time.Minute)
+
+	index("alice", "t1", http.StatusOK)
+	index("alice", "t1", http.StatusUnauthorized)
+	index("alice", "t1", http.StatusOK)
+	index("bob", "t2", http.StatusForbidden)
+	require.NoError(t, a.Index(Entry{Type: EntryTypeGRPC, Phase: EntryPhaseRequest, User: "bob", Tenant: "t2"}))
+	require.NoError(t, a.Index(Entry{Type: EntryTypeGRPC, Phase: EntryPhaseResponse, User: "bob", Tenant: "t2", StatusCode: 16}))
+
+	require.Len(t, backend.entries, 12, "all entries must be passed to the backend")
+
+	got, err := a.Counters()
+	require.NoError(t, err)
+
+	want := []CounterStat{
+		{Kind: CounterKindTenant, Name: "t1", Requests: 3, Errors: 1, RequestRate: 0.05, ErrorRate: 1.0 / 3},
+		{Kind: CounterKindUser, Name: "alice", Requests: 3, Errors: 1, RequestRate: 0.05, ErrorRate: 1.0 / 3},
+		{Kind: CounterKindTenant, Name: "t2", Requests: 2, Errors: 2, RequestRate: 2.0 / 60, ErrorRate: 1},
+		{Kind: CounterKindUser, Name: "bob", Requests: 2, Errors: 2, RequestRate: 2.0 / 60, ErrorRate: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	wantMetrics := `# HELP auditing_tenant_errors number of audited requests that failed per tenant within the last 1m0s.
+# TYPE auditing_tenant_errors gauge
+auditing_tenant_errors{tenant="t1"} 1
+auditing_tenant_errors{tenant="t2"} 2
+# HELP auditing_tenant_requests number of audited requests per tenant within the last 1m0s.
+# TYPE auditing_tenant_requests gauge
+auditing_tenant_requests{tenant="t1"} 3
+auditing_tenant_requests{tenant="t2"} 2
+# HELP auditing_user_errors number of audited requests that failed per user within the last 1m0s.
+# TYPE auditing_user_errors gauge
+auditing_user_errors{user="alice"} 1
+auditing_user_errors{user="bob"} 2
+# HELP auditing_user_requests number of audited requests per user within the last 1m0s.
+# TYPE auditing_user_requests gauge
+auditing_user_requests{user="alice"} 3
+auditing_user_requests{user="bob"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(wantMetrics)))
+}
+
+func TestCountingAuditingMetricsLimit(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	a, err := NewCounting(&memoryAuditing{}, CounterConfig{
+		MetricsRegisterer: reg,
+		MetricsLimit:      1,
+	})
+	require.NoError(t, err)
+
+	for _, user := range []string{"alice", "bob", "bob", "carol"} {
+		require.NoError(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseRequest, User: user}))
+	}
+
+	counters, err := a.Counters()
+	require.NoError(t, err)
+	require.Len(t, counters, 3, "the counters are not limited")
+
+	wantMetrics := `# HELP auditing_user_requests number of audited requests per user within the last 5m0s.
+# TYPE auditing_user_requests gauge
+auditing_user_requests{user="bob"} 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(wantMetrics), "auditing_user_requests"))
+}
+
+func TestMemoryCounterStorePrunesOnAdd(t *testing.T) {
+	store := NewMemoryCounterStore(time.Minute).(*memoryCounterStore)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Add(CounterKey{Kind: CounterKindUser, Name: "old"}, start, Counts{Requests: 1}))
+	require.NoError(t, store.Add(CounterKey{Kind: CounterKindUser, Name: "alice"}, start.Add(30*time.Second), Counts{Requests: 1}))
+	require.Len(t, store.buckets, 2)
+
+	// without calling sum, keys outside of the retention must not accumulate
+	require.NoError(t, store.Add(CounterKey{Kind: CounterKindUser, Name: "bob"}, start.Add(80*time.Second), Counts{Requests: 1}))
+
+	want := map[CounterKey]map[time.Time]Counts{
+		{Kind: CounterKindUser, Name: "alice"}: {start.Add(30 * time.Second): {Requests: 1}},
+		{Kind: CounterKindUser, Name: "bob"}:   {start.Add(80 * time.Second): {Requests: 1}},
+	}
+	if diff := cmp.Diff(want, store.buckets); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
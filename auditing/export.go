@@ -0,0 +1,181 @@
+package auditing
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is the file format of exported entries.
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON writes one json encoded entry per line.
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatCSV writes a header and one row per entry, the body and labels are json encoded.
+	ExportFormatCSV ExportFormat = "csv"
+
+	// exportChunk is the time range that is searched at once, which keeps the pages of the backend small
+	// and works around the search limit of backends that cannot stream.
+	exportChunk = time.Hour
+)
+
+var exportCSVHeader = []string{
+	"id", "component", "rqid", "type", "timestamp", "user", "subject", "email", "tenant", "detail", "phase", "sequence",
+	"path", "forwarded_for", "remote_addr", "classification", "status_code", "error", "body", "labels",
+}
+
+// Export writes all entries matching the given filter to w in the given format, e.g. for archiving the audit trail
+// or handing it over for compliance. The entries are written in the order of Search, the most recent first.
+//
+// If the filter has a start time, the time range of the filter is searched in chunks of an hour, the end time defaults
+// to now. The limit of the filter restricts the total amount of exported entries, zero exports all entries. Every
+// chunk is streamed through SearchStream, such that backends without SearchStreamer return at most the default limit
+// of Search per chunk.
+//
+// Export returns the amount of written entries.
+func Export(ctx context.Context, a Auditing, filter EntryFilter, w io.Writer, format ExportFormat) (int64, error) {
+	var (
+		write func(APIEntry) error
+		cw    *csv.Writer
+	)
+
+	switch format {
+	case ExportFormatNDJSON:
+		enc := json.NewEncoder(w)
+		write = func(e APIEntry) error {
+			return enc.Encode(e)
+		}
+	case ExportFormatCSV:
+		cw = csv.NewWriter(w)
+		defer cw.Flush()
+
+		err := cw.Write(exportCSVHeader)
+		if err != nil {
+			return 0, err
+		}
+
+		write = func(e APIEntry) error {
+			row, err := csvRow(e)
+			if err != nil {
+				return err
+			}
+			return cw.Write(row)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported export format %q, must be one of: %s|%s", format, ExportFormatNDJSON, ExportFormatCSV)
+	}
+
+	var exported int64
+
+	fn := func(e Entry) error {
+		err := write(toAPIEntry(e))
+		if err != nil {
+			return fmt.Errorf("unable to write entry %q: %w", e.Id, err)
+		}
+		exported++
+		return nil
+	}
+
+	for _, chunk := range exportChunks(filter, time.Now()) {
+		if filter.Limit > 0 {
+			chunk.Limit = filter.Limit - exported
+			if chunk.Limit <= 0 {
+				break
+			}
+		}
+
+		err := SearchStream(ctx, a, chunk, fn)
+		if err != nil {
+			return exported, err
+		}
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return exported, err
+		}
+	}
+
+	return exported, nil
+}
+
+// exportChunks splits the time range of the filter into chunks, the most recent chunk first. The bounds of the time
+// range are inclusive and of second precision, so consecutive chunks are a second apart.
+func exportChunks(filter EntryFilter, now time.Time) []EntryFilter {
+	if filter.From.IsZero() {
+		return []EntryFilter{filter}
+	}
+
+	to := filter.To
+	if to.IsZero() {
+		to = now
+	}
+	from := filter.From.Truncate(time.Second)
+	to = to.Truncate(time.Second)
+
+	var chunks []EntryFilter
+	for !to.Before(from) {
+		chunkFrom := to.Add(-exportChunk + time.Second)
+		if chunkFrom.Before(from) {
+			chunkFrom = from
+		}
+
+		chunk := filter
+		chunk.From = chunkFrom
+		chunk.To = to
+		chunks = append(chunks, chunk)
+
+		to = chunkFrom.Add(-time.Second)
+	}
+
+	return chunks
+}
+
+func csvRow(e APIEntry) ([]string, error) {
+	body := ""
+	if e.Body != nil {
+		raw, err := json.Marshal(e.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(raw)
+	}
+
+	labels := ""
+	if len(e.Labels) > 0 {
+		raw, err := json.Marshal(e.Labels)
+		if err != nil {
+			return nil, err
+		}
+		labels = string(raw)
+	}
+
+	return []string{
+		e.Id,
+		e.Component,
+		e.RequestId,
+		string(e.Type),
+		e.Timestamp.Format(time.RFC3339Nano),
+		e.User,
+		e.Subject,
+		e.EMail,
+		e.Tenant,
+		string(e.Detail),
+		string(e.Phase),
+		strconv.FormatUint(e.Sequence, 10),
+		e.Path,
+		e.ForwardedFor,
+		e.RemoteAddr,
+		string(e.Classification),
+		strconv.Itoa(e.StatusCode),
+		e.Error,
+		body,
+		labels,
+	}, nil
+}
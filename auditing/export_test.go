@@ -0,0 +1,106 @@
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeAuditing searches its entries by the time range of the filter like a real backend.
+type rangeAuditing struct {
+	memoryAuditing
+	searches []EntryFilter
+}
+
+func (r *rangeAuditing) Search(filter EntryFilter) ([]Entry, error) {
+	r.searches = append(r.searches, filter)
+
+	var res []Entry
+	for _, e := range r.entries {
+		if !filter.From.IsZero() && e.Timestamp.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && e.Timestamp.After(filter.To) {
+			continue
+		}
+		res = append(res, e)
+	}
+
+	slices.SortFunc(res, func(a, b Entry) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+
+	if filter.Limit > 0 && int64(len(res)) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+
+	return res, nil
+}
+
+func TestExport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	a := &rangeAuditing{}
+	a.entries = []Entry{
+		{Id: "1", Component: "metal-api", RequestId: "rq1", Timestamp: start.Add(10 * time.Minute), User: "alice", Body: map[string]any{"name": "m1"}},
+		{Id: "2", Component: "metal-api", RequestId: "rq1", Timestamp: start.Add(70 * time.Minute), Phase: EntryPhaseResponse, Sequence: 1, StatusCode: 200, Labels: map[string]string{"pod": "a"}},
+		{Id: "3", Component: "metal-api", RequestId: "rq2", Timestamp: start.Add(150 * time.Minute), Body: "a, \"quoted\" body"},
+	}
+
+	filter := EntryFilter{From: start, To: start.Add(3 * time.Hour)}
+
+	var ndjson bytes.Buffer
+	n, err := Export(context.Background(), a, filter, &ndjson, ExportFormatNDJSON)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+
+	wantNDJSON := `{"id":"3","component":"metal-api","rqid":"rq2","type":"","timestamp":"2024-01-01T12:30:00Z","user":"","tenant":"","detail":"","phase":"","sequence":0,"path":"","forwarded_for":"","remote_addr":"","body":"a, \"quoted\" body","status_code":0}
+{"id":"2","component":"metal-api","rqid":"rq1","type":"","timestamp":"2024-01-01T11:10:00Z","user":"","tenant":"","detail":"","phase":"response","sequence":1,"path":"","forwarded_for":"","remote_addr":"","body":null,"status_code":200,"labels":{"pod":"a"}}
+{"id":"1","component":"metal-api","rqid":"rq1","type":"","timestamp":"2024-01-01T10:10:00Z","user":"alice","tenant":"","detail":"","phase":"","sequence":0,"path":"","forwarded_for":"","remote_addr":"","body":{"name":"m1"},"status_code":0}
+`
+	if diff := cmp.Diff(wantNDJSON, ndjson.String()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	// the time range is searched in chunks of an hour, the start time of the filter is inclusive and forms the last chunk
+	require.Len(t, a.searches, 4)
+
+	var csv bytes.Buffer
+	filter.Limit = 2
+	n, err = Export(context.Background(), a, filter, &csv, ExportFormatCSV)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	wantCSV := `id,component,rqid,type,timestamp,user,subject,email,tenant,detail,phase,sequence,path,forwarded_for,remote_addr,classification,status_code,error,body,labels
+3,metal-api,rq2,,2024-01-01T12:30:00Z,,,,,,,0,,,,,0,,"""a, \""quoted\"" body""",
+2,metal-api,rq1,,2024-01-01T11:10:00Z,,,,,,response,1,,,,,200,,,"{""pod"":""a""}"
+`
+	if diff := cmp.Diff(wantCSV, csv.String()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	_, err = Export(context.Background(), a, filter, &csv, "xml")
+	require.EqualError(t, err, `unsupported export format "xml", must be one of: ndjson|csv`)
+}
+
+func TestExportChunks(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	got := exportChunks(EntryFilter{User: "alice", From: now.Add(-90 * time.Minute)}, now)
+
+	want := []EntryFilter{
+		{User: "alice", From: now.Add(-time.Hour + time.Second), To: now},
+		{User: "alice", From: now.Add(-90 * time.Minute), To: now.Add(-time.Hour)},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	got = exportChunks(EntryFilter{User: "alice"}, now)
+	require.Equal(t, []EntryFilter{{User: "alice"}}, got, "without start time the range is not chunked")
+}
@@ -0,0 +1,519 @@
+package auditing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// IntegrityChainLabel is the label that carries the chain of an entry, such that the last entry of a chain can be searched.
+	IntegrityChainLabel = "audit-chain"
+
+	IntegrityAlgorithmHMACSHA256 = "hmac-sha256"
+	IntegrityAlgorithmEd25519    = "ed25519"
+
+	// integrityLookbehind is the amount of recent entries of a chain that are searched for the end of the chain,
+	// entries with equal timestamps are not ordered by their position.
+	integrityLookbehind = 100
+)
+
+// EntryIntegrity protects an entry against modifications. The hash of an entry covers all fields except the labels
+// along with the position and the hash of the previous entry of the chain, the hash is signed.
+type EntryIntegrity struct {
+	// Chain identifies the hash chain of the entry, defaults to the component.
+	Chain string `json:"chain"`
+	// Position of the entry in the chain, starting at zero.
+	Position uint64 `json:"position"`
+	// PrevHash is the hash of the previous entry of the chain, empty for the first entry.
+	PrevHash string `json:"prev_hash,omitempty"`
+	// Hash is the hex encoded sha256 hash of the entry.
+	Hash string `json:"hash"`
+	// Algorithm of the signature, e.g. hmac-sha256 or ed25519.
+	Algorithm string `json:"algorithm"`
+	// Signature is the base64 encoded signature of the hash.
+	Signature string `json:"signature"`
+}
+
+// Signer signs the hashes of entries.
+type Signer interface {
+	// Algorithm returns the name of the signature algorithm.
+	Algorithm() string
+	Sign(hash []byte) ([]byte, error)
+}
+
+// Verifier verifies the signatures of entries.
+type Verifier interface {
+	// Algorithm returns the name of the signature algorithm.
+	Algorithm() string
+	Verify(hash, signature []byte) error
+}
+
+type hmacSigner struct {
+	key []byte
+}
+
+// NewHMACSigner returns a signer and verifier, which signs entries with an hmac-sha256 of the given secret key.
+func NewHMACSigner(key []byte) interface {
+	Signer
+	Verifier
+} {
+	return &hmacSigner{key: key}
+}
+
+func (s *hmacSigner) Algorithm() string {
+	return IntegrityAlgorithmHMACSHA256
+}
+
+func (s *hmacSigner) Sign(hash []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	_, _ = mac.Write(hash)
+	return mac.Sum(nil), nil
+}
+
+func (s *hmacSigner) Verify(hash, signature []byte) error {
+	want, _ := s.Sign(hash)
+	if !hmac.Equal(want, signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+type ed25519Signer struct {
+	private ed25519.PrivateKey
+	ed25519Verifier
+}
+
+type ed25519Verifier struct {
+	public ed25519.PublicKey
+}
+
+// NewEd25519Signer returns a signer and verifier, which signs entries with the given ed25519 private key.
+func NewEd25519Signer(key ed25519.PrivateKey) interface {
+	Signer
+	Verifier
+} {
+	return &ed25519Signer{
+		private:         key,
+		ed25519Verifier: ed25519Verifier{public: key.Public().(ed25519.PublicKey)},
+	}
+}
+
+// NewEd25519Verifier returns a verifier of signatures created with the private key of the given public key,
+// such that entries can be verified without being able to sign them.
+func NewEd25519Verifier(key ed25519.PublicKey) Verifier {
+	return &ed25519Verifier{public: key}
+}
+
+func (s *ed25519Signer) Sign(hash []byte) ([]byte, error) {
+	return ed25519.Sign(s.private, hash), nil
+}
+
+func (v *ed25519Verifier) Algorithm() string {
+	return IntegrityAlgorithmEd25519
+}
+
+func (v *ed25519Verifier) Verify(hash, signature []byte) error {
+	if !ed25519.Verify(v.public, hash, signature) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// IntegrityConfig configures the integrity protection of entries.
+type IntegrityConfig struct {
+	// Signer signs the hash of every entry.
+	Signer Signer
+	// Chain identifies the hash chain of the entries, defaults to the component. Replicas of a component that index
+	// concurrently must use distinct chains, e.g. the name of the pod.
+	Chain string
+}
+
+type integrityAuditing struct {
+	backend   Auditing
+	signer    Signer
+	chain     string
+	component string
+
+	lock   sync.Mutex
+	loaded bool
+	last   *EntryIntegrity
+	newID  func() string
+	now    func() time.Time
+}
+
+// NewWithIntegrity wraps the given auditing backend and protects every indexed entry with a signed hash, which is chained
+// to the hash of the previous entry, such that modified, deleted or injected entries are detected by Verify.
+// Entries without a component are indexed with the given default component.
+//
+// The chain continues at the most recent entry of the chain in the backend. The labels of the entries are not protected,
+// entries whose personal data was removed with AnonymizeUser fail the verification.
+func NewWithIntegrity(backend Auditing, component string, c IntegrityConfig) (Auditing, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("cannot protect nil auditing")
+	}
+	if c.Signer == nil {
+		return nil, fmt.Errorf("signer must be specified")
+	}
+
+	chain := c.Chain
+	if chain == "" {
+		chain = component
+	}
+	if chain == "" {
+		return nil, fmt.Errorf("either chain or component must be specified")
+	}
+
+	return &integrityAuditing{
+		backend:   backend,
+		signer:    c.Signer,
+		chain:     chain,
+		component: component,
+		newID:     uuid.NewString,
+		now:       time.Now,
+	}, nil
+}
+
+func (a *integrityAuditing) Flush() error {
+	return a.backend.Flush()
+}
+
+func (a *integrityAuditing) Index(e Entry) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	err := a.loadLast()
+	if err != nil {
+		return fmt.Errorf("unable to determine the end of chain %q: %w", a.chain, err)
+	}
+
+	// the fields are filled here instead of the backend, such that the hash covers them
+	if e.Id == "" {
+		e.Id = a.newID()
+	}
+	if e.Component == "" {
+		e.Component = a.component
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = a.now()
+	}
+	labels := maps.Clone(e.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[IntegrityChainLabel] = a.chain
+	e.Labels = labels
+
+	integrity := &EntryIntegrity{
+		Chain:     a.chain,
+		Algorithm: a.signer.Algorithm(),
+	}
+	if a.last != nil {
+		integrity.Position = a.last.Position + 1
+		integrity.PrevHash = a.last.Hash
+	}
+
+	hash, err := entryHash(e, integrity)
+	if err != nil {
+		return err
+	}
+	signature, err := a.signer.Sign(hash)
+	if err != nil {
+		return fmt.Errorf("unable to sign entry: %w", err)
+	}
+
+	integrity.Hash = hex.EncodeToString(hash)
+	integrity.Signature = base64.StdEncoding.EncodeToString(signature)
+	e.Integrity = integrity
+
+	err = a.backend.Index(e)
+	if err != nil {
+		return err
+	}
+
+	a.last = integrity
+
+	return nil
+}
+
+// loadLast searches the most recent entry of the chain once, such that the chain continues after a restart.
+func (a *integrityAuditing) loadLast() error {
+	if a.loaded {
+		return nil
+	}
+
+	entries, err := a.backend.Search(EntryFilter{
+		Limit:  integrityLookbehind,
+		Labels: map[string]string{IntegrityChainLabel: a.chain},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.Integrity == nil || e.Integrity.Chain != a.chain {
+			continue
+		}
+		if a.last == nil || e.Integrity.Position > a.last.Position {
+			a.last = e.Integrity
+		}
+	}
+
+	a.loaded = true
+
+	return nil
+}
+
+func (a *integrityAuditing) Search(filter EntryFilter) ([]Entry, error) {
+	return a.backend.Search(filter)
+}
+
+func (a *integrityAuditing) Count(ctx context.Context, filter EntryFilter) (int64, error) {
+	return Count(ctx, a.backend, filter)
+}
+
+func (a *integrityAuditing) SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error {
+	return SearchStream(ctx, a.backend, filter, fn)
+}
+
+func (a *integrityAuditing) Get(ctx context.Context, id string) (*Entry, error) {
+	return Get(ctx, a.backend, id)
+}
+
+func (a *integrityAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	return AnonymizeUser(ctx, a.backend, userID, c)
+}
+
+// integrityPayload contains the protected fields of an entry in a representation that survives the round trip through
+// a backend, which e.g. stores timestamps with second precision.
+type integrityPayload struct {
+	Chain          string         `json:"chain"`
+	Position       uint64         `json:"position"`
+	PrevHash       string         `json:"prev_hash"`
+	Id             string         `json:"id"`
+	Component      string         `json:"component"`
+	RequestId      string         `json:"rqid"`
+	Type           EntryType      `json:"type"`
+	Timestamp      int64          `json:"timestamp"`
+	User           string         `json:"user"`
+	Subject        string         `json:"subject"`
+	EMail          string         `json:"email"`
+	Tenant         string         `json:"tenant"`
+	Detail         EntryDetail    `json:"detail"`
+	Phase          EntryPhase     `json:"phase"`
+	Sequence       uint64         `json:"sequence"`
+	Path           string         `json:"path"`
+	ForwardedFor   string         `json:"forwarded_for"`
+	RemoteAddr     string         `json:"remote_addr"`
+	Classification Classification `json:"classification"`
+	Body           any            `json:"body"`
+	StatusCode     int            `json:"status_code"`
+	Error          string         `json:"error"`
+}
+
+// entryHash returns the sha256 hash of the protected fields of the entry and its position in the chain.
+func entryHash(e Entry, integrity *EntryIntegrity) ([]byte, error) {
+	// bodies are stored as json, so structs and maps of the same content must result in the same hash
+	var body any
+	if e.Body != nil {
+		raw, err := json.Marshal(e.Body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to hash body: %w", err)
+		}
+		err = json.Unmarshal(raw, &body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to hash body: %w", err)
+		}
+	}
+
+	payload := integrityPayload{
+		Chain:          integrity.Chain,
+		Position:       integrity.Position,
+		PrevHash:       integrity.PrevHash,
+		Id:             e.Id,
+		Component:      e.Component,
+		RequestId:      e.RequestId,
+		Type:           e.Type,
+		Timestamp:      e.Timestamp.Unix(),
+		User:           e.User,
+		Subject:        e.Subject,
+		EMail:          e.EMail,
+		Tenant:         e.Tenant,
+		Detail:         e.Detail,
+		Phase:          e.Phase,
+		Sequence:       e.Sequence,
+		Path:           e.Path,
+		ForwardedFor:   e.ForwardedFor,
+		RemoteAddr:     e.RemoteAddr,
+		Classification: e.Classification,
+		Body:           body,
+		StatusCode:     e.StatusCode,
+	}
+	if e.Error != nil {
+		payload.Error = e.Error.Error()
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return sum[:], nil
+}
+
+// IntegrityIssueKind describes why the integrity of an entry is violated.
+type IntegrityIssueKind string
+
+const (
+	// IntegrityIssueUnsigned is an entry without integrity protection, e.g. indexed before the protection was enabled.
+	IntegrityIssueUnsigned IntegrityIssueKind = "unsigned"
+	// IntegrityIssueModified is an entry whose content does not match its hash.
+	IntegrityIssueModified IntegrityIssueKind = "modified"
+	// IntegrityIssueSignature is an entry whose signature is invalid.
+	IntegrityIssueSignature IntegrityIssueKind = "invalid-signature"
+	// IntegrityIssueGap is a missing entry of a chain.
+	IntegrityIssueGap IntegrityIssueKind = "gap"
+	// IntegrityIssueBrokenLink is an entry that does not refer to the hash of its predecessor.
+	IntegrityIssueBrokenLink IntegrityIssueKind = "broken-link"
+)
+
+// IntegrityIssue is a violation of the integrity of the audit trail.
+type IntegrityIssue struct {
+	Kind IntegrityIssueKind `json:"kind"`
+	// EntryID is the id of the affected entry, empty for gaps.
+	EntryID  string `json:"entry_id,omitempty"`
+	Chain    string `json:"chain,omitempty"`
+	Position uint64 `json:"position"`
+	Message  string `json:"message"`
+}
+
+// VerifyResult is the result of the verification of the audit trail.
+type VerifyResult struct {
+	// Verified is the amount of entries that were checked.
+	Verified int64            `json:"verified"`
+	Issues   []IntegrityIssue `json:"issues"`
+}
+
+// Valid returns true if no integrity issues were found.
+func (r *VerifyResult) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Verify checks the integrity of all entries matching the given filter, see NewWithIntegrity. Every entry is checked
+// against its hash and signature and the entries of every chain must refer to their predecessor without gaps.
+// Chains are only checked between their first and last entry that match the filter, so a filter on a time range
+// does not result in gaps at its bounds, whereas filters on other fields than the component do.
+func Verify(ctx context.Context, a Auditing, filter EntryFilter, verifier Verifier) (*VerifyResult, error) {
+	if verifier == nil {
+		return nil, fmt.Errorf("verifier must be specified")
+	}
+
+	var (
+		result = &VerifyResult{}
+		chains = map[string]map[uint64]Entry{}
+	)
+
+	err := SearchStream(ctx, a, filter, func(e Entry) error {
+		result.Verified++
+
+		if e.Integrity == nil {
+			result.Issues = append(result.Issues, IntegrityIssue{Kind: IntegrityIssueUnsigned, EntryID: e.Id, Message: "entry is not signed"})
+			return nil
+		}
+
+		if issue := verifyEntry(e, verifier); issue != nil {
+			result.Issues = append(result.Issues, *issue)
+		}
+
+		chain, ok := chains[e.Integrity.Chain]
+		if !ok {
+			chain = map[uint64]Entry{}
+			chains[e.Integrity.Chain] = chain
+		}
+		chain[e.Integrity.Position] = e
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(chains)) {
+		chain := chains[name]
+		positions := slices.Sorted(maps.Keys(chain))
+
+		for i := 1; i < len(positions); i++ {
+			prev, current := chain[positions[i-1]], chain[positions[i]]
+
+			if positions[i] != positions[i-1]+1 {
+				result.Issues = append(result.Issues, IntegrityIssue{
+					Kind:     IntegrityIssueGap,
+					Chain:    name,
+					Position: positions[i-1] + 1,
+					Message:  fmt.Sprintf("entries %d to %d are missing", positions[i-1]+1, positions[i]-1),
+				})
+				continue
+			}
+
+			if current.Integrity.PrevHash != prev.Integrity.Hash {
+				result.Issues = append(result.Issues, IntegrityIssue{
+					Kind:     IntegrityIssueBrokenLink,
+					EntryID:  current.Id,
+					Chain:    name,
+					Position: positions[i],
+					Message:  fmt.Sprintf("entry does not refer to the hash of entry %q", prev.Id),
+				})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func verifyEntry(e Entry, verifier Verifier) *IntegrityIssue {
+	issue := func(kind IntegrityIssueKind, format string, args ...any) *IntegrityIssue {
+		return &IntegrityIssue{
+			Kind:     kind,
+			EntryID:  e.Id,
+			Chain:    e.Integrity.Chain,
+			Position: e.Integrity.Position,
+			Message:  fmt.Sprintf(format, args...),
+		}
+	}
+
+	hash, err := entryHash(e, e.Integrity)
+	if err != nil {
+		return issue(IntegrityIssueModified, "unable to hash entry: %s", err)
+	}
+	if hex.EncodeToString(hash) != e.Integrity.Hash {
+		return issue(IntegrityIssueModified, "entry does not match its hash")
+	}
+
+	if e.Integrity.Algorithm != verifier.Algorithm() {
+		return issue(IntegrityIssueSignature, "entry is signed with %q, verifier uses %q", e.Integrity.Algorithm, verifier.Algorithm())
+	}
+	signature, err := base64.StdEncoding.DecodeString(e.Integrity.Signature)
+	if err != nil {
+		return issue(IntegrityIssueSignature, "unable to decode signature: %s", err)
+	}
+	err = verifier.Verify(hash, signature)
+	if err != nil {
+		return issue(IntegrityIssueSignature, "%s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package auditing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrity(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		backend = &memoryAuditing{}
+		signer  = NewHMACSigner([]byte("secret"))
+		ids     = 0
+	)
+
+	newIntegrity := func() *integrityAuditing {
+		a, err := NewWithIntegrity(backend, "metal-api", IntegrityConfig{Signer: signer})
+		require.NoError(t, err)
+
+		ia := a.(*integrityAuditing)
+		ia.newID = func() string {
+			ids++
+			return fmt.Sprintf("%d", ids)
+		}
+		return ia
+	}
+
+	a := newIntegrity()
+	for i := range 3 {
+		require.NoError(t, a.Index(Entry{RequestId: fmt.Sprintf("rq%d", i), User: "alice", Body: map[string]any{"i": i}}))
+	}
+
+	// the chain continues after a restart
+	a = newIntegrity()
+	require.NoError(t, a.Index(Entry{RequestId: "rq3"}))
+
+	require.Len(t, backend.entries, 4)
+	for i, e := range backend.entries {
+		require.Equal(t, uint64(i), e.Integrity.Position)
+		require.Equal(t, "metal-api", e.Integrity.Chain)
+		require.Equal(t, "metal-api", e.Labels[IntegrityChainLabel])
+		if i > 0 {
+			require.Equal(t, backend.entries[i-1].Integrity.Hash, e.Integrity.PrevHash)
+		}
+	}
+
+	result, err := Verify(ctx, backend, EntryFilter{}, signer)
+	require.NoError(t, err)
+	require.True(t, result.Valid(), "issues: %v", result.Issues)
+	require.Equal(t, int64(4), result.Verified)
+
+	tests := []struct {
+		name   string
+		tamper func(entries []Entry) []Entry
+		want   []IntegrityIssue
+	}{
+		{
+			name: "invalid signature",
+			tamper: func(entries []Entry) []Entry {
+				i := *entries[0].Integrity
+				i.Signature = "c2lnbmF0dXJl"
+				entries[0].Integrity = &i
+				return entries
+			},
+			want: []IntegrityIssue{{Kind: IntegrityIssueSignature, EntryID: "1", Chain: "metal-api", Message: "signature mismatch"}},
+		},
+		{
+			name: "modified",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].User = "mallory"
+				return entries
+			},
+			want: []IntegrityIssue{{Kind: IntegrityIssueModified, EntryID: "2", Chain: "metal-api", Position: 1, Message: "entry does not match its hash"}},
+		},
+		{
+			name: "deleted",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			want: []IntegrityIssue{{Kind: IntegrityIssueGap, Chain: "metal-api", Position: 1, Message: "entries 1 to 1 are missing"}},
+		},
+		{
+			name: "unsigned",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries, Entry{Id: "injected"})
+			},
+			want: []IntegrityIssue{{Kind: IntegrityIssueUnsigned, EntryID: "injected", Message: "entry is not signed"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			entries := tt.tamper(append([]Entry{}, backend.entries...))
+
+			result, err := Verify(ctx, &memoryAuditing{entries: entries}, EntryFilter{}, signer)
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, result.Issues); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestIntegrityEd25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	backend := &memoryAuditing{}
+	a, err := NewWithIntegrity(backend, "metal-api", IntegrityConfig{Signer: NewEd25519Signer(private), Chain: "metal-api-0"})
+	require.NoError(t, err)
+	require.NoError(t, a.Index(Entry{RequestId: "rq1"}))
+
+	result, err := Verify(context.Background(), backend, EntryFilter{}, NewEd25519Verifier(public))
+	require.NoError(t, err)
+	require.True(t, result.Valid(), "issues: %v", result.Issues)
+
+	result, err = Verify(context.Background(), backend, EntryFilter{}, NewHMACSigner([]byte("secret")))
+	require.NoError(t, err)
+	require.Len(t, result.Issues, 1)
+	require.Equal(t, `entry is signed with "ed25519", verifier uses "hmac-sha256"`, result.Issues[0].Message)
+}
+
+func TestIntegrityMeilisearchRoundTrip(t *testing.T) {
+	type body struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+
+	backend := &memoryAuditing{}
+	signer := NewHMACSigner([]byte("secret"))
+	a, err := NewWithIntegrity(backend, "metal-api", IntegrityConfig{Signer: signer})
+	require.NoError(t, err)
+
+	require.NoError(t, a.Index(Entry{
+		Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC),
+		Phase:     EntryPhaseResponse,
+		Sequence:  1,
+		Body:      body{Name: "m1", Size: 3},
+		Error:     fmt.Errorf("failed"),
+	}))
+
+	m := &meiliAuditing{}
+	raw, err := json.Marshal(m.encodeEntry(backend.entries[0]))
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	result, err := Verify(context.Background(), &memoryAuditing{entries: []Entry{m.decodeEntry(doc)}}, EntryFilter{}, signer)
+	require.NoError(t, err)
+	require.True(t, result.Valid(), "issues: %v", result.Issues)
+}
@@ -5,8 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"maps"
+	"net/http"
 	"regexp"
 	"slices"
 	"strings"
@@ -19,6 +19,7 @@ import (
 
 type meiliAuditing struct {
 	component        string
+	labels           map[string]string
 	client           *meilisearch.Client
 	log              *slog.Logger
 	indexPrefix      string
@@ -37,16 +38,20 @@ const (
 	meiliIndexNameTimeSuffixSchema = "\\d\\d\\d\\d-\\d\\d(-\\d\\d(_\\d\\d)?)?"
 	meiliIndexCreationWaitTimeout  = 30 * time.Second
 	meiliIndexCreationWaitInterval = 100 * time.Millisecond
+	// meiliMaxTotalHits is the maximum amount of entries that can be paged through or counted per index,
+	// meilisearch defaults to 1000
+	meiliMaxTotalHits   = 1000000
+	meiliStreamPageSize = 1000
 )
 
+var meiliLabelKeyRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`)
+
 func New(c Config) (Auditing, error) {
-	if c.Component == "" {
-		ex, err := os.Executable()
-		if err != nil {
-			return nil, err
-		}
-		c.Component = filepath.Base(ex)
+	component, labels, err := defaultComponentEnv().detectComponent(c)
+	if err != nil {
+		return nil, err
 	}
+	c.Component = component
 
 	client := meilisearch.NewClient(meilisearch.ClientConfig{
 		Host:   c.URL,
@@ -60,13 +65,33 @@ func New(c Config) (Auditing, error) {
 
 	a := &meiliAuditing{
 		component:        c.Component,
+		labels:           labels,
 		client:           client,
 		log:              c.Log.WithGroup("auditing"),
 		indexPrefix:      c.IndexPrefix,
 		rotationInterval: c.RotationInterval,
 		keep:             c.Keep,
 	}
-	return a, nil
+
+	var res Auditing = a
+
+	if c.Integrity != nil {
+		res, err = NewWithIntegrity(res, c.Component, *c.Integrity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.MetricsRegisterer != nil {
+		metrics, err := NewMetrics(c.MetricsRegisterer)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewWithMetrics(res, metrics, c.Component)
+	}
+
+	return res, nil
 }
 
 func (a *meiliAuditing) Flush() error {
@@ -108,6 +133,7 @@ func (a *meiliAuditing) Index(entry Entry) error {
 	if entry.Timestamp.IsZero() {
 		entry.Timestamp = time.Now()
 	}
+	entry.Labels = mergeLabels(a.labels, entry.Labels)
 
 	doc := a.encodeEntry(entry)
 	documents := []map[string]any{doc}
@@ -115,73 +141,297 @@ func (a *meiliAuditing) Index(entry Entry) error {
 	task, err := index.AddDocuments(documents, "id")
 	if err != nil {
 		a.log.Error("index", "error", err)
-		return err
+		return classifyIndexError(err)
 	}
 	a.log.Debug("index", "task", task.TaskUID, "index", index.UID)
 	return nil
 }
 
+// classifyIndexError marks the errors of meilisearch rejecting an entry as permanent, such that the entry is not
+// retried, see Permanent.
+func classifyIndexError(err error) error {
+	var meiliError *meilisearch.Error
+	if !errors.As(err, &meiliError) {
+		return err
+	}
+
+	if meiliError.ErrCode == meilisearch.ErrCodeMarshalRequest {
+		// the entry cannot be encoded
+		return Permanent(err)
+	}
+
+	switch meiliError.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		// a wrong api key or an overloaded meilisearch is resolved without changing the entry
+		return err
+	}
+
+	if meiliError.StatusCode >= 400 && meiliError.StatusCode < 500 {
+		return Permanent(err)
+	}
+
+	return err
+}
+
 func (a *meiliAuditing) Search(filter EntryFilter) ([]Entry, error) {
-	predicates := make([]string, 0)
-	if filter.Component != "" {
-		predicates = append(predicates, fmt.Sprintf("component = %q", filter.Component))
+	if filter.Limit == 0 {
+		filter.Limit = EntryFilterDefaultLimit
 	}
-	if filter.Type != "" {
-		predicates = append(predicates, fmt.Sprintf("type = %q", filter.Type))
+
+	queries, err := a.searchQueries(filter)
+	if err != nil {
+		return nil, err
 	}
-	if filter.User != "" {
-		predicates = append(predicates, fmt.Sprintf("user = %q", filter.User))
+	if len(queries) == 0 {
+		return nil, nil
 	}
-	if filter.Tenant != "" {
-		predicates = append(predicates, fmt.Sprintf("tenant = %q", filter.Tenant))
+
+	for _, q := range queries {
+		q.Limit = filter.Limit
 	}
-	if filter.RequestId != "" {
-		predicates = append(predicates, fmt.Sprintf("rqid = %q", filter.RequestId))
+
+	resp, err := a.client.MultiSearch(&meilisearch.MultiSearchRequest{Queries: queries})
+	if err != nil {
+		return nil, err
 	}
-	if filter.Detail != "" {
-		predicates = append(predicates, fmt.Sprintf("detail = %q", filter.Detail))
+	entries := make([]Entry, 0)
+	for _, r := range resp.Results {
+		for _, h := range r.Hits {
+			h, ok := h.(map[string]any)
+			if !ok {
+				continue
+			}
+			entries = append(entries, a.decodeEntry(h))
+		}
 	}
-	if filter.Phase != "" {
-		predicates = append(predicates, fmt.Sprintf("phase = %q", filter.Phase))
+	return entries, nil
+}
+
+func (a *meiliAuditing) Count(ctx context.Context, filter EntryFilter) (int64, error) {
+	queries, err := a.searchQueries(filter)
+	if err != nil {
+		return 0, err
 	}
-	if filter.Path != "" {
-		predicates = append(predicates, fmt.Sprintf("path = %q", filter.Path))
+	if len(queries) == 0 {
+		return 0, nil
 	}
-	if filter.ForwardedFor != "" {
-		predicates = append(predicates, fmt.Sprintf("forwarded-for = %q", filter.ForwardedFor))
+
+	for _, q := range queries {
+		// the page mode returns the exhaustive number of hits instead of an estimation
+		q.Page = 1
+		q.HitsPerPage = 1
+		q.AttributesToRetrieve = []string{"id"}
 	}
-	if filter.RemoteAddr != "" {
-		predicates = append(predicates, fmt.Sprintf("remote-addr = %q", filter.RemoteAddr))
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
-	if filter.StatusCode != 0 {
-		predicates = append(predicates, fmt.Sprintf("status-code = %d", filter.StatusCode))
+
+	resp, err := a.client.MultiSearch(&meilisearch.MultiSearchRequest{Queries: queries})
+	if err != nil {
+		return 0, err
 	}
-	if filter.Error != "" {
-		predicates = append(predicates, fmt.Sprintf("error = %q", filter.Error))
+
+	var count int64
+	for _, r := range resp.Results {
+		count += r.TotalHits
 	}
 
-	if !filter.From.IsZero() {
-		predicates = append(predicates, fmt.Sprintf("timestamp-unix >= %d", filter.From.Unix()))
+	return count, nil
+}
+
+func (a *meiliAuditing) SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error {
+	queries, err := a.searchQueries(filter)
+	if err != nil {
+		return err
 	}
-	if !filter.To.IsZero() {
-		predicates = append(predicates, fmt.Sprintf("timestamp-unix <= %d", filter.To.Unix()))
+
+	// the index names end with their creation time, so the newest entries are streamed first
+	slices.SortFunc(queries, func(x, y *meilisearch.SearchRequest) int {
+		return strings.Compare(y.IndexUID, x.IndexUID)
+	})
+
+	streamed := int64(0)
+	for _, q := range queries {
+		for offset := int64(0); ; offset += meiliStreamPageSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			q.Offset = offset
+			q.Limit = meiliStreamPageSize
+			if filter.Limit > 0 {
+				q.Limit = min(q.Limit, filter.Limit-streamed)
+			}
+
+			resp, err := a.client.MultiSearch(&meilisearch.MultiSearchRequest{Queries: []*meilisearch.SearchRequest{q}})
+			if err != nil {
+				return err
+			}
+
+			hits := 0
+			for _, r := range resp.Results {
+				hits += len(r.Hits)
+
+				for _, h := range r.Hits {
+					h, ok := h.(map[string]any)
+					if !ok {
+						continue
+					}
+
+					err = fn(a.decodeEntry(h))
+					if err != nil {
+						return err
+					}
+
+					streamed++
+					if filter.Limit > 0 && streamed >= filter.Limit {
+						return nil
+					}
+				}
+			}
+
+			if int64(hits) < q.Limit {
+				break
+			}
+		}
 	}
 
-	if filter.Limit == 0 {
-		filter.Limit = EntryFilterDefaultLimit
+	return nil
+}
+
+func (a *meiliAuditing) Get(ctx context.Context, id string) (*Entry, error) {
+	queries, err := a.searchQueries(EntryFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range queries {
+		q.Filter = fmt.Sprintf("id = %q", id)
+		q.Query = ""
+		q.Sort = nil
+		q.Limit = 1
+	}
+
+	if len(queries) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := a.client.MultiSearch(&meilisearch.MultiSearchRequest{Queries: queries})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range resp.Results {
+			for _, h := range r.Hits {
+				h, ok := h.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				entry := a.decodeEntry(h)
+				return &entry, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, id)
+}
+
+func (a *meiliAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	queries, err := a.searchQueries(EntryFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AnonymizeResult{
+		Pseudonym: Pseudonym(c.Salt, userID),
+		Indexes:   map[string]int64{},
+		DryRun:    c.DryRun,
+	}
+
+	for _, q := range queries {
+		q.Filter = fmt.Sprintf("user = %q OR subject = %q OR email = %q", userID, userID, userID)
+		q.Query = ""
+		q.AttributesToRetrieve = []string{"id", "user", "subject", "email", "forwarded-for", "remote-addr"}
+
+		// all documents are collected before updating, otherwise the updated documents shift the pages
+		var docs []map[string]any
+		for offset := int64(0); ; offset += meiliStreamPageSize {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			q.Offset = offset
+			q.Limit = meiliStreamPageSize
+
+			resp, err := a.client.MultiSearch(&meilisearch.MultiSearchRequest{Queries: []*meilisearch.SearchRequest{q}})
+			if err != nil {
+				return nil, err
+			}
+
+			hits := 0
+			for _, r := range resp.Results {
+				hits += len(r.Hits)
+
+				for _, h := range r.Hits {
+					h, ok := h.(map[string]any)
+					if !ok {
+						continue
+					}
+					docs = append(docs, anonymizeDocument(h, c.Salt))
+				}
+			}
+
+			if int64(hits) < q.Limit {
+				break
+			}
+		}
+
+		if len(docs) == 0 {
+			continue
+		}
+
+		result.Indexes[q.IndexUID] = int64(len(docs))
+		result.Total += int64(len(docs))
+
+		if c.DryRun {
+			continue
+		}
+
+		tasks, err := a.client.Index(q.IndexUID).UpdateDocumentsInBatches(docs, meiliStreamPageSize, "id")
+		if err != nil {
+			return nil, fmt.Errorf("unable to anonymize entries of index %s: %w", q.IndexUID, err)
+		}
+		for _, task := range tasks {
+			_, err = a.client.WaitForTask(task.TaskUID)
+			if err != nil {
+				return nil, fmt.Errorf("unable to anonymize entries of index %s: %w", q.IndexUID, err)
+			}
+		}
+
+		a.log.Info("anonymized user", "index", q.IndexUID, "entries", len(docs))
+	}
+
+	return result, nil
+}
+
+// searchQueries returns a search request for every index that may contain entries matching the given filter.
+func (a *meiliAuditing) searchQueries(filter EntryFilter) ([]*meilisearch.SearchRequest, error) {
+	predicates, err := filterPredicates(filter)
+	if err != nil {
+		return nil, err
 	}
 
 	reqProto := &meilisearch.SearchRequest{
 		Filter: predicates,
 		Query:  filter.Body,
-		Sort:   []string{"timestamp-unix:desc", "sort-weight:desc"},
-		Limit:  filter.Limit,
-	}
-	req := &meilisearch.MultiSearchRequest{
-		Queries: []*meilisearch.SearchRequest{},
+		Sort:   []string{"timestamp-unix:desc", "sequence:desc", "sort-weight:desc"},
 	}
+	var queries []*meilisearch.SearchRequest
 
-	_, err := a.getLatestIndex()
+	_, err = a.getLatestIndex()
 	if err != nil {
 		return nil, err
 	}
@@ -201,11 +451,10 @@ func (a *meiliAuditing) Search(filter EntryFilter) ([]Entry, error) {
 			Filter: reqProto.Filter,
 			Query:  reqProto.Query,
 			Sort:   reqProto.Sort,
-			Limit:  reqProto.Limit,
 		}
 
 		indexQuery.IndexUID = index.UID
-		req.Queries = append(req.Queries, indexQuery)
+		queries = append(queries, indexQuery)
 
 		i := index
 		err = a.migrateIndexSettings(&i)
@@ -214,25 +463,73 @@ func (a *meiliAuditing) Search(filter EntryFilter) ([]Entry, error) {
 		}
 	}
 
-	if len(req.Queries) == 0 {
-		return nil, nil
-	}
+	return queries, nil
+}
 
-	resp, err := a.client.MultiSearch(req)
-	if err != nil {
-		return nil, err
+// filterPredicates returns the meilisearch filter expressions of the given filter. Label keys cannot be quoted in
+// meilisearch filters, so they are restricted to the characters of kubernetes label keys.
+func filterPredicates(filter EntryFilter) ([]string, error) {
+	predicates := make([]string, 0)
+	if filter.Component != "" {
+		predicates = append(predicates, fmt.Sprintf("component = %q", filter.Component))
 	}
-	entries := make([]Entry, 0)
-	for _, r := range resp.Results {
-		for _, h := range r.Hits {
-			h, ok := h.(map[string]any)
-			if !ok {
-				continue
-			}
-			entries = append(entries, a.decodeEntry(h))
+	if filter.Type != "" {
+		predicates = append(predicates, fmt.Sprintf("type = %q", filter.Type))
+	}
+	if filter.User != "" {
+		predicates = append(predicates, fmt.Sprintf("user = %q", filter.User))
+	}
+	if filter.Subject != "" {
+		predicates = append(predicates, fmt.Sprintf("subject = %q", filter.Subject))
+	}
+	if filter.EMail != "" {
+		predicates = append(predicates, fmt.Sprintf("email = %q", filter.EMail))
+	}
+	if filter.Tenant != "" {
+		predicates = append(predicates, fmt.Sprintf("tenant = %q", filter.Tenant))
+	}
+	if filter.RequestId != "" {
+		predicates = append(predicates, fmt.Sprintf("rqid = %q", filter.RequestId))
+	}
+	if filter.Detail != "" {
+		predicates = append(predicates, fmt.Sprintf("detail = %q", filter.Detail))
+	}
+	if filter.Phase != "" {
+		predicates = append(predicates, fmt.Sprintf("phase = %q", filter.Phase))
+	}
+	if filter.Path != "" {
+		predicates = append(predicates, fmt.Sprintf("path = %q", filter.Path))
+	}
+	if filter.ForwardedFor != "" {
+		predicates = append(predicates, fmt.Sprintf("forwarded-for = %q", filter.ForwardedFor))
+	}
+	if filter.RemoteAddr != "" {
+		predicates = append(predicates, fmt.Sprintf("remote-addr = %q", filter.RemoteAddr))
+	}
+	if filter.Classification != "" {
+		predicates = append(predicates, fmt.Sprintf("classification = %q", filter.Classification))
+	}
+	if filter.StatusCode != 0 {
+		predicates = append(predicates, fmt.Sprintf("status-code = %d", filter.StatusCode))
+	}
+	if filter.Error != "" {
+		predicates = append(predicates, fmt.Sprintf("error = %q", filter.Error))
+	}
+	for _, key := range slices.Sorted(maps.Keys(filter.Labels)) {
+		if !meiliLabelKeyRe.MatchString(key) {
+			return nil, fmt.Errorf("invalid label key %q, must match %s", key, meiliLabelKeyRe.String())
 		}
+		predicates = append(predicates, fmt.Sprintf("labels.%s = %q", key, filter.Labels[key]))
 	}
-	return entries, nil
+
+	if !filter.From.IsZero() {
+		predicates = append(predicates, fmt.Sprintf("timestamp-unix >= %d", filter.From.Unix()))
+	}
+	if !filter.To.IsZero() {
+		predicates = append(predicates, fmt.Sprintf("timestamp-unix <= %d", filter.To.Unix()))
+	}
+
+	return predicates, nil
 }
 
 func (a *meiliAuditing) encodeEntry(entry Entry) map[string]any {
@@ -240,6 +537,7 @@ func (a *meiliAuditing) encodeEntry(entry Entry) map[string]any {
 	doc["id"] = entry.Id
 	doc["component"] = entry.Component
 	doc["sort-weight"] = a.entrySortWeight(entry)
+	doc["sequence"] = entry.Sequence
 	if entry.Type != "" {
 		doc["type"] = string(entry.Type)
 	}
@@ -248,6 +546,12 @@ func (a *meiliAuditing) encodeEntry(entry Entry) map[string]any {
 	if entry.User != "" {
 		doc["user"] = entry.User
 	}
+	if entry.Subject != "" {
+		doc["subject"] = entry.Subject
+	}
+	if entry.EMail != "" {
+		doc["email"] = entry.EMail
+	}
 	if entry.Tenant != "" {
 		doc["tenant"] = entry.Tenant
 	}
@@ -269,6 +573,9 @@ func (a *meiliAuditing) encodeEntry(entry Entry) map[string]any {
 	if entry.RemoteAddr != "" {
 		doc["remote-addr"] = entry.RemoteAddr
 	}
+	if entry.Classification != "" {
+		doc["classification"] = string(entry.Classification)
+	}
 	if entry.StatusCode != 0 {
 		doc["status-code"] = entry.StatusCode
 	}
@@ -278,9 +585,35 @@ func (a *meiliAuditing) encodeEntry(entry Entry) map[string]any {
 	if entry.Body != nil {
 		doc["body"] = entry.Body
 	}
+	if len(entry.Labels) > 0 {
+		doc["labels"] = entry.Labels
+	}
+	if entry.Integrity != nil {
+		doc["integrity"] = map[string]any{
+			"chain":     entry.Integrity.Chain,
+			"position":  entry.Integrity.Position,
+			"prev-hash": entry.Integrity.PrevHash,
+			"hash":      entry.Integrity.Hash,
+			"algorithm": entry.Integrity.Algorithm,
+			"signature": entry.Integrity.Signature,
+		}
+	}
 	return doc
 }
 
+// mergeLabels returns the component labels merged with the labels of an entry, labels of the entry take precedence.
+func mergeLabels(component, entry map[string]string) map[string]string {
+	if len(component) == 0 {
+		return entry
+	}
+
+	merged := maps.Clone(component)
+	maps.Copy(merged, entry)
+
+	return merged
+}
+
+// entrySortWeight orders entries with equal timestamps and sequences, e.g. entries indexed before sequences were recorded.
 func (a *meiliAuditing) entrySortWeight(entry Entry) float32 {
 	switch entry.Phase {
 	case EntryPhaseOpened:
@@ -320,6 +653,12 @@ func (a *meiliAuditing) decodeEntry(doc map[string]any) Entry {
 	if user, ok := doc["user"].(string); ok {
 		entry.User = user
 	}
+	if subject, ok := doc["subject"].(string); ok {
+		entry.Subject = subject
+	}
+	if email, ok := doc["email"].(string); ok {
+		entry.EMail = email
+	}
 	if tenant, ok := doc["tenant"].(string); ok {
 		entry.Tenant = tenant
 	}
@@ -332,6 +671,9 @@ func (a *meiliAuditing) decodeEntry(doc map[string]any) Entry {
 	if phase, ok := doc["phase"].(string); ok {
 		entry.Phase = EntryPhase(phase)
 	}
+	if sequence, ok := doc["sequence"].(float64); ok {
+		entry.Sequence = uint64(sequence)
+	}
 	if path, ok := doc["path"].(string); ok {
 		entry.Path = path
 	}
@@ -341,6 +683,9 @@ func (a *meiliAuditing) decodeEntry(doc map[string]any) Entry {
 	if remoteAddr, ok := doc["remote-addr"].(string); ok {
 		entry.RemoteAddr = remoteAddr
 	}
+	if classification, ok := doc["classification"].(string); ok {
+		entry.Classification = Classification(classification)
+	}
 	if statusCode, ok := doc["status-code"].(float64); ok {
 		entry.StatusCode = int(statusCode)
 	}
@@ -350,6 +695,35 @@ func (a *meiliAuditing) decodeEntry(doc map[string]any) Entry {
 	if body, ok := doc["body"]; ok {
 		entry.Body = body
 	}
+	if labels, ok := doc["labels"].(map[string]any); ok {
+		entry.Labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			if value, ok := v.(string); ok {
+				entry.Labels[k] = value
+			}
+		}
+	}
+	if integrity, ok := doc["integrity"].(map[string]any); ok {
+		entry.Integrity = &EntryIntegrity{}
+		if chain, ok := integrity["chain"].(string); ok {
+			entry.Integrity.Chain = chain
+		}
+		if position, ok := integrity["position"].(float64); ok {
+			entry.Integrity.Position = uint64(position)
+		}
+		if prevHash, ok := integrity["prev-hash"].(string); ok {
+			entry.Integrity.PrevHash = prevHash
+		}
+		if hash, ok := integrity["hash"].(string); ok {
+			entry.Integrity.Hash = hash
+		}
+		if algorithm, ok := integrity["algorithm"].(string); ok {
+			entry.Integrity.Algorithm = algorithm
+		}
+		if signature, ok := integrity["signature"].(string); ok {
+			entry.Integrity.Signature = signature
+		}
+	}
 	return entry
 
 }
@@ -423,6 +797,7 @@ func (a *meiliAuditing) migrateIndexSettings(index *meilisearch.Index) error {
 		},
 		SortableAttributes: []string{
 			"timestamp-unix",
+			"sequence",
 			"sort-weight",
 		},
 		SearchableAttributes: []string{
@@ -438,15 +813,22 @@ func (a *meiliAuditing) migrateIndexSettings(index *meilisearch.Index) error {
 			"timestamp-unix",
 			"timestamp",
 			"user",
+			"subject",
+			"email",
 			"tenant",
 			"detail",
 			"phase",
 			"path",
 			"forwarded-for",
 			"remote-addr",
+			"classification",
 			"body",
 			"status-code",
 			"error",
+			"labels",
+		},
+		Pagination: &meilisearch.Pagination{
+			MaxTotalHits: meiliMaxTotalHits,
 		},
 	}
 	diff := &meilisearch.Settings{}
@@ -474,6 +856,10 @@ func (a *meiliAuditing) migrateIndexSettings(index *meilisearch.Index) error {
 		changesRequired = true
 		diff.FilterableAttributes = desired.FilterableAttributes
 	}
+	if current.Pagination == nil || current.Pagination.MaxTotalHits != desired.Pagination.MaxTotalHits {
+		changesRequired = true
+		diff.Pagination = desired.Pagination
+	}
 	if !changesRequired {
 		return nil
 	}
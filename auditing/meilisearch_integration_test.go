@@ -272,6 +272,48 @@ func TestAuditing_Meilisearch(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "count and stream entries",
+			t: func(t *testing.T, a Auditing) {
+				es := testEntries()
+				for _, e := range es {
+					err = a.Index(e)
+					require.NoError(t, err)
+				}
+
+				err = a.Flush()
+				require.NoError(t, err)
+
+				count, err := Count(context.Background(), a, EntryFilter{Limit: 1})
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(es)), count)
+
+				count, err = Count(context.Background(), a, EntryFilter{Phase: EntryPhaseResponse})
+				require.NoError(t, err)
+				assert.Equal(t, int64(2), count)
+
+				var streamed []Entry
+				err = SearchStream(context.Background(), a, EntryFilter{}, func(e Entry) error {
+					streamed = append(streamed, e)
+					return nil
+				})
+				require.NoError(t, err)
+
+				sort.Slice(streamed, func(i, j int) bool { return streamed[i].RequestId < streamed[j].RequestId })
+
+				if diff := cmp.Diff(streamed, es, cmpopts.IgnoreFields(Entry{}, "Id"), timeComparer); diff != "" {
+					t.Errorf("diff (+got -want):\n %s", diff)
+				}
+
+				streamed = nil
+				err = SearchStream(context.Background(), a, EntryFilter{Limit: 2}, func(e Entry) error {
+					streamed = append(streamed, e)
+					return nil
+				})
+				require.NoError(t, err)
+				assert.Len(t, streamed, 2)
+			},
+		},
 	}
 	for i, tt := range tests {
 		tt := tt
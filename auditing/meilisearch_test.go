@@ -1,9 +1,21 @@
 package auditing
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/meilisearch/meilisearch-go"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMeilisearchRelevantIndexNames(t *testing.T) {
@@ -51,3 +63,131 @@ func TestMeilisearchRelevantIndexNames(t *testing.T) {
 		})
 	}
 }
+
+func TestMeilisearchEncodeDecodeSequence(t *testing.T) {
+	a := &meiliAuditing{}
+
+	raw, err := json.Marshal(a.encodeEntry(Entry{Phase: EntryPhaseResponse, Sequence: 3}))
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(raw, &doc))
+
+	entry := a.decodeEntry(doc)
+	require.Equal(t, EntryPhaseResponse, entry.Phase)
+	require.Equal(t, uint64(3), entry.Sequence)
+}
+
+func TestMeilisearchFilterPredicatesLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "kubernetes label keys",
+			labels: map[string]string{"pod": "a", "app.kubernetes.io/version": "v1"},
+			want:   []string{`labels.app.kubernetes.io/version = "v1"`, `labels.pod = "a"`},
+		},
+		{
+			name:   "values are quoted",
+			labels: map[string]string{"pod": `a" OR user = "b`},
+			want:   []string{`labels.pod = "a\" OR user = \"b"`},
+		},
+		{
+			name:    "hostile key",
+			labels:  map[string]string{`pod = "a" OR user`: "b"},
+			wantErr: fmt.Errorf(`invalid label key "pod = \"a\" OR user", must match ^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`),
+		},
+		{
+			name:    "empty key",
+			labels:  map[string]string{"": "b"},
+			wantErr: fmt.Errorf(`invalid label key "", must match ^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterPredicates(EntryFilter{Labels: tt.labels})
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestMeilisearchIndexPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		wantPermanent bool
+	}{
+		{
+			name:          "invalid document",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"message":"The provided payload is malformed.","code":"malformed_payload","type":"invalid_request","link":"https://docs.meilisearch.com/errors#malformed_payload"}`,
+			wantPermanent: true,
+		},
+		{
+			name:          "payload too large",
+			statusCode:    http.StatusRequestEntityTooLarge,
+			body:          `{"message":"The provided payload reached the size limit.","code":"payload_too_large","type":"invalid_request","link":"https://docs.meilisearch.com/errors#payload_too_large"}`,
+			wantPermanent: true,
+		},
+		{
+			name:       "invalid api key",
+			statusCode: http.StatusForbidden,
+			body:       `{"message":"The provided API key is invalid.","code":"invalid_api_key","type":"auth","link":"https://docs.meilisearch.com/errors#invalid_api_key"}`,
+		},
+		{
+			name:       "unavailable",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{"message":"unavailable"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var indexed bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPost, r.Method)
+				require.True(t, strings.HasSuffix(r.URL.Path, "/documents"))
+				indexed = true
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := meilisearch.NewClient(meilisearch.ClientConfig{Host: server.URL})
+			a := &meiliAuditing{
+				client:           client,
+				log:              slog.Default(),
+				indexPrefix:      "auditing",
+				rotationInterval: DailyInterval,
+			}
+			a.index = client.Index(indexName(a.indexPrefix, a.rotationInterval))
+
+			path := filepath.Join(t.TempDir(), "audit.ndjson")
+			spooled, err := NewSpooled(context.Background(), a, SpoolConfig{Path: path, ReplayInterval: time.Hour, Log: slog.Default()})
+			require.NoError(t, err)
+
+			err = spooled.Index(Entry{Id: "1"})
+			require.True(t, indexed)
+
+			if tt.wantPermanent {
+				require.True(t, IsPermanent(err), "expected permanent error, got: %v", err)
+				require.NoFileExists(t, path, "permanently rejected entries must not be spooled")
+			} else {
+				require.NoError(t, err)
+				require.FileExists(t, path, "entries must be spooled when the error is not permanent")
+			}
+		})
+	}
+}
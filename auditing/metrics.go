@@ -0,0 +1,170 @@
+package auditing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsOperationIndex  = "index"
+	metricsOperationSearch = "search"
+	metricsOperationFlush  = "flush"
+)
+
+// Metrics are the prometheus collectors of the auditing pipeline like the amount of indexed entries, index errors,
+// backend latency and queue depth, such that degraded audit ingestion can be alerted on.
+type Metrics struct {
+	indexed    *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	queueDepth prometheus.Gauge
+}
+
+// NewMetrics creates the auditing metrics and registers them at the given registerer. The buckets of the backend
+// latency histogram are given in seconds and default to prometheus.DefBuckets.
+func NewMetrics(reg prometheus.Registerer, buckets ...float64) (*Metrics, error) {
+	if reg == nil {
+		return nil, fmt.Errorf("metrics registerer must be specified")
+	}
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	m := &Metrics{
+		indexed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "auditing",
+			Name:      "entries_indexed_total",
+			Help:      "number of entries indexed by the auditing backend.",
+		}, []string{"component", "type", "phase"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "auditing",
+			Name:      "index_errors_total",
+			Help:      "number of entries that could not be indexed by the auditing backend.",
+		}, []string{"component"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "auditing",
+			Name:      "backend_duration_seconds",
+			Help:      "duration of the calls to the auditing backend.",
+			Buckets:   buckets,
+		}, []string{"operation"}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "auditing",
+			Name:      "queue_depth",
+			Help:      "number of entries waiting to be indexed.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.indexed, m.errors, m.latency, m.queueDepth} {
+		err := reg.Register(c)
+		if err != nil {
+			return nil, fmt.Errorf("unable to register auditing metrics: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// SetQueueDepth sets the amount of entries that are waiting to be indexed, e.g. by an asynchronous auditing backend.
+func (m *Metrics) SetQueueDepth(depth int) {
+	m.queueDepth.Set(float64(depth))
+}
+
+func (m *Metrics) observeIndex(e Entry, component string, d time.Duration, err error) {
+	m.observe(metricsOperationIndex, d)
+
+	if err != nil {
+		m.errors.WithLabelValues(component).Inc()
+		return
+	}
+
+	m.indexed.WithLabelValues(component, string(e.Type), string(e.Phase)).Inc()
+}
+
+func (m *Metrics) observe(operation string, d time.Duration) {
+	m.latency.WithLabelValues(operation).Observe(d.Seconds())
+}
+
+type metricsAuditing struct {
+	backend   Auditing
+	metrics   *Metrics
+	component string
+	now       func() time.Time
+}
+
+// NewWithMetrics wraps the given auditing backend and records the indexed entries, index errors and backend latency
+// in the given metrics. Entries without a component are counted for the given default component.
+func NewWithMetrics(backend Auditing, metrics *Metrics, component string) (Auditing, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("cannot record metrics for nil auditing")
+	}
+	if metrics == nil {
+		return nil, fmt.Errorf("metrics must be specified")
+	}
+
+	return &metricsAuditing{
+		backend:   backend,
+		metrics:   metrics,
+		component: component,
+		now:       time.Now,
+	}, nil
+}
+
+func (a *metricsAuditing) Flush() error {
+	start := a.now()
+	err := a.backend.Flush()
+	a.metrics.observe(metricsOperationFlush, a.now().Sub(start))
+
+	return err
+}
+
+func (a *metricsAuditing) Index(e Entry) error {
+	component := e.Component
+	if component == "" {
+		component = a.component
+	}
+
+	start := a.now()
+	err := a.backend.Index(e)
+	a.metrics.observeIndex(e, component, a.now().Sub(start), err)
+
+	return err
+}
+
+func (a *metricsAuditing) Search(filter EntryFilter) ([]Entry, error) {
+	start := a.now()
+	entries, err := a.backend.Search(filter)
+	a.metrics.observe(metricsOperationSearch, a.now().Sub(start))
+
+	return entries, err
+}
+
+func (a *metricsAuditing) Count(ctx context.Context, filter EntryFilter) (int64, error) {
+	start := a.now()
+	count, err := Count(ctx, a.backend, filter)
+	a.metrics.observe(metricsOperationSearch, a.now().Sub(start))
+
+	return count, err
+}
+
+func (a *metricsAuditing) SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error {
+	start := a.now()
+	err := SearchStream(ctx, a.backend, filter, fn)
+	a.metrics.observe(metricsOperationSearch, a.now().Sub(start))
+
+	return err
+}
+
+func (a *metricsAuditing) Get(ctx context.Context, id string) (*Entry, error) {
+	start := a.now()
+	entry, err := Get(ctx, a.backend, id)
+	a.metrics.observe(metricsOperationSearch, a.now().Sub(start))
+
+	return entry, err
+}
+
+func (a *metricsAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	return AnonymizeUser(ctx, a.backend, userID, c)
+}
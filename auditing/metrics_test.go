@@ -0,0 +1,69 @@
+package auditing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsAuditing(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	backend := &unavailableAuditing{}
+	metrics, err := NewMetrics(reg, 0.01, 0.1)
+	require.NoError(t, err)
+
+	a, err := NewWithMetrics(backend, metrics, "metal-api")
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	a.(*metricsAuditing).now = func() time.Time {
+		now = now.Add(25 * time.Millisecond)
+		return now
+	}
+
+	require.NoError(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseRequest}))
+	require.NoError(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseResponse}))
+	require.NoError(t, a.Index(Entry{Type: EntryTypeGRPC, Phase: EntryPhaseSingle, Component: "ipam"}))
+
+	backend.down = true
+	require.Error(t, a.Index(Entry{Type: EntryTypeHTTP, Phase: EntryPhaseRequest}))
+
+	_, err = a.Search(EntryFilter{})
+	require.NoError(t, err)
+
+	metrics.SetQueueDepth(3)
+
+	want := `# HELP auditing_backend_duration_seconds duration of the calls to the auditing backend.
+# TYPE auditing_backend_duration_seconds histogram
+auditing_backend_duration_seconds_bucket{operation="index",le="0.01"} 0
+auditing_backend_duration_seconds_bucket{operation="index",le="0.1"} 4
+auditing_backend_duration_seconds_bucket{operation="index",le="+Inf"} 4
+auditing_backend_duration_seconds_sum{operation="index"} 0.1
+auditing_backend_duration_seconds_count{operation="index"} 4
+auditing_backend_duration_seconds_bucket{operation="search",le="0.01"} 0
+auditing_backend_duration_seconds_bucket{operation="search",le="0.1"} 1
+auditing_backend_duration_seconds_bucket{operation="search",le="+Inf"} 1
+auditing_backend_duration_seconds_sum{operation="search"} 0.025
+auditing_backend_duration_seconds_count{operation="search"} 1
+# HELP auditing_entries_indexed_total number of entries indexed by the auditing backend.
+# TYPE auditing_entries_indexed_total counter
+auditing_entries_indexed_total{component="ipam",phase="single",type="grpc"} 1
+auditing_entries_indexed_total{component="metal-api",phase="request",type="http"} 1
+auditing_entries_indexed_total{component="metal-api",phase="response",type="http"} 1
+# HELP auditing_index_errors_total number of entries that could not be indexed by the auditing backend.
+# TYPE auditing_index_errors_total counter
+auditing_index_errors_total{component="metal-api"} 1
+# HELP auditing_queue_depth number of entries waiting to be indexed.
+# TYPE auditing_queue_depth gauge
+auditing_queue_depth 3
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want)))
+
+	_, err = NewMetrics(reg)
+	require.Error(t, err, "metrics must not be registered twice")
+}
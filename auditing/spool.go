@@ -0,0 +1,391 @@
+package auditing
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSpoolMaxSize        int64 = 64 * 1024 * 1024
+	defaultSpoolReplayInterval       = 10 * time.Second
+)
+
+type SpoolConfig struct {
+	// Path is the file where entries are spooled while the auditing backend is unavailable.
+	Path string
+	// MaxSize is the maximum size of the spool file in bytes, defaults to 64 MiB.
+	// When the spool is full, the error of the auditing backend is returned again.
+	MaxSize int64
+	// ReplayInterval is the interval in which spooled entries are replayed to the auditing backend, defaults to 10 seconds.
+	ReplayInterval time.Duration
+	// DeadLetterPath is the file where spooled entries are moved to when the auditing backend rejects them permanently
+	// during replay, defaults to the spool path with a ".dead" suffix.
+	DeadLetterPath string
+	Log            *slog.Logger
+}
+
+// PermanentError marks an error of an auditing backend that cannot be resolved by retrying, e.g. an entry that is
+// rejected by the backend. Such entries are not spooled. The meilisearch backend marks client errors of meilisearch
+// as permanent, except for authentication failures and rate limiting.
+type PermanentError struct {
+	err error
+}
+
+// Permanent marks the given error as permanent. Returns nil for a nil error.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.err
+}
+
+// IsPermanent returns true if the given error or one of the errors it wraps is permanent.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+type spoolAuditing struct {
+	backend        Auditing
+	path           string
+	deadLetterPath string
+	maxSize        int64
+	replayInterval time.Duration
+	log            *slog.Logger
+
+	// lock guards the spool file, replayLock ensures that only one replay runs at a time
+	lock       sync.Mutex
+	replayLock sync.Mutex
+}
+
+// spooledEntry is the representation of an entry in the spool file, errors cannot be serialized directly.
+type spooledEntry struct {
+	Entry
+	Error string `json:"error,omitempty"`
+}
+
+// NewSpooled wraps the given auditing backend with a local write-ahead spool. Entries that cannot be indexed
+// because the backend is unavailable are appended to the spool file and replayed in the background once the
+// backend recovers, such that requests neither fail nor lose their audit trail during short outages.
+//
+// The background replay stops when the given context is done.
+func NewSpooled(ctx context.Context, backend Auditing, c SpoolConfig) (Auditing, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("cannot spool entries for nil auditing")
+	}
+	if c.Path == "" {
+		return nil, fmt.Errorf("spool path must be specified")
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultSpoolMaxSize
+	}
+	if c.ReplayInterval <= 0 {
+		c.ReplayInterval = defaultSpoolReplayInterval
+	}
+	if c.DeadLetterPath == "" {
+		c.DeadLetterPath = c.Path + ".dead"
+	}
+	if c.Log == nil {
+		c.Log = slog.Default()
+	}
+
+	err := os.MkdirAll(filepath.Dir(c.Path), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create spool directory: %w", err)
+	}
+
+	a := &spoolAuditing{
+		backend:        backend,
+		path:           c.Path,
+		deadLetterPath: c.DeadLetterPath,
+		maxSize:        c.MaxSize,
+		replayInterval: c.ReplayInterval,
+		log:            c.Log.WithGroup("auditing-spool"),
+	}
+
+	go a.replayLoop(ctx)
+
+	return a, nil
+}
+
+// Flush replays the spooled entries and flushes the auditing backend.
+func (a *spoolAuditing) Flush() error {
+	replayErr := a.replay()
+
+	return errors.Join(replayErr, a.backend.Flush())
+}
+
+func (a *spoolAuditing) Index(entry Entry) error {
+	// the id is set before indexing, such that replaying an entry that was already indexed does not create a duplicate
+	if entry.Id == "" {
+		entry.Id = uuid.NewString()
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	err := a.backend.Index(entry)
+	if err == nil {
+		return nil
+	}
+	if IsPermanent(err) {
+		return err
+	}
+
+	spoolErr := a.spool(entry)
+	if spoolErr != nil {
+		a.log.Error("unable to spool entry", "id", entry.Id, "error", spoolErr)
+		return err
+	}
+
+	a.log.Warn("auditing backend unavailable, spooled entry", "id", entry.Id, "error", err)
+
+	return nil
+}
+
+func (a *spoolAuditing) Search(filter EntryFilter) ([]Entry, error) {
+	return a.backend.Search(filter)
+}
+
+func (a *spoolAuditing) Count(ctx context.Context, filter EntryFilter) (int64, error) {
+	return Count(ctx, a.backend, filter)
+}
+
+func (a *spoolAuditing) SearchStream(ctx context.Context, filter EntryFilter, fn func(Entry) error) error {
+	return SearchStream(ctx, a.backend, filter, fn)
+}
+
+func (a *spoolAuditing) Get(ctx context.Context, id string) (*Entry, error) {
+	return Get(ctx, a.backend, id)
+}
+
+func (a *spoolAuditing) AnonymizeUser(ctx context.Context, userID string, c AnonymizeConfig) (*AnonymizeResult, error) {
+	return AnonymizeUser(ctx, a.backend, userID, c)
+}
+
+func (a *spoolAuditing) spool(entry Entry) error {
+	se := spooledEntry{Entry: entry}
+	if entry.Error != nil {
+		se.Error = entry.Error.Error()
+		se.Entry.Error = nil
+	}
+
+	line, err := json.Marshal(se)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var size int64
+	info, err := os.Stat(a.path)
+	if err == nil {
+		size = info.Size()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if size+int64(len(line)) > a.maxSize {
+		return fmt.Errorf("spool file exceeds max size of %d bytes", a.maxSize)
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(line)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func (a *spoolAuditing) replayLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := a.replay()
+			if err != nil {
+				a.log.Debug("unable to replay spooled entries", "error", err)
+			}
+		}
+	}
+}
+
+// replay indexes the spooled entries in order. Entries that are rejected permanently are moved to the dead letter file,
+// replay stops at the first other failure and the remaining entries stay in the spool.
+//
+// The backend is called without holding the lock of the spool file, such that concurrent calls to Index are not blocked.
+func (a *spoolAuditing) replay() error {
+	a.replayLock.Lock()
+	defer a.replayLock.Unlock()
+
+	a.lock.Lock()
+	raw, err := os.ReadFile(a.path)
+	a.lock.Unlock()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	lines, err := a.splitLines(raw)
+	if err != nil {
+		return err
+	}
+
+	var (
+		replayed  int
+		dead      [][]byte
+		replayErr error
+	)
+
+	for _, line := range lines {
+		var se spooledEntry
+		err := json.Unmarshal(line, &se)
+		if err != nil {
+			a.log.Error("dropping corrupt spooled entry", "error", err)
+			dead = append(dead, line)
+			replayed++
+			continue
+		}
+
+		entry := se.Entry
+		if se.Error != "" {
+			entry.Error = errors.New(se.Error)
+		}
+
+		err = a.backend.Index(entry)
+		if err != nil {
+			if IsPermanent(err) {
+				a.log.Error("auditing backend rejected spooled entry, moving it to dead letter file", "id", entry.Id, "error", err)
+				dead = append(dead, line)
+				replayed++
+				continue
+			}
+
+			replayErr = err
+			break
+		}
+
+		replayed++
+	}
+
+	if replayed == 0 {
+		return replayErr
+	}
+
+	a.log.Info("replayed spooled entries", "count", replayed-len(dead), "dead", len(dead), "remaining", len(lines)-replayed)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(dead) > 0 {
+		err = appendLines(a.deadLetterPath, dead)
+		if err != nil {
+			return errors.Join(replayErr, fmt.Errorf("unable to write dead letter file: %w", err))
+		}
+	}
+
+	// entries may have been spooled in the meantime, they are kept after the remaining entries
+	current, err := os.ReadFile(a.path)
+	if err != nil {
+		return errors.Join(replayErr, err)
+	}
+
+	remaining := lines[replayed:]
+	if len(current) > len(raw) {
+		appended, err := a.splitLines(current[len(raw):])
+		if err != nil {
+			return errors.Join(replayErr, err)
+		}
+		remaining = append(remaining, appended...)
+	}
+
+	if len(remaining) == 0 {
+		return errors.Join(replayErr, os.Remove(a.path))
+	}
+
+	return errors.Join(replayErr, a.rewrite(remaining))
+}
+
+func (a *spoolAuditing) splitLines(raw []byte) ([][]byte, error) {
+	var (
+		lines   [][]byte
+		scanner = bufio.NewScanner(bytes.NewReader(raw))
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), int(a.maxSize))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+
+	return lines, scanner.Err()
+}
+
+func appendLines(path string, lines [][]byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		_, err = f.Write(append(line, '\n'))
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	return f.Close()
+}
+
+func (a *spoolAuditing) rewrite(lines [][]byte) error {
+	tmp := a.path + ".tmp"
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	err := os.WriteFile(tmp, buf.Bytes(), 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, a.path)
+}
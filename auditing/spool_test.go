@@ -0,0 +1,168 @@
+package auditing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+type unavailableAuditing struct {
+	memoryAuditing
+	down bool
+	// rejected contains the ids of entries that are rejected permanently
+	rejected map[string]bool
+}
+
+func (u *unavailableAuditing) Index(e Entry) error {
+	if u.down {
+		return errors.New("connection refused")
+	}
+	if u.rejected[e.Id] {
+		return Permanent(errors.New("invalid entry"))
+	}
+	return u.memoryAuditing.Index(e)
+}
+
+func TestSpooledAuditing(t *testing.T) {
+	backend := &unavailableAuditing{down: true}
+	path := filepath.Join(t.TempDir(), "spool", "audit.ndjson")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, err := NewSpooled(ctx, backend, SpoolConfig{
+		Path:           path,
+		ReplayInterval: time.Hour,
+		Log:            slog.Default(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range []Entry{
+		{Id: "1", Path: "/v1/machine", Body: "a"},
+		{Id: "2", Path: "/v1/network", Error: errors.New("not found")},
+	} {
+		err = a.Index(e)
+		if err != nil {
+			t.Fatalf("entries must be spooled when the backend is down: %v", err)
+		}
+	}
+
+	if len(backend.entries) != 0 {
+		t.Fatalf("expected no entries in backend, got %d", len(backend.entries))
+	}
+
+	// replay fails while the backend is still down and keeps the entries
+	err = a.Flush()
+	if err == nil {
+		t.Fatal("expected replay error")
+	}
+
+	backend.down = false
+
+	err = a.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{Id: "1", Path: "/v1/machine", Body: "a"},
+		{Id: "2", Path: "/v1/network", Error: errors.New("not found")},
+	}
+	if diff := cmp.Diff(want, backend.entries, testcommon.ErrorStringComparer(), cmpopts.IgnoreFields(Entry{}, "Timestamp")); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected spool file to be removed after replay, got: %v", err)
+	}
+}
+
+func TestSpooledAuditing_MaxSize(t *testing.T) {
+	backend := &unavailableAuditing{down: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, err := NewSpooled(ctx, backend, SpoolConfig{
+		Path:    filepath.Join(t.TempDir(), "audit.ndjson"),
+		MaxSize: 1,
+		Log:     slog.Default(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.Index(Entry{Id: "1"})
+	if diff := cmp.Diff(errors.New("connection refused"), err, testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("error diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestSpooledAuditing_PermanentErrors(t *testing.T) {
+	backend := &unavailableAuditing{down: true, rejected: map[string]bool{"poisoned": true}}
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, err := NewSpooled(ctx, backend, SpoolConfig{
+		Path:           path,
+		ReplayInterval: time.Hour,
+		Log:            slog.Default(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"1", "poisoned", "2"} {
+		err = a.Index(Entry{Id: id})
+		if err != nil {
+			t.Fatalf("entries must be spooled when the backend is down: %v", err)
+		}
+	}
+
+	backend.down = false
+
+	// permanently rejected entries are not spooled
+	err = a.Index(Entry{Id: "poisoned"})
+	if !IsPermanent(err) {
+		t.Errorf("expected permanent error, got: %v", err)
+	}
+
+	// a rejected entry must not block the replay of the following entries
+	err = a.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, e := range backend.entries {
+		ids = append(ids, e.Id)
+	}
+	if diff := cmp.Diff([]string{"1", "2"}, ids); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected spool file to be removed after replay, got: %v", err)
+	}
+
+	dead, err := os.ReadFile(path + ".dead")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(dead), `"Id":"poisoned"`) {
+		t.Errorf("expected rejected entry in dead letter file, got: %s", dead)
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"net/http/httputil"
 	"os"
 	"os/exec"
-	"runtime"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,7 +26,14 @@ import (
 	"golang.org/x/oauth2"
 )
 
-const cloudContext = "cloudctl"
+const (
+	cloudContext = "cloudctl"
+
+	defaultLoginTimeout = 5 * time.Minute
+
+	defaultCallbackHost = "localhost"
+	callbackPath        = "/callback"
+)
 
 var DexScopes = []string{"groups", "openid", "profile", "email", "federated:id"}
 var GenericScopes = []string{"openid", "profile", "email"}
@@ -35,10 +44,19 @@ type Config struct {
 	IssuerURL     string `required:"true"`
 	SkipTLSVerify bool
 	IssuerRootCA  string
+	// IssuerName is set if the config was selected from a MultiIssuerConfig and is returned in the TokenInfo
+	IssuerName string
 
-	// client identification
+	// client identification, the ClientSecret is required unless PublicClient is set
 	ClientID     string `required:"true"`
-	ClientSecret string `required:"true"`
+	ClientSecret string
+
+	// PublicClient allows logging in without ClientSecret, which is required for the public clients of e.g. Keycloak,
+	// Entra ID or Auth0. PKCE is always used for public clients.
+	PublicClient bool
+	// PKCE enables the proof key for code exchange with the S256 challenge method (see https://datatracker.ietf.org/doc/html/rfc7636),
+	// which protects the authorization code from being intercepted.
+	PKCE bool
 
 	// requested scopes
 	Scopes []string
@@ -53,12 +71,30 @@ type Config struct {
 
 	// Message shown on the success page after login flow
 	SuccessMessage string
+	// SuccessPage customizes the page shown after the login flow, the default page is used if nil
+	SuccessPage *SuccessPage
+
+	// CallbackHost is the host of the redirect uri, defaults to "localhost". Some providers only allow e.g. "127.0.0.1".
+	CallbackHost string
+	// CallbackPorts are the ports for receiving the callback of the login, the first free port is used.
+	// If empty, a random port is used, which requires the provider to allow any port for the redirect uri.
+	// Use RedirectURIs for the redirect uris that have to be registered at the provider.
+	CallbackPorts []int
 
 	Log *slog.Logger
 
 	// Console if you want the library to write messages, may be nil
 	Console io.Writer
 
+	// NoBrowser disables opening a browser, the login url is only printed to the console.
+	// A browser is also not opened when none is available, e.g. in a ssh session without a display.
+	NoBrowser bool
+	// QRCode renders the login url as qr code to the console if no browser is opened, which allows logging in from a mobile device.
+	QRCode QRCodeRenderer
+
+	// LoginTimeout is the maximum duration for completing the login, after which the flow is aborted, defaults to 5 minutes.
+	LoginTimeout time.Duration
+
 	Debug bool
 }
 
@@ -81,6 +117,12 @@ type app struct {
 	provider *oidc.Provider
 
 	state string
+	// nonce is bound to the id token of the login, which prevents replaying id tokens of other logins.
+	nonce string
+	// stateConsumed is set once a callback with the valid state was received, such that repeated callbacks are rejected.
+	stateConsumed atomic.Bool
+	// codeVerifier is the PKCE verifier of the login, empty if PKCE is not used.
+	codeVerifier string
 
 	// Does the provider use "offline_access" scope to request a refresh token
 	// or does it use "access_type=offline" (e.g. Google)?
@@ -137,12 +179,48 @@ func (c Claims) Username() string {
 // or https://connect2id.com/learn/openid-connect).
 //
 // A local webserver is started to receive the callbacks from the oidc-endpoint.
+// With PKCE or a PublicClient, the authorization code is bound to a code verifier that only this process knows.
 //
 // 1. OpenID Discovery --> gather info about OIDC Provider
 // 2. open browser for login --> build url with scopes --> redirect to OIDC-Login-Flow (oidc-provider: auth with ldap, read groups, return signed jwt)
 // 3. receive Callback, extract token and redirect to Success-Page
 // 4. call TokenHandler
 func OIDCFlow(config Config) error {
+	err := validateConfig(config, !config.PublicClient)
+	if err != nil {
+		return err
+	}
+
+	appModel := &app{
+		config: config,
+	}
+
+	return oidcFlow(appModel)
+}
+
+// RedirectURIs returns the redirect uris of the login, one for each callback port, which have to be allowed for the client
+// by the provider. If no callback ports are configured, the port of the uri is random and therefore omitted.
+func (c Config) RedirectURIs() []string {
+	if len(c.CallbackPorts) == 0 {
+		return []string{fmt.Sprintf("http://%s%s", c.callbackHost(), callbackPath)}
+	}
+
+	uris := make([]string, 0, len(c.CallbackPorts))
+	for _, port := range c.CallbackPorts {
+		uris = append(uris, fmt.Sprintf("http://%s%s", net.JoinHostPort(c.callbackHost(), strconv.Itoa(port)), callbackPath))
+	}
+
+	return uris
+}
+
+func (c Config) callbackHost() string {
+	if c.CallbackHost == "" {
+		return defaultCallbackHost
+	}
+	return c.CallbackHost
+}
+
+func validateConfig(config Config, requireClientSecret bool) error {
 	if config.Log == nil {
 		return errors.New("error validating config: Log is required")
 	}
@@ -155,7 +233,7 @@ func OIDCFlow(config Config) error {
 		return errors.New("error validating config: ClientID is required")
 	}
 
-	if config.ClientSecret == "" {
+	if requireClientSecret && config.ClientSecret == "" {
 		return errors.New("error validating config: ClientSecret is required")
 	}
 
@@ -163,15 +241,17 @@ func OIDCFlow(config Config) error {
 		return errors.New("error validating config: TokenHandler is required")
 	}
 
-	if config.SkipTLSVerify && config.IssuerRootCA != "" {
-		return errors.New("it makes no sense to use IssuerRootCA and SkipTLSVerify at the same time")
+	for _, port := range config.CallbackPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("error validating config: invalid callback port %d", port)
+		}
 	}
 
-	appModel := &app{
-		config: config,
+	if config.SkipTLSVerify && config.IssuerRootCA != "" {
+		return errors.New("it makes no sense to use IssuerRootCA and SkipTLSVerify at the same time")
 	}
 
-	return oidcFlow(appModel)
+	return nil
 }
 
 func oidcFlow(appModel *app) error {
@@ -179,36 +259,99 @@ func oidcFlow(appModel *app) error {
 		appModel.config.SuccessMessage = "Please close this page and return to your terminal."
 	}
 
-	if appModel.config.IssuerRootCA != "" {
-		client, caerr := httpClientForRootCAs(appModel.config.IssuerRootCA)
+	err := appModel.initClient()
+	if err != nil {
+		return err
+	}
+
+	// generate state and nonce
+	appModel.state = uuid.NewString()
+	appModel.nonce = uuid.NewString()
+	if appModel.config.PKCE || appModel.config.PublicClient {
+		appModel.codeVerifier = oauth2.GenerateVerifier()
+	}
+
+	err = appModel.discoverProvider(oidc.ClientContext(context.Background(), appModel.client))
+	if err != nil {
+		return err
+	}
+
+	appModel.completeChan = make(chan bool)
+
+	listener, listenAddr, err := newCallbackListener(appModel.config.callbackHost(), appModel.config.CallbackPorts)
+	if err != nil {
+		return err
+	}
+
+	appModel.config.Log.Debug("Listening", slog.String("hostname", appModel.config.callbackHost()), slog.String("addr", listenAddr))
+
+	srv := &http.Server{
+		ReadHeaderTimeout: 1 * time.Minute,
+	}
+	http.HandleFunc("/", appModel.handleLogin)
+	http.HandleFunc(callbackPath, appModel.handleCallback)
+	if page := appModel.config.SuccessPage; page != nil && page.Assets != nil {
+		http.Handle(AssetsPath, http.StripPrefix(AssetsPath, http.FileServerFS(page.Assets)))
+	}
+
+	appModel.Listen = listenAddr
+	appModel.RedirectURI = fmt.Sprintf("%s%s", appModel.Listen, callbackPath)
+
+	appModel.config.Log.Debug("Redirect", slog.String("uri", appModel.RedirectURI))
+
+	appModel.openBrowser(defaultBrowserEnv())
+
+	flowErr := make(chan error, 1)
+
+	go func() {
+		flowErr <- appModel.waitShutdown()
+		err := srv.Shutdown(context.Background())
+		if err != nil {
+			appModel.config.Log.Error("Shutdown", "error", err)
+		}
+	}()
+
+	err = srv.Serve(listener)
+	// after Shutdown ErrServerClosed is returned, this is expected and ok
+	if errors.Is(err, http.ErrServerClosed) {
+		return <-flowErr
+	}
+
+	return err
+}
+
+// initClient creates the http client for the communication with the oidc provider.
+func (a *app) initClient() error {
+	if a.config.IssuerRootCA != "" {
+		client, caerr := httpClientForRootCAs(a.config.IssuerRootCA)
 		if caerr != nil {
 			return caerr
 		}
-		appModel.client = client
+		a.client = client
 	}
 
-	if appModel.client == nil {
+	if a.client == nil {
 		transport := http.DefaultTransport.(*http.Transport).Clone()
 		/* #nosec */
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: appModel.config.SkipTLSVerify} // ignore expired SSL certificates
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: a.config.SkipTLSVerify} // ignore expired SSL certificates
 
-		appModel.client = &http.Client{
+		a.client = &http.Client{
 			Transport: transport,
 		}
 	}
 
-	if appModel.config.Debug {
-		appModel.client.Transport = debugTransport{roundTripper: appModel.client.Transport, log: appModel.config.Log}
+	if a.config.Debug {
+		a.client.Transport = debugTransport{roundTripper: a.client.Transport, log: a.config.Log}
 	}
 
-	// generate state
-	appModel.state = uuid.NewString()
-
-	clientCtx := oidc.ClientContext(context.Background(), appModel.client)
+	return nil
+}
 
-	provider, err := oidc.NewProvider(clientCtx, appModel.config.IssuerURL)
+// discoverProvider gathers the info about the oidc provider.
+func (a *app) discoverProvider(clientCtx context.Context) error {
+	provider, err := oidc.NewProvider(clientCtx, a.config.IssuerURL)
 	if err != nil {
-		return fmt.Errorf("failed to query provider %q error: %w", appModel.config.IssuerURL, err)
+		return fmt.Errorf("failed to query provider %q error: %w", a.config.IssuerURL, err)
 	}
 
 	var s struct {
@@ -216,19 +359,28 @@ func oidcFlow(appModel *app) error {
 		//
 		// See: https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
 		ScopesSupported []string `json:"scopes_supported"`
+		// Which PKCE code challenge methods does a provider support?
+		//
+		// See: https://datatracker.ietf.org/doc/html/rfc8414#section-2
+		CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
 	}
 	if err := provider.Claims(&s); err != nil {
 		return fmt.Errorf("failed to parse provider scopes_supported: %w", err)
 	}
 
+	// code_challenge_methods_supported is optional as well, if missing, assume that S256 is supported
+	if a.codeVerifier != "" && len(s.CodeChallengeMethodsSupported) > 0 && !slices.Contains(s.CodeChallengeMethodsSupported, "S256") {
+		return fmt.Errorf("provider %q does not support the PKCE code challenge method S256", a.config.IssuerURL)
+	}
+
 	if len(s.ScopesSupported) == 0 {
 		// scopes_supported is a "RECOMMENDED" discovery Claims, not a required
 		// one. If missing, assume that the provider follows the spec and has
 		// an "offline_access" scope.
-		appModel.offlineAsScope = true
+		a.offlineAsScope = true
 	} else {
 		// See if scopes_supported has the "offline_access" scope.
-		appModel.offlineAsScope = func() bool {
+		a.offlineAsScope = func() bool {
 			for _, scope := range s.ScopesSupported {
 				if scope == oidc.ScopeOfflineAccess {
 					return true
@@ -238,53 +390,10 @@ func oidcFlow(appModel *app) error {
 		}()
 	}
 
-	appModel.provider = provider
-	appModel.verifier = provider.Verifier(&oidc.Config{ClientID: appModel.config.ClientID})
-	appModel.completeChan = make(chan bool)
-
-	listener, listenAddr, err := newRandomPortListener()
-	if err != nil {
-		return err
-	}
-
-	callbackPath := "/callback"
-
-	appModel.config.Log.Debug("Listening", slog.String("hostname", "localhost"), slog.String("addr", listenAddr))
-
-	srv := &http.Server{
-		ReadHeaderTimeout: 1 * time.Minute,
-	}
-	http.HandleFunc("/", appModel.handleLogin)
-	http.HandleFunc(callbackPath, appModel.handleCallback)
-
-	appModel.Listen = listenAddr
-	appModel.RedirectURI = fmt.Sprintf("%s%s", appModel.Listen, callbackPath)
-
-	appModel.config.Log.Debug("Opening Browser for Authentication")
-
-	appModel.Consolef("Opening Browser for Authentication. If this does not work, please point your browser to %s\n", appModel.Listen)
-
-	go func() {
-		err := openBrowser(appModel.Listen)
-		if err != nil {
-			appModel.config.Log.Error("openBrowser", "error", err)
-		}
-	}()
-	go func() {
-		appModel.waitShutdown()
-		err = srv.Shutdown(context.Background())
-		if err != nil {
-			appModel.config.Log.Error("Shutdown", "error", err)
-		}
-	}()
-
-	err = srv.Serve(listener)
-	// after Shutdown ErrServerClosed is returned, this is expected and ok
-	if errors.Is(err, http.ErrServerClosed) {
-		return nil
-	}
+	a.provider = provider
+	a.verifier = provider.Verifier(&oidc.Config{ClientID: a.config.ClientID})
 
-	return err
+	return nil
 }
 
 // return an HTTP client which trusts the provided root CAs.
@@ -345,32 +454,49 @@ func (a *app) oauth2Config(scopes []string) *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:     a.config.ClientID,
 		ClientSecret: a.config.ClientSecret,
-		Endpoint:     a.provider.Endpoint(),
+		Endpoint:     clientEndpoint(a.provider, a.config.ClientSecret),
 		Scopes:       scopes,
 		RedirectURL:  a.RedirectURI,
 	}
 }
 
-func (a *app) handleLogin(w http.ResponseWriter, r *http.Request) {
-
-	var authCodeURL string
+// clientEndpoint returns the endpoint of the provider, public clients without secret pass their client id
+// in the request body instead of authenticating with basic auth.
+func clientEndpoint(provider *oidc.Provider, clientSecret string) oauth2.Endpoint {
+	endpoint := provider.Endpoint()
+	if clientSecret == "" {
+		endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+	return endpoint
+}
 
+// requestedScopes returns the scopes and options for the authorization request, which ask for a refresh token if configured.
+func (a *app) requestedScopes() ([]string, []oauth2.AuthCodeOption) {
 	var scopes = a.config.Scopes
 	if scopes == nil {
 		scopes = DexScopes
 	}
 
-	if a.config.RequestRefreshToken {
-		if a.offlineAsScope {
-			scopes = append(scopes, "offline_access")
-			authCodeURL = a.oauth2Config(scopes).AuthCodeURL(a.state)
-		} else {
-			authCodeURL = a.oauth2Config(scopes).AuthCodeURL(a.state, oauth2.AccessTypeOffline)
-		}
-	} else {
-		authCodeURL = a.oauth2Config(scopes).AuthCodeURL(a.state)
+	if !a.config.RequestRefreshToken {
+		return scopes, nil
 	}
 
+	if a.offlineAsScope {
+		return append(scopes, "offline_access"), nil
+	}
+
+	return scopes, []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
+}
+
+func (a *app) handleLogin(w http.ResponseWriter, r *http.Request) {
+
+	scopes, opts := a.requestedScopes()
+	opts = append(opts, oidc.Nonce(a.nonce))
+	if a.codeVerifier != "" {
+		opts = append(opts, oauth2.S256ChallengeOption(a.codeVerifier))
+	}
+	authCodeURL := a.oauth2Config(scopes).AuthCodeURL(a.state, opts...)
+
 	http.Redirect(w, r, authCodeURL, http.StatusSeeOther)
 }
 
@@ -398,7 +524,16 @@ func (a *app) handleCallback(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("expected state %q got %q", a.state, state), http.StatusBadRequest)
 			return
 		}
-		token, err = oauth2Config.Exchange(ctx, code)
+		// the state is only valid for a single callback
+		if !a.stateConsumed.CompareAndSwap(false, true) {
+			http.Error(w, "login was already completed with this state", http.StatusBadRequest)
+			return
+		}
+		var opts []oauth2.AuthCodeOption
+		if a.codeVerifier != "" {
+			opts = append(opts, oauth2.VerifierOption(a.codeVerifier))
+		}
+		token, err = oauth2Config.Exchange(ctx, code, opts...)
 	case "POST":
 		// Form request from frontend to refresh a token.
 		refresh := r.FormValue("refresh_token")
@@ -432,6 +567,11 @@ func (a *app) handleCallback(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("failed to verify ID token: %v", err), http.StatusInternalServerError)
 		return
 	}
+	// refreshed id tokens are not bound to the nonce of the login
+	if r.Method == "GET" && idToken.Nonce != a.nonce {
+		http.Error(w, "nonce of the ID token does not match the login", http.StatusBadRequest)
+		return
+	}
 	var rawClaims json.RawMessage
 	err = idToken.Claims(&rawClaims)
 	if err != nil {
@@ -456,24 +596,14 @@ func (a *app) handleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if a.config.TokenHandler != nil {
-		err = a.config.TokenHandler(TokenInfo{
-			IDToken:      rawIDToken,
-			RefreshToken: token.RefreshToken,
-			TokenClaims:  claims,
-			IssuerConfig: IssuerConfig{
-				ClientID:     a.config.ClientID,
-				ClientSecret: a.config.ClientSecret,
-				IssuerURL:    a.config.IssuerURL,
-				IssuerCA:     a.config.IssuerRootCA,
-			},
-		})
+		err = a.config.TokenHandler(a.tokenInfo(rawIDToken, token.RefreshToken, claims))
 
 		if err != nil {
 			a.config.Log.Error("error handling token", "error", err)
 		}
 	}
 
-	renderToken(w, rawIDToken, token.RefreshToken, buff.Bytes(), a.config.SuccessMessage, a.config.Debug)
+	renderToken(w, a.config.SuccessPage, rawIDToken, token.RefreshToken, claims, buff.Bytes(), a.config.SuccessMessage, a.config.Debug)
 
 	a.config.Log.Debug("Login Succeeded", slog.String("username", claims.Username()))
 	a.config.Log.Debug("Login-Data", slog.String("token", rawIDToken), slog.String("Refresh Token", token.RefreshToken), slog.String("Claims", string(rawClaims)))
@@ -483,32 +613,70 @@ func (a *app) handleCallback(w http.ResponseWriter, r *http.Request) {
 	}()
 }
 
-// waits for the token to be generated
-func (a *app) waitShutdown() {
-	<-a.completeChan
+func (a *app) tokenInfo(rawIDToken, refreshToken string, claims Claims) TokenInfo {
+	return TokenInfo{
+		IDToken:      rawIDToken,
+		RefreshToken: refreshToken,
+		TokenClaims:  claims,
+		IssuerConfig: IssuerConfig{
+			Name:         a.config.IssuerName,
+			ClientID:     a.config.ClientID,
+			ClientSecret: a.config.ClientSecret,
+			IssuerURL:    a.config.IssuerURL,
+			IssuerCA:     a.config.IssuerRootCA,
+		},
+	}
 }
 
-// Opens the given url in the browser (OS-dependent).
-func openBrowser(url string) error {
+// opens the browser for the login or prints the login url if no browser can be opened
+func (a *app) openBrowser(env browserEnv) {
+	cmd, args, err := env.browserCommand(a.Listen)
+	if err == nil && a.config.NoBrowser {
+		err = errNoBrowser
+	}
+
+	if err != nil {
+		a.config.Log.Debug("not opening browser for authentication", "error", err)
 
-	var cmd string
-	var args []string
+		a.Consolef("Unable to open a browser, please point your browser to %s\n", a.Listen)
 
-	switch runtime.GOOS {
-	case "windows":
-		cmd = "cmd"
-		args = []string{"/c", "start"}
-	case "darwin":
-		cmd = "open"
-	default: // "linux", "freebsd", "openbsd", "netbsd"
-		cmd = "xdg-open"
+		if a.config.QRCode != nil && a.config.Console != nil {
+			err := a.config.QRCode(a.config.Console, a.Listen)
+			if err != nil {
+				a.config.Log.Error("rendering qr code", "error", err)
+			}
+		}
+
+		return
 	}
-	args = append(args, url)
-	err := exec.Command(cmd, args...).Start()
+
+	a.config.Log.Debug("Opening Browser for Authentication")
+
+	a.Consolef("Opening Browser for Authentication. If this does not work, please point your browser to %s\n", a.Listen)
+
+	err = exec.Command(cmd, args...).Start()
 	if err != nil {
-		return fmt.Errorf("error opening browser cmd:%s args:%s error: %w", cmd, args, err)
+		a.config.Log.Error("openBrowser", "error", fmt.Errorf("error opening browser cmd:%s args:%s error: %w", cmd, args, err))
 	}
-	return nil
+}
+
+// waits for the token to be generated, returns an error if the login did not complete within the login timeout
+func (a *app) waitShutdown() error {
+	timeout := a.loginTimeout()
+
+	select {
+	case <-a.completeChan:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("login was not completed within %s, aborting", timeout)
+	}
+}
+
+func (a *app) loginTimeout() time.Duration {
+	if a.config.LoginTimeout <= 0 {
+		return defaultLoginTimeout
+	}
+	return a.config.LoginTimeout
 }
 
 // KubeConfigHandlerOption func for specifying options
@@ -546,11 +714,16 @@ type updateKubeConfig struct {
 	userIDExtractor UserIDExtractor
 	//optional writer to print out messages
 	writer io.Writer
+	// optional exec credential plugin, which is written instead of the oidc auth-provider
+	exec *ExecConfig
+	// optional writer and file for the machine-readable login result
+	resultWriter io.Writer
+	resultFile   string
 }
 
 func (u *updateKubeConfig) updateKubeConfigFunc(tokenInfo TokenInfo) error {
 
-	filename, err := UpdateKubeConfigContext(u.kubeConfig, tokenInfo, u.userIDExtractor, u.contextName)
+	filename, err := updateKubeConfigContext(u.kubeConfig, tokenInfo, u.userIDExtractor, u.contextName, u.exec)
 	if err != nil {
 		return err
 	}
@@ -559,18 +732,34 @@ func (u *updateKubeConfig) updateKubeConfigFunc(tokenInfo TokenInfo) error {
 		fmt.Fprintf(u.writer, "Successfully written token to %s\n", filename)
 	}
 
-	return nil
+	return u.writeResult(newLoginResult(tokenInfo, filename, u.contextName))
 }
 
 func newRandomPortListener() (net.Listener, string, error) {
-	listener, err := net.Listen("tcp", ":0") //nolint:gosec
-	if err != nil {
-		return nil, "", err
+	return newCallbackListener(defaultCallbackHost, nil)
+}
+
+// newCallbackListener listens on the first free port of the given ports or on a random port if none are given.
+func newCallbackListener(host string, ports []int) (net.Listener, string, error) {
+	if len(ports) == 0 {
+		ports = []int{0}
+	}
+
+	var errs []error
+	for _, port := range ports {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port)) //nolint:gosec
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		port = listener.Addr().(*net.TCPAddr).Port
+		listenAddr := fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(port)))
+
+		return listener, listenAddr, nil
 	}
-	port := listener.Addr().(*net.TCPAddr).Port
-	listenAddr := fmt.Sprintf("http://localhost:%d", port)
 
-	return listener, listenAddr, nil
+	return nil, "", fmt.Errorf("unable to listen on any of the callback ports %v: %w", ports, errors.Join(errs...))
 }
 
 func fetchJSON(url string, data any) error {
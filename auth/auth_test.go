@@ -2,12 +2,23 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 )
 
 const testCloudContextName123 = "ctx123"
@@ -102,3 +113,162 @@ func Test_NewUpdateKubeConfigHandlerWithContext(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "123", authCtx.IDToken)
 }
+
+func TestCallbackStateAndNonce(t *testing.T) {
+	newApp := func(t *testing.T, idTokenNonce string, handled *int) *app {
+		srv := newTestProvider(t, 0, idTokenNonce)
+
+		a := &app{
+			config: Config{
+				IssuerURL:    srv.URL,
+				ClientID:     "cli",
+				ClientSecret: "secret",
+				Log:          slog.Default(),
+				TokenHandler: func(tokenInfo TokenInfo) error {
+					*handled++
+					return nil
+				},
+			},
+			state:        "state",
+			nonce:        "nonce",
+			completeChan: make(chan bool, 1),
+		}
+		require.NoError(t, a.initClient())
+		require.NoError(t, a.discoverProvider(oidc.ClientContext(context.Background(), a.client)))
+
+		return a
+	}
+
+	callback := func(a *app, state string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		a.handleCallback(w, httptest.NewRequest(http.MethodGet, "/callback?code=code&state="+state, nil))
+		return w
+	}
+
+	var handled int
+
+	a := newApp(t, "nonce", &handled)
+
+	w := callback(a, "other")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), `expected state "state" got "other"`)
+
+	w = callback(a, "state")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, handled)
+
+	w = callback(a, "state")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "login was already completed with this state")
+	require.Equal(t, 1, handled)
+
+	a = newApp(t, "replayed", &handled)
+
+	w = callback(a, "state")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "nonce of the ID token does not match the login")
+	require.Equal(t, 1, handled)
+}
+
+func TestPublicClientWithPKCE(t *testing.T) {
+	err := OIDCFlow(Config{
+		IssuerURL:    "https://dex:4711",
+		ClientID:     "123",
+		PublicClient: true,
+	})
+	require.EqualError(t, err, "error validating config: Log is required")
+
+	srv := newTestProvider(t, 0, "nonce")
+
+	var handled int
+	a := &app{
+		config: Config{
+			IssuerURL:    srv.URL,
+			ClientID:     "cli",
+			PublicClient: true,
+			Log:          slog.Default(),
+			TokenHandler: func(tokenInfo TokenInfo) error {
+				handled++
+				return nil
+			},
+		},
+		state:        "state",
+		nonce:        "nonce",
+		codeVerifier: "verifier",
+		completeChan: make(chan bool, 1),
+	}
+	require.NoError(t, a.initClient())
+	require.NoError(t, a.discoverProvider(oidc.ClientContext(context.Background(), a.client)))
+
+	w := httptest.NewRecorder()
+	a.handleLogin(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusSeeOther, w.Code)
+
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, oauth2.S256ChallengeFromVerifier("verifier"), location.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+
+	w = httptest.NewRecorder()
+	a.handleCallback(w, httptest.NewRequest(http.MethodGet, "/callback?code=pkce-code&state=state", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, 1, handled)
+}
+
+func TestConfig_RedirectURIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{
+			name:   "random port",
+			config: Config{},
+			want:   []string{"http://localhost/callback"},
+		},
+		{
+			name:   "fixed ports and host",
+			config: Config{CallbackHost: "127.0.0.1", CallbackPorts: []int{8000, 18000}},
+			want:   []string{"http://127.0.0.1:8000/callback", "http://127.0.0.1:18000/callback"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.config.RedirectURIs(), tt.want); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestCallbackListenerFallback(t *testing.T) {
+	occupied, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	free, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	freePort := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	occupiedPort := occupied.Addr().(*net.TCPAddr).Port
+
+	listener, listenAddr, err := newCallbackListener("127.0.0.1", []int{occupiedPort, freePort})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", freePort), listenAddr)
+
+	_, _, err = newCallbackListener("127.0.0.1", []int{occupiedPort})
+	require.ErrorContains(t, err, fmt.Sprintf("unable to listen on any of the callback ports [%d]", occupiedPort))
+}
+
+func TestLoginTimeout(t *testing.T) {
+	a := &app{
+		config:       Config{LoginTimeout: 10 * time.Millisecond},
+		completeChan: make(chan bool),
+	}
+
+	require.EqualError(t, a.waitShutdown(), "login was not completed within 10ms, aborting")
+}
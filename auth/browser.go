@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var errNoBrowser = errors.New("no browser available in this environment")
+
+// QRCodeRenderer renders the given url as qr code to the writer, e.g. by using a qr code library with terminal output.
+type QRCodeRenderer func(w io.Writer, url string) error
+
+// browserEnv abstracts the environment that is used to decide how a browser can be opened.
+type browserEnv struct {
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+	readFile func(string) ([]byte, error)
+}
+
+func defaultBrowserEnv() browserEnv {
+	return browserEnv{
+		goos:     runtime.GOOS,
+		getenv:   os.Getenv,
+		lookPath: exec.LookPath,
+		readFile: os.ReadFile,
+	}
+}
+
+// isWSL returns true when running inside the windows subsystem for linux.
+func (e browserEnv) isWSL() bool {
+	if e.goos != "linux" {
+		return false
+	}
+	if e.getenv("WSL_DISTRO_NAME") != "" || e.getenv("WSL_INTEROP") != "" {
+		return true
+	}
+
+	version, err := e.readFile("/proc/version")
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
+
+// isRemote returns true when running in a ssh session.
+func (e browserEnv) isRemote() bool {
+	return e.getenv("SSH_CONNECTION") != "" || e.getenv("SSH_CLIENT") != "" || e.getenv("SSH_TTY") != ""
+}
+
+func (e browserEnv) hasDisplay() bool {
+	return e.getenv("DISPLAY") != "" || e.getenv("WAYLAND_DISPLAY") != ""
+}
+
+// browserCommand returns the command for opening the given url in a browser or errNoBrowser if no browser
+// can be opened, e.g. in a ssh session without a forwarded display.
+func (e browserEnv) browserCommand(url string) (string, []string, error) {
+	if browser := e.getenv("BROWSER"); browser != "" {
+		return browser, []string{url}, nil
+	}
+
+	switch e.goos {
+	case "windows":
+		return "cmd", []string{"/c", "start", url}, nil
+	case "darwin":
+		if e.isRemote() {
+			return "", nil, errNoBrowser
+		}
+		return "open", []string{url}, nil
+	}
+
+	// "linux", "freebsd", "openbsd", "netbsd"
+	if e.isWSL() {
+		if _, err := e.lookPath("wslview"); err == nil {
+			return "wslview", []string{url}, nil
+		}
+		if _, err := e.lookPath("powershell.exe"); err == nil {
+			return "powershell.exe", []string{"-NoProfile", "-NonInteractive", "-Command", "Start-Process", fmt.Sprintf("'%s'", strings.ReplaceAll(url, "'", "''"))}, nil
+		}
+		return "", nil, errNoBrowser
+	}
+
+	if !e.hasDisplay() {
+		return "", nil, errNoBrowser
+	}
+
+	return "xdg-open", []string{url}, nil
+}
+
+// open opens the given url in the browser (OS-dependent).
+func (e browserEnv) open(url string) error {
+	cmd, args, err := e.browserCommand(url)
+	if err != nil {
+		return err
+	}
+
+	err = exec.Command(cmd, args...).Start()
+	if err != nil {
+		return fmt.Errorf("error opening browser cmd:%s args:%s error: %w", cmd, args, err)
+	}
+
+	return nil
+}
+
+// Opens the given url in the browser (OS-dependent).
+func openBrowser(url string) error {
+	return defaultBrowserEnv().open(url)
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserCommand(t *testing.T) {
+	const url = "http://localhost:4711"
+
+	tests := []struct {
+		name     string
+		goos     string
+		env      map[string]string
+		binaries []string
+		version  string
+		wantCmd  string
+		wantArgs []string
+		wantErr  error
+	}{
+		{
+			name:     "linux with display",
+			goos:     "linux",
+			env:      map[string]string{"DISPLAY": ":0"},
+			wantCmd:  "xdg-open",
+			wantArgs: []string{url},
+		},
+		{
+			name:    "linux via ssh without display",
+			goos:    "linux",
+			env:     map[string]string{"SSH_CONNECTION": "1.2.3.4 22 5.6.7.8 22"},
+			wantErr: errNoBrowser,
+		},
+		{
+			name:     "browser env takes precedence",
+			goos:     "linux",
+			env:      map[string]string{"BROWSER": "firefox"},
+			wantCmd:  "firefox",
+			wantArgs: []string{url},
+		},
+		{
+			name:     "wsl with wslview",
+			goos:     "linux",
+			env:      map[string]string{"WSL_DISTRO_NAME": "Ubuntu"},
+			binaries: []string{"wslview", "powershell.exe"},
+			wantCmd:  "wslview",
+			wantArgs: []string{url},
+		},
+		{
+			name:     "wsl detected by kernel version falls back to powershell",
+			goos:     "linux",
+			version:  "Linux version 5.15.90.1-microsoft-standard-WSL2",
+			binaries: []string{"powershell.exe"},
+			wantCmd:  "powershell.exe",
+			wantArgs: []string{"-NoProfile", "-NonInteractive", "-Command", "Start-Process", "'" + url + "'"},
+		},
+		{
+			name:    "wsl without launcher",
+			goos:    "linux",
+			env:     map[string]string{"WSL_INTEROP": "/run/WSL/1_interop", "DISPLAY": ":0"},
+			wantErr: errNoBrowser,
+		},
+		{
+			name:     "darwin",
+			goos:     "darwin",
+			wantCmd:  "open",
+			wantArgs: []string{url},
+		},
+		{
+			name:    "darwin via ssh",
+			goos:    "darwin",
+			env:     map[string]string{"SSH_TTY": "/dev/ttys001"},
+			wantErr: errNoBrowser,
+		},
+		{
+			name:     "windows",
+			goos:     "windows",
+			wantCmd:  "cmd",
+			wantArgs: []string{"/c", "start", url},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			env := browserEnv{
+				goos: tt.goos,
+				getenv: func(key string) string {
+					return tt.env[key]
+				},
+				lookPath: func(file string) (string, error) {
+					for _, b := range tt.binaries {
+						if b == file {
+							return "/usr/bin/" + file, nil
+						}
+					}
+					return "", fmt.Errorf("%s not found", file)
+				},
+				readFile: func(string) ([]byte, error) {
+					if tt.version == "" {
+						return nil, os.ErrNotExist
+					}
+					return []byte(tt.version), nil
+				},
+			}
+
+			gotCmd, gotArgs, err := env.browserCommand(url)
+			require.ErrorIs(t, err, tt.wantErr)
+
+			if diff := cmp.Diff(tt.wantCmd, gotCmd); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantArgs, gotArgs); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestOpenBrowserFallsBackToQRCode(t *testing.T) {
+	var console bytes.Buffer
+
+	a := &app{
+		Listen: "http://localhost:4711",
+		config: Config{
+			Log:     slog.Default(),
+			Console: &console,
+			QRCode: func(w io.Writer, url string) error {
+				_, err := fmt.Fprintf(w, "[qr %s]\n", url)
+				return err
+			},
+		},
+	}
+
+	a.openBrowser(browserEnv{
+		goos:     "linux",
+		getenv:   func(string) string { return "" },
+		lookPath: func(string) (string, error) { return "", errors.New("not found") },
+		readFile: func(string) ([]byte, error) { return nil, os.ErrNotExist },
+	})
+
+	require.Equal(t, "Unable to open a browser, please point your browser to http://localhost:4711\n[qr http://localhost:4711]\n", console.String())
+}
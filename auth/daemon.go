@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// DaemonConfig for the session keep-alive daemon, which is intended to run as a background process
+// like "<binary> auth daemon" during long running operations.
+type DaemonConfig struct {
+	// KubeConfig is the path to the kubeconfig holding the tokens, if empty the default location is used
+	KubeConfig string
+	// Contexts to keep alive, if empty all contexts with an oidc auth-provider and a refresh token are kept alive
+	Contexts []string
+
+	// RefreshBefore defines how long before the expiry of the id token it gets refreshed, defaults to 5 minutes
+	RefreshBefore time.Duration
+	// Interval in which the tokens are checked, defaults to 30 seconds
+	Interval time.Duration
+
+	// StatusListen is the local address of the status endpoint, e.g. "localhost:8765". No endpoint is served if empty.
+	StatusListen string
+
+	Log *slog.Logger
+}
+
+// ContextStatus is the keep-alive status of a context as returned by the status endpoint of the daemon.
+type ContextStatus struct {
+	Context     string     `json:"context"`
+	User        string     `json:"user"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastRefresh *time.Time `json:"last_refresh,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+type daemon struct {
+	config DaemonConfig
+
+	refresh func(ctx context.Context, authCtx AuthContext) (TokenInfo, error)
+
+	mu     sync.RWMutex
+	status map[string]ContextStatus
+}
+
+// RunDaemon refreshes the tokens of the configured contexts before they expire and writes them back into the kubeconfig
+// until the given context is canceled.
+func RunDaemon(ctx context.Context, config DaemonConfig) error {
+	d, err := newDaemon(config)
+	if err != nil {
+		return err
+	}
+
+	return d.run(ctx)
+}
+
+func newDaemon(config DaemonConfig) (*daemon, error) {
+	if config.Log == nil {
+		return nil, errors.New("error validating config: Log is required")
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = 5 * time.Minute
+	}
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+
+	return &daemon{
+		config:  config,
+		refresh: refreshToken,
+		status:  map[string]ContextStatus{},
+	}, nil
+}
+
+func (d *daemon) run(ctx context.Context) error {
+	if d.config.StatusListen != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/status", d.handleStatus)
+
+		srv := &http.Server{
+			Addr:              d.config.StatusListen,
+			Handler:           mux,
+			ReadHeaderTimeout: 1 * time.Minute,
+		}
+
+		go func() {
+			<-ctx.Done()
+			err := srv.Shutdown(context.Background())
+			if err != nil {
+				d.config.Log.Error("shutdown status endpoint", "error", err)
+			}
+		}()
+
+		go func() {
+			d.config.Log.Info("serving status endpoint", "addr", d.config.StatusListen)
+			err := srv.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				d.config.Log.Error("status endpoint", "error", err)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// check refreshes all tokens that expire within the configured refresh period
+func (d *daemon) check(ctx context.Context) {
+	contexts := d.config.Contexts
+	discovered := len(contexts) == 0
+
+	if discovered {
+		var err error
+		contexts, err = kubeConfigContextNames(d.config.KubeConfig)
+		if err != nil {
+			d.config.Log.Error("unable to read contexts from kubeconfig", "error", err)
+			return
+		}
+	}
+
+	for _, name := range contexts {
+		authCtx, err := GetAuthContext(d.config.KubeConfig, name)
+		if err != nil || authCtx.RefreshToken == "" {
+			if discovered {
+				continue
+			}
+			if err == nil {
+				err = errors.New("no refresh token present, please login again")
+			}
+			d.setStatus(ContextStatus{Context: name, Error: err.Error()})
+			continue
+		}
+
+		status := d.getStatus(name)
+		status.Context = name
+		status.User = authCtx.User
+		status.Error = ""
+
+		expiresAt, err := tokenExpiry(authCtx.IDToken)
+		if err != nil {
+			d.config.Log.Warn("unable to determine token expiry, refreshing token", "context", name, "error", err)
+		} else {
+			status.ExpiresAt = expiresAt
+			if time.Until(expiresAt) > d.config.RefreshBefore {
+				d.setStatus(status)
+				continue
+			}
+		}
+
+		tokenInfo, err := d.refresh(ctx, authCtx)
+		if err == nil {
+			err = updateKubeConfigUser(d.config.KubeConfig, tokenInfo, authCtx.User)
+		}
+		if err != nil {
+			d.config.Log.Error("unable to refresh token", "context", name, "error", err)
+			status.Error = err.Error()
+			d.setStatus(status)
+			continue
+		}
+
+		now := time.Now()
+		status.LastRefresh = &now
+		status.ExpiresAt = time.Unix(tokenInfo.TokenClaims.ExpiresAt, 0)
+		d.setStatus(status)
+
+		d.config.Log.Info("refreshed token", "context", name, "user", authCtx.User, "expires-at", status.ExpiresAt)
+	}
+}
+
+func (d *daemon) getStatus(name string) ContextStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status[name]
+}
+
+func (d *daemon) setStatus(status ContextStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status[status.Context] = status
+}
+
+func (d *daemon) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	d.mu.RLock()
+	result := make([]ContextStatus, 0, len(d.status))
+	for _, s := range d.status {
+		result = append(result, s)
+	}
+	d.mu.RUnlock()
+
+	slices.SortFunc(result, func(a, b ContextStatus) int {
+		return strings.Compare(a.Context, b.Context)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(result)
+	if err != nil {
+		d.config.Log.Error("unable to write status", "error", err)
+	}
+}
+
+// refreshToken uses the refresh token of the given context to obtain a new id token from the issuer
+func refreshToken(ctx context.Context, authCtx AuthContext) (TokenInfo, error) {
+	client := http.DefaultClient
+	if authCtx.IssuerCA != "" {
+		var err error
+		client, err = httpClientForRootCAs(authCtx.IssuerCA)
+		if err != nil {
+			return TokenInfo{}, err
+		}
+	}
+
+	clientCtx := oidc.ClientContext(ctx, client)
+
+	provider, err := oidc.NewProvider(clientCtx, authCtx.IssuerURL)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to query provider %q error: %w", authCtx.IssuerURL, err)
+	}
+
+	oauth2Config := &oauth2.Config{
+		ClientID:     authCtx.ClientID,
+		ClientSecret: authCtx.ClientSecret,
+		Endpoint:     clientEndpoint(provider, authCtx.ClientSecret),
+	}
+
+	token, err := oauth2Config.TokenSource(clientCtx, &oauth2.Token{
+		RefreshToken: authCtx.RefreshToken,
+		Expiry:       time.Now().Add(-time.Hour),
+	}).Token()
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return TokenInfo{}, errors.New("no id_token in token response")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: authCtx.ClientID}).Verify(clientCtx, rawIDToken)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+
+	var claims Claims
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return TokenInfo{}, fmt.Errorf("failed to parse claims: %w", err)
+	}
+
+	refresh := token.RefreshToken
+	if refresh == "" {
+		// not every issuer rotates the refresh token
+		refresh = authCtx.RefreshToken
+	}
+
+	return TokenInfo{
+		IDToken:      rawIDToken,
+		RefreshToken: refresh,
+		TokenClaims:  claims,
+		IssuerConfig: authCtx.IssuerConfig,
+	}, nil
+}
+
+func kubeConfigContextNames(kubeConfig string) ([]string, error) {
+	cfg, _, _, err := LoadKubeConfigFile(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts, err := cfg.Contexts()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, c := range contexts {
+		if c.Name != "" {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWT(t *testing.T, expiresAt time.Time) string {
+	payload, err := json.Marshal(Claims{ExpiresAt: expiresAt.Unix(), Issuer: "https://issuer"})
+	require.NoError(t, err)
+
+	return fmt.Sprintf("e30.%s.c2ln", base64.RawURLEncoding.EncodeToString(payload))
+}
+
+func TestDaemonCheck(t *testing.T) {
+	var (
+		kubeconfig = filepath.Join(t.TempDir(), "config")
+		now        = time.Now().Truncate(time.Second)
+		expiring   = now.Add(time.Minute)
+		valid      = now.Add(time.Hour)
+		refreshed  = now.Add(2 * time.Hour)
+	)
+
+	for name, token := range map[string]TokenInfo{
+		"expiring": {IDToken: testJWT(t, expiring), RefreshToken: "refresh-1", TokenClaims: Claims{Name: "a", Issuer: "https://issuer"}},
+		"valid":    {IDToken: testJWT(t, valid), RefreshToken: "refresh-2", TokenClaims: Claims{Name: "b", Issuer: "https://issuer"}},
+		"no-token": {IDToken: testJWT(t, expiring), TokenClaims: Claims{Name: "c", Issuer: "https://issuer"}},
+	} {
+		_, err := UpdateKubeConfigContext(kubeconfig, token, ExtractName, name)
+		require.NoError(t, err)
+	}
+
+	var refreshedContexts []string
+
+	d, err := newDaemon(DaemonConfig{
+		KubeConfig: kubeconfig,
+		Log:        slog.Default(),
+	})
+	require.NoError(t, err)
+
+	d.refresh = func(_ context.Context, authCtx AuthContext) (TokenInfo, error) {
+		refreshedContexts = append(refreshedContexts, authCtx.Ctx)
+		return TokenInfo{
+			IDToken:      testJWT(t, refreshed),
+			RefreshToken: authCtx.RefreshToken + "-rotated",
+			TokenClaims:  Claims{ExpiresAt: refreshed.Unix(), Issuer: authCtx.IssuerURL},
+			IssuerConfig: authCtx.IssuerConfig,
+		}, nil
+	}
+
+	d.check(context.Background())
+
+	require.Equal(t, []string{"expiring"}, refreshedContexts)
+
+	authCtx, err := GetAuthContext(kubeconfig, "expiring")
+	require.NoError(t, err)
+	require.Equal(t, "a", authCtx.User)
+	require.Equal(t, "refresh-1-rotated", authCtx.RefreshToken)
+	require.Equal(t, testJWT(t, refreshed), authCtx.IDToken)
+
+	w := httptest.NewRecorder()
+	d.handleStatus(w, httptest.NewRequest("GET", "/status", nil))
+
+	var got []ContextStatus
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	want := []ContextStatus{
+		{Context: "expiring", User: "a", ExpiresAt: refreshed},
+		{Context: "valid", User: "b", ExpiresAt: valid},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(ContextStatus{}, "LastRefresh"), cmpopts.EquateApproxTime(0)); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+	require.NotNil(t, got[0].LastRefresh)
+	require.Nil(t, got[1].LastRefresh)
+}
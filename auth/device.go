@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// DeviceCodeFlow validates the given config and starts the OAuth2 device authorization grant
+// (see https://datatracker.ietf.org/doc/html/rfc8628).
+//
+// In contrast to the OIDCFlow, no browser and no local webserver are required on this host, which allows logging in
+// from ssh sessions and ci environments. The login is completed on any other device, so the ClientSecret is optional
+// for this flow as device clients are usually public clients.
+//
+// 1. OpenID Discovery --> gather info about OIDC Provider, which needs to provide a device authorization endpoint
+// 2. request a device code --> print verification url and user code to the console (and the qr code, if configured)
+// 3. poll the token endpoint until the user completed the login on the other device, the device code expired or the login timeout is reached
+// 4. call TokenHandler
+func DeviceCodeFlow(config Config) error {
+	err := validateConfig(config, false)
+	if err != nil {
+		return err
+	}
+
+	appModel := &app{
+		config: config,
+	}
+
+	return deviceCodeFlow(context.Background(), appModel)
+}
+
+func deviceCodeFlow(ctx context.Context, appModel *app) error {
+	err := appModel.initClient()
+	if err != nil {
+		return err
+	}
+
+	timeout := appModel.loginTimeout()
+
+	ctx, cancel := context.WithTimeout(oidc.ClientContext(ctx, appModel.client), timeout)
+	defer cancel()
+
+	err = appModel.discoverProvider(ctx)
+	if err != nil {
+		return err
+	}
+
+	if appModel.provider.Endpoint().DeviceAuthURL == "" {
+		return fmt.Errorf("provider %q does not support the device authorization grant", appModel.config.IssuerURL)
+	}
+
+	scopes, opts := appModel.requestedScopes()
+	oauth2Config := appModel.oauth2Config(scopes)
+
+	deviceAuth, err := oauth2Config.DeviceAuth(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to request device code: %w", err)
+	}
+
+	appModel.printDeviceCode(deviceAuth)
+
+	token, err := oauth2Config.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("login was not completed within %s, aborting", timeout)
+		}
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return errors.New("no id_token in token response")
+	}
+
+	claims, rawClaims, err := appModel.verifyIDToken(ctx, rawIDToken)
+	if err != nil {
+		return err
+	}
+
+	err = appModel.config.TokenHandler(appModel.tokenInfo(rawIDToken, token.RefreshToken, claims))
+	if err != nil {
+		return fmt.Errorf("error handling token: %w", err)
+	}
+
+	appModel.Consolef("Login succeeded.\n")
+
+	appModel.config.Log.Debug("Login Succeeded", slog.String("username", claims.Username()))
+	appModel.config.Log.Debug("Login-Data", slog.String("token", rawIDToken), slog.String("Refresh Token", token.RefreshToken), slog.String("Claims", string(rawClaims)))
+
+	return nil
+}
+
+// prints the verification url and the user code, which have to be entered on another device
+func (a *app) printDeviceCode(deviceAuth *oauth2.DeviceAuthResponse) {
+	a.Consolef("To log in, point a browser on any device to %s and enter the code %s\n", deviceAuth.VerificationURI, deviceAuth.UserCode)
+
+	if a.config.QRCode == nil || a.config.Console == nil {
+		return
+	}
+
+	url := deviceAuth.VerificationURIComplete
+	if url == "" {
+		url = deviceAuth.VerificationURI
+	}
+
+	err := a.config.QRCode(a.config.Console, url)
+	if err != nil {
+		a.config.Log.Error("rendering qr code", "error", err)
+	}
+}
+
+// verifies the id token and returns its claims
+func (a *app) verifyIDToken(ctx context.Context, rawIDToken string) (Claims, json.RawMessage, error) {
+	idToken, err := a.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Claims{}, nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+
+	var rawClaims json.RawMessage
+	err = idToken.Claims(&rawClaims)
+	if err != nil {
+		return Claims{}, nil, fmt.Errorf("failed to parse claims: %w", err)
+	}
+
+	var claims Claims
+	err = json.Unmarshal(rawClaims, &claims)
+	if err != nil {
+		return Claims{}, nil, fmt.Errorf("failed to read claims: %w", err)
+	}
+
+	return claims, rawClaims, nil
+}
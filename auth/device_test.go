@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider returns a fake oidc provider supporting the authorization code grant for the code "code" and the
+// device authorization grant, which reports the authorization as pending for the given amount of polls.
+// The issued id tokens contain the given nonce.
+func newTestProvider(t *testing.T, pending int32, nonce string) *httptest.Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"))
+	require.NoError(t, err)
+
+	var (
+		polls atomic.Int32
+		mux   = http.NewServeMux()
+		srv   = httptest.NewServer(mux)
+	)
+
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                srv.URL,
+			"authorization_endpoint":                srv.URL + "/auth",
+			"device_authorization_endpoint":         srv.URL + "/device/code",
+			"token_endpoint":                        srv.URL + "/token",
+			"jwks_uri":                              srv.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/device/code", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"device_code":               "device-code",
+			"user_code":                 "ABCD-EFGH",
+			"verification_uri":          srv.URL + "/device",
+			"verification_uri_complete": srv.URL + "/device?user_code=ABCD-EFGH",
+			"expires_in":                60,
+			"interval":                  1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("grant_type") {
+		case "authorization_code":
+			switch r.FormValue("code") {
+			case "code":
+			case "pkce-code":
+				// the code was requested with the challenge of the verifier "verifier"
+				if r.FormValue("code_verifier") != "verifier" || r.FormValue("client_id") != "cli" {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+					return
+				}
+			default:
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			if r.FormValue("device_code") != "device-code" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			if polls.Add(1) <= pending {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+				return
+			}
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+			return
+		}
+
+		idToken, err := jwt.Signed(signer).Claims(map[string]any{
+			"iss":   srv.URL,
+			"sub":   "achim",
+			"aud":   "cli",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"name":  "achim",
+			"email": "achim@metal-stack.io",
+			"nonce": nonce,
+		}).Serialize()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token":  "access-token",
+			"token_type":    "bearer",
+			"refresh_token": "refresh-token",
+			"expires_in":    3600,
+			"id_token":      idToken,
+		})
+	})
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestDeviceCodeFlow(t *testing.T) {
+	srv := newTestProvider(t, 1, "")
+
+	var (
+		console bytes.Buffer
+		got     TokenInfo
+	)
+
+	err := DeviceCodeFlow(Config{
+		IssuerURL: srv.URL,
+		ClientID:  "cli",
+		Log:       slog.Default(),
+		Console:   &console,
+		QRCode: func(w io.Writer, url string) error {
+			_, err := fmt.Fprintf(w, "[qr %s]\n", url)
+			return err
+		},
+		TokenHandler: func(tokenInfo TokenInfo) error {
+			got = tokenInfo
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "refresh-token", got.RefreshToken)
+	require.Equal(t, "achim", got.TokenClaims.Username())
+	require.Equal(t, "achim@metal-stack.io", got.TokenClaims.EMail)
+	require.Equal(t, IssuerConfig{ClientID: "cli", IssuerURL: srv.URL}, got.IssuerConfig)
+
+	want := fmt.Sprintf("To log in, point a browser on any device to %[1]s/device and enter the code ABCD-EFGH\n[qr %[1]s/device?user_code=ABCD-EFGH]\nLogin succeeded.\n", srv.URL)
+	require.Equal(t, want, console.String())
+}
+
+func TestDeviceCodeFlowTokenHandlerError(t *testing.T) {
+	srv := newTestProvider(t, 0, "")
+
+	err := deviceCodeFlow(context.Background(), &app{
+		config: Config{
+			IssuerURL: srv.URL,
+			ClientID:  "cli",
+			Log:       slog.Default(),
+			TokenHandler: func(tokenInfo TokenInfo) error {
+				return fmt.Errorf("unable to write kubeconfig")
+			},
+		},
+	})
+	require.EqualError(t, err, "error handling token: unable to write kubeconfig")
+}
+
+func TestDeviceCodeFlowValidation(t *testing.T) {
+	err := DeviceCodeFlow(Config{
+		IssuerURL: "https://dex:4711",
+		ClientID:  "123",
+		Log:       slog.Default(),
+	})
+	require.EqualError(t, err, "error validating config: TokenHandler is required")
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+	// oidcTokensExtension is the name of the user extension in the kubeconfig, which stores the tokens of exec users
+	oidcTokensExtension = "oidc.metal-stack.io"
+	// execRefreshBefore is the remaining validity of the id token below which it is refreshed before printing the ExecCredential
+	execRefreshBefore = time.Minute
+)
+
+// ExecConfig configures a kubeconfig user, which obtains its token with the exec credential plugin mechanism of kubectl
+// instead of the deprecated oidc auth-provider (see https://kubernetes.io/docs/reference/access-authn-authz/authentication/#client-go-credential-plugins).
+//
+// kubectl invokes the command, which is expected to print the ExecCredential of the stored tokens, see PrintExecCredential.
+type ExecConfig struct {
+	// Command is the executable invoked by kubectl, e.g. "metalctl"
+	Command string
+	// Args are passed to the command, e.g. ["print-access-token", "--context", "cloudctl"]
+	Args []string
+	// Env are additional environment variables for the command
+	Env map[string]string
+	// InstallHint is shown by kubectl if the command cannot be found
+	InstallHint string
+}
+
+// WithExecCredential writes an exec user instead of an auth-provider user into the kubeconfig
+func WithExecCredential(exec ExecConfig) KubeConfigHandlerOption {
+	return func(c *updateKubeConfig) {
+		c.exec = &exec
+	}
+}
+
+// UpdateKubeConfigContextExec saves the given tokenInfo in the given kubeConfig like UpdateKubeConfigContext,
+// but the user obtains its token with the exec credential plugin configured by exec.
+//
+// returns filename the config got written to or error if any
+func UpdateKubeConfigContextExec(kubeConfig string, tokenInfo TokenInfo, userIDExtractor UserIDExtractor, contextName string, exec ExecConfig) (string, error) {
+	if exec.Command == "" {
+		return "", fmt.Errorf("exec command must not be empty")
+	}
+
+	return updateKubeConfigContext(kubeConfig, tokenInfo, userIDExtractor, contextName, &exec)
+}
+
+// AddExecUser adds the given exec user to the kubecfg or replaces an already existing user,
+// the given user-auth-configMap is stored in an extension of the user
+func AddExecUser(kubecfg map[interface{}]interface{}, userName string, exec ExecConfig, configMap map[string]string) error {
+	user := NamedUser{
+		Name: userName,
+		User: User{
+			Exec: newExecUser(exec),
+		},
+	}
+
+	err := setExecTokenConfig(&user.User, configMap)
+	if err != nil {
+		return err
+	}
+
+	raw, err := yaml.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	userMap := map[string]interface{}{}
+	err = yaml.Unmarshal(raw, userMap)
+	if err != nil {
+		return err
+	}
+
+	return addUser(kubecfg, userMap)
+}
+
+func newExecUser(exec ExecConfig) *ExecUser {
+	e := &ExecUser{
+		APIVersion:      execCredentialAPIVersion,
+		Command:         exec.Command,
+		Args:            exec.Args,
+		InstallHint:     exec.InstallHint,
+		InteractiveMode: "Never",
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(exec.Env)) {
+		e.Env = append(e.Env, ExecEnvVar{Name: name, Value: exec.Env[name]})
+	}
+
+	return e
+}
+
+// setExecTokenConfig stores the given user-auth-configMap in the extension of the given exec user
+func setExecTokenConfig(user *User, configMap map[string]string) error {
+	var extension yaml.Node
+	err := extension.Encode(configMap)
+	if err != nil {
+		return err
+	}
+
+	for i := range user.Extensions {
+		if user.Extensions[i].Name == oidcTokensExtension {
+			user.Extensions[i].Extension = extension
+			return nil
+		}
+	}
+
+	user.Extensions = append(user.Extensions, NamedExtension{
+		Name:      oidcTokensExtension,
+		Extension: extension,
+	})
+
+	return nil
+}
+
+// execTokenConfig returns the user-auth-configMap stored in the extension of the given exec user
+func execTokenConfig(user User) (map[string]string, error) {
+	for _, e := range user.Extensions {
+		if e.Name != oidcTokensExtension {
+			continue
+		}
+
+		var configMap map[string]string
+		err := e.Extension.Decode(&configMap)
+		if err != nil {
+			return nil, fmt.Errorf("exec user contains invalid stored tokens: %w", err)
+		}
+
+		return configMap, nil
+	}
+
+	return nil, fmt.Errorf("exec user contains no extension %q with stored tokens", oidcTokensExtension)
+}
+
+// ExecCredential returns the ExecCredential for the given id token in json format, the expiry is taken from the token.
+func ExecCredential(idToken string) ([]byte, error) {
+	status := map[string]any{
+		"token": idToken,
+	}
+
+	expiresAt, err := tokenExpiry(idToken)
+	if err == nil {
+		status["expirationTimestamp"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return json.Marshal(map[string]any{
+		"apiVersion": execCredentialAPIVersion,
+		"kind":       "ExecCredential",
+		"status":     status,
+	})
+}
+
+// PrintExecCredential prints the ExecCredential of the tokens stored for the given context of the kubeconfig, which is intended
+// to be called by the command of an exec user. An id token that is about to expire is refreshed first, if a refresh token is stored.
+func PrintExecCredential(w io.Writer, kubeConfig string, contextName string) error {
+	authCtx, err := GetAuthContext(kubeConfig, contextName)
+	if err != nil {
+		return err
+	}
+
+	expiresAt, err := tokenExpiry(authCtx.IDToken)
+	if authCtx.RefreshToken != "" && (err != nil || time.Until(expiresAt) < execRefreshBefore) {
+		tokenInfo, err := refreshToken(context.Background(), authCtx)
+		if err != nil {
+			return err
+		}
+
+		err = updateKubeConfigUser(kubeConfig, tokenInfo, authCtx.User)
+		if err != nil {
+			return err
+		}
+
+		authCtx.IDToken = tokenInfo.IDToken
+	}
+
+	credential, err := ExecCredential(authCtx.IDToken)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", credential)
+	return err
+}
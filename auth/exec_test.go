@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+
+	"github.com/icza/dyno"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateKubeConfigContextExec(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+
+	tokenInfo := demoToken
+	tokenInfo.RefreshToken = ""
+
+	_, err := UpdateKubeConfigContextExec(kubeconfig, tokenInfo, ExtractName, testCloudContextName, ExecConfig{
+		Command:     "metalctl",
+		Args:        []string{"print-access-token"},
+		Env:         map[string]string{"METALCTL_CONTEXT": "prod"},
+		InstallHint: "install metalctl",
+	})
+	require.NoError(t, err)
+
+	cfg, _, _, err := LoadKubeConfig(kubeconfig)
+	require.NoError(t, err)
+
+	user, _, err := findMapListMap(cfg, "users", "name", "user001")
+	require.NoError(t, err)
+
+	_, err = dyno.Get(user, "user", "auth-provider")
+	require.Error(t, err, "exec users must not contain an auth-provider")
+
+	command, err := dyno.GetString(user, "user", "exec", "command")
+	require.NoError(t, err)
+	require.Equal(t, "metalctl", command)
+
+	apiVersion, err := dyno.GetString(user, "user", "exec", "apiVersion")
+	require.NoError(t, err)
+	require.Equal(t, "client.authentication.k8s.io/v1", apiVersion)
+
+	authCtx, err := GetAuthContext(kubeconfig, testCloudContextName)
+	require.NoError(t, err)
+	require.True(t, authCtx.Exec)
+	require.True(t, authCtx.AuthProviderOidc)
+	require.Equal(t, demoToken.IDToken, authCtx.IDToken)
+	require.Equal(t, demoToken.ClientID, authCtx.ClientID)
+	require.Equal(t, demoToken.ClientSecret, authCtx.ClientSecret)
+
+	// refreshed tokens keep the exec user
+	refreshed := tokenInfo
+	refreshed.IDToken = "refreshed"
+	require.NoError(t, updateKubeConfigUser(kubeconfig, refreshed, "user001"))
+
+	authCtx, err = GetAuthContext(kubeconfig, testCloudContextName)
+	require.NoError(t, err)
+	require.True(t, authCtx.Exec)
+	require.Equal(t, "refreshed", authCtx.IDToken)
+
+	var out bytes.Buffer
+	require.NoError(t, PrintExecCredential(&out, kubeconfig, testCloudContextName))
+	require.JSONEq(t, `{"apiVersion":"client.authentication.k8s.io/v1","kind":"ExecCredential","status":{"token":"refreshed"}}`, out.String())
+
+	_, err = UpdateKubeConfigContextExec(kubeconfig, tokenInfo, ExtractName, testCloudContextName, ExecConfig{})
+	require.EqualError(t, err, "exec command must not be empty")
+}
+
+func TestExecCredential(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1700000000}`))
+	idToken := "eyJhbGciOiJub25lIn0." + payload + ".sig"
+
+	got, err := ExecCredential(idToken)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"apiVersion": "client.authentication.k8s.io/v1",
+		"kind": "ExecCredential",
+		"status": {
+			"token": "`+idToken+`",
+			"expirationTimestamp": "2023-11-14T22:13:20Z"
+		}
+	}`, string(got))
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Issuer is one of the issuers of a MultiIssuerConfig, e.g. the issuer of a metal-stack partition.
+type Issuer struct {
+	// IssuerConfig of the issuer, the Name is required and must be unique
+	IssuerConfig
+	// Scopes requested from this issuer, defaults to the Scopes of the MultiIssuerConfig
+	Scopes []string
+}
+
+// MultiIssuerConfig holds several issuers of which one is selected for a login, such that clis with several backends
+// can use the same login code path for all of them.
+type MultiIssuerConfig struct {
+	// Config is shared by all issuers, the issuer and client settings are taken from the selected issuer
+	Config
+	// Issuers to select from
+	Issuers []Issuer
+	// Default is the name of the issuer that is selected if no issuer is given, optional if there is only one issuer
+	Default string
+	// Contexts maps the names of contexts, e.g. kubeconfig contexts or the contexts of a cli, to the name of their issuer
+	Contexts map[string]string
+}
+
+// IssuerNames returns the names of all issuers in the order of their configuration.
+func (c MultiIssuerConfig) IssuerNames() []string {
+	names := make([]string, 0, len(c.Issuers))
+	for _, issuer := range c.Issuers {
+		names = append(names, issuer.Name)
+	}
+	return names
+}
+
+// Select returns the config for the login with the issuer of the given name.
+// If the name is empty, the default issuer is selected.
+func (c MultiIssuerConfig) Select(name string) (Config, error) {
+	if err := c.validate(); err != nil {
+		return Config{}, err
+	}
+
+	if name == "" {
+		name = c.defaultIssuer()
+	}
+	if name == "" {
+		return Config{}, fmt.Errorf("no issuer selected and no default issuer configured, available issuers: %s", strings.Join(c.IssuerNames(), ", "))
+	}
+
+	idx := slices.IndexFunc(c.Issuers, func(issuer Issuer) bool { return issuer.Name == name })
+	if idx < 0 {
+		return Config{}, fmt.Errorf("issuer %q is not configured, available issuers: %s", name, strings.Join(c.IssuerNames(), ", "))
+	}
+
+	issuer := c.Issuers[idx]
+
+	config := c.Config
+	config.IssuerName = issuer.Name
+	config.IssuerURL = issuer.IssuerURL
+	config.IssuerRootCA = issuer.IssuerCA
+	config.ClientID = issuer.ClientID
+	config.ClientSecret = issuer.ClientSecret
+	if len(issuer.Scopes) > 0 {
+		config.Scopes = issuer.Scopes
+	}
+
+	return config, nil
+}
+
+// SelectForContext returns the config for the login with the issuer of the given context.
+// Contexts without an issuer use the default issuer.
+func (c MultiIssuerConfig) SelectForContext(contextName string) (Config, error) {
+	return c.Select(c.Contexts[contextName])
+}
+
+func (c MultiIssuerConfig) defaultIssuer() string {
+	if c.Default != "" {
+		return c.Default
+	}
+	if len(c.Issuers) == 1 {
+		return c.Issuers[0].Name
+	}
+	return ""
+}
+
+func (c MultiIssuerConfig) validate() error {
+	if len(c.Issuers) == 0 {
+		return errors.New("error validating config: at least one issuer is required")
+	}
+
+	seen := map[string]bool{}
+	for _, issuer := range c.Issuers {
+		if issuer.Name == "" {
+			return errors.New("error validating config: the name of an issuer is required")
+		}
+		if seen[issuer.Name] {
+			return fmt.Errorf("error validating config: issuer %q is configured more than once", issuer.Name)
+		}
+		seen[issuer.Name] = true
+	}
+
+	return nil
+}
+
+// MultiIssuerOIDCFlow starts the OIDCFlow against the issuer of the given name, the default issuer is used if the name is empty.
+// The IssuerConfig in the TokenInfo carries the name of the selected issuer.
+func MultiIssuerOIDCFlow(config MultiIssuerConfig, issuer string) error {
+	c, err := config.Select(issuer)
+	if err != nil {
+		return err
+	}
+
+	return OIDCFlow(c)
+}
+
+// MultiIssuerDeviceCodeFlow starts the DeviceCodeFlow against the issuer of the given name, the default issuer is used if the name is empty.
+// The IssuerConfig in the TokenInfo carries the name of the selected issuer.
+func MultiIssuerDeviceCodeFlow(config MultiIssuerConfig, issuer string) error {
+	c, err := config.Select(issuer)
+	if err != nil {
+		return err
+	}
+
+	return DeviceCodeFlow(c)
+}
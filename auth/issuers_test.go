@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiIssuerConfigSelect(t *testing.T) {
+	issuers := []Issuer{
+		{
+			IssuerConfig: IssuerConfig{Name: "fra", IssuerURL: "https://dex.fra", ClientID: "cli-fra", ClientSecret: "secret", IssuerCA: "ca"},
+		},
+		{
+			IssuerConfig: IssuerConfig{Name: "nbg", IssuerURL: "https://keycloak.nbg", ClientID: "cli-nbg"},
+			Scopes:       GenericScopes,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		config  MultiIssuerConfig
+		issuer  string
+		context string
+		want    Config
+		wantErr error
+	}{
+		{
+			name:   "select by name",
+			config: MultiIssuerConfig{Config: Config{Scopes: DexScopes, PKCE: true}, Issuers: issuers},
+			issuer: "fra",
+			want:   Config{IssuerName: "fra", IssuerURL: "https://dex.fra", IssuerRootCA: "ca", ClientID: "cli-fra", ClientSecret: "secret", Scopes: DexScopes, PKCE: true},
+		},
+		{
+			name:   "issuer scopes take precedence",
+			config: MultiIssuerConfig{Config: Config{Scopes: DexScopes}, Issuers: issuers},
+			issuer: "nbg",
+			want:   Config{IssuerName: "nbg", IssuerURL: "https://keycloak.nbg", ClientID: "cli-nbg", Scopes: GenericScopes},
+		},
+		{
+			name:   "default issuer",
+			config: MultiIssuerConfig{Issuers: issuers, Default: "nbg"},
+			want:   Config{IssuerName: "nbg", IssuerURL: "https://keycloak.nbg", ClientID: "cli-nbg", Scopes: GenericScopes},
+		},
+		{
+			name:   "single issuer is the default",
+			config: MultiIssuerConfig{Issuers: issuers[:1]},
+			want:   Config{IssuerName: "fra", IssuerURL: "https://dex.fra", IssuerRootCA: "ca", ClientID: "cli-fra", ClientSecret: "secret"},
+		},
+		{
+			name:    "issuer of context",
+			config:  MultiIssuerConfig{Issuers: issuers, Default: "fra", Contexts: map[string]string{"prod": "nbg"}},
+			context: "prod",
+			want:    Config{IssuerName: "nbg", IssuerURL: "https://keycloak.nbg", ClientID: "cli-nbg", Scopes: GenericScopes},
+		},
+		{
+			name:    "context without issuer uses default",
+			config:  MultiIssuerConfig{Issuers: issuers, Default: "fra", Contexts: map[string]string{"prod": "nbg"}},
+			context: "dev",
+			want:    Config{IssuerName: "fra", IssuerURL: "https://dex.fra", IssuerRootCA: "ca", ClientID: "cli-fra", ClientSecret: "secret"},
+		},
+		{
+			name:    "no default",
+			config:  MultiIssuerConfig{Issuers: issuers},
+			wantErr: errors.New("no issuer selected and no default issuer configured, available issuers: fra, nbg"),
+		},
+		{
+			name:    "unknown issuer",
+			config:  MultiIssuerConfig{Issuers: issuers},
+			issuer:  "muc",
+			wantErr: errors.New(`issuer "muc" is not configured, available issuers: fra, nbg`),
+		},
+		{
+			name:    "no issuers",
+			config:  MultiIssuerConfig{},
+			wantErr: errors.New("error validating config: at least one issuer is required"),
+		},
+		{
+			name:    "duplicate issuers",
+			config:  MultiIssuerConfig{Issuers: []Issuer{issuers[0], issuers[0]}},
+			issuer:  "fra",
+			wantErr: errors.New(`error validating config: issuer "fra" is configured more than once`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got Config
+				err error
+			)
+			if tt.context != "" {
+				got, err = tt.config.SelectForContext(tt.context)
+			} else {
+				got, err = tt.config.Select(tt.issuer)
+			}
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestMultiIssuerDeviceCodeFlow(t *testing.T) {
+	fra := newTestProvider(t, 0, "")
+	nbg := newTestProvider(t, 0, "")
+
+	var got TokenInfo
+
+	err := MultiIssuerDeviceCodeFlow(MultiIssuerConfig{
+		Config: Config{
+			Log: slog.Default(),
+			TokenHandler: func(tokenInfo TokenInfo) error {
+				got = tokenInfo
+				return nil
+			},
+		},
+		Issuers: []Issuer{
+			{IssuerConfig: IssuerConfig{Name: "fra", IssuerURL: fra.URL, ClientID: "cli"}},
+			{IssuerConfig: IssuerConfig{Name: "nbg", IssuerURL: nbg.URL, ClientID: "cli"}},
+		},
+	}, "nbg")
+	require.NoError(t, err)
+
+	require.Equal(t, IssuerConfig{Name: "nbg", ClientID: "cli", IssuerURL: nbg.URL}, got.IssuerConfig)
+}
@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 
 	"github.com/icza/dyno"
 	"gopkg.in/yaml.v3"
@@ -17,8 +17,9 @@ import (
 // So we tried to be generic and structure agnostic, so that we can read a config,
 // modify just the parts we need and write it back and do not loose anything,
 // we don't want to know and care about.
-// It would be much easier to un/marshall structs, but then we would be in danger
-// of loosing fields that we don't have in our version.
+// The KubeConfig model therefore keeps the yaml document and only un/marshalls
+// the entries we modify into structs, which pass through fields that we don't
+// have in our version. The map based functions remain for compatibility.
 //
 
 const (
@@ -57,50 +58,54 @@ func UpdateKubeConfig(kubeConfig string, tokenInfo TokenInfo, userIDExtractor Us
 //
 // returns filename the config got written to or error if any
 func UpdateKubeConfigContext(kubeConfig string, tokenInfo TokenInfo, userIDExtractor UserIDExtractor, contextName string) (string, error) {
+	return updateKubeConfigContext(kubeConfig, tokenInfo, userIDExtractor, contextName, nil)
+}
 
+func updateKubeConfigContext(kubeConfig string, tokenInfo TokenInfo, userIDExtractor UserIDExtractor, contextName string, exec *ExecConfig) (string, error) {
 	if userIDExtractor == nil {
 		return "", errors.New("userIdExtractor must not be nil")
 	}
 
-	cfg, outputFilename, isDefault, err := LoadKubeConfig(kubeConfig)
+	cfg, outputFilename, isDefault, err := LoadKubeConfigFile(kubeConfig)
 	if err != nil {
 		// file does not exist, we create it from scratch
 		outputFilename = kubeConfig
-		err = CreateFromTemplate(&cfg)
-		if err != nil {
-			return "", err
-		}
-	}
-
-	usersSlice, err := dyno.GetSlice(cfg, "users")
-	if err != nil {
-		usersSlice = make([]interface{}, 0)
+		cfg = NewKubeConfig()
 	}
-	cfg["users"] = usersSlice
 
 	userName := userIDExtractor(tokenInfo)
 
-	config := map[string]string{
-		"client-id":                 tokenInfo.ClientID,
-		"client-secret":             tokenInfo.ClientSecret,
-		"id-token":                  tokenInfo.IDToken,
-		"refresh-token":             tokenInfo.RefreshToken,
-		"idp-issuer-url":            tokenInfo.TokenClaims.Issuer,
-		"idp-certificate-authority": tokenInfo.IssuerCA,
+	user := NamedUser{
+		Name: userName,
+	}
+	if exec != nil {
+		user.User.Exec = newExecUser(*exec)
+		err = setExecTokenConfig(&user.User, tokenInfoConfigMap(tokenInfo))
+		if err != nil {
+			return "", err
+		}
+	} else {
+		user.User.AuthProvider = &AuthProvider{
+			Name:   oidcAuthProvider,
+			Config: tokenInfoConfigMap(tokenInfo),
+		}
 	}
 
-	err = AddUserConfigMap(cfg, userName, config)
+	err = cfg.SetUser(user)
 	if err != nil {
 		return "", err
 	}
 
-	err = AddContext(cfg, contextName, "", userName)
-	if err != nil {
-		return "", err
+	// an existing context only gets its user replaced
+	context := NamedContext{
+		Name: contextName,
+	}
+	if existing, err := cfg.Context(contextName); err == nil {
+		context = *existing
 	}
+	context.Context.User = userName
 
-	// use configured yaml
-	yamlBytes, err := EncodeKubeconfig(cfg)
+	err = cfg.SetContext(context)
 	if err != nil {
 		return "", err
 	}
@@ -113,7 +118,7 @@ func UpdateKubeConfigContext(kubeConfig string, tokenInfo TokenInfo, userIDExtra
 		}
 	}
 
-	err = os.WriteFile(outputFilename, yamlBytes.Bytes(), 0600)
+	err = cfg.Save(outputFilename)
 	if err != nil {
 		return "", err
 	}
@@ -121,6 +126,51 @@ func UpdateKubeConfigContext(kubeConfig string, tokenInfo TokenInfo, userIDExtra
 	return outputFilename, nil
 }
 
+// updateKubeConfigUser saves the given tokenInfo for an existing user in the given kubeConfig, contexts are left untouched.
+func updateKubeConfigUser(kubeConfig string, tokenInfo TokenInfo, userName string) error {
+	cfg, outputFilename, _, err := LoadKubeConfigFile(kubeConfig)
+	if err != nil {
+		return err
+	}
+
+	user, err := cfg.User(userName)
+	if err != nil {
+		return err
+	}
+
+	// the kind of the user is kept, exec users only get their stored tokens updated
+	if user.User.Exec != nil {
+		err = setExecTokenConfig(&user.User, tokenInfoConfigMap(tokenInfo))
+		if err != nil {
+			return err
+		}
+	} else {
+		if user.User.AuthProvider == nil {
+			user.User.AuthProvider = &AuthProvider{}
+		}
+		user.User.AuthProvider.Name = oidcAuthProvider
+		user.User.AuthProvider.Config = tokenInfoConfigMap(tokenInfo)
+	}
+
+	err = cfg.SetUser(*user)
+	if err != nil {
+		return err
+	}
+
+	return cfg.Save(outputFilename)
+}
+
+func tokenInfoConfigMap(tokenInfo TokenInfo) map[string]string {
+	return map[string]string{
+		"client-id":                 tokenInfo.ClientID,
+		"client-secret":             tokenInfo.ClientSecret,
+		"id-token":                  tokenInfo.IDToken,
+		"refresh-token":             tokenInfo.RefreshToken,
+		"idp-issuer-url":            tokenInfo.TokenClaims.Issuer,
+		"idp-certificate-authority": tokenInfo.IssuerCA,
+	}
+}
+
 //AddUserConfigMap adds the given user-auth-configMap to the kubecfg or replaces an already existing user
 func AddUserConfigMap(kubecfg map[interface{}]interface{}, userName string, configMap map[string]string) error {
 
@@ -138,6 +188,11 @@ func AddUserConfigMap(kubecfg map[interface{}]interface{}, userName string, conf
 		"user": userMap,
 	}
 
+	return addUser(kubecfg, user)
+}
+
+// addUser adds the given user to the kubecfg or replaces an already existing user with the same name
+func addUser(kubecfg map[interface{}]interface{}, user map[string]interface{}) error {
 	usersSlice, err := dyno.GetSlice(kubecfg, "users")
 	if err != nil {
 		return err
@@ -147,7 +202,7 @@ func AddUserConfigMap(kubecfg map[interface{}]interface{}, userName string, conf
 	}
 
 	// check if user already exists
-	_, index, err := findMapListMap(kubecfg, "users", "name", userName)
+	_, index, err := findMapListMap(kubecfg, "users", "name", fmt.Sprintf("%v", user["name"]))
 	if err != nil {
 		// not found, append
 		err = dyno.Append(kubecfg, user, "users")
@@ -296,7 +351,7 @@ func EncodeKubeconfig(kubeconfig map[interface{}]interface{}) (bytes.Buffer, err
 
 // ensureDirectory checks all directories in fqFile exist and creates if necessary
 func ensureDirectory(fqFile string) error {
-	kcPath := path.Dir(fqFile)
+	kcPath := filepath.Dir(fqFile)
 	if _, err := os.Stat(kcPath); os.IsNotExist(err) {
 		return os.MkdirAll(kcPath, 0700)
 	}
@@ -313,6 +368,9 @@ type AuthContext struct {
 	AuthProviderName string
 	// Flag if the AuthProvider is oidc, i.e. valid for our usecases
 	AuthProviderOidc bool
+	// Exec is set if the user obtains its token with the exec credential plugin of kubectl, see ExecConfig.
+	// The tokens of exec users are stored in an extension of the user instead of the auth-provider.
+	Exec bool
 
 	// IDToken, only if AuthProviderOidc is true
 	IDToken string
@@ -352,89 +410,73 @@ func CurrentAuthContext(kubeConfig string) (AuthContext, error) {
 
 // GetAuthContext returns the AuthContext for the given contextName from the given kubeConfig
 func GetAuthContext(kubeConfig string, contextName string) (AuthContext, error) {
-
 	empty := AuthContext{}
-	cfg, _, _, err := LoadKubeConfig(kubeConfig)
-	if err != nil {
-		return empty, err
-	}
 
-	// get context "metalctl" to determine user
-
-	context, _, err := findMapListMap(cfg, "contexts", "name", contextName)
+	cfg, _, _, err := LoadKubeConfigFile(kubeConfig)
 	if err != nil {
 		return empty, err
 	}
-	if context == nil {
-		return empty, fmt.Errorf("cannot determine user from kube-config, context '%s' does not exist", contextName)
-	}
-	empty.Ctx = contextName
 
-	// determine username from context
-	contextMap, err := dyno.GetMapS(context, "context")
+	// get context to determine user
+	context, err := cfg.Context(contextName)
 	if err != nil {
 		return empty, err
 	}
-	userName := fmt.Sprintf("%v", contextMap["user"])
-	empty.User = userName
 
-	// get user
-	userMap, _, err := findMapListMap(cfg, "users", "name", userName)
+	user, err := cfg.User(context.Context.User)
 	if err != nil {
 		return empty, err
 	}
 
-	authProviderMap, err := dyno.GetMapS(userMap, "user", "auth-provider")
-	if err != nil {
-		return empty, err
-	}
+	var (
+		authProviderName string
+		config           map[string]string
+		isExec           bool
+	)
 
-	// read auth-data
-	authProviderName, err := dyno.GetString(authProviderMap, "name")
-	if err != nil {
-		return empty, err
-	}
-
-	isOidc := authProviderName == oidcAuthProvider
-	if isOidc {
-		token, err := dyno.GetString(authProviderMap, "config", "id-token")
-		if err != nil {
-			return empty, err
-		}
-		issuerURL, err := dyno.GetString(authProviderMap, "config", "idp-issuer-url")
-		if err != nil {
-			return empty, err
-		}
-		issuerCA, err := dyno.GetString(authProviderMap, "config", "idp-certificate-authority")
-		if err != nil {
-			return empty, err
-		}
-		clientId, err := dyno.GetString(authProviderMap, "config", "client-id")
-		if err != nil {
-			return empty, err
-		}
-		clientSecret, err := dyno.GetString(authProviderMap, "config", "client-secret")
+	switch {
+	case user.User.Exec != nil:
+		config, err = execTokenConfig(user.User)
 		if err != nil {
 			return empty, err
 		}
 
-		return AuthContext{
-			Ctx:              contextName,
-			User:             userName,
-			AuthProviderName: authProviderName,
-			AuthProviderOidc: isOidc,
-			IDToken:          token,
+		authProviderName = oidcAuthProvider
+		isExec = true
+	case user.User.AuthProvider != nil:
+		authProviderName = user.User.AuthProvider.Name
+		config = user.User.AuthProvider.Config
+	default:
+		return empty, fmt.Errorf("user %q has neither an auth-provider nor an exec configured", user.Name)
+	}
 
-			IssuerConfig: IssuerConfig{
-				IssuerURL:    issuerURL,
-				IssuerCA:     issuerCA,
-				ClientID:     clientId,
-				ClientSecret: clientSecret,
-			},
-		}, nil
+	if authProviderName != oidcAuthProvider {
+		return empty, errors.New("cannot determine user from kube-config, no current context set")
 	}
 
-	return empty, errors.New("cannot determine user from kube-config, no current context set")
+	for _, key := range []string{"id-token", "idp-issuer-url", "idp-certificate-authority", "client-id", "client-secret"} {
+		if _, ok := config[key]; !ok {
+			return empty, fmt.Errorf("user %q is missing %s in its config", user.Name, key)
+		}
+	}
+
+	return AuthContext{
+		Ctx:              contextName,
+		User:             user.Name,
+		AuthProviderName: authProviderName,
+		AuthProviderOidc: true,
+		Exec:             isExec,
+		IDToken:          config["id-token"],
+		// the refresh token is optional, it is only present if it was requested during login
+		RefreshToken: config["refresh-token"],
+
+		IssuerConfig: IssuerConfig{
+			IssuerURL:    config["idp-issuer-url"],
+			IssuerCA:     config["idp-certificate-authority"],
+			ClientID:     config["client-id"],
+			ClientSecret: config["client-secret"],
+		},
+	}, nil
 }
 
 // LoadKubeConfig loads the kube-config from the given location, if kubeConfig is "" the default location will be used.
@@ -442,81 +484,58 @@ func GetAuthContext(kubeConfig string, contextName string) (AuthContext, error)
 // If the default location is used and no file exists, the contents of the kubeconfigTemplate are returned.
 // returns map, filename, isDefaultLocation and error
 func LoadKubeConfig(kubeConfig string) (content map[interface{}]interface{}, filename string, isDefaultLocation bool, e error) {
+	filename, isDefault, err := kubeConfigLocation(kubeConfig)
+	if err != nil {
+		return nil, "", false, err
+	}
 
-	var err error
 	var cfg map[interface{}]interface{}
-	var outputFilename string
 
-	isDefault := false
-
-	if kubeConfig != "" {
-		if _, err = os.Stat(kubeConfig); os.IsNotExist(err) {
-			// no file, use default
-			return nil, "", false, fmt.Errorf("error loading kube-config: %w", err)
-		} else {
-			// read exactly the specified file
-			cfg, err = readFile(kubeConfig)
-			if err != nil {
-				return nil, "", false, err
-			}
-			if len(cfg) == 0 {
-				return nil, "", false, errors.New("error loading kube-config - config is empty")
-			}
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		// read exactly the specified file
+		cfg, err = readFile(filename)
+		if err != nil {
+			return nil, "", isDefault, err
 		}
+	}
 
-		outputFilename = kubeConfig
+	if len(cfg) == 0 {
+		if kubeConfig != "" {
+			return nil, "", false, errors.New("error loading kube-config - config is empty")
+		}
 
-	} else {
-		// try path from env
-		envPaths := fromEnv()
-		if len(envPaths) > 1 {
-			return nil, "", false, fmt.Errorf("there are multiple files in env %s, don't know which one to update - please use cmdline-option", RecommendedConfigPathEnvVar)
+		err = CreateFromTemplate(&cfg)
+		if err != nil {
+			return nil, "", isDefault, err
 		}
+	}
 
-		if len(envPaths) == 1 {
-			filename := envPaths[0]
-
-			if _, err = os.Stat(filename); os.IsNotExist(err) {
-				// no file, use default
-				err = nil
-			} else {
-				// read exactly the specified file
-				cfg, err = readFile(filename)
-				if err != nil {
-					return nil, "", false, err
-				}
-			}
+	return cfg, filename, isDefault, nil
+}
 
-			outputFilename = filename
+// kubeConfigLocation returns the file of the kube-config for the given location, see LoadKubeConfig.
+// returns filename and isDefaultLocation
+func kubeConfigLocation(kubeConfig string) (string, bool, error) {
+	if kubeConfig != "" {
+		if _, err := os.Stat(kubeConfig); os.IsNotExist(err) {
+			return "", false, fmt.Errorf("error loading kube-config: %w", err)
+		}
 
-		} else {
-			// use default location
-			filename := RecommendedHomeFile
-			isDefault = true
-
-			if _, err = os.Stat(filename); os.IsNotExist(err) {
-				// no file, use default
-				err = nil
-			} else {
-				// read exactly the specified file
-				cfg, err = readFile(filename)
-				if err != nil {
-					return nil, "", isDefault, err
-				}
-			}
+		return kubeConfig, false, nil
+	}
 
-			outputFilename = filename
-		}
+	// try path from env
+	envPaths := fromEnv()
+	if len(envPaths) > 1 {
+		return "", false, fmt.Errorf("there are multiple files in env %s, don't know which one to update - please use cmdline-option", RecommendedConfigPathEnvVar)
 	}
 
-	if len(cfg) == 0 {
-		err = CreateFromTemplate(&cfg)
-		if err != nil {
-			return nil, "", isDefault, err
-		}
+	if len(envPaths) == 1 {
+		return envPaths[0], false, nil
 	}
 
-	return cfg, outputFilename, isDefault, err
+	// use default location
+	return RecommendedHomeFile, true, nil
 }
 
 // reads the given yaml-file and unmarshalls the contents (top level map)
@@ -538,12 +557,12 @@ func readFile(filename string) (map[interface{}]interface{}, error) {
 	return cfg, err
 }
 
-// minimal kube config
+// minimal kube config, the keys are sorted as new keys are inserted in alphabetical order
 const kubeconfigTemplate = `apiVersion: v1
-kind: Config
 clusters: []
 contexts: []
 current-context: ""
+kind: Config
 preferences: {}
 users: []
 `
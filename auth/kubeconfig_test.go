@@ -110,7 +110,7 @@ func Test_GetCurrentUser(t *testing.T) {
 		{
 			filename:    "./testdata/config-no-oidc",
 			contextName: testCloudContextName,
-			validate:    expectError("missing key: auth-provider (path element idx: 1)"),
+			validate:    expectError(`user "developer" has neither an auth-provider nor an exec configured`),
 		},
 		{
 			filename:    "./testdata/config-notexists",
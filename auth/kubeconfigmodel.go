@@ -0,0 +1,431 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubeConfig is a kubeconfig, which is backed by the yaml document it was loaded from. Users, contexts and clusters
+// are accessed with typed structs, everything else of the document including comments and the ordering of fields
+// is written back as it was read.
+//
+// Fields of users, contexts and clusters that are not modelled are passed through in the Extra fields.
+type KubeConfig struct {
+	root *yaml.Node
+}
+
+// NamedUser is an entry of the users of a kubeconfig.
+type NamedUser struct {
+	Name  string               `yaml:"name"`
+	User  User                 `yaml:"user"`
+	Extra map[string]yaml.Node `yaml:",inline"`
+}
+
+// User contains the credentials of a kubeconfig user.
+type User struct {
+	AuthProvider *AuthProvider        `yaml:"auth-provider,omitempty"`
+	Exec         *ExecUser            `yaml:"exec,omitempty"`
+	Extensions   []NamedExtension     `yaml:"extensions,omitempty"`
+	Extra        map[string]yaml.Node `yaml:",inline"`
+}
+
+// AuthProvider is the deprecated auth-provider of a kubeconfig user, the oidc auth-provider stores the tokens in the config.
+type AuthProvider struct {
+	Name   string               `yaml:"name"`
+	Config map[string]string    `yaml:"config,omitempty"`
+	Extra  map[string]yaml.Node `yaml:",inline"`
+}
+
+// ExecUser is the exec credential plugin of a kubeconfig user, see ExecConfig.
+type ExecUser struct {
+	APIVersion         string               `yaml:"apiVersion,omitempty"`
+	Command            string               `yaml:"command"`
+	Args               []string             `yaml:"args,omitempty"`
+	Env                []ExecEnvVar         `yaml:"env,omitempty"`
+	InstallHint        string               `yaml:"installHint,omitempty"`
+	InteractiveMode    string               `yaml:"interactiveMode,omitempty"`
+	ProvideClusterInfo bool                 `yaml:"provideClusterInfo"`
+	Extra              map[string]yaml.Node `yaml:",inline"`
+}
+
+// ExecEnvVar is an environment variable passed to the command of an exec user.
+type ExecEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// NamedExtension is an extension of a kubeconfig entry, its content is passed through.
+type NamedExtension struct {
+	Name      string    `yaml:"name"`
+	Extension yaml.Node `yaml:"extension,omitempty"`
+}
+
+// NamedContext is an entry of the contexts of a kubeconfig.
+type NamedContext struct {
+	Name    string               `yaml:"name"`
+	Context Context              `yaml:"context"`
+	Extra   map[string]yaml.Node `yaml:",inline"`
+}
+
+// Context references the user and the cluster of a kubeconfig context.
+type Context struct {
+	Cluster   string               `yaml:"cluster"`
+	User      string               `yaml:"user"`
+	Namespace string               `yaml:"namespace,omitempty"`
+	Extra     map[string]yaml.Node `yaml:",inline"`
+}
+
+// NamedCluster is an entry of the clusters of a kubeconfig.
+type NamedCluster struct {
+	Name    string               `yaml:"name"`
+	Cluster Cluster              `yaml:"cluster"`
+	Extra   map[string]yaml.Node `yaml:",inline"`
+}
+
+// Cluster contains the connection details of a kubeconfig cluster.
+type Cluster struct {
+	Server                   string               `yaml:"server,omitempty"`
+	CertificateAuthority     string               `yaml:"certificate-authority,omitempty"`
+	CertificateAuthorityData string               `yaml:"certificate-authority-data,omitempty"`
+	InsecureSkipTLSVerify    bool                 `yaml:"insecure-skip-tls-verify,omitempty"`
+	Extra                    map[string]yaml.Node `yaml:",inline"`
+}
+
+// NewKubeConfig returns a minimal kubeconfig.
+func NewKubeConfig() *KubeConfig {
+	k, err := ParseKubeConfig([]byte(kubeconfigTemplate))
+	if err != nil {
+		panic(fmt.Sprintf("invalid kubeconfig template: %v", err))
+	}
+	return k
+}
+
+// ParseKubeConfig parses the given kubeconfig, an empty kubeconfig results in an empty document.
+func ParseKubeConfig(data []byte) (*KubeConfig, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// like for the map based functions, documents without content or null are empty
+	if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+		return &KubeConfig{root: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}}, nil
+	}
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("kubeconfig must be a map")
+	}
+
+	return &KubeConfig{root: doc.Content[0]}, nil
+}
+
+// LoadKubeConfigFile loads the kube-config from the given location like LoadKubeConfig.
+// returns kubeconfig, filename, isDefaultLocation and error
+func LoadKubeConfigFile(kubeConfig string) (cfg *KubeConfig, filename string, isDefaultLocation bool, e error) {
+	filename, isDefault, err := kubeConfigLocation(kubeConfig)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		raw, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, "", isDefault, fmt.Errorf("error reading %s error: %w", filename, err)
+		}
+
+		cfg, err = ParseKubeConfig(raw)
+		if err != nil {
+			return nil, "", isDefault, fmt.Errorf("error un-marshalling %s error: %w", filename, err)
+		}
+	}
+
+	if cfg == nil || cfg.empty() {
+		if kubeConfig != "" {
+			return nil, "", false, errors.New("error loading kube-config - config is empty")
+		}
+		cfg = NewKubeConfig()
+	}
+
+	return cfg, filename, isDefault, nil
+}
+
+func (k *KubeConfig) empty() bool {
+	return len(k.root.Content) == 0
+}
+
+// Encode serializes the kubeconfig.
+func (k *KubeConfig) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	e := yaml.NewEncoder(&buf)
+	e.SetIndent(2)
+	err := e.Encode(k.root)
+	if err != nil {
+		return nil, err
+	}
+	err = e.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Save writes the kubeconfig to the given file, which is only readable by the owner.
+func (k *KubeConfig) Save(filename string) error {
+	raw, err := k.Encode()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, raw, 0600)
+}
+
+// CurrentContext returns the name of the current context, which is empty if not set.
+func (k *KubeConfig) CurrentContext() string {
+	n := k.value("current-context")
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
+
+// SetCurrentContext sets the current context to the given name.
+func (k *KubeConfig) SetCurrentContext(name string) {
+	k.setValue("current-context", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name})
+}
+
+// Users returns all users of the kubeconfig.
+func (k *KubeConfig) Users() ([]NamedUser, error) {
+	return listNamed[NamedUser](k, "users")
+}
+
+// User returns the user with the given name.
+func (k *KubeConfig) User(name string) (*NamedUser, error) {
+	return getNamed[NamedUser](k, "users", name)
+}
+
+// SetUser adds the given user or replaces an existing user with the same name.
+func (k *KubeConfig) SetUser(user NamedUser) error {
+	return k.setNamed("users", user.Name, user)
+}
+
+// DeleteUser removes the user with the given name, returns false if the user does not exist.
+func (k *KubeConfig) DeleteUser(name string) bool {
+	return k.deleteNamed("users", name)
+}
+
+// Contexts returns all contexts of the kubeconfig.
+func (k *KubeConfig) Contexts() ([]NamedContext, error) {
+	return listNamed[NamedContext](k, "contexts")
+}
+
+// Context returns the context with the given name.
+func (k *KubeConfig) Context(name string) (*NamedContext, error) {
+	return getNamed[NamedContext](k, "contexts", name)
+}
+
+// SetContext adds the given context or replaces an existing context with the same name.
+func (k *KubeConfig) SetContext(context NamedContext) error {
+	return k.setNamed("contexts", context.Name, context)
+}
+
+// DeleteContext removes the context with the given name, returns false if the context does not exist.
+func (k *KubeConfig) DeleteContext(name string) bool {
+	return k.deleteNamed("contexts", name)
+}
+
+// Clusters returns all clusters of the kubeconfig.
+func (k *KubeConfig) Clusters() ([]NamedCluster, error) {
+	return listNamed[NamedCluster](k, "clusters")
+}
+
+// Cluster returns the cluster with the given name.
+func (k *KubeConfig) Cluster(name string) (*NamedCluster, error) {
+	return getNamed[NamedCluster](k, "clusters", name)
+}
+
+// SetCluster adds the given cluster or replaces an existing cluster with the same name.
+func (k *KubeConfig) SetCluster(cluster NamedCluster) error {
+	return k.setNamed("clusters", cluster.Name, cluster)
+}
+
+// DeleteCluster removes the cluster with the given name, returns false if the cluster does not exist.
+func (k *KubeConfig) DeleteCluster(name string) bool {
+	return k.deleteNamed("clusters", name)
+}
+
+// value returns the value of the given top level key or nil if it does not exist.
+func (k *KubeConfig) value(key string) *yaml.Node {
+	for i := 0; i+1 < len(k.root.Content); i += 2 {
+		if k.root.Content[i].Value == key {
+			return k.root.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setValue replaces the value of the given top level key, new keys are inserted in alphabetical order.
+func (k *KubeConfig) setValue(key string, value *yaml.Node) {
+	for i := 0; i+1 < len(k.root.Content); i += 2 {
+		if k.root.Content[i].Value == key {
+			k.root.Content[i+1] = value
+			return
+		}
+	}
+
+	i := 0
+	for ; i+1 < len(k.root.Content); i += 2 {
+		if k.root.Content[i].Value > key {
+			break
+		}
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	k.root.Content = slices.Insert(k.root.Content, i, keyNode, value)
+}
+
+// list returns the items of the given top level list, which is nil if it does not exist.
+func (k *KubeConfig) list(listKey string) ([]*yaml.Node, error) {
+	n := k.value(listKey)
+	if n == nil || n.Tag == "!!null" {
+		return nil, nil
+	}
+	if n.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s must be a list", listKey)
+	}
+	return n.Content, nil
+}
+
+// find returns the index of the item with the given name in the given top level list or -1 if it does not exist.
+func (k *KubeConfig) find(listKey string, name string) (int, error) {
+	items, err := k.list(listKey)
+	if err != nil {
+		return -1, err
+	}
+
+	for i, item := range items {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			if item.Content[j].Value == "name" && item.Content[j+1].Value == name {
+				return i, nil
+			}
+		}
+	}
+
+	return -1, nil
+}
+
+func listNamed[T any](k *KubeConfig, listKey string) ([]T, error) {
+	items, err := k.list(listKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []T
+	for _, item := range items {
+		var t T
+		err := item.Decode(&t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry in %s: %w", listKey, err)
+		}
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+func getNamed[T any](k *KubeConfig, listKey string, name string) (*T, error) {
+	i, err := k.find(listKey, name)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("no %s, name=%s found", listKey, name)
+	}
+
+	items, _ := k.list(listKey)
+
+	var t T
+	err = items[i].Decode(&t)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entry %q in %s: %w", name, listKey, err)
+	}
+
+	return &t, nil
+}
+
+func (k *KubeConfig) setNamed(listKey string, name string, v any) error {
+	var item yaml.Node
+	err := item.Encode(v)
+	if err != nil {
+		return err
+	}
+	sortMappings(&item)
+
+	i, err := k.find(listKey, name)
+	if err != nil {
+		return err
+	}
+
+	list := k.value(listKey)
+	if list == nil || list.Kind != yaml.SequenceNode {
+		list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		k.setValue(listKey, list)
+	}
+
+	if i >= 0 {
+		list.Content[i] = &item
+		return nil
+	}
+
+	// empty lists are usually written in flow style, which is not readable anymore with entries
+	list.Style &^= yaml.FlowStyle
+	list.Content = append(list.Content, &item)
+
+	return nil
+}
+
+func (k *KubeConfig) deleteNamed(listKey string, name string) bool {
+	i, err := k.find(listKey, name)
+	if err != nil || i < 0 {
+		return false
+	}
+
+	list := k.value(listKey)
+	list.Content = slices.Delete(list.Content, i, i+1)
+
+	return true
+}
+
+// sortMappings sorts the keys of all mappings of the given node like the serialization of maps does,
+// such that modified entries look the same as if they were written by kubectl.
+func sortMappings(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		type pair struct{ key, value *yaml.Node }
+
+		var pairs []pair
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, pair{key: n.Content[i], value: n.Content[i+1]})
+		}
+
+		slices.SortStableFunc(pairs, func(a, b pair) int {
+			return strings.Compare(a.key.Value, b.key.Value)
+		})
+
+		n.Content = n.Content[:0]
+		for _, p := range pairs {
+			n.Content = append(n.Content, p.key, p.value)
+		}
+	}
+
+	for _, c := range n.Content {
+		sortMappings(c)
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubeConfigPreservesUnknownFields(t *testing.T) {
+	given, err := os.ReadFile("./testdata/UEUgivenConfig")
+	require.NoError(t, err)
+
+	raw := "# managed by hand\n" + strings.Replace(string(given), "    name: mycluster", "    name: mycluster\n    custom: value # keep me", 1)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(raw), 0600))
+
+	_, err = UpdateKubeConfigContext(kubeconfig, demoToken, ExtractEMail, testCloudContextName)
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(kubeconfig)
+	require.NoError(t, err)
+	require.Contains(t, string(got), "# managed by hand\n")
+	require.Contains(t, string(got), "custom: value # keep me")
+
+	cfg, _, _, err := LoadKubeConfigFile(kubeconfig)
+	require.NoError(t, err)
+
+	ctx, err := cfg.Context("mycluster")
+	require.NoError(t, err)
+	require.Equal(t, "testcluster", ctx.Context.Cluster)
+	require.Equal(t, "value", ctx.Extra["custom"].Value)
+
+	user, err := cfg.User("developer")
+	require.NoError(t, err)
+	require.Nil(t, user.User.AuthProvider)
+	require.Equal(t, "fake-cert-file", user.User.Extra["client-certificate"].Value)
+}
+
+func TestKubeConfigEmptyName(t *testing.T) {
+	cfg := NewKubeConfig()
+
+	// the user id extractor may return an empty name, such entries are replaced like named entries
+	require.NoError(t, cfg.SetUser(NamedUser{User: User{AuthProvider: &AuthProvider{Name: "oidc"}}}))
+	require.NoError(t, cfg.SetUser(NamedUser{User: User{AuthProvider: &AuthProvider{Name: "other"}}}))
+
+	users, err := cfg.Users()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, "other", users[0].User.AuthProvider.Name)
+}
+
+func TestKubeConfigCRUD(t *testing.T) {
+	cfg := NewKubeConfig()
+
+	require.NoError(t, cfg.SetCluster(NamedCluster{Name: "a", Cluster: Cluster{Server: "https://a"}}))
+	require.NoError(t, cfg.SetCluster(NamedCluster{Name: "b", Cluster: Cluster{Server: "https://b"}}))
+	require.NoError(t, cfg.SetCluster(NamedCluster{Name: "a", Cluster: Cluster{Server: "https://a2"}}))
+	require.NoError(t, cfg.SetContext(NamedContext{Name: "ctx", Context: Context{Cluster: "a", User: "u"}}))
+	require.NoError(t, cfg.SetUser(NamedUser{Name: "u", User: User{AuthProvider: &AuthProvider{Name: "oidc", Config: map[string]string{"id-token": "t"}}}}))
+	cfg.SetCurrentContext("ctx")
+
+	clusters, err := cfg.Clusters()
+	require.NoError(t, err)
+	if diff := cmp.Diff([]NamedCluster{
+		{Name: "a", Cluster: Cluster{Server: "https://a2"}},
+		{Name: "b", Cluster: Cluster{Server: "https://b"}},
+	}, clusters); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	require.True(t, cfg.DeleteCluster("b"))
+	require.False(t, cfg.DeleteCluster("b"))
+
+	_, err = cfg.Cluster("b")
+	require.EqualError(t, err, "no clusters, name=b found")
+
+	got, err := cfg.Encode()
+	require.NoError(t, err)
+
+	require.Equal(t, `apiVersion: v1
+clusters:
+  - cluster:
+      server: https://a2
+    name: a
+contexts:
+  - context:
+      cluster: a
+      user: u
+    name: ctx
+current-context: ctx
+kind: Config
+preferences: {}
+users:
+  - name: u
+    user:
+      auth-provider:
+        config:
+          id-token: t
+        name: oidc
+`, string(got))
+
+	parsed, err := ParseKubeConfig(got)
+	require.NoError(t, err)
+	require.Equal(t, "ctx", parsed.CurrentContext())
+
+	users, err := parsed.Users()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, "t", users[0].User.AuthProvider.Config["id-token"])
+}
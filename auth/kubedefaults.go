@@ -2,7 +2,6 @@ package auth
 
 import (
 	"os"
-	"path"
 	"path/filepath"
 )
 
@@ -13,8 +12,8 @@ const (
 )
 
 var (
-	RecommendedConfigDir = path.Join(HomeDir(), RecommendedHomeDir)
-	RecommendedHomeFile  = path.Join(RecommendedConfigDir, RecommendedFileName)
+	RecommendedConfigDir = filepath.Join(HomeDir(), RecommendedHomeDir)
+	RecommendedHomeFile  = filepath.Join(RecommendedConfigDir, RecommendedFileName)
 )
 
 // returns the paths from env, may be empty or contain multiple paths
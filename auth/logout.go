@@ -85,6 +85,10 @@ func Logout(params *LogoutParams) error {
 	go func() {
 		log.Debug("opening browser", "addr", listenAddr, "end-session-url", endSessionURL.String())
 		err := openBrowser(endSessionURL.String())
+		if errors.Is(err, errNoBrowser) {
+			fmt.Printf("Unable to open a browser, please point your browser to %s\n", endSessionURL.String())
+			return
+		}
 		if err != nil {
 			log.Error("open browser", "error", err)
 		}
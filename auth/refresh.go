@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RefreshConfig for the RefreshManager.
+type RefreshConfig struct {
+	// IssuerConfig of the issuer that issued the tokens
+	IssuerConfig
+
+	// IDToken is the currently stored id token, if empty the token is refreshed on first use
+	IDToken string
+	// RefreshToken is the stored refresh token, which is used to obtain new id tokens
+	RefreshToken string `required:"true"`
+
+	// TokenHandler persists the refreshed tokens, e.g. NewUpdateKubeConfigHandler, may be nil
+	TokenHandler TokenHandlerFunc
+
+	// RefreshBefore defines how long before the expiry of the id token it gets refreshed, defaults to 5 minutes
+	RefreshBefore time.Duration
+	// Jitter is the maximum random duration that is added to RefreshBefore, such that many clients sharing the same
+	// tokens do not refresh at the same moment, defaults to 1 minute
+	Jitter time.Duration
+	// RetryInterval is the duration after which a failed background refresh is retried, defaults to 30 seconds
+	RetryInterval time.Duration
+
+	Log *slog.Logger
+}
+
+// RefreshManager keeps an id token valid by refreshing it with the refresh token before it expires,
+// so that users do not have to go through the login flow again until the refresh token itself expires.
+type RefreshManager struct {
+	config RefreshConfig
+
+	refresh func(ctx context.Context, authCtx AuthContext) (TokenInfo, error)
+	now     func() time.Time
+
+	mu           sync.Mutex
+	idToken      string
+	refreshToken string
+	expiresAt    time.Time
+	refreshAt    time.Time
+}
+
+// NewRefreshManager returns a RefreshManager for the given tokens. Call Run for renewing the token in the background,
+// otherwise the token is only refreshed on calls to Token.
+func NewRefreshManager(config RefreshConfig) (*RefreshManager, error) {
+	if config.Log == nil {
+		return nil, errors.New("error validating config: Log is required")
+	}
+	if config.IssuerURL == "" {
+		return nil, errors.New("error validating config: IssuerURL is required")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("error validating config: ClientID is required")
+	}
+	if config.RefreshToken == "" {
+		return nil, errors.New("error validating config: RefreshToken is required")
+	}
+	if config.RefreshBefore <= 0 {
+		config.RefreshBefore = 5 * time.Minute
+	}
+	if config.Jitter <= 0 {
+		config.Jitter = time.Minute
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 30 * time.Second
+	}
+
+	m := &RefreshManager{
+		config:       config,
+		refresh:      refreshToken,
+		now:          time.Now,
+		refreshToken: config.RefreshToken,
+	}
+
+	m.setIDToken(config.IDToken)
+
+	return m, nil
+}
+
+// Token returns a valid id token, which is refreshed first if it expires within the refresh period.
+func (m *RefreshManager) Token(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.idToken != "" && m.now().Before(m.refreshAt) {
+		return m.idToken, nil
+	}
+
+	err := m.refreshLocked(ctx)
+	if err != nil {
+		// a token that was not refreshed in time is still usable until it expires
+		if m.idToken != "" && m.now().Before(m.expiresAt) {
+			m.config.Log.Warn("unable to refresh token, using current token", "expires-at", m.expiresAt, "error", err)
+			return m.idToken, nil
+		}
+		return "", err
+	}
+
+	return m.idToken, nil
+}
+
+// Run refreshes the token in the background before it expires until the given context is canceled.
+func (m *RefreshManager) Run(ctx context.Context) {
+	for {
+		m.mu.Lock()
+		wait := m.refreshAt.Sub(m.now())
+		m.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		m.mu.Lock()
+		err := m.refreshLocked(ctx)
+		if err != nil {
+			m.config.Log.Error("unable to refresh token", "retry-in", m.config.RetryInterval, "error", err)
+			m.refreshAt = m.now().Add(m.config.RetryInterval)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Transport returns a http.RoundTripper that authenticates requests with the id token of the manager.
+// If base is nil, http.DefaultTransport is used.
+func (m *RefreshManager) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &refreshTransport{manager: m, base: base}
+}
+
+func (m *RefreshManager) refreshLocked(ctx context.Context) error {
+	tokenInfo, err := m.refresh(ctx, AuthContext{
+		IDToken:      m.idToken,
+		RefreshToken: m.refreshToken,
+		IssuerConfig: m.config.IssuerConfig,
+	})
+	if err != nil {
+		return err
+	}
+
+	if m.config.TokenHandler != nil {
+		err = m.config.TokenHandler(tokenInfo)
+		if err != nil {
+			return fmt.Errorf("error handling token: %w", err)
+		}
+	}
+
+	m.refreshToken = tokenInfo.RefreshToken
+	m.setIDToken(tokenInfo.IDToken)
+
+	m.config.Log.Debug("refreshed token", "expires-at", m.expiresAt, "next-refresh", m.refreshAt)
+
+	return nil
+}
+
+// setIDToken stores the id token and schedules the next refresh, tokens without expiry are refreshed immediately.
+func (m *RefreshManager) setIDToken(idToken string) {
+	m.idToken = idToken
+
+	expiresAt, err := tokenExpiry(idToken)
+	if err != nil {
+		m.expiresAt = time.Time{}
+		m.refreshAt = time.Time{}
+		return
+	}
+
+	m.expiresAt = expiresAt
+	m.refreshAt = expiresAt.Add(-m.config.RefreshBefore - rand.N(m.config.Jitter)) //nolint:gosec
+}
+
+type refreshTransport struct {
+	manager *RefreshManager
+	base    http.RoundTripper
+}
+
+func (t *refreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.manager.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(req)
+}
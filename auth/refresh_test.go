@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshManagerToken(t *testing.T) {
+	var (
+		now       = time.Now().Truncate(time.Second)
+		current   = testJWT(t, now.Add(time.Hour))
+		refreshed = testJWT(t, now.Add(2*time.Hour))
+		persisted []TokenInfo
+		refreshes int
+		failing   error
+	)
+
+	m, err := NewRefreshManager(RefreshConfig{
+		IssuerConfig:  IssuerConfig{ClientID: "cli", IssuerURL: "https://issuer"},
+		IDToken:       current,
+		RefreshToken:  "refresh-1",
+		RefreshBefore: 5 * time.Minute,
+		Jitter:        time.Minute,
+		TokenHandler: func(tokenInfo TokenInfo) error {
+			persisted = append(persisted, tokenInfo)
+			return nil
+		},
+		Log: slog.Default(),
+	})
+	require.NoError(t, err)
+
+	m.now = func() time.Time { return now }
+	m.refresh = func(_ context.Context, authCtx AuthContext) (TokenInfo, error) {
+		refreshes++
+		if failing != nil {
+			return TokenInfo{}, failing
+		}
+		return TokenInfo{
+			IDToken:      refreshed,
+			RefreshToken: authCtx.RefreshToken + "-rotated",
+			IssuerConfig: authCtx.IssuerConfig,
+		}, nil
+	}
+
+	// the refresh is scheduled within the refresh period and the jitter
+	require.True(t, !m.refreshAt.Before(now.Add(time.Hour-6*time.Minute)) && !m.refreshAt.After(now.Add(time.Hour-5*time.Minute)), "unexpected refresh at %s", m.refreshAt)
+
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, current, token)
+	require.Zero(t, refreshes)
+
+	// the token expires soon, but refreshing fails, so the current token is still used
+	now = now.Add(56 * time.Minute)
+	failing = errors.New("issuer unavailable")
+
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, current, token)
+	require.Equal(t, 1, refreshes)
+
+	failing = nil
+
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, refreshed, token)
+	require.Equal(t, 2, refreshes)
+	require.Len(t, persisted, 1)
+	require.Equal(t, "refresh-1-rotated", persisted[0].RefreshToken)
+
+	// the expired token cannot be used anymore when refreshing fails
+	now = now.Add(2 * time.Hour)
+	failing = errors.New("refresh token expired")
+
+	_, err = m.Token(context.Background())
+	require.EqualError(t, err, "refresh token expired")
+}
+
+func TestRefreshManagerRun(t *testing.T) {
+	refreshed := make(chan string, 1)
+
+	m, err := NewRefreshManager(RefreshConfig{
+		IssuerConfig: IssuerConfig{ClientID: "cli", IssuerURL: "https://issuer"},
+		RefreshToken: "refresh-1",
+		Log:          slog.Default(),
+	})
+	require.NoError(t, err)
+
+	token := testJWT(t, time.Now().Add(time.Hour))
+	m.refresh = func(_ context.Context, authCtx AuthContext) (TokenInfo, error) {
+		refreshed <- authCtx.RefreshToken
+		return TokenInfo{IDToken: token, RefreshToken: "refresh-2"}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	// without an id token, the token is refreshed immediately
+	require.Equal(t, "refresh-1", <-refreshed)
+
+	cancel()
+	<-done
+
+	got, err := m.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, token, got)
+}
+
+func TestRefreshManagerTransport(t *testing.T) {
+	token := testJWT(t, time.Now().Add(time.Hour))
+
+	m, err := NewRefreshManager(RefreshConfig{
+		IssuerConfig: IssuerConfig{ClientID: "cli", IssuerURL: "https://issuer"},
+		IDToken:      token,
+		RefreshToken: "refresh-1",
+		Log:          slog.Default(),
+	})
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: m.Transport(nil)}
+
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer "+token, string(body))
+}
+
+func TestRefreshManagerValidation(t *testing.T) {
+	_, err := NewRefreshManager(RefreshConfig{
+		IssuerConfig: IssuerConfig{ClientID: "cli", IssuerURL: "https://issuer"},
+		Log:          slog.Default(),
+	})
+	require.EqualError(t, err, "error validating config: RefreshToken is required")
+}
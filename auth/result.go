@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LoginResult is the machine-readable outcome of a login, which can be consumed by wrappers of the cli, e.g. in CI pipelines.
+type LoginResult struct {
+	Issuer   string `json:"issuer"`
+	Subject  string `json:"subject"`
+	Username string `json:"username,omitempty"`
+	EMail    string `json:"email,omitempty"`
+	// Expiry is the expiry of the id token, omitted if the token has no expiry
+	Expiry *time.Time `json:"expiry,omitempty"`
+	// KubeConfig is the path of the kubeconfig the token was written to
+	KubeConfig string `json:"kubeconfig"`
+	// Context is the name of the kubeconfig context the token was written to
+	Context string `json:"context"`
+}
+
+// WithJSONResult writes the outcome of the login as json LoginResult to the given writer.
+// The writer should differ from the writer for messages, otherwise the output cannot be parsed.
+func WithJSONResult(w io.Writer) KubeConfigHandlerOption {
+	return func(c *updateKubeConfig) {
+		c.resultWriter = w
+	}
+}
+
+// WithJSONResultFile writes the outcome of the login as json LoginResult to the file with the given path.
+func WithJSONResultFile(path string) KubeConfigHandlerOption {
+	return func(c *updateKubeConfig) {
+		c.resultFile = path
+	}
+}
+
+func newLoginResult(tokenInfo TokenInfo, kubeConfig, contextName string) LoginResult {
+	result := LoginResult{
+		Issuer:     tokenInfo.TokenClaims.Issuer,
+		Subject:    tokenInfo.TokenClaims.Subject,
+		Username:   tokenInfo.TokenClaims.Username(),
+		EMail:      tokenInfo.TokenClaims.EMail,
+		KubeConfig: kubeConfig,
+		Context:    contextName,
+	}
+
+	if result.Issuer == "" {
+		result.Issuer = tokenInfo.IssuerURL
+	}
+
+	if expiry, err := tokenExpiry(tokenInfo.IDToken); err == nil {
+		result.Expiry = &expiry
+	}
+
+	return result
+}
+
+func (u *updateKubeConfig) writeResult(result LoginResult) error {
+	if u.resultWriter == nil && u.resultFile == "" {
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+
+	if u.resultWriter != nil {
+		_, err = u.resultWriter.Write(raw)
+		if err != nil {
+			return fmt.Errorf("unable to write login result: %w", err)
+		}
+	}
+
+	if u.resultFile != "" {
+		err = os.MkdirAll(filepath.Dir(u.resultFile), 0700)
+		if err != nil {
+			return fmt.Errorf("unable to write login result: %w", err)
+		}
+
+		err = os.WriteFile(u.resultFile, raw, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to write login result: %w", err)
+		}
+	}
+
+	return nil
+}
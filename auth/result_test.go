@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateKubeConfigHandlerJSONResult(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		kubeConfig = filepath.Join(dir, "kubeconfig")
+		resultFile = filepath.Join(dir, "out", "result.json")
+		expiry     = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	tokenInfo := TokenInfo{
+		IDToken: testJWTWithClaims(t, Claims{ExpiresAt: expiry.Unix()}),
+		TokenClaims: Claims{
+			Issuer:            "https://dex.example.com",
+			Subject:           "user-1",
+			PreferredUsername: "achim",
+			EMail:             "achim@example.com",
+		},
+	}
+
+	var (
+		messages bytes.Buffer
+		result   bytes.Buffer
+	)
+
+	thf := NewUpdateKubeConfigHandler(kubeConfig, &messages, WithContextName("ctx123"), WithJSONResult(&result), WithJSONResultFile(resultFile))
+	require.NoError(t, thf(tokenInfo))
+
+	require.Equal(t, "Successfully written token to "+kubeConfig+"\n", messages.String())
+
+	want := LoginResult{
+		Issuer:     "https://dex.example.com",
+		Subject:    "user-1",
+		Username:   "achim",
+		EMail:      "achim@example.com",
+		Expiry:     &expiry,
+		KubeConfig: kubeConfig,
+		Context:    "ctx123",
+	}
+
+	var got LoginResult
+	require.NoError(t, json.Unmarshal(result.Bytes(), &got))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	raw, err := os.ReadFile(resultFile)
+	require.NoError(t, err)
+	require.Equal(t, result.String(), string(raw))
+}
+
+func TestNewLoginResultWithoutExpiry(t *testing.T) {
+	got := newLoginResult(TokenInfo{
+		IDToken:      "123",
+		IssuerConfig: IssuerConfig{IssuerURL: "https://dex.example.com"},
+	}, "/kubeconfig", "ctx")
+
+	require.Equal(t, LoginResult{Issuer: "https://dex.example.com", KubeConfig: "/kubeconfig", Context: "ctx"}, got)
+}
@@ -1,19 +1,55 @@
 package auth
 
 import (
-	"errors"
+	"bytes"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
+	"time"
 )
 
-type tokenTmplData struct {
-	IDToken        string
-	RefreshToken   string
-	RedirectURL    string
-	Claims         string
+// AssetsPath is the path below which the assets of the SuccessPage are served by the callback server.
+const AssetsPath = "/assets/"
+
+// SuccessPage customizes the page that is shown in the browser after a successful login,
+// which allows products to white-label the login experience.
+type SuccessPage struct {
+	// Template replaces the default page, it is executed with the SuccessPageData.
+	Template *template.Template
+	// Assets like logos or stylesheets are served below AssetsPath and can be referenced by the template.
+	Assets fs.FS
+
+	// Lang is the language of the default page, defaults to "en".
+	Lang string
+	// Title of the default page, defaults to "Authentication successful".
+	Title string
+	// Heading of the default page, defaults to "Authentication successful".
+	Heading string
+
+	// AutoClose closes the page with javascript after the given duration if set. Browsers may refuse to close
+	// a page that was not opened by a script, so the success message should still ask the user to close it.
+	AutoClose time.Duration
+}
+
+// SuccessPageData is passed to the template of the SuccessPage.
+type SuccessPageData struct {
+	Lang           string
+	Title          string
+	Heading        string
 	SuccessMessage template.HTML
-	Debug          bool
+	// AssetsPath is the path of the assets of the SuccessPage
+	AssetsPath string
+	// AutoCloseMillis is the number of milliseconds after which the page should be closed, zero if it should not be closed
+	AutoCloseMillis int64
+
+	Claims Claims
+
+	// the tokens and the raw claims are only set in debug mode
+	IDToken      string
+	RefreshToken string
+	RawClaims    string
+	Debug        bool
 }
 
 const (
@@ -38,15 +74,20 @@ pre {
 </style>`
 )
 
-var tokenTmpl = template.Must(template.New("token.html").Parse(`<html>
-  <head>` + commonStyle + `
+var tokenTmpl = template.Must(template.New("token.html").Parse(`<html lang="{{ .Lang }}">
+  <head>
+    <meta charset="utf-8">
+    <title>{{ .Title }}</title>` + commonStyle + `
+  {{- if .AutoCloseMillis }}
+    <script>setTimeout(function() { window.close(); }, {{ .AutoCloseMillis }});</script>
+  {{- end }}
   </head>
   <body>
-		<h3>Authentication successful</h3>
+		<h3>{{ .Heading }}</h3>
 		<h4>{{ .SuccessMessage }}</h4>
 	{{ if .Debug }}
 		<p> Token: <pre><code>{{ .IDToken }}</code></pre></p>
-    <p> Claims: <pre><code>{{ .Claims }}</code></pre></p>
+    <p> Claims: <pre><code>{{ .RawClaims }}</code></pre></p>
 		{{ if .RefreshToken }}
     <p> Refresh Token: <pre><code>{{ .RefreshToken }}</code></pre></p>
 		{{ end }}
@@ -56,28 +97,56 @@ var tokenTmpl = template.Must(template.New("token.html").Parse(`<html>
 `))
 
 // renders response page in browser which is displayed to the user at the end of the oidc-flow
-func renderToken(w http.ResponseWriter, idToken, refreshToken string, claims []byte, successMessage string, debug bool) {
-	renderTemplate(w, tokenTmpl, tokenTmplData{
-		IDToken:        idToken,
-		RefreshToken:   refreshToken,
-		Claims:         string(claims),
-		SuccessMessage: template.HTML(successMessage), //nolint
-		Debug:          debug,
-	})
+func renderToken(w http.ResponseWriter, page *SuccessPage, idToken, refreshToken string, claims Claims, rawClaims []byte, successMessage string, debug bool) {
+	if page == nil {
+		page = &SuccessPage{}
+	}
+
+	data := SuccessPageData{
+		Lang:            page.Lang,
+		Title:           page.Title,
+		Heading:         page.Heading,
+		SuccessMessage:  template.HTML(successMessage), //nolint
+		AssetsPath:      AssetsPath,
+		AutoCloseMillis: page.AutoClose.Milliseconds(),
+		Claims:          claims,
+		Debug:           debug,
+	}
+	if data.Lang == "" {
+		data.Lang = "en"
+	}
+	if data.Title == "" {
+		data.Title = "Authentication successful"
+	}
+	if data.Heading == "" {
+		data.Heading = "Authentication successful"
+	}
+	if debug {
+		data.IDToken = idToken
+		data.RefreshToken = refreshToken
+		data.RawClaims = string(rawClaims)
+	}
+
+	tmpl := tokenTmpl
+	if page.Template != nil {
+		tmpl = page.Template
+	}
+
+	renderTemplate(w, tmpl, data)
 }
 
 func renderTemplate(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
-	err := tmpl.Execute(w, data)
-	if err == nil {
+	// render into a buffer first, such that a failing template, e.g. a custom one, does not produce a partial page
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, data)
+	if err != nil {
+		log.Printf("Error rendering template %s: %s", tmpl.Name(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	var templateErr *template.Error
-	if errors.As(err, &templateErr) {
-		// An ExecError guarantees that Execute has not written to the underlying reader.
-		log.Printf("Error rendering template %s: %s", tmpl.Name(), err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-	} else {
-		log.Printf("Error rendering template %s: %s", tmpl.Name(), err)
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		log.Printf("Error writing template %s: %s", tmpl.Name(), err)
 	}
 }
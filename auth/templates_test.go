@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderToken(t *testing.T) {
+	claims := Claims{Name: "achim", EMail: "achim@metal-stack.io"}
+
+	t.Run("default page", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		renderToken(w, nil, "id-token", "refresh-token", claims, []byte(`{"name":"achim"}`), "Return to your terminal.", false)
+
+		body := w.Body.String()
+		require.Contains(t, body, `<html lang="en">`)
+		require.Contains(t, body, "<title>Authentication successful</title>")
+		require.Contains(t, body, "<h4>Return to your terminal.</h4>")
+		require.NotContains(t, body, "id-token")
+		require.NotContains(t, body, "window.close")
+	})
+
+	t.Run("localized default page with auto close", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		renderToken(w, &SuccessPage{
+			Lang:      "de",
+			Title:     "Anmeldung",
+			Heading:   "Anmeldung erfolgreich",
+			AutoClose: 3 * time.Second,
+		}, "id-token", "refresh-token", claims, []byte(`{"name":"achim"}`), "Bitte zum Terminal zurückkehren.", true)
+
+		body := w.Body.String()
+		require.Contains(t, body, `<html lang="de">`)
+		require.Contains(t, body, "<title>Anmeldung</title>")
+		require.Contains(t, body, "<h3>Anmeldung erfolgreich</h3>")
+		require.Contains(t, body, "setTimeout(function() { window.close(); },  3000 );")
+		require.Contains(t, body, "id-token")
+		require.Contains(t, body, "refresh-token")
+	})
+
+	t.Run("custom template", func(t *testing.T) {
+		tmpl := template.Must(template.New("custom").Parse(`<img src="{{ .AssetsPath }}logo.svg">Welcome {{ .Claims.Username }} ({{ .Claims.EMail }}){{ .IDToken }}`))
+
+		w := httptest.NewRecorder()
+		renderToken(w, &SuccessPage{Template: tmpl}, "id-token", "refresh-token", claims, nil, "", false)
+
+		require.Equal(t, `<img src="/assets/logo.svg">Welcome achim (achim@metal-stack.io)`, w.Body.String())
+	})
+
+	t.Run("broken custom template", func(t *testing.T) {
+		tmpl := template.Must(template.New("custom").Parse(`{{ .Unknown }}`))
+
+		w := httptest.NewRecorder()
+		renderToken(w, &SuccessPage{Template: tmpl}, "id-token", "refresh-token", claims, nil, "", false)
+
+		require.Equal(t, 500, w.Code)
+	})
+}
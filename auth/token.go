@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Session describes the identity and validity of a stored token.
+type Session struct {
+	// Context is the name of the kubeconfig context the token is stored in
+	Context string `json:"context"`
+	// User is the name of the kubeconfig user the token is stored for
+	User string `json:"user"`
+
+	Issuer   string    `json:"issuer"`
+	Subject  string    `json:"subject"`
+	Username string    `json:"username,omitempty"`
+	EMail    string    `json:"email,omitempty"`
+	Groups   []string  `json:"groups,omitempty"`
+	Expiry   time.Time `json:"expiry"`
+	Expired  bool      `json:"expired"`
+	// Refreshable is true if a refresh token is stored alongside the id token
+	Refreshable bool `json:"refreshable"`
+}
+
+// ParseToken returns the claims of the given jwt.
+//
+// The signature of the token is NOT verified, the claims must only be used for displaying session information
+// and never for authorization decisions.
+func ParseToken(raw string) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("token is not a jwt")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, fmt.Errorf("unable to decode token payload: %w", err)
+	}
+
+	var claims Claims
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return Claims{}, fmt.Errorf("unable to parse token claims: %w", err)
+	}
+
+	return claims, nil
+}
+
+// ExpiresIn returns the duration until the given jwt expires, which is negative if it is already expired.
+func ExpiresIn(raw string) (time.Duration, error) {
+	expiresAt, err := tokenExpiry(raw)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Until(expiresAt), nil
+}
+
+// IsExpired returns true if the given jwt expires within the given clock skew. Tokens that cannot be parsed
+// or do not contain an expiry are considered expired.
+func IsExpired(raw string, skew time.Duration) bool {
+	return isExpired(raw, skew, time.Now())
+}
+
+func isExpired(raw string, skew time.Duration, now time.Time) bool {
+	expiresAt, err := tokenExpiry(raw)
+	if err != nil {
+		return true
+	}
+
+	return !now.Add(skew).Before(expiresAt)
+}
+
+// WhoAmI returns the session stored for the given context of the kubeconfig without contacting the issuer.
+func WhoAmI(kubeConfig string, contextName string) (*Session, error) {
+	authCtx, err := GetAuthContext(kubeConfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	if authCtx.IDToken == "" {
+		return nil, fmt.Errorf("no token stored for context %q", authCtx.Ctx)
+	}
+
+	claims, err := ParseToken(authCtx.IDToken)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse token of context %q: %w", authCtx.Ctx, err)
+	}
+
+	return newSession(authCtx, claims, time.Now()), nil
+}
+
+func newSession(authCtx AuthContext, claims Claims, now time.Time) *Session {
+	s := &Session{
+		Context:     authCtx.Ctx,
+		User:        authCtx.User,
+		Issuer:      claims.Issuer,
+		Subject:     claims.Subject,
+		Username:    claims.Username(),
+		EMail:       claims.EMail,
+		Groups:      claims.Groups,
+		Expired:     true,
+		Refreshable: authCtx.RefreshToken != "",
+	}
+
+	if claims.ExpiresAt != 0 {
+		s.Expiry = time.Unix(claims.ExpiresAt, 0)
+		s.Expired = !now.Before(s.Expiry)
+	}
+
+	return s
+}
+
+// tokenExpiry returns the expiry of the given jwt without verifying it
+func tokenExpiry(rawToken string) (time.Time, error) {
+	claims, err := ParseToken(rawToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if claims.ExpiresAt == 0 {
+		return time.Time{}, errors.New("token has no expiry")
+	}
+
+	return time.Unix(claims.ExpiresAt, 0), nil
+}
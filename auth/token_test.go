@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func testJWTWithClaims(t *testing.T, claims Claims) string {
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("e30.%s.c2ln", base64.RawURLEncoding.EncodeToString(payload))
+}
+
+func TestParseToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Claims
+		wantErr string
+	}{
+		{
+			name: "valid token",
+			raw:  testJWTWithClaims(t, Claims{Issuer: "https://issuer", Subject: "achim", Groups: []string{"admins"}, ExpiresAt: 1700000000}),
+			want: Claims{Issuer: "https://issuer", Subject: "achim", Groups: []string{"admins"}, ExpiresAt: 1700000000},
+		},
+		{
+			name:    "no jwt",
+			raw:     "abc",
+			wantErr: "token is not a jwt",
+		},
+		{
+			name:    "invalid payload encoding",
+			raw:     "e30.!!!.c2ln",
+			wantErr: "unable to decode token payload: illegal base64 data at input byte 0",
+		},
+		{
+			name:    "invalid payload",
+			raw:     "e30." + base64.RawURLEncoding.EncodeToString([]byte("x")) + ".c2ln",
+			wantErr: "unable to parse token claims: invalid character 'x' looking for beginning of value",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseToken(tt.raw)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+
+	got, err := tokenExpiry(testJWT(t, expiresAt))
+	require.NoError(t, err)
+	require.True(t, expiresAt.Equal(got))
+
+	_, err = tokenExpiry("abc")
+	require.EqualError(t, err, "token is not a jwt")
+
+	_, err = tokenExpiry(testJWTWithClaims(t, Claims{Issuer: "https://issuer"}))
+	require.EqualError(t, err, "token has no expiry")
+}
+
+func TestExpiresIn(t *testing.T) {
+	got, err := ExpiresIn(testJWT(t, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	require.InDelta(t, time.Hour.Seconds(), got.Seconds(), 5)
+
+	got, err = ExpiresIn(testJWT(t, time.Now().Add(-time.Hour)))
+	require.NoError(t, err)
+	require.Negative(t, got)
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name string
+		raw  string
+		skew time.Duration
+		want bool
+	}{
+		{
+			name: "valid",
+			raw:  testJWT(t, now.Add(time.Hour)),
+			want: false,
+		},
+		{
+			name: "expired",
+			raw:  testJWT(t, now.Add(-time.Second)),
+			want: true,
+		},
+		{
+			name: "expires exactly now",
+			raw:  testJWT(t, now),
+			want: true,
+		},
+		{
+			name: "expires within skew",
+			raw:  testJWT(t, now.Add(time.Minute)),
+			skew: 2 * time.Minute,
+			want: true,
+		},
+		{
+			name: "no expiry",
+			raw:  testJWTWithClaims(t, Claims{Issuer: "https://issuer"}),
+			want: true,
+		},
+		{
+			name: "no jwt",
+			raw:  "abc",
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isExpired(tt.raw, tt.skew, now))
+		})
+	}
+}
+
+func TestWhoAmI(t *testing.T) {
+	var (
+		kubeconfig = filepath.Join(t.TempDir(), "config")
+		expiresAt  = time.Now().Add(time.Hour).Truncate(time.Second)
+		claims     = Claims{
+			Issuer:    "https://issuer",
+			Subject:   "1234",
+			Name:      "achim",
+			EMail:     "achim@metal-stack.io",
+			Groups:    []string{"admins", "devs"},
+			ExpiresAt: expiresAt.Unix(),
+		}
+	)
+
+	_, err := UpdateKubeConfigContext(kubeconfig, TokenInfo{
+		IDToken:      testJWTWithClaims(t, claims),
+		RefreshToken: "refresh",
+		TokenClaims:  claims,
+		IssuerConfig: IssuerConfig{IssuerURL: "https://issuer", ClientID: "client"},
+	}, ExtractName, "prod")
+	require.NoError(t, err)
+
+	got, err := WhoAmI(kubeconfig, "prod")
+	require.NoError(t, err)
+
+	want := &Session{
+		Context:     "prod",
+		User:        "achim",
+		Issuer:      "https://issuer",
+		Subject:     "1234",
+		Username:    "achim",
+		EMail:       "achim@metal-stack.io",
+		Groups:      []string{"admins", "devs"},
+		Expiry:      expiresAt,
+		Expired:     false,
+		Refreshable: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	_, err = WhoAmI(kubeconfig, "unknown")
+	require.Error(t, err)
+}
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const defaultTokenStoreFile = "tokens.json"
+
+// ErrTokenNotFound is returned by a TokenStore if no tokens are stored for a key.
+var ErrTokenNotFound = errors.New("no token found")
+
+// TokenKey identifies the tokens of a user, which were issued for a client by an issuer.
+type TokenKey struct {
+	IssuerURL string `json:"issuer-url"`
+	ClientID  string `json:"client-id"`
+	User      string `json:"user"`
+}
+
+func (k TokenKey) String() string {
+	return fmt.Sprintf("issuer=%s client=%s user=%s", k.IssuerURL, k.ClientID, k.User)
+}
+
+// StoredToken are the tokens of a TokenStore.
+type StoredToken struct {
+	TokenKey
+
+	IDToken      string `json:"id-token"`
+	RefreshToken string `json:"refresh-token,omitempty"`
+}
+
+// TokenStore stores the tokens of several users, clients and issuers, such that clis can keep tokens of multiple
+// profiles without writing them into a kubeconfig.
+type TokenStore interface {
+	// Get returns the tokens of the given key or ErrTokenNotFound.
+	Get(key TokenKey) (StoredToken, error)
+	// Put stores the given tokens, existing tokens of the same key are replaced.
+	Put(token StoredToken) error
+	// Delete removes the tokens of the given key, it is not an error if there are no tokens for the key.
+	Delete(key TokenKey) error
+	// List returns all stored tokens sorted by their key.
+	List() ([]StoredToken, error)
+}
+
+// FileTokenStoreOption func for specifying options of the FileTokenStore
+type FileTokenStoreOption func(s *FileTokenStore)
+
+// WithTokenStoreFile sets the name of the file in the directory of the store, defaults to tokens.json.
+func WithTokenStoreFile(name string) FileTokenStoreOption {
+	return func(s *FileTokenStore) {
+		s.path = filepath.Join(filepath.Dir(s.path), name)
+	}
+}
+
+// WithEncryptionKey encrypts the stored tokens with AES-GCM, the key must be 16, 24 or 32 bytes long.
+// A store written without encryption key cannot be read with an encryption key and vice versa.
+func WithEncryptionKey(key []byte) FileTokenStoreOption {
+	return func(s *FileTokenStore) {
+		s.key = key
+	}
+}
+
+// FileTokenStore is a TokenStore that keeps the tokens in a single file, which is only readable by the user (0600).
+type FileTokenStore struct {
+	path string
+	key  []byte
+
+	mu sync.Mutex
+}
+
+type tokenStoreFile struct {
+	Tokens []StoredToken `json:"tokens"`
+}
+
+// NewFileTokenStore returns a TokenStore that keeps the tokens in a file in the given directory,
+// e.g. the config directory of a cli. The directory is created on the first write.
+func NewFileTokenStore(dir string, opts ...FileTokenStoreOption) (*FileTokenStore, error) {
+	if dir == "" {
+		return nil, errors.New("directory of the token store must not be empty")
+	}
+
+	s := &FileTokenStore{
+		path: filepath.Join(dir, defaultTokenStoreFile),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.key != nil {
+		if _, err := aes.NewCipher(s.key); err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Path returns the path of the file the tokens are stored in.
+func (s *FileTokenStore) Path() string {
+	return s.path
+}
+
+// Get implements TokenStore
+func (s *FileTokenStore) Get(key TokenKey) (StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return StoredToken{}, err
+	}
+
+	idx := slices.IndexFunc(f.Tokens, func(t StoredToken) bool { return t.TokenKey == key })
+	if idx < 0 {
+		return StoredToken{}, fmt.Errorf("%w: %s", ErrTokenNotFound, key)
+	}
+
+	return f.Tokens[idx], nil
+}
+
+// Put implements TokenStore
+func (s *FileTokenStore) Put(token StoredToken) error {
+	if token.IssuerURL == "" || token.ClientID == "" || token.User == "" {
+		return errors.New("issuer url, client id and user of a token are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	f.Tokens = slices.DeleteFunc(f.Tokens, func(t StoredToken) bool { return t.TokenKey == token.TokenKey })
+	f.Tokens = append(f.Tokens, token)
+
+	return s.write(f)
+}
+
+// Delete implements TokenStore
+func (s *FileTokenStore) Delete(key TokenKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	before := len(f.Tokens)
+	f.Tokens = slices.DeleteFunc(f.Tokens, func(t StoredToken) bool { return t.TokenKey == key })
+	if len(f.Tokens) == before {
+		return nil
+	}
+
+	return s.write(f)
+}
+
+// List implements TokenStore
+func (s *FileTokenStore) List() ([]StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Tokens, nil
+}
+
+// NewTokenStoreHandler returns a TokenHandlerFunc, which stores the tokens of a login or a refresh in the given store.
+// The user of the key is determined by the given extractor, e.g. ExtractEMail.
+func NewTokenStoreHandler(store TokenStore, userIDExtractor UserIDExtractor) TokenHandlerFunc {
+	return func(tokenInfo TokenInfo) error {
+		return store.Put(StoredToken{
+			TokenKey: TokenKey{
+				IssuerURL: tokenInfo.IssuerURL,
+				ClientID:  tokenInfo.ClientID,
+				User:      userIDExtractor(tokenInfo),
+			},
+			IDToken:      tokenInfo.IDToken,
+			RefreshToken: tokenInfo.RefreshToken,
+		})
+	}
+}
+
+func (s *FileTokenStore) read() (*tokenStoreFile, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &tokenStoreFile{}, nil
+		}
+		return nil, fmt.Errorf("unable to read token store: %w", err)
+	}
+
+	if s.key != nil {
+		raw, err = s.decrypt(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt token store %s: %w", s.path, err)
+		}
+	}
+
+	var f tokenStoreFile
+	err = json.Unmarshal(raw, &f)
+	if err != nil {
+		return nil, fmt.Errorf("token store %s is corrupted: %w", s.path, err)
+	}
+
+	return &f, nil
+}
+
+func (s *FileTokenStore) write(f *tokenStoreFile) error {
+	slices.SortFunc(f.Tokens, func(a, b StoredToken) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if s.key != nil {
+		raw, err = s.encrypt(raw)
+		if err != nil {
+			return fmt.Errorf("unable to encrypt token store: %w", err)
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+
+	err = os.WriteFile(tmp, raw, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+func (s *FileTokenStore) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals the plaintext, the nonce is prepended to the ciphertext
+func (s *FileTokenStore) encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *FileTokenStore) decrypt(ciphertext []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	var (
+		alice = StoredToken{
+			TokenKey:     TokenKey{IssuerURL: "https://dex.fra", ClientID: "cli", User: "alice"},
+			IDToken:      "id-alice",
+			RefreshToken: "refresh-alice",
+		}
+		bob = StoredToken{
+			TokenKey: TokenKey{IssuerURL: "https://dex.fra", ClientID: "cli", User: "bob"},
+			IDToken:  "id-bob",
+		}
+	)
+
+	tests := []struct {
+		name string
+		opts []FileTokenStoreOption
+	}{
+		{
+			name: "plain",
+		},
+		{
+			name: "encrypted",
+			opts: []FileTokenStoreOption{WithEncryptionKey([]byte("0123456789abcdef0123456789abcdef"))},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFileTokenStore(filepath.Join(t.TempDir(), "metalctl"), tt.opts...)
+			require.NoError(t, err)
+
+			_, err = s.Get(alice.TokenKey)
+			require.True(t, errors.Is(err, ErrTokenNotFound), "unexpected error: %v", err)
+
+			require.NoError(t, s.Put(bob))
+			require.NoError(t, s.Put(alice))
+
+			alice.IDToken = "id-alice-refreshed"
+			require.NoError(t, s.Put(alice))
+
+			got, err := s.Get(alice.TokenKey)
+			require.NoError(t, err)
+			if diff := cmp.Diff(alice, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			list, err := s.List()
+			require.NoError(t, err)
+			if diff := cmp.Diff([]StoredToken{alice, bob}, list); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(s.Path())
+				require.NoError(t, err)
+				require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+			}
+
+			raw, err := os.ReadFile(s.Path())
+			require.NoError(t, err)
+			require.Equal(t, tt.opts == nil, bytes.Contains(raw, []byte("id-bob")))
+
+			require.NoError(t, s.Delete(bob.TokenKey))
+			require.NoError(t, s.Delete(bob.TokenKey))
+
+			list, err = s.List()
+			require.NoError(t, err)
+			if diff := cmp.Diff([]StoredToken{alice}, list); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestFileTokenStoreEncryptionKey(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewFileTokenStore(dir, WithEncryptionKey([]byte("too short")))
+	require.EqualError(t, err, "invalid encryption key: crypto/aes: invalid key size 9")
+
+	s, err := NewFileTokenStore(dir, WithEncryptionKey([]byte("0123456789abcdef")))
+	require.NoError(t, err)
+	require.NoError(t, s.Put(StoredToken{TokenKey: TokenKey{IssuerURL: "https://dex.fra", ClientID: "cli", User: "alice"}, IDToken: "id"}))
+
+	other, err := NewFileTokenStore(dir, WithEncryptionKey([]byte("fedcba9876543210")))
+	require.NoError(t, err)
+	_, err = other.List()
+	require.EqualError(t, err, "unable to decrypt token store "+s.Path()+": cipher: message authentication failed")
+
+	plain, err := NewFileTokenStore(dir)
+	require.NoError(t, err)
+	_, err = plain.List()
+	require.ErrorContains(t, err, "token store "+s.Path()+" is corrupted")
+}
+
+func TestNewTokenStoreHandler(t *testing.T) {
+	s, err := NewFileTokenStore(t.TempDir(), WithTokenStoreFile("metal.json"))
+	require.NoError(t, err)
+	require.Equal(t, "metal.json", filepath.Base(s.Path()))
+
+	handler := NewTokenStoreHandler(s, ExtractEMail)
+	err = handler(TokenInfo{
+		IDToken:      "id",
+		RefreshToken: "refresh",
+		TokenClaims:  Claims{EMail: "alice@metal-stack.io"},
+		IssuerConfig: IssuerConfig{IssuerURL: "https://dex.fra", ClientID: "cli"},
+	})
+	require.NoError(t, err)
+
+	got, err := s.Get(TokenKey{IssuerURL: "https://dex.fra", ClientID: "cli", User: "alice@metal-stack.io"})
+	require.NoError(t, err)
+	require.Equal(t, "refresh", got.RefreshToken)
+
+	err = handler(TokenInfo{IDToken: "id"})
+	require.EqualError(t, err, "issuer url, client id and user of a token are required")
+}
@@ -2,6 +2,8 @@ package auth
 
 //IssuerConfig holds the config for openID connect issuer
 type IssuerConfig struct {
+	// Name of the issuer if it was selected from a MultiIssuerConfig
+	Name string
 	// Client-ID
 	ClientID string
 	// ClientSecret
@@ -0,0 +1,369 @@
+package bus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultAdminTimeout = 10 * time.Second
+
+// An AdminConfig represents the config of an Admin.
+type AdminConfig struct {
+	// NSQDs are the http endpoints of the nsqds to manage. If empty, the nsqds are discovered through the lookupds.
+	NSQDs []string
+	// Lookupds are the http endpoints of the nsqlookupds. Deleted topics and channels are also removed from them,
+	// such that consumers do not reconnect to the deleted topics.
+	Lookupds []string
+	// TLS enables https with client certificates for the http endpoints.
+	TLS *TLSConfig
+	// Timeout of a single request, defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Admin manages topics and channels through the http apis of nsqd and nsqlookupd, such that operators and
+// integration tests do not need to go through nsqadmin.
+type Admin struct {
+	nsqds    []string
+	lookupds []string
+	scheme   string
+	client   *http.Client
+}
+
+// DepthStats are the depths of a topic and its channels summed up over all nsqds.
+type DepthStats struct {
+	Topic    string
+	Depth    int64
+	Paused   bool
+	Channels map[string]ChannelDepth
+}
+
+// ChannelDepth is the depth of a channel summed up over all nsqds.
+type ChannelDepth struct {
+	Depth         int64
+	InFlightCount int
+	DeferredCount int
+	Paused        bool
+}
+
+// NewAdmin creates a new admin for the nsqds and lookupds of the given config.
+// An error is returned if the config is invalid, see AdminConfig.Validate.
+func NewAdmin(cfg *AdminConfig) (*Admin, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid admin config: %w", err)
+	}
+
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultAdminTimeout
+	}
+
+	a := &Admin{
+		nsqds:    cfg.NSQDs,
+		lookupds: cfg.Lookupds,
+		scheme:   "http",
+		client:   &http.Client{Timeout: timeout},
+	}
+
+	if !cfg.TLS.Inactive() {
+		tlsConfig, err := newHTTPTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		a.scheme = "https"
+		a.client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
+
+	return a, nil
+}
+
+func newHTTPTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	ca, err := os.ReadFile(cfg.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ca certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in %q", cfg.CACertFile)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+		GetClientCertificate: func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return LoadCertificate(cfg.ClientCertFile)
+		},
+	}, nil
+}
+
+// CreateTopic creates the given topic on all nsqds.
+func (a *Admin) CreateTopic(ctx context.Context, topic string) error {
+	return a.onNSQDs(ctx, "/topic/create", url.Values{"topic": {topic}})
+}
+
+// DeleteTopic deletes the given topic including its channels and messages from all nsqds and lookupds.
+func (a *Admin) DeleteTopic(ctx context.Context, topic string) error {
+	query := url.Values{"topic": {topic}}
+	return a.onNSQDsAndLookupds(ctx, "/topic/delete", query)
+}
+
+// PauseTopic pauses the given topic on all nsqds, messages are queued in the topic but not delivered to the channels.
+func (a *Admin) PauseTopic(ctx context.Context, topic string) error {
+	return a.onNSQDs(ctx, "/topic/pause", url.Values{"topic": {topic}})
+}
+
+// UnpauseTopic resumes the delivery of messages of the given topic on all nsqds.
+func (a *Admin) UnpauseTopic(ctx context.Context, topic string) error {
+	return a.onNSQDs(ctx, "/topic/unpause", url.Values{"topic": {topic}})
+}
+
+// CreateChannel creates the given channel of the topic on all nsqds, such that messages are queued in the
+// channel before the first consumer connects.
+func (a *Admin) CreateChannel(ctx context.Context, topic, channel string) error {
+	return a.onNSQDs(ctx, "/channel/create", url.Values{"topic": {topic}, "channel": {channel}})
+}
+
+// DeleteChannel deletes the given channel of the topic including its messages from all nsqds and lookupds.
+func (a *Admin) DeleteChannel(ctx context.Context, topic, channel string) error {
+	query := url.Values{"topic": {topic}, "channel": {channel}}
+	return a.onNSQDsAndLookupds(ctx, "/channel/delete", query)
+}
+
+// PauseChannel pauses the given channel of the topic on all nsqds, messages are queued in the channel but not
+// delivered to the consumers.
+func (a *Admin) PauseChannel(ctx context.Context, topic, channel string) error {
+	return a.onNSQDs(ctx, "/channel/pause", url.Values{"topic": {topic}, "channel": {channel}})
+}
+
+// UnpauseChannel resumes the delivery of messages of the given channel on all nsqds.
+func (a *Admin) UnpauseChannel(ctx context.Context, topic, channel string) error {
+	return a.onNSQDs(ctx, "/channel/unpause", url.Values{"topic": {topic}, "channel": {channel}})
+}
+
+// Stats returns the statistics of the given topic per nsqd http endpoint, see Stats.
+func (a *Admin) Stats(ctx context.Context, topic, channel string) (map[string][]TopicStats, error) {
+	nsqds, err := a.NSQDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result = map[string][]TopicStats{}
+		errs   []error
+	)
+
+	for _, nsqd := range nsqds {
+		stats, err := stats(ctx, a.client, a.endpointURL(nsqd, "/stats"), topic, channel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("nsqd %s: %w", nsqd, err))
+			continue
+		}
+		result[nsqd] = stats
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// DepthStats returns the depths of the given topic and its channels summed up over all nsqds.
+// A topic is considered paused if it is paused on any nsqd, the same applies to channels.
+func (a *Admin) DepthStats(ctx context.Context, topic string) (*DepthStats, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("topic must not be empty")
+	}
+
+	perNSQD, err := a.Stats(ctx, topic, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DepthStats{
+		Topic:    topic,
+		Channels: map[string]ChannelDepth{},
+	}
+
+	for _, topics := range perNSQD {
+		for _, t := range topics {
+			if t.TopicName != topic {
+				continue
+			}
+
+			result.Depth += t.Depth
+			result.Paused = result.Paused || t.Paused
+
+			for _, c := range t.Channels {
+				depth := result.Channels[c.ChannelName]
+				depth.Depth += c.Depth
+				depth.InFlightCount += c.InFlightCount
+				depth.DeferredCount += c.DeferredCount
+				depth.Paused = depth.Paused || c.Paused
+				result.Channels[c.ChannelName] = depth
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// NSQDs returns the http endpoints of the managed nsqds. If no nsqds are configured, the nsqds registered at the
+// lookupds are returned.
+func (a *Admin) NSQDs(ctx context.Context) ([]string, error) {
+	if len(a.nsqds) > 0 {
+		return a.nsqds, nil
+	}
+
+	var (
+		seen   = map[string]bool{}
+		result []string
+		errs   []error
+	)
+
+	for _, lookupd := range a.lookupds {
+		producers, err := lookupdProducers(ctx, a.client, a.endpointURL(lookupd, "/nodes"))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lookupd %s: %w", lookupd, err))
+			continue
+		}
+
+		for _, p := range producers {
+			endpoint := net.JoinHostPort(p.BroadcastAddress, strconv.Itoa(p.HTTPPort))
+			if seen[endpoint] {
+				continue
+			}
+			seen[endpoint] = true
+			result = append(result, endpoint)
+		}
+	}
+
+	// nsqds are found if at least one lookupd is reachable
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+type lookupdProducer struct {
+	BroadcastAddress string `json:"broadcast_address"`
+	TCPPort          int    `json:"tcp_port"`
+	HTTPPort         int    `json:"http_port"`
+}
+
+// lookupdProducers returns the producers of a lookupd endpoint that lists producers, e.g. /nodes or /lookup.
+func lookupdProducers(ctx context.Context, client *http.Client, u string) ([]lookupdProducer, error) {
+	body, err := doRequest(ctx, client, http.MethodGet, u)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Producers []lookupdProducer `json:"producers"`
+		// nsqlookupd before v1.0 wraps the response into a data field
+		Data *struct {
+			Producers []lookupdProducer `json:"producers"`
+		} `json:"data"`
+	}
+
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode producers: %w", err)
+	}
+
+	if resp.Data != nil {
+		return resp.Data.Producers, nil
+	}
+
+	return resp.Producers, nil
+}
+
+func (a *Admin) onNSQDs(ctx context.Context, path string, query url.Values) error {
+	nsqds, err := a.NSQDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nsqds) == 0 {
+		return fmt.Errorf("no nsqds found")
+	}
+
+	var errs []error
+	for _, nsqd := range nsqds {
+		_, err := a.do(ctx, http.MethodPost, a.endpointURL(nsqd, path)+"?"+query.Encode())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("nsqd %s: %w", nsqd, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Admin) onNSQDsAndLookupds(ctx context.Context, path string, query url.Values) error {
+	err := a.onNSQDs(ctx, path, query)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, lookupd := range a.lookupds {
+		_, err := a.do(ctx, http.MethodPost, a.endpointURL(lookupd, path)+"?"+query.Encode())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lookupd %s: %w", lookupd, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *Admin) endpointURL(endpoint, path string) string {
+	return fmt.Sprintf("%s://%s%s", a.scheme, endpoint, path)
+}
+
+func (a *Admin) do(ctx context.Context, method, u string) ([]byte, error) {
+	return doRequest(ctx, a.client, method, u)
+}
+
+func doRequest(ctx context.Context, client *http.Client, method, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, &statusError{method: method, path: req.URL.Path, code: resp.StatusCode, body: string(raw)}
+	}
+
+	return raw, nil
+}
+
+// statusError is returned by doRequest if the response has no successful status code.
+type statusError struct {
+	method string
+	path   string
+	code   int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s returned status %d: %s", e.method, e.path, e.code, e.body)
+}
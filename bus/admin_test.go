@@ -0,0 +1,200 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNSQAPI struct {
+	mu       sync.Mutex
+	requests []string
+	handler  http.HandlerFunc
+}
+
+func newFakeNSQAPI(t *testing.T, handler http.HandlerFunc) (*fakeNSQAPI, string) {
+	f := &fakeNSQAPI{handler: handler}
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		f.mu.Unlock()
+
+		if f.handler != nil {
+			f.handler(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+
+	return f, strings.TrimPrefix(s.URL, "http://")
+}
+
+func (f *fakeNSQAPI) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func TestAdminTopicsAndChannels(t *testing.T) {
+	nsqd1, endpoint1 := newFakeNSQAPI(t, nil)
+	nsqd2, endpoint2 := newFakeNSQAPI(t, nil)
+	lookupd, lookupdEndpoint := newFakeNSQAPI(t, nil)
+
+	admin, err := NewAdmin(&AdminConfig{
+		NSQDs:    []string{endpoint1, endpoint2},
+		Lookupds: []string{lookupdEndpoint},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	require.NoError(t, admin.CreateTopic(ctx, "topic"))
+	require.NoError(t, admin.CreateChannel(ctx, "topic", "channel"))
+	require.NoError(t, admin.PauseTopic(ctx, "topic"))
+	require.NoError(t, admin.UnpauseTopic(ctx, "topic"))
+	require.NoError(t, admin.PauseChannel(ctx, "topic", "channel"))
+	require.NoError(t, admin.UnpauseChannel(ctx, "topic", "channel"))
+	require.NoError(t, admin.DeleteChannel(ctx, "topic", "channel"))
+	require.NoError(t, admin.DeleteTopic(ctx, "topic"))
+
+	wantNSQD := []string{
+		"POST /topic/create?topic=topic",
+		"POST /channel/create?channel=channel&topic=topic",
+		"POST /topic/pause?topic=topic",
+		"POST /topic/unpause?topic=topic",
+		"POST /channel/pause?channel=channel&topic=topic",
+		"POST /channel/unpause?channel=channel&topic=topic",
+		"POST /channel/delete?channel=channel&topic=topic",
+		"POST /topic/delete?topic=topic",
+	}
+	if diff := cmp.Diff(wantNSQD, nsqd1.Requests()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+	if diff := cmp.Diff(wantNSQD, nsqd2.Requests()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	wantLookupd := []string{
+		"POST /channel/delete?channel=channel&topic=topic",
+		"POST /topic/delete?topic=topic",
+	}
+	if diff := cmp.Diff(wantLookupd, lookupd.Requests()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestAdminError(t *testing.T) {
+	_, endpoint1 := newFakeNSQAPI(t, nil)
+	_, endpoint2 := newFakeNSQAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "TOPIC_NOT_FOUND", http.StatusNotFound)
+	})
+
+	admin, err := NewAdmin(&AdminConfig{NSQDs: []string{endpoint1, endpoint2}})
+	require.NoError(t, err)
+
+	err = admin.PauseTopic(context.Background(), "topic")
+	require.EqualError(t, err, fmt.Sprintf("nsqd %s: POST /topic/pause returned status 404: TOPIC_NOT_FOUND\n", endpoint2))
+}
+
+func TestAdminDepthStatsWithLookupd(t *testing.T) {
+	statsHandler := func(depth int64, paused bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/stats", r.URL.Path)
+			require.Equal(t, "topic", r.URL.Query().Get("topic"))
+			_, _ = fmt.Fprintf(w, `{"topics":[{"topic_name":"topic","depth":%d,"channels":[{"channel_name":"a","depth":%d,"in_flight_count":1,"paused":%t},{"channel_name":"b","depth":1,"deferred_count":2}]}]}`, depth, depth, paused)
+		}
+	}
+
+	_, endpoint1 := newFakeNSQAPI(t, statsHandler(3, false))
+	_, endpoint2 := newFakeNSQAPI(t, statsHandler(4, true))
+
+	producer := func(endpoint string) string {
+		host, port, err := net.SplitHostPort(endpoint)
+		require.NoError(t, err)
+		p, err := strconv.Atoi(port)
+		require.NoError(t, err)
+		return fmt.Sprintf(`{"broadcast_address":%q,"tcp_port":4150,"http_port":%d}`, host, p)
+	}
+
+	_, lookupd1 := newFakeNSQAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"producers":[%s,%s]}`, producer(endpoint1), producer(endpoint2))
+	})
+	// nsqlookupd before v1.0 wraps the response into a data field
+	_, lookupd2 := newFakeNSQAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"status_code":200,"data":{"producers":[%s]}}`, producer(endpoint2))
+	})
+
+	admin, err := NewAdmin(&AdminConfig{Lookupds: []string{lookupd1, lookupd2}})
+	require.NoError(t, err)
+
+	nsqds, err := admin.NSQDs(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{endpoint1, endpoint2}, nsqds)
+
+	got, err := admin.DepthStats(context.Background(), "topic")
+	require.NoError(t, err)
+
+	want := &DepthStats{
+		Topic: "topic",
+		Depth: 7,
+		Channels: map[string]ChannelDepth{
+			"a": {Depth: 7, InFlightCount: 2, Paused: true},
+			"b": {Depth: 2, DeferredCount: 4},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestAdminConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *AdminConfig
+		wantErr string
+	}{
+		{
+			name:    "nil",
+			wantErr: "admin config must not be nil",
+		},
+		{
+			name:    "no endpoints",
+			cfg:     &AdminConfig{},
+			wantErr: "at least one of NSQDs or Lookupds must be given",
+		},
+		{
+			name:    "invalid nsqd",
+			cfg:     &AdminConfig{NSQDs: []string{"localhost"}},
+			wantErr: `NSQDs[0] "localhost" must be of the form host:port: address localhost: missing port in address`,
+		},
+		{
+			name:    "partial tls",
+			cfg:     &AdminConfig{Lookupds: []string{"localhost:4161"}, TLS: &TLSConfig{CACertFile: "ca.pem"}},
+			wantErr: "tls: ClientCertFile must be set when CACertFile is set",
+		},
+		{
+			name: "valid",
+			cfg:  &AdminConfig{NSQDs: []string{"localhost:4151"}, Lookupds: []string{"localhost:4161"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package bus
+
+import (
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+var (
+	backoffEnterRegex = regexp.MustCompile(`\] backing off for (\S+) \(backoff level (\d+)\)`)
+	backoffExitRegex  = regexp.MustCompile(`\] exiting backoff`)
+)
+
+// BackoffEvent describes a change of the backoff state of a consumer.
+type BackoffEvent struct {
+	Topic   string
+	Channel string
+	// Duration is the time the consumer stops receiving messages, zero when exiting backoff.
+	Duration time.Duration
+	// Level is the amount of consecutive failures that led to the backoff, zero when exiting backoff.
+	Level int
+}
+
+// OnBackoff registers callbacks that are called when a consumer enters backoff because handling messages failed
+// and when it exits backoff again. onEnter is called again for every further failure that increases the backoff level.
+// Either callback may be nil.
+func OnBackoff(onEnter, onExit func(BackoffEvent)) Option {
+	return func(c *Consumer) *Consumer {
+		c.onBackoffEnter = onEnter
+		c.onBackoffExit = onExit
+		return c
+	}
+}
+
+// ExponentialJitterBackoff backs off for a random duration between zero and base * 2^level on failures,
+// never exceeding max. Spreading retries randomly prevents retry storms of many consumers failing at once.
+func ExponentialJitterBackoff(base, max time.Duration) Option {
+	return backoff(&exponentialBackoff{base: base, jitter: true}, max)
+}
+
+// ExponentialBackoff backs off for base * 2^level on failures, never exceeding max. This is the default of nsq.
+func ExponentialBackoff(base, max time.Duration) Option {
+	return backoff(&exponentialBackoff{base: base}, max)
+}
+
+// ConstantBackoff backs off for the given duration on every failure.
+func ConstantBackoff(d time.Duration) Option {
+	return backoff(constantBackoff(d), d)
+}
+
+// NoBackoff disables backing off, failed messages are only requeued.
+func NoBackoff() Option {
+	// nsq does not back off if the strategy exceeds the maximum backoff duration already on the first attempt
+	return backoff(constantBackoff(time.Second), 0)
+}
+
+func backoff(strategy nsq.BackoffStrategy, max time.Duration) Option {
+	return func(c *Consumer) *Consumer {
+		c.config.BackoffStrategy = strategy
+		c.config.MaxBackoffDuration = max
+		return c
+	}
+}
+
+type exponentialBackoff struct {
+	base   time.Duration
+	jitter bool
+}
+
+func (s *exponentialBackoff) Calculate(attempt int) time.Duration {
+	// limit the exponent to prevent overflows, nsq caps the duration anyway
+	d := s.base * time.Duration(math.Pow(2, float64(min(attempt, 30))))
+	if s.jitter && d > 0 {
+		return time.Duration(rand.Int64N(int64(d)))
+	}
+	return d
+}
+
+type constantBackoff time.Duration
+
+func (s constantBackoff) Calculate(_ int) time.Duration {
+	return time.Duration(s)
+}
+
+// notifyBackoff parses the nsq log messages for backoff state changes as nsq does not offer hooks for this.
+func (cr *ConsumerRegistration) notifyBackoff(msg string) {
+	if cr.consumer.onBackoffEnter != nil {
+		if match := backoffEnterRegex.FindStringSubmatch(msg); match != nil {
+			d, err := time.ParseDuration(match[1])
+			if err != nil {
+				return
+			}
+			level, err := strconv.Atoi(match[2])
+			if err != nil {
+				return
+			}
+
+			cr.consumer.onBackoffEnter(BackoffEvent{
+				Topic:    cr.topic,
+				Channel:  cr.channel,
+				Duration: d,
+				Level:    level,
+			})
+			return
+		}
+	}
+
+	if cr.consumer.onBackoffExit != nil && backoffExitRegex.MatchString(msg) {
+		cr.consumer.onBackoffExit(BackoffEvent{
+			Topic:   cr.topic,
+			Channel: cr.channel,
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package bus
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffPresets(t *testing.T) {
+	tests := []struct {
+		name    string
+		opt     Option
+		attempt int
+		wantMax time.Duration
+		want    func(t *testing.T, d time.Duration)
+	}{
+		{
+			name:    "exponential",
+			opt:     ExponentialBackoff(time.Second, time.Minute),
+			attempt: 3,
+			wantMax: time.Minute,
+			want: func(t *testing.T, d time.Duration) {
+				require.Equal(t, 8*time.Second, d)
+			},
+		},
+		{
+			name:    "exponential with jitter",
+			opt:     ExponentialJitterBackoff(time.Second, time.Minute),
+			attempt: 3,
+			wantMax: time.Minute,
+			want: func(t *testing.T, d time.Duration) {
+				require.GreaterOrEqual(t, d, time.Duration(0))
+				require.Less(t, d, 8*time.Second)
+			},
+		},
+		{
+			name:    "constant",
+			opt:     ConstantBackoff(3 * time.Second),
+			attempt: 5,
+			wantMax: 3 * time.Second,
+			want: func(t *testing.T, d time.Duration) {
+				require.Equal(t, 3*time.Second, d)
+			},
+		},
+		{
+			name:    "none",
+			opt:     NoBackoff(),
+			attempt: 1,
+			wantMax: 0,
+			want: func(t *testing.T, d time.Duration) {
+				require.Greater(t, d, time.Duration(0), "first backoff must exceed max backoff duration to disable backoff")
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewConsumer(slog.Default(), nil)
+			require.NoError(t, err)
+
+			c.With(tt.opt)
+
+			require.Equal(t, tt.wantMax, c.config.MaxBackoffDuration)
+			tt.want(t, c.config.BackoffStrategy.Calculate(tt.attempt))
+		})
+	}
+}
+
+func TestRegisterWithBackoffOverride(t *testing.T) {
+	c, err := NewConsumer(slog.Default(), nil)
+	require.NoError(t, err)
+
+	c.With(ConstantBackoff(time.Second))
+
+	cr, err := c.Register("topic", "channel", NoBackoff())
+	require.NoError(t, err)
+
+	require.Equal(t, time.Second, c.config.MaxBackoffDuration)
+	require.Equal(t, time.Duration(0), cr.consumer.config.MaxBackoffDuration)
+}
+
+func TestOnBackoff(t *testing.T) {
+	var events []BackoffEvent
+
+	c, err := NewConsumer(slog.Default(), nil)
+	require.NoError(t, err)
+
+	cr, err := c.With(OnBackoff(
+		func(e BackoffEvent) { events = append(events, e) },
+		func(e BackoffEvent) { events = append(events, e) },
+	)).Register("topic", "channel")
+	require.NoError(t, err)
+
+	for _, msg := range []string{
+		"INF    1 [topic/channel] querying nsqlookupd http://localhost:4161/lookup?topic=topic",
+		"WRN    1 [topic/channel] backing off for 2s (backoff level 1), setting all to RDY 0",
+		"WRN    1 [topic/channel] backing off for 4s (backoff level 2), setting all to RDY 0",
+		"WRN    1 [topic/channel] exiting backoff, returning all to RDY 10",
+	} {
+		require.NoError(t, cr.Output(2, msg))
+	}
+
+	want := []BackoffEvent{
+		{Topic: "topic", Channel: "channel", Duration: 2 * time.Second, Level: 1},
+		{Topic: "topic", Channel: "channel", Duration: 4 * time.Second, Level: 2},
+		{Topic: "topic", Channel: "channel"},
+	}
+	if diff := cmp.Diff(want, events); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
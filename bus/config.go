@@ -0,0 +1,139 @@
+package bus
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Validate returns an error describing the misconfigured field if the TLS config is incomplete or its files are unusable.
+// An empty TLS config is valid and disables TLS, a partially filled one is invalid as TLS would be disabled silently.
+func (cfg *TLSConfig) Validate() error {
+	if cfg == nil || (cfg.CACertFile == "" && cfg.ClientCertFile == "") {
+		return nil
+	}
+
+	if cfg.CACertFile == "" {
+		return fmt.Errorf("tls: CACertFile must be set when ClientCertFile is set")
+	}
+	if cfg.ClientCertFile == "" {
+		return fmt.Errorf("tls: ClientCertFile must be set when CACertFile is set")
+	}
+
+	ca, err := os.ReadFile(cfg.CACertFile)
+	if err != nil {
+		return fmt.Errorf("tls: CACertFile: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(ca) {
+		return fmt.Errorf("tls: CACertFile: no certificate found in %q", cfg.CACertFile)
+	}
+
+	cert, err := LoadCertificate(cfg.ClientCertFile)
+	if err != nil {
+		return fmt.Errorf("tls: ClientCertFile: %w", err)
+	}
+	if cert.PrivateKey == nil {
+		return fmt.Errorf("tls: ClientCertFile: no private key found in %q", cfg.ClientCertFile)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("tls: ClientCertFile: %w", err)
+	}
+
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("tls: ClientCertFile: unsupported private key in %q", cfg.ClientCertFile)
+	}
+
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(leaf.PublicKey) {
+		return fmt.Errorf("tls: ClientCertFile: private key does not match the certificate in %q", cfg.ClientCertFile)
+	}
+
+	return nil
+}
+
+// Validate returns an error describing the misconfigured field, such that misconfigurations are detected when
+// creating the publisher instead of on the first publish.
+func (p *PublisherConfig) Validate() error {
+	if p == nil {
+		return errors.New("publisher config must not be nil")
+	}
+
+	if err := validateAddress("TCPAddress", p.TCPAddress); err != nil {
+		return err
+	}
+
+	if p.HTTPEndpoint != "" {
+		if err := validateAddress("HTTPEndpoint", p.HTTPEndpoint); err != nil {
+			return err
+		}
+	}
+
+	if err := p.TLS.Validate(); err != nil {
+		return err
+	}
+
+	if p.NSQ != nil {
+		if err := p.NSQ.Validate(); err != nil {
+			return fmt.Errorf("NSQ: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate returns an error describing the misconfigured field, such that misconfigurations are detected when
+// creating the admin instead of on the first request.
+func (a *AdminConfig) Validate() error {
+	if a == nil {
+		return errors.New("admin config must not be nil")
+	}
+
+	if len(a.NSQDs) == 0 && len(a.Lookupds) == 0 {
+		return errors.New("at least one of NSQDs or Lookupds must be given")
+	}
+
+	if err := validateAddresses("NSQDs", a.NSQDs); err != nil {
+		return err
+	}
+
+	if err := validateAddresses("Lookupds", a.Lookupds); err != nil {
+		return err
+	}
+
+	if a.Timeout < 0 {
+		return fmt.Errorf("Timeout must not be negative")
+	}
+
+	return a.TLS.Validate()
+}
+
+func validateAddresses(field string, addresses []string) error {
+	for i, address := range addresses {
+		if err := validateAddress(fmt.Sprintf("%s[%d]", field, i), address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateAddress(field, address string) error {
+	if address == "" {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%s %q must be of the form host:port: %w", field, address, err)
+	}
+	if host == "" || port == "" {
+		return fmt.Errorf("%s %q must contain a host and a port", field, address)
+	}
+
+	return nil
+}
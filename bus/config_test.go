@@ -0,0 +1,176 @@
+package bus
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and a key to a file and returns its path,
+// the key does not match the certificate if mismatch is true.
+func writeCertificate(t *testing.T, name string, withKey, mismatch bool) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	raw := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	if withKey {
+		if mismatch {
+			key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+		}
+
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		raw = append(raw, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})...)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, raw, 0600))
+
+	return path
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	var (
+		ca          = writeCertificate(t, "ca.pem", false, false)
+		client      = writeCertificate(t, "client.pem", true, false)
+		clientNoKey = writeCertificate(t, "client-no-key.pem", false, false)
+		mismatched  = writeCertificate(t, "client-mismatch.pem", true, true)
+	)
+
+	tests := []struct {
+		name    string
+		cfg     *TLSConfig
+		wantErr error
+	}{
+		{
+			name: "nil config disables tls",
+		},
+		{
+			name: "empty config disables tls",
+			cfg:  &TLSConfig{},
+		},
+		{
+			name: "valid config",
+			cfg:  &TLSConfig{CACertFile: ca, ClientCertFile: client},
+		},
+		{
+			name:    "missing client cert",
+			cfg:     &TLSConfig{CACertFile: ca},
+			wantErr: fmt.Errorf("tls: ClientCertFile must be set when CACertFile is set"),
+		},
+		{
+			name:    "missing ca cert",
+			cfg:     &TLSConfig{ClientCertFile: client},
+			wantErr: fmt.Errorf("tls: CACertFile must be set when ClientCertFile is set"),
+		},
+		{
+			name:    "client cert without key",
+			cfg:     &TLSConfig{CACertFile: ca, ClientCertFile: clientNoKey},
+			wantErr: fmt.Errorf("tls: ClientCertFile: no private key found in %q", clientNoKey),
+		},
+		{
+			name:    "key does not match client cert",
+			cfg:     &TLSConfig{CACertFile: ca, ClientCertFile: mismatched},
+			wantErr: fmt.Errorf("tls: ClientCertFile: private key does not match the certificate in %q", mismatched),
+		},
+		{
+			name:    "missing ca file",
+			cfg:     &TLSConfig{CACertFile: filepath.Join(filepath.Dir(client), "missing.pem"), ClientCertFile: client},
+			wantErr: fmt.Errorf("tls: CACertFile: %w", fmt.Errorf("open %s: no such file or directory", filepath.Join(filepath.Dir(client), "missing.pem"))),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestPublisherConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *PublisherConfig
+		wantErr error
+	}{
+		{
+			name: "valid config",
+			cfg:  &PublisherConfig{TCPAddress: "nsqd:4150", HTTPEndpoint: "nsqd:4151"},
+		},
+		{
+			name:    "nil config",
+			wantErr: fmt.Errorf("publisher config must not be nil"),
+		},
+		{
+			name:    "empty tcp address",
+			cfg:     &PublisherConfig{},
+			wantErr: fmt.Errorf("TCPAddress must not be empty"),
+		},
+		{
+			name:    "tcp address without port",
+			cfg:     &PublisherConfig{TCPAddress: "nsqd"},
+			wantErr: fmt.Errorf(`TCPAddress "nsqd" must be of the form host:port: %w`, errors.New("address nsqd: missing port in address")),
+		},
+		{
+			name:    "http endpoint without host",
+			cfg:     &PublisherConfig{TCPAddress: "nsqd:4150", HTTPEndpoint: ":4151"},
+			wantErr: fmt.Errorf(`HTTPEndpoint ":4151" must contain a host and a port`),
+		},
+		{
+			name:    "partial tls config",
+			cfg:     &PublisherConfig{TCPAddress: "nsqd:4150", TLS: &TLSConfig{CACertFile: "ca.pem"}},
+			wantErr: fmt.Errorf("tls: ClientCertFile must be set when CACertFile is set"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestConsumerAddressValidation(t *testing.T) {
+	_, err := NewConsumer(slog.Default(), nil, "lookupd:4161", "")
+	require.EqualError(t, err, "invalid consumer config: lookupds[1] must not be empty")
+
+	c, err := NewConsumer(slog.Default(), nil)
+	require.NoError(t, err)
+
+	_, err = c.Register("topic", "channel", NSQDs("nsqd"))
+	require.EqualError(t, err, `invalid consumer config: nsqds[0] "nsqd" must be of the form host:port: address nsqd: missing port in address`)
+}
@@ -9,6 +9,11 @@ import (
 
 const (
 	defaultWriteTimeout = 10 * time.Second
+
+	defaultLookupdPollInterval = 5 * time.Second
+	defaultHeartbeatInterval   = 5 * time.Second
+	defaultRequeueDelay        = 5 * time.Second
+	defaultMaxInFlight         = 10
 )
 
 // ConfigureTLS configures the publisher regarding NSQ.
@@ -0,0 +1,332 @@
+package bus
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+const (
+	defaultDedupWindow = 10 * time.Minute
+	defaultDedupSize   = 10000
+)
+
+// DedupStore remembers the keys of successfully handled messages, such that duplicate deliveries of nsq can be
+// suppressed. Implementations must be safe for concurrent use.
+type DedupStore interface {
+	// Seen returns true if the given key was marked and did not expire yet.
+	Seen(key string) (bool, error)
+	// Mark records the given key as handled, the key expires after the given window.
+	Mark(key string, window time.Duration) error
+}
+
+// DedupKeyFunc returns the key identifying a message for deduplication, messages with an empty key are not deduplicated.
+type DedupKeyFunc func(message *nsq.Message) string
+
+// DedupConfig configures the deduplication of messages, see Deduplicate.
+type DedupConfig struct {
+	// Store remembers the handled messages, defaults to an in-memory store with the last 10000 keys.
+	// A shared store like redis is required to deduplicate across multiple consumer processes.
+	Store DedupStore
+	// Window is the duration in which duplicates are suppressed, defaults to 10 minutes.
+	Window time.Duration
+	// Key returns the key of a message, defaults to MessageIDKey.
+	Key DedupKeyFunc
+}
+
+// Deduplicate suppresses duplicate invocations of the handler for messages with the same key within the window of
+// the given config. Keys are marked after the handler succeeded, such that failed messages are retried.
+// Duplicates that are delivered concurrently to the first delivery may still be handled twice.
+func Deduplicate(c DedupConfig) crOption {
+	return func(cr *ConsumerRegistration) *ConsumerRegistration {
+		if c.Store == nil {
+			c.Store = NewMemoryDedupStore(defaultDedupSize)
+		}
+		if c.Window <= 0 {
+			c.Window = defaultDedupWindow
+		}
+		if c.Key == nil {
+			c.Key = MessageIDKey
+		}
+		cr.dedup = &c
+		return cr
+	}
+}
+
+// MessageIDKey returns the id of function invocations published with deduplication, see Endpoints.WithDeduplication,
+// and the nsq message id for all other messages. The nsq message id stays the same for redeliveries of a message,
+// e.g. when the handler finished too late, but differs for messages that were published twice.
+func MessageIDKey(message *nsq.Message) string {
+	var envelope struct {
+		ID string `json:"__id"`
+	}
+	if json.Unmarshal(message.Body, &envelope) == nil && envelope.ID != "" {
+		return envelope.ID
+	}
+	return hex.EncodeToString(message.ID[:])
+}
+
+// isDuplicate returns true if the message was already handled. Store errors are logged and the message is handled
+// as deduplication is best effort.
+func (tw *timeoutWrapper) isDuplicate(message *nsq.Message) (string, bool) {
+	if tw.dedup == nil {
+		return "", false
+	}
+
+	key := tw.dedup.Key(message)
+	if key == "" {
+		return "", false
+	}
+
+	seen, err := tw.dedup.Store.Seen(key)
+	if err != nil {
+		if tw.log != nil {
+			tw.log.Error("unable to look up message for deduplication", "id", string(message.ID[:]), "key", key, "error", err)
+		}
+		return key, false
+	}
+
+	if seen && tw.log != nil {
+		tw.log.Info("dropping duplicate message", "id", string(message.ID[:]), "key", key)
+	}
+
+	return key, seen
+}
+
+func (tw *timeoutWrapper) markHandled(message *nsq.Message, key string) {
+	if tw.dedup == nil || key == "" {
+		return
+	}
+
+	err := tw.dedup.Store.Mark(key, tw.dedup.Window)
+	if err != nil && tw.log != nil {
+		tw.log.Error("unable to mark message for deduplication", "id", string(message.ID[:]), "key", key, "error", err)
+	}
+}
+
+// memoryDedupStore is a least recently used cache of keys.
+type memoryDedupStore struct {
+	mu      sync.Mutex
+	size    int
+	now     func() time.Time
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemoryDedupStore returns a store, which keeps up to size keys in memory and evicts the least recently marked
+// keys first. Duplicates are only suppressed within a single process.
+func NewMemoryDedupStore(size int) DedupStore {
+	if size <= 0 {
+		size = defaultDedupSize
+	}
+	return &memoryDedupStore{
+		size:    size,
+		now:     time.Now,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (s *memoryDedupStore) Seen(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return false, nil
+	}
+
+	if s.now().After(e.Value.(*dedupEntry).expires) {
+		s.order.Remove(e)
+		delete(s.entries, key)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (s *memoryDedupStore) Mark(key string, window time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mark(key, s.now().Add(window))
+
+	return nil
+}
+
+func (s *memoryDedupStore) mark(key string, expires time.Time) {
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*dedupEntry).expires = expires
+		s.order.MoveToFront(e)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&dedupEntry{key: key, expires: expires})
+
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*dedupEntry).key)
+	}
+}
+
+// fileDedupStore keeps the keys in memory and appends marked keys to a file, such that duplicates are also
+// suppressed after a restart of the process.
+type fileDedupStore struct {
+	*memoryDedupStore
+
+	path string
+	file *os.File
+}
+
+// NewFileDedupStore returns a store, which persists marked keys in the file at the given path. On creation, the
+// keys that did not expire yet are loaded and the file is compacted. At most size keys are kept, see NewMemoryDedupStore.
+func NewFileDedupStore(path string, size int) (DedupStore, error) {
+	s := &fileDedupStore{
+		memoryDedupStore: NewMemoryDedupStore(size).(*memoryDedupStore),
+		path:             path,
+	}
+
+	err := s.load()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load dedup store: %w", err)
+	}
+
+	err = s.compact()
+	if err != nil {
+		return nil, fmt.Errorf("unable to compact dedup store: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *fileDedupStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	now := s.now()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, expiresUnix, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+
+		nanos, err := strconv.ParseInt(expiresUnix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		expires := time.Unix(0, nanos)
+		if now.After(expires) {
+			continue
+		}
+
+		s.mark(key, expires)
+	}
+
+	return scanner.Err()
+}
+
+// compact rewrites the file with the keys that are currently held in memory.
+func (s *fileDedupStore) compact() error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for e := s.order.Back(); e != nil; e = e.Prev() {
+		entry := e.Value.(*dedupEntry)
+		fmt.Fprintf(w, "%s\t%d\n", entry.key, entry.expires.UnixNano())
+	}
+
+	err = w.Flush()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp, s.path)
+	if err != nil {
+		return err
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	return err
+}
+
+func (s *fileDedupStore) Mark(key string, window time.Duration) error {
+	if strings.ContainsAny(key, "\t\n") {
+		return fmt.Errorf("key %q must not contain tabs or newlines", key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires := s.now().Add(window)
+	s.mark(key, expires)
+
+	_, err := fmt.Fprintf(s.file, "%s\t%d\n", key, expires.UnixNano())
+	return err
+}
+
+// RedisClient is the subset of a redis client used by the redis dedup store. It is implemented by a small adapter
+// around the redis client of choice, such that this package does not depend on a specific redis client.
+type RedisClient interface {
+	// Exists returns true if the given key exists.
+	Exists(key string) (bool, error)
+	// Set sets the given key with an expiration.
+	Set(key, value string, expiration time.Duration) error
+}
+
+type redisDedupStore struct {
+	client RedisClient
+	prefix string
+}
+
+// NewRedisDedupStore returns a store, which records marked keys with the given prefix in redis, such that
+// duplicates are suppressed across all consumer processes sharing the redis.
+func NewRedisDedupStore(client RedisClient, prefix string) DedupStore {
+	return &redisDedupStore{client: client, prefix: prefix}
+}
+
+func (s *redisDedupStore) Seen(key string) (bool, error) {
+	return s.client.Exists(s.prefix + key)
+}
+
+func (s *redisDedupStore) Mark(key string, window time.Duration) error {
+	return s.client.Set(s.prefix+key, "1", window)
+}
@@ -0,0 +1,139 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutWrapper_Deduplicate(t *testing.T) {
+	var (
+		calls   int
+		failing = true
+	)
+
+	cr := Deduplicate(DedupConfig{})(&ConsumerRegistration{})
+	tw := &timeoutWrapper{
+		msgType: reflect.TypeOf(""),
+		recv: func(interface{}) error {
+			calls++
+			if failing {
+				return errors.New("failed")
+			}
+			return nil
+		},
+		dedup: cr.dedup,
+	}
+
+	msg := nsq.NewMessage(nsq.MessageID{'1'}, []byte(`"payload"`))
+
+	// failed messages are not marked and handled again
+	require.Error(t, tw.handle(msg))
+	failing = false
+	require.NoError(t, tw.handle(msg))
+	require.Equal(t, 2, calls)
+
+	// redeliveries of a handled message are dropped
+	require.NoError(t, tw.handle(msg))
+	require.Equal(t, 2, calls)
+
+	// other messages are handled
+	require.NoError(t, tw.handle(nsq.NewMessage(nsq.MessageID{'2'}, []byte(`"payload"`))))
+	require.Equal(t, 3, calls)
+}
+
+func TestMessageIDKey(t *testing.T) {
+	body, err := json.Marshal(functionEnvelope{ID: "invocation-1", Payload: json.RawMessage(`"payload"`)})
+	require.NoError(t, err)
+
+	require.Equal(t, "invocation-1", MessageIDKey(nsq.NewMessage(nsq.MessageID{'1'}, body)))
+	require.Equal(t, "invocation-1", MessageIDKey(nsq.NewMessage(nsq.MessageID{'2'}, body)))
+	require.Equal(t, "31000000000000000000000000000000", MessageIDKey(nsq.NewMessage(nsq.MessageID{'1'}, []byte(`"payload"`))))
+}
+
+func TestMemoryDedupStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewMemoryDedupStore(2).(*memoryDedupStore)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Mark("a", time.Minute))
+	require.NoError(t, s.Mark("b", time.Hour))
+
+	seen, err := s.Seen("a")
+	require.NoError(t, err)
+	require.True(t, seen)
+
+	// the least recently marked key is evicted
+	require.NoError(t, s.Mark("c", time.Hour))
+	seen, err = s.Seen("a")
+	require.NoError(t, err)
+	require.False(t, seen)
+
+	// keys expire after the window
+	now = now.Add(2 * time.Hour)
+	seen, err = s.Seen("b")
+	require.NoError(t, err)
+	require.False(t, seen)
+}
+
+func TestFileDedupStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup", "keys")
+
+	s, err := NewFileDedupStore(path, 10)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Mark("a", time.Hour))
+	require.NoError(t, s.Mark("b", -time.Hour))
+	require.EqualError(t, s.Mark("c\n", time.Hour), `key "c\n" must not contain tabs or newlines`)
+
+	// a new process loads the keys, which did not expire yet
+	restarted, err := NewFileDedupStore(path, 10)
+	require.NoError(t, err)
+
+	seen, err := restarted.Seen("a")
+	require.NoError(t, err)
+	require.True(t, seen)
+
+	seen, err = restarted.Seen("b")
+	require.NoError(t, err)
+	require.False(t, seen)
+}
+
+type fakeRedis struct {
+	mu   sync.Mutex
+	keys map[string]time.Duration
+}
+
+func (r *fakeRedis) Exists(key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.keys[key]
+	return ok, nil
+}
+
+func (r *fakeRedis) Set(key, value string, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key] = expiration
+	return nil
+}
+
+func TestRedisDedupStore(t *testing.T) {
+	redis := &fakeRedis{keys: map[string]time.Duration{}}
+	s := NewRedisDedupStore(redis, "dedup:")
+
+	require.NoError(t, s.Mark("a", time.Minute))
+	require.Equal(t, map[string]time.Duration{"dedup:a": time.Minute}, redis.keys)
+
+	seen, err := s.Seen("a")
+	require.NoError(t, err)
+	require.True(t, seen)
+}
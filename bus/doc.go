@@ -7,6 +7,15 @@
   `Consumer` and `Publisher` are thin wrappers around the basic concept of
   nsq with small additions.
 
+  Errors
+
+  Handlers control the delivery of a message with the returned error. Errors wrapped with `Terminal`
+  cannot be resolved by retrying, the message is finished and published to the dead letter topic if
+  one is configured with `DeadLetterTopic`. Errors wrapped with `Retryable` requeue the message with
+  the given delay without backing off. All other errors requeue the message with backoff.
+
+     return bus.Retryable(err, 30*time.Second)
+
   Functions
 
   A `Function` abstracts an asynchronous function which will be called by marshalling the
@@ -50,5 +59,25 @@
   can be used to transport responses from well known services back to clients. The client has
   to register a unique consumer and pass the name of this function to the service which will post
   back the response back to the client.
+
+  Versioned Functions
+
+  When the payload of a function changes incompatibly, register a handler per payload version with
+  `VersionedFunction`. Invocations are dispatched by the version of their payload, unversioned invocations
+  are handled by version 1. The invocations per version are counted by `VersionMetrics`, so the handler of
+  an old version can be removed once no producer publishes it anymore.
+
+     _, f, err := ep.VersionedFunction("machine-create", bus.Versions{
+        1: func(m MachineV1) error { ... },
+        2: func(m MachineV2) error { ... },
+     }, 2)
+
+  Load Tests
+
+  `RunLoadTest` publishes messages with a configurable rate and size and measures the throughput,
+  the end to end latency percentiles and the requeues of the consumer. The integration tests contain
+  a soak test and benchmarks against an nsqd started with testcontainers:
+
+    make bus-loadtest
 */
 package bus
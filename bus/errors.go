@@ -0,0 +1,157 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+// TerminalError marks a failure of a handler that cannot be resolved by retrying, e.g. invalid payloads or
+// entities that do not exist anymore. The message is finished and sent to the dead letter topic if configured.
+type TerminalError struct {
+	err error
+}
+
+// Terminal marks the given error as terminal, such that the message is not retried. Returns nil for a nil error.
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TerminalError{err: err}
+}
+
+func (e *TerminalError) Error() string {
+	return "terminal: " + e.err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.err
+}
+
+// RetryableError marks a failure of a handler that is expected to resolve itself after the given delay,
+// e.g. a dependency that is temporarily unavailable. The message is requeued with this delay without
+// causing the consumer to back off.
+type RetryableError struct {
+	err error
+	// After is the delay until the message is delivered again, the default requeue delay of the consumer is used if zero.
+	After time.Duration
+}
+
+// Retryable marks the given error as retryable after the given delay. Returns nil for a nil error.
+func Retryable(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryableError{err: err, After: after}
+}
+
+func (e *RetryableError) Error() string {
+	return "retryable: " + e.err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.err
+}
+
+// IsTerminal returns true if the given error or one of the errors it wraps is terminal.
+func IsTerminal(err error) bool {
+	var terminal *TerminalError
+	return errors.As(err, &terminal)
+}
+
+// RetryAfter returns the delay of a retryable error and false for errors that are not retryable explicitly.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		return retryable.After, true
+	}
+	return 0, false
+}
+
+// DeadLetter is published to the dead letter topic for messages that failed with a terminal error.
+type DeadLetter struct {
+	Topic    string          `json:"topic"`
+	Channel  string          `json:"channel"`
+	Error    string          `json:"error"`
+	Attempts uint16          `json:"attempts"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// DeadLetterTopic publishes messages that failed with a terminal error to the given topic, such that these
+// can be inspected and replayed later on. Without a dead letter topic, these messages are dropped.
+func DeadLetterTopic(publisher Publisher, topic string) crOption {
+	return func(cr *ConsumerRegistration) *ConsumerRegistration {
+		cr.deadLetterPublisher = publisher
+		cr.deadLetterTopic = topic
+		return cr
+	}
+}
+
+// respond interprets the error returned by a handler. Terminal errors finish the message, retryable errors requeue
+// the message with the given delay and all other errors are returned to nsq, which requeues the message with backoff.
+func (tw *timeoutWrapper) respond(message *nsq.Message, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	tw.report(message, err)
+
+	if IsTerminal(err) {
+		tw.deadLetter(message, err)
+		return nil
+	}
+
+	if after, ok := RetryAfter(err); ok {
+		if tw.log != nil {
+			tw.log.Info("requeuing message", "id", string(message.ID[:]), "after", after, "error", err)
+		}
+
+		if after <= 0 {
+			after = -1
+		}
+
+		message.RequeueWithoutBackoff(after)
+
+		// the message was already responded to, so nsq does not respond again
+		return nil
+	}
+
+	return err
+}
+
+func (tw *timeoutWrapper) deadLetter(message *nsq.Message, err error) {
+	if tw.deadLetterPublisher == nil {
+		if tw.log != nil {
+			tw.log.Error("dropping message due to terminal error", "id", string(message.ID[:]), "error", err)
+		}
+		return
+	}
+
+	body := json.RawMessage(message.Body)
+	if !json.Valid(body) {
+		// the body must be valid json to be embedded, invalid payloads are embedded as string instead
+		raw, _ := json.Marshal(string(message.Body))
+		body = raw
+	}
+
+	pubErr := tw.deadLetterPublisher.Publish(tw.deadLetterTopic, DeadLetter{
+		Topic:    tw.topic,
+		Channel:  tw.channel,
+		Error:    err.Error(),
+		Attempts: message.Attempts,
+		Body:     body,
+	})
+	if pubErr != nil {
+		// the message is dropped anyway as retrying cannot resolve a terminal error
+		if tw.log != nil {
+			tw.log.Error("unable to publish message to dead letter topic", "id", string(message.ID[:]), "topic", tw.deadLetterTopic, "error", pubErr)
+		}
+		return
+	}
+
+	if tw.log != nil {
+		tw.log.Warn("sent message to dead letter topic due to terminal error", "id", string(message.ID[:]), "topic", tw.deadLetterTopic, "error", err)
+	}
+}
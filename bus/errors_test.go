@@ -0,0 +1,133 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingDelegate struct {
+	finished bool
+	requeued bool
+	delay    time.Duration
+	backoff  bool
+}
+
+func (d *recordingDelegate) OnFinish(*nsq.Message) { d.finished = true }
+func (d *recordingDelegate) OnRequeue(_ *nsq.Message, delay time.Duration, backoff bool) {
+	d.requeued = true
+	d.delay = delay
+	d.backoff = backoff
+}
+func (d *recordingDelegate) OnTouch(*nsq.Message) {}
+
+type recordingPublisher struct {
+	topic string
+	data  any
+}
+
+func (p *recordingPublisher) Publish(topic string, data interface{}) error {
+	p.topic = topic
+	p.data = data
+	return nil
+}
+func (p *recordingPublisher) CreateTopic(string) error { return nil }
+func (p *recordingPublisher) Stop()                    {}
+
+func TestErrorClassification(t *testing.T) {
+	err := fmt.Errorf("handling failed: %w", Terminal(errors.New("machine not found")))
+	require.True(t, IsTerminal(err))
+	require.EqualError(t, err, "handling failed: terminal: machine not found")
+
+	after, ok := RetryAfter(Retryable(errors.New("database unavailable"), time.Minute))
+	require.True(t, ok)
+	require.Equal(t, time.Minute, after)
+
+	_, ok = RetryAfter(errors.New("generic"))
+	require.False(t, ok)
+	require.False(t, IsTerminal(errors.New("generic")))
+
+	require.NoError(t, Terminal(nil))
+	require.NoError(t, Retryable(nil, time.Second))
+}
+
+func TestTimeoutWrapper_Respond(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantErr        bool
+		wantRequeued   bool
+		wantDelay      time.Duration
+		wantDeadLetter *DeadLetter
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:    "generic errors are returned to nsq",
+			err:     errors.New("generic"),
+			wantErr: true,
+		},
+		{
+			name:         "retryable errors are requeued without backoff",
+			err:          Retryable(errors.New("database unavailable"), time.Minute),
+			wantRequeued: true,
+			wantDelay:    time.Minute,
+		},
+		{
+			name: "terminal errors are sent to the dead letter topic",
+			err:  Terminal(errors.New("machine not found")),
+			wantDeadLetter: &DeadLetter{
+				Topic:    "machine",
+				Channel:  "metal-api",
+				Error:    "terminal: machine not found",
+				Attempts: 3,
+				Body:     json.RawMessage(`{"id":"m1"}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				delegate  = &recordingDelegate{}
+				publisher = &recordingPublisher{}
+				message   = &nsq.Message{Body: []byte(`{"id":"m1"}`), Attempts: 3, Delegate: delegate}
+			)
+
+			tw := timeoutWrapper{
+				msgType: reflect.TypeOf(map[string]string{}),
+				recv: func(any) error {
+					return tt.err
+				},
+				topic:               "machine",
+				channel:             "metal-api",
+				deadLetterPublisher: publisher,
+				deadLetterTopic:     "machine-dlq",
+			}
+
+			err := tw.handleWithTimeout(message)
+			require.Equal(t, tt.wantErr, err != nil)
+
+			require.Equal(t, tt.wantRequeued, delegate.requeued)
+			require.Equal(t, tt.wantDelay, delegate.delay)
+			require.False(t, delegate.backoff)
+
+			if tt.wantDeadLetter != nil {
+				require.Equal(t, "machine-dlq", publisher.topic)
+				if diff := cmp.Diff(*tt.wantDeadLetter, publisher.data); diff != "" {
+					t.Errorf("diff (+got -want):\n %s", diff)
+				}
+			} else {
+				require.Nil(t, publisher.data)
+			}
+		})
+	}
+}
@@ -41,6 +41,15 @@ type Consumer struct {
 	config   *nsq.Config
 	log      *slog.Logger
 	logLevel nsq.LogLevel
+
+	onBackoffEnter func(BackoffEvent)
+	onBackoffExit  func(BackoffEvent)
+
+	onHandlerError func(HandlerErrorEvent)
+	handlerMetrics *HandlerMetrics
+
+	snapshots        NSQDSnapshotStore
+	snapshotInterval time.Duration
 }
 
 type ConsumerRegistration struct {
@@ -48,12 +57,21 @@ type ConsumerRegistration struct {
 	log       *slog.Logger
 	c         *nsq.Consumer
 	connected bool
+	topic     string
+	channel   string
 
 	timeout   time.Duration
 	onTimeout OnTimeout
 
 	// time to live for message in nanos
 	ttl time.Duration
+
+	deadLetterPublisher Publisher
+	deadLetterTopic     string
+
+	dedup *DedupConfig
+
+	stopSnapshots func()
 }
 
 type Option func(registration *Consumer) *Consumer
@@ -107,12 +125,20 @@ func MaxInFlight(num int) Option {
 }
 
 // NewConsumer returns a consumer and stores the addresses of the lookupd's.
+// An error is returned if the TLS config or one of the addresses is invalid, see TLSConfig.Validate.
 func NewConsumer(log *slog.Logger, tlsCfg *TLSConfig, lookupds ...string) (*Consumer, error) {
+	if err := tlsCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid consumer config: %w", err)
+	}
+	if err := validateAddresses("lookupds", lookupds); err != nil {
+		return nil, fmt.Errorf("invalid consumer config: %w", err)
+	}
+
 	cfg := CreateNSQConfig(tlsCfg)
-	cfg.LookupdPollInterval = time.Second * 5
-	cfg.HeartbeatInterval = time.Second * 5
-	cfg.DefaultRequeueDelay = time.Second * 5
-	cfg.MaxInFlight = 10
+	cfg.LookupdPollInterval = defaultLookupdPollInterval
+	cfg.HeartbeatInterval = defaultHeartbeatInterval
+	cfg.DefaultRequeueDelay = defaultRequeueDelay
+	cfg.MaxInFlight = defaultMaxInFlight
 
 	return &Consumer{
 		config:   cfg,
@@ -129,15 +155,25 @@ func (c *Consumer) With(opts ...Option) *Consumer {
 	return c
 }
 
-func (c *Consumer) MustRegister(topic, channel string) *ConsumerRegistration {
-	cr, err := c.Register(topic, channel)
+func (c *Consumer) MustRegister(topic, channel string, opts ...Option) *ConsumerRegistration {
+	cr, err := c.Register(topic, channel, opts...)
 	if err != nil {
 		panic(err)
 	}
 	return cr
 }
 
-func (c *Consumer) Register(topic, channel string) (*ConsumerRegistration, error) {
+// Register creates a registration for the given topic and channel. The given options override
+// the options of the consumer for this registration only, e.g. to use a different backoff.
+func (c *Consumer) Register(topic, channel string, opts ...Option) (*ConsumerRegistration, error) {
+	if len(opts) > 0 {
+		c = c.clone().With(opts...)
+	}
+
+	if err := validateAddresses("nsqds", c.nsqds); err != nil {
+		return nil, fmt.Errorf("invalid consumer config: %w", err)
+	}
+
 	q, err := nsq.NewConsumer(topic, channel, c.config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create consumer for topic:%q, channel:%q: %w", topic, channel, err)
@@ -147,13 +183,29 @@ func (c *Consumer) Register(topic, channel string) (*ConsumerRegistration, error
 		consumer: c,
 		log:      c.log,
 		c:        q,
+		topic:    topic,
+		channel:  channel,
 	}
 
 	return cr, nil
 }
 
+func (c *Consumer) clone() *Consumer {
+	clone := *c
+	cfg := *c.config
+	clone.config = &cfg
+	return &clone
+}
+
 // FIXME: wtf is this
 func (cr *ConsumerRegistration) Output(num int, msg string) error {
+	cr.notifyBackoff(msg)
+
+	if nsqLogLevel(msg) < cr.consumer.logLevel {
+		// the nsq log level was lowered in order to receive backoff messages
+		return nil
+	}
+
 	bridgeNsqLogToCoreLog(msg, cr.log)
 	return nil
 }
@@ -181,9 +233,24 @@ type timeoutWrapper struct {
 	msgType   reflect.Type
 	recv      Receiver
 	log       *slog.Logger
+
+	topic               string
+	channel             string
+	deadLetterPublisher Publisher
+	deadLetterTopic     string
+
+	dedup *DedupConfig
+
+	onError func(HandlerErrorEvent)
+	metrics *HandlerMetrics
 }
 
+// handleWithTimeout handles the message and controls its delivery according to the returned error, see Terminal and Retryable.
 func (tw *timeoutWrapper) handleWithTimeout(message *nsq.Message) error {
+	return tw.respond(message, tw.handle(message))
+}
+
+func (tw *timeoutWrapper) handle(message *nsq.Message) error {
 
 	if tw.ttl > 0 {
 		// calculate the age of the message in nanos
@@ -201,6 +268,11 @@ func (tw *timeoutWrapper) handleWithTimeout(message *nsq.Message) error {
 		}
 	}
 
+	key, duplicate := tw.isDuplicate(message)
+	if duplicate {
+		return nil
+	}
+
 	newval := reflect.New(tw.msgType)
 	nv := newval.Elem().Addr().Interface()
 	err := json.Unmarshal(message.Body, nv)
@@ -208,14 +280,25 @@ func (tw *timeoutWrapper) handleWithTimeout(message *nsq.Message) error {
 		return err
 	}
 
+	// panics of the handler are recovered, otherwise the whole process crashes
+	recv := func(v interface{}) error {
+		err := recoverPanic(func() error {
+			return tw.recv(v)
+		})
+		if err == nil {
+			tw.markHandled(message, key)
+		}
+		return err
+	}
+
 	// timeout == 0 means synchronous call without timeout
 	if tw.timeout == 0 {
-		return tw.recv(nv)
+		return recv(nv)
 	}
 
 	c1 := make(chan error, 1)
 	go func() {
-		c1 <- tw.recv(nv)
+		c1 <- recv(nv)
 	}()
 
 	select {
@@ -272,20 +355,42 @@ func (cr *ConsumerRegistration) Consume(paramProto interface{}, recv Receiver, c
 		timeout:   cr.timeout,
 		ttl:       cr.ttl,
 		log:       cr.log,
+
+		topic:               cr.topic,
+		channel:             cr.channel,
+		deadLetterPublisher: cr.deadLetterPublisher,
+		deadLetterTopic:     cr.deadLetterTopic,
+
+		dedup: cr.dedup,
+
+		onError: cr.consumer.onHandlerError,
+		metrics: cr.consumer.handlerMetrics,
 	}
 
-	cr.c.SetLogger(cr, cr.consumer.logLevel)
+	logLevel := cr.consumer.logLevel
+	if (cr.consumer.onBackoffEnter != nil || cr.consumer.onBackoffExit != nil) && logLevel > nsq.LogLevelWarning {
+		// backoff state changes can only be detected through warning logs of nsq
+		logLevel = nsq.LogLevelWarning
+	}
+
+	cr.c.SetLogger(cr, logLevel)
 	cr.c.AddConcurrentHandlers(nsq.HandlerFunc(tw.handleWithTimeout), concurrent)
 	cr.connected = true
 
 	if cr.consumer.nsqds != nil {
 		return cr.c.ConnectToNSQDs(cr.consumer.nsqds)
 	}
+	if cr.consumer.snapshots != nil {
+		cr.bootstrapFromSnapshot()
+	}
 	return cr.c.ConnectToNSQLookupds(cr.consumer.lookupds)
 }
 
 // Close disconnects from all nsqd's or all nsq-lookupd's.
 func (cr *ConsumerRegistration) Close() error {
+	if cr.stopSnapshots != nil {
+		cr.stopSnapshots()
+	}
 	if cr.c != nil {
 		cr.c.Stop()
 	}
@@ -327,7 +432,12 @@ func (p *nsqPublisher) Output(num int, msg string) error {
 }
 
 // NewPublisher creates a new publisher to produce events for topics.
+// An error is returned if the config is invalid, see PublisherConfig.Validate.
 func NewPublisher(zlog *slog.Logger, publisherCfg *PublisherConfig) (Publisher, error) {
+	if err := publisherCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid publisher config: %w", err)
+	}
+
 	publisherCfg.ConfigureNSQ()
 	p, err := nsq.NewProducer(publisherCfg.TCPAddress, publisherCfg.NSQ)
 	if err != nil {
@@ -388,7 +498,7 @@ func (p *nsqPublisher) Stop() {
 	}
 }
 
-// understands the nsq log message and writes it in the zap.logger
+// understands the nsq log message and writes it to the slog.Logger
 //
 // Format:
 // INF    2 [switch/fra-equ01-leaf01] querying nsqlookupd http://metal.test.metal-stack.io:4161/lookup?topic=switch      {"app": "metal-core"}
@@ -409,3 +519,20 @@ func bridgeNsqLogToCoreLog(nsqLogMessage string, log *slog.Logger) {
 		log.Info(logMessage)
 	}
 }
+
+func nsqLogLevel(nsqLogMessage string) nsq.LogLevel {
+	if len(nsqLogMessage) < 3 {
+		return nsq.LogLevelInfo
+	}
+
+	switch nsqLogMessage[:3] {
+	case nsq.LogLevelError.String():
+		return nsq.LogLevelError
+	case nsq.LogLevelWarning.String():
+		return nsq.LogLevelWarning
+	case nsq.LogLevelDebug.String():
+		return nsq.LogLevelDebug
+	default:
+		return nsq.LogLevelInfo
+	}
+}
@@ -0,0 +1,61 @@
+package bus_test
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/metal-stack/metal-lib/bus"
+	"github.com/nsqio/go-nsq"
+)
+
+// A production setup with TLS enabled publisher and consumer. Misconfigurations like missing ports or a client
+// certificate that does not match its key are reported by the constructors instead of on the first publish.
+func Example() {
+	log := slog.Default()
+
+	tlsCfg := &bus.TLSConfig{
+		CACertFile:     "/etc/nsq/ca.pem",
+		ClientCertFile: "/etc/nsq/client.pem",
+	}
+
+	nsqCfg := nsq.NewConfig()
+	nsqCfg.DialTimeout = 5 * time.Second
+
+	publisher, err := bus.NewPublisher(log, &bus.PublisherConfig{
+		TCPAddress:   "nsqd.metal-control-plane:4150",
+		HTTPEndpoint: "nsqd.metal-control-plane:4152",
+		TLS:          tlsCfg,
+		NSQ:          nsqCfg,
+	})
+	if err != nil {
+		log.Error("invalid publisher config", "error", err)
+		return
+	}
+	defer publisher.Stop()
+
+	consumer, err := bus.NewConsumer(log, tlsCfg, "nsq-lookupd.metal-control-plane:4161")
+	if err != nil {
+		log.Error("invalid consumer config", "error", err)
+		return
+	}
+
+	registration, err := consumer.With(bus.LogLevel(bus.Warning), bus.MaxInFlight(20)).
+		Register("machine", "metal-api")
+	if err != nil {
+		log.Error("unable to register consumer", "error", err)
+		return
+	}
+
+	err = registration.Consume(map[string]any{}, func(msg any) error {
+		return nil
+	}, 5,
+		bus.Timeout(30*time.Second, func(err bus.TimeoutError) error {
+			log.Error("timeout processing message", "error", err)
+			return nil
+		}),
+		bus.DeadLetterTopic(publisher, "machine-dead-letter"),
+	)
+	if err != nil {
+		log.Error("unable to consume", "error", err)
+	}
+}
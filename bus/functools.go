@@ -1,6 +1,7 @@
 package bus
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -18,6 +19,9 @@ const (
 type Endpoints struct {
 	consumer  *Consumer
 	publisher Publisher
+	latency   *LatencyTracker
+	versions  *VersionMetrics
+	dedup     *DedupConfig
 }
 
 // NewEndpoints creates the Endpoints for the given publisher and consumer. If one of the values
@@ -39,6 +43,21 @@ func DirectEndpoints() *Endpoints {
 	return &Endpoints{}
 }
 
+// WithLatencyTracking records the latency of the invocations of all functions created by these endpoints
+// in the given tracker. See LatencyTracker for the rollout of latency tracking in a distributed system.
+func (e *Endpoints) WithLatencyTracking(tracker *LatencyTracker) *Endpoints {
+	e.latency = tracker
+	return e
+}
+
+// WithDeduplication suppresses duplicate invocations of all functions created by these endpoints, see Deduplicate.
+// Every invocation is published with a unique id, which is used as deduplication key. The deduplication must be
+// enabled on the invoking and the receiving side, invocations without id are handled as before.
+func (e *Endpoints) WithDeduplication(c DedupConfig) *Endpoints {
+	e.dedup = &c
+	return e
+}
+
 // A Function encapsulates a Func which can be called with an argument. The invocation will be delegated through
 // nsq so multiple instances of the same function can run in different processes. Only one of them
 // will be invoked.
@@ -47,6 +66,14 @@ type Function struct {
 	registration *ConsumerRegistration
 	fn           reflect.Value
 	name         string
+	latency      *LatencyTracker
+
+	// handlers of a versioned function by payload version, nil for unversioned functions
+	handlers       map[int]reflect.Value
+	version        int
+	versionMetrics *VersionMetrics
+
+	dedup *DedupConfig
 }
 
 type Func func(interface{}) error
@@ -67,12 +94,12 @@ type Func func(interface{}) error
 // `DirectEndpoints` the parameters are not marshalled/unmarshalled via JSON, so using addresses
 // can have side effects.
 func (e *Endpoints) Function(name string, fn interface{}) (*Function, Func, error) {
-	return e.function(name, "function", fn)
+	return e.function(name, "function", fn, nil, 0)
 }
 
 // Client returns a new function client for the function with the registered name.
 func (e *Endpoints) Client(name string) (*Function, Func, error) {
-	return e.function(name, "function", nil)
+	return e.function(name, "function", nil, nil, 0)
 }
 
 // Unique uses an unique, ephemeral topic so the topic will be deregistered when there is no
@@ -88,30 +115,39 @@ func (e *Endpoints) Unique(name string, fn interface{}) (*Function, Func, string
 	}
 	id := uuid.NewString()
 	topic := name + "-" + id + "#ephemeral"
-	fnc, f, err := e.function(topic, "function#ephemeral", fn)
+	fnc, f, err := e.function(topic, "function#ephemeral", fn, nil, 0)
 	return fnc, f, topic, err
 }
 
-func (e *Endpoints) function(name, chanName string, fn interface{}) (*Function, Func, error) {
+func validateFunc(fn interface{}) error {
+	fntype := reflect.TypeOf(fn)
+	if fntype.Kind() != reflect.Func {
+		return fmt.Errorf("the function parameter must be a function")
+	}
+	if fntype.NumIn() != 1 {
+		return fmt.Errorf("the number of parameters in the function must be one")
+	}
+	if fntype.NumOut() != 1 {
+		return fmt.Errorf("the function must return exactly one value of type error")
+	}
+	errtype := reflect.TypeOf(errors.New(""))
+	if !errtype.AssignableTo(fntype.Out(0)) {
+		return fmt.Errorf("the return type is not of type 'error'")
+	}
+	return nil
+}
+
+// function creates a function, handlers are only given for versioned functions and the version is the payload
+// version of the published invocations.
+func (e *Endpoints) function(name, chanName string, fn interface{}, handlers map[int]reflect.Value, version int) (*Function, Func, error) {
 	if fn != nil {
-		fntype := reflect.TypeOf(fn)
-		if fntype.Kind() != reflect.Func {
-			return nil, nil, fmt.Errorf("the function parameter must be a function")
-		}
-		if fntype.NumIn() != 1 {
-			return nil, nil, fmt.Errorf("the number of parameters in the function must be one")
-		}
-		if fntype.NumOut() != 1 {
-			return nil, nil, fmt.Errorf("the function must return exactly one value of type error")
-		}
-		errtype := reflect.TypeOf(errors.New(""))
-		if !errtype.AssignableTo(fntype.Out(0)) {
-			return nil, nil, fmt.Errorf("the return type is not of type 'error'")
+		if err := validateFunc(fn); err != nil {
+			return nil, nil, err
 		}
 	}
 	if e.consumer == nil && e.publisher == nil {
 		// someone wants a local function
-		f := &Function{name: name, fn: reflect.ValueOf(fn)}
+		f := &Function{name: name, fn: reflect.ValueOf(fn), latency: e.latency, handlers: handlers, version: version, versionMetrics: e.versions}
 		return f, f.invoker(), nil
 	}
 	if e.publisher != nil {
@@ -120,9 +156,14 @@ func (e *Endpoints) function(name, chanName string, fn interface{}) (*Function,
 		}
 	}
 	cb := &Function{
-		endpoints: e,
-		fn:        reflect.ValueOf(fn),
-		name:      name,
+		endpoints:      e,
+		fn:             reflect.ValueOf(fn),
+		name:           name,
+		latency:        e.latency,
+		handlers:       handlers,
+		version:        version,
+		versionMetrics: e.versions,
+		dedup:          e.dedup,
 	}
 	if e.consumer != nil && fn != nil {
 		reg, err := e.consumer.Register(name, chanName)
@@ -135,7 +176,15 @@ func (e *Endpoints) function(name, chanName string, fn interface{}) (*Function,
 			partype = partype.Elem()
 		}
 		pvalue := reflect.New(partype).Elem()
-		if err = reg.Consume(pvalue.Interface(), cb.receive, numParallelReceivers); err != nil {
+		proto, recv := pvalue.Interface(), Receiver(cb.receive)
+		var opts []crOption
+		if cb.dedup != nil {
+			opts = append(opts, Deduplicate(*cb.dedup))
+		}
+		if cb.latency != nil || cb.handlers != nil || cb.dedup != nil {
+			proto, recv = functionEnvelope{}, cb.receiveEnvelope
+		}
+		if err = reg.Consume(proto, recv, numParallelReceivers, opts...); err != nil {
 			return nil, nil, fmt.Errorf("cannot consume: %w", err)
 		}
 	}
@@ -157,11 +206,15 @@ func (f *Function) Close() error {
 // value type, this value will be copied so we can pass a pointer to the target
 // function.
 func (f *Function) receive(par interface{}) error {
+	return f.call(f.fn, par)
+}
+
+func (f *Function) call(fn reflect.Value, par interface{}) error {
 	v := reflect.ValueOf(par)
 	vkind := reflect.TypeOf(par).Kind()
 	pkind := vkind
-	if !f.fn.IsZero() {
-		pkind = f.fn.Type().In(0).Kind()
+	if !fn.IsZero() {
+		pkind = fn.Type().In(0).Kind()
 	}
 
 	params := []reflect.Value{v}
@@ -177,13 +230,41 @@ func (f *Function) receive(par interface{}) error {
 			params = []reflect.Value{v.Elem()}
 		}
 	}
-	res := f.fn.Call(params)
+	res := fn.Call(params)
 	if res[0].IsNil() {
 		return nil
 	}
 	return res[0].Interface().(error)
 }
 
+// receiveEnvelope unwraps the argument from the envelope, dispatches it to the handler of its version and tracks
+// the latency of the invocation.
+func (f *Function) receiveEnvelope(par interface{}) error {
+	envelope, ok := par.(*functionEnvelope)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", par)
+	}
+
+	fn, err := f.handler(envelope.Version)
+	if err != nil {
+		return err
+	}
+
+	partype := fn.Type().In(0)
+	for partype.Kind() == reflect.Ptr {
+		partype = partype.Elem()
+	}
+
+	arg := reflect.New(partype).Interface()
+	if err := json.Unmarshal(envelope.Payload, arg); err != nil {
+		return fmt.Errorf("cannot unmarshal function argument: %w", err)
+	}
+
+	return f.latency.track(f.name, envelope.Published, func() error {
+		return f.call(fn, arg)
+	})
+}
+
 func (f *Function) invoker() Func {
 	return func(arg interface{}) error {
 		return f.must(arg)
@@ -195,13 +276,22 @@ func (f *Function) invoker() Func {
 // communication problem with nsq.
 func (f *Function) must(arg interface{}) error {
 	if f.endpoints == nil {
+		published := time.Now()
 		go func(arg interface{}) {
 			// local function. this is not the "normal" use case so here we do a
 			// simple fork of a goroutine. it is up to the target function to
 			// return a nil value. if no nil value is returned ever, this goroutine
 			// will never end!
+			if f.handlers != nil {
+				f.versionMetrics.inc(f.name, f.version)
+			}
 			for {
-				if err := f.receive(arg); err == nil {
+				err := f.latency.track(f.name, published, func() error {
+					return recoverPanic(func() error {
+						return f.receive(arg)
+					})
+				})
+				if err == nil {
 					return
 				}
 				time.Sleep(time.Millisecond * 100)
@@ -209,5 +299,19 @@ func (f *Function) must(arg interface{}) error {
 		}(arg)
 		return nil
 	}
+	if f.latency != nil || f.version != 0 || f.dedup != nil {
+		payload, err := json.Marshal(arg)
+		if err != nil {
+			return fmt.Errorf("cannot marshal data to json: %w", err)
+		}
+		envelope := functionEnvelope{Version: f.version, Payload: payload}
+		if f.latency != nil {
+			envelope.Published = time.Now()
+		}
+		if f.dedup != nil {
+			envelope.ID = uuid.NewString()
+		}
+		return f.endpoints.publisher.Publish(f.name, envelope)
+	}
 	return f.endpoints.publisher.Publish(f.name, arg)
 }
@@ -0,0 +1,197 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+// PeekedMessage is a message that was received from a channel without finishing it.
+type PeekedMessage struct {
+	ID        string
+	Timestamp time.Time
+	Attempts  uint16
+	Body      []byte
+}
+
+// TopicStats are the statistics of a topic as reported by a nsqd.
+type TopicStats struct {
+	TopicName    string         `json:"topic_name"`
+	Depth        int64          `json:"depth"`
+	BackendDepth int64          `json:"backend_depth"`
+	MessageCount uint64         `json:"message_count"`
+	Paused       bool           `json:"paused"`
+	Channels     []ChannelStats `json:"channels"`
+}
+
+// ChannelStats are the statistics of a channel as reported by a nsqd.
+type ChannelStats struct {
+	ChannelName   string `json:"channel_name"`
+	Depth         int64  `json:"depth"`
+	BackendDepth  int64  `json:"backend_depth"`
+	InFlightCount int    `json:"in_flight_count"`
+	DeferredCount int    `json:"deferred_count"`
+	MessageCount  uint64 `json:"message_count"`
+	RequeueCount  uint64 `json:"requeue_count"`
+	TimeoutCount  uint64 `json:"timeout_count"`
+	ClientCount   int    `json:"client_count"`
+	Paused        bool   `json:"paused"`
+}
+
+// Peek receives up to max messages from the given topic and channel without finishing them. The received messages
+// are requeued immediately such that they are delivered to the regular consumers of the channel again.
+// Peek returns as soon as max messages were received or the given context is done.
+func (c *Consumer) Peek(ctx context.Context, topic, channel string, max int) ([]PeekedMessage, error) {
+	if max < 1 {
+		return nil, fmt.Errorf("at least one message must be peeked")
+	}
+
+	cr, err := c.Register(topic, channel, MaxInFlight(max))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu       sync.Mutex
+		received []*nsq.Message
+		stopped  bool
+		done     = make(chan struct{})
+	)
+
+	cr.c.SetLogger(cr, cr.consumer.logLevel)
+	cr.c.AddHandler(nsq.HandlerFunc(func(m *nsq.Message) error {
+		m.DisableAutoResponse()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if stopped || len(received) >= max {
+			m.RequeueWithoutBackoff(0)
+			return nil
+		}
+
+		received = append(received, m)
+		if len(received) == max {
+			close(done)
+		}
+
+		return nil
+	}))
+
+	if cr.consumer.nsqds != nil {
+		err = cr.c.ConnectToNSQDs(cr.consumer.nsqds)
+	} else {
+		err = cr.c.ConnectToNSQLookupds(cr.consumer.lookupds)
+	}
+	if err != nil {
+		cr.c.Stop()
+		return nil, err
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+
+	var result []PeekedMessage
+	for _, m := range received {
+		result = append(result, PeekedMessage{
+			ID:        string(m.ID[:]),
+			Timestamp: time.Unix(0, m.Timestamp),
+			Attempts:  m.Attempts,
+			Body:      m.Body,
+		})
+
+		m.RequeueWithoutBackoff(0)
+	}
+
+	// messages arriving until the consumer is stopped are requeued by the handler
+	stopped = true
+
+	mu.Unlock()
+
+	cr.c.Stop()
+	<-cr.c.StopChan
+
+	return result, nil
+}
+
+// DecodePayload decodes the json body of a message, e.g. the argument of a function invocation, into the
+// type of the given paramProto. If paramProto is nil, the body is decoded into generic maps and slices.
+func DecodePayload(body []byte, paramProto interface{}) (interface{}, error) {
+	if paramProto == nil {
+		var v interface{}
+		err := json.Unmarshal(body, &v)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode payload: %w", err)
+		}
+		return v, nil
+	}
+
+	tp := reflect.TypeOf(paramProto)
+	for tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	v := reflect.New(tp).Interface()
+	err := json.Unmarshal(body, v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode payload into %s: %w", tp, err)
+	}
+
+	return v, nil
+}
+
+// Stats returns the statistics of the topics of the nsqd at the given http endpoint. If topic or channel are given,
+// only the statistics of the given topic or channel are returned.
+func Stats(client *http.Client, httpEndpoint, topic, channel string) ([]TopicStats, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return stats(context.Background(), client, fmt.Sprintf("http://%s/stats", httpEndpoint), topic, channel)
+}
+
+func stats(ctx context.Context, client *http.Client, statsURL, topic, channel string) ([]TopicStats, error) {
+	query := url.Values{}
+	query.Set("format", "json")
+	if topic != "" {
+		query.Set("topic", topic)
+	}
+	if channel != "" {
+		query.Set("channel", channel)
+	}
+
+	body, err := doRequest(ctx, client, http.MethodGet, statsURL+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stats: %w", err)
+	}
+
+	var stats struct {
+		Topics []TopicStats `json:"topics"`
+		// nsqd before v1.0 wraps the response into a data field
+		Data *struct {
+			Topics []TopicStats `json:"topics"`
+		} `json:"data"`
+	}
+
+	err = json.Unmarshal(body, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode stats: %w", err)
+	}
+
+	if stats.Data != nil {
+		return stats.Data.Topics, nil
+	}
+
+	return stats.Topics, nil
+}
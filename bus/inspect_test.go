@@ -0,0 +1,105 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeekAndStats(t *testing.T) {
+	const (
+		topic   = "peek-topic"
+		channel = "peek-channel"
+	)
+
+	require.NoError(t, publisher.CreateTopic(topic))
+	require.NoError(t, publisher.Publish(topic, map[string]string{"name": "one"}))
+	require.NoError(t, publisher.Publish(topic, map[string]string{"name": "two"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msgs, err := consumer.Peek(ctx, topic, channel, 2)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	var names []string
+	for _, m := range msgs {
+		payload, err := DecodePayload(m.Body, nil)
+		require.NoError(t, err)
+		names = append(names, payload.(map[string]interface{})["name"].(string))
+	}
+	require.ElementsMatch(t, []string{"one", "two"}, names)
+
+	// peeked messages were not finished and must be delivered again
+	msgs, err = consumer.Peek(ctx, topic, channel, 2)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	for _, m := range msgs {
+		require.Greater(t, m.Attempts, uint16(1))
+	}
+
+	stats, err := Stats(nil, httpAddress, topic, channel)
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	require.Equal(t, topic, stats[0].TopicName)
+	require.Len(t, stats[0].Channels, 1)
+	require.Equal(t, channel, stats[0].Channels[0].ChannelName)
+	require.Equal(t, uint64(2), stats[0].Channels[0].MessageCount)
+}
+
+func TestDecodePayload(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		paramProto interface{}
+		want       interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "struct",
+			body:       `{"name":"one"}`,
+			paramProto: payload{},
+			want:       &payload{Name: "one"},
+		},
+		{
+			name:       "pointer to struct",
+			body:       `{"name":"one"}`,
+			paramProto: &payload{},
+			want:       &payload{Name: "one"},
+		},
+		{
+			name: "generic",
+			body: `{"name":"one"}`,
+			want: map[string]interface{}{"name": "one"},
+		},
+		{
+			name:       "invalid",
+			body:       `{"name":1}`,
+			paramProto: payload{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePayload([]byte(tt.body), tt.paramProto)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
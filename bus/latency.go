@@ -0,0 +1,159 @@
+package bus
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histograms if no buckets are given.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// LatencyEvent describes a single invocation of a function.
+type LatencyEvent struct {
+	Function string
+	// Published is the time the invocation was published by the caller.
+	Published time.Time
+	// Started is the time the function was called by the consumer.
+	Started time.Time
+	// Finished is the time the function returned.
+	Finished time.Time
+	// Error is the error returned by the function.
+	Error error
+}
+
+// Queued is the time between publishing and handling an invocation, a high value indicates slow brokers or a backlog.
+func (e LatencyEvent) Queued() time.Duration {
+	return e.Started.Sub(e.Published)
+}
+
+// Handled is the time the function took, a high value indicates slow consumers.
+func (e LatencyEvent) Handled() time.Duration {
+	return e.Finished.Sub(e.Started)
+}
+
+// EndToEnd is the time between publishing an invocation and the function returning.
+func (e LatencyEvent) EndToEnd() time.Duration {
+	return e.Finished.Sub(e.Published)
+}
+
+// LatencyTracker records the latency of function invocations. Publishers stamp the publish time into the message
+// and consumers record when the function was started and finished.
+//
+// As the message format changes, latency tracking needs to be enabled for the consumers of a function before it is
+// enabled for the publishers. Consumers with latency tracking still accept messages of publishers without it, but these
+// invocations are not tracked.
+//
+// The tracker is a prometheus.Collector and reports the latency histograms per function when it is registered.
+type LatencyTracker struct {
+	queued   *prometheus.HistogramVec
+	handled  *prometheus.HistogramVec
+	endToEnd *prometheus.HistogramVec
+
+	threshold time.Duration
+	onSlow    func(LatencyEvent)
+}
+
+var _ prometheus.Collector = &LatencyTracker{}
+
+// NewLatencyTracker returns a latency tracker with the given histogram buckets in seconds, DefaultLatencyBuckets are used if none are given.
+func NewLatencyTracker(buckets ...float64) *LatencyTracker {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	histogram := func(name, help string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "bus",
+			Subsystem: "function",
+			Name:      name,
+			Help:      help,
+			Buckets:   buckets,
+		}, []string{"function"})
+	}
+
+	return &LatencyTracker{
+		queued:   histogram("queued_seconds", "time between publishing and handling a function invocation."),
+		handled:  histogram("handled_seconds", "time the function took to handle an invocation."),
+		endToEnd: histogram("latency_seconds", "end-to-end latency between publishing a function invocation and the function returning."),
+	}
+}
+
+// OnSlow registers a callback that is called when the end-to-end latency of an invocation exceeds the given threshold.
+func (t *LatencyTracker) OnSlow(threshold time.Duration, fn func(LatencyEvent)) *LatencyTracker {
+	t.threshold = threshold
+	t.onSlow = fn
+	return t
+}
+
+// Describe implements prometheus.Collector.
+func (t *LatencyTracker) Describe(ch chan<- *prometheus.Desc) {
+	t.queued.Describe(ch)
+	t.handled.Describe(ch)
+	t.endToEnd.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (t *LatencyTracker) Collect(ch chan<- prometheus.Metric) {
+	t.queued.Collect(ch)
+	t.handled.Collect(ch)
+	t.endToEnd.Collect(ch)
+}
+
+// track calls the given function and records its latency. A nil tracker only calls the function.
+func (t *LatencyTracker) track(function string, published time.Time, fn func() error) error {
+	if t == nil || published.IsZero() {
+		return fn()
+	}
+
+	started := time.Now()
+	err := fn()
+
+	t.record(LatencyEvent{
+		Function:  function,
+		Published: published,
+		Started:   started,
+		Finished:  time.Now(),
+		Error:     err,
+	})
+
+	return err
+}
+
+func (t *LatencyTracker) record(e LatencyEvent) {
+	t.queued.WithLabelValues(e.Function).Observe(e.Queued().Seconds())
+	t.handled.WithLabelValues(e.Function).Observe(e.Handled().Seconds())
+	t.endToEnd.WithLabelValues(e.Function).Observe(e.EndToEnd().Seconds())
+
+	if t.onSlow != nil && e.EndToEnd() > t.threshold {
+		t.onSlow(e)
+	}
+}
+
+// functionEnvelope wraps the argument of a function invocation together with the time it was published,
+// the version of the payload and the id of the invocation for deduplication.
+type functionEnvelope struct {
+	Published time.Time       `json:"__published"`
+	Version   int             `json:"__version,omitempty"`
+	ID        string          `json:"__id,omitempty"`
+	Payload   json.RawMessage `json:"__payload"`
+}
+
+// UnmarshalJSON accepts messages of publishers without latency tracking, versions and deduplication, these are taken as payload
+// without a publish time.
+func (e *functionEnvelope) UnmarshalJSON(data []byte) error {
+	type envelope functionEnvelope
+
+	var decoded envelope
+	err := json.Unmarshal(data, &decoded)
+	if err == nil && decoded.Payload != nil && (!decoded.Published.IsZero() || decoded.Version != 0 || decoded.ID != "") {
+		*e = functionEnvelope(decoded)
+		return nil
+	}
+
+	*e = functionEnvelope{Payload: slices.Clone(data)}
+
+	return nil
+}
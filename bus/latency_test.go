@@ -0,0 +1,84 @@
+package bus
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyTrackingWithDirectEndpoints(t *testing.T) {
+	slow := make(chan LatencyEvent, 1)
+
+	tracker := NewLatencyTracker(0.001, 3600).OnSlow(0, func(e LatencyEvent) {
+		slow <- e
+	})
+
+	_, f, err := DirectEndpoints().WithLatencyTracking(tracker).Function("latency", func(arg string) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, f("hello"))
+
+	select {
+	case e := <-slow:
+		require.Equal(t, "latency", e.Function)
+		require.False(t, e.Started.Before(e.Published))
+		require.Equal(t, e.Queued()+e.Handled(), e.EndToEnd())
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow callback was not called")
+	}
+
+	require.Equal(t, 3, testutil.CollectAndCount(tracker), "one histogram of every kind for the function")
+}
+
+func TestLatencyTrackerMetrics(t *testing.T) {
+	tracker := NewLatencyTracker(0.1, 1)
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker.record(LatencyEvent{
+		Function:  "machine-create",
+		Published: published,
+		Started:   published.Add(50 * time.Millisecond),
+		Finished:  published.Add(1500 * time.Millisecond),
+	})
+
+	want := `# HELP bus_function_latency_seconds end-to-end latency between publishing a function invocation and the function returning.
+# TYPE bus_function_latency_seconds histogram
+bus_function_latency_seconds_bucket{function="machine-create",le="0.1"} 0
+bus_function_latency_seconds_bucket{function="machine-create",le="1"} 0
+bus_function_latency_seconds_bucket{function="machine-create",le="+Inf"} 1
+bus_function_latency_seconds_sum{function="machine-create"} 1.5
+bus_function_latency_seconds_count{function="machine-create"} 1
+# HELP bus_function_queued_seconds time between publishing and handling a function invocation.
+# TYPE bus_function_queued_seconds histogram
+bus_function_queued_seconds_bucket{function="machine-create",le="0.1"} 1
+bus_function_queued_seconds_bucket{function="machine-create",le="1"} 1
+bus_function_queued_seconds_bucket{function="machine-create",le="+Inf"} 1
+bus_function_queued_seconds_sum{function="machine-create"} 0.05
+bus_function_queued_seconds_count{function="machine-create"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(tracker, strings.NewReader(want), "bus_function_latency_seconds", "bus_function_queued_seconds"))
+}
+
+func TestLatencyEnvelopeUnmarshal(t *testing.T) {
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	raw, err := json.Marshal(functionEnvelope{Published: published, Payload: json.RawMessage(`{"name":"a"}`)})
+	require.NoError(t, err)
+
+	var envelope functionEnvelope
+	require.NoError(t, json.Unmarshal(raw, &envelope))
+	require.Equal(t, published, envelope.Published)
+	require.JSONEq(t, `{"name":"a"}`, string(envelope.Payload))
+
+	// messages of publishers without latency tracking
+	envelope = functionEnvelope{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"a"}`), &envelope))
+	require.True(t, envelope.Published.IsZero())
+	require.JSONEq(t, `{"name":"a"}`, string(envelope.Payload))
+}
@@ -0,0 +1,302 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errLoadTestRequeue = errors.New("requeued by load test")
+
+// LoadTestConfig configures a load test, see RunLoadTest.
+type LoadTestConfig struct {
+	// Topic the messages are published to.
+	Topic string
+	// Rate is the amount of messages published per second by all publishers, zero publishes as fast as possible.
+	Rate int
+	// MessageSize is the size of the payload of a message in bytes.
+	MessageSize int
+	// Duration is the time messages are published.
+	Duration time.Duration
+	// Publishers is the amount of concurrent publishers, defaults to one.
+	Publishers int
+	// Concurrency is the amount of concurrent handlers of the consumer, defaults to one.
+	Concurrency int
+	// RequeueRatio is the ratio of messages, which are requeued on their first delivery in order to measure
+	// the requeue overhead, e.g. 0.1 requeues every tenth message.
+	RequeueRatio float64
+	// RequeueDelay is the delay of requeued messages.
+	RequeueDelay time.Duration
+	// DrainTimeout is the time to wait for outstanding messages after publishing has finished, defaults to ten seconds.
+	DrainTimeout time.Duration
+}
+
+// LoadTestResult contains the measurements of a load test.
+type LoadTestResult struct {
+	// Published is the amount of successfully published messages.
+	Published uint64
+	// PublishErrors is the amount of messages that could not be published.
+	PublishErrors uint64
+	// Received is the amount of distinct messages handled successfully.
+	Received uint64
+	// Requeued is the amount of deliveries that were requeued.
+	Requeued uint64
+	// Duplicates is the amount of messages that were handled successfully more than once.
+	Duplicates uint64
+	// Duration is the time between the first publish and the last received message.
+	Duration time.Duration
+
+	latencies []time.Duration
+}
+
+type loadTestMessage struct {
+	ID        uint64    `json:"id"`
+	Published time.Time `json:"published"`
+	Payload   string    `json:"payload"`
+}
+
+// Lost returns the amount of published messages that were never received.
+func (r *LoadTestResult) Lost() uint64 {
+	if r.Received > r.Published {
+		return 0
+	}
+	return r.Published - r.Received
+}
+
+// Throughput returns the received messages per second.
+func (r *LoadTestResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Received) / r.Duration.Seconds()
+}
+
+// Percentile returns the end to end latency below which the given percentile (0-100) of the received messages fall.
+func (r *LoadTestResult) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	i := int(float64(len(r.latencies)-1) * p / 100)
+	i = max(0, min(i, len(r.latencies)-1))
+
+	return r.latencies[i]
+}
+
+func (r *LoadTestResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "published=%d publishErrors=%d received=%d lost=%d requeued=%d duplicates=%d", r.Published, r.PublishErrors, r.Received, r.Lost(), r.Requeued, r.Duplicates)
+	fmt.Fprintf(&sb, " duration=%s throughput=%.1f/s", r.Duration, r.Throughput())
+	fmt.Fprintf(&sb, " p50=%s p90=%s p99=%s max=%s", r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100))
+	return sb.String()
+}
+
+type loadTestRecorder struct {
+	lock      sync.Mutex
+	start     time.Time
+	last      time.Time
+	seen      map[uint64]bool
+	latencies []time.Duration
+
+	requeued   uint64
+	duplicates uint64
+
+	requeueRatio float64
+	requeueDelay time.Duration
+
+	// done is closed when expected messages have been received
+	done     chan struct{}
+	expected uint64
+	closed   bool
+}
+
+func (rec *loadTestRecorder) receive(e interface{}) error {
+	msg, ok := e.(*loadTestMessage)
+	if !ok {
+		return Terminal(fmt.Errorf("unexpected message type %T", e))
+	}
+
+	now := time.Now()
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	if _, ok := rec.seen[msg.ID]; !ok && rec.requeueRatio > 0 && rand.Float64() < rec.requeueRatio { //nolint:gosec
+		rec.seen[msg.ID] = false
+		rec.requeued++
+		return Retryable(errLoadTestRequeue, rec.requeueDelay)
+	}
+
+	if rec.seen[msg.ID] {
+		rec.duplicates++
+		return nil
+	}
+
+	rec.seen[msg.ID] = true
+	rec.latencies = append(rec.latencies, now.Sub(msg.Published))
+	rec.last = now
+
+	rec.checkDone()
+
+	return nil
+}
+
+// received returns the amount of distinct messages handled successfully, the lock must be held.
+func (rec *loadTestRecorder) received() uint64 {
+	return uint64(len(rec.latencies))
+}
+
+// expect sets the amount of messages to wait for, the lock must not be held.
+func (rec *loadTestRecorder) expect(n uint64) {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	rec.expected = n
+	rec.checkDone()
+}
+
+// checkDone closes the done channel if all expected messages were received, the lock must be held.
+func (rec *loadTestRecorder) checkDone() {
+	if rec.closed || rec.expected == 0 || rec.received() < rec.expected {
+		return
+	}
+	rec.closed = true
+	close(rec.done)
+}
+
+// RunLoadTest publishes messages with the given publisher according to the config and consumes them with the given
+// consumer registration, which must not be connected yet. The registration needs to be registered for the same topic.
+//
+// It is intended to be used in benchmarks and soak tests in order to detect regressions in the marshalling and
+// delivery of messages, e.g. against an nsqd started with testcontainers.
+func RunLoadTest(ctx context.Context, publisher Publisher, cr *ConsumerRegistration, cfg LoadTestConfig) (*LoadTestResult, error) {
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("load test topic must not be empty")
+	}
+	if cfg.Duration <= 0 {
+		return nil, fmt.Errorf("load test duration must be positive")
+	}
+	if cfg.Rate < 0 || cfg.MessageSize < 0 {
+		return nil, fmt.Errorf("load test rate and message size must not be negative")
+	}
+	if cfg.RequeueRatio < 0 || cfg.RequeueRatio >= 1 {
+		return nil, fmt.Errorf("load test requeue ratio must be in [0, 1)")
+	}
+	if cfg.Publishers <= 0 {
+		cfg.Publishers = 1
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 10 * time.Second
+	}
+
+	rec := &loadTestRecorder{
+		seen:         map[uint64]bool{},
+		requeueRatio: cfg.RequeueRatio,
+		requeueDelay: cfg.RequeueDelay,
+		done:         make(chan struct{}),
+	}
+
+	if err := publisher.CreateTopic(cfg.Topic); err != nil {
+		return nil, fmt.Errorf("unable to create load test topic: %w", err)
+	}
+
+	if err := cr.Consume(loadTestMessage{}, rec.receive, cfg.Concurrency); err != nil {
+		return nil, fmt.Errorf("unable to consume load test topic: %w", err)
+	}
+	defer func() {
+		_ = cr.Close()
+	}()
+
+	var (
+		published     atomic.Uint64
+		publishErrors atomic.Uint64
+		ids           atomic.Uint64
+		wg            sync.WaitGroup
+		payload       = strings.Repeat("x", cfg.MessageSize)
+	)
+
+	// every publisher publishes its share of the rate
+	var interval time.Duration
+	if cfg.Rate > 0 {
+		interval = time.Duration(float64(time.Second) * float64(cfg.Publishers) / float64(cfg.Rate))
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	rec.start = time.Now()
+
+	for range cfg.Publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var tick <-chan time.Time
+			if interval > 0 {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				tick = ticker.C
+			}
+
+			for {
+				if tick != nil {
+					select {
+					case <-publishCtx.Done():
+						return
+					case <-tick:
+					}
+				} else if publishCtx.Err() != nil {
+					return
+				}
+
+				err := publisher.Publish(cfg.Topic, loadTestMessage{
+					ID:        ids.Add(1),
+					Published: time.Now(),
+					Payload:   payload,
+				})
+				if err != nil {
+					publishErrors.Add(1)
+					continue
+				}
+				published.Add(1)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	rec.expect(published.Load())
+
+	select {
+	case <-rec.done:
+	case <-time.After(cfg.DrainTimeout):
+	case <-ctx.Done():
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	res := &LoadTestResult{
+		Published:     published.Load(),
+		PublishErrors: publishErrors.Load(),
+		Received:      rec.received(),
+		Requeued:      rec.requeued,
+		Duplicates:    rec.duplicates,
+		latencies:     slices.Clone(rec.latencies),
+	}
+	if !rec.last.IsZero() {
+		res.Duration = rec.last.Sub(rec.start)
+	}
+	slices.Sort(res.latencies)
+
+	return res, ctx.Err()
+}
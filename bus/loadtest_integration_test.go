@@ -0,0 +1,121 @@
+//go:build integration
+// +build integration
+
+package bus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+type nsqConnectionDetails struct {
+	TCPAddress   string
+	HTTPEndpoint string
+}
+
+func StartNSQD(t testing.TB) (testcontainers.Container, *nsqConnectionDetails) {
+	ctx := context.Background()
+
+	nsqdContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "nsqio/nsq:v1.3.0",
+			Cmd:          []string{"/nsqd"},
+			ExposedPorts: []string{"4150/tcp", "4151/tcp"},
+			WaitingFor: wait.ForAll(
+				wait.ForListeningPort("4150/tcp"),
+				wait.ForHTTP("/ping").WithPort("4151/tcp"),
+			),
+		},
+		Started: true,
+		Logger:  testcontainers.TestLogger(t),
+	})
+	require.NoError(t, err)
+
+	host, err := nsqdContainer.Host(ctx)
+	require.NoError(t, err)
+	tcpPort, err := nsqdContainer.MappedPort(ctx, "4150")
+	require.NoError(t, err)
+	httpPort, err := nsqdContainer.MappedPort(ctx, "4151")
+	require.NoError(t, err)
+
+	return nsqdContainer, &nsqConnectionDetails{
+		TCPAddress:   fmt.Sprintf("%s:%s", host, tcpPort.Port()),
+		HTTPEndpoint: fmt.Sprintf("%s:%s", host, httpPort.Port()),
+	}
+}
+
+func runLoadTest(t testing.TB, conn *nsqConnectionDetails, cfg LoadTestConfig) *LoadTestResult {
+	log := slog.Default()
+
+	publisher, err := NewPublisher(log, &PublisherConfig{
+		TCPAddress:   conn.TCPAddress,
+		HTTPEndpoint: conn.HTTPEndpoint,
+	})
+	require.NoError(t, err)
+	defer publisher.Stop()
+
+	consumer, err := NewConsumer(log, nil)
+	require.NoError(t, err)
+
+	cr, err := consumer.With(NSQDs(conn.TCPAddress), LogLevel(Warning), MaxInFlight(cfg.Concurrency)).Register(cfg.Topic, "loadtest")
+	require.NoError(t, err)
+
+	res, err := RunLoadTest(context.Background(), publisher, cr, cfg)
+	require.NoError(t, err)
+
+	return res
+}
+
+func TestLoadSoak(t *testing.T) {
+	container, conn := StartNSQD(t)
+	defer func() {
+		_ = container.Terminate(context.Background())
+	}()
+
+	res := runLoadTest(t, conn, LoadTestConfig{
+		Topic:        "soak",
+		Rate:         500,
+		MessageSize:  1024,
+		Duration:     10 * time.Second,
+		Publishers:   2,
+		Concurrency:  10,
+		RequeueRatio: 0.05,
+		RequeueDelay: 10 * time.Millisecond,
+	})
+	t.Log(res)
+
+	require.Zero(t, res.PublishErrors)
+	require.Zero(t, res.Lost())
+	require.NotZero(t, res.Requeued)
+	require.Less(t, res.Percentile(99), time.Second)
+}
+
+func BenchmarkLoad(b *testing.B) {
+	container, conn := StartNSQD(b)
+	defer func() {
+		_ = container.Terminate(context.Background())
+	}()
+
+	for _, size := range []int{128, 4096, 65536} {
+		b.Run(fmt.Sprintf("size-%d", size), func(b *testing.B) {
+			res := runLoadTest(b, conn, LoadTestConfig{
+				Topic:       fmt.Sprintf("bench-%d", size),
+				MessageSize: size,
+				Duration:    time.Duration(b.N) * time.Millisecond,
+				Concurrency: 10,
+			})
+
+			b.ReportMetric(res.Throughput(), "msgs/s")
+			b.ReportMetric(float64(res.Percentile(50).Microseconds()), "p50-µs")
+			b.ReportMetric(float64(res.Percentile(99).Microseconds()), "p99-µs")
+			b.ReportMetric(float64(res.Requeued), "requeues")
+		})
+	}
+}
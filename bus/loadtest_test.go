@@ -0,0 +1,64 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestRecorder(t *testing.T) {
+	rec := &loadTestRecorder{
+		seen: map[uint64]bool{},
+		done: make(chan struct{}),
+	}
+
+	for _, id := range []uint64{1, 2, 2, 3} {
+		require.NoError(t, rec.receive(&loadTestMessage{ID: id, Published: time.Now()}))
+	}
+
+	require.Equal(t, uint64(3), rec.received())
+	require.Equal(t, uint64(1), rec.duplicates)
+
+	rec.expect(3)
+	select {
+	case <-rec.done:
+	default:
+		t.Fatal("recorder is not done after receiving all expected messages")
+	}
+
+	rec = &loadTestRecorder{
+		seen:         map[uint64]bool{},
+		done:         make(chan struct{}),
+		requeueRatio: 1,
+	}
+
+	err := rec.receive(&loadTestMessage{ID: 1})
+	require.True(t, errors.Is(err, errLoadTestRequeue))
+	_, retryable := RetryAfter(err)
+	require.True(t, retryable)
+
+	// redeliveries are not requeued again
+	require.NoError(t, rec.receive(&loadTestMessage{ID: 1}))
+	require.Equal(t, uint64(1), rec.requeued)
+	require.Equal(t, uint64(1), rec.received())
+
+	require.True(t, IsTerminal(rec.receive("unexpected")))
+}
+
+func TestLoadTestResult(t *testing.T) {
+	res := &LoadTestResult{
+		Published: 5,
+		Received:  4,
+		Duration:  2 * time.Second,
+		latencies: []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 10 * time.Millisecond},
+	}
+
+	require.Equal(t, uint64(1), res.Lost())
+	require.InDelta(t, 2.0, res.Throughput(), 0.001)
+	require.Equal(t, 1*time.Millisecond, res.Percentile(0))
+	require.Equal(t, 2*time.Millisecond, res.Percentile(50))
+	require.Equal(t, 10*time.Millisecond, res.Percentile(100))
+	require.Equal(t, time.Duration(0), (&LoadTestResult{}).Percentile(99))
+}
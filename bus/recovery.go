@@ -0,0 +1,155 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PanicError is returned for handlers that panicked. The panic is recovered such that the consumer keeps running,
+// the message is requeued with backoff like for other errors unless the panic value is a terminal or retryable error.
+type PanicError struct {
+	// Value is the value the handler panicked with.
+	Value any
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, such that panics with terminal errors are not retried.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverPanic calls fn and converts a panic into a PanicError.
+func recoverPanic(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return fn()
+}
+
+// ErrorClass classifies the errors returned by handlers.
+type ErrorClass string
+
+const (
+	// ErrorClassTerminal are errors that are not retried, see Terminal.
+	ErrorClassTerminal ErrorClass = "terminal"
+	// ErrorClassRetryable are errors that are retried after a delay without backoff, see Retryable.
+	ErrorClassRetryable ErrorClass = "retryable"
+	// ErrorClassPanic are panics of handlers, see PanicError.
+	ErrorClassPanic ErrorClass = "panic"
+	// ErrorClassGeneric are all other errors, which are retried with backoff.
+	ErrorClassGeneric ErrorClass = "error"
+)
+
+// Classify returns the class of the given error, which must not be nil. Terminal and retryable errors are
+// classified as such even if they were raised by a panic.
+func Classify(err error) ErrorClass {
+	if IsTerminal(err) {
+		return ErrorClassTerminal
+	}
+	if _, ok := RetryAfter(err); ok {
+		return ErrorClassRetryable
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		return ErrorClassPanic
+	}
+	return ErrorClassGeneric
+}
+
+// HandlerErrorEvent describes a message whose handler failed.
+type HandlerErrorEvent struct {
+	Topic     string
+	Channel   string
+	MessageID string
+	Attempts  uint16
+	Class     ErrorClass
+	Error     error
+}
+
+// OnHandlerError registers a callback that is called for every message whose handler returned an error or panicked,
+// e.g. for alerting on permanent failures.
+func OnHandlerError(fn func(HandlerErrorEvent)) Option {
+	return func(c *Consumer) *Consumer {
+		c.onHandlerError = fn
+		return c
+	}
+}
+
+// HandlerMetrics counts the errors of handlers per topic, channel and error class.
+//
+// The metrics are a prometheus.Collector and report the errors when registered.
+type HandlerMetrics struct {
+	errors *prometheus.CounterVec
+}
+
+var _ prometheus.Collector = &HandlerMetrics{}
+
+// NewHandlerMetrics returns metrics for the errors of handlers.
+func NewHandlerMetrics() *HandlerMetrics {
+	return &HandlerMetrics{
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bus",
+			Subsystem: "consumer",
+			Name:      "handler_errors_total",
+			Help:      "errors of message handlers by error class.",
+		}, []string{"topic", "channel", "class"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *HandlerMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *HandlerMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.errors.Collect(ch)
+}
+
+// WithHandlerMetrics counts the errors of the handlers of the consumer in the given metrics.
+func WithHandlerMetrics(metrics *HandlerMetrics) Option {
+	return func(c *Consumer) *Consumer {
+		c.handlerMetrics = metrics
+		return c
+	}
+}
+
+// report logs the failure of a handler and passes it to the metrics and the callback of the consumer.
+func (tw *timeoutWrapper) report(message *nsq.Message, err error) {
+	class := Classify(err)
+
+	if tw.log != nil {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			tw.log.Error("recovered panic of message handler", "id", string(message.ID[:]), "topic", tw.topic, "channel", tw.channel, "error", err, "stack", string(panicErr.Stack))
+		}
+	}
+
+	if tw.metrics != nil {
+		tw.metrics.errors.WithLabelValues(tw.topic, tw.channel, string(class)).Inc()
+	}
+
+	if tw.onError != nil {
+		tw.onError(HandlerErrorEvent{
+			Topic:     tw.topic,
+			Channel:   tw.channel,
+			MessageID: string(message.ID[:]),
+			Attempts:  message.Attempts,
+			Class:     class,
+			Error:     err,
+		})
+	}
+}
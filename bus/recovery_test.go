@@ -0,0 +1,98 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{
+			name: "generic",
+			err:  errors.New("generic"),
+			want: ErrorClassGeneric,
+		},
+		{
+			name: "terminal",
+			err:  fmt.Errorf("wrapped: %w", Terminal(errors.New("not found"))),
+			want: ErrorClassTerminal,
+		},
+		{
+			name: "retryable",
+			err:  Retryable(errors.New("unavailable"), time.Second),
+			want: ErrorClassRetryable,
+		},
+		{
+			name: "panic",
+			err:  &PanicError{Value: "boom"},
+			want: ErrorClassPanic,
+		},
+		{
+			name: "panic with terminal error",
+			err:  &PanicError{Value: Terminal(errors.New("not found"))},
+			want: ErrorClassTerminal,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
+
+func TestTimeoutWrapper_RecoverPanic(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second} {
+		var events []HandlerErrorEvent
+
+		metrics := NewHandlerMetrics()
+		tw := &timeoutWrapper{
+			msgType: reflect.TypeOf(""),
+			recv: func(interface{}) error {
+				panic("boom")
+			},
+			timeout: timeout,
+			topic:   "topic",
+			channel: "channel",
+			onError: func(e HandlerErrorEvent) { events = append(events, e) },
+			metrics: metrics,
+		}
+
+		delegate := &recordingDelegate{}
+		msg := nsq.NewMessage(nsq.MessageID{'1'}, []byte(`"payload"`))
+		msg.Delegate = delegate
+		msg.Attempts = 2
+
+		err := tw.handleWithTimeout(msg)
+		require.EqualError(t, err, "panic: boom")
+
+		var panicErr *PanicError
+		require.ErrorAs(t, err, &panicErr)
+		require.Contains(t, string(panicErr.Stack), "recovery_test.go")
+
+		require.Len(t, events, 1)
+		require.Equal(t, "topic", events[0].Topic)
+		require.Equal(t, "channel", events[0].Channel)
+		require.Equal(t, uint16(2), events[0].Attempts)
+		require.Equal(t, ErrorClassPanic, events[0].Class)
+
+		want := `
+# HELP bus_consumer_handler_errors_total errors of message handlers by error class.
+# TYPE bus_consumer_handler_errors_total counter
+bus_consumer_handler_errors_total{channel="channel",class="panic",topic="topic"} 1
+`
+		require.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(want)))
+	}
+}
@@ -0,0 +1,220 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSnapshotInterval = time.Minute
+
+// NSQDSnapshotStore persists the nsqds that produce a topic as last discovered through the lookupds.
+type NSQDSnapshotStore interface {
+	// Load returns the tcp addresses of the nsqds of the given topic, nil if there is no snapshot.
+	Load(topic string) ([]string, error)
+	// Save stores the tcp addresses of the nsqds of the given topic.
+	Save(topic string, nsqds []string) error
+}
+
+type fileSnapshotStore struct {
+	dir string
+}
+
+// NewFileNSQDSnapshotStore returns a store, which persists the nsqds of every topic in a json file in the given
+// directory, e.g. a cache directory that survives restarts of the process.
+func NewFileNSQDSnapshotStore(dir string) NSQDSnapshotStore {
+	return &fileSnapshotStore{dir: dir}
+}
+
+type nsqdSnapshot struct {
+	Timestamp time.Time `json:"timestamp"`
+	NSQDs     []string  `json:"nsqds"`
+}
+
+func (s *fileSnapshotStore) path(topic string) string {
+	return filepath.Join(s.dir, topic+".json")
+}
+
+func (s *fileSnapshotStore) Load(topic string) ([]string, error) {
+	raw, err := os.ReadFile(s.path(topic))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot nsqdSnapshot
+	err = json.Unmarshal(raw, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot of topic %q: %w", topic, err)
+	}
+
+	return snapshot.NSQDs, nil
+}
+
+func (s *fileSnapshotStore) Save(topic string, nsqds []string) error {
+	raw, err := json.Marshal(nsqdSnapshot{Timestamp: time.Now(), NSQDs: nsqds})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	// write atomically such that concurrent loads never read a partial snapshot
+	tmp := s.path(topic) + ".tmp"
+	err = os.WriteFile(tmp, raw, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path(topic))
+}
+
+// NSQDSnapshot persists the nsqds that produce the topics of the consumer in the given store, such that consumers
+// connect to the last known nsqds if no lookupd is reachable on startup, e.g. during a restart of the lookupds.
+// Consumers still connect to the lookupds and discover nsqds as soon as the lookupds are reachable again.
+//
+// The snapshot is refreshed in the given interval while the lookupds are reachable, defaults to one minute.
+// Snapshots are only used for consumers that discover the nsqds through lookupds.
+func NSQDSnapshot(store NSQDSnapshotStore, interval time.Duration) Option {
+	return func(c *Consumer) *Consumer {
+		if interval <= 0 {
+			interval = defaultSnapshotInterval
+		}
+		c.snapshots = store
+		c.snapshotInterval = interval
+		return c
+	}
+}
+
+// bootstrapFromSnapshot connects to the nsqds of the snapshot if no lookupd is reachable and keeps the snapshot up to
+// date in the background until the registration is closed.
+func (cr *ConsumerRegistration) bootstrapFromSnapshot() {
+	client := &http.Client{Timeout: cr.consumer.config.LookupdPollTimeout}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cr.stopSnapshots = cancel
+
+	nsqds, err := cr.refreshSnapshot(ctx, client)
+	if err != nil {
+		cr.logSnapshot("unable to discover nsqds through lookupds, connecting to the nsqds of the snapshot", "nsqds", nsqds, "error", err)
+
+		for _, nsqd := range nsqds {
+			err := cr.c.ConnectToNSQD(nsqd)
+			if err != nil {
+				cr.logSnapshot("unable to connect to nsqd of the snapshot", "nsqd", nsqd, "error", err)
+			}
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(cr.consumer.snapshotInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, err := cr.refreshSnapshot(ctx, client)
+				if err != nil {
+					cr.logSnapshot("unable to refresh snapshot of nsqds", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// refreshSnapshot looks up the nsqds of the topic and saves them in the snapshot. If the lookupds are unreachable,
+// the nsqds of the snapshot are returned along with the error. Failing to save the snapshot is only logged, because
+// the nsqds were discovered through the lookupds anyway.
+func (cr *ConsumerRegistration) refreshSnapshot(ctx context.Context, client *http.Client) ([]string, error) {
+	store := cr.consumer.snapshots
+
+	nsqds, lookupErr := lookupNSQDs(ctx, client, cr.consumer.lookupds, cr.topic)
+	if lookupErr == nil {
+		err := store.Save(cr.topic, nsqds)
+		if err != nil {
+			cr.logSnapshot("unable to save snapshot of nsqds", "nsqds", nsqds, "error", err)
+		}
+		return nsqds, nil
+	}
+
+	nsqds, err := store.Load(cr.topic)
+	if err != nil {
+		return nil, errors.Join(lookupErr, fmt.Errorf("unable to load snapshot: %w", err))
+	}
+
+	return nsqds, lookupErr
+}
+
+func (cr *ConsumerRegistration) logSnapshot(msg string, args ...any) {
+	if cr.log == nil {
+		return
+	}
+	cr.log.Warn(msg, append([]any{"topic", cr.topic, "channel", cr.channel}, args...)...)
+}
+
+// lookupNSQDs returns the tcp addresses of the nsqds producing the given topic. An error is only returned if none of
+// the lookupds is reachable, topics unknown to a lookupd have no nsqds.
+func lookupNSQDs(ctx context.Context, client *http.Client, lookupds []string, topic string) ([]string, error) {
+	var (
+		seen   = map[string]bool{}
+		result = []string{}
+		errs   []error
+	)
+
+	for _, lookupd := range lookupds {
+		producers, err := lookupProducers(ctx, client, lookupd, topic)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lookupd %s: %w", lookupd, err))
+			continue
+		}
+
+		for _, p := range producers {
+			address := net.JoinHostPort(p.BroadcastAddress, strconv.Itoa(p.TCPPort))
+			if seen[address] {
+				continue
+			}
+			seen[address] = true
+			result = append(result, address)
+		}
+	}
+
+	if len(errs) == len(lookupds) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+func lookupProducers(ctx context.Context, client *http.Client, lookupd, topic string) ([]lookupdProducer, error) {
+	// like nsq, lookupd addresses without scheme are queried through http
+	endpoint := lookupd
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	producers, err := lookupdProducers(ctx, client, endpoint+"/lookup?"+url.Values{"topic": {topic}}.Encode())
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		// the topic was not registered by any nsqd yet
+		return nil, nil
+	}
+
+	return producers, err
+}
@@ -0,0 +1,96 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNSQDSnapshotStore(t *testing.T) {
+	store := NewFileNSQDSnapshotStore(t.TempDir() + "/snapshots")
+
+	nsqds, err := store.Load("topic")
+	require.NoError(t, err)
+	require.Nil(t, nsqds)
+
+	require.NoError(t, store.Save("topic", []string{"10.0.0.1:4150", "10.0.0.2:4150"}))
+
+	nsqds, err = store.Load("topic")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:4150", "10.0.0.2:4150"}, nsqds)
+}
+
+func TestRefreshSnapshot(t *testing.T) {
+	lookupd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/lookup", r.URL.Path)
+
+		switch r.URL.Query().Get("topic") {
+		case "topic":
+			_, _ = fmt.Fprint(w, `{"producers":[{"broadcast_address":"10.0.0.1","tcp_port":4150,"http_port":4151}]}`)
+		default:
+			http.Error(w, `{"message":"TOPIC_NOT_FOUND"}`, http.StatusNotFound)
+		}
+	}))
+	defer lookupd.Close()
+
+	cr := &ConsumerRegistration{
+		consumer: &Consumer{
+			lookupds:  []string{strings.TrimPrefix(lookupd.URL, "http://")},
+			snapshots: NewFileNSQDSnapshotStore(t.TempDir()),
+		},
+		topic: "topic",
+	}
+
+	nsqds, err := cr.refreshSnapshot(context.Background(), http.DefaultClient)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:4150"}, nsqds)
+
+	// unknown topics have no nsqds
+	unknown := *cr
+	unknown.topic = "unknown"
+	nsqds, err = unknown.refreshSnapshot(context.Background(), http.DefaultClient)
+	require.NoError(t, err)
+	require.Empty(t, nsqds)
+
+	// the snapshot is used when the lookupd is unreachable
+	lookupd.Close()
+
+	nsqds, err = cr.refreshSnapshot(context.Background(), http.DefaultClient)
+	require.Error(t, err)
+	require.Equal(t, []string{"10.0.0.1:4150"}, nsqds)
+}
+
+type failingSnapshotStore struct {
+	NSQDSnapshotStore
+}
+
+func (failingSnapshotStore) Save(string, []string) error {
+	return errors.New("disk full")
+}
+
+func TestRefreshSnapshotSaveFailure(t *testing.T) {
+	lookupd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// nsqlookupd before v1.0 wraps the response into a data field
+		_, _ = fmt.Fprint(w, `{"data":{"producers":[{"broadcast_address":"10.0.0.1","tcp_port":4150,"http_port":4151}]}}`)
+	}))
+	defer lookupd.Close()
+
+	cr := &ConsumerRegistration{
+		consumer: &Consumer{
+			lookupds:  []string{lookupd.URL},
+			snapshots: failingSnapshotStore{},
+		},
+		topic: "topic",
+	}
+
+	// the nsqds were discovered, so the failing snapshot is no lookup error
+	nsqds, err := cr.refreshSnapshot(context.Background(), http.DefaultClient)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:4150"}, nsqds)
+}
@@ -0,0 +1,124 @@
+package bus
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Versions maps payload versions to the handlers of a versioned function, see VersionedFunction.
+type Versions map[int]interface{}
+
+// VersionMetrics counts the invocations of versioned functions per payload version, such that the remaining producers
+// of an old version can be tracked down before its handler is removed.
+//
+// The metrics are a prometheus.Collector and report the invocations per function and version when registered.
+type VersionMetrics struct {
+	invocations *prometheus.CounterVec
+}
+
+var _ prometheus.Collector = &VersionMetrics{}
+
+// NewVersionMetrics returns metrics for the invocations of versioned functions.
+func NewVersionMetrics() *VersionMetrics {
+	return &VersionMetrics{
+		invocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bus",
+			Subsystem: "function",
+			Name:      "invocations_total",
+			Help:      "invocations of versioned functions by payload version.",
+		}, []string{"function", "version"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *VersionMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.invocations.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *VersionMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.invocations.Collect(ch)
+}
+
+// inc counts an invocation of the given function version, nil metrics do not count.
+func (m *VersionMetrics) inc(function string, version int) {
+	if m == nil {
+		return
+	}
+	m.invocations.WithLabelValues(function, strconv.Itoa(version)).Inc()
+}
+
+// WithVersionMetrics counts the invocations of all versioned functions created by these endpoints in the given metrics.
+func (e *Endpoints) WithVersionMetrics(metrics *VersionMetrics) *Endpoints {
+	e.versions = metrics
+	return e
+}
+
+// VersionedFunction creates a function like Function, which dispatches every invocation to the handler of its payload
+// version. This allows changing the payload of a function incompatibly: the new payload is registered as a new version
+// while the handler of the old version keeps processing invocations of producers that were not updated yet.
+// The returned Func publishes invocations with the given version.
+//
+// Versions start at 1. Invocations without a version, i.e. of producers created with Function or Client, are dispatched
+// to version 1, so an existing function is migrated by registering its handler as version 1. As consumers created with
+// Function cannot unwrap versioned invocations, all consumers must be migrated before producers publish versions.
+// Invocations of versions without a handler fail with a terminal error.
+func (e *Endpoints) VersionedFunction(name string, versions Versions, version int) (*Function, Func, error) {
+	if len(versions) == 0 {
+		return nil, nil, fmt.Errorf("versioned function %q requires at least one version", name)
+	}
+
+	handlers := map[int]reflect.Value{}
+	for v, fn := range versions {
+		if v < 1 {
+			return nil, nil, fmt.Errorf("version %d of function %q is invalid, versions start at 1", v, name)
+		}
+		if fn == nil {
+			return nil, nil, fmt.Errorf("version %d of function %q has no handler", v, name)
+		}
+		if err := validateFunc(fn); err != nil {
+			return nil, nil, fmt.Errorf("version %d of function %q: %w", v, name, err)
+		}
+		handlers[v] = reflect.ValueOf(fn)
+	}
+
+	fn, ok := versions[version]
+	if !ok {
+		return nil, nil, fmt.Errorf("function %q has no handler for the published version %d", name, version)
+	}
+
+	return e.function(name, "function", fn, handlers, version)
+}
+
+// VersionedClient returns a client for a versioned function, which publishes invocations with the given version.
+func (e *Endpoints) VersionedClient(name string, version int) (*Function, Func, error) {
+	if version < 1 {
+		return nil, nil, fmt.Errorf("version %d of function %q is invalid, versions start at 1", version, name)
+	}
+
+	return e.function(name, "function", nil, nil, version)
+}
+
+// handler returns the handler for the given payload version and counts the invocation.
+// Unversioned functions handle all invocations regardless of their version.
+func (f *Function) handler(version int) (reflect.Value, error) {
+	if f.handlers == nil {
+		return f.fn, nil
+	}
+
+	if version == 0 {
+		version = 1
+	}
+
+	fn, ok := f.handlers[version]
+	if !ok {
+		return reflect.Value{}, Terminal(fmt.Errorf("function %q has no handler for version %d", f.name, version))
+	}
+
+	f.versionMetrics.inc(f.name, version)
+
+	return fn, nil
+}
@@ -0,0 +1,157 @@
+package bus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type machineV1 struct {
+	Name string `json:"name"`
+}
+
+type machineV2 struct {
+	Hostname string `json:"hostname"`
+}
+
+func TestVersionedFunctionDispatch(t *testing.T) {
+	var (
+		v1      []string
+		v2      []string
+		metrics = NewVersionMetrics()
+	)
+
+	fn, _, err := DirectEndpoints().WithVersionMetrics(metrics).VersionedFunction("machine-create", Versions{
+		1: func(m machineV1) error {
+			v1 = append(v1, m.Name)
+			return nil
+		},
+		2: func(m *machineV2) error {
+			v2 = append(v2, m.Hostname)
+			return nil
+		},
+	}, 2)
+	require.NoError(t, err)
+
+	envelope := func(version int, payload string) *functionEnvelope {
+		var e functionEnvelope
+		raw := payload
+		if version != 0 {
+			raw = fmt.Sprintf(`{"__version":%d,"__payload":%s}`, version, payload)
+		}
+		require.NoError(t, json.Unmarshal([]byte(raw), &e))
+		return &e
+	}
+
+	require.NoError(t, fn.receiveEnvelope(envelope(0, `{"name":"legacy"}`)))
+	require.NoError(t, fn.receiveEnvelope(envelope(1, `{"name":"old"}`)))
+	require.NoError(t, fn.receiveEnvelope(envelope(2, `{"hostname":"new"}`)))
+
+	err = fn.receiveEnvelope(envelope(3, `{"hostname":"future"}`))
+	require.EqualError(t, err, `terminal: function "machine-create" has no handler for version 3`)
+	require.True(t, IsTerminal(err))
+
+	require.Equal(t, []string{"legacy", "old"}, v1)
+	require.Equal(t, []string{"new"}, v2)
+
+	want := `# HELP bus_function_invocations_total invocations of versioned functions by payload version.
+# TYPE bus_function_invocations_total counter
+bus_function_invocations_total{function="machine-create",version="1"} 2
+bus_function_invocations_total{function="machine-create",version="2"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(metrics, strings.NewReader(want)))
+}
+
+func TestVersionedFunctionDirectEndpoints(t *testing.T) {
+	called := make(chan string, 1)
+
+	_, f, err := DirectEndpoints().VersionedFunction("machine-create", Versions{
+		1: func(m machineV1) error {
+			called <- "v1"
+			return nil
+		},
+		2: func(m machineV2) error {
+			called <- "v2"
+			return nil
+		},
+	}, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, f(machineV2{Hostname: "a"}))
+
+	select {
+	case got := <-called:
+		require.Equal(t, "v2", got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("function was not called")
+	}
+}
+
+func TestVersionedClientPublishesVersion(t *testing.T) {
+	p := &recordingPublisher{}
+
+	_, f, err := NewEndpoints(nil, p).VersionedClient("machine-create", 2)
+	require.NoError(t, err)
+
+	require.NoError(t, f(machineV2{Hostname: "a"}))
+
+	require.Equal(t, "machine-create", p.topic)
+	raw, err := json.Marshal(p.data)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"__published":"0001-01-01T00:00:00Z","__version":2,"__payload":{"hostname":"a"}}`, string(raw))
+
+	var e functionEnvelope
+	require.NoError(t, json.Unmarshal(raw, &e))
+	require.Equal(t, 2, e.Version)
+	require.True(t, e.Published.IsZero())
+}
+
+func TestVersionedFunctionValidation(t *testing.T) {
+	ok := func(machineV1) error { return nil }
+
+	tests := []struct {
+		name     string
+		versions Versions
+		version  int
+		wantErr  string
+	}{
+		{
+			name:    "no versions",
+			version: 1,
+			wantErr: `versioned function "f" requires at least one version`,
+		},
+		{
+			name:     "invalid version",
+			versions: Versions{0: ok},
+			version:  0,
+			wantErr:  `version 0 of function "f" is invalid, versions start at 1`,
+		},
+		{
+			name:     "invalid handler",
+			versions: Versions{1: func(a, b string) error { return nil }},
+			version:  1,
+			wantErr:  `version 1 of function "f": the number of parameters in the function must be one`,
+		},
+		{
+			name:     "published version without handler",
+			versions: Versions{1: ok},
+			version:  2,
+			wantErr:  `function "f" has no handler for the published version 2`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DirectEndpoints().VersionedFunction("f", tt.versions, tt.version)
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	_, _, err := DirectEndpoints().VersionedClient("f", 0)
+	require.EqualError(t, err, `version 0 of function "f" is invalid, versions start at 1`)
+}
@@ -0,0 +1,130 @@
+package httperrors
+
+import (
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StatusClientClosedRequest is the non-standard http status code for requests that were canceled by the client.
+const StatusClientClosedRequest = 499
+
+// StatusFromConnectCode returns the http status code for the given connect code as defined by the connect protocol.
+func StatusFromConnectCode(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return StatusClientClosedRequest
+	case connect.CodeInvalidArgument, connect.CodeFailedPrecondition, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// StatusFromGRPCCode returns the http status code for the given grpc code, see StatusFromConnectCode.
+func StatusFromGRPCCode(code codes.Code) int {
+	if code == codes.OK {
+		return http.StatusOK
+	}
+	// connect codes are identical to grpc codes
+	return StatusFromConnectCode(connect.Code(code))
+}
+
+// ConnectCodeFromStatus returns the connect code for the given http status code.
+func ConnectCodeFromStatus(statusCode int) connect.Code {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return connect.CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return connect.CodeUnauthenticated
+	case http.StatusForbidden:
+		return connect.CodePermissionDenied
+	case http.StatusNotFound:
+		return connect.CodeNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return connect.CodeDeadlineExceeded
+	case http.StatusConflict:
+		return connect.CodeAlreadyExists
+	case http.StatusPreconditionFailed:
+		return connect.CodeFailedPrecondition
+	case http.StatusTooManyRequests:
+		return connect.CodeResourceExhausted
+	case StatusClientClosedRequest:
+		return connect.CodeCanceled
+	case http.StatusNotImplemented:
+		return connect.CodeUnimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return connect.CodeUnavailable
+	case http.StatusInternalServerError:
+		return connect.CodeInternal
+	default:
+		return connect.CodeUnknown
+	}
+}
+
+// GRPCCodeFromStatus returns the grpc code for the given http status code, see ConnectCodeFromStatus.
+func GRPCCodeFromStatus(statusCode int) codes.Code {
+	if statusCode >= 200 && statusCode < 300 {
+		return codes.OK
+	}
+	return codes.Code(ConnectCodeFromStatus(statusCode))
+}
+
+// FromConnect converts the given connect error to an http error response. Errors that are no connect errors are
+// internal server errors, nil is returned for a nil error.
+func FromConnect(err error) *HTTPErrorResponse {
+	if err == nil {
+		return nil
+	}
+
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return InternalServerError(err)
+	}
+
+	return NewHTTPError(StatusFromConnectCode(connectErr.Code()), errors.New(connectErr.Message()))
+}
+
+// ToConnect converts the given http error response to a connect error.
+func ToConnect(httpErr *HTTPErrorResponse) *connect.Error {
+	return connect.NewError(ConnectCodeFromStatus(httpErr.StatusCode), errors.New(httpErr.Message))
+}
+
+// FromGRPC converts the given grpc status error to an http error response. Errors that are no grpc status errors are
+// internal server errors, nil is returned for a nil error.
+func FromGRPC(err error) *HTTPErrorResponse {
+	if err == nil {
+		return nil
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return InternalServerError(err)
+	}
+
+	return NewHTTPError(StatusFromGRPCCode(s.Code()), errors.New(s.Message()))
+}
+
+// ToGRPC converts the given http error response to a grpc status error.
+func ToGRPC(httpErr *HTTPErrorResponse) error {
+	return status.Error(GRPCCodeFromStatus(httpErr.StatusCode), httpErr.Message)
+}
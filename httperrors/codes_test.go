@@ -0,0 +1,61 @@
+package httperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConnectRoundTrip(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		code       connect.Code
+	}{
+		{statusCode: http.StatusBadRequest, code: connect.CodeInvalidArgument},
+		{statusCode: http.StatusUnauthorized, code: connect.CodeUnauthenticated},
+		{statusCode: http.StatusForbidden, code: connect.CodePermissionDenied},
+		{statusCode: http.StatusNotFound, code: connect.CodeNotFound},
+		{statusCode: http.StatusConflict, code: connect.CodeAlreadyExists},
+		{statusCode: http.StatusTooManyRequests, code: connect.CodeResourceExhausted},
+		{statusCode: StatusClientClosedRequest, code: connect.CodeCanceled},
+		{statusCode: http.StatusNotImplemented, code: connect.CodeUnimplemented},
+		{statusCode: http.StatusServiceUnavailable, code: connect.CodeUnavailable},
+		{statusCode: http.StatusGatewayTimeout, code: connect.CodeDeadlineExceeded},
+		{statusCode: http.StatusInternalServerError, code: connect.CodeInternal},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("%d", tt.statusCode), func(t *testing.T) {
+			connectErr := ToConnect(NewHTTPError(tt.statusCode, errors.New("message")))
+			require.Equal(t, tt.code, connectErr.Code())
+			require.Equal(t, "message", connectErr.Message())
+
+			require.Equal(t, NewHTTPError(tt.statusCode, errors.New("message")), FromConnect(connectErr))
+
+			grpcErr := ToGRPC(NewHTTPError(tt.statusCode, errors.New("message")))
+			require.Equal(t, codes.Code(tt.code), status.Code(grpcErr))
+
+			require.Equal(t, NewHTTPError(tt.statusCode, errors.New("message")), FromGRPC(grpcErr))
+		})
+	}
+}
+
+func TestFromConnect(t *testing.T) {
+	require.Nil(t, FromConnect(nil))
+	require.Equal(t, InternalServerError(errors.New("boom")), FromConnect(errors.New("boom")))
+	require.Equal(t, BadRequest(errors.New("out of range")), FromConnect(fmt.Errorf("wrapped: %w", connect.NewError(connect.CodeOutOfRange, errors.New("out of range")))))
+	require.Equal(t, connect.CodeUnknown, ConnectCodeFromStatus(http.StatusTeapot))
+}
+
+func TestFromGRPC(t *testing.T) {
+	require.Nil(t, FromGRPC(nil))
+	require.Equal(t, InternalServerError(errors.New("boom")), FromGRPC(errors.New("boom")))
+	require.Equal(t, codes.OK, GRPCCodeFromStatus(http.StatusOK))
+	require.Equal(t, http.StatusOK, StatusFromGRPCCode(codes.OK))
+}
@@ -0,0 +1,199 @@
+package httperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// ProblemContentType is the content type of problem details as defined by RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// problemMembers are the members of problem details defined by RFC 7807, which are no extensions.
+var problemMembers = map[string]bool{
+	"type":     true,
+	"title":    true,
+	"status":   true,
+	"detail":   true,
+	"instance": true,
+}
+
+// ProblemDetails describes an error in the format of RFC 7807.
+type ProblemDetails struct {
+	// Type is a uri reference identifying the problem type, defaults to "about:blank".
+	Type string `json:"type,omitempty"`
+	// Title is a short summary of the problem type, for "about:blank" the text of the http status.
+	Title string `json:"title,omitempty"`
+	// Status is the http status code.
+	Status int `json:"status,omitempty"`
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a uri reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Extensions are additional members of the problem details, which are serialized next to the standard members.
+	Extensions map[string]any `json:"-"`
+}
+
+// NewProblem creates problem details of type "about:blank" for the given status code and error.
+func NewProblem(status int, err error) *ProblemDetails {
+	p := &ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+	if err != nil {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+func (p *ProblemDetails) Error() string {
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	return fmt.Sprintf("%s (%d)", msg, p.Status)
+}
+
+// HTTPError converts the problem details to an http error response.
+func (p *ProblemDetails) HTTPError() *HTTPErrorResponse {
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+	return &HTTPErrorResponse{
+		StatusCode: p.Status,
+		Message:    msg,
+	}
+}
+
+// Problem converts the http error response to problem details.
+func (h *HTTPErrorResponse) Problem() *ProblemDetails {
+	p := NewProblem(h.StatusCode, nil)
+	p.Detail = h.Message
+	return p
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := map[string]any{}
+	for k, v := range p.Extensions {
+		if problemMembers[k] {
+			continue
+		}
+		members[k] = v
+	}
+
+	type plain ProblemDetails
+	raw, err := json.Marshal(plain(p))
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return raw, nil
+	}
+
+	err = json.Unmarshal(raw, &members)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(members)
+}
+
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type plain ProblemDetails
+	var standard plain
+	err := json.Unmarshal(data, &standard)
+	if err != nil {
+		return err
+	}
+
+	var members map[string]any
+	err = json.Unmarshal(data, &members)
+	if err != nil {
+		return err
+	}
+
+	*p = ProblemDetails(standard)
+	p.Extensions = nil
+
+	for k, v := range members {
+		if problemMembers[k] {
+			continue
+		}
+		if p.Extensions == nil {
+			p.Extensions = map[string]any{}
+		}
+		p.Extensions[k] = v
+	}
+
+	return nil
+}
+
+// ToProblem converts the given error to problem details. Http error responses, problem details and connect errors
+// keep their status, all other errors are internal server errors.
+func ToProblem(err error) *ProblemDetails {
+	var problem *ProblemDetails
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var httpErr *HTTPErrorResponse
+	if errors.As(err, &httpErr) {
+		return httpErr.Problem()
+	}
+
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return NewProblem(StatusFromConnectCode(connectErr.Code()), errors.New(connectErr.Message()))
+	}
+
+	return NewProblem(http.StatusInternalServerError, err)
+}
+
+// Write writes the given error to the response. If the client accepts problem details, the error is written as
+// problem details as defined by RFC 7807, otherwise as HTTPErrorResponse for compatibility with existing clients.
+func Write(w http.ResponseWriter, r *http.Request, err error) error {
+	problem := *ToProblem(err)
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+
+	// the http error response implements encoding.TextMarshaler, which would be encoded as json string
+	type plainHTTPError HTTPErrorResponse
+
+	var body any = plainHTTPError(*problem.HTTPError())
+	contentType := "application/json"
+
+	if acceptsProblem(r) {
+		body = problem
+		contentType = ProblemContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(problem.Status)
+
+	return json.NewEncoder(w).Encode(body)
+}
+
+func acceptsProblem(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err == nil && mediaType == ProblemContentType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package httperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemDetailsJSON(t *testing.T) {
+	p := &ProblemDetails{
+		Type:     "https://metal-stack.io/problems/quota",
+		Title:    "quota exceeded",
+		Status:   http.StatusForbidden,
+		Detail:   "the project has no ips left",
+		Instance: "/v1/ip/allocate",
+		Extensions: map[string]any{
+			"quota":  float64(10),
+			"status": "ignored",
+		},
+	}
+
+	raw, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"https://metal-stack.io/problems/quota","title":"quota exceeded","status":403,"detail":"the project has no ips left","instance":"/v1/ip/allocate","quota":10}`, string(raw))
+
+	var got ProblemDetails
+	require.NoError(t, json.Unmarshal(raw, &got))
+
+	p.Extensions = map[string]any{"quota": float64(10)}
+	if diff := cmp.Diff(p, &got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestToProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *ProblemDetails
+	}{
+		{
+			name: "http error",
+			err:  NotFound(errors.New("machine not found")),
+			want: &ProblemDetails{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "machine not found"},
+		},
+		{
+			name: "wrapped http error",
+			err:  fmt.Errorf("wrapped: %w", Conflict(errors.New("already exists"))),
+			want: &ProblemDetails{Type: "about:blank", Title: "Conflict", Status: http.StatusConflict, Detail: "already exists"},
+		},
+		{
+			name: "connect error",
+			err:  connect.NewError(connect.CodePermissionDenied, errors.New("not allowed")),
+			want: &ProblemDetails{Type: "about:blank", Title: "Forbidden", Status: http.StatusForbidden, Detail: "not allowed"},
+		},
+		{
+			name: "other error",
+			err:  errors.New("boom"),
+			want: &ProblemDetails{Type: "about:blank", Title: "Internal Server Error", Status: http.StatusInternalServerError, Detail: "boom"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ToProblem(tt.err)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "legacy clients get http error response",
+			accept:          "application/json",
+			wantContentType: "application/json",
+			wantBody:        `{"statuscode":404,"message":"machine not found"}`,
+		},
+		{
+			name:            "problem details are negotiated",
+			accept:          "application/json, application/problem+json;q=0.9",
+			wantContentType: ProblemContentType,
+			wantBody:        `{"type":"about:blank","title":"Not Found","status":404,"detail":"machine not found"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			require.NoError(t, Write(w, r, NotFound(errors.New("machine not found"))))
+
+			require.Equal(t, http.StatusNotFound, w.Code)
+			require.Equal(t, tt.wantContentType, w.Header().Get("Content-Type"))
+			require.JSONEq(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestProblemHTTPErrorConversion(t *testing.T) {
+	httpErr := BadRequest(errors.New("invalid name"))
+	require.Equal(t, httpErr, httpErr.Problem().HTTPError())
+	require.Equal(t, "Not Found (404)", NewProblem(http.StatusNotFound, nil).Error())
+}
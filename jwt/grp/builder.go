@@ -0,0 +1,109 @@
+package grp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ADPermission is the permission suffix of ActiveDirectory groups
+type ADPermission string
+
+const (
+	ADPermissionFull ADPermission = "full"
+	ADPermissionMod  ADPermission = "mod"
+	ADPermissionRead ADPermission = "read"
+
+	// adGroupType is the group type of permission groups: TnPg
+	adGroupType = "Pg"
+	// adSecondLevelOU is the second level organizational unit: Srv
+	adSecondLevelOU = "Srv"
+	// adTenantPrefixLength is the length of the tenant prefix of ActiveDirectory groups: Tn
+	adTenantPrefixLength = 2
+)
+
+// BuildADGroup returns the ActiveDirectory group for the given tenant prefix, group and permission,
+// e.g. TnPg_Srv_Appkaas-ddd#clustername-namespace-role_full.
+//
+// The result can be parsed with ParseADGroup, which returns the given tenant prefix and group in lower case.
+func BuildADGroup(tenantPrefix string, group Group, permission ADPermission) (string, error) {
+	if len(tenantPrefix) != adTenantPrefixLength {
+		return "", fmt.Errorf("%w: tenant prefix %q must have a length of %d", errInvalidFormat, tenantPrefix, adTenantPrefixLength)
+	}
+	if err := validateGroupPart("tenant prefix", tenantPrefix, false); err != nil {
+		return "", err
+	}
+
+	switch ADPermission(strings.ToLower(string(permission))) {
+	case ADPermissionFull, ADPermissionMod, ADPermissionRead:
+	default:
+		return "", fmt.Errorf("%w: unsupported permission %q", errInvalidFormat, permission)
+	}
+
+	if err := validateGroup(group); err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		tenantPrefix + adGroupType,
+		adSecondLevelOU,
+		adReferencePrefix + group.ToFullGroupString(),
+		strings.ToLower(string(permission)),
+	}, outerGroupPartSeparator), nil
+}
+
+// BuildUnixLDAPGroup returns the UNIX LDAP group for the given tenant prefix and group,
+// e.g. tnnt_kaas-ddd#clustername-namespace-role.
+//
+// The result can be parsed with ParseUnixLDAPGroup, which returns the given tenant prefix and group in lower case.
+func BuildUnixLDAPGroup(tenantPrefix string, group Group) (string, error) {
+	if err := validateGroupPart("tenant prefix", tenantPrefix, false); err != nil {
+		return "", err
+	}
+
+	if err := validateGroup(group); err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		tenantPrefix,
+		group.ToFullGroupString(),
+	}, outerGroupPartSeparator), nil
+}
+
+// validateGroup ensures that the group can be parsed again, i.e. that no part contains one of the separators.
+func validateGroup(group Group) error {
+	for _, part := range []struct {
+		name     string
+		value    string
+		optional bool
+	}{
+		{name: "app prefix", value: group.AppPrefix},
+		{name: "on behalf tenant", value: group.OnBehalfTenant, optional: true},
+		{name: "first scope", value: group.FirstScope},
+		{name: "second scope", value: group.SecondScope},
+		{name: "role", value: group.Role},
+	} {
+		if err := validateGroupPart(part.name, part.value, part.optional); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateGroupPart(name, value string, optional bool) error {
+	if value == "" {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must not be empty", errInvalidFormat, name)
+	}
+
+	for _, sep := range []string{outerGroupPartSeparator, innerGroupPartSeparator, onBehalfAndScopeSeparator} {
+		if strings.Contains(value, sep) {
+			return fmt.Errorf("%w: %s %q must not contain %q", errInvalidFormat, name, value, sep)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package grp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildADGroup(t *testing.T) {
+	tests := []struct {
+		name         string
+		tenantPrefix string
+		group        Group
+		permission   ADPermission
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "group",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			permission:   ADPermissionFull,
+			want:         "TnPg_Srv_Appkaas-clustername-namespace-admin_full",
+		},
+		{
+			name:         "on behalf group",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", OnBehalfTenant: "ddd", FirstScope: "clustername", SecondScope: "all", Role: "view"},
+			permission:   ADPermissionRead,
+			want:         "TnPg_Srv_Appkaas-ddd#clustername-all-view_read",
+		},
+		{
+			name:         "encoded name",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", FirstScope: grpr.GroupEncodeName("my-cluster"), SecondScope: "all", Role: "view"},
+			permission:   ADPermissionMod,
+			want:         "TnPg_Srv_Appkaas-my$cluster-all-view_mod",
+		},
+		{
+			name:         "invalid tenant prefix",
+			tenantPrefix: "tnnt",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			permission:   ADPermissionFull,
+			wantErr:      errInvalidFormat,
+		},
+		{
+			name:         "invalid permission",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			permission:   "owner",
+			wantErr:      errInvalidFormat,
+		},
+		{
+			name:         "missing role",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace"},
+			permission:   ADPermissionFull,
+			wantErr:      errInvalidFormat,
+		},
+		{
+			name:         "separator in scope",
+			tenantPrefix: "Tn",
+			group:        Group{AppPrefix: "kaas", FirstScope: "my-cluster", SecondScope: "namespace", Role: "admin"},
+			permission:   ADPermissionFull,
+			wantErr:      errInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildADGroup(tt.tenantPrefix, tt.group, tt.permission)
+			require.ErrorIs(t, err, tt.wantErr)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			parsed, err := grpr.ParseADGroup(got)
+			require.NoError(t, err)
+			require.Equal(t, strings.ToLower(tt.tenantPrefix), parsed.TenantPrefix)
+			require.Equal(t, tt.group, parsed.Group)
+		})
+	}
+}
+
+func TestBuildUnixLDAPGroup(t *testing.T) {
+	tests := []struct {
+		name         string
+		tenantPrefix string
+		group        Group
+		want         string
+		wantErr      error
+	}{
+		{
+			name:         "group",
+			tenantPrefix: "tnnt",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			want:         "tnnt_kaas-clustername-namespace-admin",
+		},
+		{
+			name:         "on behalf group",
+			tenantPrefix: "tnnt",
+			group:        Group{AppPrefix: "kaas", OnBehalfTenant: "ddd", FirstScope: "clustername", SecondScope: "all", Role: "view"},
+			want:         "tnnt_kaas-ddd#clustername-all-view",
+		},
+		{
+			name:         "missing tenant prefix",
+			tenantPrefix: "",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			wantErr:      errInvalidFormat,
+		},
+		{
+			name:         "separator in tenant prefix",
+			tenantPrefix: "tn_nt",
+			group:        Group{AppPrefix: "kaas", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			wantErr:      errInvalidFormat,
+		},
+		{
+			name:         "separator in on behalf tenant",
+			tenantPrefix: "tnnt",
+			group:        Group{AppPrefix: "kaas", OnBehalfTenant: "d#d", FirstScope: "clustername", SecondScope: "namespace", Role: "admin"},
+			wantErr:      errInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildUnixLDAPGroup(tt.tenantPrefix, tt.group)
+			require.ErrorIs(t, err, tt.wantErr)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			parsed, err := grpr.ParseUnixLDAPGroup(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.tenantPrefix, parsed.TenantPrefix)
+			require.Equal(t, tt.group, parsed.Group)
+		})
+	}
+}
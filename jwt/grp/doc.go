@@ -9,5 +9,9 @@
 
 	For group policies all that matters are the elements of the stripped
     "inner" group-name, in this case "clustername", "namespace", "role"
+
+	The grammar of the inner group-name (separators, escaping and number of scopes)
+	can be configured per Grpr, see Grammar. Directories with inconsistently written
+	group names can be matched with unicode case folding and NFC normalization, see Normalizations.
 */
 package grp
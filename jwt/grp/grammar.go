@@ -0,0 +1,91 @@
+package grp
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultGrammarScopes = 2
+
+// Grammar describes the format of inner group names, by default [app]-[opt. onBehalfTenant#][firstScope]-[secondScope]-[role].
+// Tenants with different naming conventions can configure their own grammar, e.g. [app]:[firstScope]:[secondScope]:[thirdScope]:[role].
+type Grammar struct {
+	// Separator separates the parts of the inner group name, defaults to "-"
+	Separator string
+	// OnBehalfSeparator separates the optional on behalf tenant from the first scope, defaults to "#"
+	OnBehalfSeparator string
+	// Escape replaces occurrences of the separator in scope names, see GroupEncodeName, defaults to "$"
+	Escape string
+	// Scopes is the number of scope segments between app prefix and role, defaults to 2.
+	// Scopes beyond the second scope are contained in the AdditionalScopes of a group.
+	Scopes int
+}
+
+// DefaultGrammar returns the grammar of groups like kaas-ddd#clustername-namespace-role
+func DefaultGrammar() Grammar {
+	return Grammar{
+		Separator:         innerGroupPartSeparator,
+		OnBehalfSeparator: onBehalfAndScopeSeparator,
+		Escape:            groupNameEscape,
+		Scopes:            defaultGrammarScopes,
+	}
+}
+
+// withDefaults fills the unset fields of the grammar with the default grammar
+func (gr Grammar) withDefaults() Grammar {
+	def := DefaultGrammar()
+
+	if gr.Separator == "" {
+		gr.Separator = def.Separator
+	}
+	if gr.OnBehalfSeparator == "" {
+		gr.OnBehalfSeparator = def.OnBehalfSeparator
+	}
+	if gr.Escape == "" {
+		gr.Escape = def.Escape
+	}
+	if gr.Scopes == 0 {
+		gr.Scopes = def.Scopes
+	}
+
+	return gr
+}
+
+func (gr Grammar) validate() error {
+	if gr.Scopes < 1 {
+		return fmt.Errorf("grammar must have at least one scope, got %d", gr.Scopes)
+	}
+
+	// the outer separator is used by the directory specific formats around the inner group name
+	if strings.Contains(gr.Separator, outerGroupPartSeparator) || strings.Contains(gr.OnBehalfSeparator, outerGroupPartSeparator) || strings.Contains(gr.Escape, outerGroupPartSeparator) {
+		return fmt.Errorf("grammar must not contain the outer group separator %q", outerGroupPartSeparator)
+	}
+
+	if gr.Separator == gr.OnBehalfSeparator || gr.Separator == gr.Escape || gr.OnBehalfSeparator == gr.Escape {
+		return fmt.Errorf("separator %q, on behalf separator %q and escape %q of grammar must be distinct", gr.Separator, gr.OnBehalfSeparator, gr.Escape)
+	}
+
+	if strings.Contains(gr.Escape, gr.Separator) {
+		return fmt.Errorf("escape %q of grammar must not contain the separator %q", gr.Escape, gr.Separator)
+	}
+
+	return nil
+}
+
+// FormatGroupName returns the inner group name of the given group according to the grammar of the Grpr,
+// i.e. the inverse of ParseGroupName.
+func (g *Grpr) FormatGroupName(group *Group) string {
+	firstScope := group.FirstScope
+	if group.OnBehalfTenant != "" {
+		firstScope = group.OnBehalfTenant + g.grammar.OnBehalfSeparator + firstScope
+	}
+
+	parts := []string{group.AppPrefix, firstScope}
+	if g.grammar.Scopes > 1 {
+		parts = append(parts, group.SecondScope)
+	}
+	parts = append(parts, group.AdditionalScopes...)
+	parts = append(parts, group.Role)
+
+	return strings.Join(parts, g.grammar.Separator)
+}
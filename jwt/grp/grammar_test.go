@@ -0,0 +1,112 @@
+package grp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrammarConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar Grammar
+		wantErr string
+	}{
+		{
+			name:    "defaults",
+			grammar: Grammar{},
+		},
+		{
+			name:    "colon separator with three scopes",
+			grammar: Grammar{Separator: ":", Scopes: 3},
+		},
+		{
+			name:    "negative scopes",
+			grammar: Grammar{Scopes: -1},
+			wantErr: "grammar must have at least one scope, got -1",
+		},
+		{
+			name:    "outer separator",
+			grammar: Grammar{Separator: "_"},
+			wantErr: `grammar must not contain the outer group separator "_"`,
+		},
+		{
+			name:    "separator equals escape",
+			grammar: Grammar{Separator: "$"},
+			wantErr: `separator "$", on behalf separator "#" and escape "$" of grammar must be distinct`,
+		},
+		{
+			name:    "escape contains separator",
+			grammar: Grammar{Escape: "--"},
+			wantErr: `escape "--" of grammar must not contain the separator "-"`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGrpr(Config{ProviderTenant: "tnnt", Grammar: tt.grammar})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCustomGrammar(t *testing.T) {
+	g := MustNewGrpr(Config{
+		ProviderTenant: "tnnt",
+		Grammar: Grammar{
+			Separator:         ":",
+			OnBehalfSeparator: "@",
+			Escape:            "~",
+			Scopes:            3,
+		},
+	})
+
+	ctx, err := g.ParseUnixLDAPGroup("tnnt_kaas:ddd@project:my-cluster:namespace:admin")
+	require.NoError(t, err)
+	require.Equal(t, &GroupContext{
+		TenantPrefix: "tnnt",
+		Group: Group{
+			AppPrefix:        "kaas",
+			OnBehalfTenant:   "ddd",
+			FirstScope:       "project",
+			SecondScope:      "my-cluster",
+			AdditionalScopes: []string{"namespace"},
+			Role:             "admin",
+		},
+	}, ctx)
+
+	require.Equal(t, "kaas:ddd@project:my-cluster:namespace:admin", g.FormatGroupName(&ctx.Group))
+
+	_, err = g.ParseGroupName("kaas:project:cluster:admin")
+	require.ErrorIs(t, err, errInvalidFormat)
+
+	require.Equal(t, "a~b", g.GroupEncodeName("a:b"))
+}
+
+func TestSingleScopeGrammar(t *testing.T) {
+	g := MustNewGrpr(Config{
+		ProviderTenant: "tnnt",
+		Grammar:        Grammar{Scopes: 1},
+	})
+
+	group, err := g.ParseGroupName("kaas-project-admin")
+	require.NoError(t, err)
+	require.Equal(t, &Group{
+		AppPrefix:  "kaas",
+		FirstScope: "project",
+		Role:       "admin",
+	}, group)
+
+	require.Equal(t, "kaas-project-admin", g.FormatGroupName(group))
+}
+
+func TestDefaultGrammarFormat(t *testing.T) {
+	group, err := grpr.ParseGroupName("kaas-ddd#cluster-namespace-admin")
+	require.NoError(t, err)
+
+	require.Equal(t, group.ToFullGroupString(), grpr.FormatGroupName(group))
+}
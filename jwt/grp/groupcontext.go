@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 )
 
 /*
@@ -32,6 +33,9 @@ const (
 	// separator within the clustername part: k8s-ddd#cluster-namespace-role
 	onBehalfAndScopeSeparator = "#"
 
+	// replacement of the inner separator in names: k8s-my$cluster-namespace-role
+	groupNameEscape = "$"
+
 	// ReferencePrefix "App"
 	adReferencePrefix = "App"
 
@@ -45,12 +49,23 @@ const (
 
 // Grpr encapsulates conversion from and to groups.
 type Grpr struct {
-	config Config
+	config  Config
+	grammar Grammar
+
+	lock    sync.RWMutex
+	parsers map[string]GroupContextParseFunc
 }
 
 type Config struct {
 	// tenant-prefixes are dependant on directory-environment
 	ProviderTenant string
+	// GroupParseFuncs are parse funcs for additional directory types, see RegisterGroupParseFunc
+	GroupParseFuncs map[string]GroupContextParseFunc
+	// Grammar is the format of the inner group names, unset fields default to the DefaultGrammar
+	Grammar Grammar
+	// Normalizations are applied to the components of parsed groups and by group expressions created with
+	// NewGroupExpression, by default components are taken as they are.
+	Normalizations Normalizations
 }
 
 // Init configures the Grpr
@@ -58,7 +73,23 @@ func NewGrpr(cfg Config) (*Grpr, error) {
 	if cfg.ProviderTenant == "" {
 		return nil, errors.New("providerTenant must be specified")
 	}
-	return &Grpr{config: cfg}, nil
+
+	grammar := cfg.Grammar.withDefaults()
+	err := grammar.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Grpr{config: cfg, grammar: grammar}
+
+	for directoryType, fn := range cfg.GroupParseFuncs {
+		err := g.RegisterGroupParseFunc(directoryType, fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
 }
 
 // Init configures the Grpr and panics if an error occurs.
@@ -73,30 +104,75 @@ func MustNewGrpr(cfg Config) *Grpr {
 // common signature for the GroupContext parsing funcs
 type GroupContextParseFunc func(group string) (*GroupContext, error)
 
-// SelectGroupParseFunc selects the parsing func according to the given directoryType, see constants
+// RegisterGroupParseFunc registers the parsing func for an additional directoryType, e.g. for path-style groups of keycloak.
+// The directoryType is selected through the directory of the connector id or the directory annotation of the issuer config.
+// The built-in directory types cannot be overridden.
+func (g *Grpr) RegisterGroupParseFunc(directoryType string, fn GroupContextParseFunc) error {
+	directoryType = strings.ToLower(directoryType)
+
+	if directoryType == "" {
+		return errors.New("directoryType must be specified")
+	}
+	if fn == nil {
+		return fmt.Errorf("parse func for directoryType %s must not be nil", directoryType)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.builtinGroupParseFunc(directoryType); ok {
+		return fmt.Errorf("directoryType %s is built-in and cannot be overridden", directoryType)
+	}
+	if _, ok := g.parsers[directoryType]; ok {
+		return fmt.Errorf("directoryType %s is already registered", directoryType)
+	}
+
+	if g.parsers == nil {
+		g.parsers = map[string]GroupContextParseFunc{}
+	}
+	g.parsers[directoryType] = fn
+
+	return nil
+}
+
+// SelectGroupParseFunc selects the parsing func according to the given directoryType, see constants and RegisterGroupParseFunc
 func (g *Grpr) SelectGroupParseFunc(directoryType string) (GroupContextParseFunc, error) {
-	switch strings.ToLower(directoryType) {
+	directoryType = strings.ToLower(directoryType)
+
+	if fn, ok := g.builtinGroupParseFunc(directoryType); ok {
+		return fn, nil
+	}
+
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if fn, ok := g.parsers[directoryType]; ok {
+		return fn, nil
+	}
+
+	return nil, fmt.Errorf("invalid directoryType %s", directoryType)
+}
+
+func (g *Grpr) builtinGroupParseFunc(directoryType string) (GroupContextParseFunc, bool) {
+	switch directoryType {
 	case directoryTypeAD:
-		return g.ParseADGroup, nil
+		return g.ParseADGroup, true
 	case directoryTypeLDAP:
-		return g.ParseUnixLDAPGroup, nil
+		return g.ParseUnixLDAPGroup, true
 	default:
-		return nil, fmt.Errorf("invalid directoryType %s", directoryType)
+		return nil, false
 	}
 }
 
 // IsProviderTenant returns true, if the given tenant is the provider/operator of the service
 // i.e. "tnnt" or "Tn" in our case
 func (g *Grpr) IsProviderTenant(tenant string, directoryType string) (bool, error) {
-
-	switch strings.ToLower(directoryType) {
-	case directoryTypeAD:
-		return tenant == g.config.ProviderTenant, nil
-	case directoryTypeLDAP:
-		return tenant == g.config.ProviderTenant, nil
-	default:
-		return false, fmt.Errorf("invalid directoryType %s", directoryType)
+	_, err := g.SelectGroupParseFunc(directoryType)
+	if err != nil {
+		return false, err
 	}
+
+	return tenant == g.config.ProviderTenant, nil
 }
 
 // Parse parses and structurally validates a group.
@@ -166,45 +242,54 @@ func (g *Grpr) ParseUnixLDAPGroup(groupname string) (*GroupContext, error) {
 	}, nil
 }
 
-// parses the "inner" groupname with stripped tenant prefixes and idm-suffixes
+// parses the "inner" groupname with stripped tenant prefixes and idm-suffixes according to the grammar
 // example kaas-clustername-namespace-role
 func (g *Grpr) ParseGroupName(groupname string) (*Group, error) {
 
-	innerSplit := strings.Split(groupname, innerGroupPartSeparator)
-	if len(innerSplit) != 4 {
+	innerSplit := strings.Split(groupname, g.grammar.Separator)
+	if len(innerSplit) != g.grammar.Scopes+2 {
 		return nil, errInvalidFormat
 	}
 
-	var clusterTenant string
-	clusterName := innerSplit[1]
-	if strings.Contains(clusterName, onBehalfAndScopeSeparator) {
-		cn := strings.Split(clusterName, onBehalfAndScopeSeparator)
-
-		clusterTenant = cn[0]
-		clusterName = cn[1]
+	var (
+		scopes        = innerSplit[1 : len(innerSplit)-1]
+		clusterTenant string
+		clusterName   = scopes[0]
+	)
+	if tenant, name, found := strings.Cut(clusterName, g.grammar.OnBehalfSeparator); found {
+		clusterTenant = tenant
+		clusterName = name
 	}
 
 	group := &Group{
 		AppPrefix:      innerSplit[0],
 		OnBehalfTenant: clusterTenant,
 		FirstScope:     clusterName,
-		SecondScope:    innerSplit[2],
-		Role:           innerSplit[3],
+		Role:           innerSplit[len(innerSplit)-1],
+	}
+
+	if len(scopes) > 1 {
+		group.SecondScope = scopes[1]
 	}
+	if len(scopes) > 2 {
+		group.AdditionalScopes = scopes[2:]
+	}
+
+	normalized := g.config.Normalizations.Apply(*group)
 
-	return group, nil
+	return &normalized, nil
 }
 
-// encodes the name so that it can be used in groups, i.e. "-" are replaced by "$"
+// encodes the name so that it can be used in groups, i.e. "-" are replaced by "$" (or the separator and escape of the grammar)
 func (g *Grpr) GroupEncodeName(name string) string {
-	return strings.ReplaceAll(name, "-", "$")
+	return strings.ReplaceAll(name, g.grammar.Separator, g.grammar.Escape)
 }
 
-// encodes the names so that it can be used in groups, i.e. "-" are replaced by "$"
+// encodes the names so that it can be used in groups, i.e. "-" are replaced by "$" (or the separator and escape of the grammar)
 func (g *Grpr) GroupEncodeNames(names []string) []string {
 	var result []string
 	for i := range names {
-		result = append(result, strings.ReplaceAll(names[i], "-", "$"))
+		result = append(result, g.GroupEncodeName(names[i]))
 	}
 	return result
 }
@@ -3,6 +3,7 @@ package grp
 import (
 	"github.com/stretchr/testify/require"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -514,3 +515,52 @@ func TestGroup_ToOnBehalfGroupString(t *testing.T) {
 		})
 	}
 }
+
+func TestRegisterGroupParseFunc(t *testing.T) {
+	g := MustNewGrpr(Config{
+		ProviderTenant: "tnnt",
+	})
+
+	// keycloak path-style groups like /tnnt/kaas-all-all-admin
+	keycloak := func(groupname string) (*GroupContext, error) {
+		tenant, inner, found := strings.Cut(strings.TrimPrefix(strings.ToLower(groupname), "/"), "/")
+		if !found {
+			return nil, errInvalidFormat
+		}
+
+		group, err := g.ParseGroupName(inner)
+		if err != nil {
+			return nil, err
+		}
+
+		return &GroupContext{TenantPrefix: tenant, Group: *group}, nil
+	}
+
+	_, err := g.SelectGroupParseFunc("keycloak")
+	require.EqualError(t, err, "invalid directoryType keycloak")
+
+	require.NoError(t, g.RegisterGroupParseFunc("Keycloak", keycloak))
+	require.EqualError(t, g.RegisterGroupParseFunc("keycloak", keycloak), "directoryType keycloak is already registered")
+	require.EqualError(t, g.RegisterGroupParseFunc("ad", keycloak), "directoryType ad is built-in and cannot be overridden")
+	require.EqualError(t, g.RegisterGroupParseFunc("azure", nil), "parse func for directoryType azure must not be nil")
+
+	fn, err := g.SelectGroupParseFunc("keycloak")
+	require.NoError(t, err)
+
+	got, err := fn("/tnnt/kaas-all-all-admin")
+	require.NoError(t, err)
+	require.Equal(t, &GroupContext{TenantPrefix: "tnnt", Group: Group{AppPrefix: "kaas", FirstScope: "all", SecondScope: "all", Role: "admin"}}, got)
+
+	isProvider, err := g.IsProviderTenant("tnnt", "keycloak")
+	require.NoError(t, err)
+	require.True(t, isProvider)
+
+	_, err = g.IsProviderTenant("tnnt", "unknown")
+	require.EqualError(t, err, "invalid directoryType unknown")
+
+	_, err = NewGrpr(Config{
+		ProviderTenant:  "tnnt",
+		GroupParseFuncs: map[string]GroupContextParseFunc{"ldap": keycloak},
+	})
+	require.EqualError(t, err, "directoryType ldap is built-in and cannot be overridden")
+}
@@ -15,25 +15,33 @@ type GroupExpression struct {
 	SecondScope string
 	// role in the given context
 	Role string
+	// Normalizations are applied to the fields of the expression and the group before they are compared,
+	// see Grpr.NewGroupExpression
+	Normalizations Normalizations
 }
 
 //Matches returns if the given groupExpression matches this Group
 func (g *GroupExpression) Matches(group Group) bool {
+	return g.MismatchedField(group) == ""
+}
 
-	ok := matchField(group.AppPrefix, g.AppPrefix, false)
-	if !ok {
-		return false
-	}
-	ok = matchField(group.FirstScope, g.FirstScope, true)
-	if !ok {
-		return false
-	}
-	ok = matchField(group.SecondScope, g.SecondScope, true)
-	if !ok {
-		return false
+// MismatchedField returns the name of the first field of the given Group that does not match this groupExpression,
+// or an empty string if the group matches.
+func (g *GroupExpression) MismatchedField(group Group) string {
+	n := g.Normalizations
+
+	switch {
+	case !matchField(n.AppPrefix.Apply(group.AppPrefix), n.AppPrefix.Apply(g.AppPrefix), false):
+		return "AppPrefix"
+	case !matchField(n.Scopes.Apply(group.FirstScope), n.Scopes.Apply(g.FirstScope), true):
+		return "FirstScope"
+	case !matchField(n.Scopes.Apply(group.SecondScope), n.Scopes.Apply(g.SecondScope), true):
+		return "SecondScope"
+	case !matchField(n.Role.Apply(group.Role), n.Role.Apply(g.Role), false):
+		return "Role"
+	default:
+		return ""
 	}
-	ok = matchField(group.Role, g.Role, false)
-	return ok
 }
 
 // matchFiled does a simple equal-fold-match of the given value with the given expression.
@@ -318,6 +318,9 @@ func TestGroupExpression_Matches(t *testing.T) {
 			if got := g.Matches(tt.args.group); got != tt.want {
 				t.Errorf("Matches() = %v, want %v", got, tt.want)
 			}
+			if got := g.MismatchedField(tt.args.group); (got == "") != tt.want {
+				t.Errorf("MismatchedField() = %q, want match %v", got, tt.want)
+			}
 		})
 	}
 }
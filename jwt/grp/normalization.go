@@ -0,0 +1,79 @@
+package grp
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization describes how a component of a group name is normalized, such that differently written names of
+// directories are treated as equal.
+type Normalization struct {
+	// CaseFold folds the component with full unicode case folding, e.g. "STRASSE" and "straße" are equal.
+	CaseFold bool
+	// NFC normalizes the component to the unicode normalization form C, e.g. a precomposed "é" and an "e" followed
+	// by a combining acute accent are equal.
+	NFC bool
+}
+
+// Normalizations configures the normalization per component of a group name.
+type Normalizations struct {
+	AppPrefix      Normalization
+	OnBehalfTenant Normalization
+	// Scopes applies to the first, second and additional scopes
+	Scopes Normalization
+	Role   Normalization
+}
+
+// NormalizeAll returns normalizations, which apply case folding and NFC normalization to all components.
+func NormalizeAll() Normalizations {
+	all := Normalization{CaseFold: true, NFC: true}
+	return Normalizations{
+		AppPrefix:      all,
+		OnBehalfTenant: all,
+		Scopes:         all,
+		Role:           all,
+	}
+}
+
+// Apply returns the normalized value. NFC normalization is applied before case folding, as case folding
+// can produce decomposed characters.
+func (n Normalization) Apply(value string) string {
+	if n.NFC {
+		value = norm.NFC.String(value)
+	}
+	if n.CaseFold {
+		value = norm.NFC.String(cases.Fold().String(value))
+	}
+	return value
+}
+
+// Apply returns a copy of the given group with all components normalized.
+func (n Normalizations) Apply(group Group) Group {
+	group.AppPrefix = n.AppPrefix.Apply(group.AppPrefix)
+	group.OnBehalfTenant = n.OnBehalfTenant.Apply(group.OnBehalfTenant)
+	group.FirstScope = n.Scopes.Apply(group.FirstScope)
+	group.SecondScope = n.Scopes.Apply(group.SecondScope)
+
+	if group.AdditionalScopes != nil {
+		scopes := make([]string, 0, len(group.AdditionalScopes))
+		for _, s := range group.AdditionalScopes {
+			scopes = append(scopes, n.Scopes.Apply(s))
+		}
+		group.AdditionalScopes = scopes
+	}
+
+	group.Role = n.Role.Apply(group.Role)
+
+	return group
+}
+
+// NewGroupExpression returns a group expression, which matches groups with the normalizations of the Grpr.
+func (g *Grpr) NewGroupExpression(appPrefix, firstScope, secondScope, role string) *GroupExpression {
+	return &GroupExpression{
+		AppPrefix:      appPrefix,
+		FirstScope:     firstScope,
+		SecondScope:    secondScope,
+		Role:           role,
+		Normalizations: g.config.Normalizations,
+	}
+}
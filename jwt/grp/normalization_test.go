@@ -0,0 +1,107 @@
+package grp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalization_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     Normalization
+		value string
+		want  string
+	}{
+		{
+			name:  "no normalization",
+			value: "Cafe\u0301",
+			want:  "Cafe\u0301",
+		},
+		{
+			name:  "nfc composes combining characters",
+			n:     Normalization{NFC: true},
+			value: "Cafe\u0301",
+			want:  "Caf\u00e9",
+		},
+		{
+			name:  "case folding",
+			n:     Normalization{CaseFold: true},
+			value: "KaaS",
+			want:  "kaas",
+		},
+		{
+			name:  "full case folding of sharp s",
+			n:     Normalization{CaseFold: true},
+			value: "STRAßE",
+			want:  "strasse",
+		},
+		{
+			name:  "case folding of decomposed characters is composed",
+			n:     Normalization{CaseFold: true, NFC: true},
+			value: "CAFE\u0301",
+			want:  "caf\u00e9",
+		},
+		{
+			name:  "greek final sigma",
+			n:     Normalization{CaseFold: true},
+			value: "ΟΔΟΣ",
+			want:  "οδοσ",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.n.Apply(tt.value))
+		})
+	}
+}
+
+func TestNormalizedParsing(t *testing.T) {
+	g := MustNewGrpr(Config{
+		ProviderTenant: "tnnt",
+		Normalizations: Normalizations{
+			Scopes: Normalization{CaseFold: true, NFC: true},
+		},
+	})
+
+	got, err := g.ParseGroupName("Kaas-Cafe\u0301-STRAßE-Admin")
+	require.NoError(t, err)
+
+	want := &Group{
+		AppPrefix:   "Kaas",
+		FirstScope:  "caf\u00e9",
+		SecondScope: "strasse",
+		Role:        "Admin",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestNormalizedGroupExpression(t *testing.T) {
+	group := Group{
+		AppPrefix:   "kaas",
+		FirstScope:  "Caf\u00e9",
+		SecondScope: "straße",
+		Role:        "admin",
+	}
+
+	plain := &GroupExpression{AppPrefix: "kaas", FirstScope: "CAFE\u0301", SecondScope: "STRASSE", Role: "admin"}
+	require.False(t, plain.Matches(group), "without normalization only simple case folding applies")
+	require.Equal(t, "FirstScope", plain.MismatchedField(group))
+
+	g := MustNewGrpr(Config{ProviderTenant: "tnnt", Normalizations: NormalizeAll()})
+
+	expr := g.NewGroupExpression("kaas", "CAFE\u0301", "STRASSE", "admin")
+	require.True(t, expr.Matches(group))
+
+	expr = g.NewGroupExpression("kaas", "*", "*", "ADMIN")
+	require.True(t, expr.Matches(group))
+
+	require.True(t, expr.Matches(Group{AppPrefix: "kaas", FirstScope: "all", SecondScope: "ALL", Role: "admin"}), "all matches every scope")
+
+	expr = g.NewGroupExpression("kaas", "cafe", "*", "admin")
+	require.False(t, expr.Matches(group), "accents are not removed")
+}
@@ -24,6 +24,8 @@ type Group struct {
 	FirstScope string
 	// SecondScope e.g. for app kaas name of the cluster, for app k8s namespace in the cluster (example: 'all' for group 'app-ddd#dev-all-admin')
 	SecondScope string
+	// AdditionalScopes are the scopes after the second scope for grammars with more than two scopes
+	AdditionalScopes []string
 	// Role is the in the given context (example: 'admin' for group 'app-ddd#dev-all-admin')
 	Role string
 }
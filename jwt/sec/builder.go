@@ -0,0 +1,189 @@
+package sec
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/metal-stack/metal-lib/auth"
+)
+
+// DefaultTokenLifetime is the lifetime of tokens built by the TokenBuilder if no expiry is given.
+const DefaultTokenLifetime = time.Hour
+
+// TokenBuilder mints signed jwt tokens with the standard claims and the metal-stack specific claims like groups, roles
+// and federated claims, such that services and integration tests can issue tokens without running an identity provider.
+type TokenBuilder struct {
+	signer    jose.Signer
+	alg       jose.SignatureAlgorithm
+	key       any
+	kid       string
+	issuedAt  time.Time
+	expiresAt time.Time
+	audience  []string
+	claims    auth.Claims
+	extra     map[string]any
+}
+
+// NewTokenBuilder returns a token builder, a signer or signing key is required before building a token.
+func NewTokenBuilder() *TokenBuilder {
+	return &TokenBuilder{
+		extra: map[string]any{},
+	}
+}
+
+// WithSigner sets the signer of the tokens, which takes precedence over the signing key.
+func (b *TokenBuilder) WithSigner(signer jose.Signer) *TokenBuilder {
+	b.signer = signer
+	return b
+}
+
+// WithSigningKey sets the algorithm and private key the tokens are signed with, e.g. a key created by security.CreateWebkeyPair.
+func (b *TokenBuilder) WithSigningKey(alg jose.SignatureAlgorithm, key any) *TokenBuilder {
+	b.alg = alg
+	b.key = key
+	return b
+}
+
+// WithKeyID sets the kid header of the tokens, which allows verifiers to select the key from a key set.
+func (b *TokenBuilder) WithKeyID(kid string) *TokenBuilder {
+	b.kid = kid
+	return b
+}
+
+func (b *TokenBuilder) WithID(id string) *TokenBuilder {
+	b.claims.Id = id
+	return b
+}
+
+func (b *TokenBuilder) WithIssuer(issuer string) *TokenBuilder {
+	b.claims.Issuer = issuer
+	return b
+}
+
+func (b *TokenBuilder) WithSubject(subject string) *TokenBuilder {
+	b.claims.Subject = subject
+	return b
+}
+
+func (b *TokenBuilder) WithAudience(audience ...string) *TokenBuilder {
+	b.audience = audience
+	return b
+}
+
+// WithIssuedAt sets the time the token was issued at, defaults to the time of building the token.
+func (b *TokenBuilder) WithIssuedAt(issuedAt time.Time) *TokenBuilder {
+	b.issuedAt = issuedAt
+	return b
+}
+
+// WithExpiresAt sets the expiry of the token, defaults to DefaultTokenLifetime after the token was issued.
+func (b *TokenBuilder) WithExpiresAt(expiresAt time.Time) *TokenBuilder {
+	b.expiresAt = expiresAt
+	return b
+}
+
+func (b *TokenBuilder) WithName(name string) *TokenBuilder {
+	b.claims.Name = name
+	return b
+}
+
+func (b *TokenBuilder) WithPreferredUsername(username string) *TokenBuilder {
+	b.claims.PreferredUsername = username
+	return b
+}
+
+func (b *TokenBuilder) WithEMail(email string) *TokenBuilder {
+	b.claims.EMail = email
+	return b
+}
+
+// WithGroups sets the groups claim of "old" style tokens, which are only evaluated together with federated claims.
+func (b *TokenBuilder) WithGroups(groups ...string) *TokenBuilder {
+	b.claims.Groups = groups
+	return b
+}
+
+// WithRoles sets the roles claim of "new" style tokens.
+func (b *TokenBuilder) WithRoles(roles ...string) *TokenBuilder {
+	b.claims.Roles = roles
+	return b
+}
+
+// WithFederatedClaims sets the federated claims, the tenant of the user is derived from the connector_id.
+func (b *TokenBuilder) WithFederatedClaims(claims map[string]string) *TokenBuilder {
+	b.claims.FederatedClaims = claims
+	return b
+}
+
+// WithClaim adds a custom claim to the token, standard and metal-stack specific claims cannot be overridden.
+func (b *TokenBuilder) WithClaim(key string, value any) *TokenBuilder {
+	b.extra[key] = value
+	return b
+}
+
+// Build signs and serializes a token with the configured claims.
+func (b *TokenBuilder) Build() (string, error) {
+	signer, err := b.newSigner()
+	if err != nil {
+		return "", err
+	}
+
+	claims := b.claims
+
+	issuedAt := b.issuedAt
+	if issuedAt.IsZero() {
+		issuedAt = time.Now()
+	}
+	expiresAt := b.expiresAt
+	if expiresAt.IsZero() {
+		expiresAt = issuedAt.Add(DefaultTokenLifetime)
+	}
+
+	claims.IssuedAt = issuedAt.Unix()
+	claims.NotBefore = issuedAt.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	switch len(b.audience) {
+	case 0:
+	case 1:
+		claims.Audience = b.audience[0]
+	default:
+		claims.Audience = b.audience
+	}
+
+	builder := jwt.Signed(signer)
+	if len(b.extra) > 0 {
+		builder = builder.Claims(maps.Clone(b.extra))
+	}
+
+	token, err := builder.Claims(claims).Serialize()
+	if err != nil {
+		return "", fmt.Errorf("unable to sign token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (b *TokenBuilder) newSigner() (jose.Signer, error) {
+	if b.signer != nil {
+		return b.signer, nil
+	}
+	if b.key == nil {
+		return nil, fmt.Errorf("either a signer or a signing key must be configured")
+	}
+
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if b.kid != "" {
+		opts = opts.WithHeader(jose.HeaderKey("kid"), b.kid)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: b.alg, Key: b.key}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer: %w", err)
+	}
+
+	return signer, nil
+}
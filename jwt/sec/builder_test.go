@@ -0,0 +1,68 @@
+package sec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBuilder(t *testing.T) {
+	publicKey, privateKey, err := security.CreateWebkeyPair(jose.RS256, "sig", 0)
+	require.NoError(t, err)
+
+	issuedAt := time.Unix(1557381999, 0)
+
+	token, err := NewTokenBuilder().
+		WithSigningKey(jose.RS256, privateKey.Key).
+		WithKeyID("test-key").
+		WithIssuer("https://dex.test.metal-stack.io/dex").
+		WithSubject("achim").
+		WithAudience("metal-api").
+		WithIssuedAt(issuedAt).
+		WithName("achim").
+		WithEMail("achim.admin@tenant.de").
+		WithGroups("tnnt_kaas-all-all-admin").
+		WithFederatedClaims(map[string]string{"connector_id": "tnnt_ldap_openldap"}).
+		WithClaim("custom", "value").
+		Build()
+	require.NoError(t, err)
+
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	require.NoError(t, err)
+	require.Len(t, parsed.Headers, 1)
+	require.Equal(t, "test-key", parsed.Headers[0].KeyID)
+
+	var custom map[string]any
+	err = parsed.Claims(publicKey.Key, &custom)
+	require.NoError(t, err, "signature must be verifiable with the public key")
+	require.Equal(t, "value", custom["custom"])
+
+	user, claims, err := ParseTokenUnvalidatedUnfiltered(token)
+	require.NoError(t, err)
+
+	wantUser := &security.User{
+		Issuer:  "https://dex.test.metal-stack.io/dex",
+		Subject: "achim",
+		Name:    "achim",
+		EMail:   "achim.admin@tenant.de",
+		Groups:  ToResourceAccess("tnnt_kaas-all-all-admin"),
+		Tenant:  "tnnt",
+	}
+	if diff := cmp.Diff(wantUser, user); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	require.Equal(t, "metal-api", claims.Audience)
+	require.Equal(t, issuedAt.Unix(), claims.IssuedAt)
+	require.Equal(t, issuedAt.Add(DefaultTokenLifetime).Unix(), claims.ExpiresAt)
+}
+
+func TestTokenBuilderWithoutSigner(t *testing.T) {
+	_, err := NewTokenBuilder().WithSubject("achim").Build()
+	require.EqualError(t, err, "either a signer or a signing key must be configured")
+}
@@ -0,0 +1,129 @@
+package sec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/jwt/grp"
+	"github.com/metal-stack/security"
+)
+
+// GroupExplanation explains the evaluation of a group expression for the groups of a user, see ExplainGroupExpression.
+type GroupExplanation struct {
+	Matched bool
+	// Group is the first group of the user that fulfils the expression, empty if none does.
+	Group security.ResourceAccess
+	// Reason summarizes why the expression is fulfilled or not.
+	Reason string
+	// Groups contains the evaluation of every group of the user in order.
+	Groups []GroupEvaluation
+}
+
+// GroupEvaluation is the evaluation of a single group of the user.
+type GroupEvaluation struct {
+	Group   security.ResourceAccess
+	Matched bool
+	Reason  string
+}
+
+func (e GroupExplanation) String() string {
+	var b strings.Builder
+
+	b.WriteString(e.Reason)
+
+	for _, g := range e.Groups {
+		fmt.Fprintf(&b, "\n  group %q: %s", g.Group, g.Reason)
+	}
+
+	return b.String()
+}
+
+// ExplainGroupExpression evaluates the group expression like HasGroupExpression, but additionally returns which
+// group of the user fulfils the expression or why none of the groups does. This is intended for denial messages
+// and audit entries and slower than HasGroupExpression, so it should not be used for the authorization itself.
+func (p *Plugin) ExplainGroupExpression(user *security.User, resourceTenant string, groupExpression grp.GroupExpression) GroupExplanation {
+	var e GroupExplanation
+
+	switch {
+	case user == nil:
+		e.Reason = "no user given"
+		return e
+	case resourceTenant == "":
+		e.Reason = "no resource tenant given"
+		return e
+	case len(user.Groups) == 0:
+		e.Reason = fmt.Sprintf("user %q has no groups", user.Name)
+		return e
+	}
+
+	for _, group := range user.Groups {
+		eval := GroupEvaluation{Group: group}
+
+		grpCtx, err := p.grpr.ParseGroupName(string(group))
+		if err != nil {
+			eval.Reason = fmt.Sprintf("unable to parse group: %s", err)
+			e.Groups = append(e.Groups, eval)
+			continue
+		}
+
+		field := groupExpression.MismatchedField(*grpCtx)
+
+		switch {
+		case !groupAppliesToTenant(user, resourceTenant, grpCtx):
+			eval.Reason = tenantMismatchReason(user, resourceTenant, grpCtx)
+		case field != "":
+			eval.Reason = fmt.Sprintf("%s %q does not match %q", field, groupField(grpCtx, field), expressionField(groupExpression, field))
+		default:
+			eval.Matched = true
+			eval.Reason = "group matches the expression"
+
+			if !e.Matched {
+				e.Matched = true
+				e.Group = group
+			}
+		}
+
+		e.Groups = append(e.Groups, eval)
+	}
+
+	if e.Matched {
+		e.Reason = fmt.Sprintf("group %q of user %q matches the expression for tenant %q", e.Group, user.Name, resourceTenant)
+	} else {
+		e.Reason = fmt.Sprintf("no group of user %q matches the expression for tenant %q", user.Name, resourceTenant)
+	}
+
+	return e
+}
+
+func tenantMismatchReason(user *security.User, resourceTenant string, grpCtx *grp.Group) string {
+	if grpCtx.OnBehalfTenant == "" {
+		return fmt.Sprintf("group applies to the tenant %q of the user only, not to %q", user.Tenant, resourceTenant)
+	}
+	return fmt.Sprintf("group applies on behalf of tenant %q only, not to %q", grpCtx.OnBehalfTenant, resourceTenant)
+}
+
+func groupField(g *grp.Group, field string) string {
+	switch field {
+	case "AppPrefix":
+		return g.AppPrefix
+	case "FirstScope":
+		return g.FirstScope
+	case "SecondScope":
+		return g.SecondScope
+	default:
+		return g.Role
+	}
+}
+
+func expressionField(expr grp.GroupExpression, field string) string {
+	switch field {
+	case "AppPrefix":
+		return expr.AppPrefix
+	case "FirstScope":
+		return expr.FirstScope
+	case "SecondScope":
+		return expr.SecondScope
+	default:
+		return expr.Role
+	}
+}
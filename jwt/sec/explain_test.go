@@ -0,0 +1,114 @@
+package sec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/jwt/grp"
+	"github.com/metal-stack/security"
+)
+
+func TestExplainGroupExpression(t *testing.T) {
+	_, parseErr := grpr.ParseGroupName("invalid-grp")
+
+	user := &security.User{
+		Name:   "achim",
+		Tenant: "tnnt",
+		Groups: ToResourceAccess("invalid-grp", "kaas-all-all-view", "kaas-ddd#all-all-admin", "kaas-all-all-admin"),
+	}
+
+	admin := grp.GroupExpression{AppPrefix: "kaas", FirstScope: "*", SecondScope: "*", Role: "admin"}
+
+	tests := []struct {
+		name           string
+		user           *security.User
+		resourceTenant string
+		expr           grp.GroupExpression
+		want           GroupExplanation
+	}{
+		{
+			name:           "no user",
+			resourceTenant: "tnnt",
+			expr:           admin,
+			want:           GroupExplanation{Reason: "no user given"},
+		},
+		{
+			name: "no resource tenant",
+			user: user,
+			expr: admin,
+			want: GroupExplanation{Reason: "no resource tenant given"},
+		},
+		{
+			name:           "no groups",
+			user:           &security.User{Name: "achim", Tenant: "tnnt"},
+			resourceTenant: "tnnt",
+			expr:           admin,
+			want:           GroupExplanation{Reason: `user "achim" has no groups`},
+		},
+		{
+			name:           "own tenant",
+			user:           user,
+			resourceTenant: "tnnt",
+			expr:           admin,
+			want: GroupExplanation{
+				Matched: true,
+				Group:   "kaas-all-all-admin",
+				Reason:  `group "kaas-all-all-admin" of user "achim" matches the expression for tenant "tnnt"`,
+				Groups: []GroupEvaluation{
+					{Group: "invalid-grp", Reason: fmt.Sprintf("unable to parse group: %s", parseErr)},
+					{Group: "kaas-all-all-view", Reason: `Role "view" does not match "admin"`},
+					{Group: "kaas-ddd#all-all-admin", Reason: `group applies on behalf of tenant "ddd" only, not to "tnnt"`},
+					{Group: "kaas-all-all-admin", Matched: true, Reason: "group matches the expression"},
+				},
+			},
+		},
+		{
+			name:           "on behalf",
+			user:           user,
+			resourceTenant: "ddd",
+			expr:           admin,
+			want: GroupExplanation{
+				Matched: true,
+				Group:   "kaas-ddd#all-all-admin",
+				Reason:  `group "kaas-ddd#all-all-admin" of user "achim" matches the expression for tenant "ddd"`,
+				Groups: []GroupEvaluation{
+					{Group: "invalid-grp", Reason: fmt.Sprintf("unable to parse group: %s", parseErr)},
+					{Group: "kaas-all-all-view", Reason: `group applies to the tenant "tnnt" of the user only, not to "ddd"`},
+					{Group: "kaas-ddd#all-all-admin", Matched: true, Reason: "group matches the expression"},
+					{Group: "kaas-all-all-admin", Reason: `group applies to the tenant "tnnt" of the user only, not to "ddd"`},
+				},
+			},
+		},
+		{
+			name:           "no match",
+			user:           user,
+			resourceTenant: "tnnt",
+			expr:           grp.GroupExpression{AppPrefix: "k8s", FirstScope: "*", SecondScope: "*", Role: "admin"},
+			want: GroupExplanation{
+				Reason: `no group of user "achim" matches the expression for tenant "tnnt"`,
+				Groups: []GroupEvaluation{
+					{Group: "invalid-grp", Reason: fmt.Sprintf("unable to parse group: %s", parseErr)},
+					{Group: "kaas-all-all-view", Reason: `AppPrefix "kaas" does not match "k8s"`},
+					{Group: "kaas-ddd#all-all-admin", Reason: `group applies on behalf of tenant "ddd" only, not to "tnnt"`},
+					{Group: "kaas-all-all-admin", Reason: `AppPrefix "kaas" does not match "k8s"`},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := plugin.ExplainGroupExpression(tt.user, tt.resourceTenant, tt.expr)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if tt.user != nil {
+				if has := plugin.HasGroupExpression(tt.user, tt.resourceTenant, tt.expr); has != got.Matched {
+					t.Errorf("explanation differs from HasGroupExpression, got %v, want %v", got.Matched, has)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package sec
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/metal-stack/security"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultGroupCacheSize = 1000
+	defaultGroupCacheTTL  = 5 * time.Minute
+)
+
+type GroupCacheConfig struct {
+	// Size is the maximum amount of cached group sets, the least recently used group sets are evicted first, defaults to 1000.
+	Size int
+	// TTL is the maximum duration a group set is cached, which is further bound by the expiry of the token, defaults to 5 minutes.
+	TTL time.Duration
+	// MetricsRegisterer registers the hits, misses, evictions and size of the cache if set. Like prometheus.MustRegister,
+	// registering the metrics a second time panics.
+	MetricsRegisterer prometheus.Registerer
+}
+
+// GroupCacheStats are the counters of the group cache.
+type GroupCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// HitRate returns the ratio of hits to lookups.
+func (s GroupCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// groupCache is a lru cache of the processed groups of tokens.
+type groupCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   GroupCacheStats
+}
+
+type groupCacheEntry struct {
+	key     string
+	groups  []security.ResourceAccess
+	expires time.Time
+}
+
+// WithGroupCache caches the processed groups of tokens, such that the group strings are not parsed again on every request
+// with the same token. As the processed groups only depend on the tenant, the directory and the groups of a token, the cache
+// is keyed by a hash of these, which also allows sharing entries between tokens of the same user.
+func (p *Plugin) WithGroupCache(c GroupCacheConfig) *Plugin {
+	if c.Size <= 0 {
+		c.Size = defaultGroupCacheSize
+	}
+	if c.TTL <= 0 {
+		c.TTL = defaultGroupCacheTTL
+	}
+
+	p.cache = &groupCache{
+		size:    c.Size,
+		ttl:     c.TTL,
+		now:     time.Now,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+
+	if c.MetricsRegisterer != nil {
+		c.MetricsRegisterer.MustRegister(groupCacheCollector{p: p})
+	}
+
+	return p
+}
+
+// GroupCacheStats returns the counters of the group cache, which are zero if no cache is configured.
+func (p *Plugin) GroupCacheStats() GroupCacheStats {
+	if p.cache == nil {
+		return GroupCacheStats{}
+	}
+
+	p.cache.lock.Lock()
+	defer p.cache.lock.Unlock()
+
+	stats := p.cache.stats
+	stats.Size = p.cache.lru.Len()
+
+	return stats
+}
+
+var (
+	groupCacheHitsDesc      = prometheus.NewDesc("jwt_group_cache_hits_total", "number of tokens whose groups were taken from the cache.", nil, nil)
+	groupCacheMissesDesc    = prometheus.NewDesc("jwt_group_cache_misses_total", "number of tokens whose groups had to be parsed.", nil, nil)
+	groupCacheEvictionsDesc = prometheus.NewDesc("jwt_group_cache_evictions_total", "number of group sets evicted from the cache due to its size.", nil, nil)
+	groupCacheSizeDesc      = prometheus.NewDesc("jwt_group_cache_size", "number of cached group sets.", nil, nil)
+)
+
+// groupCacheCollector reports the counters of the group cache of a plugin to prometheus.
+type groupCacheCollector struct {
+	p *Plugin
+}
+
+func (c groupCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- groupCacheHitsDesc
+	ch <- groupCacheMissesDesc
+	ch <- groupCacheEvictionsDesc
+	ch <- groupCacheSizeDesc
+}
+
+func (c groupCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.p.GroupCacheStats()
+
+	ch <- prometheus.MustNewConstMetric(groupCacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(groupCacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(groupCacheEvictionsDesc, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(groupCacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+}
+
+// cached returns an extractGroupsFn which takes the groups from the cache or stores the result of the given fn.
+// Entries expire after the ttl of the cache or at the given expiry of the token, whatever comes first.
+func (c *groupCache) cached(fn extractGroupsFn, expiry time.Time) extractGroupsFn {
+	return func(tenant string, directory string, groups []string) ([]security.ResourceAccess, error) {
+		key := groupCacheKey(tenant, directory, groups)
+
+		if cached, ok := c.get(key); ok {
+			return cached, nil
+		}
+
+		result, err := fn(tenant, directory, groups)
+		if err != nil {
+			return nil, err
+		}
+
+		expires := c.now().Add(c.ttl)
+		if !expiry.IsZero() && expiry.Before(expires) {
+			expires = expiry
+		}
+
+		c.add(key, result, expires)
+
+		return result, nil
+	}
+}
+
+func (c *groupCache) get(key string) ([]security.ResourceAccess, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*groupCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+
+	// the caller may modify the returned groups
+	return slices.Clone(entry.groups), true
+}
+
+func (c *groupCache) add(key string, groups []security.ResourceAccess, expires time.Time) {
+	if !c.now().Before(expires) {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry := &groupCacheEntry{key: key, groups: slices.Clone(groups), expires: expires}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*groupCacheEntry).key)
+		c.stats.Evictions++
+	}
+}
+
+func groupCacheKey(tenant, directory string, groups []string) string {
+	h := sha256.New()
+	for _, part := range append([]string{tenant, directory}, groups...) {
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,79 @@
+package sec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/metal-stack/security"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupCache(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	p := NewPlugin(grpr).WithGroupCache(GroupCacheConfig{Size: 2, TTL: time.Minute, MetricsRegisterer: reg})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p.cache.now = func() time.Time { return now }
+
+	claims := func(expiresAt time.Time, groups ...string) *security.Claims {
+		return &security.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+			Name:             "achim",
+			Groups:           groups,
+			FederatedClaims:  map[string]string{"connector_id": "tnnt_ldap_openldap"},
+		}
+	}
+
+	extract := func(c *security.Claims) []security.ResourceAccess {
+		user, err := p.ExtractUserProcessGroups(c)
+		require.NoError(t, err)
+		return user.Groups
+	}
+
+	admin := claims(now.Add(time.Hour), "tnnt_kaas-all-all-admin")
+
+	require.Equal(t, ToResourceAccess("kaas-all-all-admin"), extract(admin))
+	require.Equal(t, ToResourceAccess("kaas-all-all-admin"), extract(admin))
+	require.Equal(t, GroupCacheStats{Hits: 1, Misses: 1, Size: 1}, p.GroupCacheStats())
+
+	// entries expire with the ttl of the cache
+	now = now.Add(time.Minute)
+	extract(admin)
+	require.Equal(t, GroupCacheStats{Hits: 1, Misses: 2, Size: 1}, p.GroupCacheStats())
+
+	// entries expire with the token
+	viewer := claims(now.Add(10*time.Second), "tnnt_kaas-all-all-view")
+	require.Equal(t, ToResourceAccess("kaas-all-all-view"), extract(viewer))
+	now = now.Add(10 * time.Second)
+	extract(viewer)
+	require.Equal(t, GroupCacheStats{Hits: 1, Misses: 4, Size: 1}, p.GroupCacheStats())
+
+	// the least recently used entry is evicted
+	extract(admin)
+	extract(claims(now.Add(time.Hour), "tnnt_kaas-all-all-edit"))
+	extract(claims(now.Add(time.Hour), "tnnt_kaas-all-all-owner"))
+	require.Equal(t, GroupCacheStats{Hits: 2, Misses: 6, Evictions: 1, Size: 2}, p.GroupCacheStats())
+	require.InDelta(t, 0.25, p.GroupCacheStats().HitRate(), 0.0001)
+
+	extract(admin)
+	require.Equal(t, GroupCacheStats{Hits: 2, Misses: 7, Evictions: 2, Size: 2}, p.GroupCacheStats())
+
+	want := `# HELP jwt_group_cache_evictions_total number of group sets evicted from the cache due to its size.
+# TYPE jwt_group_cache_evictions_total counter
+jwt_group_cache_evictions_total 2
+# HELP jwt_group_cache_hits_total number of tokens whose groups were taken from the cache.
+# TYPE jwt_group_cache_hits_total counter
+jwt_group_cache_hits_total 2
+# HELP jwt_group_cache_misses_total number of tokens whose groups had to be parsed.
+# TYPE jwt_group_cache_misses_total counter
+jwt_group_cache_misses_total 7
+# HELP jwt_group_cache_size number of cached group sets.
+# TYPE jwt_group_cache_size gauge
+jwt_group_cache_size 2
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(want)))
+}
@@ -3,6 +3,7 @@ package sec
 import (
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/metal-stack/metal-lib/jwt/grp"
 	"github.com/metal-stack/security"
@@ -11,7 +12,9 @@ import (
 const OidcDirectory = "oidc.metal-stack.io/directory"
 
 type Plugin struct {
-	grpr *grp.Grpr
+	grpr           *grp.Grpr
+	cache          *groupCache
+	projectMapping *ProjectMapping
 }
 
 func NewPlugin(grpr *grp.Grpr) *Plugin {
@@ -27,7 +30,7 @@ func (p *Plugin) GenericOIDCExtractUserProcessGroups(ic *security.IssuerConfig,
 	if ic == nil {
 		return nil, errors.New("issuerConfig must not be nil")
 	}
-	return genericOidcExtractUser(ic, claims, p.extractAndProcessGroups)
+	return genericOidcExtractUser(ic, claims, p.extractGroupsFn(time.Time{}))
 }
 
 // extractUser returns the User, groups are extracted with the given fn.
@@ -55,7 +58,20 @@ func genericOidcExtractUser(ic *security.IssuerConfig, claims *security.GenericO
 // Groups will reformatted [app]-[]-[]-[role], e.g. "maas-all-all-admin", "kaas-all-all-kaasadmin", "k8s-all-all-admin".
 // All groups without or with another the tenant-prefix are filtered.
 func (p *Plugin) ExtractUserProcessGroups(claims *security.Claims) (user *security.User, err error) {
-	return extractUser(claims, p.extractAndProcessGroups)
+	var expiry time.Time
+	if claims != nil && claims.ExpiresAt != nil {
+		expiry = claims.ExpiresAt.Time
+	}
+
+	return extractUser(claims, p.extractGroupsFn(expiry))
+}
+
+// extractGroupsFn returns the extractAndProcessGroups, which is cached until the given expiry of the token if a group cache is configured.
+func (p *Plugin) extractGroupsFn(expiry time.Time) extractGroupsFn {
+	if p.cache == nil {
+		return p.extractAndProcessGroups
+	}
+	return p.cache.cached(p.extractAndProcessGroups, expiry)
 }
 
 // extractUser returns the User, groups are extracted with the given fn.
@@ -161,31 +177,65 @@ func (p *Plugin) HasGroupExpression(user *security.User, resourceTenant string,
 			continue
 		}
 
-		// check if group matches for any of the tenants
-		if resourceTenant == grp.Any {
-			if groupExpression.Matches(*grpCtx) {
-				return true
-			}
-			continue
+		if groupAppliesToTenant(user, resourceTenant, grpCtx) && groupExpression.Matches(*grpCtx) {
+			return true
 		}
-		// resource belongs to own tenant
-		if strings.EqualFold(user.Tenant, resourceTenant) && grpCtx.OnBehalfTenant == "" {
-			if groupExpression.Matches(*grpCtx) {
-				return true
-			}
+	}
+
+	return false
+
+}
+
+// EvaluateAll checks for all given group-expressions if the user has group permissions that fulfil them, see HasGroupExpression.
+// The groups of the user are only parsed once, which makes this more efficient than calling HasGroupExpression for every expression.
+// The returned map contains the result for every index of the given expressions.
+func (p *Plugin) EvaluateAll(user *security.User, resourceTenant string, exprs []grp.GroupExpression) map[int]bool {
+	result := make(map[int]bool, len(exprs))
+	for i := range exprs {
+		result[i] = false
+	}
+
+	// no resource tenant is not ok, there can be no default on this layer
+	if resourceTenant == "" || user == nil {
+		return result
+	}
+
+	var groups []grp.Group
+	for i := range user.Groups {
+		grpCtx, err := p.grpr.ParseGroupName(string(user.Groups[i]))
+		if err != nil {
 			continue
 		}
-		// resource belongs to other tenant, access "on behalf": if group is for resource-tenant or for "all" then check
-		if strings.EqualFold(grpCtx.OnBehalfTenant, resourceTenant) || grpCtx.OnBehalfTenant == grp.All {
-			if groupExpression.Matches(*grpCtx) {
-				return true
+
+		if groupAppliesToTenant(user, resourceTenant, grpCtx) {
+			groups = append(groups, *grpCtx)
+		}
+	}
+
+	for i := range exprs {
+		for _, g := range groups {
+			if exprs[i].Matches(g) {
+				result[i] = true
+				break
 			}
-			continue
 		}
 	}
 
-	return false
+	return result
+}
 
+// groupAppliesToTenant returns if the group of the user can be used for accessing resources of the given tenant.
+func groupAppliesToTenant(user *security.User, resourceTenant string, grpCtx *grp.Group) bool {
+	// check if group matches for any of the tenants
+	if resourceTenant == grp.Any {
+		return true
+	}
+	// resource belongs to own tenant
+	if strings.EqualFold(user.Tenant, resourceTenant) && grpCtx.OnBehalfTenant == "" {
+		return true
+	}
+	// resource belongs to other tenant, access "on behalf": if group is for resource-tenant or for "all" then check
+	return strings.EqualFold(grpCtx.OnBehalfTenant, resourceTenant) || grpCtx.OnBehalfTenant == grp.All
 }
 
 // GroupsOnBehalf returns the list of groups that the user can do an behalf of the other tenant.
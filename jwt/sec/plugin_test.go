@@ -781,6 +781,64 @@ func TestHasGroupExpression(t *testing.T) {
 				}
 			})
 		}
+
+		t.Run(fmt.Sprintf("%s:EvaluateAll", tt.name), func(t *testing.T) {
+			var (
+				exprs []grp.GroupExpression
+				want  = map[int]bool{}
+			)
+			for i, exp := range tt.args.expression {
+				exprs = append(exprs, exp.expr)
+				want[i] = exp.want
+			}
+
+			got := plugin.EvaluateAll(tt.args.user, tt.args.resourceTenant, exprs)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("EvaluateAll() diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func benchmarkUserAndExpressions() (*security.User, []grp.GroupExpression) {
+	user := &security.User{
+		Tenant: "tnnt",
+	}
+	for i := range 20 {
+		user.Groups = append(user.Groups,
+			security.ResourceAccess(fmt.Sprintf("kaas-cluster%d-all-view", i)),
+			security.ResourceAccess(fmt.Sprintf("kaas-xyz#cluster%d-namespace-admin", i)),
+		)
+	}
+
+	var exprs []grp.GroupExpression
+	for i := range 50 {
+		exprs = append(exprs, grp.GroupExpression{
+			AppPrefix:   "kaas",
+			FirstScope:  fmt.Sprintf("cluster%d", i),
+			SecondScope: "namespace",
+			Role:        "admin",
+		})
+	}
+
+	return user, exprs
+}
+
+func BenchmarkEvaluateAll(b *testing.B) {
+	user, exprs := benchmarkUserAndExpressions()
+
+	for range b.N {
+		_ = plugin.EvaluateAll(user, "tnnt", exprs)
+	}
+}
+
+func BenchmarkHasGroupExpression(b *testing.B) {
+	user, exprs := benchmarkUserAndExpressions()
+
+	for range b.N {
+		for _, expr := range exprs {
+			_ = plugin.HasGroupExpression(user, "tnnt", expr)
+		}
 	}
 }
 
@@ -0,0 +1,192 @@
+package sec
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/jwt/grp"
+	"github.com/metal-stack/security"
+	"sigs.k8s.io/yaml"
+)
+
+// Policy grants the actions on a resource to users with a group matching the expression.
+// Resource and actions support "*" as wildcard.
+type Policy struct {
+	Resource   string              `json:"resource"`
+	Actions    []string            `json:"actions"`
+	Expression grp.GroupExpression `json:"expression"`
+}
+
+// Policies is a set of policies, e.g. loaded from a YAML file:
+//
+//	policies:
+//	  - resource: cluster
+//	    actions: [get, list]
+//	    expression:
+//	      appprefix: kaas
+//	      firstscope: "*"
+//	      secondscope: "*"
+//	      role: view
+type Policies struct {
+	Policies []Policy `json:"policies"`
+}
+
+// ParsePolicies parses the given policies in YAML or JSON format.
+func ParsePolicies(raw []byte) (*Policies, error) {
+	var p Policies
+	err := yaml.UnmarshalStrict(raw, &p)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse policies: %w", err)
+	}
+
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// LoadPolicies reads the policies from the given file, see ParsePolicies.
+func LoadPolicies(path string) (*Policies, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePolicies(raw)
+}
+
+// Validate ensures that all policies are complete.
+func (p *Policies) Validate() error {
+	for i, policy := range p.Policies {
+		if policy.Resource == "" {
+			return fmt.Errorf("policy %d: resource must not be empty", i)
+		}
+		if len(policy.Actions) == 0 {
+			return fmt.Errorf("policy %d: at least one action is required", i)
+		}
+		if policy.Expression.AppPrefix == "" || policy.Expression.Role == "" {
+			return fmt.Errorf("policy %d: app prefix and role of the expression must not be empty", i)
+		}
+	}
+
+	return nil
+}
+
+// Authorizer decides on the actions of users on resources according to the policies.
+type Authorizer struct {
+	plugin   *Plugin
+	policies []Policy
+}
+
+// NewAuthorizer returns an authorizer for the given policies, which evaluates the group expressions with this plugin.
+func (p *Plugin) NewAuthorizer(policies *Policies) (*Authorizer, error) {
+	if policies == nil {
+		return nil, fmt.Errorf("policies must not be nil")
+	}
+
+	err := policies.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authorizer{
+		plugin:   p,
+		policies: slices.Clone(policies.Policies),
+	}, nil
+}
+
+// Decision is the result of an authorization.
+type Decision struct {
+	Allowed bool
+	// Policy is the first policy that granted the action, nil if the action was denied.
+	Policy *Policy
+	// Trace contains the evaluation of every policy in order, which explains the decision.
+	Trace []DecisionStep
+}
+
+// DecisionStep is the evaluation of a single policy.
+type DecisionStep struct {
+	Policy  Policy
+	Matched bool
+	Reason  string
+}
+
+func (d Decision) String() string {
+	var b strings.Builder
+
+	if d.Allowed {
+		b.WriteString("allowed")
+	} else {
+		b.WriteString("denied")
+	}
+
+	for i, step := range d.Trace {
+		fmt.Fprintf(&b, "\n  policy %d (%s %s): %s", i, step.Policy.Resource, strings.Join(step.Policy.Actions, ","), step.Reason)
+	}
+
+	return b.String()
+}
+
+// Authorize decides if the user may perform the action on the resource of the given tenant. The action is allowed
+// if any policy for the resource and action has an expression that is fulfilled by the groups of the user,
+// see HasGroupExpression.
+func (a *Authorizer) Authorize(user *security.User, tenant, resource, action string) Decision {
+	var (
+		decision   Decision
+		applicable []int
+		exprs      []grp.GroupExpression
+	)
+
+	for i, policy := range a.policies {
+		step := DecisionStep{Policy: policy}
+
+		switch {
+		case !matchesOrAny(policy.Resource, resource):
+			step.Reason = "resource does not match"
+		case !slices.ContainsFunc(policy.Actions, func(a string) bool { return matchesOrAny(a, action) }):
+			step.Reason = "action does not match"
+		default:
+			applicable = append(applicable, i)
+			exprs = append(exprs, policy.Expression)
+		}
+
+		decision.Trace = append(decision.Trace, step)
+	}
+
+	if user == nil {
+		for _, i := range applicable {
+			decision.Trace[i].Reason = "no user"
+		}
+		return decision
+	}
+
+	results := a.plugin.EvaluateAll(user, tenant, exprs)
+
+	for n, i := range applicable {
+		step := &decision.Trace[i]
+
+		if !results[n] {
+			step.Reason = "no group of the user matches the expression"
+			continue
+		}
+
+		step.Matched = true
+		step.Reason = "group of the user matches the expression"
+
+		if !decision.Allowed {
+			policy := a.policies[i]
+			decision.Allowed = true
+			decision.Policy = &policy
+		}
+	}
+
+	return decision
+}
+
+func matchesOrAny(value, expected string) bool {
+	return value == grp.Any || strings.EqualFold(value, expected)
+}
@@ -0,0 +1,141 @@
+package sec
+
+import (
+	"testing"
+
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = `
+policies:
+  - resource: cluster
+    actions: [get, list]
+    expression:
+      appprefix: kaas
+      firstscope: "*"
+      secondscope: "*"
+      role: view
+  - resource: cluster
+    actions: ["*"]
+    expression:
+      appprefix: kaas
+      firstscope: "*"
+      secondscope: "*"
+      role: admin
+`
+
+func TestParsePolicies(t *testing.T) {
+	_, err := ParsePolicies([]byte(`
+policies:
+  - resource: cluster
+    expression:
+      appprefix: kaas
+      role: view
+`))
+	require.EqualError(t, err, "policy 0: at least one action is required")
+
+	_, err = ParsePolicies([]byte(`
+policies:
+  - resource: cluster
+    actions: [get]
+    unknown: true
+`))
+	require.ErrorContains(t, err, "unable to parse policies")
+}
+
+func TestAuthorize(t *testing.T) {
+	policies, err := ParsePolicies([]byte(testPolicies))
+	require.NoError(t, err)
+
+	a, err := plugin.NewAuthorizer(policies)
+	require.NoError(t, err)
+
+	viewer := &security.User{
+		Tenant: "tnnt",
+		Groups: ToResourceAccess("kaas-all-all-view"),
+	}
+	admin := &security.User{
+		Tenant: "tnnt",
+		Groups: ToResourceAccess("kaas-ddd#all-all-admin"),
+	}
+
+	tests := []struct {
+		name     string
+		user     *security.User
+		tenant   string
+		resource string
+		action   string
+		want     bool
+		reasons  []string
+	}{
+		{
+			name:     "viewer may get",
+			user:     viewer,
+			tenant:   "tnnt",
+			resource: "cluster",
+			action:   "get",
+			want:     true,
+			reasons:  []string{"group of the user matches the expression", "no group of the user matches the expression"},
+		},
+		{
+			name:     "viewer may not delete",
+			user:     viewer,
+			tenant:   "tnnt",
+			resource: "cluster",
+			action:   "delete",
+			want:     false,
+			reasons:  []string{"action does not match", "no group of the user matches the expression"},
+		},
+		{
+			name:     "admin may delete on behalf",
+			user:     admin,
+			tenant:   "ddd",
+			resource: "cluster",
+			action:   "delete",
+			want:     true,
+			reasons:  []string{"action does not match", "group of the user matches the expression"},
+		},
+		{
+			name:     "admin may not delete in other tenant",
+			user:     admin,
+			tenant:   "xyz",
+			resource: "cluster",
+			action:   "delete",
+			want:     false,
+			reasons:  []string{"action does not match", "no group of the user matches the expression"},
+		},
+		{
+			name:     "unknown resource",
+			user:     admin,
+			tenant:   "ddd",
+			resource: "machine",
+			action:   "get",
+			want:     false,
+			reasons:  []string{"resource does not match", "resource does not match"},
+		},
+		{
+			name:     "no user",
+			tenant:   "tnnt",
+			resource: "cluster",
+			action:   "get",
+			want:     false,
+			reasons:  []string{"no user", "no user"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			decision := a.Authorize(tt.user, tt.tenant, tt.resource, tt.action)
+
+			require.Equal(t, tt.want, decision.Allowed, decision.String())
+			require.Equal(t, tt.want, decision.Policy != nil)
+
+			var reasons []string
+			for _, step := range decision.Trace {
+				reasons = append(reasons, step.Reason)
+			}
+			require.Equal(t, tt.reasons, reasons)
+		})
+	}
+}
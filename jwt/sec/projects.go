@@ -0,0 +1,151 @@
+package sec
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/jwt/grp"
+	"github.com/metal-stack/security"
+)
+
+// Role is a project scoped role, e.g. "owner", "editor" or "viewer".
+type Role string
+
+// ProjectMapping translates the groups of users into project role assignments, e.g. loaded from a YAML file:
+//
+//	rules:
+//	  - appprefix: kaas
+//	    scope: 1
+//	    projects:
+//	      dev: 0a1b2c3d-project-id
+//	    roles:
+//	      admin: owner
+//	      edit: editor
+//	      view: viewer
+//	rolepriority: [owner, editor, viewer]
+type ProjectMapping struct {
+	// Rules are applied to every group of the user, a group may be mapped by multiple rules.
+	Rules []ProjectRule `json:"rules"`
+	// RolePriority orders the roles from highest to lowest. If a user is assigned multiple roles in a project,
+	// the highest one is taken. Roles not contained are ranked lowest.
+	RolePriority []Role `json:"rolepriority"`
+}
+
+// ProjectRule maps the groups of an application to project roles.
+type ProjectRule struct {
+	// AppPrefix of the groups this rule applies to.
+	AppPrefix string `json:"appprefix"`
+	// Scope defines which scope of the group contains the namespace, that is mapped to a project, 1 for the first and 2 for the second scope.
+	Scope int `json:"scope"`
+	// Projects maps the namespaces to project ids, groups with unmapped namespaces are ignored.
+	// If nil, the namespace is taken as project id.
+	Projects map[string]string `json:"projects"`
+	// Roles translates the roles of the groups to project roles, groups with unmapped roles are ignored.
+	Roles map[string]Role `json:"roles"`
+}
+
+// Validate ensures that all rules are complete.
+func (m *ProjectMapping) Validate() error {
+	for i, rule := range m.Rules {
+		if rule.AppPrefix == "" {
+			return fmt.Errorf("rule %d: app prefix must not be empty", i)
+		}
+		if rule.Scope != 1 && rule.Scope != 2 {
+			return fmt.Errorf("rule %d: scope must be 1 or 2, got %d", i, rule.Scope)
+		}
+		if len(rule.Roles) == 0 {
+			return fmt.Errorf("rule %d: at least one role translation is required", i)
+		}
+	}
+
+	return nil
+}
+
+// WithProjectMapping enables mapping the groups of users to project roles, see ProjectRoles.
+func (p *Plugin) WithProjectMapping(m ProjectMapping) (*Plugin, error) {
+	err := m.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	p.projectMapping = &m
+
+	return p, nil
+}
+
+// ProjectRoles returns the roles of the user per project id, as defined by the project mapping of the plugin.
+// Groups granting permissions for all namespaces (i.e. "all") are returned with the project id "*".
+// The result is empty if no project mapping is configured.
+func (p *Plugin) ProjectRoles(user *security.User) map[string]Role {
+	result := map[string]Role{}
+
+	if p.projectMapping == nil || user == nil {
+		return result
+	}
+
+	for i := range user.Groups {
+		grpCtx, err := p.grpr.ParseGroupName(string(user.Groups[i]))
+		if err != nil {
+			continue
+		}
+
+		for _, rule := range p.projectMapping.Rules {
+			project, role, ok := rule.apply(grpCtx)
+			if !ok {
+				continue
+			}
+
+			if current, ok := result[project]; !ok || p.projectMapping.higher(role, current) {
+				result[project] = role
+			}
+		}
+	}
+
+	return result
+}
+
+// apply returns the project role assignment of the given group, ok is false if the rule does not map the group.
+func (r *ProjectRule) apply(g *grp.Group) (project string, role Role, ok bool) {
+	if !strings.EqualFold(g.AppPrefix, r.AppPrefix) {
+		return "", "", false
+	}
+
+	role, ok = r.Roles[g.Role]
+	if !ok {
+		return "", "", false
+	}
+
+	namespace := g.FirstScope
+	if r.Scope == 2 {
+		namespace = g.SecondScope
+	}
+
+	if strings.EqualFold(namespace, grp.All) {
+		return grp.Any, role, true
+	}
+
+	if r.Projects == nil {
+		return namespace, role, true
+	}
+
+	project, ok = r.Projects[namespace]
+	if !ok {
+		return "", "", false
+	}
+
+	return project, role, true
+}
+
+// higher returns if role a has a higher priority than role b.
+func (m *ProjectMapping) higher(a, b Role) bool {
+	rank := func(r Role) int {
+		i := slices.Index(m.RolePriority, r)
+		if i < 0 {
+			return len(m.RolePriority)
+		}
+		return i
+	}
+
+	return rank(a) < rank(b)
+}
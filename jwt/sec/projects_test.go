@@ -0,0 +1,121 @@
+package sec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestProjectRoles(t *testing.T) {
+	var mapping ProjectMapping
+	err := yaml.UnmarshalStrict([]byte(`
+rules:
+  - appprefix: kaas
+    scope: 1
+    projects:
+      dev: project-dev
+      prod: project-prod
+    roles:
+      admin: owner
+      edit: editor
+      view: viewer
+  - appprefix: k8s
+    scope: 2
+    roles:
+      admin: owner
+rolepriority: [owner, editor, viewer]
+`), &mapping)
+	require.NoError(t, err)
+
+	p, err := NewPlugin(grpr).WithProjectMapping(mapping)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   map[string]Role
+	}{
+		{
+			name: "no groups",
+			want: map[string]Role{},
+		},
+		{
+			name:   "namespace mapped to project",
+			groups: []string{"kaas-dev-all-view", "kaas-prod-all-edit"},
+			want:   map[string]Role{"project-dev": "viewer", "project-prod": "editor"},
+		},
+		{
+			name:   "highest role wins",
+			groups: []string{"kaas-dev-all-view", "kaas-dev-cluster1-admin", "kaas-dev-all-edit"},
+			want:   map[string]Role{"project-dev": "owner"},
+		},
+		{
+			name:   "unmapped namespaces, roles and apps are ignored",
+			groups: []string{"kaas-test-all-admin", "kaas-dev-all-cadm", "maas-dev-all-admin", "invalid-grp"},
+			want:   map[string]Role{},
+		},
+		{
+			name:   "all namespaces",
+			groups: []string{"kaas-all-all-view"},
+			want:   map[string]Role{"*": "viewer"},
+		},
+		{
+			name:   "namespace is taken as project without project mapping",
+			groups: []string{"k8s-cluster1-ns1-admin", "k8s-cluster1-ns2-view"},
+			want:   map[string]Role{"ns1": "owner"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.ProjectRoles(&security.User{Name: "achim", Tenant: "tnnt", Groups: ToResourceAccess(tt.groups...)})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+
+	require.Empty(t, NewPlugin(grpr).ProjectRoles(&security.User{Groups: ToResourceAccess("kaas-dev-all-admin")}))
+}
+
+func TestProjectMappingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping ProjectMapping
+		wantErr error
+	}{
+		{
+			name:    "empty mapping",
+			mapping: ProjectMapping{},
+		},
+		{
+			name:    "missing app prefix",
+			mapping: ProjectMapping{Rules: []ProjectRule{{Scope: 1, Roles: map[string]Role{"admin": "owner"}}}},
+			wantErr: fmt.Errorf("rule 0: app prefix must not be empty"),
+		},
+		{
+			name:    "invalid scope",
+			mapping: ProjectMapping{Rules: []ProjectRule{{AppPrefix: "kaas", Scope: 3, Roles: map[string]Role{"admin": "owner"}}}},
+			wantErr: fmt.Errorf("rule 0: scope must be 1 or 2, got 3"),
+		},
+		{
+			name:    "missing roles",
+			mapping: ProjectMapping{Rules: []ProjectRule{{AppPrefix: "kaas", Scope: 1}}},
+			wantErr: fmt.Errorf("rule 0: at least one role translation is required"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mapping.Validate()
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package sec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/metal-stack/security"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultVerifierClockSkew          = time.Minute
+	defaultVerifierRefreshInterval    = 15 * time.Minute
+	defaultVerifierMinRefreshInterval = 30 * time.Second
+)
+
+type VerifierConfig struct {
+	// Issuer is the expected issuer of the tokens.
+	Issuer string
+	// JWKSURL is the url of the key set of the issuer, if empty it is discovered from the openid configuration of the issuer.
+	JWKSURL string
+	// Audience is the expected audience of the tokens, optional.
+	Audience string
+	// ClockSkew is the tolerated clock skew when validating the time based claims, defaults to one minute.
+	ClockSkew time.Duration
+	// RefreshInterval is the interval in which the key set is refreshed in the background, defaults to 15 minutes.
+	RefreshInterval time.Duration
+	// MinRefreshInterval is the minimum interval between refreshes caused by tokens with an unknown key id,
+	// such that tokens with arbitrary key ids cannot overload the issuer, defaults to 30 seconds.
+	MinRefreshInterval time.Duration
+	// Client is the http client used for fetching the key set, defaults to the http.DefaultClient.
+	Client *http.Client
+	Log    *slog.Logger
+}
+
+// Verifier validates tokens against the key set of an issuer. The key set is cached and refreshed in the background
+// as well as on tokens signed with an unknown key id, such that key rotations of the issuer are picked up.
+type Verifier struct {
+	issuer             string
+	jwksURL            string
+	audience           string
+	clockSkew          time.Duration
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	client             *http.Client
+	log                *slog.Logger
+	now                func() time.Time
+
+	lock        sync.RWMutex
+	keys        jose.JSONWebKeySet
+	lastRefresh time.Time
+
+	// refreshes caused by unknown key ids are deduplicated, such that concurrent misses only fetch the key set once
+	refreshGroup singleflight.Group
+}
+
+// NewVerifier returns a verifier for the given issuer, the key set is fetched initially and then refreshed
+// in the background until the given context is done.
+func NewVerifier(ctx context.Context, c VerifierConfig) (*Verifier, error) {
+	if c.Issuer == "" {
+		return nil, fmt.Errorf("issuer must be specified")
+	}
+	if c.ClockSkew <= 0 {
+		c.ClockSkew = defaultVerifierClockSkew
+	}
+	if c.RefreshInterval <= 0 {
+		c.RefreshInterval = defaultVerifierRefreshInterval
+	}
+	if c.MinRefreshInterval <= 0 {
+		c.MinRefreshInterval = defaultVerifierMinRefreshInterval
+	}
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+	if c.Log == nil {
+		c.Log = slog.Default()
+	}
+
+	v := &Verifier{
+		issuer:             c.Issuer,
+		jwksURL:            c.JWKSURL,
+		audience:           c.Audience,
+		clockSkew:          c.ClockSkew,
+		refreshInterval:    c.RefreshInterval,
+		minRefreshInterval: c.MinRefreshInterval,
+		client:             c.Client,
+		log:                c.Log.WithGroup("verifier"),
+		now:                time.Now,
+	}
+
+	if v.jwksURL == "" {
+		jwksURL, err := v.discover(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v.jwksURL = jwksURL
+	}
+
+	err := v.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go v.refreshLoop(ctx)
+
+	return v, nil
+}
+
+// Verify validates the signature, issuer, audience and time based claims of the given token and decodes the claims
+// into the given destinations, e.g. a security.Claims. Tokens without expiry are rejected.
+func (v *Verifier) Verify(ctx context.Context, token string, claims ...any) error {
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return fmt.Errorf("error parsing token: %w", err)
+	}
+
+	if len(parsed.Headers) != 1 {
+		return fmt.Errorf("token must have exactly one signature")
+	}
+
+	keys, err := v.lookup(ctx, parsed.Headers[0].KeyID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		standard jwt.Claims
+		errs     []error
+	)
+
+	for _, key := range keys {
+		err = parsed.Claims(key.Key, append([]any{&standard}, claims...)...)
+		if err == nil {
+			break
+		}
+		errs = append(errs, err)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid token signature: %w", errors.Join(errs...))
+	}
+
+	expected := jwt.Expected{
+		Issuer: v.issuer,
+		Time:   v.now(),
+	}
+	if v.audience != "" {
+		expected.AnyAudience = jwt.Audience{v.audience}
+	}
+
+	err = standard.ValidateWithLeeway(expected, v.clockSkew)
+	if err != nil {
+		return fmt.Errorf("invalid token: %w", err)
+	}
+
+	// exp is only validated when present, a token without expiry would be valid forever
+	if standard.Expiry == nil {
+		return fmt.Errorf("invalid token: %w", errors.New("token has no expiry"))
+	}
+
+	return nil
+}
+
+// VerifyClaims validates the given token and returns its claims.
+func (v *Verifier) VerifyClaims(ctx context.Context, token string) (*security.Claims, error) {
+	claims := &security.Claims{}
+
+	err := v.Verify(ctx, token, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// VerifyGenericOIDCClaims validates the given token and returns its claims.
+func (v *Verifier) VerifyGenericOIDCClaims(ctx context.Context, token string) (*security.GenericOIDCClaims, error) {
+	claims := &security.GenericOIDCClaims{}
+
+	err := v.Verify(ctx, token, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// lookup returns the keys for the given key id, the key set is refreshed if the key id is unknown.
+// Tokens without key id are verified against all keys.
+func (v *Verifier) lookup(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	keys, ok := v.cachedKeys(kid)
+	if ok {
+		return keys, nil
+	}
+
+	_, err, _ := v.refreshGroup.Do("refresh", func() (any, error) {
+		v.lock.RLock()
+		recentlyRefreshed := v.now().Sub(v.lastRefresh) < v.minRefreshInterval
+		v.lock.RUnlock()
+
+		if recentlyRefreshed {
+			return nil, nil
+		}
+
+		return nil, v.refresh(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, ok = v.cachedKeys(kid)
+	if ok {
+		return keys, nil
+	}
+
+	return nil, fmt.Errorf("no key found for key id %q", kid)
+}
+
+func (v *Verifier) cachedKeys(kid string) ([]jose.JSONWebKey, bool) {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	if kid == "" {
+		return v.keys.Keys, len(v.keys.Keys) > 0
+	}
+
+	keys := v.keys.Key(kid)
+
+	return keys, len(keys) > 0
+}
+
+func (v *Verifier) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(v.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := v.refresh(ctx)
+			if err != nil {
+				// the cached keys stay valid until the next successful refresh
+				v.log.Error("unable to refresh key set", "url", v.jwksURL, "error", err)
+			}
+		}
+	}
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+	var keys jose.JSONWebKeySet
+
+	err := v.get(ctx, v.jwksURL, &keys)
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.lastRefresh = v.now()
+
+	if err != nil {
+		return fmt.Errorf("unable to fetch key set: %w", err)
+	}
+
+	v.keys = keys
+
+	return nil
+}
+
+func (v *Verifier) discover(ctx context.Context) (string, error) {
+	var config struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+
+	err := v.get(ctx, strings.TrimSuffix(v.issuer, "/")+"/.well-known/openid-configuration", &config)
+	if err != nil {
+		return "", fmt.Errorf("unable to discover openid configuration: %w", err)
+	}
+
+	if config.JWKSURL == "" {
+		return "", fmt.Errorf("openid configuration of issuer %s contains no jwks_uri", v.issuer)
+	}
+
+	return config.JWKSURL, nil
+}
+
+func (v *Verifier) get(ctx context.Context, url string, into any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(into)
+}
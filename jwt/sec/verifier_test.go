@@ -0,0 +1,216 @@
+package sec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+type testIssuer struct {
+	lock   sync.Mutex
+	keys   jose.JSONWebKeySet
+	server *httptest.Server
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	i := &testIssuer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": i.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+		_ = json.NewEncoder(w).Encode(i.keys)
+	})
+
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+
+	return i
+}
+
+// rotate creates a new signing key, which is published along with the previous keys, and returns a builder for tokens signed with it.
+func (i *testIssuer) rotate(t *testing.T, kid string) *TokenBuilder {
+	publicKey, privateKey, err := security.CreateWebkeyPair(jose.RS256, "sig", 0)
+	require.NoError(t, err)
+
+	publicKey.KeyID = kid
+
+	i.lock.Lock()
+	i.keys.Keys = append(i.keys.Keys, publicKey)
+	i.lock.Unlock()
+
+	return NewTokenBuilder().
+		WithSigningKey(jose.RS256, privateKey.Key).
+		WithKeyID(kid).
+		WithIssuer(i.server.URL).
+		WithAudience("metal-api").
+		WithSubject("achim").
+		WithName("achim").
+		WithFederatedClaims(map[string]string{"connector_id": "tnnt_ldap_openldap"})
+}
+
+func TestVerifier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	issuer := newTestIssuer(t)
+	builder := issuer.rotate(t, "key-1")
+
+	v, err := NewVerifier(ctx, VerifierConfig{
+		Issuer:   issuer.server.URL,
+		Audience: "metal-api",
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	token, err := builder.WithIssuedAt(now).Build()
+	require.NoError(t, err)
+
+	claims, err := v.VerifyClaims(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, "achim", claims.Name)
+	require.Equal(t, "tnnt_ldap_openldap", claims.FederatedClaims["connector_id"])
+
+	t.Run("expired tokens are rejected", func(t *testing.T) {
+		expired, err := builder.WithIssuedAt(now.Add(-2 * time.Hour)).WithExpiresAt(now.Add(-time.Hour)).Build()
+		require.NoError(t, err)
+
+		_, err = v.VerifyClaims(ctx, expired)
+		require.ErrorContains(t, err, "invalid token")
+	})
+
+	t.Run("clock skew is tolerated", func(t *testing.T) {
+		skewed, err := builder.WithIssuedAt(now.Add(-2 * time.Hour)).WithExpiresAt(now.Add(-30 * time.Second)).Build()
+		require.NoError(t, err)
+
+		_, err = v.VerifyClaims(ctx, skewed)
+		require.NoError(t, err)
+	})
+
+	t.Run("wrong audience is rejected", func(t *testing.T) {
+		other, err := builder.WithIssuedAt(now).WithExpiresAt(time.Time{}).WithAudience("other").Build()
+		require.NoError(t, err)
+
+		_, err = v.VerifyClaims(ctx, other)
+		require.ErrorContains(t, err, "invalid token")
+	})
+
+	t.Run("rotated keys are fetched on unknown key id", func(t *testing.T) {
+		rotated, err := issuer.rotate(t, "key-2").WithIssuedAt(now).Build()
+		require.NoError(t, err)
+
+		// the initial fetch was too recent to refresh again
+		_, err = v.VerifyClaims(ctx, rotated)
+		require.EqualError(t, err, `no key found for key id "key-2"`)
+
+		now = now.Add(time.Minute)
+
+		_, err = v.VerifyClaims(ctx, rotated)
+		require.NoError(t, err)
+	})
+
+	t.Run("tokens without expiry are rejected", func(t *testing.T) {
+		publicKey, privateKey, err := security.CreateWebkeyPair(jose.RS256, "sig", 0)
+		require.NoError(t, err)
+
+		publicKey.KeyID = "key-1"
+		v.lock.Lock()
+		v.keys.Keys = append(v.keys.Keys, publicKey)
+		v.lock.Unlock()
+
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: privateKey.Key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key-1"))
+		require.NoError(t, err)
+
+		unlimited, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   issuer.server.URL,
+			Audience: jwt.Audience{"metal-api"},
+			IssuedAt: jwt.NewNumericDate(now),
+		}).Serialize()
+		require.NoError(t, err)
+
+		_, err = v.VerifyClaims(ctx, unlimited)
+		require.EqualError(t, err, "invalid token: token has no expiry")
+	})
+
+	t.Run("tokens of unknown keys are rejected", func(t *testing.T) {
+		_, privateKey, err := security.CreateWebkeyPair(jose.RS256, "sig", 0)
+		require.NoError(t, err)
+
+		forged, err := builder.WithSigningKey(jose.RS256, privateKey.Key).WithIssuedAt(now).Build()
+		require.NoError(t, err)
+
+		_, err = v.VerifyClaims(ctx, forged)
+		require.ErrorContains(t, err, "invalid token signature")
+	})
+}
+
+func TestVerifierConcurrentRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		issuer   = newTestIssuer(t)
+		builder  = issuer.rotate(t, "key-1")
+		lock     sync.Mutex
+		fetches  int
+		original = issuer.server.Config.Handler
+	)
+
+	issuer.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/keys" {
+			lock.Lock()
+			fetches++
+			lock.Unlock()
+
+			// a slow issuer makes the concurrent misses overlap
+			time.Sleep(100 * time.Millisecond)
+		}
+		original.ServeHTTP(w, r)
+	})
+
+	v, err := NewVerifier(ctx, VerifierConfig{
+		Issuer:             issuer.server.URL,
+		MinRefreshInterval: time.Nanosecond,
+	})
+	require.NoError(t, err)
+
+	token, err := builder.WithKeyID("unknown").Build()
+	require.NoError(t, err)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 20)
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.VerifyClaims(ctx, token)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.EqualError(t, err, `no key found for key id "unknown"`)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Less(t, fetches, 10, "concurrent misses must share the refresh of the key set")
+}
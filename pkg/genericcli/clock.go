@@ -0,0 +1,90 @@
+package genericcli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock provides the current time to the generic cli, e.g. for measuring the durations of bulk operations.
+// It can be replaced through WithClock in order to get deterministic output in golden tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock is a clock for tests, which starts at a given time and advances by a fixed step on every call of Now.
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock returns a fake clock starting at the given time that advances by the given step on every call of Now.
+// With a step of zero, the clock stands still.
+func NewFakeClock(start time.Time, step time.Duration) *FakeClock {
+	return &FakeClock{
+		now:  start,
+		step: step,
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+
+	return now
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// IDGenerator provides unique ids to the generic cli, e.g. for grouping the history entries of a single cli invocation.
+// It can be replaced through WithIDGenerator in order to get deterministic output in golden tests.
+type IDGenerator interface {
+	NewID() string
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string {
+	return uuid.NewString()
+}
+
+// FakeIDGenerator is an id generator for tests, which returns sequential ids with a given prefix, e.g. run-1, run-2, ...
+type FakeIDGenerator struct {
+	lock   sync.Mutex
+	prefix string
+	count  int
+}
+
+// NewFakeIDGenerator returns a fake id generator returning sequential ids with the given prefix.
+func NewFakeIDGenerator(prefix string) *FakeIDGenerator {
+	return &FakeIDGenerator{
+		prefix: prefix,
+	}
+}
+
+func (g *FakeIDGenerator) NewID() string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.count++
+
+	return fmt.Sprintf("%s-%d", g.prefix, g.count)
+}
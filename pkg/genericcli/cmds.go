@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/multisort"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
 )
 
 type DefaultCmd string
@@ -22,6 +25,10 @@ const (
 	DeleteCmd   DefaultCmd = "delete"
 	ApplyCmd    DefaultCmd = "apply"
 	EditCmd     DefaultCmd = "edit"
+	HistoryCmd  DefaultCmd = "history"
+	UndoCmd     DefaultCmd = "undo"
+	LabelCmd    DefaultCmd = "label"
+	AnnotateCmd DefaultCmd = "annotate"
 )
 
 func allCmds() map[DefaultCmd]bool {
@@ -33,6 +40,10 @@ func allCmds() map[DefaultCmd]bool {
 		DeleteCmd:   true,
 		ApplyCmd:    true,
 		EditCmd:     true,
+		HistoryCmd:  true,
+		UndoCmd:     true,
+		LabelCmd:    true,
+		AnnotateCmd: true,
 	}
 }
 
@@ -68,6 +79,11 @@ type CmdsConfig[C any, U any, R any] struct {
 	// Args defines how many arguments are being used for the entity's id and how they are named, this defaults to ["id"]
 	Args []string
 
+	// Examples can be used to provide usage examples for the default commands, which are shown in the help output.
+	// For commands without an entry, an example is generated from the binary name and the entity name.
+	// An empty string omits the example for a command.
+	Examples map[DefaultCmd]string
+
 	// DescribePrinter is the printer that is used for describing the entity. It's a function because printers potentially get initialized later in the game.
 	DescribePrinter func() printers.Printer
 	// ListPrinter is the printer that is used for listing multiple entities. It's a function because printers potentially get initialized later in the game.
@@ -80,10 +96,50 @@ type CmdsConfig[C any, U any, R any] struct {
 
 	// Sorter allows sorting the results of list commands.
 	Sorter *multisort.Sorter[R]
+	// DefaultSortKeys are the sort keys of the list command when no sort keys are passed, which are shown in the help text.
+	// If empty, the default sort keys of the sorter are used.
+	DefaultSortKeys multisort.Keys
+	// SortPreferences stores the sort order of the user per resource, which takes precedence over the default sort keys.
+	// If set, the list command gets a --save-sort-by flag, which stores the passed sort keys as preference.
+	SortPreferences SortPreferences
+	// ListColumns are the column ids of the list table, which enables selecting and sorting the printed columns with
+	// the --columns and --sort-by-column flags when the list printer is a table printer.
+	ListColumns []string
+	// ListFooterFn can be used to show additional information below the list results, e.g. the quota usage of a project.
+	// It receives the listed entities and the returned footer is printed below tables and as metadata of json and yaml outputs.
+	ListFooterFn func(items []R) (*ListFooter, error)
 
 	// ValidArgsFn is a completion function that returns the valid command line arguments.
 	ValidArgsFn func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
 
+	// ErrorClassifier can be used to classify errors of the api client, which are not known to ClassifyError.
+	// The errors returned by the default commands carry the error kind, which is mapped to the process exit code by ExitCode.
+	ErrorClassifier func(err error) ErrorKind
+
+	// HistoryDir enables recording deleted and updated entities in a history file within this directory, typically the
+	// config directory of the cli. If set, the history and undo commands are added, which allow reverting accidental operations.
+	HistoryDir string
+
+	// ReadCacheDir enables caching the results of the list and describe commands per active context in this directory,
+	// see ReadCacheDir for the default location. The cached data is shown when the api is unreachable and can be requested
+	// explicitly with the --cached flag, in both cases the data is marked as stale.
+	ReadCacheDir string
+	// ReadCacheTTL is the maximum age of cached data that is shown, defaults to 24 hours.
+	ReadCacheTTL time.Duration
+
+	// ConfirmPolicy defines which commands ask for an interactive confirmation, which can be skipped with the --yes flag.
+	// If empty, only bulk operations are confirmed.
+	ConfirmPolicy ConfirmPolicy
+	// ConfirmShowEntity shows the affected entity as yaml when asking for the confirmation of a single delete or an undo,
+	// which requires fetching the entity before deleting it.
+	ConfirmShowEntity bool
+	// ConfirmSkipNonInteractive skips confirmations when the input is not a terminal instead of failing.
+	ConfirmSkipNonInteractive bool
+	// ActiveContext returns the active context of the cli, optional. Deleting entities or undoing operations in a
+	// protected context requires typing the name of the context, which can be confirmed for automation through the
+	// <BINARYNAME>_CONFIRM_CONTEXT environment variable.
+	ActiveContext func() (*Context, error)
+
 	// In defines from where input is read, defaults to stdin.
 	In io.Reader
 	// Out defines to where output is written, defaults to stdout.
@@ -98,6 +154,10 @@ type CmdsConfig[C any, U any, R any] struct {
 	DeleteCmdMutateFn   func(cmd *cobra.Command)
 	ApplyCmdMutateFn    func(cmd *cobra.Command)
 	EditCmdMutateFn     func(cmd *cobra.Command)
+	HistoryCmdMutateFn  func(cmd *cobra.Command)
+	UndoCmdMutateFn     func(cmd *cobra.Command)
+	LabelCmdMutateFn    func(cmd *cobra.Command)
+	AnnotateCmdMutateFn func(cmd *cobra.Command)
 }
 
 // NewCmds can be used to generate a new cobra/viper root cmd with a set of default cmds provided by the generic cli.
@@ -114,6 +174,9 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 	if c.Sorter != nil {
 		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithSorter(c.Sorter)
 	}
+	if c.HistoryDir != "" {
+		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithHistory(HistoryPath(c.HistoryDir, c.Singular))
+	}
 
 	Must(c.validate())
 
@@ -131,18 +194,59 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 			Use:     "list",
 			Aliases: []string{"ls"},
 			Short:   fmt.Sprintf("list all %s", c.Plural),
+			Example: c.example(ListCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
-				sortKeys, err := ParseSortFlags()
+				err := c.evalReadCache(listFilter(cmd))
+				if err != nil {
+					return err
+				}
+
+				sortKeys, err := c.listSortKeys()
 				if err != nil {
 					return err
 				}
 
-				return c.MultiArgGenericCLI.ListAndPrint(c.ListPrinter(), sortKeys...)
+				p := c.ListPrinter()
+
+				if tp, ok := p.(*printers.TablePrinter); ok && len(c.ListColumns) > 0 {
+					columns, columnSortKeys, err := ParseColumnFlags()
+					if err != nil {
+						return err
+					}
+
+					p = tp.WithColumns(columns, columnSortKeys)
+				}
+
+				if tp, ok := p.(*printers.TablePrinter); ok && viper.IsSet("label-columns") {
+					columnsFn, err := c.MultiArgGenericCLI.LabelColumns(viper.GetStringSlice("label-columns"))
+					if err != nil {
+						return err
+					}
+
+					p = tp.WithAdditionalColumns(columnsFn)
+				}
+
+				if c.ListFooterFn != nil {
+					return c.listAndPrintWithFooter(p, sortKeys)
+				}
+
+				return c.MultiArgGenericCLI.ListAndPrint(p, sortKeys...)
 			},
 		}
 
 		if c.Sorter != nil {
-			AddSortFlag(cmd, c.Sorter)
+			addSortFlag(cmd, c.Sorter, c.defaultSortKeys())
+
+			if c.SortPreferences != nil {
+				cmd.Flags().Bool("save-sort-by", false, fmt.Sprintf("stores the sort order given by --sort-by as default for listing %s, passing no sort keys removes the stored default", c.Plural))
+			}
+		}
+		if len(c.ListColumns) > 0 {
+			AddColumnFlags(cmd, c.ListColumns)
+		}
+		c.addReadCacheFlag(cmd)
+		if _, ok := c.MultiArgGenericCLI.labels(); ok {
+			cmd.Flags().StringSlice("label-columns", []string{}, "shows the values of the given (comma separated) label keys as additional columns in table output")
 		}
 
 		if c.ListCmdMutateFn != nil {
@@ -162,17 +266,25 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 			Use:     use,
 			Aliases: []string{"get"},
 			Short:   fmt.Sprintf("describes the %s", c.Singular),
+			Example: c.example(DescribeCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
 				id, err := GetExactlyNArgs(len(c.Args), args)
 				if err != nil {
 					return err
 				}
 
+				err = c.evalReadCache(nil)
+				if err != nil {
+					return err
+				}
+
 				return c.MultiArgGenericCLI.DescribeAndPrint(c.DescribePrinter(), id...)
 			},
 			ValidArgsFunction: c.ValidArgsFn,
 		}
 
+		c.addReadCacheFlag(cmd)
+
 		if c.DescribeCmdMutateFn != nil {
 			c.DescribeCmdMutateFn(cmd)
 		}
@@ -182,8 +294,9 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 
 	if _, ok := c.OnlyCmds[CreateCmd]; ok {
 		cmd := &cobra.Command{
-			Use:   "create",
-			Short: fmt.Sprintf("creates the %s", c.Singular),
+			Use:     "create",
+			Short:   fmt.Sprintf("creates the %s", c.Singular),
+			Example: c.example(CreateCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
 				if c.CreateRequestFromCLI != nil && !viper.IsSet("file") {
 					rq, err := c.CreateRequestFromCLI()
@@ -191,10 +304,18 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 						return err
 					}
 
+					err = c.confirm(CreateCmd)
+					if err != nil {
+						return err
+					}
+
 					return c.MultiArgGenericCLI.CreateAndPrint(rq, c.DescribePrinter())
 				}
 
-				p := c.evalBulkFlags()
+				p, err := c.evalBulkFlags(CreateCmd)
+				if err != nil {
+					return err
+				}
 
 				return c.MultiArgGenericCLI.CreateFromFileAndPrint(viper.GetString("file"), p())
 			},
@@ -218,19 +339,47 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 		}
 
 		cmd := &cobra.Command{
-			Use:   use,
-			Short: fmt.Sprintf("updates the %s", c.Singular),
+			Use:     use,
+			Short:   fmt.Sprintf("updates the %s", c.Singular),
+			Example: c.example(UpdateCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
+				if viper.IsSet("patch-file") {
+					id, err := GetExactlyNArgs(len(c.Args), args)
+					if err != nil {
+						return err
+					}
+
+					if viper.GetBool("dry-run") {
+						c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithDryRun()
+					} else {
+						err = c.confirm(UpdateCmd, id...)
+						if err != nil {
+							return err
+						}
+					}
+					c.evalDiffFlag()
+
+					return c.MultiArgGenericCLI.PatchAndPrint(viper.GetString("patch-file"), c.DescribePrinter(), id...)
+				}
+
 				if c.UpdateRequestFromCLI != nil && !viper.IsSet("file") {
 					rq, err := c.UpdateRequestFromCLI(args)
 					if err != nil {
 						return err
 					}
 
+					err = c.confirm(UpdateCmd, args...)
+					if err != nil {
+						return err
+					}
+
 					return c.MultiArgGenericCLI.UpdateAndPrint(rq, c.DescribePrinter())
 				}
 
-				p := c.evalBulkFlags()
+				p, err := c.evalBulkFlags(UpdateCmd)
+				if err != nil {
+					return err
+				}
 
 				return c.MultiArgGenericCLI.UpdateFromFileAndPrint(viper.GetString("file"), p())
 			},
@@ -238,6 +387,10 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 		}
 
 		c.addFileFlags(cmd)
+		cmd.Flags().String("patch-file", "", "updates the entity with the given id with a merge patch from the given file, which only contains the fields to change (fields set to null are removed)")
+		cmd.Flags().Bool("dry-run", false, "when used with --patch-file: prints the patched entity without updating it")
+		cmd.Flags().Bool("no-diff", false, "when used with --patch-file: skips printing the diff between the current and the patched entity")
+		cmd.MarkFlagsMutuallyExclusive("file", "patch-file")
 
 		if c.UpdateCmdMutateFn != nil {
 			c.UpdateCmdMutateFn(cmd)
@@ -255,18 +408,38 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 		cmd := &cobra.Command{
 			Use:     use,
 			Short:   fmt.Sprintf("deletes the %s", c.Singular),
+			Example: c.example(DeleteCmd),
 			Aliases: []string{"destroy", "rm", "remove"},
 			RunE: func(cmd *cobra.Command, args []string) error {
+				err := c.confirmProtectedContext(DeleteCmd)
+				if err != nil {
+					return err
+				}
+
 				if !viper.IsSet("file") {
 					id, err := GetExactlyNArgs(len(c.Args), args)
 					if err != nil {
 						return err
 					}
 
+					err = c.confirmWithPreview(DeleteCmd, func() ([]byte, error) {
+						entity, err := c.MultiArgGenericCLI.Describe(id...)
+						if err != nil {
+							return nil, err
+						}
+						return yaml.Marshal(entity)
+					}, id...)
+					if err != nil {
+						return err
+					}
+
 					return c.MultiArgGenericCLI.DeleteAndPrint(c.DescribePrinter(), id...)
 				}
 
-				p := c.evalBulkFlags()
+				p, err := c.evalBulkFlags(DeleteCmd)
+				if err != nil {
+					return err
+				}
 
 				return c.MultiArgGenericCLI.DeleteFromFileAndPrint(viper.GetString("file"), p())
 			},
@@ -284,20 +457,22 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 
 	if _, ok := c.OnlyCmds[ApplyCmd]; ok {
 		cmd := &cobra.Command{
-			Use:   "apply",
-			Short: fmt.Sprintf("applies one or more %s from a given file", c.Plural),
+			Use:     "apply",
+			Short:   fmt.Sprintf("applies one or more %s from a given file", c.Plural),
+			Example: c.example(ApplyCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
-				if !viper.GetBool("skip-security-prompts") {
-					c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithBulkSecurityPrompt(c.In, c.Out)
+				p, err := c.evalBulkFlags(ApplyCmd)
+				if err != nil {
+					return err
 				}
-
-				p := c.evalBulkFlags()
+				c.evalDiffFlag()
 
 				return c.MultiArgGenericCLI.ApplyFromFileAndPrint(viper.GetString("file"), p())
 			},
 		}
 
 		c.addFileFlags(cmd)
+		c.addDiffFlag(cmd)
 		Must(cmd.MarkFlagRequired("file"))
 
 		if c.ApplyCmdMutateFn != nil {
@@ -314,14 +489,19 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 		}
 
 		cmd := &cobra.Command{
-			Use:   use,
-			Short: fmt.Sprintf("edit the %s through an editor and update", c.Singular),
+			Use:     use,
+			Short:   fmt.Sprintf("edit the %s through an editor and update", c.Singular),
+			Example: c.example(EditCmd),
 			RunE: func(cmd *cobra.Command, args []string) error {
+				c.evalDiffFlag()
+
 				return c.MultiArgGenericCLI.EditAndPrint(len(c.Args), args, c.DescribePrinter())
 			},
 			ValidArgsFunction: c.ValidArgsFn,
 		}
 
+		c.addDiffFlag(cmd)
+
 		if c.EditCmdMutateFn != nil {
 			c.EditCmdMutateFn(cmd)
 		}
@@ -329,54 +509,357 @@ func NewCmds[C any, U any, R any](c *CmdsConfig[C, U, R], additionalCmds ...*cob
 		cmds = append(cmds, cmd)
 	}
 
+	if _, ok := c.OnlyCmds[HistoryCmd]; ok && c.HistoryDir != "" {
+		cmd := &cobra.Command{
+			Use:     "history",
+			Short:   fmt.Sprintf("shows the recently deleted and updated %s", c.Plural),
+			Example: c.example(HistoryCmd),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.MultiArgGenericCLI.HistoryAndPrint(printers.NewTablePrinter(&printers.TablePrinterConfig{
+					ToHeaderAndRows: HistoryTable,
+					Out:             c.Out,
+				}))
+			},
+		}
+
+		if c.HistoryCmdMutateFn != nil {
+			c.HistoryCmdMutateFn(cmd)
+		}
+
+		cmds = append(cmds, cmd)
+	}
+
+	if _, ok := c.OnlyCmds[UndoCmd]; ok && c.HistoryDir != "" {
+		cmd := &cobra.Command{
+			Use:     "undo",
+			Short:   fmt.Sprintf("re-creates or restores the %s of the most recent delete or update", c.Plural),
+			Example: c.example(UndoCmd),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := c.confirmProtectedContext(UndoCmd)
+				if err != nil {
+					return err
+				}
+
+				err = c.confirmWithPreview(UndoCmd, c.MultiArgGenericCLI.undoPreview)
+				if err != nil {
+					return err
+				}
+
+				return c.MultiArgGenericCLI.UndoAndPrint(c.DescribePrinter())
+			},
+		}
+
+		cmd.Flags().Bool("yes", false, "confirms the operation without asking interactively")
+
+		if c.UndoCmdMutateFn != nil {
+			c.UndoCmdMutateFn(cmd)
+		}
+
+		cmds = append(cmds, cmd)
+	}
+
+	if _, ok := c.MultiArgGenericCLI.labels(); ok {
+		if _, ok := c.OnlyCmds[LabelCmd]; ok {
+			cmd := c.keyValuesCmd(LabelCmd, "labels", c.MultiArgGenericCLI.LabelAndPrint, c.MultiArgGenericCLI.LabelFromFileAndPrint)
+
+			if c.LabelCmdMutateFn != nil {
+				c.LabelCmdMutateFn(cmd)
+			}
+
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	if _, ok := c.MultiArgGenericCLI.annotations(); ok {
+		if _, ok := c.OnlyCmds[AnnotateCmd]; ok {
+			cmd := c.keyValuesCmd(AnnotateCmd, "annotations", c.MultiArgGenericCLI.AnnotateAndPrint, c.MultiArgGenericCLI.AnnotateFromFileAndPrint)
+
+			if c.AnnotateCmdMutateFn != nil {
+				c.AnnotateCmdMutateFn(cmd)
+			}
+
+			cmds = append(cmds, cmd)
+		}
+	}
+
 	if c.RootCmdMutateFn != nil {
 		c.RootCmdMutateFn(rootCmd)
 	}
 
+	for _, cmd := range cmds {
+		traceRunE(cmd, c)
+		classifyRunE(cmd, c.ErrorClassifier)
+	}
+
 	rootCmd.AddCommand(cmds...)
 	rootCmd.AddCommand(additionalCmds...)
 
 	return rootCmd
 }
 
+// keyValuesCmd returns the label or the annotate command, which either applies the changes given as arguments after the id
+// or merges the key values of the entities in the given file.
+func (c *CmdsConfig[C, U, R]) keyValuesCmd(defaultCmd DefaultCmd, name string, changeAndPrint func(p printers.Printer, changes []string, id ...string) error, fromFileAndPrint func(from string, p printers.Printer) error) *cobra.Command {
+	use := string(defaultCmd)
+	for _, arg := range c.Args {
+		use += fmt.Sprintf(" <%s>", arg)
+	}
+	use += " <key>=<value>|<key>-..."
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   fmt.Sprintf("adds, updates or removes %s of the %s", name, c.Singular),
+		Example: c.example(defaultCmd),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !viper.IsSet("file") {
+				if len(args) <= len(c.Args) {
+					return NewError(ErrorKindValidation, fmt.Errorf("%d positional args for the id and at least one change of the %s are required, %d were provided", len(c.Args), name, len(args)))
+				}
+
+				id, changes := args[:len(c.Args)], args[len(c.Args):]
+
+				err := c.confirm(defaultCmd, id...)
+				if err != nil {
+					return err
+				}
+
+				return changeAndPrint(c.DescribePrinter(), changes, id...)
+			}
+
+			p, err := c.evalBulkFlags(defaultCmd)
+			if err != nil {
+				return err
+			}
+
+			return fromFileAndPrint(viper.GetString("file"), p())
+		},
+		ValidArgsFunction: c.ValidArgsFn,
+	}
+
+	c.addFileFlags(cmd)
+
+	return cmd
+}
+
+func (c *CmdsConfig[C, U, R]) defaultSortKeys() multisort.Keys {
+	if len(c.DefaultSortKeys) > 0 {
+		return c.DefaultSortKeys
+	}
+	if c.Sorter != nil {
+		return c.Sorter.DefaultKeys()
+	}
+	return nil
+}
+
+func (c *CmdsConfig[C, U, R]) listSortKeys() (multisort.Keys, error) {
+	values := viper.GetStringSlice("sort-by")
+
+	keys, err := resolveSortKeys(values, c.SortPreferences, c.Singular, c.DefaultSortKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SortPreferences != nil && viper.GetBool("save-sort-by") {
+		explicit, err := parseSortKeys(values)
+		if err != nil {
+			return nil, err
+		}
+
+		// sorting no data only validates the sort keys, such that no invalid preference is stored
+		err = c.Sorter.SortBy(nil, explicit...)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.SortPreferences.SetSortKeys(c.Singular, formatSortKeys(explicit))
+		if err != nil {
+			return nil, fmt.Errorf("unable to store sort preference: %w", err)
+		}
+	}
+
+	return keys, nil
+}
+
 func ParseSortFlags() (multisort.Keys, error) {
+	return parseSortKeys(viper.GetStringSlice("sort-by"))
+}
+
+func parseSortKeys(values []string) (multisort.Keys, error) {
 	var keys multisort.Keys
 
-	for _, col := range viper.GetStringSlice("sort-by") {
+	for _, col := range values {
 		col = strings.ToLower(strings.TrimSpace(col))
 
-		var descending bool
+		direction := multisort.Ascending
 
 		id, directionRaw, found := strings.Cut(col, ":")
 		if found {
-			switch directionRaw {
-			case "asc", "ascending":
-				descending = false
-			case "desc", "descending":
-				descending = true
-			default:
-				return nil, fmt.Errorf("unsupported sort direction: %s", directionRaw)
+			var err error
+			direction, err = multisort.ParseDirection(directionRaw)
+			if err != nil {
+				return nil, err
 			}
 		}
 
-		keys = append(keys, multisort.Key{ID: id, Descending: descending})
+		keys = append(keys, multisort.Key{ID: id, Descending: direction.IsDescending()})
 	}
 
 	return keys, nil
 }
 
 func AddSortFlag[R any](cmd *cobra.Command, sorter *multisort.Sorter[R]) {
+	addSortFlag(cmd, sorter, sorter.DefaultKeys())
+}
+
+func addSortFlag[R any](cmd *cobra.Command, sorter *multisort.Sorter[R], defaults multisort.Keys) {
 	if sortKeys := sorter.AvailableKeys(); len(sortKeys) > 0 {
-		cmd.Flags().StringSlice("sort-by", []string{}, fmt.Sprintf("sort by (comma separated) column(s), sort direction can be changed by appending :asc or :desc behind the column identifier. possible values: %s", strings.Join(sortKeys, "|")))
-		Must(cmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions(sorter.AvailableKeys(), cobra.ShellCompDirectiveNoFileComp)))
+		var directions []string
+		for _, d := range multisort.DirectionValues() {
+			directions = append(directions, ":"+d.String())
+		}
+
+		cmd.Flags().StringSlice("sort-by", []string{}, fmt.Sprintf("sort by (comma separated) column(s), sort direction can be changed by appending %s behind the column identifier. possible values: %s", strings.Join(directions, " or "), strings.Join(sortKeys, "|"))+sortDefaultHelp(defaults))
+		Must(cmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions(sortFlagCompletions(sortKeys), cobra.ShellCompDirectiveNoFileComp)))
+	}
+}
+
+// ParseColumnFlags returns the selected columns and the column sort keys of the flags added by AddColumnFlags.
+func ParseColumnFlags() ([]string, multisort.Keys, error) {
+	return parseColumnFlags(viper.GetViper())
+}
+
+func parseColumnFlags(v *viper.Viper) ([]string, multisort.Keys, error) {
+	var columns []string
+	for _, col := range v.GetStringSlice("columns") {
+		columns = append(columns, printers.ColumnID(col))
 	}
+
+	sortKeys, err := parseSortKeys(v.GetStringSlice("sort-by-column"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return columns, sortKeys, nil
+}
+
+// AddColumnFlags adds flags for selecting and sorting the columns of a table printer, see ParseColumnFlags.
+func AddColumnFlags(cmd *cobra.Command, columns []string) {
+	var directions []string
+	for _, d := range multisort.DirectionValues() {
+		directions = append(directions, ":"+d.String())
+	}
+
+	cmd.Flags().StringSlice("columns", []string{}, fmt.Sprintf("select the (comma separated) column(s) to print in the given order. possible values: %s", strings.Join(columns, "|")))
+	cmd.Flags().StringSlice("sort-by-column", []string{}, fmt.Sprintf("sort the printed rows by (comma separated) column(s), sort direction can be changed by appending %s behind the column identifier. possible values: %s", strings.Join(directions, " or "), strings.Join(columns, "|")))
+	Must(cmd.RegisterFlagCompletionFunc("columns", cobra.FixedCompletions(columns, cobra.ShellCompDirectiveNoFileComp)))
+	Must(cmd.RegisterFlagCompletionFunc("sort-by-column", cobra.FixedCompletions(sortFlagCompletions(columns), cobra.ShellCompDirectiveNoFileComp)))
+}
+
+func sortFlagCompletions(sortKeys []string) []string {
+	var res []string
+
+	for _, key := range sortKeys {
+		res = append(res, key)
+		for _, d := range multisort.DirectionValues() {
+			res = append(res, key+":"+d.String())
+		}
+	}
+
+	return res
 }
 
 func (c *CmdsConfig[C, U, R]) addFileFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP("file", "f", "", c.fileFlagHelpText(cmd.Use))
 	cmd.Flags().Bool("skip-security-prompts", false, c.skipPromptsFlagText())
+	cmd.Flags().Bool("yes", false, "confirms the operation without asking interactively")
 	cmd.Flags().Bool("bulk-output", false, c.bulkFlagText())
 	cmd.Flags().Bool("timestamps", false, c.bulkTimestampsText())
+	cmd.Flags().String("state-file", "", "when used with --file (bulk operation): records the successfully processed documents in the given file. defaults to <file>.state when used with --resume.")
+	cmd.Flags().Bool("resume", false, "when used with --file (bulk operation): skips the documents that were already processed successfully according to the state file of a previous run")
+	cmd.Flags().String("on-error", string(OnErrorContinue), "when used with --file (bulk operation): defines the behavior on errors. fail aborts on the first error, continue processes all documents and fails if any error occurred, threshold=<percentage> succeeds if at least the given percentage of documents was processed successfully")
+	cmd.Flags().Bool("substitute-env", false, "when used with --file: substitutes environment variables like ${VAR} or ${VAR:-default} in the file before parsing, a literal ${ can be escaped as $${")
+	Must(cmd.RegisterFlagCompletionFunc("on-error", cobra.FixedCompletions([]string{string(OnErrorFail), string(OnErrorContinue), string(OnErrorThreshold) + "="}, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace)))
+}
+
+func (c *CmdsConfig[C, U, R]) addReadCacheFlag(cmd *cobra.Command) {
+	if c.ReadCacheDir == "" {
+		return
+	}
+
+	cmd.Flags().Bool("cached", false, fmt.Sprintf("shows the cached %s of the active context without contacting the api, e.g. when the api is unreachable. cached data may be stale.", c.Plural))
+}
+
+// listPresentationFlags are flags of the list command that do not filter the listed entities.
+var listPresentationFlags = map[string]bool{
+	"cached":         true,
+	"sort-by":        true,
+	"save-sort-by":   true,
+	"columns":        true,
+	"sort-by-column": true,
+	"label-columns":  true,
+}
+
+// listFilter returns the values of the flags of the list command that filter the listed entities, e.g. flags added by
+// the ListCmdMutateFn, as far as they differ from their defaults.
+func listFilter(cmd *cobra.Command) map[string]string {
+	filter := map[string]string{}
+
+	cmd.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if listPresentationFlags[f.Name] {
+			return
+		}
+
+		value := f.Value.String()
+		if viper.IsSet(f.Name) {
+			value = fmt.Sprint(viper.Get(f.Name))
+		}
+		if value == f.DefValue {
+			return
+		}
+
+		filter[f.Name] = value
+	})
+
+	return filter
+}
+
+func (c *CmdsConfig[C, U, R]) evalReadCache(listFilter map[string]string) error {
+	if c.ReadCacheDir == "" {
+		return nil
+	}
+
+	name := ""
+	if c.ActiveContext != nil {
+		ctx, err := c.ActiveContext()
+		if err != nil {
+			return fmt.Errorf("unable to determine active context: %w", err)
+		}
+		if ctx != nil {
+			name = ctx.Name
+		}
+	}
+
+	c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithReadCache(&ReadCache{
+		Dir:     c.ReadCacheDir,
+		Context: name,
+		Entity:  c.Singular,
+		TTL:     c.ReadCacheTTL,
+		Offline: viper.GetBool("cached"),
+
+		ListFilter: listFilter,
+	})
+
+	return nil
+}
+
+func (c *CmdsConfig[C, U, R]) addDiffFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("no-diff", false, "skips printing the diff between the current and the desired state of the entity")
+}
+
+func (c *CmdsConfig[C, U, R]) evalDiffFlag() {
+	if !viper.GetBool("no-diff") {
+		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithDiff(c.Out)
+	}
 }
 
 func (c *CmdsConfig[C, U, R]) validate() error {
@@ -411,22 +894,103 @@ func (c *CmdsConfig[C, U, R]) validate() error {
 	return nil
 }
 
-func (c *CmdsConfig[C, U, R]) evalBulkFlags() func() printers.Printer {
-	if !viper.GetBool("skip-security-prompts") {
-		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithBulkSecurityPrompt(c.In, c.Out)
+func (c *CmdsConfig[C, U, R]) evalBulkFlags(cmd DefaultCmd) (func() printers.Printer, error) {
+	if c.confirmRequired(cmd, true) {
+		interactive, err := c.ensureInteractive()
+		if err != nil {
+			return nil, err
+		}
+
+		if interactive {
+			c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithBulkSecurityPrompt(c.In, c.Out)
+		}
 	}
 
 	if viper.GetBool("timestamps") {
 		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithTimestamps()
 	}
 
+	if viper.GetBool("substitute-env") {
+		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithEnvSubstitution()
+	}
+
+	if viper.GetBool("resume") || viper.GetString("state-file") != "" {
+		stateFile := viper.GetString("state-file")
+		if file := viper.GetString("file"); stateFile == "" && file != "-" {
+			stateFile = file + ".state"
+		}
+
+		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithStateFile(stateFile, viper.GetBool("resume"))
+	}
+
+	if viper.IsSet("on-error") {
+		policy, err := ParseOnErrorPolicy(viper.GetString("on-error"))
+		if err != nil {
+			return nil, NewError(ErrorKindValidation, err)
+		}
+
+		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithOnError(policy)
+	}
+
 	p := c.DescribePrinter
 	if viper.GetBool("bulk-output") {
 		p = c.ListPrinter
 		c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithBulkPrint()
 	}
 
-	return p
+	return p, nil
+}
+
+func (c *CmdsConfig[C, U, R]) example(cmd DefaultCmd) string {
+	if example, ok := c.Examples[cmd]; ok {
+		return example
+	}
+
+	var (
+		prefix = fmt.Sprintf("  $ %s %s", c.BinaryName, c.Singular)
+		ids    string
+		file   = c.Singular + ".yaml"
+		lines  []string
+	)
+
+	for _, arg := range c.Args {
+		ids += fmt.Sprintf(" <%s>", arg)
+	}
+
+	switch cmd {
+	case ListCmd:
+		lines = append(lines, prefix+" list")
+		if c.Sorter != nil {
+			if keys := c.Sorter.AvailableKeys(); len(keys) > 0 {
+				lines = append(lines, fmt.Sprintf("%s list --sort-by %s:%s", prefix, keys[0], multisort.Descending))
+			}
+		}
+	case DescribeCmd:
+		lines = append(lines, prefix+" describe"+ids)
+	case CreateCmd:
+		lines = append(lines, fmt.Sprintf("%s create -f %s", prefix, file))
+	case UpdateCmd:
+		if c.UpdateRequestFromCLI != nil {
+			lines = append(lines, prefix+" update"+ids)
+		}
+		lines = append(lines, fmt.Sprintf("%s update -f %s", prefix, file), fmt.Sprintf("%s update%s --patch-file patch.yaml", prefix, ids))
+	case DeleteCmd:
+		lines = append(lines, prefix+" delete"+ids, fmt.Sprintf("%s delete -f %s", prefix, file))
+	case ApplyCmd:
+		lines = append(lines, fmt.Sprintf("%s describe%s -o yaml > %s", prefix, ids, file), fmt.Sprintf("%s apply -f %s", prefix, file))
+	case EditCmd:
+		lines = append(lines, prefix+" edit"+ids)
+	case HistoryCmd:
+		lines = append(lines, prefix+" history")
+	case UndoCmd:
+		lines = append(lines, prefix+" history", prefix+" undo")
+	case LabelCmd:
+		lines = append(lines, prefix+" label"+ids+" env=prod stage-", fmt.Sprintf("%s label -f %s", prefix, file))
+	case AnnotateCmd:
+		lines = append(lines, prefix+" annotate"+ids+" owner=team-a deprecated-", fmt.Sprintf("%s annotate -f %s", prefix, file))
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 func (c *CmdsConfig[C, U, R]) fileFlagHelpText(command string) string {
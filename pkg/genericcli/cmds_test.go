@@ -0,0 +1,61 @@
+package genericcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCmdsConfigExample(t *testing.T) {
+	c := &CmdsConfig[any, any, any]{
+		BinaryName: "metalctl",
+		Singular:   "machine",
+		Args:       []string{"id"},
+		Examples: map[DefaultCmd]string{
+			EditCmd:   "",
+			CreateCmd: "  $ metalctl machine create --name foo",
+		},
+	}
+
+	tests := []struct {
+		cmd  DefaultCmd
+		want string
+	}{
+		{
+			cmd:  ListCmd,
+			want: "  $ metalctl machine list",
+		},
+		{
+			cmd:  DescribeCmd,
+			want: "  $ metalctl machine describe <id>",
+		},
+		{
+			cmd:  CreateCmd,
+			want: "  $ metalctl machine create --name foo",
+		},
+		{
+			cmd:  UpdateCmd,
+			want: "  $ metalctl machine update -f machine.yaml\n  $ metalctl machine update <id> --patch-file patch.yaml",
+		},
+		{
+			cmd:  DeleteCmd,
+			want: "  $ metalctl machine delete <id>\n  $ metalctl machine delete -f machine.yaml",
+		},
+		{
+			cmd:  ApplyCmd,
+			want: "  $ metalctl machine describe <id> -o yaml > machine.yaml\n  $ metalctl machine apply -f machine.yaml",
+		},
+		{
+			cmd:  EditCmd,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.cmd), func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, c.example(tt.cmd)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
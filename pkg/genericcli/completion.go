@@ -0,0 +1,177 @@
+package genericcli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+const defaultCompletionCacheTTL = 30 * time.Second
+
+var completionCacheKeySanitizer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// CompletionConfig contains the configuration for completing entity ids with descriptions.
+type CompletionConfig[R any] struct {
+	// List returns the entities that are offered for completion.
+	List func() ([]R, error)
+	// Describe returns the id of an entity and a short description like the name or status of the entity, which is shown next to the id by the shell.
+	Describe func(r R) (id string, description string)
+
+	// CacheKey enables caching of the completions when not empty. As every completion request starts a new process,
+	// the completions are cached on the file system. The key should be unique for the binary and entity, e.g. "metalctl-machine".
+	CacheKey string
+	// CacheDir is the directory where the completions are cached, defaults to the user's cache directory.
+	CacheDir string
+	// CacheTTL is the duration for which cached completions are used, defaults to 30 seconds.
+	CacheTTL time.Duration
+	// Clock is used for expiring the cache, defaults to the system clock.
+	Clock Clock
+
+	fs afero.Fs
+}
+
+type completionCache struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Completions []string  `json:"completions"`
+}
+
+// CompletionWithDescription returns a completion for the given value with a description as understood by cobra.
+func CompletionWithDescription(value string, description ...string) string {
+	desc := strings.Join(strings.Fields(strings.Join(description, " ")), " ")
+	if desc == "" {
+		return value
+	}
+	return value + "\t" + desc
+}
+
+// DescribedCompletion returns a function that completes entity ids including a description, which can be used as a ValidArgsFunction.
+func DescribedCompletion[R any](c *CompletionConfig[R]) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		completions, err := c.completions()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// DescribedCompletion returns a function that completes entity ids including a description, which can be used as a ValidArgsFunction.
+// If no list function is configured, the list function of this generic cli is used.
+func (a *MultiArgGenericCLI[C, U, R]) DescribedCompletion(c *CompletionConfig[R]) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if c.List == nil {
+		c.List = func() ([]R, error) {
+			return a.List()
+		}
+	}
+	if c.fs == nil {
+		c.fs = a.fs
+	}
+	if c.Clock == nil {
+		c.Clock = a.clock
+	}
+
+	return DescribedCompletion(c)
+}
+
+// DescribedCompletion returns a function that completes entity ids including a description, which can be used as a ValidArgsFunction.
+// If no list function is configured, the list function of this generic cli is used.
+func (a *GenericCLI[C, U, R]) DescribedCompletion(c *CompletionConfig[R]) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return a.multiCLI.DescribedCompletion(c)
+}
+
+func (c *CompletionConfig[R]) completions() ([]string, error) {
+	if c.fs == nil {
+		c.fs = afero.NewOsFs()
+	}
+	if c.CacheTTL <= 0 {
+		c.CacheTTL = defaultCompletionCacheTTL
+	}
+	if c.Clock == nil {
+		c.Clock = realClock{}
+	}
+
+	cachePath := c.cachePath()
+
+	if cachePath != "" {
+		if completions, ok := c.readCache(cachePath); ok {
+			return completions, nil
+		}
+	}
+
+	entities, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var completions []string
+	for _, e := range entities {
+		completions = append(completions, CompletionWithDescription(c.Describe(e)))
+	}
+
+	if cachePath != "" {
+		// failing to write the cache must not break the completion
+		_ = c.writeCache(cachePath, completions)
+	}
+
+	return completions, nil
+}
+
+func (c *CompletionConfig[R]) cachePath() string {
+	if c.CacheKey == "" {
+		return ""
+	}
+
+	dir := c.CacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(userCacheDir, "genericcli-completion")
+	}
+
+	return filepath.Join(dir, completionCacheKeySanitizer.ReplaceAllString(c.CacheKey, "_")+".json")
+}
+
+func (c *CompletionConfig[R]) readCache(path string) ([]string, bool) {
+	raw, err := afero.ReadFile(c.fs, path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache completionCache
+	err = json.Unmarshal(raw, &cache)
+	if err != nil {
+		return nil, false
+	}
+
+	if c.Clock.Now().Sub(cache.Timestamp) > c.CacheTTL {
+		return nil, false
+	}
+
+	return cache.Completions, true
+}
+
+func (c *CompletionConfig[R]) writeCache(path string, completions []string) error {
+	raw, err := json.Marshal(completionCache{
+		Timestamp:   c.Clock.Now(),
+		Completions: completions,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.fs.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(c.fs, path, raw, 0600)
+}
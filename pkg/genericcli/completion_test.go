@@ -0,0 +1,83 @@
+package genericcli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func TestDescribedCompletion(t *testing.T) {
+	var (
+		calls    int
+		clock    = NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+		entities = []*testResponse{{ID: "1", Name: "one"}, {ID: "2", Name: "two\twith tab"}}
+		c        = &CompletionConfig[*testResponse]{
+			List: func() ([]*testResponse, error) {
+				calls++
+				return entities, nil
+			},
+			Describe: func(r *testResponse) (string, string) {
+				return r.ID, r.Name
+			},
+			CacheKey: "test/machine",
+			CacheDir: "/cache",
+			Clock:    clock,
+			fs:       afero.NewMemMapFs(),
+		}
+		fn = DescribedCompletion(c)
+	)
+
+	want := []string{"1\tone", "2\ttwo with tab"}
+
+	for range 2 {
+		got, directive := fn(nil, nil, "")
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("unexpected directive %d", directive)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected list to be called once because of caching, was called %d times", calls)
+	}
+
+	exists, err := afero.Exists(c.fs, "/cache/test_machine.json")
+	if err != nil {
+		t.Error(err)
+	}
+	if !exists {
+		t.Errorf("expected cache file to be written")
+	}
+
+	clock.Advance(defaultCompletionCacheTTL + time.Second)
+
+	_, _ = fn(nil, nil, "")
+	if calls != 2 {
+		t.Errorf("expected list to be called again after the cache expired, was called %d times", calls)
+	}
+}
+
+func TestDescribedCompletionError(t *testing.T) {
+	fn := DescribedCompletion(&CompletionConfig[*testResponse]{
+		List: func() ([]*testResponse, error) {
+			return nil, errors.New("backend unavailable")
+		},
+		Describe: func(r *testResponse) (string, string) {
+			return r.ID, r.Name
+		},
+	})
+
+	got, directive := fn(nil, nil, "")
+	if got != nil {
+		t.Errorf("expected no completions, got %v", got)
+	}
+	if directive != cobra.ShellCompDirectiveError {
+		t.Errorf("unexpected directive %d", directive)
+	}
+}
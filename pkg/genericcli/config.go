@@ -0,0 +1,309 @@
+package genericcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+// Settings are the global settings of a cli, which are not bound to a context, e.g. the default output format.
+type Settings map[string]string
+
+// Setting describes a known key of the global settings.
+type Setting struct {
+	// Key is the name of the setting, e.g. "output-format".
+	Key string
+	// Description is shown in the list command.
+	Description string
+	// Default is the value in effect if the setting is not set.
+	Default string
+	// Values restricts the setting to the given values, which are also used for completion, optional.
+	Values []string
+	// Validate validates a value before it is stored, optional.
+	Validate func(value string) error
+}
+
+// ConfigCmdConfig contains the configuration for the config command.
+type ConfigCmdConfig struct {
+	// File stores the settings, e.g. NewConfigFile[Settings]("~/.metalctl/settings.yaml").
+	File *ConfigFile[Settings]
+	// Settings are the known settings, only these can be set.
+	Settings []Setting
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+}
+
+// NewConfigCmd returns a config command with set, get, unset and list sub commands for the global settings of a cli,
+// which are not bound to a context like the default output format, the color policy or the telemetry opt-in.
+func NewConfigCmd(c *ConfigCmdConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "manage the global settings of the cli",
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "sets a setting",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := c.Set(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.out(), "set %s to %q\n", args[0], args[1])
+
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return c.keyCompletions(), cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				setting, err := c.setting(args[0])
+				if err != nil {
+					return nil, cobra.ShellCompDirectiveError
+				}
+				return setting.Values, cobra.ShellCompDirectiveNoFileComp
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "prints the value of a setting, the default is printed if it is not set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := c.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(c.out(), value)
+
+			return nil
+		},
+		ValidArgsFunction: c.completeKey,
+	}
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "removes a setting, such that the default is in effect",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := c.Unset(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.out(), "unset %s\n", args[0])
+
+			return nil
+		},
+		ValidArgsFunction: c.completeKey,
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "lists all known settings",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := c.list()
+			if err != nil {
+				return err
+			}
+
+			p, err := PrinterFromViper(&PrinterConfig{
+				ToHeaderAndRows: settingsTable,
+				Out:             c.Out,
+			})
+			if err != nil {
+				return err
+			}
+
+			return p.Print(settings)
+		},
+	}
+
+	cmd.AddCommand(setCmd, getCmd, unsetCmd, listCmd, c.File.NewRepairCmd())
+
+	return cmd
+}
+
+// SettingValue is a known setting along with its current value.
+type SettingValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Default     string `json:"default"`
+	IsSet       bool   `json:"is_set"`
+	Description string `json:"description"`
+}
+
+// Get returns the value of the given setting or its default if it is not set, which can be used by the cli to
+// apply the settings, e.g. as defaults for viper.
+func (c *ConfigCmdConfig) Get(key string) (string, error) {
+	setting, err := c.setting(key)
+	if err != nil {
+		return "", err
+	}
+
+	settings, err := c.File.Read()
+	if err != nil {
+		return "", err
+	}
+
+	if value, ok := (*settings)[key]; ok {
+		return value, nil
+	}
+
+	return setting.Default, nil
+}
+
+// Set validates and stores the value of the given setting.
+func (c *ConfigCmdConfig) Set(key, value string) error {
+	setting, err := c.setting(key)
+	if err != nil {
+		return err
+	}
+
+	if len(setting.Values) > 0 && !slices.Contains(setting.Values, value) {
+		return NewError(ErrorKindValidation, fmt.Errorf("invalid value %q for setting %q, must be one of: %v", value, key, setting.Values))
+	}
+	if setting.Validate != nil {
+		err = setting.Validate(value)
+		if err != nil {
+			return NewError(ErrorKindValidation, fmt.Errorf("invalid value %q for setting %q: %w", value, key, err))
+		}
+	}
+
+	settings, err := c.File.Read()
+	if err != nil {
+		return err
+	}
+
+	if *settings == nil {
+		*settings = Settings{}
+	}
+	(*settings)[key] = value
+
+	return c.File.Write(settings)
+}
+
+// Unset removes the value of the given setting, such that its default is in effect.
+func (c *ConfigCmdConfig) Unset(key string) error {
+	_, err := c.setting(key)
+	if err != nil {
+		return err
+	}
+
+	settings, err := c.File.Read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := (*settings)[key]; !ok {
+		return nil
+	}
+
+	delete(*settings, key)
+
+	return c.File.Write(settings)
+}
+
+func (c *ConfigCmdConfig) list() ([]SettingValue, error) {
+	settings, err := c.File.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []SettingValue
+	for _, s := range c.Settings {
+		value, ok := (*settings)[s.Key]
+		if !ok {
+			value = s.Default
+		}
+
+		res = append(res, SettingValue{
+			Key:         s.Key,
+			Value:       value,
+			Default:     s.Default,
+			IsSet:       ok,
+			Description: s.Description,
+		})
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res, nil
+}
+
+func (c *ConfigCmdConfig) setting(key string) (*Setting, error) {
+	for _, s := range c.Settings {
+		if s.Key == key {
+			return &s, nil
+		}
+	}
+
+	return nil, NewError(ErrorKindNotFound, fmt.Errorf("unknown setting %q, must be one of: %v", key, c.keyCompletions()))
+}
+
+func (c *ConfigCmdConfig) keyCompletions() []string {
+	var keys []string
+	for _, s := range c.Settings {
+		keys = append(keys, s.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *ConfigCmdConfig) completeKey(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return c.keyCompletions(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func (c *ConfigCmdConfig) out() io.Writer {
+	if c.Out == nil {
+		return os.Stdout
+	}
+	return c.Out
+}
+
+func settingsTable(data any, wide bool) ([]string, [][]string, error) {
+	settings, ok := data.([]SettingValue)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported type for settings table: %T", data)
+	}
+
+	header := []string{"Key", "Value", "Default"}
+	if wide {
+		header = append(header, "Description")
+	}
+
+	var rows [][]string
+	for _, s := range settings {
+		value := s.Value
+		if !s.IsSet {
+			value = ""
+		}
+
+		row := []string{s.Key, value, s.Default}
+		if wide {
+			row = append(row, s.Description)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
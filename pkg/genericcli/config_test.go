@@ -0,0 +1,95 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCmd(t *testing.T) {
+	var (
+		fs  = afero.NewMemMapFs()
+		out bytes.Buffer
+		c   = &ConfigCmdConfig{
+			File: NewConfigFile[Settings]("/home/.cli/settings.yaml").WithFS(fs),
+			Settings: []Setting{
+				{Key: "output-format", Default: "table", Values: []string{"table", "wide", "yaml", "json"}, Description: "the default output format"},
+				{Key: "telemetry", Default: "false", Validate: func(value string) error {
+					_, err := strconv.ParseBool(value)
+					return err
+				}},
+			},
+			Out: &out,
+		}
+	)
+
+	run := func(args ...string) error {
+		out.Reset()
+		cmd := NewConfigCmd(c)
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	require.NoError(t, run("get", "output-format"))
+	require.Equal(t, "table\n", out.String(), "the default is returned if not set")
+
+	require.NoError(t, run("set", "output-format", "yaml"))
+	require.NoError(t, run("set", "telemetry", "true"))
+	require.NoError(t, run("get", "output-format"))
+	require.Equal(t, "yaml\n", out.String())
+
+	raw, err := afero.ReadFile(fs, "/home/.cli/settings.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "output-format: yaml\ntelemetry: \"true\"\n", string(raw))
+
+	err = run("set", "output-format", "xml")
+	require.EqualError(t, err, `invalid value "xml" for setting "output-format", must be one of: [table wide yaml json]`)
+	var cliErr *Error
+	require.True(t, errors.As(err, &cliErr))
+
+	err = run("set", "telemetry", "maybe")
+	require.EqualError(t, err, `invalid value "maybe" for setting "telemetry": strconv.ParseBool: parsing "maybe": invalid syntax`)
+
+	err = run("set", "color", "always")
+	require.EqualError(t, err, `unknown setting "color", must be one of: [output-format telemetry]`)
+
+	require.NoError(t, run("unset", "output-format"))
+	value, err := c.Get("output-format")
+	require.NoError(t, err)
+	require.Equal(t, "table", value)
+
+	viper.Set("output-format", "table")
+	defer viper.Reset()
+
+	require.NoError(t, run("list"))
+	require.Equal(t, "KEY             VALUE   DEFAULT \noutput-format           table     \ntelemetry       true    false     \n", out.String())
+}
+
+func TestConfigCmdCompletion(t *testing.T) {
+	c := &ConfigCmdConfig{
+		File: NewConfigFile[Settings]("/settings.yaml").WithFS(afero.NewMemMapFs()),
+		Settings: []Setting{
+			{Key: "telemetry", Values: []string{"true", "false"}},
+			{Key: "output-format"},
+		},
+	}
+
+	cmd := NewConfigCmd(c)
+	setCmd, _, err := cmd.Find([]string{"set"})
+	require.NoError(t, err)
+
+	keys, directive := setCmd.ValidArgsFunction(setCmd, nil, "")
+	require.Equal(t, []string{"output-format", "telemetry"}, keys)
+	require.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	values, _ := setCmd.ValidArgsFunction(setCmd, []string{"telemetry"}, "")
+	require.Equal(t, []string{"true", "false"}, values)
+}
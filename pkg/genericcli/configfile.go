@@ -0,0 +1,219 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrConfigCorrupted is returned when a config file cannot be parsed anymore, e.g. because it was truncated.
+var ErrConfigCorrupted = errors.New("config file is corrupted")
+
+// ConfigFile reads and writes a yaml config file of a CLI like the context configuration.
+//
+// Before every write, the current config file is backed up to <path>.bak and the new content is written atomically.
+// If the config file turns out to be corrupted on read, the user is offered to restore it from the backup.
+//
+// T is the type of the configuration.
+type ConfigFile[T any] struct {
+	fs   afero.Fs
+	path string
+	in   io.Reader
+	out  io.Writer
+
+	isTerminal func(in io.Reader) bool
+}
+
+func NewConfigFile[T any](path string) *ConfigFile[T] {
+	return &ConfigFile[T]{
+		fs:   afero.NewOsFs(),
+		path: path,
+		in:   os.Stdin,
+		out:  os.Stdout,
+
+		isTerminal: func(in io.Reader) bool {
+			f, ok := in.(*os.File)
+			return ok && isatty.IsTerminal(f.Fd())
+		},
+	}
+}
+
+func (c *ConfigFile[T]) WithFS(fs afero.Fs) *ConfigFile[T] {
+	c.fs = fs
+	return c
+}
+
+// WithPrompt defines where the restore prompt is read from and written to, defaults to stdin and stdout.
+func (c *ConfigFile[T]) WithPrompt(in io.Reader, out io.Writer) *ConfigFile[T] {
+	c.in = in
+	c.out = out
+	return c
+}
+
+// BackupPath returns the path of the backup file.
+func (c *ConfigFile[T]) BackupPath() string {
+	return c.path + ".bak"
+}
+
+// Read reads the config file. A non-existing config file results in an empty configuration.
+// If the config file is corrupted and a valid backup exists, the user is asked to restore the backup when running in a terminal.
+func (c *ConfigFile[T]) Read() (*T, error) {
+	config, err := c.read(c.path)
+	if err == nil {
+		return config, nil
+	}
+	if !errors.Is(err, ErrConfigCorrupted) {
+		return nil, err
+	}
+
+	if _, backupErr := c.read(c.BackupPath()); backupErr != nil {
+		return nil, fmt.Errorf("%w, no valid backup found to restore from: %w", err, backupErr)
+	}
+
+	if !c.isTerminal(c.in) {
+		return nil, fmt.Errorf("%w, a backup can be restored with the repair command", err)
+	}
+
+	promptErr := PromptCustom(&PromptConfig{
+		Message:     fmt.Sprintf("%s, do you want to restore it from %s?", err, c.BackupPath()),
+		ShowAnswers: true,
+		In:          c.in,
+		Out:         c.out,
+	})
+	if promptErr != nil {
+		return nil, err
+	}
+
+	err = c.Restore()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.read(c.path)
+}
+
+// Write backs up the current config file if it is valid and writes the given configuration atomically.
+func (c *ConfigFile[T]) Write(config *T) error {
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.read(c.path); err == nil {
+		if exists, _ := afero.Exists(c.fs, c.path); exists {
+			err = c.copy(c.path, c.BackupPath())
+			if err != nil {
+				return fmt.Errorf("unable to backup config file: %w", err)
+			}
+		}
+	}
+
+	return c.writeAtomic(c.path, raw)
+}
+
+// Restore overwrites the config file with the backup if the backup is valid.
+func (c *ConfigFile[T]) Restore() error {
+	_, err := c.read(c.BackupPath())
+	if err != nil {
+		return fmt.Errorf("unable to restore from backup: %w", err)
+	}
+
+	return c.copy(c.BackupPath(), c.path)
+}
+
+// NewRepairCmd returns a command that restores the config file from the backup, which can be added e.g. as "context repair".
+func (c *ConfigFile[T]) NewRepairCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "restores a corrupted config file from its backup",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := c.read(c.path)
+			if err == nil {
+				fmt.Fprintf(c.out, "config file %s is valid, nothing to repair\n", c.path)
+				return nil
+			}
+
+			if !viper.GetBool("yes") {
+				err = PromptCustom(&PromptConfig{
+					Message:     fmt.Sprintf("%s, do you want to restore it from %s?", err, c.BackupPath()),
+					ShowAnswers: true,
+					In:          c.in,
+					Out:         c.out,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			err = c.Restore()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(c.out, "restored config file %s from backup\n", c.path)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("yes", false, "restores the config file without asking for confirmation")
+
+	return cmd
+}
+
+func (c *ConfigFile[T]) read(path string) (*T, error) {
+	var config T
+
+	raw, err := afero.ReadFile(c.fs, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && path == c.path {
+			return &config, nil
+		}
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrConfigCorrupted, path)
+	}
+
+	err = yaml.UnmarshalStrict(raw, &config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrConfigCorrupted, path, err)
+	}
+
+	return &config, nil
+}
+
+func (c *ConfigFile[T]) copy(from, to string) error {
+	raw, err := afero.ReadFile(c.fs, from)
+	if err != nil {
+		return err
+	}
+
+	return c.writeAtomic(to, raw)
+}
+
+func (c *ConfigFile[T]) writeAtomic(path string, raw []byte) error {
+	err := c.fs.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	err = afero.WriteFile(c.fs, tmp, raw, 0600)
+	if err != nil {
+		return err
+	}
+
+	return c.fs.Rename(tmp, path)
+}
@@ -0,0 +1,187 @@
+package genericcli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+type testContextConfig struct {
+	CurrentContext string            `json:"current"`
+	Contexts       map[string]string `json:"contexts"`
+}
+
+func TestConfigFile(t *testing.T) {
+	const path = "/home/.cli/config.yaml"
+
+	var (
+		fs  = afero.NewMemMapFs()
+		out bytes.Buffer
+		c   = NewConfigFile[testContextConfig](path).WithFS(fs).WithPrompt(&bytes.Buffer{}, &out)
+	)
+
+	config, err := c.Read()
+	require.NoError(t, err)
+	require.Equal(t, &testContextConfig{}, config, "a missing config file results in an empty config")
+
+	first := &testContextConfig{CurrentContext: "a", Contexts: map[string]string{"a": "https://a"}}
+	second := &testContextConfig{CurrentContext: "b", Contexts: map[string]string{"a": "https://a", "b": "https://b"}}
+
+	require.NoError(t, c.Write(first))
+	require.NoError(t, c.Write(second))
+
+	raw, err := afero.ReadFile(fs, c.BackupPath())
+	require.NoError(t, err)
+	require.Equal(t, "contexts:\n  a: https://a\ncurrent: a\n", string(raw))
+
+	// simulate a truncated write
+	require.NoError(t, afero.WriteFile(fs, path, []byte("contexts:\n  a: https://a\ncurr"), 0600))
+
+	_, err = c.Read()
+	require.ErrorIs(t, err, ErrConfigCorrupted)
+	require.ErrorContains(t, err, "a backup can be restored with the repair command")
+
+	// writing over a corrupted config file must keep the last valid backup
+	require.NoError(t, c.Write(second))
+	require.NoError(t, afero.WriteFile(fs, path, []byte{}, 0600))
+
+	raw, err = afero.ReadFile(fs, c.BackupPath())
+	require.NoError(t, err)
+	require.Equal(t, "contexts:\n  a: https://a\ncurrent: a\n", string(raw))
+
+	require.NoError(t, c.Restore())
+
+	config, err = c.Read()
+	require.NoError(t, err)
+	require.Equal(t, first, config)
+}
+
+func TestConfigFileRestorePrompt(t *testing.T) {
+	const path = "/home/.cli/config.yaml"
+
+	valid := &testContextConfig{CurrentContext: "a", Contexts: map[string]string{"a": "https://a"}}
+
+	tests := []struct {
+		name       string
+		input      string
+		want       *testContextConfig
+		wantErr    string
+		wantPrompt bool
+	}{
+		{
+			name:       "restore confirmed",
+			input:      "y\n",
+			want:       valid,
+			wantPrompt: true,
+		},
+		{
+			name:       "restore declined",
+			input:      "n\n",
+			wantErr:    "config file is corrupted: /home/.cli/config.yaml is empty",
+			wantPrompt: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				fs  = afero.NewMemMapFs()
+				out bytes.Buffer
+				c   = NewConfigFile[testContextConfig](path).WithFS(fs).WithPrompt(strings.NewReader(tt.input), &out)
+			)
+			c.isTerminal = func(io.Reader) bool { return true }
+
+			require.NoError(t, c.Write(valid))
+			require.NoError(t, c.Write(valid))
+			require.NoError(t, afero.WriteFile(fs, path, []byte{}, 0600))
+
+			config, err := c.Read()
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, ErrConfigCorrupted)
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, config)
+			require.Equal(t, tt.wantPrompt, strings.Contains(out.String(), "do you want to restore it from /home/.cli/config.yaml.bak?"))
+		})
+	}
+}
+
+func TestConfigFileRepairCmd(t *testing.T) {
+	const path = "/home/.cli/config.yaml"
+
+	valid := &testContextConfig{CurrentContext: "a", Contexts: map[string]string{"a": "https://a"}}
+
+	tests := []struct {
+		name    string
+		corrupt bool
+		yes     bool
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "nothing to repair",
+			want: "config file /home/.cli/config.yaml is valid, nothing to repair\n",
+		},
+		{
+			name:    "repair without confirmation",
+			corrupt: true,
+			yes:     true,
+			want:    "restored config file /home/.cli/config.yaml from backup\n",
+		},
+		{
+			name:    "repair confirmed",
+			corrupt: true,
+			input:   "y\n",
+			want:    "config file is corrupted: /home/.cli/config.yaml is empty, do you want to restore it from /home/.cli/config.yaml.bak? [Y/n] restored config file /home/.cli/config.yaml from backup\n",
+		},
+		{
+			name:    "repair declined",
+			corrupt: true,
+			input:   "n\n",
+			want:    "config file is corrupted: /home/.cli/config.yaml is empty, do you want to restore it from /home/.cli/config.yaml.bak? [Y/n] ",
+			wantErr: `aborting due to given answer ("n")`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("yes", tt.yes)
+			defer viper.Reset()
+
+			var (
+				fs  = afero.NewMemMapFs()
+				out bytes.Buffer
+				c   = NewConfigFile[testContextConfig](path).WithFS(fs).WithPrompt(strings.NewReader(tt.input), &out)
+			)
+
+			require.NoError(t, c.Write(valid))
+			require.NoError(t, c.Write(valid))
+			if tt.corrupt {
+				require.NoError(t, afero.WriteFile(fs, path, []byte{}, 0600))
+			}
+
+			cmd := c.NewRepairCmd()
+			cmd.SetArgs([]string{})
+
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+
+				config, err := c.Read()
+				require.NoError(t, err)
+				require.Equal(t, valid, config)
+			}
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/viper"
+)
+
+// ConfirmPolicy defines which default commands ask for an interactive confirmation before they are executed.
+type ConfirmPolicy string
+
+const (
+	// ConfirmNever never asks for confirmation, not even for bulk operations.
+	ConfirmNever ConfirmPolicy = "never"
+	// ConfirmAlways asks for confirmation for all commands that modify entities.
+	ConfirmAlways ConfirmPolicy = "always"
+	// ConfirmDestructiveOnly asks for confirmation before entities are deleted or restored from the history,
+	// both for single and bulk operations.
+	ConfirmDestructiveOnly ConfirmPolicy = "destructive-only"
+)
+
+var errConfirmationRequired = errors.New("confirmation required but input is not a terminal, use --yes to confirm non-interactively")
+
+// confirmRequired returns true if the given command needs to be confirmed according to the confirm policy and the --yes flag.
+// Without a confirm policy only bulk operations are confirmed, which can be skipped with --skip-security-prompts.
+func (c *CmdsConfig[C, U, R]) confirmRequired(cmd DefaultCmd, bulk bool) bool {
+	if viper.GetBool("yes") || viper.GetBool("skip-security-prompts") {
+		return false
+	}
+
+	switch c.ConfirmPolicy {
+	case ConfirmNever:
+		return false
+	case ConfirmAlways:
+		return true
+	case ConfirmDestructiveOnly:
+		return cmd == DeleteCmd || cmd == UndoCmd
+	default:
+		return bulk
+	}
+}
+
+// ensureInteractive returns an error if a confirm policy is configured and the input is not a terminal,
+// such that confirmations cannot be bypassed by accident, e.g. when running in a pipeline.
+// If ConfirmSkipNonInteractive is set, false is returned instead and the confirmation is skipped.
+func (c *CmdsConfig[C, U, R]) ensureInteractive() (bool, error) {
+	if c.ConfirmPolicy == "" {
+		return true, nil
+	}
+
+	var in io.Reader = os.Stdin
+	if c.In != nil {
+		in = c.In
+	}
+
+	if f, ok := in.(*os.File); ok && !isatty.IsTerminal(f.Fd()) {
+		if c.ConfirmSkipNonInteractive {
+			return false, nil
+		}
+		return false, errConfirmationRequired
+	}
+
+	return true, nil
+}
+
+// confirm asks the user to confirm the given command on the entity with the given id.
+func (c *CmdsConfig[C, U, R]) confirm(cmd DefaultCmd, id ...string) error {
+	return c.confirmWithPreview(cmd, nil, id...)
+}
+
+// confirmWithPreview asks the user to confirm the given command like confirm. If ConfirmShowEntity is set,
+// the yaml returned by the preview func is shown along with the question, such that the user can verify
+// that the right entity is affected.
+func (c *CmdsConfig[C, U, R]) confirmWithPreview(cmd DefaultCmd, preview func() ([]byte, error), id ...string) error {
+	if !c.confirmRequired(cmd, false) {
+		return nil
+	}
+
+	interactive, err := c.ensureInteractive()
+	if err != nil {
+		return err
+	}
+	if !interactive {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s %s", cmd, c.Singular)
+	if len(id) > 0 {
+		message += fmt.Sprintf(" %q", strings.Join(id, "/"))
+	}
+	message += ", continue?"
+
+	if c.ConfirmShowEntity && preview != nil {
+		raw, err := preview()
+		if err != nil {
+			return err
+		}
+
+		message = fmt.Sprintf("%s\n\n%s\n\n", message, PrintColoredYAML(raw))
+	}
+
+	return PromptCustom(&PromptConfig{
+		Message:         message,
+		ShowAnswers:     true,
+		AcceptedAnswers: PromptDefaultAnswers(),
+		DefaultAnswer:   "n",
+		No:              "n",
+		In:              c.In,
+		Out:             c.Out,
+	})
+}
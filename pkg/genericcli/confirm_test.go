@@ -0,0 +1,124 @@
+package genericcli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmRequired(t *testing.T) {
+	tests := []struct {
+		policy ConfirmPolicy
+		cmd    DefaultCmd
+		bulk   bool
+		want   bool
+	}{
+		{policy: "", cmd: DeleteCmd, bulk: false, want: false},
+		{policy: "", cmd: DeleteCmd, bulk: true, want: true},
+		{policy: ConfirmNever, cmd: DeleteCmd, bulk: true, want: false},
+		{policy: ConfirmAlways, cmd: CreateCmd, bulk: false, want: true},
+		{policy: ConfirmDestructiveOnly, cmd: DeleteCmd, bulk: false, want: true},
+		{policy: ConfirmDestructiveOnly, cmd: ApplyCmd, bulk: true, want: false},
+		{policy: ConfirmDestructiveOnly, cmd: UndoCmd, bulk: false, want: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("%s %s bulk=%t", tt.policy, tt.cmd, tt.bulk), func(t *testing.T) {
+			c := &CmdsConfig[any, any, any]{ConfirmPolicy: tt.policy}
+			require.Equal(t, tt.want, c.confirmRequired(tt.cmd, tt.bulk))
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "confirmed",
+			input: "y\n",
+			want:  `delete machine "m1", continue? [y/N] `,
+		},
+		{
+			name:    "declined by default",
+			input:   "\n",
+			want:    `delete machine "m1", continue? [y/N] `,
+			wantErr: fmt.Errorf(`aborting due to given answer ("n")`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			c := &CmdsConfig[any, any, any]{
+				Singular:      "machine",
+				ConfirmPolicy: ConfirmDestructiveOnly,
+				In:            strings.NewReader(tt.input),
+				Out:           &out,
+			}
+
+			err := c.confirm(DeleteCmd, "m1")
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestConfirmWithPreview(t *testing.T) {
+	var out bytes.Buffer
+
+	c := &CmdsConfig[any, any, any]{
+		Singular:          "machine",
+		ConfirmPolicy:     ConfirmDestructiveOnly,
+		ConfirmShowEntity: true,
+		In:                strings.NewReader("y\n"),
+		Out:               &out,
+	}
+
+	err := c.confirmWithPreview(DeleteCmd, func() ([]byte, error) {
+		return []byte("id: m1\n"), nil
+	}, "m1")
+	require.NoError(t, err)
+	require.Contains(t, out.String(), `delete machine "m1", continue?`)
+	require.Contains(t, out.String(), "m1")
+
+	c.In = strings.NewReader("y\n")
+	err = c.confirmWithPreview(DeleteCmd, func() ([]byte, error) {
+		return nil, fmt.Errorf("machine m1 not found")
+	}, "m1")
+	require.EqualError(t, err, "machine m1 not found")
+}
+
+func TestConfirmNonInteractive(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "input")
+	require.NoError(t, err)
+	defer f.Close()
+
+	c := &CmdsConfig[any, any, any]{
+		Singular:      "machine",
+		ConfirmPolicy: ConfirmDestructiveOnly,
+		In:            f,
+	}
+
+	err = c.confirm(DeleteCmd, "m1")
+	require.ErrorIs(t, err, errConfirmationRequired)
+
+	c.ConfirmSkipNonInteractive = true
+
+	err = c.confirm(DeleteCmd, "m1")
+	require.NoError(t, err)
+}
@@ -0,0 +1,144 @@
+package genericcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// ConsoleSymbols are the symbols that a cli prints for signaling the outcome of an operation.
+type ConsoleSymbols struct {
+	Success string
+	Failure string
+	Warning string
+}
+
+var (
+	unicodeSymbols = ConsoleSymbols{
+		Success: "✔",
+		Failure: "✗",
+		Warning: "⚠",
+	}
+	asciiSymbols = ConsoleSymbols{
+		Success: "v",
+		Failure: "x",
+		Warning: "!",
+	}
+)
+
+// Console describes the capabilities of the terminal a cli is running in.
+type Console struct {
+	// Unicode is true if the console is able to render unicode characters.
+	Unicode bool
+	// Color is true if the console is able to render colors.
+	Color bool
+}
+
+// consoleEnv abstracts the environment that is used to detect the console capabilities.
+type consoleEnv struct {
+	goos    string
+	getenv  func(string) string
+	noColor bool
+}
+
+// DetectConsole detects the capabilities of the console, such that clis can fall back to plain characters
+// on terminals that garble unicode characters or colors, e.g. the legacy windows console.
+func DetectConsole() *Console {
+	return consoleEnv{
+		goos:    runtime.GOOS,
+		getenv:  os.Getenv,
+		noColor: color.NoColor,
+	}.detect()
+}
+
+func (e consoleEnv) detect() *Console {
+	if e.getenv("TERM") == "dumb" {
+		return &Console{}
+	}
+
+	return &Console{
+		Unicode: e.unicode(),
+		Color:   !e.noColor && e.getenv("NO_COLOR") == "",
+	}
+}
+
+func (e consoleEnv) unicode() bool {
+	if e.goos == "windows" {
+		// the legacy console host does not render unicode symbols with its default fonts,
+		// only modern terminals set these variables
+		return e.getenv("WT_SESSION") != "" || e.getenv("TERM_PROGRAM") != "" || strings.EqualFold(e.getenv("ConEmuANSI"), "ON")
+	}
+
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := e.getenv(key); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+
+	// most terminals on non-windows platforms support unicode, even without a locale
+	return true
+}
+
+// Symbols returns the symbols that can be rendered by the console.
+func (c *Console) Symbols() ConsoleSymbols {
+	if c.Unicode {
+		return unicodeSymbols
+	}
+	return asciiSymbols
+}
+
+// Success returns the success symbol, green if the console supports colors.
+func (c *Console) Success() string {
+	return c.colorize(c.Symbols().Success, color.FgGreen)
+}
+
+// Failure returns the failure symbol, red if the console supports colors.
+func (c *Console) Failure() string {
+	return c.colorize(c.Symbols().Failure, color.FgRed)
+}
+
+// Warning returns the warning symbol, yellow if the console supports colors.
+func (c *Console) Warning() string {
+	return c.colorize(c.Symbols().Warning, color.FgYellow)
+}
+
+func (c *Console) colorize(s string, attr color.Attribute) string {
+	if !c.Color {
+		return s
+	}
+
+	col := color.New(attr)
+	col.EnableColor()
+
+	return col.Sprint(s)
+}
+
+// DefaultConfigDir returns the default config directory of the cli with the given name,
+// which is %APPDATA%\<name> on windows and ~/.<name> on other platforms.
+func DefaultConfigDir(name string) (string, error) {
+	return defaultConfigDir(runtime.GOOS, os.Getenv, os.UserHomeDir, name)
+}
+
+func defaultConfigDir(goos string, getenv func(string) string, homeDir func() (string, error), name string) (string, error) {
+	if goos == "windows" {
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, name), nil
+		}
+	}
+
+	home, err := homeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine home directory: %w", err)
+	}
+
+	if goos == "windows" {
+		return filepath.Join(home, "AppData", "Roaming", name), nil
+	}
+
+	return filepath.Join(home, "."+name), nil
+}
@@ -0,0 +1,94 @@
+package genericcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectConsole(t *testing.T) {
+	tests := []struct {
+		name    string
+		goos    string
+		env     map[string]string
+		noColor bool
+		want    *Console
+	}{
+		{
+			name: "linux with utf-8 locale",
+			goos: "linux",
+			env:  map[string]string{"LANG": "en_US.UTF-8"},
+			want: &Console{Unicode: true, Color: true},
+		},
+		{
+			name:    "linux with posix locale and no color",
+			goos:    "linux",
+			env:     map[string]string{"LC_ALL": "C", "LANG": "en_US.UTF-8"},
+			noColor: true,
+			want:    &Console{Unicode: false, Color: false},
+		},
+		{
+			name: "dumb terminal",
+			goos: "darwin",
+			env:  map[string]string{"TERM": "dumb"},
+			want: &Console{},
+		},
+		{
+			name: "legacy windows console",
+			goos: "windows",
+			want: &Console{Unicode: false, Color: true},
+		},
+		{
+			name: "windows terminal",
+			goos: "windows",
+			env:  map[string]string{"WT_SESSION": "4711", "NO_COLOR": "1"},
+			want: &Console{Unicode: true, Color: false},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := consoleEnv{
+				goos: tt.goos,
+				getenv: func(key string) string {
+					return tt.env[key]
+				},
+				noColor: tt.noColor,
+			}.detect()
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestConsoleSymbols(t *testing.T) {
+	require.Equal(t, "✔", (&Console{Unicode: true}).Success())
+	require.Equal(t, "x", (&Console{}).Failure())
+	require.Equal(t, "\x1b[33m!\x1b[0m", (&Console{Color: true}).Warning())
+}
+
+func TestDefaultConfigDir(t *testing.T) {
+	homeDir := func() (string, error) {
+		return "/home/metal", nil
+	}
+
+	got, err := defaultConfigDir("linux", func(string) string { return "" }, homeDir, "metalctl")
+	require.NoError(t, err)
+	require.Equal(t, "/home/metal/.metalctl", got)
+
+	got, err = defaultConfigDir("windows", func(key string) string {
+		if key == "APPDATA" {
+			return "/appdata"
+		}
+		return ""
+	}, homeDir, "metalctl")
+	require.NoError(t, err)
+	require.Equal(t, "/appdata/metalctl", got)
+
+	got, err = defaultConfigDir("windows", func(string) string { return "" }, homeDir, "metalctl")
+	require.NoError(t, err)
+	require.Equal(t, "/home/metal/AppData/Roaming/metalctl", got)
+}
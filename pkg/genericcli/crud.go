@@ -6,6 +6,7 @@ import (
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/metal-stack/metal-lib/pkg/multisort"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func GetExactlyOneArg(args []string) (string, error) {
@@ -35,7 +36,12 @@ func GetExactlyNArgs(n int, args []string) ([]string, error) {
 }
 
 func (a *MultiArgGenericCLI[C, U, R]) List(sortKeys ...multisort.Key) ([]R, error) {
-	resp, err := a.crud.List()
+	resp, err := cachedRead(a.readCache, a.fs, a.clock, a.readCache.listKey(), func() ([]R, error) {
+		end := a.startSpan("list")
+		resp, _, err := retry(a.retry, func() ([]R, error) { return a.crud.List() })
+		end(err)
+		return resp, err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +55,22 @@ func (a *MultiArgGenericCLI[C, U, R]) List(sortKeys ...multisort.Key) ([]R, erro
 	return resp, nil
 }
 
+// ListAndPrint lists and prints the entities.
+//
+// In case the printer is a stream printer, the CRUD implementation is a ListStreamer and no sort keys are given, the entities are printed
+// one by one as they arrive without holding the entire list in memory. Note that streamed entities are not sorted.
 func (a *MultiArgGenericCLI[C, U, R]) ListAndPrint(p printers.Printer, sortKeys ...multisort.Key) error {
+	if sp, ok := p.(printers.StreamPrinter); ok && len(sortKeys) == 0 && a.readCache == nil {
+		if streamer, ok := a.listStreamer(); ok {
+			end := a.startSpan("list", attribute.Bool("stream", true))
+			err := streamer.ListStream(func(r R) error {
+				return sp.PrintElement(r)
+			})
+			end(err)
+			return err
+		}
+	}
+
 	resp, err := a.List(sortKeys...)
 	if err != nil {
 		return err
@@ -58,10 +79,25 @@ func (a *MultiArgGenericCLI[C, U, R]) ListAndPrint(p printers.Printer, sortKeys
 	return p.Print(resp)
 }
 
+func (a *MultiArgGenericCLI[C, U, R]) listStreamer() (ListStreamer[R], bool) {
+	if mapper, ok := a.crud.(multiArgMapper[C, U, R]); ok {
+		streamer, ok := mapper.singleArg.(ListStreamer[R])
+		return streamer, ok
+	}
+
+	streamer, ok := a.crud.(ListStreamer[R])
+	return streamer, ok
+}
+
 func (a *MultiArgGenericCLI[C, U, R]) Describe(id ...string) (R, error) {
 	var zero R
 
-	resp, err := a.crud.Get(id...)
+	resp, err := cachedRead(a.readCache, a.fs, a.clock, a.readCache.describeKey(id...), func() (R, error) {
+		end := a.startSpan("get", attribute.StringSlice("id", id))
+		resp, _, err := retry(a.retry, func() (R, error) { return a.crud.Get(id...) })
+		end(err)
+		return resp, err
+	})
 	if err != nil {
 		return zero, err
 	}
@@ -81,11 +117,15 @@ func (a *MultiArgGenericCLI[C, U, R]) DescribeAndPrint(p printers.Printer, id ..
 func (a *MultiArgGenericCLI[C, U, R]) Delete(id ...string) (R, error) {
 	var zero R
 
-	resp, err := a.crud.Delete(id...)
+	end := a.startSpan("delete", attribute.StringSlice("id", id))
+	resp, _, err := retry(a.retry, func() (R, error) { return a.crud.Delete(id...) })
+	end(err)
 	if err != nil {
 		return zero, err
 	}
 
+	a.recordHistory(HistoryOperationDelete, id, resp)
+
 	return resp, nil
 }
 
@@ -101,7 +141,9 @@ func (a *MultiArgGenericCLI[C, U, R]) DeleteAndPrint(p printers.Printer, id ...s
 func (a *MultiArgGenericCLI[C, U, R]) Create(rq C) (R, error) {
 	var zero R
 
-	resp, err := a.crud.Create(rq)
+	end := a.startSpan("create")
+	resp, _, err := retry(a.retry, func() (R, error) { return a.crud.Create(rq) })
+	end(err)
 	if err != nil {
 		return zero, err
 	}
@@ -121,7 +163,9 @@ func (a *MultiArgGenericCLI[C, U, R]) CreateAndPrint(rq C, p printers.Printer) e
 func (a *MultiArgGenericCLI[C, U, R]) Update(rq U) (R, error) {
 	var zero R
 
-	resp, err := a.crud.Update(rq)
+	end := a.startSpan("update")
+	resp, _, err := retry(a.retry, func() (R, error) { return a.crud.Update(rq) })
+	end(err)
 	if err != nil {
 		return zero, err
 	}
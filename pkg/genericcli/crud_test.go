@@ -0,0 +1,44 @@
+package genericcli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+)
+
+type streamingTestCRUD struct {
+	testCRUD
+	items []*testResponse
+}
+
+func (s streamingTestCRUD) ListStream(fn func(*testResponse) error) error {
+	for _, item := range s.items {
+		err := fn(item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestListAndPrintStreams(t *testing.T) {
+	var (
+		out  bytes.Buffer
+		crud = streamingTestCRUD{items: []*testResponse{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}}
+		cli  = NewGenericMultiArgCLI[*testCreate, *testUpdate, *testResponse](crud)
+	)
+
+	err := cli.ListAndPrint(printers.NewNDJSONPrinter().WithOut(&out))
+	if err != nil {
+		t.Error(err)
+	}
+
+	want := `{"id":"1","name":"one"}
+{"id":"2","name":"two"}
+`
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
@@ -0,0 +1,114 @@
+package genericcli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
+	"sigs.k8s.io/yaml"
+)
+
+// YamlDiff returns a colored unified diff between the yaml representations of the current and the desired entity.
+// If current is nil, the entire desired entity is shown as an addition. An empty string is returned if both are equal.
+func YamlDiff(current, desired any) (string, error) {
+	var currentRaw []byte
+	if current != nil {
+		var err error
+		currentRaw, err = yaml.Marshal(current)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	desiredRaw, err := yaml.Marshal(desired)
+	if err != nil {
+		return "", err
+	}
+
+	return unifiedYamlDiff(currentRaw, desiredRaw)
+}
+
+func unifiedYamlDiff(current, desired []byte) (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(current)),
+		B:        splitLines(string(desired)),
+		FromFile: "current",
+		ToFile:   "desired",
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return colorizeDiff(diff), nil
+}
+
+// splitLines splits the given text into lines keeping their line breaks, in contrast to difflib.SplitLines
+// no additional empty line is appended.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func colorizeDiff(diff string) string {
+	var (
+		added   = color.New(color.FgHiGreen).SprintFunc()
+		removed = color.New(color.FgHiRed).SprintFunc()
+		hunk    = color.New(color.FgHiCyan).SprintFunc()
+		lines   = strings.SplitAfter(diff, "\n")
+	)
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			// file headers are not colored
+		case strings.HasPrefix(line, "+"):
+			lines[i] = added(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removed(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = hunk(line)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+func printDiff(out io.Writer, id []string, diff string) {
+	if diff == "" {
+		fmt.Fprintf(out, "no changes for %q\n", strings.Join(id, "/"))
+		return
+	}
+
+	fmt.Fprintf(out, "changes for %q:\n\n%s\n", strings.Join(id, "/"), diff)
+}
+
+// diffCallback prints the diff between the entity on the server-side and the entity from the file.
+// If the entity cannot be retrieved, it is considered to be created.
+func (a *MultiArgGenericCLI[C, U, R]) diffCallback(out io.Writer) func(R) error {
+	return func(r R) error {
+		id, _, _, err := a.crud.Convert(r)
+		if err != nil {
+			return err
+		}
+
+		var current any
+		if existing, err := a.crud.Get(id...); err == nil {
+			current = existing
+		}
+
+		diff, err := YamlDiff(current, r)
+		if err != nil {
+			return err
+		}
+
+		printDiff(out, id, diff)
+
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+package genericcli
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestYamlDiff(t *testing.T) {
+	color.NoColor = true
+
+	type entity struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name    string
+		current any
+		desired any
+		want    string
+	}{
+		{
+			name:    "equal",
+			current: entity{ID: "1", Name: "a"},
+			desired: entity{ID: "1", Name: "a"},
+			want:    "",
+		},
+		{
+			name:    "changed",
+			current: entity{ID: "1", Name: "a"},
+			desired: entity{ID: "1", Name: "b"},
+			want: `--- current
++++ desired
+@@ -1,2 +1,2 @@
+ id: "1"
+-name: a
++name: b
+`,
+		},
+		{
+			name:    "created",
+			current: nil,
+			desired: entity{ID: "1", Name: "a"},
+			want: `--- current
++++ desired
+@@ -0,0 +1,2 @@
++id: "1"
++name: a
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := YamlDiff(tt.current, tt.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package genericcli
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+type DocsFormat string
+
+const (
+	DocsFormatMarkdown DocsFormat = "markdown"
+	DocsFormatMan      DocsFormat = "man"
+)
+
+// NewCompletionCmd returns a command that generates shell completion scripts for bash, zsh, fish and powershell.
+func NewCompletionCmd(binaryName string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion",
+		Short: "generates shell completion scripts",
+		Long: fmt.Sprintf(`generates shell completion scripts for %[1]s.
+
+To load the completion in the current shell, run e.g.:
+
+  $ source <(%[1]s completion bash)
+
+To load the completion for every new session, add this line to your shell profile.`, binaryName),
+		DisableFlagsInUseLine: true,
+	}
+
+	for _, shell := range []struct {
+		name string
+		gen  func(root *cobra.Command, out *bytes.Buffer) error
+	}{
+		{
+			name: "bash",
+			gen: func(root *cobra.Command, out *bytes.Buffer) error {
+				return root.GenBashCompletionV2(out, true)
+			},
+		},
+		{
+			name: "zsh",
+			gen: func(root *cobra.Command, out *bytes.Buffer) error {
+				return root.GenZshCompletion(out)
+			},
+		},
+		{
+			name: "fish",
+			gen: func(root *cobra.Command, out *bytes.Buffer) error {
+				return root.GenFishCompletion(out, true)
+			},
+		},
+		{
+			name: "powershell",
+			gen: func(root *cobra.Command, out *bytes.Buffer) error {
+				return root.GenPowerShellCompletionWithDesc(out)
+			},
+		},
+	} {
+		shell := shell
+		cmd.AddCommand(&cobra.Command{
+			Use:                   shell.name,
+			Short:                 fmt.Sprintf("generates the completion script for %s", shell.name),
+			Example:               fmt.Sprintf("  $ %s completion %s", binaryName, shell.name),
+			Args:                  cobra.NoArgs,
+			DisableFlagsInUseLine: true,
+			ValidArgsFunction:     cobra.NoFileCompletions,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var buf bytes.Buffer
+				err := shell.gen(cmd.Root(), &buf)
+				if err != nil {
+					return err
+				}
+
+				_, err = cmd.OutOrStdout().Write(buf.Bytes())
+				return err
+			},
+		})
+	}
+
+	return cmd
+}
+
+// NewDocsCmd returns a command that generates the documentation of all commands of the cli in markdown or man page format.
+func NewDocsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "docs",
+		Short: "generates the documentation of this cli",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return GenerateDocs(cmd.Root(), afero.NewOsFs(), viper.GetString("dir"), DocsFormat(viper.GetString("format")))
+		},
+		Hidden: true,
+	}
+
+	cmd.Flags().String("dir", "docs", "the directory to write the documentation to")
+	cmd.Flags().String("format", string(DocsFormatMarkdown), "the format of the documentation, can be markdown or man")
+
+	Must(cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{string(DocsFormatMarkdown), string(DocsFormatMan)}, cobra.ShellCompDirectiveNoFileComp)))
+
+	return cmd
+}
+
+// GenerateDocs writes a documentation file in the given format for every available command of the given command tree.
+func GenerateDocs(root *cobra.Command, fs afero.Fs, dir string, format DocsFormat) error {
+	var gen func(cmd *cobra.Command) (string, []byte)
+
+	switch format {
+	case DocsFormatMarkdown:
+		gen = markdownDoc
+	case DocsFormatMan:
+		gen = manDoc
+	default:
+		return fmt.Errorf("unsupported docs format: %s", format)
+	}
+
+	err := fs.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	var walk func(cmd *cobra.Command) error
+	walk = func(cmd *cobra.Command) error {
+		for _, child := range cmd.Commands() {
+			if !documented(child) {
+				continue
+			}
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+
+		cmd.InitDefaultHelpFlag()
+
+		name, content := gen(cmd)
+
+		return afero.WriteFile(fs, filepath.Join(dir, name), content, 0644) //nolint:gosec
+	}
+
+	return walk(root)
+}
+
+func documented(cmd *cobra.Command) bool {
+	return cmd.IsAvailableCommand() && !cmd.IsAdditionalHelpTopicCommand()
+}
+
+func documentedChildren(cmd *cobra.Command) []*cobra.Command {
+	var children []*cobra.Command
+	for _, child := range cmd.Commands() {
+		if documented(child) {
+			children = append(children, child)
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Name() < children[j].Name()
+	})
+
+	return children
+}
+
+func markdownDoc(cmd *cobra.Command) (string, []byte) {
+	var (
+		buf  bytes.Buffer
+		name = func(c *cobra.Command) string {
+			return strings.ReplaceAll(c.CommandPath(), " ", "_") + ".md"
+		}
+	)
+
+	fmt.Fprintf(&buf, "## %s\n\n%s\n\n", cmd.CommandPath(), cmd.Short)
+
+	if cmd.Runnable() || cmd.Long != "" {
+		buf.WriteString("### Synopsis\n\n")
+		if cmd.Long != "" {
+			fmt.Fprintf(&buf, "%s\n\n", cmd.Long)
+		}
+		if cmd.Runnable() {
+			fmt.Fprintf(&buf, "```\n%s\n```\n\n", cmd.UseLine())
+		}
+	}
+
+	if cmd.Example != "" {
+		fmt.Fprintf(&buf, "### Examples\n\n```\n%s\n```\n\n", cmd.Example)
+	}
+
+	if flags := cmd.NonInheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&buf, "### Options\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+
+	if flags := cmd.InheritedFlags(); flags.HasAvailableFlags() {
+		fmt.Fprintf(&buf, "### Options inherited from parent commands\n\n```\n%s```\n\n", flags.FlagUsages())
+	}
+
+	children := documentedChildren(cmd)
+
+	if cmd.HasParent() || len(children) > 0 {
+		buf.WriteString("### SEE ALSO\n\n")
+
+		if cmd.HasParent() {
+			parent := cmd.Parent()
+			fmt.Fprintf(&buf, "* [%s](%s)\t - %s\n", parent.CommandPath(), name(parent), parent.Short)
+		}
+
+		for _, child := range children {
+			fmt.Fprintf(&buf, "* [%s](%s)\t - %s\n", child.CommandPath(), name(child), child.Short)
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return name(cmd), buf.Bytes()
+}
+
+func manDoc(cmd *cobra.Command) (string, []byte) {
+	var (
+		buf  bytes.Buffer
+		name = func(c *cobra.Command) string {
+			return strings.ReplaceAll(c.CommandPath(), " ", "-")
+		}
+	)
+
+	fmt.Fprintf(&buf, ".TH \"%s\" \"1\" \"\" \"%s\" \"\"\n", strings.ToUpper(name(cmd)), cmd.Root().Name())
+
+	fmt.Fprintf(&buf, ".SH NAME\n%s \\- %s\n", manEscape(name(cmd)), manEscape(cmd.Short))
+
+	fmt.Fprintf(&buf, ".SH SYNOPSIS\n\\fB%s\\fP\n", manEscape(cmd.UseLine()))
+
+	description := cmd.Long
+	if description == "" {
+		description = cmd.Short
+	}
+	fmt.Fprintf(&buf, ".SH DESCRIPTION\n%s\n", manEscape(description))
+
+	for _, section := range []struct {
+		title string
+		flags *pflag.FlagSet
+	}{
+		{title: "OPTIONS", flags: cmd.NonInheritedFlags()},
+		{title: "OPTIONS INHERITED FROM PARENT COMMANDS", flags: cmd.InheritedFlags()},
+	} {
+		if !section.flags.HasAvailableFlags() {
+			continue
+		}
+
+		fmt.Fprintf(&buf, ".SH %s\n", section.title)
+
+		section.flags.VisitAll(func(f *pflag.Flag) {
+			if f.Hidden {
+				return
+			}
+
+			buf.WriteString(".TP\n")
+			if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+				fmt.Fprintf(&buf, "\\fB\\-%s\\fP, ", f.Shorthand)
+			}
+			fmt.Fprintf(&buf, "\\fB\\-\\-%s\\fP", manEscape(f.Name))
+			if f.Value.Type() != "bool" {
+				fmt.Fprintf(&buf, "=%s", manEscape(fmt.Sprintf("%q", f.DefValue)))
+			}
+			fmt.Fprintf(&buf, "\n%s\n", manEscape(f.Usage))
+		})
+	}
+
+	if cmd.Example != "" {
+		fmt.Fprintf(&buf, ".SH EXAMPLE\n.PP\n.RS\n.nf\n%s\n.fi\n.RE\n", manEscape(cmd.Example))
+	}
+
+	var related []string
+	if cmd.HasParent() {
+		related = append(related, fmt.Sprintf("\\fB%s(1)\\fP", manEscape(name(cmd.Parent()))))
+	}
+	for _, child := range documentedChildren(cmd) {
+		related = append(related, fmt.Sprintf("\\fB%s(1)\\fP", manEscape(name(child))))
+	}
+	if len(related) > 0 {
+		fmt.Fprintf(&buf, ".SH SEE ALSO\n%s\n", strings.Join(related, ", "))
+	}
+
+	return name(cmd) + ".1", buf.Bytes()
+}
+
+// manEscape escapes text for the use in roff documents.
+func manEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// lines starting with a control character would be interpreted as roff requests
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
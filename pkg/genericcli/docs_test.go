@@ -0,0 +1,105 @@
+package genericcli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func newDocsTestRoot() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "metalctl",
+		Short: "cli for managing metal-stack",
+	}
+	root.PersistentFlags().Bool("debug", false, "debug output")
+
+	machine := &cobra.Command{
+		Use:   "machine",
+		Short: "manage machine entities",
+	}
+
+	list := &cobra.Command{
+		Use:     "list",
+		Short:   "list all machines",
+		Example: "  $ metalctl machine list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+	list.Flags().StringP("output", "o", "table", "the output format")
+
+	machine.AddCommand(list)
+	root.AddCommand(machine, NewCompletionCmd("metalctl"), NewDocsCmd())
+
+	return root
+}
+
+func TestGenerateDocs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := GenerateDocs(newDocsTestRoot(), fs, "/docs", DocsFormatMarkdown)
+	require.NoError(t, err)
+
+	files, err := afero.ReadDir(fs, "/docs")
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+
+	// hidden commands like docs must not be documented
+	require.Equal(t, []string{
+		"metalctl.md",
+		"metalctl_completion.md",
+		"metalctl_completion_bash.md",
+		"metalctl_completion_fish.md",
+		"metalctl_completion_powershell.md",
+		"metalctl_completion_zsh.md",
+		"metalctl_machine.md",
+		"metalctl_machine_list.md",
+	}, names)
+
+	raw, err := afero.ReadFile(fs, "/docs/metalctl_machine_list.md")
+	require.NoError(t, err)
+
+	want := "## metalctl machine list\n\n" +
+		"list all machines\n\n" +
+		"### Synopsis\n\n" +
+		"```\nmetalctl machine list [flags]\n```\n\n" +
+		"### Examples\n\n" +
+		"```\n  $ metalctl machine list\n```\n\n" +
+		"### Options\n\n" +
+		"```\n  -h, --help            help for list\n  -o, --output string   the output format (default \"table\")\n```\n\n" +
+		"### Options inherited from parent commands\n\n" +
+		"```\n      --debug   debug output\n```\n\n" +
+		"### SEE ALSO\n\n" +
+		"* [metalctl machine](metalctl_machine.md)\t - manage machine entities\n\n"
+
+	if diff := cmp.Diff(want, string(raw)); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	err = GenerateDocs(newDocsTestRoot(), fs, "/man", DocsFormatMan)
+	require.NoError(t, err)
+
+	raw, err = afero.ReadFile(fs, "/man/metalctl-machine-list.1")
+	require.NoError(t, err)
+	require.Contains(t, string(raw), ".SH NAME\nmetalctl\\-machine\\-list \\- list all machines\n")
+	require.Contains(t, string(raw), ".TP\n\\fB\\-o\\fP, \\fB\\-\\-output\\fP=\"table\"\nthe output format\n")
+}
+
+func TestNewCompletionCmd(t *testing.T) {
+	root := newDocsTestRoot()
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{"completion", "bash"})
+
+	require.NoError(t, root.Execute())
+	require.Contains(t, out.String(), "bash completion V2 for metalctl")
+}
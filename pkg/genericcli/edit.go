@@ -1,15 +1,21 @@
 package genericcli
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
 	"github.com/spf13/afero"
 	"sigs.k8s.io/yaml"
 )
 
+// Edit opens the update entity of the given id in an editor and updates the entity with the edited content.
+// If the edited content cannot be parsed, the editor is opened again with the error shown in a comment banner
+// until the content is valid or left unchanged.
 func (a *MultiArgGenericCLI[C, U, R]) Edit(n int, args []string) (R, error) {
 	var zero R
 
@@ -18,11 +24,6 @@ func (a *MultiArgGenericCLI[C, U, R]) Edit(n int, args []string) (R, error) {
 		return zero, err
 	}
 
-	editor, ok := os.LookupEnv("EDITOR")
-	if !ok {
-		editor = "vi"
-	}
-
 	tmpfile, err := afero.TempFile(a.fs, "", "metallib-*.yaml")
 	if err != nil {
 		return zero, err
@@ -46,46 +47,137 @@ func (a *MultiArgGenericCLI[C, U, R]) Edit(n int, args []string) (R, error) {
 		return zero, err
 	}
 
-	err = afero.WriteFile(a.fs, tmpfile.Name(), raw, 0755)
-	if err != nil {
-		return zero, err
+	var (
+		content       = raw
+		editedContent []byte
+		uparser       = MultiDocumentYAML[U]{fs: a.fs}
+	)
+
+	for {
+		err = afero.WriteFile(a.fs, tmpfile.Name(), content, 0755)
+		if err != nil {
+			return zero, err
+		}
+
+		editor := editorCommand(runtime.GOOS, os.Getenv)
+		editCommand := exec.Command(editor[0], append(editor[1:], tmpfile.Name())...) //nolint:gosec
+		editCommand.Stdout = os.Stdout
+		editCommand.Stdin = os.Stdin
+		editCommand.Stderr = os.Stderr
+
+		err = editCommand.Run()
+		if err != nil {
+			return zero, err
+		}
+
+		edited, err := afero.ReadFile(a.fs, tmpfile.Name())
+		if err != nil {
+			return zero, err
+		}
+
+		editedContent = stripEditBanner(edited)
+
+		if len(bytes.TrimSpace(removeComments(editedContent))) == 0 {
+			return zero, fmt.Errorf("edited file is empty, aborting")
+		}
+
+		equal, err := YamlIsEqual(raw, editedContent)
+		if err == nil && equal {
+			return zero, fmt.Errorf("no changes were made, aborting")
+		}
+
+		if err == nil {
+			updateDoc, err = uparser.ReadOne(tmpfile.Name())
+		}
+		if err == nil {
+			break
+		}
+
+		if bytes.Equal(editedContent, stripEditBanner(content)) {
+			return zero, fmt.Errorf("edited file is still invalid, aborting: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "error in edited file: %s\n", err)
+
+		content = append(editBanner(err), editedContent...)
 	}
 
-	editCommand := exec.Command(editor, tmpfile.Name())
-	editCommand.Stdout = os.Stdout
-	editCommand.Stdin = os.Stdin
-	editCommand.Stderr = os.Stderr
+	if a.diffOut != nil {
+		diff, err := unifiedYamlDiff(raw, editedContent)
+		if err != nil {
+			return zero, err
+		}
 
-	err = editCommand.Run()
-	if err != nil {
-		return zero, err
+		printDiff(a.diffOut, id, diff)
 	}
 
-	editedContent, err := afero.ReadFile(a.fs, tmpfile.Name())
+	result, err := a.crud.Update(updateDoc)
 	if err != nil {
-		return zero, err
+		return zero, fmt.Errorf("error updating entity: %w", err)
 	}
 
-	equal, err := YamlIsEqual(raw, editedContent)
-	if err != nil {
-		return zero, err
+	a.recordHistory(HistoryOperationUpdate, id, doc)
+
+	return result, nil
+}
+
+// editorCommand returns the editor command and its arguments from the EDITOR or VISUAL environment variables,
+// falling back to notepad on windows and vi on other platforms.
+func editorCommand(goos string, getenv func(string) string) []string {
+	for _, key := range []string{"EDITOR", "VISUAL"} {
+		if editor := strings.Fields(getenv(key)); len(editor) > 0 {
+			return editor
+		}
 	}
-	if equal {
-		return zero, fmt.Errorf("no changes were made, aborting")
+
+	if goos == "windows" {
+		return []string{"notepad"}
 	}
 
-	uparser := MultiDocumentYAML[U]{fs: a.fs}
-	updateDoc, err = uparser.ReadOne(tmpfile.Name())
-	if err != nil {
-		return zero, err
+	return []string{"vi"}
+}
+
+const editBannerPrefix = "# "
+
+// editBanner returns a comment banner that shows the given error on top of the edited file.
+func editBanner(err error) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString(editBannerPrefix + "The edited file is invalid, please correct the error below.\n")
+	buf.WriteString(editBannerPrefix + "Leaving the file unchanged aborts the edit, lines beginning with '#' are ignored.\n")
+	buf.WriteString("#\n")
+	for _, line := range strings.Split(err.Error(), "\n") {
+		buf.WriteString(editBannerPrefix + "error: " + line + "\n")
 	}
+	buf.WriteString("#\n")
 
-	result, err := a.crud.Update(updateDoc)
-	if err != nil {
-		return zero, fmt.Errorf("error updating entity: %w", err)
+	return buf.Bytes()
+}
+
+// stripEditBanner removes the comment lines on top of the edited file, which contain the banner of the previous attempt.
+func stripEditBanner(content []byte) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("#")) {
+			return bytes.Join(lines[i:], nil)
+		}
 	}
 
-	return result, nil
+	return nil
+}
+
+func removeComments(content []byte) []byte {
+	var res []byte
+
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+		res = append(res, line...)
+	}
+
+	return res
 }
 
 func (a *MultiArgGenericCLI[C, U, R]) EditAndPrint(n int, args []string, p printers.Printer) error {
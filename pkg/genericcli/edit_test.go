@@ -0,0 +1,71 @@
+package genericcli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "editor with arguments",
+			goos: "linux",
+			env:  map[string]string{"EDITOR": "code --wait", "VISUAL": "vim"},
+			want: []string{"code", "--wait"},
+		},
+		{
+			name: "visual",
+			goos: "darwin",
+			env:  map[string]string{"VISUAL": "nano"},
+			want: []string{"nano"},
+		},
+		{
+			name: "linux fallback",
+			goos: "linux",
+			want: []string{"vi"},
+		},
+		{
+			name: "windows fallback",
+			goos: "windows",
+			want: []string{"notepad"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := editorCommand(tt.goos, func(key string) string { return tt.env[key] })
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestEditBanner(t *testing.T) {
+	content := []byte("id: \"1\"\nname: one\n")
+
+	withBanner := append(editBanner(errors.New("error converting YAML to JSON: yaml: line 2: mapping values are not allowed in this context")), content...)
+
+	want := `# The edited file is invalid, please correct the error below.
+# Leaving the file unchanged aborts the edit, lines beginning with '#' are ignored.
+#
+# error: error converting YAML to JSON: yaml: line 2: mapping values are not allowed in this context
+#
+id: "1"
+name: one
+`
+	if diff := cmp.Diff(want, string(withBanner)); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	if diff := cmp.Diff(string(content), string(stripEditBanner(withBanner))); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
@@ -0,0 +1,133 @@
+package genericcli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SubstituteEnv replaces references to environment variables in the given input before it is decoded, such that
+// manifests can be reused across environments. Supported are ${VAR} and ${VAR:-default}, where the default is used
+// if the variable is unset or empty. Referencing an unset variable without default is an error. A literal ${ can be
+// written as $${. Variable references must not span multiple lines.
+func SubstituteEnv(raw []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	return io.ReadAll(newEnvSubstReader(bytes.NewReader(raw), lookup))
+}
+
+// envSubstReader substitutes environment variables line by line, such that streamed input does not need to be held in memory.
+type envSubstReader struct {
+	source  io.Reader
+	reader  *bufio.Reader
+	lookup  func(string) (string, bool)
+	pending []byte
+	line    int
+	err     error
+}
+
+func newEnvSubstReader(r io.Reader, lookup func(string) (string, bool)) io.Reader {
+	return &envSubstReader{
+		source: r,
+		reader: bufio.NewReader(r),
+		lookup: lookup,
+	}
+}
+
+func (r *envSubstReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		line, err := r.reader.ReadBytes('\n')
+		if err != nil {
+			r.err = err
+		}
+
+		r.line++
+
+		substituted, substErr := expandLine(line, r.lookup)
+		if substErr != nil {
+			r.err = fmt.Errorf("line %d: %w", r.line, substErr)
+			return 0, r.err
+		}
+
+		r.pending = substituted
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+// Close closes the underlying reader if it is closable.
+func (r *envSubstReader) Close() error {
+	if closer, ok := r.source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func expandLine(line []byte, lookup func(string) (string, bool)) ([]byte, error) {
+	var out bytes.Buffer
+
+	for len(line) > 0 {
+		i := bytes.IndexByte(line, '$')
+		if i < 0 {
+			out.Write(line)
+			break
+		}
+
+		out.Write(line[:i])
+		line = line[i:]
+
+		switch {
+		case bytes.HasPrefix(line, []byte("$${")):
+			out.WriteString("${")
+			line = line[3:]
+		case bytes.HasPrefix(line, []byte("${")):
+			end := bytes.IndexByte(line, '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated variable reference")
+			}
+
+			value, err := expandVariable(string(line[2:end]), lookup)
+			if err != nil {
+				return nil, err
+			}
+
+			out.WriteString(value)
+			line = line[end+1:]
+		default:
+			out.WriteByte('$')
+			line = line[1:]
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+func expandVariable(expression string, lookup func(string) (string, bool)) (string, error) {
+	name, def, hasDefault := strings.Cut(expression, ":-")
+
+	if name == "" || strings.ContainsAny(name, " \t$") {
+		return "", fmt.Errorf("invalid variable reference ${%s}", expression)
+	}
+
+	value, ok := lookup(name)
+	if value != "" {
+		return value, nil
+	}
+
+	if hasDefault {
+		return def, nil
+	}
+
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,143 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubstituteEnv(t *testing.T) {
+	env := map[string]string{
+		"NAME":  "a",
+		"EMPTY": "",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "no variables",
+			raw:  "id: a\nprice: 5$\n",
+			want: "id: a\nprice: 5$\n",
+		},
+		{
+			name: "variable is substituted",
+			raw:  "id: ${NAME}\nlabels:\n  - ${NAME}-label\n",
+			want: "id: a\nlabels:\n  - a-label\n",
+		},
+		{
+			name: "default is used for unset variable",
+			raw:  "id: ${UNSET:-b}",
+			want: "id: b",
+		},
+		{
+			name: "default is used for empty variable",
+			raw:  "id: ${EMPTY:-b}",
+			want: "id: b",
+		},
+		{
+			name: "default is not used for set variable",
+			raw:  "id: ${NAME:-b}",
+			want: "id: a",
+		},
+		{
+			name: "empty variable without default",
+			raw:  "id: '${EMPTY}'",
+			want: "id: ''",
+		},
+		{
+			name: "escaped reference",
+			raw:  "script: echo $${HOME}",
+			want: "script: echo ${HOME}",
+		},
+		{
+			name:    "unset variable without default",
+			raw:     "id: a\nname: ${UNSET}\n",
+			wantErr: fmt.Errorf("line 2: %w", errors.New("environment variable UNSET is not set")),
+		},
+		{
+			name:    "unterminated reference",
+			raw:     "id: ${NAME\n}",
+			wantErr: fmt.Errorf("line 1: %w", errors.New("unterminated variable reference")),
+		},
+		{
+			name:    "invalid reference",
+			raw:     "id: ${}",
+			wantErr: fmt.Errorf("line 1: %w", errors.New("invalid variable reference ${}")),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SubstituteEnv([]byte(tt.raw), lookup)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestMultiDocumentYAML_WithEnvSubstitution(t *testing.T) {
+	const testFile = "/test.yaml"
+
+	t.Setenv("TEST_LABEL", "from-env")
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, testFile, []byte(`
+---
+id: ${TEST_ID:-a}
+labels:
+  - ${TEST_LABEL}
+---
+id: b
+labels:
+  - ${TEST_LABEL}
+`), 0755))
+
+	m := (&MultiDocumentYAML[testYAML]{fs: fs}).WithEnvSubstitution()
+
+	want := []testYAML{
+		{
+			ID:     "a",
+			Labels: []string{"from-env"},
+		},
+		{
+			ID:     "b",
+			Labels: []string{"from-env"},
+		},
+	}
+
+	got, err := m.ReadAll(testFile)
+	require.NoError(t, err)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	var streamed []testYAML
+	for doc, err := range m.Stream(testFile) {
+		require.NoError(t, err)
+		streamed = append(streamed, doc)
+	}
+	if diff := cmp.Diff(want, streamed); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
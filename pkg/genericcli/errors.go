@@ -0,0 +1,162 @@
+package genericcli
+
+import (
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/spf13/cobra"
+)
+
+// ErrorKind classifies errors such that scripts wrapping a cli can branch on the type of failure through the exit code.
+type ErrorKind string
+
+const (
+	ErrorKindUnknown      ErrorKind = "unknown"
+	ErrorKindNotFound     ErrorKind = "not-found"
+	ErrorKindConflict     ErrorKind = "conflict"
+	ErrorKindUnauthorized ErrorKind = "unauthorized"
+	ErrorKindValidation   ErrorKind = "validation"
+)
+
+// The process exit codes for the error kinds, which are documented for users of the clis:
+//
+//	0 - success
+//	1 - unknown error
+//	3 - entity not found
+//	4 - conflict, e.g. the entity already exists
+//	5 - unauthorized or forbidden
+//	6 - validation error, e.g. invalid input
+//
+// Exit code 2 is omitted as it is commonly used by shells for the misuse of commands.
+const (
+	ExitCodeSuccess      = 0
+	ExitCodeUnknown      = 1
+	ExitCodeNotFound     = 3
+	ExitCodeConflict     = 4
+	ExitCodeUnauthorized = 5
+	ExitCodeValidation   = 6
+)
+
+// Error is an error with an error kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+// NewError returns an error of the given kind wrapping the given error.
+func NewError(kind ErrorKind, err error) *Error {
+	return &Error{
+		Kind: kind,
+		Err:  err,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns the error kind of the given error. Besides errors of this package, http error responses
+// and connect errors are classified.
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+
+	if errors.Is(err, AlreadyExistsError()) {
+		return ErrorKindConflict
+	}
+
+	var httpErr *httperrors.HTTPErrorResponse
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusNotFound:
+			return ErrorKindNotFound
+		case http.StatusConflict:
+			return ErrorKindConflict
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrorKindUnauthorized
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return ErrorKindValidation
+		}
+	}
+
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		switch connectErr.Code() {
+		case connect.CodeNotFound:
+			return ErrorKindNotFound
+		case connect.CodeAlreadyExists:
+			return ErrorKindConflict
+		case connect.CodeUnauthenticated, connect.CodePermissionDenied:
+			return ErrorKindUnauthorized
+		case connect.CodeInvalidArgument:
+			return ErrorKindValidation
+		}
+	}
+
+	return ErrorKindUnknown
+}
+
+// ExitCode returns the process exit code for the given error, which is typically returned by the execution of the root command:
+//
+//	if err := rootCmd.Execute(); err != nil {
+//		os.Exit(genericcli.ExitCode(err))
+//	}
+func ExitCode(err error) int {
+	switch ClassifyError(err) {
+	case "":
+		return ExitCodeSuccess
+	case ErrorKindNotFound:
+		return ExitCodeNotFound
+	case ErrorKindConflict:
+		return ExitCodeConflict
+	case ErrorKindUnauthorized:
+		return ExitCodeUnauthorized
+	case ErrorKindValidation:
+		return ExitCodeValidation
+	default:
+		return ExitCodeUnknown
+	}
+}
+
+// classifyRunE wraps the errors returned by the given command with an error kind, which is either determined
+// by the given classifier or by ClassifyError.
+func classifyRunE(cmd *cobra.Command, classifier func(error) ErrorKind) {
+	runE := cmd.RunE
+	if runE == nil {
+		return
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		err := runE(cmd, args)
+		if err == nil {
+			return nil
+		}
+
+		var typed *Error
+		if errors.As(err, &typed) {
+			return err
+		}
+
+		kind := ErrorKindUnknown
+		if classifier != nil {
+			kind = classifier(err)
+		}
+		if kind == "" || kind == ErrorKindUnknown {
+			kind = ClassifyError(err)
+		}
+
+		return NewError(kind, err)
+	}
+}
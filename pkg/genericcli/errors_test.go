@@ -0,0 +1,94 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "no error",
+			err:  nil,
+			want: ExitCodeSuccess,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("something went wrong"),
+			want: ExitCodeUnknown,
+		},
+		{
+			name: "typed error",
+			err:  fmt.Errorf("wrapped: %w", NewError(ErrorKindValidation, errors.New("invalid"))),
+			want: ExitCodeValidation,
+		},
+		{
+			name: "already exists",
+			err:  fmt.Errorf("error creating entity: %w", AlreadyExistsError()),
+			want: ExitCodeConflict,
+		},
+		{
+			name: "http not found",
+			err:  httperrors.NotFound(errors.New("machine not found")),
+			want: ExitCodeNotFound,
+		},
+		{
+			name: "http forbidden",
+			err:  httperrors.Forbidden(errors.New("access denied")),
+			want: ExitCodeUnauthorized,
+		},
+		{
+			name: "http internal server error",
+			err:  httperrors.InternalServerError(errors.New("boom")),
+			want: ExitCodeUnknown,
+		},
+		{
+			name: "connect unauthenticated",
+			err:  connect.NewError(connect.CodeUnauthenticated, errors.New("token expired")),
+			want: ExitCodeUnauthorized,
+		},
+		{
+			name: "connect invalid argument",
+			err:  fmt.Errorf("error updating entity: %w", connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))),
+			want: ExitCodeValidation,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, ExitCode(tt.err))
+		})
+	}
+}
+
+func TestClassifyRunE(t *testing.T) {
+	errCustomNotFound := errors.New("custom not found")
+
+	cmd := &cobra.Command{
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return errCustomNotFound
+		},
+	}
+
+	classifyRunE(cmd, func(err error) ErrorKind {
+		if errors.Is(err, errCustomNotFound) {
+			return ErrorKindNotFound
+		}
+		return ErrorKindUnknown
+	})
+
+	err := cmd.RunE(cmd, nil)
+	require.ErrorIs(t, err, errCustomNotFound)
+	require.EqualError(t, err, "custom not found")
+	require.Equal(t, ExitCodeNotFound, ExitCode(err))
+}
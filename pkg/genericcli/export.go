@@ -0,0 +1,235 @@
+package genericcli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// ExportResource is a resource whose entities are exported by the export command, see NewExportResource.
+type ExportResource struct {
+	// Name of the resource, which is used as directory name of the exported entities.
+	Name string
+
+	export func() ([]exportedEntity, error)
+}
+
+type exportedEntity struct {
+	id  []string
+	raw []byte
+}
+
+// NewExportResource returns a resource for the export command, which exports all entities listed by the given cli.
+// The entities are written as response objects, such that they can be applied with the apply command of the entity.
+func NewExportResource[C any, U any, R any](name string, cli *MultiArgGenericCLI[C, U, R]) ExportResource {
+	return ExportResource{
+		Name: name,
+		export: func() ([]exportedEntity, error) {
+			entities, err := cli.List()
+			if err != nil {
+				return nil, err
+			}
+
+			var result []exportedEntity
+			for _, entity := range entities {
+				id, _, _, err := cli.Interface().Convert(entity)
+				if err != nil {
+					return nil, err
+				}
+
+				raw, err := yaml.Marshal(entity)
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, exportedEntity{id: id, raw: raw})
+			}
+
+			return result, nil
+		},
+	}
+}
+
+// ExportCmdConfig contains the configuration for the export command.
+type ExportCmdConfig struct {
+	// Fs is the filesystem the export is written to, defaults to the os filesystem.
+	Fs afero.Fs
+	// Out is used when the export is written to stdout, defaults to os.Stdout.
+	Out io.Writer
+	// Err is used for printing a summary of the export, defaults to os.Stderr.
+	Err io.Writer
+}
+
+// NewExportCmd returns a command that exports all entities of the given resources, e.g. as a backup for disaster recovery.
+//
+// With --output-dir every entity is written to a file <output-dir>/<resource>/<id>.yaml, with --file all entities are
+// written to a single multi-document yaml file. As the apply command reads documents of a single entity, --resources
+// should be used to restrict a single file to one resource.
+func NewExportCmd(c *ExportCmdConfig, resources ...ExportResource) *cobra.Command {
+	var names []string
+	for _, r := range resources {
+		names = append(names, r.Name)
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "exports all entities of the resources, which can be restored with the apply commands",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selected, err := selectExportResources(resources, viper.GetStringSlice("resources"))
+			if err != nil {
+				return err
+			}
+
+			var (
+				dir  = viper.GetString("output-dir")
+				file = viper.GetString("file")
+			)
+
+			switch {
+			case dir != "" && file != "":
+				return fmt.Errorf("only one of --output-dir or --file can be given")
+			case dir != "":
+				return c.ExportToDir(dir, selected...)
+			case file != "":
+				return c.ExportToFile(file, selected...)
+			default:
+				return fmt.Errorf("either --output-dir or --file must be given")
+			}
+		},
+	}
+
+	cmd.Flags().String("output-dir", "", "writes every entity to a file <output-dir>/<resource>/<id>.yaml")
+	cmd.Flags().StringP("file", "f", "", "writes all entities to a single multi-document yaml file, use - for stdout")
+	cmd.Flags().StringSlice("resources", nil, fmt.Sprintf("the resources to export, defaults to all resources (%s)", strings.Join(names, ", ")))
+	Must(cmd.RegisterFlagCompletionFunc("resources", cobra.FixedCompletions(names, cobra.ShellCompDirectiveNoFileComp)))
+
+	return cmd
+}
+
+func selectExportResources(resources []ExportResource, names []string) ([]ExportResource, error) {
+	if len(names) == 0 {
+		return resources, nil
+	}
+
+	var result []ExportResource
+	for _, name := range names {
+		idx := slices.IndexFunc(resources, func(r ExportResource) bool { return r.Name == name })
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown resource %q", name)
+		}
+		result = append(result, resources[idx])
+	}
+
+	return result, nil
+}
+
+// ExportToDir writes every entity of the given resources to a file <dir>/<resource>/<id>.yaml.
+func (c *ExportCmdConfig) ExportToDir(dir string, resources ...ExportResource) error {
+	fs := c.fs()
+
+	for _, r := range resources {
+		entities, err := r.export()
+		if err != nil {
+			return fmt.Errorf("unable to export %s: %w", r.Name, err)
+		}
+
+		resourceDir := filepath.Join(dir, r.Name)
+		err = fs.MkdirAll(resourceDir, 0700)
+		if err != nil {
+			return err
+		}
+
+		written := map[string]bool{}
+		for _, e := range entities {
+			name, err := exportFileName(e.id)
+			if err != nil {
+				return fmt.Errorf("unable to export %s: %w", r.Name, err)
+			}
+			if written[name] {
+				return fmt.Errorf("unable to export %s: multiple entities are written to %s", r.Name, name)
+			}
+			written[name] = true
+
+			err = afero.WriteFile(fs, filepath.Join(resourceDir, name), append([]byte("---\n"), e.raw...), 0600)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(c.err(), "exported %d %s to %s\n", len(entities), r.Name, resourceDir)
+	}
+
+	return nil
+}
+
+// ExportToFile writes all entities of the given resources to a single multi-document yaml file, the document
+// separators are annotated with the name of the resource. If file is "-", the entities are written to stdout.
+func (c *ExportCmdConfig) ExportToFile(file string, resources ...ExportResource) error {
+	var buf bytes.Buffer
+
+	for _, r := range resources {
+		entities, err := r.export()
+		if err != nil {
+			return fmt.Errorf("unable to export %s: %w", r.Name, err)
+		}
+
+		for _, e := range entities {
+			fmt.Fprintf(&buf, "--- # %s\n", r.Name)
+			buf.Write(e.raw)
+		}
+
+		fmt.Fprintf(c.err(), "exported %d %s\n", len(entities), r.Name)
+	}
+
+	if file == "-" {
+		out := c.Out
+		if out == nil {
+			out = os.Stdout
+		}
+		_, err := out.Write(buf.Bytes())
+		return err
+	}
+
+	fs := c.fs()
+	err := fs.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(fs, file, buf.Bytes(), 0600)
+}
+
+func exportFileName(id []string) (string, error) {
+	name := unsafeFileNameChars.ReplaceAllString(strings.Join(id, "_"), "_")
+	if strings.Trim(name, "._") == "" {
+		return "", fmt.Errorf("entity has no usable id: %v", id)
+	}
+	return name + ".yaml", nil
+}
+
+func (c *ExportCmdConfig) fs() afero.Fs {
+	if c.Fs == nil {
+		return afero.NewOsFs()
+	}
+	return c.Fs
+}
+
+func (c *ExportCmdConfig) err() io.Writer {
+	if c.Err == nil {
+		return os.Stderr
+	}
+	return c.Err
+}
@@ -0,0 +1,113 @@
+package genericcli
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportToDir(t *testing.T) {
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return([]*testResponse{{ID: "1", Name: "one"}, {ID: "a/b", Name: "two"}}, nil).Once()
+	}, nil)
+
+	var (
+		fs     = afero.NewMemMapFs()
+		errOut bytes.Buffer
+		c      = &ExportCmdConfig{Fs: fs, Err: &errOut}
+	)
+
+	require.NoError(t, c.ExportToDir("/backup", NewExportResource("test", cli)))
+
+	raw, err := afero.ReadFile(fs, "/backup/test/1.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "---\nid: \"1\"\nname: one\n", string(raw))
+
+	raw, err = afero.ReadFile(fs, "/backup/test/a_b.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "---\nid: a/b\nname: two\n", string(raw))
+
+	require.Equal(t, "exported 2 test to /backup/test\n", errOut.String())
+
+	// the exported entities can be restored with apply
+	docs, err := (&MultiDocumentYAML[*testResponse]{fs: fs}).ReadAll("/backup/test/a_b.yaml")
+	require.NoError(t, err)
+	require.Equal(t, []*testResponse{{ID: "a/b", Name: "two"}}, docs)
+}
+
+func TestExportToDirDuplicateFileName(t *testing.T) {
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return([]*testResponse{{ID: "a/b"}, {ID: "a:b"}}, nil).Once()
+	}, nil)
+
+	c := &ExportCmdConfig{Fs: afero.NewMemMapFs(), Err: &bytes.Buffer{}}
+
+	err := c.ExportToDir("/backup", NewExportResource("test", cli))
+	require.EqualError(t, err, "unable to export test: multiple entities are written to a_b.yaml")
+}
+
+func TestExportToFile(t *testing.T) {
+	first := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return([]*testResponse{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}, nil).Once()
+	}, nil)
+	second := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return([]*testResponse{{ID: "3", Name: "three"}}, nil).Once()
+	}, nil)
+
+	var (
+		fs     = afero.NewMemMapFs()
+		errOut bytes.Buffer
+		c      = &ExportCmdConfig{Fs: fs, Err: &errOut}
+	)
+
+	require.NoError(t, c.ExportToFile("/backup/all.yaml", NewExportResource("first", first), NewExportResource("second", second)))
+
+	raw, err := afero.ReadFile(fs, "/backup/all.yaml")
+	require.NoError(t, err)
+	require.Equal(t, `--- # first
+id: "1"
+name: one
+--- # first
+id: "2"
+name: two
+--- # second
+id: "3"
+name: three
+`, string(raw))
+	require.Equal(t, "exported 2 first\nexported 1 second\n", errOut.String())
+
+	docs, err := (&MultiDocumentYAML[*testResponse]{fs: fs}).ReadAll("/backup/all.yaml")
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+}
+
+func TestExportToFileListError(t *testing.T) {
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return(nil, fmt.Errorf("api unreachable")).Once()
+	}, nil)
+
+	var out bytes.Buffer
+	c := &ExportCmdConfig{Out: &out, Err: &bytes.Buffer{}}
+
+	err := c.ExportToFile("-", NewExportResource("test", cli))
+	require.EqualError(t, err, "unable to export test: api unreachable")
+	require.Empty(t, out.String())
+}
+
+func TestSelectExportResources(t *testing.T) {
+	resources := []ExportResource{{Name: "a"}, {Name: "b"}}
+
+	got, err := selectExportResources(resources, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	got, err = selectExportResources(resources, []string{"b"})
+	require.NoError(t, err)
+	require.Equal(t, "b", got[0].Name)
+
+	_, err = selectExportResources(resources, []string{"c"})
+	require.EqualError(t, err, `unknown resource "c"`)
+}
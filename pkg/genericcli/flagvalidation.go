@@ -0,0 +1,176 @@
+package genericcli
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// FlagValidator validates the values of a flag, see AddFlagValidators.
+type FlagValidator interface {
+	// ValidateFlagValue returns an error if the given value of the flag is invalid. For slice flags, it is called for every element.
+	ValidateFlagValue(cmd *cobra.Command, value string) error
+}
+
+// flagCompleter is implemented by validators that know the valid values of a flag.
+type flagCompleter interface {
+	completions() []string
+}
+
+// flagSetValidator is implemented by validators that do not validate the value but the relation to other flags.
+type flagSetValidator interface {
+	validateFlagSet(cmd *cobra.Command, name string) error
+}
+
+type enumValidator []string
+
+// Enum only allows the given values. The values are offered for shell completion of the flag.
+func Enum(values ...string) FlagValidator {
+	return enumValidator(values)
+}
+
+func (e enumValidator) ValidateFlagValue(_ *cobra.Command, value string) error {
+	if slices.Contains(e, value) {
+		return nil
+	}
+	return fmt.Errorf("must be one of %s", strings.Join(e, ", "))
+}
+
+func (e enumValidator) completions() []string {
+	return e
+}
+
+type intRangeValidator struct {
+	lower, upper int64
+}
+
+// IntRange only allows integers between lower and upper, both inclusive.
+func IntRange(lower, upper int64) FlagValidator {
+	return intRangeValidator{lower: lower, upper: upper}
+}
+
+func (r intRangeValidator) ValidateFlagValue(_ *cobra.Command, value string) error {
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("must be an integer")
+	}
+	if i < r.lower || i > r.upper {
+		return fmt.Errorf("must be between %d and %d", r.lower, r.upper)
+	}
+	return nil
+}
+
+type regexpValidator struct {
+	re *regexp.Regexp
+}
+
+// MatchRegexp only allows values matching the given regular expression, panics if the expression cannot be compiled.
+func MatchRegexp(expr string) FlagValidator {
+	return regexpValidator{re: regexp.MustCompile(expr)}
+}
+
+func (r regexpValidator) ValidateFlagValue(_ *cobra.Command, value string) error {
+	if r.re.MatchString(value) {
+		return nil
+	}
+	return fmt.Errorf("must match %q", r.re.String())
+}
+
+type requiredWithValidator []string
+
+// RequiredWith requires the given flags to be set as well if the flag is set.
+func RequiredWith(flags ...string) FlagValidator {
+	return requiredWithValidator(flags)
+}
+
+func (r requiredWithValidator) ValidateFlagValue(_ *cobra.Command, _ string) error {
+	return nil
+}
+
+func (r requiredWithValidator) validateFlagSet(cmd *cobra.Command, name string) error {
+	var missing []string
+	for _, other := range r {
+		if !cmd.Flags().Changed(other) {
+			missing = append(missing, "--"+other)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("flag \"--%s\" requires %s to be set", name, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// AddFlagValidators attaches the validators to the flag with the given name, which are evaluated in the PreRunE of the command
+// if the flag was set. A PreRunE or PreRun that is already defined on the command runs after the validation.
+// Validation errors are of the kind ErrorKindValidation and worded like the parse errors of flags, e.g.
+// `invalid argument "xl" for "--size" flag: must be one of s, m, l`.
+func AddFlagValidators(cmd *cobra.Command, name string, validators ...FlagValidator) {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil {
+		Must(fmt.Errorf("flag %q is not defined on command %q", name, cmd.Name()))
+	}
+
+	var completions []string
+	for _, v := range validators {
+		if c, ok := v.(flagCompleter); ok {
+			completions = append(completions, c.completions()...)
+		}
+	}
+	if len(completions) > 0 {
+		Must(cmd.RegisterFlagCompletionFunc(name, cobra.FixedCompletions(completions, cobra.ShellCompDirectiveNoFileComp)))
+	}
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	cmd.PreRun = nil
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		err := validateFlag(cmd, flag, validators)
+		if err != nil {
+			return NewError(ErrorKindValidation, err)
+		}
+
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+
+		return nil
+	}
+}
+
+func validateFlag(cmd *cobra.Command, flag *pflag.Flag, validators []FlagValidator) error {
+	if !flag.Changed {
+		return nil
+	}
+
+	values := []string{flag.Value.String()}
+	if sv, ok := flag.Value.(pflag.SliceValue); ok {
+		values = sv.GetSlice()
+	}
+
+	for _, v := range validators {
+		if sv, ok := v.(flagSetValidator); ok {
+			err := sv.validateFlagSet(cmd, flag.Name)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, value := range values {
+			err := v.ValidateFlagValue(cmd, value)
+			if err != nil {
+				return fmt.Errorf("invalid argument %q for \"--%s\" flag: %w", value, flag.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddFlagValidators(t *testing.T) {
+	newCmd := func(preRunCalled *bool) *cobra.Command {
+		cmd := &cobra.Command{
+			Use: "create",
+			PreRun: func(cmd *cobra.Command, args []string) {
+				*preRunCalled = true
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return nil
+			},
+		}
+
+		cmd.Flags().String("size", "", "")
+		cmd.Flags().StringSlice("tags", nil, "")
+		cmd.Flags().Int("replicas", 1, "")
+		cmd.Flags().String("name", "", "")
+		cmd.Flags().String("tls-cert", "", "")
+		cmd.Flags().String("tls-key", "", "")
+
+		AddFlagValidators(cmd, "size", Enum("s", "m", "l"))
+		AddFlagValidators(cmd, "tags", Enum("a", "b"))
+		AddFlagValidators(cmd, "replicas", IntRange(1, 5))
+		AddFlagValidators(cmd, "name", MatchRegexp(`^[a-z]+$`))
+		AddFlagValidators(cmd, "tls-cert", RequiredWith("tls-key"))
+
+		return cmd
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{
+			name: "no flags",
+		},
+		{
+			name: "valid flags",
+			args: []string{"--size", "m", "--tags", "a,b", "--replicas", "5", "--name", "abc", "--tls-cert", "cert", "--tls-key", "key"},
+		},
+		{
+			name:    "invalid enum",
+			args:    []string{"--size", "xl"},
+			wantErr: NewError(ErrorKindValidation, fmt.Errorf(`invalid argument "xl" for "--size" flag: %w`, errors.New("must be one of s, m, l"))),
+		},
+		{
+			name:    "invalid slice element",
+			args:    []string{"--tags", "a,c"},
+			wantErr: NewError(ErrorKindValidation, fmt.Errorf(`invalid argument "c" for "--tags" flag: %w`, errors.New("must be one of a, b"))),
+		},
+		{
+			name:    "out of range",
+			args:    []string{"--replicas", "0"},
+			wantErr: NewError(ErrorKindValidation, fmt.Errorf(`invalid argument "0" for "--replicas" flag: %w`, errors.New("must be between 1 and 5"))),
+		},
+		{
+			name:    "no match",
+			args:    []string{"--name", "ABC"},
+			wantErr: NewError(ErrorKindValidation, fmt.Errorf(`invalid argument "ABC" for "--name" flag: %w`, errors.New(`must match "^[a-z]+$"`))),
+		},
+		{
+			name:    "required with",
+			args:    []string{"--tls-cert", "cert"},
+			wantErr: NewError(ErrorKindValidation, errors.New(`flag "--tls-cert" requires --tls-key to be set`)),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var preRunCalled bool
+
+			cmd := newCmd(&preRunCalled)
+			cmd.SetArgs(tt.args)
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			err := cmd.Execute()
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if err != nil {
+				require.Equal(t, ErrorKindValidation, ClassifyError(err))
+			}
+			require.Equal(t, err == nil, preRunCalled)
+		})
+	}
+}
+
+func TestAddFlagValidatorsCompletion(t *testing.T) {
+	cmd := &cobra.Command{Use: "create", Run: func(cmd *cobra.Command, args []string) {}}
+	cmd.Flags().String("size", "", "")
+
+	AddFlagValidators(cmd, "size", Enum("s", "m", "l"))
+
+	root := &cobra.Command{Use: "cli"}
+	root.AddCommand(cmd)
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetArgs([]string{cobra.ShellCompRequestCmd, "create", "--size", ""})
+	require.NoError(t, root.Execute())
+
+	// the completions are followed by the shell completion directive
+	require.Equal(t, "s\nm\nl\n:4\n", out.String())
+}
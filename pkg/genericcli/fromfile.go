@@ -1,15 +1,20 @@
 package genericcli
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"iter"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/mattn/go-isatty"
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"go.opentelemetry.io/otel/attribute"
 	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 var alreadyExistsError = errors.New("entity already exists")
@@ -35,6 +40,8 @@ type (
 		Action   BulkAction
 		Error    error
 		Duration time.Duration
+		// Attempts is the number of attempts it took to perform the operation, which is greater than one if retries were configured
+		Attempts int
 	}
 
 	BulkResults[R any] []BulkResult[R]
@@ -189,6 +196,10 @@ func intermediatePrintCallback[R any](p printers.Printer) func(BulkResult[R]) er
 
 func timestampCallback[R any]() func(BulkResult[R]) error {
 	return func(mar BulkResult[R]) error {
+		if mar.Attempts > 1 {
+			fmt.Printf("took %s (%d attempts)\n", mar.Duration.String(), mar.Attempts)
+			return nil
+		}
 		fmt.Printf("took %s\n", mar.Duration.String())
 		return nil
 	}
@@ -226,6 +237,13 @@ func (a *MultiArgGenericCLI[C, U, R]) multiOperationPrint(from string, p printer
 		afterCallbacks  []func(BulkResult[R]) error
 	)
 
+	if a.diffOut != nil {
+		switch op.(type) {
+		case multiOperationApply[C, U, R], multiOperationUpdate[C, U, R]:
+			beforeCallbacks = append(beforeCallbacks, a.diffCallback(a.diffOut))
+		}
+	}
+
 	if a.bulkSecurityPrompt != nil {
 		in := a.bulkSecurityPrompt.In
 		if in == nil {
@@ -246,7 +264,7 @@ func (a *MultiArgGenericCLI[C, U, R]) multiOperationPrint(from string, p printer
 		afterCallbacks = append(afterCallbacks, timestampCallback[R]())
 	}
 
-	if a.bulkPrint {
+	if a.bulkPrint && !a.streaming {
 		_, err := a.multiOperation(&multiOperationArgs[C, U, R]{
 			from:            from,
 			op:              op,
@@ -284,33 +302,146 @@ func (a *MultiArgGenericCLI[C, U, R]) multiOperation(args *multiOperationArgs[C,
 		}
 	)
 
-	docs, err := a.parser.ReadAll(args.from)
-	if err != nil {
-		return nil, err
+	var (
+		docs   iter.Seq2[R, error]
+		all    []R
+		total  int
+		failed int
+		index  = -1
+	)
+
+	if a.streaming {
+		docs = a.streamDocuments(args.from, args.op)
+	} else {
+		all, err = a.readDocuments(args.from)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, isDelete := args.op.(multiOperationDelete[C, U, R]); !isDelete {
+			if validator, ok := a.validator(); ok {
+				var errs []error
+				for index := range all {
+					if err := validator.Validate(all[index]); err != nil {
+						errs = append(errs, fmt.Errorf("document %d: %w", index, err))
+					}
+				}
+				if len(errs) > 0 {
+					return nil, NewError(ErrorKindValidation, fmt.Errorf("validation failed: %w", errors.Join(errs...)))
+				}
+			}
+		}
+
+		docs = func(yield func(R, error) bool) {
+			for _, doc := range all {
+				if !yield(doc, nil) {
+					return
+				}
+			}
+		}
+		total = len(all)
 	}
 
-	for _, c := range args.beforeAllCallbacks {
-		c := c
-		err := c(docs)
+	var state *bulkStateFile
+	if a.stateFile != "" || a.resume {
+		state, err = openBulkState(a.fs, a.stateFile, a.resume, args.from, args.op.verb())
 		if err != nil {
-			return callbackErr(err)
+			return nil, err
+		}
+	}
+
+	if !a.streaming {
+		for _, c := range args.beforeAllCallbacks {
+			c := c
+			err := c(all)
+			if err != nil {
+				return callbackErr(err)
+			}
 		}
 	}
 
-	for index := range docs {
+	succeeded := 0
+
+	for doc, err := range docs {
+		index++
+
+		if err != nil {
+			return callbackErr(err)
+		}
+
+		if a.streaming {
+			total++
+		}
+
+		var hash string
+		if state != nil {
+			hash, err = documentHash(doc)
+			if err != nil {
+				return callbackErr(err)
+			}
+
+			if state.completed(hash) {
+				fmt.Fprintf(os.Stderr, "skipping document %d, it was already processed in a previous run\n", index)
+				total--
+				continue
+			}
+		}
+
 		for _, c := range args.beforeCallbacks {
 			c := c
-			err := c(docs[index])
+			err := c(doc)
 			if err != nil {
 				return callbackErr(err)
 			}
 		}
 
-		start := time.Now()
-		result := args.op.do(a.crud, docs[index])
-		result.Duration = time.Since(start)
+		var (
+			previous    R
+			hasPrevious bool
+		)
+		switch args.op.(type) {
+		case multiOperationApply[C, U, R], multiOperationUpdate[C, U, R], multiOperationKeyValues[C, U, R]:
+			previous, hasPrevious = a.previousForHistory(doc)
+		}
+
+		end := a.startSpan("bulk item", attribute.String("operation", args.op.verb()), attribute.Int("index", index))
+		start := a.clock.Now()
+		result := a.doWithRetry(args.op, doc)
+		result.Duration = a.clock.Now().Sub(start)
+		end(result.Error)
+
+		if result.Error == nil {
+			succeeded++
+		}
 
-		results = append(results, result)
+		if !a.streaming || result.Error != nil {
+			results = append(results, result)
+		}
+
+		if result.Error == nil {
+			switch {
+			case result.Action == BulkDeleted:
+				id, _, _, _ := a.crud.Convert(result.Result)
+				a.recordHistory(HistoryOperationDelete, id, result.Result)
+			case result.Action == BulkUpdated && hasPrevious:
+				id, _, _, _ := a.crud.Convert(previous)
+				a.recordHistory(HistoryOperationUpdate, id, previous)
+			}
+		}
+
+		if state != nil && result.Error == nil {
+			id, _, _, _ := a.crud.Convert(result.Result)
+
+			err := state.add(bulkStateEntry{
+				Document: index,
+				ID:       id,
+				Hash:     hash,
+				Action:   result.Action,
+			})
+			if err != nil {
+				return callbackErr(err)
+			}
+		}
 
 		for _, c := range args.afterCallbacks {
 			c := c
@@ -319,6 +450,21 @@ func (a *MultiArgGenericCLI[C, U, R]) multiOperation(args *multiOperationArgs[C,
 				return callbackErr(err)
 			}
 		}
+
+		if result.Error != nil {
+			failed++
+
+			abort := a.onError.abort(failed, total)
+			if a.streaming {
+				// the amount of remaining documents is unknown, so a threshold cannot be evaluated before the end
+				abort = a.onError.failFast()
+			}
+
+			if abort {
+				fmt.Fprintf(os.Stderr, "aborting bulk operation after document %d due to on-error policy %s\n", index, a.onError)
+				break
+			}
+		}
 	}
 
 	for _, c := range args.afterAllCallbacks {
@@ -329,7 +475,42 @@ func (a *MultiArgGenericCLI[C, U, R]) multiOperation(args *multiOperationArgs[C,
 		}
 	}
 
-	return results, results.ToError(args.joinErrors)
+	bulkErr := results.ToError(args.joinErrors)
+
+	if state != nil && bulkErr == nil {
+		err := state.remove()
+		if err != nil {
+			return results, fmt.Errorf("unable to remove state file: %w", err)
+		}
+	}
+
+	if a.onError != nil {
+		summary := a.onError.summary(succeeded, failed, total)
+
+		fmt.Fprintf(os.Stderr, "%s\n", summary)
+
+		if bulkErr != nil {
+			if a.onError.tolerates(succeeded, total) {
+				return results, nil
+			}
+			return results, fmt.Errorf("%s: %w", summary, bulkErr)
+		}
+	}
+
+	return results, bulkErr
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) doWithRetry(op multiOperation[C, U, R], doc R) BulkResult[R] {
+	for attempt := 1; ; attempt++ {
+		result := op.do(a.crud, doc)
+		result.Attempts = attempt
+
+		if !a.retry.shouldRetry(attempt, result.Error) {
+			return result
+		}
+
+		a.retry.wait(attempt)
+	}
 }
 
 func (m multiOperationCreate[C, U, R]) verb() string { //nolint:unused
@@ -408,3 +589,108 @@ func (m multiOperationDelete[C, U, R]) do(crud MultiArgCRUD[C, U, R], doc R) Bul
 
 	return BulkResult[R]{Action: BulkDeleted, Result: result}
 }
+
+func (a *MultiArgGenericCLI[C, U, R]) readDocuments(from string) ([]R, error) {
+	if a.schema == nil {
+		return a.parser.ReadAll(from)
+	}
+
+	raw, err := a.parser.readRaw(from)
+	if err != nil {
+		return nil, err
+	}
+
+	validate := raw
+	if isJSON(raw) {
+		validate, err = jsonToYAMLDocuments(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.schema.ValidateYAML(validate)
+	if err != nil {
+		return nil, NewError(ErrorKindValidation, fmt.Errorf("schema validation failed: %w", err))
+	}
+
+	return a.parser.decodeAll(bytes.NewReader(raw))
+}
+
+// streamDocuments returns the documents of a given file one by one, every document is validated right before it is returned.
+// As in readDocuments, the schema validation takes place on the raw documents, such that unknown fields are detected.
+func (a *MultiArgGenericCLI[C, U, R]) streamDocuments(from string, op multiOperation[C, U, R]) iter.Seq2[R, error] {
+	_, isDelete := op.(multiOperationDelete[C, U, R])
+	validator, hasValidator := a.validator()
+
+	docs := a.parser.Stream(from)
+	if a.schema != nil {
+		docs = a.validatedStream(from)
+	}
+
+	return func(yield func(R, error) bool) {
+		index := -1
+
+		for doc, err := range docs {
+			index++
+
+			if err == nil && hasValidator && !isDelete {
+				if validationErr := validator.Validate(doc); validationErr != nil {
+					err = NewError(ErrorKindValidation, fmt.Errorf("validation failed: document %d: %w", index, validationErr))
+				}
+			}
+
+			if !yield(doc, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// validatedStream validates every raw document against the schema before it gets decoded.
+func (a *MultiArgGenericCLI[C, U, R]) validatedStream(from string) iter.Seq2[R, error] {
+	return func(yield func(R, error) bool) {
+		var (
+			zero  R
+			index = -1
+		)
+
+		for raw, err := range a.parser.streamRaw(from) {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			index++
+
+			if errs := a.schema.validateDocument(index, raw); len(errs) > 0 {
+				yield(zero, NewError(ErrorKindValidation, fmt.Errorf("schema validation failed: %w", errors.Join(errs...))))
+				return
+			}
+
+			var doc R
+			err = sigsyaml.Unmarshal(raw.raw, &doc)
+			if err != nil {
+				yield(zero, fmt.Errorf("decode error: %w", err))
+				return
+			}
+
+			if pointer.IsZero(doc) {
+				continue
+			}
+
+			if !yield(doc, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) validator() (Validator[R], bool) {
+	if mapper, ok := a.crud.(multiArgMapper[C, U, R]); ok {
+		validator, ok := mapper.singleArg.(Validator[R])
+		return validator, ok
+	}
+
+	validator, ok := a.crud.(Validator[R])
+	return validator, ok
+}
@@ -3,6 +3,7 @@ package genericcli
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -41,7 +42,8 @@ func TestApplyFromFile(t *testing.T) {
 			},
 			want: BulkResults[*testResponse]{
 				{
-					Action: BulkCreated,
+					Action:   BulkCreated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "1",
 						Name: "one",
@@ -76,14 +78,16 @@ name: two
 			},
 			want: BulkResults[*testResponse]{
 				{
-					Action: BulkCreated,
+					Action:   BulkCreated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "1",
 						Name: "one",
 					},
 				},
 				{
-					Action: BulkCreated,
+					Action:   BulkCreated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "2",
 						Name: "two",
@@ -123,14 +127,16 @@ name: two
 			},
 			want: BulkResults[*testResponse]{
 				{
-					Action: BulkCreated,
+					Action:   BulkCreated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "1",
 						Name: "one",
 					},
 				},
 				{
-					Action: BulkUpdated,
+					Action:   BulkUpdated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "2",
 						Name: "two",
@@ -170,11 +176,13 @@ name: two
 			},
 			want: BulkResults[*testResponse]{
 				{
-					Action: BulkErrorOnCreate,
-					Error:  fmt.Errorf("error creating entity: creation error for id 1"),
+					Action:   BulkErrorOnCreate,
+					Attempts: 1,
+					Error:    fmt.Errorf("error creating entity: creation error for id 1"),
 				},
 				{
-					Action: BulkUpdated,
+					Action:   BulkUpdated,
+					Attempts: 1,
 					Result: &testResponse{
 						ID:   "2",
 						Name: "two",
@@ -277,6 +285,7 @@ func newMockCLI(t *testing.T, mockFn func(mock *mockTestClient), fileMockFn func
 		crud:   testCRUD{client: client},
 		fs:     fs,
 		parser: MultiDocumentYAML[*testResponse]{fs: fs},
+		clock:  realClock{},
 	}
 
 	if mockFn != nil {
@@ -295,3 +304,183 @@ func mustMarshal(t *testing.T, d any) []byte {
 	require.NoError(t, err)
 	return b
 }
+
+func TestApplyFromFileWithStateFile(t *testing.T) {
+	const (
+		testFile  = "/apply.yaml"
+		stateFile = "/apply.yaml.state"
+	)
+
+	fileMockFn := func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, []byte(`---
+id: "1"
+name: one
+---
+id: "2"
+name: two
+`), 0755))
+	}
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+		mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("service unavailable")).Once()
+	}, fileMockFn).WithStateFile(stateFile, false)
+
+	_, err := cli.ApplyFromFile(testFile)
+	if diff := cmp.Diff(fmt.Errorf("error creating entity: service unavailable"), err, testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("error diff (+got -want):\n %s", diff)
+	}
+
+	header, entries, err := readBulkState(cli.fs, stateFile)
+	require.NoError(t, err)
+	require.Equal(t, "applying", header.Operation)
+	require.Len(t, entries, 1)
+	require.Equal(t, 0, entries[0].Document)
+	require.Equal(t, []string{"1"}, entries[0].ID)
+	require.Equal(t, BulkCreated, entries[0].Action)
+
+	// the first document must not be created again when resuming
+	resumed := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(&testResponse{ID: "2", Name: "two"}, nil).Once()
+	}, nil).WithStateFile(stateFile, true)
+	resumed.fs = cli.fs
+	resumed.parser = cli.parser
+
+	got, err := resumed.ApplyFromFile(testFile)
+	require.NoError(t, err)
+
+	want := BulkResults[*testResponse]{
+		{
+			Action:   BulkCreated,
+			Attempts: 1,
+			Result:   &testResponse{ID: "2", Name: "two"},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(BulkResult[*testResponse]{}, "Duration")); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	exists, err := afero.Exists(cli.fs, stateFile)
+	require.NoError(t, err)
+	require.False(t, exists, "state file must be removed after successful completion")
+}
+
+func TestApplyFromFileWithClock(t *testing.T) {
+	const testFile = "/apply.yaml"
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil)
+	}, func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, mustMarshal(t, &testCreate{
+			ID:   "1",
+			Name: "one",
+		}), 0755))
+	}).WithClock(NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1500*time.Millisecond))
+
+	got, err := cli.ApplyFromFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, 1500*time.Millisecond, got[0].Duration)
+}
+
+func TestApplyFromFileWithOnError(t *testing.T) {
+	const testFile = "/apply.yaml"
+
+	fileMockFn := func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, []byte(`---
+id: "1"
+name: one
+---
+id: "2"
+name: two
+---
+id: "3"
+name: three
+`), 0755))
+	}
+
+	tests := []struct {
+		name        string
+		policy      string
+		mockFn      func(mock *mockTestClient)
+		wantResults int
+		wantErr     error
+	}{
+		{
+			name:   "fail aborts on first error",
+			policy: "fail",
+			mockFn: func(mock *mockTestClient) {
+				mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+				mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("boom")).Once()
+			},
+			wantResults: 2,
+			wantErr:     fmt.Errorf("1 of 3 documents succeeded (33.3%%), 1 failed, 1 not processed: %w", errors.New("error creating entity: boom")),
+		},
+		{
+			name:   "continue processes all documents",
+			policy: "continue",
+			mockFn: func(mock *mockTestClient) {
+				mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+				mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("boom")).Once()
+				mock.On("Create", &testCreate{ID: "3", Name: "three"}).Return(&testResponse{ID: "3", Name: "three"}, nil).Once()
+			},
+			wantResults: 3,
+			wantErr:     fmt.Errorf("2 of 3 documents succeeded (66.7%%), 1 failed: %w", errors.New("error creating entity: boom")),
+		},
+		{
+			name:   "threshold reached",
+			policy: "threshold=60",
+			mockFn: func(mock *mockTestClient) {
+				mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+				mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("boom")).Once()
+				mock.On("Create", &testCreate{ID: "3", Name: "three"}).Return(&testResponse{ID: "3", Name: "three"}, nil).Once()
+			},
+			wantResults: 3,
+		},
+		{
+			name:   "threshold can no longer be reached",
+			policy: "threshold=80",
+			mockFn: func(mock *mockTestClient) {
+				mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+				mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("boom")).Once()
+			},
+			wantResults: 2,
+			wantErr:     fmt.Errorf("1 of 3 documents succeeded (33.3%%), 1 failed, 1 not processed, threshold of 80%% not reached: %w", errors.New("error creating entity: boom")),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseOnErrorPolicy(tt.policy)
+			require.NoError(t, err)
+
+			cli := newMockCLI(t, tt.mockFn, fileMockFn).WithOnError(policy)
+
+			got, err := cli.ApplyFromFile(testFile)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			require.Len(t, got, tt.wantResults)
+		})
+	}
+}
+
+func TestApplyFromFileWithStreaming(t *testing.T) {
+	const testFile = "/apply.ndjson"
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+		mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("boom")).Once()
+		mock.On("Create", &testCreate{ID: "3", Name: "three"}).Return(&testResponse{ID: "3", Name: "three"}, nil).Once()
+	}, func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, []byte(`{"id":"1","name":"one"}
+{"id":"2","name":"two"}
+{"id":"3","name":"three"}
+`), 0755))
+	}).WithStreaming()
+
+	got, err := cli.ApplyFromFile(testFile)
+	require.EqualError(t, err, "error creating entity: boom")
+	require.Len(t, got, 1, "only failed operations are returned when streaming")
+	require.Equal(t, BulkErrorOnCreate, got[0].Action)
+}
@@ -1,10 +1,13 @@
 package genericcli
 
 import (
+	"context"
 	"io"
+	"os"
 
 	"github.com/metal-stack/metal-lib/pkg/multisort"
 	"github.com/spf13/afero"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MultiArgGenericCLI can be used to gain generic CLI functionality.
@@ -21,6 +24,20 @@ type MultiArgGenericCLI[C any, U any, R any] struct {
 	bulkPrint          bool
 	bulkSecurityPrompt *PromptConfig
 	timestamps         bool
+	diffOut            io.Writer
+	dryRun             bool
+	schema             *JSONSchema
+	stateFile          string
+	resume             bool
+	retry              *RetryPolicy
+	onError            *OnErrorPolicy
+	streaming          bool
+	history            *history
+	readCache          *ReadCache
+	clock              Clock
+	ids                IDGenerator
+	traceCtx           context.Context
+	tracer             trace.Tracer
 }
 
 // MultiArgCRUD must be implemented in order to get generic CLI functionality.
@@ -44,6 +61,22 @@ type MultiArgCRUD[C any, U any, R any] interface {
 	Convert(r R) ([]string, C, U, error)
 }
 
+// Validator can optionally be implemented along with the CRUD interface in order to validate entities from files
+// before they are created, updated or applied, e.g. through a server-side dry-run.
+// All entities of a file are validated before the first operation is performed such that invalid bulk files fail fast.
+type Validator[R any] interface {
+	// Validate returns an error if the given entity is invalid.
+	Validate(doc R) error
+}
+
+// ListStreamer can optionally be implemented along with the CRUD interface in order to stream entities during list operations.
+// This is useful for very large lists, which can be printed without holding all entities in memory.
+type ListStreamer[R any] interface {
+	// ListStream calls the given function for every entity as soon as it arrives, e.g. page-wise from the backend.
+	// Streaming stops as soon as the function returns an error.
+	ListStream(fn func(R) error) error
+}
+
 // NewGenericMultiArgCLI returns a new generic cli.
 //
 // C is the create request for an entity.
@@ -56,12 +89,14 @@ func NewGenericMultiArgCLI[C any, U any, R any](crud MultiArgCRUD[C, U, R]) *Mul
 		fs:        fs,
 		parser:    MultiDocumentYAML[R]{fs: fs},
 		bulkPrint: false,
+		clock:     realClock{},
+		ids:       uuidGenerator{},
 	}
 }
 
 func (a *MultiArgGenericCLI[C, U, R]) WithFS(fs afero.Fs) *MultiArgGenericCLI[C, U, R] {
 	a.fs = fs
-	a.parser = MultiDocumentYAML[R]{fs: fs}
+	a.parser.fs = fs
 	return a
 }
 
@@ -92,6 +127,95 @@ func (a *MultiArgGenericCLI[C, U, R]) WithTimestamps() *MultiArgGenericCLI[C, U,
 	return a
 }
 
+// WithDiff prints a diff between the server-side state and the desired entity before applying, updating or editing an entity.
+func (a *MultiArgGenericCLI[C, U, R]) WithDiff(out io.Writer) *MultiArgGenericCLI[C, U, R] {
+	if out == nil {
+		out = os.Stdout
+	}
+	a.diffOut = out
+	return a
+}
+
+// WithDryRun shows the result of a patch without updating the entity, see Patch.
+func (a *MultiArgGenericCLI[C, U, R]) WithDryRun() *MultiArgGenericCLI[C, U, R] {
+	a.dryRun = true
+	return a
+}
+
+// WithSchema validates the documents of files against the given JSON schema before any operation is performed.
+func (a *MultiArgGenericCLI[C, U, R]) WithSchema(schema *JSONSchema) *MultiArgGenericCLI[C, U, R] {
+	a.schema = schema
+	return a
+}
+
+// WithStateFile records the documents that were processed successfully during a bulk operation in the given state file.
+// When resume is set, documents recorded in an existing state file are skipped such that interrupted operations do not redo work.
+// The state file is removed after all documents were processed successfully.
+func (a *MultiArgGenericCLI[C, U, R]) WithStateFile(path string, resume bool) *MultiArgGenericCLI[C, U, R] {
+	a.stateFile = path
+	a.resume = resume
+	return a
+}
+
+// WithRetry retries failed operations according to the given retry policy, which also applies to every single operation of a bulk operation.
+func (a *MultiArgGenericCLI[C, U, R]) WithRetry(policy *RetryPolicy) *MultiArgGenericCLI[C, U, R] {
+	a.retry = policy
+	return a
+}
+
+// WithOnError sets the policy for dealing with errors during bulk operations, which controls the early abort and the returned error.
+// When a policy is set, a summary of the bulk operation is printed to stderr.
+func (a *MultiArgGenericCLI[C, U, R]) WithOnError(policy *OnErrorPolicy) *MultiArgGenericCLI[C, U, R] {
+	a.onError = policy
+	return a
+}
+
+// WithStreaming processes the documents of bulk operations one by one while they are read, such that huge files
+// or streams from stdin can be processed with constant memory. Besides multi-document YAML, streams of JSON documents
+// (e.g. NDJSON) are supported.
+//
+// As documents are not read upfront, validation happens right before a document is processed, bulk printing falls back
+// to printing every result immediately and the returned bulk results only contain the failed operations.
+func (a *MultiArgGenericCLI[C, U, R]) WithStreaming() *MultiArgGenericCLI[C, U, R] {
+	a.streaming = true
+	return a
+}
+
+// WithHistory records the previous state of deleted and updated entities in the given history file, such that
+// these operations can be reverted with Undo. Use HistoryPath for placing the file in the config directory of the cli.
+func (a *MultiArgGenericCLI[C, U, R]) WithHistory(path string) *MultiArgGenericCLI[C, U, R] {
+	a.history = newHistory(path)
+	return a
+}
+
+// WithEnvSubstitution substitutes references to environment variables like ${VAR} or ${VAR:-default} in files
+// before the documents are decoded, see SubstituteEnv.
+func (a *MultiArgGenericCLI[C, U, R]) WithEnvSubstitution() *MultiArgGenericCLI[C, U, R] {
+	a.parser.WithEnvSubstitution()
+	return a
+}
+
+// WithTracing creates spans around the calls of the CRUD implementation and around every document of bulk operations.
+// The spans are children of the span in the given context and are recorded by its tracer provider, such that
+// tracing is a no-op if the context carries no span, see ExecuteWithTracing.
+func (a *MultiArgGenericCLI[C, U, R]) WithTracing(ctx context.Context) *MultiArgGenericCLI[C, U, R] {
+	a.traceCtx = ctx
+	a.tracer = trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return a
+}
+
+// WithClock replaces the clock that is used for measuring durations and timestamps, which is useful for golden tests.
+func (a *MultiArgGenericCLI[C, U, R]) WithClock(clock Clock) *MultiArgGenericCLI[C, U, R] {
+	a.clock = clock
+	return a
+}
+
+// WithIDGenerator replaces the generator of ids like the run ids of history entries, which is useful for golden tests.
+func (a *MultiArgGenericCLI[C, U, R]) WithIDGenerator(ids IDGenerator) *MultiArgGenericCLI[C, U, R] {
+	a.ids = ids
+	return a
+}
+
 // Interface returns the interface that was used to create this generic cli.
 func (a *MultiArgGenericCLI[C, U, R]) Interface() MultiArgCRUD[C, U, R] {
 	return a.crud
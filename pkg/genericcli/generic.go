@@ -1,6 +1,7 @@
 package genericcli
 
 import (
+	"context"
 	"io"
 
 	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
@@ -79,6 +80,91 @@ func (a *GenericCLI[C, U, R]) WithTimestamps() *GenericCLI[C, U, R] {
 	return a
 }
 
+// WithDiff prints a diff between the server-side state and the desired entity before applying, updating or editing an entity.
+func (a *GenericCLI[C, U, R]) WithDiff(out io.Writer) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithDiff(out)
+	return a
+}
+
+// WithDryRun shows the result of a patch without updating the entity, see Patch.
+func (a *GenericCLI[C, U, R]) WithDryRun() *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithDryRun()
+	return a
+}
+
+// WithSchema validates the documents of files against the given JSON schema before any operation is performed.
+func (a *GenericCLI[C, U, R]) WithSchema(schema *JSONSchema) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithSchema(schema)
+	return a
+}
+
+// WithStateFile records the documents that were processed successfully during a bulk operation in the given state file.
+// When resume is set, documents recorded in an existing state file are skipped such that interrupted operations do not redo work.
+func (a *GenericCLI[C, U, R]) WithStateFile(path string, resume bool) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithStateFile(path, resume)
+	return a
+}
+
+// WithRetry retries failed operations according to the given retry policy, which also applies to every single operation of a bulk operation.
+func (a *GenericCLI[C, U, R]) WithRetry(policy *RetryPolicy) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithRetry(policy)
+	return a
+}
+
+// WithOnError sets the policy for dealing with errors during bulk operations, which controls the early abort and the returned error.
+// When a policy is set, a summary of the bulk operation is printed to stderr.
+func (a *GenericCLI[C, U, R]) WithOnError(policy *OnErrorPolicy) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithOnError(policy)
+	return a
+}
+
+// WithStreaming processes the documents of bulk operations one by one while they are read, such that huge files
+// or streams from stdin can be processed with constant memory.
+func (a *GenericCLI[C, U, R]) WithStreaming() *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithStreaming()
+	return a
+}
+
+// WithHistory records the previous state of deleted and updated entities in the given history file, such that
+// these operations can be reverted with Undo. Use HistoryPath for placing the file in the config directory of the cli.
+func (a *GenericCLI[C, U, R]) WithHistory(path string) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithHistory(path)
+	return a
+}
+
+// WithReadCache caches the results of list and describe operations, which are served when the api is unreachable or
+// the cache is offline, see ReadCache.
+func (a *GenericCLI[C, U, R]) WithReadCache(cache *ReadCache) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithReadCache(cache)
+	return a
+}
+
+// WithEnvSubstitution substitutes references to environment variables like ${VAR} or ${VAR:-default} in files
+// before the documents are decoded, see SubstituteEnv.
+func (a *GenericCLI[C, U, R]) WithEnvSubstitution() *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithEnvSubstitution()
+	return a
+}
+
+// WithTracing creates spans around the calls of the CRUD implementation and around every document of bulk operations
+// as children of the span in the given context, see ExecuteWithTracing.
+func (a *GenericCLI[C, U, R]) WithTracing(ctx context.Context) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithTracing(ctx)
+	return a
+}
+
+// WithClock replaces the clock that is used for measuring durations and timestamps, which is useful for golden tests.
+func (a *GenericCLI[C, U, R]) WithClock(clock Clock) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithClock(clock)
+	return a
+}
+
+// WithIDGenerator replaces the generator of ids like the run ids of history entries, which is useful for golden tests.
+func (a *GenericCLI[C, U, R]) WithIDGenerator(ids IDGenerator) *GenericCLI[C, U, R] {
+	a.multiCLI = a.multiCLI.WithIDGenerator(ids)
+	return a
+}
+
 // Interface returns the interface that was used to create this generic cli.
 func (a *GenericCLI[C, U, R]) Interface() MultiArgCRUD[C, U, R] {
 	return a.multiCLI.Interface()
@@ -149,6 +235,48 @@ func (a *GenericCLI[C, U, R]) DeleteFromFile(from string) (BulkResults[R], error
 func (a *GenericCLI[C, U, R]) DeleteFromFileAndPrint(from string, p printers.Printer) error {
 	return a.multiCLI.DeleteFromFileAndPrint(from, p)
 }
+func (a *GenericCLI[C, U, R]) History() ([]HistoryEntry, error) {
+	return a.multiCLI.History()
+}
+func (a *GenericCLI[C, U, R]) HistoryAndPrint(p printers.Printer) error {
+	return a.multiCLI.HistoryAndPrint(p)
+}
+func (a *GenericCLI[C, U, R]) Undo() (BulkResults[R], error) {
+	return a.multiCLI.Undo()
+}
+func (a *GenericCLI[C, U, R]) UndoAndPrint(p printers.Printer) error {
+	return a.multiCLI.UndoAndPrint(p)
+}
+func (a *GenericCLI[C, U, R]) Label(id string, changes []string) (R, error) {
+	return a.multiCLI.Label(changes, id)
+}
+func (a *GenericCLI[C, U, R]) LabelAndPrint(id string, changes []string, p printers.Printer) error {
+	return a.multiCLI.LabelAndPrint(p, changes, id)
+}
+func (a *GenericCLI[C, U, R]) LabelFromFile(from string) (BulkResults[R], error) {
+	return a.multiCLI.LabelFromFile(from)
+}
+func (a *GenericCLI[C, U, R]) LabelFromFileAndPrint(from string, p printers.Printer) error {
+	return a.multiCLI.LabelFromFileAndPrint(from, p)
+}
+func (a *GenericCLI[C, U, R]) Patch(id string, from string) (R, error) {
+	return a.multiCLI.Patch(from, id)
+}
+func (a *GenericCLI[C, U, R]) PatchAndPrint(id string, from string, p printers.Printer) error {
+	return a.multiCLI.PatchAndPrint(from, p, id)
+}
+func (a *GenericCLI[C, U, R]) Annotate(id string, changes []string) (R, error) {
+	return a.multiCLI.Annotate(changes, id)
+}
+func (a *GenericCLI[C, U, R]) AnnotateAndPrint(id string, changes []string, p printers.Printer) error {
+	return a.multiCLI.AnnotateAndPrint(p, changes, id)
+}
+func (a *GenericCLI[C, U, R]) AnnotateFromFile(from string) (BulkResults[R], error) {
+	return a.multiCLI.AnnotateFromFile(from)
+}
+func (a *GenericCLI[C, U, R]) AnnotateFromFileAndPrint(from string, p printers.Printer) error {
+	return a.multiCLI.AnnotateFromFileAndPrint(from, p)
+}
 
 type multiArgMapper[C any, U any, R any] struct {
 	singleArg CRUD[C, U, R]
@@ -0,0 +1,315 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultHistoryLimit = 100
+
+// HistoryOperation is a destructive operation that is recorded in the history.
+type HistoryOperation string
+
+const (
+	HistoryOperationDelete HistoryOperation = "delete"
+	HistoryOperationUpdate HistoryOperation = "update"
+)
+
+// HistoryEntry is a record of a destructive operation, which contains the entity as it was before the operation.
+type HistoryEntry struct {
+	// Run groups the entries that were recorded during a single invocation of the cli.
+	Run       string           `json:"run"`
+	Timestamp time.Time        `json:"timestamp"`
+	Operation HistoryOperation `json:"operation"`
+	ID        []string         `json:"id"`
+	// Previous is the yaml representation of the entity before the operation.
+	Previous string `json:"previous"`
+}
+
+type history struct {
+	path  string
+	limit int
+	// run is assigned on the first recorded operation of a cli invocation
+	run string
+}
+
+func newHistory(path string) *history {
+	return &history{
+		path:  path,
+		limit: defaultHistoryLimit,
+	}
+}
+
+// HistoryPath returns the path of a history file for the given entity in the given directory, e.g. the config directory of a cli.
+func HistoryPath(dir, entity string) string {
+	return filepath.Join(dir, "history", entity+".yaml")
+}
+
+func (h *history) entries(fs afero.Fs) ([]HistoryEntry, error) {
+	raw, err := afero.ReadFile(fs, h.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read history file: %w", err)
+	}
+
+	var entries []HistoryEntry
+	err = yaml.Unmarshal(raw, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse history file %s: %w", h.path, err)
+	}
+
+	return entries, nil
+}
+
+func (h *history) record(fs afero.Fs, now time.Time, op HistoryOperation, id []string, previous any) error {
+	raw, err := yaml.Marshal(previous)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.entries(fs)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, HistoryEntry{
+		Run:       h.run,
+		Timestamp: now,
+		Operation: op,
+		ID:        id,
+		Previous:  string(raw),
+	})
+
+	if len(entries) > h.limit {
+		entries = entries[len(entries)-h.limit:]
+	}
+
+	return h.write(fs, entries)
+}
+
+// lastRun returns the entries of the most recent run.
+func (h *history) lastRun(fs afero.Fs) ([]HistoryEntry, error) {
+	entries, err := h.entries(fs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	run := entries[len(entries)-1].Run
+
+	var res []HistoryEntry
+	for _, e := range entries {
+		if e.Run == run {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+// drop removes the given entry from the history.
+func (h *history) drop(fs afero.Fs, entry HistoryEntry) error {
+	entries, err := h.entries(fs)
+	if err != nil {
+		return err
+	}
+
+	var res []HistoryEntry
+	for _, e := range entries {
+		if e.Run == entry.Run && e.Timestamp.Equal(entry.Timestamp) && strings.Join(e.ID, "/") == strings.Join(entry.ID, "/") {
+			continue
+		}
+		res = append(res, e)
+	}
+
+	return h.write(fs, res)
+}
+
+func (h *history) write(fs afero.Fs, entries []HistoryEntry) error {
+	raw, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	err = fs.MkdirAll(filepath.Dir(h.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := h.path + ".tmp"
+
+	err = afero.WriteFile(fs, tmp, raw, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write history file: %w", err)
+	}
+
+	return fs.Rename(tmp, h.path)
+}
+
+// recordHistory records a destructive operation if a history is configured. As the operation already took place,
+// failures are only reported and do not fail the operation.
+func (a *MultiArgGenericCLI[C, U, R]) recordHistory(op HistoryOperation, id []string, previous R) {
+	if a.history == nil {
+		return
+	}
+
+	if a.history.run == "" {
+		a.history.run = a.ids.NewID()
+	}
+
+	err := a.history.record(a.fs, a.clock.Now(), op, id, previous)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to record %s of %q in history: %s\n", op, strings.Join(id, "/"), err)
+	}
+}
+
+// previousForHistory returns the current state of the entity before it gets updated, which is only fetched if a history is configured.
+func (a *MultiArgGenericCLI[C, U, R]) previousForHistory(doc R) (R, bool) {
+	var zero R
+
+	if a.history == nil {
+		return zero, false
+	}
+
+	id, _, _, err := a.crud.Convert(doc)
+	if err != nil {
+		return zero, false
+	}
+
+	previous, err := a.crud.Get(id...)
+	if err != nil {
+		return zero, false
+	}
+
+	return previous, true
+}
+
+// History returns the recorded destructive operations, oldest first.
+func (a *MultiArgGenericCLI[C, U, R]) History() ([]HistoryEntry, error) {
+	if a.history == nil {
+		return nil, fmt.Errorf("no history configured")
+	}
+
+	return a.history.entries(a.fs)
+}
+
+// HistoryAndPrint prints the recorded destructive operations as a table.
+func (a *MultiArgGenericCLI[C, U, R]) HistoryAndPrint(p printers.Printer) error {
+	entries, err := a.History()
+	if err != nil {
+		return err
+	}
+
+	return p.Print(entries)
+}
+
+// HistoryTable returns the table representation of history entries for a table printer.
+func HistoryTable(data any, _ bool) ([]string, [][]string, error) {
+	entries, ok := data.([]HistoryEntry)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported content: %T", data)
+	}
+
+	var rows [][]string
+	for _, e := range entries {
+		rows = append(rows, []string{e.Timestamp.Format(time.RFC3339), string(e.Operation), strings.Join(e.ID, "/"), e.Run})
+	}
+
+	return []string{"Time", "Operation", "ID", "Run"}, rows, nil
+}
+
+// Undo reverts the operations of the most recent run in the history, deleted entities are created again and
+// updated entities are updated to their previous state. Reverted operations are removed from the history.
+func (a *MultiArgGenericCLI[C, U, R]) Undo() (BulkResults[R], error) {
+	if a.history == nil {
+		return nil, fmt.Errorf("no history configured")
+	}
+
+	entries, err := a.history.lastRun(a.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("history is empty, nothing to undo")
+	}
+
+	var results BulkResults[R]
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		var previous R
+		err := yaml.Unmarshal([]byte(entry.Previous), &previous)
+		if err != nil {
+			return results, fmt.Errorf("unable to parse previous state of %q: %w", strings.Join(entry.ID, "/"), err)
+		}
+
+		var result BulkResult[R]
+		switch entry.Operation {
+		case HistoryOperationDelete:
+			result = multiOperationCreate[C, U, R]{}.do(a.crud, previous)
+		case HistoryOperationUpdate:
+			result = multiOperationUpdate[C, U, R]{}.do(a.crud, previous)
+		default:
+			return results, fmt.Errorf("unsupported history operation: %s", entry.Operation)
+		}
+
+		results = append(results, result)
+
+		if result.Error != nil {
+			continue
+		}
+
+		err = a.history.drop(a.fs, entry)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, results.ToError(true)
+}
+
+// undoPreview returns the states the entities of the most recent run in the history are restored to.
+func (a *MultiArgGenericCLI[C, U, R]) undoPreview() ([]byte, error) {
+	if a.history == nil {
+		return nil, fmt.Errorf("no history configured")
+	}
+
+	entries, err := a.history.lastRun(a.fs)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []string
+	for _, entry := range entries {
+		docs = append(docs, strings.TrimSpace(entry.Previous))
+	}
+
+	return []byte(strings.Join(docs, "\n---\n")), nil
+}
+
+// UndoAndPrint reverts the operations of the most recent run in the history and prints the restored entities.
+func (a *MultiArgGenericCLI[C, U, R]) UndoAndPrint(p printers.Printer) error {
+	results, err := a.Undo()
+	for _, r := range results {
+		r := r
+		if r.Error == nil {
+			r.Print(p)
+		}
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package genericcli
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteFromFileAndUndo(t *testing.T) {
+	const (
+		testFile    = "/delete.yaml"
+		historyFile = "/config/history/test.yaml"
+	)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Delete", "1").Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+		mock.On("Delete", "2").Return(&testResponse{ID: "2", Name: "two"}, nil).Once()
+		mock.On("Get", "3").Return(&testResponse{ID: "3", Name: "three"}, nil).Once()
+		mock.On("Update", &testUpdate{ID: "3", Name: "drei"}).Return(&testResponse{ID: "3", Name: "drei"}, nil).Once()
+	}, func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, []byte(`---
+id: "1"
+name: one
+---
+id: "2"
+name: two
+`), 0755))
+		require.NoError(t, afero.WriteFile(fs, "/update.yaml", []byte(`---
+id: "3"
+name: drei
+`), 0755))
+	}).WithHistory(historyFile).WithClock(NewFakeClock(now, 0)).WithIDGenerator(NewFakeIDGenerator("run"))
+
+	_, err := cli.UpdateFromFile("/update.yaml")
+	require.NoError(t, err)
+
+	// every cli invocation is a new run
+	cli.history.run = ""
+
+	_, err = cli.DeleteFromFile(testFile)
+	require.NoError(t, err)
+
+	entries, err := cli.History()
+	require.NoError(t, err)
+
+	want := []HistoryEntry{
+		{Run: "run-1", Timestamp: now, Operation: HistoryOperationUpdate, ID: []string{"3"}, Previous: "id: \"3\"\nname: three\n"},
+		{Run: "run-2", Timestamp: now, Operation: HistoryOperationDelete, ID: []string{"1"}, Previous: "id: \"1\"\nname: one\n"},
+		{Run: "run-2", Timestamp: now, Operation: HistoryOperationDelete, ID: []string{"2"}, Previous: "id: \"2\"\nname: two\n"},
+	}
+	if diff := cmp.Diff(want, entries); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	// undo only reverts the most recent run in reverse order
+	undo := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(&testResponse{ID: "2", Name: "two"}, nil).Once()
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(nil, fmt.Errorf("quota exceeded")).Once()
+	}, nil).WithHistory(historyFile)
+	undo.fs = cli.fs
+
+	got, err := undo.Undo()
+	require.EqualError(t, err, "error creating entity: quota exceeded")
+	require.Len(t, got, 2)
+
+	entries, err = undo.History()
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "only successfully reverted operations are removed from the history")
+	require.Equal(t, []string{"3"}, entries[0].ID)
+	require.Equal(t, []string{"1"}, entries[1].ID)
+}
+
+func TestHistoryLimit(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	h := newHistory("/history.yaml")
+	h.limit = 2
+
+	for i := range 3 {
+		require.NoError(t, h.record(fs, time.Now(), HistoryOperationDelete, []string{fmt.Sprintf("%d", i)}, &testResponse{ID: fmt.Sprintf("%d", i)}))
+	}
+
+	entries, err := h.entries(fs)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, []string{"1"}, entries[0].ID)
+	require.Equal(t, []string{"2"}, entries[1].ID)
+}
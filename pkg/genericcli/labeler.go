@@ -0,0 +1,294 @@
+package genericcli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Labeler can optionally be implemented along with the CRUD interface in order to edit the labels of entities
+// with the label command and to show labels as columns of the list table.
+type Labeler[R any] interface {
+	// GetLabels returns the labels of the given entity.
+	GetLabels(r R) map[string]string
+	// SetLabels replaces the labels of the given entity and returns the updated entity.
+	SetLabels(r R, labels map[string]string) (R, error)
+}
+
+// Annotator can optionally be implemented along with the CRUD interface in order to edit the annotations of entities
+// with the annotate command.
+type Annotator[R any] interface {
+	// GetAnnotations returns the annotations of the given entity.
+	GetAnnotations(r R) map[string]string
+	// SetAnnotations replaces the annotations of the given entity and returns the updated entity.
+	SetAnnotations(r R, annotations map[string]string) (R, error)
+}
+
+// KeyValueChanges are changes to key value pairs like labels and annotations, see ParseKeyValueChanges.
+type KeyValueChanges struct {
+	Set    map[string]string
+	Remove []string
+}
+
+// ParseKeyValueChanges parses changes in the form key=value for setting and key- for removing a key.
+func ParseKeyValueChanges(changes []string) (*KeyValueChanges, error) {
+	res := &KeyValueChanges{
+		Set: map[string]string{},
+	}
+
+	for _, change := range changes {
+		key, value, found := strings.Cut(change, "=")
+
+		switch {
+		case found && key != "":
+			res.Set[key] = value
+		case !found && len(change) > 1 && strings.HasSuffix(change, "-"):
+			res.Remove = append(res.Remove, strings.TrimSuffix(change, "-"))
+		default:
+			return nil, fmt.Errorf("changes must be in the form <key>=<value> or <key>-, found: %s", change)
+		}
+	}
+
+	for _, key := range res.Remove {
+		if _, ok := res.Set[key]; ok {
+			return nil, fmt.Errorf("key %q cannot be set and removed at the same time", key)
+		}
+	}
+
+	if len(res.Set) == 0 && len(res.Remove) == 0 {
+		return nil, fmt.Errorf("no changes given")
+	}
+
+	return res, nil
+}
+
+// Apply returns a copy of the given key value pairs with the changes applied.
+func (c *KeyValueChanges) Apply(current map[string]string) map[string]string {
+	res := maps.Clone(current)
+	if res == nil {
+		res = map[string]string{}
+	}
+
+	for _, key := range c.Remove {
+		delete(res, key)
+	}
+	maps.Copy(res, c.Set)
+
+	return res
+}
+
+// keyValues provides access to the labels or the annotations of an entity.
+type keyValues[R any] struct {
+	name string
+	op   string
+	verb string
+	get  func(R) map[string]string
+	set  func(R, map[string]string) (R, error)
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) labels() (*keyValues[R], bool) {
+	l, ok := optionalCRUD[Labeler[R]](a.crud)
+	if !ok {
+		return nil, false
+	}
+
+	return &keyValues[R]{name: "labels", op: "label", verb: "labeling", get: l.GetLabels, set: l.SetLabels}, true
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) annotations() (*keyValues[R], bool) {
+	an, ok := optionalCRUD[Annotator[R]](a.crud)
+	if !ok {
+		return nil, false
+	}
+
+	return &keyValues[R]{name: "annotations", op: "annotate", verb: "annotating", get: an.GetAnnotations, set: an.SetAnnotations}, true
+}
+
+// optionalCRUD returns the CRUD implementation as T if it implements this optional interface.
+func optionalCRUD[T any, C any, U any, R any](crud MultiArgCRUD[C, U, R]) (T, bool) {
+	if mapper, ok := crud.(multiArgMapper[C, U, R]); ok {
+		t, ok := mapper.singleArg.(T)
+		return t, ok
+	}
+
+	t, ok := crud.(T)
+	return t, ok
+}
+
+// Label applies the given changes to the labels of the entity with the given id, see ParseKeyValueChanges.
+// The CRUD implementation needs to implement the Labeler interface.
+func (a *MultiArgGenericCLI[C, U, R]) Label(changes []string, id ...string) (R, error) {
+	kv, ok := a.labels()
+	if !ok {
+		var zero R
+		return zero, fmt.Errorf("labels are not supported by this entity")
+	}
+
+	return a.changeKeyValues(kv, changes, id...)
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) LabelAndPrint(p printers.Printer, changes []string, id ...string) error {
+	resp, err := a.Label(changes, id...)
+	if err != nil {
+		return err
+	}
+
+	return p.Print(resp)
+}
+
+// Annotate applies the given changes to the annotations of the entity with the given id, see ParseKeyValueChanges.
+// The CRUD implementation needs to implement the Annotator interface.
+func (a *MultiArgGenericCLI[C, U, R]) Annotate(changes []string, id ...string) (R, error) {
+	kv, ok := a.annotations()
+	if !ok {
+		var zero R
+		return zero, fmt.Errorf("annotations are not supported by this entity")
+	}
+
+	return a.changeKeyValues(kv, changes, id...)
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) AnnotateAndPrint(p printers.Printer, changes []string, id ...string) error {
+	resp, err := a.Annotate(changes, id...)
+	if err != nil {
+		return err
+	}
+
+	return p.Print(resp)
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) changeKeyValues(kv *keyValues[R], changes []string, id ...string) (R, error) {
+	var zero R
+
+	parsed, err := ParseKeyValueChanges(changes)
+	if err != nil {
+		return zero, NewError(ErrorKindValidation, err)
+	}
+
+	current, err := a.Describe(id...)
+	if err != nil {
+		return zero, err
+	}
+
+	end := a.startSpan(kv.op, attribute.StringSlice("id", id))
+	resp, _, err := retry(a.retry, func() (R, error) { return kv.set(current, parsed.Apply(kv.get(current))) })
+	end(err)
+	if err != nil {
+		return zero, err
+	}
+
+	a.recordHistory(HistoryOperationUpdate, id, current)
+
+	return resp, nil
+}
+
+// LabelFromFile adds the labels of the entities contained in the given file to the existing entities,
+// existing labels with the same keys are overwritten.
+func (a *MultiArgGenericCLI[C, U, R]) LabelFromFile(from string) (BulkResults[R], error) {
+	kv, ok := a.labels()
+	if !ok {
+		return nil, fmt.Errorf("labels are not supported by this entity")
+	}
+
+	return a.multiOperation(&multiOperationArgs[C, U, R]{
+		from:       from,
+		op:         multiOperationKeyValues[C, U, R]{kv: kv},
+		joinErrors: true,
+	})
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) LabelFromFileAndPrint(from string, p printers.Printer) error {
+	kv, ok := a.labels()
+	if !ok {
+		return fmt.Errorf("labels are not supported by this entity")
+	}
+
+	return a.multiOperationPrint(from, p, multiOperationKeyValues[C, U, R]{kv: kv})
+}
+
+// AnnotateFromFile adds the annotations of the entities contained in the given file to the existing entities,
+// existing annotations with the same keys are overwritten.
+func (a *MultiArgGenericCLI[C, U, R]) AnnotateFromFile(from string) (BulkResults[R], error) {
+	kv, ok := a.annotations()
+	if !ok {
+		return nil, fmt.Errorf("annotations are not supported by this entity")
+	}
+
+	return a.multiOperation(&multiOperationArgs[C, U, R]{
+		from:       from,
+		op:         multiOperationKeyValues[C, U, R]{kv: kv},
+		joinErrors: true,
+	})
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) AnnotateFromFileAndPrint(from string, p printers.Printer) error {
+	kv, ok := a.annotations()
+	if !ok {
+		return fmt.Errorf("annotations are not supported by this entity")
+	}
+
+	return a.multiOperationPrint(from, p, multiOperationKeyValues[C, U, R]{kv: kv})
+}
+
+type multiOperationKeyValues[C any, U any, R any] struct {
+	kv *keyValues[R]
+}
+
+func (m multiOperationKeyValues[C, U, R]) verb() string { //nolint:unused
+	return m.kv.verb
+}
+
+func (m multiOperationKeyValues[C, U, R]) do(crud MultiArgCRUD[C, U, R], doc R) BulkResult[R] { //nolint:unused
+	id, _, _, err := crud.Convert(doc)
+	if err != nil {
+		return BulkResult[R]{Action: BulkErrorOnUpdate, Error: fmt.Errorf("error retrieving id from response entity: %w", err)}
+	}
+
+	current, err := crud.Get(id...)
+	if err != nil {
+		return BulkResult[R]{Action: BulkErrorOnUpdate, Error: fmt.Errorf("error getting entity: %w", err)}
+	}
+
+	changes := &KeyValueChanges{Set: m.kv.get(doc)}
+
+	result, err := m.kv.set(current, changes.Apply(m.kv.get(current)))
+	if err != nil {
+		return BulkResult[R]{Action: BulkErrorOnUpdate, Error: fmt.Errorf("error updating %s of entity: %w", m.kv.name, err)}
+	}
+
+	return BulkResult[R]{Action: BulkUpdated, Result: result}
+}
+
+// LabelColumns returns a function for printers.TablePrinter.WithAdditionalColumns, which adds a column for every given
+// label key to the printed list of entities. The CRUD implementation needs to implement the Labeler interface.
+func (a *MultiArgGenericCLI[C, U, R]) LabelColumns(keys []string) (func(data any) ([]string, [][]string, error), error) {
+	kv, ok := a.labels()
+	if !ok {
+		return nil, fmt.Errorf("labels are not supported by this entity")
+	}
+
+	return func(data any) ([]string, [][]string, error) {
+		items, ok := data.([]R)
+		if !ok {
+			return nil, nil, nil
+		}
+
+		rows := make([][]string, 0, len(items))
+		for _, item := range items {
+			labels := kv.get(item)
+
+			var row []string
+			for _, key := range keys {
+				row = append(row, labels[key])
+			}
+
+			rows = append(rows, row)
+		}
+
+		return slices.Clone(keys), rows, nil
+	}, nil
+}
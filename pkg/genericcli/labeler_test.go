@@ -0,0 +1,201 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+type labeledTest struct {
+	ID     string            `json:"id"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// labeledTestCRUD is an in-memory CRUD implementing the Labeler interface.
+type labeledTestCRUD struct {
+	entities map[string]*labeledTest
+}
+
+func (l *labeledTestCRUD) Get(id string) (*labeledTest, error) {
+	e, ok := l.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", id)
+	}
+	return &labeledTest{ID: e.ID, Labels: maps.Clone(e.Labels)}, nil
+}
+
+func (l *labeledTestCRUD) List() ([]*labeledTest, error) {
+	var res []*labeledTest
+	for _, id := range []string{"1", "2"} {
+		e, err := l.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func (l *labeledTestCRUD) Create(rq *labeledTest) (*labeledTest, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (l *labeledTestCRUD) Update(rq *labeledTest) (*labeledTest, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (l *labeledTestCRUD) Delete(id string) (*labeledTest, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (l *labeledTestCRUD) Convert(r *labeledTest) (string, *labeledTest, *labeledTest, error) {
+	return r.ID, r, r, nil
+}
+
+func (l *labeledTestCRUD) GetLabels(r *labeledTest) map[string]string {
+	return r.Labels
+}
+
+func (l *labeledTestCRUD) SetLabels(r *labeledTest, labels map[string]string) (*labeledTest, error) {
+	l.entities[r.ID] = &labeledTest{ID: r.ID, Labels: labels}
+	return l.Get(r.ID)
+}
+
+func newLabeledTestCRUD() *labeledTestCRUD {
+	return &labeledTestCRUD{
+		entities: map[string]*labeledTest{
+			"1": {ID: "1", Labels: map[string]string{"env": "prod", "stage": "1"}},
+			"2": {ID: "2"},
+		},
+	}
+}
+
+func TestParseKeyValueChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []string
+		want    *KeyValueChanges
+		wantErr error
+	}{
+		{
+			name:    "set and remove",
+			changes: []string{"a=b", "c=", "d-"},
+			want: &KeyValueChanges{
+				Set:    map[string]string{"a": "b", "c": ""},
+				Remove: []string{"d"},
+			},
+		},
+		{
+			name:    "values may contain equal signs",
+			changes: []string{"a=b=c"},
+			want: &KeyValueChanges{
+				Set: map[string]string{"a": "b=c"},
+			},
+		},
+		{
+			name:    "invalid change",
+			changes: []string{"a"},
+			wantErr: errors.New("changes must be in the form <key>=<value> or <key>-, found: a"),
+		},
+		{
+			name:    "empty key",
+			changes: []string{"=b"},
+			wantErr: errors.New("changes must be in the form <key>=<value> or <key>-, found: =b"),
+		},
+		{
+			name:    "set and remove same key",
+			changes: []string{"a=b", "a-"},
+			wantErr: errors.New(`key "a" cannot be set and removed at the same time`),
+		},
+		{
+			name:    "no changes",
+			changes: nil,
+			wantErr: errors.New("no changes given"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyValueChanges(tt.changes)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	crud := newLabeledTestCRUD()
+	cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud)
+
+	got, err := cli.Label("1", []string{"env=dev", "stage-", "team=a"})
+	require.NoError(t, err)
+
+	want := &labeledTest{ID: "1", Labels: map[string]string{"env": "dev", "team": "a"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	_, err = cli.Label("1", []string{"env"})
+	require.EqualError(t, err, "changes must be in the form <key>=<value> or <key>-, found: env")
+
+	_, err = cli.Annotate("1", []string{"a=b"})
+	require.EqualError(t, err, "annotations are not supported by this entity")
+}
+
+func TestLabelFromFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/labels.yaml", []byte(`---
+id: "1"
+labels:
+  env: dev
+---
+id: "2"
+labels:
+  env: test
+`), 0755))
+
+	crud := newLabeledTestCRUD()
+	cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud).WithFS(fs)
+
+	results, err := cli.LabelFromFile("/labels.yaml")
+	require.NoError(t, err)
+
+	want := []*labeledTest{
+		{ID: "1", Labels: map[string]string{"env": "dev", "stage": "1"}},
+		{ID: "2", Labels: map[string]string{"env": "test"}},
+	}
+	if diff := cmp.Diff(want, results.ToList()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestLabelColumns(t *testing.T) {
+	crud := newLabeledTestCRUD()
+	cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud)
+
+	columnsFn, err := cli.multiCLI.LabelColumns([]string{"env", "stage"})
+	require.NoError(t, err)
+
+	items, err := cli.List()
+	require.NoError(t, err)
+
+	header, rows, err := columnsFn(items)
+	require.NoError(t, err)
+
+	if diff := cmp.Diff([]string{"env", "stage"}, header); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+	if diff := cmp.Diff([][]string{{"prod", "1"}, {"", ""}}, rows); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
@@ -0,0 +1,87 @@
+package genericcli
+
+import (
+	"fmt"
+
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+)
+
+// ListFooter contains additional information about the listed entities like the quota usage of a project, which is printed
+// below the table of the list command and as metadata of structured outputs.
+type ListFooter struct {
+	// Used is the amount of used entities.
+	Used int `json:"used"`
+	// Limit is the maximum amount of entities, zero means that there is no limit.
+	Limit int `json:"limit,omitempty"`
+	// Resource is the name of the entities, defaults to the plural of the cmds config.
+	Resource string `json:"resource,omitempty"`
+	// Scope is where the usage applies to, e.g. "project X".
+	Scope string `json:"scope,omitempty"`
+	// Message replaces the generated footer line of the table output if not empty.
+	Message string `json:"message,omitempty"`
+}
+
+// String returns the footer line, e.g. "12/20 machines used in project X".
+func (f *ListFooter) String() string {
+	if f.Message != "" {
+		return f.Message
+	}
+
+	line := fmt.Sprintf("%d %s used", f.Used, f.Resource)
+	if f.Limit > 0 {
+		line = fmt.Sprintf("%d/%d %s used", f.Used, f.Limit, f.Resource)
+	}
+
+	if f.Scope != "" {
+		line += " in " + f.Scope
+	}
+
+	return line
+}
+
+// ListWithMetadata is printed by the json and yaml printers of the list command instead of the plain list when a list footer is configured.
+type ListWithMetadata[R any] struct {
+	Items    []R          `json:"items"`
+	Metadata ListMetadata `json:"metadata"`
+}
+
+// ListMetadata is the metadata of a list, see ListWithMetadata.
+type ListMetadata struct {
+	Footer *ListFooter `json:"footer,omitempty"`
+}
+
+// listAndPrintWithFooter lists the entities and prints them together with the footer returned by the ListFooterFn.
+// Only table, json and yaml printers support footers, all other printers print the plain list.
+func (c *CmdsConfig[C, U, R]) listAndPrintWithFooter(p printers.Printer, sortKeys multisort.Keys) error {
+	switch p.(type) {
+	case *printers.TablePrinter, *printers.JSONPrinter, *printers.YAMLPrinter:
+	default:
+		return c.MultiArgGenericCLI.ListAndPrint(p, sortKeys...)
+	}
+
+	resp, err := c.MultiArgGenericCLI.List(sortKeys...)
+	if err != nil {
+		return err
+	}
+
+	footer, err := c.ListFooterFn(resp)
+	if err != nil {
+		return fmt.Errorf("unable to fetch list footer: %w", err)
+	}
+	if footer == nil {
+		return p.Print(resp)
+	}
+	if footer.Resource == "" {
+		footer.Resource = c.Plural
+	}
+
+	if tp, ok := p.(*printers.TablePrinter); ok {
+		return tp.WithFooter(footer.String()).Print(resp)
+	}
+
+	return p.Print(ListWithMetadata[R]{
+		Items:    resp,
+		Metadata: ListMetadata{Footer: footer},
+	})
+}
@@ -0,0 +1,143 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+type listTestCRUD struct {
+	streamingTestCRUD
+}
+
+func (l listTestCRUD) List() ([]*testResponse, error) {
+	return l.items, nil
+}
+
+func TestListAndPrintWithFooter(t *testing.T) {
+	tests := []struct {
+		name     string
+		printer  func(out *bytes.Buffer) printers.Printer
+		footerFn func(items []*testResponse) (*ListFooter, error)
+		want     string
+		wantErr  error
+	}{
+		{
+			name: "table",
+			printer: func(out *bytes.Buffer) printers.Printer {
+				return printers.NewTablePrinter(&printers.TablePrinterConfig{
+					Out: out,
+					ToHeaderAndRows: func(data any, wide bool) ([]string, [][]string, error) {
+						var rows [][]string
+						for _, r := range data.([]*testResponse) {
+							rows = append(rows, []string{r.ID, r.ID})
+						}
+						return []string{"a", "b"}, rows, nil
+					},
+				})
+			},
+			footerFn: func(items []*testResponse) (*ListFooter, error) {
+				return &ListFooter{Used: len(items), Limit: 20, Scope: "project x"}, nil
+			},
+			want: `A   B 
+1   1   
+2   2   
+
+2/20 tests used in project x
+`,
+		},
+		{
+			name: "json",
+			printer: func(out *bytes.Buffer) printers.Printer {
+				return printers.NewJSONPrinter().WithOut(out)
+			},
+			footerFn: func(items []*testResponse) (*ListFooter, error) {
+				return &ListFooter{Used: 12}, nil
+			},
+			want: `{
+    "items": [
+        {
+            "id": "1",
+            "name": "one"
+        },
+        {
+            "id": "2",
+            "name": "two"
+        }
+    ],
+    "metadata": {
+        "footer": {
+            "used": 12,
+            "resource": "tests"
+        }
+    }
+}
+`,
+		},
+		{
+			name: "no footer",
+			printer: func(out *bytes.Buffer) printers.Printer {
+				return printers.NewYAMLPrinter().WithOut(out)
+			},
+			footerFn: func(items []*testResponse) (*ListFooter, error) {
+				return nil, nil
+			},
+			want: `---
+- id: "1"
+  name: one
+- id: "2"
+  name: two
+`,
+		},
+		{
+			name: "footer not supported by printer",
+			printer: func(out *bytes.Buffer) printers.Printer {
+				return printers.NewNDJSONPrinter().WithOut(out)
+			},
+			footerFn: func(items []*testResponse) (*ListFooter, error) {
+				t.Error("footer must not be fetched")
+				return nil, nil
+			},
+			want: `{"id":"1","name":"one"}
+{"id":"2","name":"two"}
+`,
+		},
+		{
+			name: "footer error",
+			printer: func(out *bytes.Buffer) printers.Printer {
+				return printers.NewJSONPrinter().WithOut(out)
+			},
+			footerFn: func(items []*testResponse) (*ListFooter, error) {
+				return nil, fmt.Errorf("quota service unavailable")
+			},
+			wantErr: fmt.Errorf("unable to fetch list footer: %w", errors.New("quota service unavailable")),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				out  bytes.Buffer
+				crud = listTestCRUD{streamingTestCRUD{items: []*testResponse{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}}}
+				c    = &CmdsConfig[*testCreate, *testUpdate, *testResponse]{
+					MultiArgGenericCLI: NewGenericMultiArgCLI[*testCreate, *testUpdate, *testResponse](crud),
+					Plural:             "tests",
+					ListFooterFn:       tt.footerFn,
+				}
+			)
+
+			err := c.listAndPrintWithFooter(tt.printer(&out), nil)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package genericcli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OnErrorMode defines how bulk operations deal with documents that could not be processed.
+type OnErrorMode string
+
+const (
+	// OnErrorFail aborts the bulk operation on the first error.
+	OnErrorFail OnErrorMode = "fail"
+	// OnErrorContinue processes all documents and fails if any error occurred, this is the default.
+	OnErrorContinue OnErrorMode = "continue"
+	// OnErrorThreshold processes documents as long as the threshold can still be reached and succeeds
+	// if the percentage of successfully processed documents reaches the threshold.
+	OnErrorThreshold OnErrorMode = "threshold"
+)
+
+// OnErrorPolicy controls the early abort and the final result of bulk operations.
+type OnErrorPolicy struct {
+	Mode OnErrorMode
+	// Threshold is the percentage of documents that need to be processed successfully, only used in threshold mode.
+	Threshold float64
+}
+
+// ParseOnErrorPolicy parses a policy in the form fail, continue or threshold=<percentage>, e.g. threshold=80.
+func ParseOnErrorPolicy(s string) (*OnErrorPolicy, error) {
+	mode, value, found := strings.Cut(strings.TrimSpace(s), "=")
+
+	switch OnErrorMode(strings.ToLower(mode)) {
+	case OnErrorFail, OnErrorContinue:
+		if found {
+			return nil, fmt.Errorf("on-error policy %q does not take a value", mode)
+		}
+		return &OnErrorPolicy{Mode: OnErrorMode(strings.ToLower(mode))}, nil
+	case OnErrorThreshold:
+		threshold, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if !found || err != nil {
+			return nil, fmt.Errorf("on-error policy threshold requires a percentage, e.g. threshold=80")
+		}
+		if threshold < 0 || threshold > 100 {
+			return nil, fmt.Errorf("on-error threshold must be between 0 and 100, got %s", value)
+		}
+		return &OnErrorPolicy{Mode: OnErrorThreshold, Threshold: threshold}, nil
+	default:
+		return nil, fmt.Errorf("unsupported on-error policy: %q, possible values: fail|continue|threshold=<percentage>", s)
+	}
+}
+
+func (p *OnErrorPolicy) String() string {
+	if p == nil {
+		return string(OnErrorContinue)
+	}
+	if p.Mode == OnErrorThreshold {
+		return fmt.Sprintf("%s=%s", p.Mode, strconv.FormatFloat(p.Threshold, 'f', -1, 64))
+	}
+	return string(p.Mode)
+}
+
+// abort returns true if the bulk operation should not process any further documents.
+func (p *OnErrorPolicy) abort(failed, total int) bool {
+	if p == nil || failed == 0 {
+		return false
+	}
+
+	switch p.Mode {
+	case OnErrorFail:
+		return true
+	case OnErrorThreshold:
+		return percentage(total-failed, total) < p.Threshold
+	default:
+		return false
+	}
+}
+
+// failFast returns true if the bulk operation is aborted on the first error.
+func (p *OnErrorPolicy) failFast() bool {
+	return p != nil && p.Mode == OnErrorFail
+}
+
+// tolerates returns true if the bulk operation is considered successful despite the given amount of failures.
+func (p *OnErrorPolicy) tolerates(succeeded, total int) bool {
+	if p == nil || p.Mode != OnErrorThreshold {
+		return succeeded == total
+	}
+	return percentage(succeeded, total) >= p.Threshold
+}
+
+// summary describes the outcome of a bulk operation with respect to the policy.
+func (p *OnErrorPolicy) summary(succeeded, failed, total int) string {
+	msg := fmt.Sprintf("%d of %d documents succeeded (%s%%), %d failed", succeeded, total, strconv.FormatFloat(percentage(succeeded, total), 'f', 1, 64), failed)
+
+	if skipped := total - succeeded - failed; skipped > 0 {
+		msg += fmt.Sprintf(", %d not processed", skipped)
+	}
+
+	if p != nil && p.Mode == OnErrorThreshold {
+		reached := "not reached"
+		if p.tolerates(succeeded, total) {
+			reached = "reached"
+		}
+		msg += fmt.Sprintf(", threshold of %s%% %s", strconv.FormatFloat(p.Threshold, 'f', -1, 64), reached)
+	}
+
+	return msg
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(part) / float64(total) * 100
+}
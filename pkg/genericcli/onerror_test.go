@@ -0,0 +1,38 @@
+package genericcli
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestParseOnErrorPolicy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    *OnErrorPolicy
+		wantErr error
+	}{
+		{input: "fail", want: &OnErrorPolicy{Mode: OnErrorFail}},
+		{input: "Continue", want: &OnErrorPolicy{Mode: OnErrorContinue}},
+		{input: "threshold=80", want: &OnErrorPolicy{Mode: OnErrorThreshold, Threshold: 80}},
+		{input: "threshold=99.5%", want: &OnErrorPolicy{Mode: OnErrorThreshold, Threshold: 99.5}},
+		{input: "threshold", wantErr: errors.New("on-error policy threshold requires a percentage, e.g. threshold=80")},
+		{input: "threshold=120", wantErr: errors.New("on-error threshold must be between 0 and 100, got 120")},
+		{input: "fail=1", wantErr: errors.New(`on-error policy "fail" does not take a value`)},
+		{input: "ignore", wantErr: errors.New(`unsupported on-error policy: "ignore", possible values: fail|continue|threshold=<percentage>`)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOnErrorPolicy(tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
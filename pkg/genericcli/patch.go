@@ -0,0 +1,143 @@
+package genericcli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"go.opentelemetry.io/otel/attribute"
+	"sigs.k8s.io/yaml"
+)
+
+// Patch applies the JSON merge patch (RFC 7386) from the given file onto the entity with the given id and updates the entity.
+// The patch only contains the fields of the response entity to change, fields set to null are removed. As the patched entity
+// is converted to an update request, only fields which are part of the update request can be changed.
+//
+// The patched entity is validated if the CRUD implementation is a Validator. With dry-run, the patched entity is returned
+// without updating the entity.
+func (a *MultiArgGenericCLI[C, U, R]) Patch(from string, id ...string) (R, error) {
+	var zero R
+
+	rawPatch, err := a.parser.readRaw(from)
+	if err != nil {
+		return zero, err
+	}
+
+	current, err := a.Describe(id...)
+	if err != nil {
+		return zero, err
+	}
+
+	patched, err := applyMergePatch(current, rawPatch)
+	if err != nil {
+		return zero, NewError(ErrorKindValidation, err)
+	}
+
+	if validator, ok := a.validator(); ok {
+		if err := validator.Validate(patched); err != nil {
+			return zero, NewError(ErrorKindValidation, fmt.Errorf("validation failed: %w", err))
+		}
+	}
+
+	if a.diffOut != nil {
+		diff, err := YamlDiff(current, patched)
+		if err != nil {
+			return zero, err
+		}
+
+		printDiff(a.diffOut, id, diff)
+	}
+
+	if a.dryRun {
+		return patched, nil
+	}
+
+	_, _, updateRq, err := a.crud.Convert(patched)
+	if err != nil {
+		return zero, fmt.Errorf("error converting patched entity: %w", err)
+	}
+
+	end := a.startSpan("patch", attribute.StringSlice("id", id))
+	resp, _, err := retry(a.retry, func() (R, error) { return a.crud.Update(updateRq) })
+	end(err)
+	if err != nil {
+		return zero, fmt.Errorf("error updating entity: %w", err)
+	}
+
+	a.recordHistory(HistoryOperationUpdate, id, current)
+
+	return resp, nil
+}
+
+func (a *MultiArgGenericCLI[C, U, R]) PatchAndPrint(from string, p printers.Printer, id ...string) error {
+	resp, err := a.Patch(from, id...)
+	if err != nil {
+		return err
+	}
+
+	return p.Print(resp)
+}
+
+// applyMergePatch applies the given YAML or JSON merge patch onto the JSON representation of the given entity.
+func applyMergePatch[R any](current R, rawPatch []byte) (R, error) {
+	var zero R
+
+	var patch any
+	err := yaml.Unmarshal(rawPatch, &patch)
+	if err != nil {
+		return zero, fmt.Errorf("unable to parse patch: %w", err)
+	}
+
+	if _, ok := patch.(map[string]any); !ok {
+		return zero, fmt.Errorf("patch must contain an object with the fields to change")
+	}
+
+	currentRaw, err := json.Marshal(current)
+	if err != nil {
+		return zero, err
+	}
+
+	var target any
+	err = json.Unmarshal(currentRaw, &target)
+	if err != nil {
+		return zero, err
+	}
+
+	patchedRaw, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return zero, err
+	}
+
+	var patched R
+	err = json.Unmarshal(patchedRaw, &patched)
+	if err != nil {
+		return zero, fmt.Errorf("patched entity is invalid: %w", err)
+	}
+
+	return patched, nil
+}
+
+// mergePatch merges the patch into the target according to RFC 7386: objects are merged recursively,
+// null removes a field and all other values replace the target.
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
@@ -0,0 +1,140 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// patchTestCRUD is an in-memory CRUD, which updates the labels of entities.
+type patchTestCRUD struct {
+	*labeledTestCRUD
+}
+
+func (p *patchTestCRUD) Update(rq *labeledTest) (*labeledTest, error) {
+	return p.SetLabels(rq, rq.Labels)
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target any
+		patch  any
+		want   any
+	}{
+		{
+			name:   "replace value",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"a": "c"},
+			want:   map[string]any{"a": "c"},
+		},
+		{
+			name:   "add value",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"b": "c"},
+			want:   map[string]any{"a": "b", "b": "c"},
+		},
+		{
+			name:   "remove value",
+			target: map[string]any{"a": "b", "b": "c"},
+			patch:  map[string]any{"a": nil},
+			want:   map[string]any{"b": "c"},
+		},
+		{
+			name:   "arrays are replaced",
+			target: map[string]any{"a": []any{"b"}},
+			patch:  map[string]any{"a": []any{"c", "d"}},
+			want:   map[string]any{"a": []any{"c", "d"}},
+		},
+		{
+			name:   "nested objects are merged",
+			target: map[string]any{"a": map[string]any{"b": "c", "d": "e"}},
+			patch:  map[string]any{"a": map[string]any{"b": "x", "d": nil}},
+			want:   map[string]any{"a": map[string]any{"b": "x"}},
+		},
+		{
+			name:   "object replaces scalar",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"a": map[string]any{"c": nil, "d": "e"}},
+			want:   map[string]any{"a": map[string]any{"d": "e"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergePatch(tt.target, tt.patch)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/patch.yaml", []byte(`
+labels:
+  env: dev
+  stage: null
+  team: a
+`), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/invalid.yaml", []byte(`- a`), 0755))
+
+	t.Run("dry-run", func(t *testing.T) {
+		crud := &patchTestCRUD{labeledTestCRUD: newLabeledTestCRUD()}
+		var diff bytes.Buffer
+		cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud).WithFS(fs).WithDryRun().WithDiff(&diff)
+
+		got, err := cli.Patch("1", "/patch.yaml")
+		require.NoError(t, err)
+
+		want := &labeledTest{ID: "1", Labels: map[string]string{"env": "dev", "team": "a"}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+
+		require.Contains(t, diff.String(), `changes for "1":`)
+
+		current, err := crud.Get("1")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"env": "prod", "stage": "1"}, current.Labels, "dry-run must not update the entity")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		crud := &patchTestCRUD{labeledTestCRUD: newLabeledTestCRUD()}
+		cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud).WithFS(fs)
+
+		got, err := cli.Patch("1", "/patch.yaml")
+		require.NoError(t, err)
+
+		want := &labeledTest{ID: "1", Labels: map[string]string{"env": "dev", "team": "a"}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+
+		current, err := crud.Get("1")
+		require.NoError(t, err)
+		if diff := cmp.Diff(want, current); diff != "" {
+			t.Errorf("diff (+got -want):\n %s", diff)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		crud := &patchTestCRUD{labeledTestCRUD: newLabeledTestCRUD()}
+		cli := NewGenericCLI[*labeledTest, *labeledTest, *labeledTest](crud).WithFS(fs)
+
+		_, err := cli.Patch("1", "/invalid.yaml")
+		if diff := cmp.Diff(NewError(ErrorKindValidation, errors.New("patch must contain an object with the fields to change")), err, testcommon.ErrorStringComparer()); diff != "" {
+			t.Errorf("error diff (+got -want):\n %s", diff)
+		}
+		require.Equal(t, ErrorKindValidation, ClassifyError(err))
+
+		_, err = cli.Patch("3", "/patch.yaml")
+		require.EqualError(t, err, "3 not found")
+	})
+}
@@ -0,0 +1,80 @@
+package genericcli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// PrinterConfig contains the configuration for creating a printer from the global output flags, see PrinterFromViper.
+type PrinterConfig struct {
+	// ToHeaderAndRows is used by the tabular output formats (table, wide and markdown).
+	ToHeaderAndRows func(data any, wide bool) ([]string, [][]string, error)
+	// DefaultFormat is used when no output format is set, defaults to table.
+	DefaultFormat printers.OutputFormat
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+	// Viper is used to read the flag values, defaults to the global viper instance.
+	Viper *viper.Viper
+}
+
+// AddPrinterFlags adds the flags read by PrinterFromViper as persistent flags to the given command, typically the root command.
+func AddPrinterFlags(cmd *cobra.Command) {
+	formats := printers.OutputFormatStrings(printers.OutputFormatValues()...)
+
+	cmd.PersistentFlags().StringP("output-format", "o", printers.OutputFormatTable.String(), fmt.Sprintf("output format, can be one of: %s", strings.Join(formats, "|")))
+	cmd.PersistentFlags().String("template", "", `output template for template, jsonpath and custom-columns output formats, e.g. --template "{{ .id }}"`)
+	cmd.PersistentFlags().Bool("force-color", false, "force colored output even without tty")
+	cmd.PersistentFlags().Bool("no-headers", false, "do not print headers of tabular output formats")
+
+	Must(cmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp)))
+}
+
+// PrinterFromViper returns a printer for the output format configured by the flags added by AddPrinterFlags.
+// If the column flags added by AddColumnFlags are set, they are respected as well.
+func PrinterFromViper(c *PrinterConfig) (printers.Printer, error) {
+	if c == nil {
+		c = &PrinterConfig{}
+	}
+
+	v := c.Viper
+	if v == nil {
+		v = viper.GetViper()
+	}
+
+	if v.GetBool("force-color") {
+		color.NoColor = false
+	}
+
+	format := c.DefaultFormat
+	if format == "" {
+		format = printers.OutputFormatTable
+	}
+
+	if raw := v.GetString("output-format"); raw != "" {
+		var err error
+		format, err = printers.ParseOutputFormat(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	columns, sortKeys, err := parseColumnFlags(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return printers.NewPrinterForFormat(format, &printers.FormatPrinterConfig{
+		ToHeaderAndRows: c.ToHeaderAndRows,
+		Expression:      v.GetString("template"),
+		NoHeaders:       v.GetBool("no-headers"),
+		Columns:         columns,
+		SortBy:          sortKeys,
+		Out:             c.Out,
+	})
+}
@@ -0,0 +1,109 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrinterFromViper(t *testing.T) {
+	toHeaderAndRows := func(data any, wide bool) ([]string, [][]string, error) {
+		return []string{"ID", "Name"}, [][]string{{"1", "a"}}, nil
+	}
+
+	tests := []struct {
+		name          string
+		values        map[string]any
+		defaultFormat printers.OutputFormat
+		want          string
+		wantErr       error
+	}{
+		{
+			name:   "json",
+			values: map[string]any{"output-format": "json"},
+			want:   "{\n    \"id\": \"1\"\n}\n",
+		},
+		{
+			name:   "yaml is case-insensitive",
+			values: map[string]any{"output-format": "YAML"},
+			want:   "---\nid: \"1\"\n",
+		},
+		{
+			name:   "template",
+			values: map[string]any{"output-format": "template", "template": "{{ .id }}"},
+			want:   "1\n",
+		},
+		{
+			name:          "default format",
+			defaultFormat: printers.OutputFormatCSV,
+			values:        map[string]any{"no-headers": true},
+			want:          "1;a\n",
+		},
+		{
+			name:   "selected columns",
+			values: map[string]any{"output-format": "csv", "columns": []string{"name"}},
+			want:   "Name\na\n",
+		},
+		{
+			name:    "template without expression",
+			values:  map[string]any{"output-format": "template"},
+			wantErr: errors.New(`output format "template" requires an expression`),
+		},
+		{
+			name:    "unknown format",
+			values:  map[string]any{"output-format": "xml"},
+			wantErr: errors.New(`unsupported output format: "xml", possible values: table|wide|markdown|json|jsonraw|ndjson|yaml|yamlraw|template|jsonpath|custom-columns|csv|tsv`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for key, value := range tt.values {
+				v.Set(key, value)
+			}
+
+			var out bytes.Buffer
+			p, err := PrinterFromViper(&PrinterConfig{
+				ToHeaderAndRows: toHeaderAndRows,
+				DefaultFormat:   tt.defaultFormat,
+				Out:             &out,
+				Viper:           v,
+			})
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			require.NoError(t, p.Print(map[string]any{"id": "1"}))
+
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestPrinterFromViperForceColor(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	color.NoColor = true
+
+	v := viper.New()
+	v.Set("output-format", "yaml")
+	v.Set("force-color", true)
+
+	_, err := PrinterFromViper(&PrinterConfig{Viper: v})
+	require.NoError(t, err)
+	require.False(t, color.NoColor)
+}
@@ -0,0 +1,114 @@
+package printers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+)
+
+// ColumnID returns the identifier of a table column as used for column selection and sorting, which is the lower-cased header.
+func ColumnID(header string) string {
+	return strings.ToLower(strings.TrimSpace(header))
+}
+
+// ColumnIDs returns the column identifiers for the given headers.
+func ColumnIDs(header []string) []string {
+	var res []string
+	for _, h := range header {
+		res = append(res, ColumnID(h))
+	}
+	return res
+}
+
+// SortAndSelectColumns sorts the rows by the given columns and afterwards reduces the table to the selected columns
+// in the given order. Sorting is applied first, such that rows can also be sorted by columns that are not selected.
+// Columns are referenced by their ColumnID. Empty columns or sort keys leave the table untouched.
+func SortAndSelectColumns(header []string, rows [][]string, columns []string, sortBy multisort.Keys) ([]string, [][]string, error) {
+	ids := ColumnIDs(header)
+
+	index := map[string]int{}
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	if len(sortBy) > 0 {
+		fields := multisort.FieldMap[[]string]{}
+		for id, i := range index {
+			i := i
+			fields[id] = func(a, b []string, descending bool) multisort.CompareResult {
+				return multisort.WithCompareFunc(func() int {
+					return compareCells(cell(a, i), cell(b, i))
+				}, descending)
+			}
+		}
+
+		for _, key := range sortBy {
+			if _, ok := index[key.ID]; !ok {
+				return nil, nil, fmt.Errorf("unknown column to sort by: %s, available columns: %s", key.ID, strings.Join(ids, "|"))
+			}
+		}
+
+		err := multisort.New(fields, nil).SortBy(rows, sortBy...)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(columns) == 0 {
+		return header, rows, nil
+	}
+
+	var selected []int
+	for _, col := range columns {
+		i, ok := index[ColumnID(col)]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown column: %s, available columns: %s", col, strings.Join(ids, "|"))
+		}
+		selected = append(selected, i)
+	}
+
+	var (
+		newHeader []string
+		newRows   [][]string
+	)
+
+	for _, i := range selected {
+		newHeader = append(newHeader, header[i])
+	}
+	for _, row := range rows {
+		var newRow []string
+		for _, i := range selected {
+			newRow = append(newRow, cell(row, i))
+		}
+		newRows = append(newRows, newRow)
+	}
+
+	return newHeader, newRows, nil
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// compareCells compares numbers numerically and everything else lexically.
+func compareCells(a, b string) int {
+	af, aErr := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	bf, bErr := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(a, b)
+}
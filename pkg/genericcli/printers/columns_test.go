@@ -0,0 +1,82 @@
+package printers_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestSortAndSelectColumns(t *testing.T) {
+	header := []string{"ID", "Name", "Size"}
+	rows := func() [][]string {
+		return [][]string{
+			{"1", "b", "10"},
+			{"2", "a", "9"},
+			{"3", "c", "100"},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		columns    []string
+		sortBy     multisort.Keys
+		wantHeader []string
+		wantRows   [][]string
+		wantErr    error
+	}{
+		{
+			name:       "untouched",
+			wantHeader: header,
+			wantRows:   rows(),
+		},
+		{
+			name:       "select and reorder columns",
+			columns:    []string{"size", "ID"},
+			wantHeader: []string{"Size", "ID"},
+			wantRows:   [][]string{{"10", "1"}, {"9", "2"}, {"100", "3"}},
+		},
+		{
+			name:       "sort by column",
+			sortBy:     multisort.Keys{{ID: "name"}},
+			wantHeader: header,
+			wantRows:   [][]string{{"2", "a", "9"}, {"1", "b", "10"}, {"3", "c", "100"}},
+		},
+		{
+			name:       "sort numbers numerically by unselected column",
+			columns:    []string{"id"},
+			sortBy:     multisort.Keys{{ID: "size", Descending: true}},
+			wantHeader: []string{"ID"},
+			wantRows:   [][]string{{"3"}, {"1"}, {"2"}},
+		},
+		{
+			name:    "unknown column",
+			columns: []string{"foo"},
+			wantErr: fmt.Errorf("unknown column: foo, available columns: id|name|size"),
+		},
+		{
+			name:    "unknown sort column",
+			sortBy:  multisort.Keys{{ID: "foo"}},
+			wantErr: fmt.Errorf("unknown column to sort by: foo, available columns: id|name|size"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			gotHeader, gotRows, err := printers.SortAndSelectColumns(header, rows(), tt.columns, tt.sortBy)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantHeader, gotHeader); diff != "" {
+				t.Errorf("header diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantRows, gotRows); diff != "" {
+				t.Errorf("rows diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
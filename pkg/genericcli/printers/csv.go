@@ -7,10 +7,15 @@ import (
 	"strings"
 )
 
-const defaultDelimiter = ';'
+const (
+	defaultDelimiter = ';'
+	tsvDelimiter     = '\t'
+)
 
+// CSVPrinter prints data as delimiter-separated values
 type CSVPrinter struct {
-	c *CSVPrinterConfig
+	c         *CSVPrinterConfig
+	sanitizer *strings.Replacer
 }
 
 type CSVPrinterConfig struct {
@@ -42,6 +47,32 @@ func NewCSVPrinter(config *CSVPrinterConfig) *CSVPrinter {
 	}
 }
 
+// NewTSVPrinter returns a CSV printer that separates columns by tabs. Tabs and newlines contained in values are replaced by whitespaces
+// such that every row stays on one line and can be processed with tools like awk or cut.
+func NewTSVPrinter(config *CSVPrinterConfig) *CSVPrinter {
+	if config == nil {
+		config = &CSVPrinterConfig{}
+	}
+
+	config.Delimiter = tsvDelimiter
+
+	cp := NewCSVPrinter(config)
+	cp.sanitizer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+	return cp
+}
+
+// FromTableHeaderAndRows adapts the ToHeaderAndRows function of a table printer configuration such that it can be used for the CSV printer.
+func FromTableHeaderAndRows(fn func(data any, wide bool) ([]string, [][]string, error), wide bool) func(data any) ([]string, [][]string, error) {
+	if fn == nil {
+		return nil
+	}
+
+	return func(data any) ([]string, [][]string, error) {
+		return fn(data, wide)
+	}
+}
+
 func (cp *CSVPrinter) WithOut(out io.Writer) *CSVPrinter {
 	cp.c.Out = out
 
@@ -59,12 +90,24 @@ func (cp *CSVPrinter) Print(data any) error {
 	}
 
 	if !cp.c.NoHeaders {
-		fmt.Fprintln(cp.c.Out, strings.Join(headers, string(cp.c.Delimiter)))
+		fmt.Fprintln(cp.c.Out, cp.join(headers))
 	}
 
 	for _, row := range rows {
-		fmt.Fprintln(cp.c.Out, strings.Join(row, string(cp.c.Delimiter)))
+		fmt.Fprintln(cp.c.Out, cp.join(row))
 	}
 
 	return nil
 }
+
+func (cp *CSVPrinter) join(values []string) string {
+	if cp.sanitizer != nil {
+		sanitized := make([]string, 0, len(values))
+		for _, v := range values {
+			sanitized = append(sanitized, cp.sanitizer.Replace(v))
+		}
+		values = sanitized
+	}
+
+	return strings.Join(values, string(cp.c.Delimiter))
+}
@@ -95,3 +95,29 @@ func TestBasicCSVPrinterWithCustomDelimiter(t *testing.T) {
 		t.Errorf("diff (+got -want):\n %s", diff)
 	}
 }
+
+func TestTSVPrinterFromTableHeaderAndRows(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	printer := printers.NewTSVPrinter(&printers.CSVPrinterConfig{
+		Out: buffer,
+		ToHeaderAndRows: printers.FromTableHeaderAndRows(func(data any, wide bool) ([]string, [][]string, error) {
+			if !wide {
+				t.Errorf("want wide to be passed")
+			}
+			return []string{"a", "b"}, [][]string{
+				{"1", "2\twith tab"},
+				{"3", "4\nwith newline"},
+			}, nil
+		}, true),
+	})
+
+	err := printer.Print("test")
+	if err != nil {
+		t.Error(err)
+	}
+	got := buffer.String()
+	want := "a\tb\n1\t2 with tab\n3\t4 with newline\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
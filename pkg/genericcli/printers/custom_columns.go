@@ -0,0 +1,213 @@
+package printers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+)
+
+const customColumnsNone = "<none>"
+
+// CustomColumnsPrinter prints data into a table with columns defined by JSONPath expressions.
+//
+// The columns are given in the form of <header>:<expression>[,<header>:<expression>...], e.g. "ID:.id,NAME:{.name}".
+// An expression is either a JSONPath (see JSONPathPrinter for the supported syntax) or a Go template when
+// starting with "{{", e.g. "NAME:{{ .name | upper }}". Commas separate the columns only outside of braces, brackets and quotes,
+// so they can still be used inside of an expression, e.g. "TAGS:{{ join .tags \",\" }}".
+type CustomColumnsPrinter struct {
+	out       io.Writer
+	spec      string
+	noHeaders bool
+}
+
+func NewCustomColumnsPrinter(spec string) *CustomColumnsPrinter {
+	return &CustomColumnsPrinter{
+		out:  os.Stdout,
+		spec: spec,
+	}
+}
+
+func (p *CustomColumnsPrinter) WithOut(out io.Writer) *CustomColumnsPrinter {
+	p.out = out
+	return p
+}
+
+func (p *CustomColumnsPrinter) WithNoHeaders() *CustomColumnsPrinter {
+	p.noHeaders = true
+	return p
+}
+
+func (p *CustomColumnsPrinter) Print(data any) error {
+	var buf bytes.Buffer
+
+	err := NewTablePrinter(&TablePrinterConfig{
+		ToHeaderAndRows: p.toHeaderAndRows,
+		NoHeaders:       p.noHeaders,
+		Out:             &buf,
+	}).Print(data)
+	if err != nil {
+		return err
+	}
+
+	// the table pads the last column as well, which is not wanted for scripting purposes
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+
+		_, err = fmt.Fprintln(p.out, strings.TrimRight(line, " \n"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type customColumn struct {
+	header string
+	nodes  []jsonPathNode
+	t      *template.Template
+}
+
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("custom columns must not be empty")
+	}
+
+	var columns []customColumn
+
+	cols, err := splitCustomColumns(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range cols {
+		header, expression, found := strings.Cut(col, ":")
+		header, expression = strings.TrimSpace(header), strings.TrimSpace(expression)
+		if !found || header == "" || expression == "" {
+			return nil, fmt.Errorf("custom column must be in the form <header>:<expression>, found: %s", col)
+		}
+
+		if strings.HasPrefix(expression, "{{") {
+			t, err := template.New(header).Funcs(sprig.TxtFuncMap()).Parse(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template for column %q: %w", header, err)
+			}
+
+			columns = append(columns, customColumn{header: header, t: t})
+			continue
+		}
+
+		if !strings.HasPrefix(expression, "{") {
+			expression = "{" + expression + "}"
+		}
+
+		nodes, err := parseJSONPathTemplate(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for column %q: %w", header, err)
+		}
+
+		columns = append(columns, customColumn{header: header, nodes: nodes})
+	}
+
+	return columns, nil
+}
+
+// splitCustomColumns splits the spec at every comma that is not enclosed in braces, brackets or quotes.
+func splitCustomColumns(spec string) ([]string, error) {
+	var (
+		cols  []string
+		depth int
+		quote rune
+		start int
+	)
+
+	for i, r := range spec {
+		if quote != 0 {
+			if r == quote && (i == 0 || spec[i-1] != '\\') {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '"', '\'', '`':
+			if depth > 0 {
+				quote = r
+			}
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced %q in custom columns at position %d", r, i)
+			}
+		case ',':
+			if depth == 0 {
+				cols = append(cols, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	if depth != 0 || quote != 0 {
+		return nil, fmt.Errorf("unterminated expression in custom columns: %s", spec[start:])
+	}
+
+	return append(cols, spec[start:]), nil
+}
+
+func (p *CustomColumnsPrinter) toHeaderAndRows(data any, _ bool) ([]string, [][]string, error) {
+	columns, err := parseCustomColumns(p.spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	elems, err := toGenericJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		header []string
+		rows   [][]string
+	)
+
+	for _, col := range columns {
+		header = append(header, col.header)
+	}
+
+	for _, elem := range elems {
+		var row []string
+
+		for _, col := range columns {
+			var buf bytes.Buffer
+
+			if col.t != nil {
+				err = col.t.Execute(&buf, elem)
+			} else {
+				err = executeJSONPath(&buf, col.nodes, elem, elem)
+			}
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to evaluate column %q: %w", col.header, err)
+			}
+
+			value := buf.String()
+			if value == "" {
+				value = customColumnsNone
+			}
+
+			row = append(row, value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
@@ -0,0 +1,175 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+)
+
+// OutputFormat is the format in which a CLI prints its output.
+type OutputFormat string
+
+const (
+	OutputFormatTable         OutputFormat = "table"
+	OutputFormatWide          OutputFormat = "wide"
+	OutputFormatMarkdown      OutputFormat = "markdown"
+	OutputFormatJSON          OutputFormat = "json"
+	OutputFormatJSONRaw       OutputFormat = "jsonraw"
+	OutputFormatNDJSON        OutputFormat = "ndjson"
+	OutputFormatYAML          OutputFormat = "yaml"
+	OutputFormatYAMLRaw       OutputFormat = "yamlraw"
+	OutputFormatTemplate      OutputFormat = "template"
+	OutputFormatJSONPath      OutputFormat = "jsonpath"
+	OutputFormatCustomColumns OutputFormat = "custom-columns"
+	OutputFormatCSV           OutputFormat = "csv"
+	OutputFormatTSV           OutputFormat = "tsv"
+)
+
+// OutputFormatValues returns all output formats known to this package.
+func OutputFormatValues() []OutputFormat {
+	return []OutputFormat{
+		OutputFormatTable,
+		OutputFormatWide,
+		OutputFormatMarkdown,
+		OutputFormatJSON,
+		OutputFormatJSONRaw,
+		OutputFormatNDJSON,
+		OutputFormatYAML,
+		OutputFormatYAMLRaw,
+		OutputFormatTemplate,
+		OutputFormatJSONPath,
+		OutputFormatCustomColumns,
+		OutputFormatCSV,
+		OutputFormatTSV,
+	}
+}
+
+// ParseOutputFormat returns the output format for the given string, the comparison is case-insensitive.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	for _, f := range OutputFormatValues() {
+		if strings.EqualFold(strings.TrimSpace(s), string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported output format: %q, possible values: %s", s, strings.Join(OutputFormatStrings(OutputFormatValues()...), "|"))
+}
+
+// OutputFormatStrings returns the string representations of the given output formats, useful for flag help texts and completion.
+func OutputFormatStrings(formats ...OutputFormat) []string {
+	var res []string
+	for _, f := range formats {
+		res = append(res, f.String())
+	}
+	return res
+}
+
+func (f OutputFormat) String() string {
+	return string(f)
+}
+
+// FormatPrinterConfig contains the configuration for creating a printer for an output format.
+type FormatPrinterConfig struct {
+	// ToHeaderAndRows is used by the tabular output formats (table, wide, markdown, csv and tsv).
+	ToHeaderAndRows func(data any, wide bool) ([]string, [][]string, error)
+	// Expression is used by the template, jsonpath and custom-columns output formats.
+	Expression string
+	// NoHeaders will omit headers for the tabular output formats.
+	NoHeaders bool
+	// Columns selects and orders the columns of the tabular output formats, see TablePrinterConfig.
+	Columns []string
+	// SortBy sorts the rows of the tabular output formats by column, see TablePrinterConfig.
+	SortBy multisort.Keys
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+}
+
+// NewPrinterForFormat returns the printer for the given output format.
+func NewPrinterForFormat(format OutputFormat, c *FormatPrinterConfig) (Printer, error) {
+	if c == nil {
+		c = &FormatPrinterConfig{}
+	}
+
+	requireExpression := func() error {
+		if c.Expression == "" {
+			return fmt.Errorf("output format %q requires an expression", format)
+		}
+		return nil
+	}
+
+	switch format {
+	case OutputFormatTable, OutputFormatWide, OutputFormatMarkdown:
+		return NewTablePrinter(&TablePrinterConfig{
+			ToHeaderAndRows: c.ToHeaderAndRows,
+			Wide:            format == OutputFormatWide,
+			Markdown:        format == OutputFormatMarkdown,
+			NoHeaders:       c.NoHeaders,
+			Out:             c.Out,
+			Columns:         c.Columns,
+			SortBy:          c.SortBy,
+		}), nil
+	case OutputFormatCSV, OutputFormatTSV:
+		config := &CSVPrinterConfig{
+			ToHeaderAndRows: FromTableHeaderAndRows(c.tableHeaderAndRows, false),
+			NoHeaders:       c.NoHeaders,
+			Out:             c.Out,
+		}
+		if format == OutputFormatTSV {
+			return NewTSVPrinter(config), nil
+		}
+		return NewCSVPrinter(config), nil
+	case OutputFormatJSON:
+		return withOut(NewJSONPrinter(), c.Out), nil
+	case OutputFormatJSONRaw:
+		return withOut(NewProtoJSONPrinter().WithFallback(true), c.Out), nil
+	case OutputFormatNDJSON:
+		return withOut(NewNDJSONPrinter(), c.Out), nil
+	case OutputFormatYAML:
+		return withOut(NewYAMLPrinter(), c.Out), nil
+	case OutputFormatYAMLRaw:
+		return withOut(NewProtoYAMLPrinter().WithFallback(true), c.Out), nil
+	case OutputFormatTemplate:
+		if err := requireExpression(); err != nil {
+			return nil, err
+		}
+		return withOut(NewTemplatePrinter(c.Expression), c.Out), nil
+	case OutputFormatJSONPath:
+		if err := requireExpression(); err != nil {
+			return nil, err
+		}
+		return withOut(NewJSONPathPrinter(c.Expression), c.Out), nil
+	case OutputFormatCustomColumns:
+		if err := requireExpression(); err != nil {
+			return nil, err
+		}
+		p := withOut(NewCustomColumnsPrinter(c.Expression), c.Out)
+		if c.NoHeaders {
+			p = p.WithNoHeaders()
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %q", format)
+	}
+}
+
+func (c *FormatPrinterConfig) tableHeaderAndRows(data any, wide bool) ([]string, [][]string, error) {
+	if c.ToHeaderAndRows == nil {
+		return nil, nil, fmt.Errorf("missing to header and rows function in printer configuration")
+	}
+
+	header, rows, err := c.ToHeaderAndRows(data, wide)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return SortAndSelectColumns(header, rows, c.Columns, c.SortBy)
+}
+
+func withOut[P interface{ WithOut(io.Writer) P }](p P, out io.Writer) P {
+	if out == nil {
+		return p
+	}
+	return p.WithOut(out)
+}
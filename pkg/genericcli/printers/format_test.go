@@ -0,0 +1,105 @@
+package printers
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OutputFormat
+		wantErr error
+	}{
+		{input: "yaml", want: OutputFormatYAML},
+		{input: "JSONRAW", want: OutputFormatJSONRaw},
+		{input: "custom-columns", want: OutputFormatCustomColumns},
+		{input: "xml", wantErr: errors.New(`unsupported output format: "xml", possible values: table|wide|markdown|json|jsonraw|ndjson|yaml|yamlraw|template|jsonpath|custom-columns|csv|tsv`)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOutputFormat(tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPrinterForFormat(t *testing.T) {
+	toHeaderAndRows := func(data any, wide bool) ([]string, [][]string, error) {
+		return []string{"id"}, [][]string{{"1"}}, nil
+	}
+
+	tests := []struct {
+		name    string
+		format  OutputFormat
+		config  *FormatPrinterConfig
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "csv",
+			format: OutputFormatCSV,
+			config: &FormatPrinterConfig{ToHeaderAndRows: toHeaderAndRows},
+			want:   "id\n1\n",
+		},
+		{
+			name:   "tsv without headers",
+			format: OutputFormatTSV,
+			config: &FormatPrinterConfig{ToHeaderAndRows: toHeaderAndRows, NoHeaders: true},
+			want:   "1\n",
+		},
+		{
+			name:   "jsonpath",
+			format: OutputFormatJSONPath,
+			config: &FormatPrinterConfig{Expression: "{.id}"},
+			want:   "1\n",
+		},
+		{
+			name:    "jsonpath without expression",
+			format:  OutputFormatJSONPath,
+			config:  &FormatPrinterConfig{},
+			wantErr: errors.New(`output format "jsonpath" requires an expression`),
+		},
+		{
+			name:    "unknown format",
+			format:  OutputFormat("xml"),
+			wantErr: errors.New(`unsupported output format: "xml"`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if tt.config != nil {
+				tt.config.Out = &out
+			}
+
+			p, err := NewPrinterForFormat(tt.format, tt.config)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			err = p.Print(map[string]string{"id": "1"})
+			if err != nil {
+				t.Error(err)
+			}
+
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
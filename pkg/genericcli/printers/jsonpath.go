@@ -0,0 +1,515 @@
+package printers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPathPrinter prints data with a given JSONPath expression.
+//
+// It supports a subset of the kubectl JSONPath syntax:
+//
+//	{.a.b}                     child fields
+//	{.a['b']}                  child fields in bracket notation
+//	{.items[0]}, {.items[-1]}  array index
+//	{.items[1:3]}              array slice
+//	{.items[*].name}, {.a.*}   wildcards
+//	{..name}                   recursive descent
+//	{range .items[*]}...{end}  iteration
+//	{"\t"}                     string literals
+//
+// Like the template printer, slices are printed element-wise, each element on a separate line.
+type JSONPathPrinter struct {
+	out       io.Writer
+	text      string
+	nodes     []jsonPathNode
+	omitEmpty bool
+}
+
+func NewJSONPathPrinter(expression string) *JSONPathPrinter {
+	return &JSONPathPrinter{
+		out:       os.Stdout,
+		text:      expression,
+		omitEmpty: true,
+	}
+}
+
+func (p *JSONPathPrinter) WithOut(out io.Writer) *JSONPathPrinter {
+	p.out = out
+	return p
+}
+
+func (p *JSONPathPrinter) WithoutOmitEmptyLines() *JSONPathPrinter {
+	p.omitEmpty = false
+	return p
+}
+
+func (p *JSONPathPrinter) Print(data any) error {
+	if p.nodes == nil {
+		var err error
+		p.nodes, err = parseJSONPathTemplate(p.text)
+		if err != nil {
+			return err
+		}
+	}
+
+	elems, err := toGenericJSON(data)
+	if err != nil {
+		return err
+	}
+
+	for _, elem := range elems {
+		var buf bytes.Buffer
+
+		err := executeJSONPath(&buf, p.nodes, elem, elem)
+		if err != nil {
+			return fmt.Errorf("unable to evaluate jsonpath: %w", err)
+		}
+
+		if !p.omitEmpty || buf.Len() > 0 {
+			fmt.Fprintf(p.out, "%s\n", buf.String())
+		}
+	}
+
+	return nil
+}
+
+// toGenericJSON transforms the input into its generic json representation such that field names are equal to the json struct tags.
+// slices are returned element-wise.
+func toGenericJSON(data any) ([]any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSlice(data) {
+		var d []any
+		err = json.Unmarshal(raw, &d)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+
+	var d any
+	err = json.Unmarshal(raw, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{d}, nil
+}
+
+type (
+	jsonPathNode any
+
+	jsonPathText  string
+	jsonPathValue struct {
+		path jsonPath
+	}
+	jsonPathRange struct {
+		path jsonPath
+		body []jsonPathNode
+	}
+
+	jsonPath struct {
+		fromRoot bool
+		segments []jsonPathSegment
+	}
+
+	jsonPathSegmentKind int
+
+	jsonPathSegment struct {
+		kind      jsonPathSegmentKind
+		recursive bool
+
+		name string
+
+		index int
+
+		start, end       int
+		hasStart, hasEnd bool
+	}
+)
+
+const (
+	jsonPathSegmentField jsonPathSegmentKind = iota
+	jsonPathSegmentWildcard
+	jsonPathSegmentIndex
+	jsonPathSegmentSlice
+)
+
+func parseJSONPathTemplate(text string) ([]jsonPathNode, error) {
+	nodes, _, err := parseJSONPathNodes(text, false)
+	return nodes, err
+}
+
+// parseJSONPathNodes parses nodes until the end of text or an {end} action when inRange is set, returning the remaining text after {end}.
+func parseJSONPathNodes(text string, inRange bool) ([]jsonPathNode, string, error) {
+	var nodes []jsonPathNode
+
+	for text != "" {
+		start := strings.IndexRune(text, '{')
+		if start < 0 {
+			nodes = append(nodes, jsonPathText(text))
+			text = ""
+			break
+		}
+		if start > 0 {
+			nodes = append(nodes, jsonPathText(text[:start]))
+		}
+
+		end, err := findJSONPathActionEnd(text, start)
+		if err != nil {
+			return nil, "", err
+		}
+
+		action := strings.TrimSpace(text[start+1 : end])
+		text = text[end+1:]
+
+		switch {
+		case action == "end":
+			if !inRange {
+				return nil, "", fmt.Errorf("unexpected {end} without {range}")
+			}
+			return nodes, text, nil
+		case strings.HasPrefix(action, "range "):
+			path, err := parseJSONPath(strings.TrimSpace(strings.TrimPrefix(action, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+
+			body, rest, err := parseJSONPathNodes(text, true)
+			if err != nil {
+				return nil, "", err
+			}
+
+			nodes = append(nodes, jsonPathRange{path: path, body: body})
+			text = rest
+		case strings.HasPrefix(action, `"`):
+			literal, err := strconv.Unquote(action)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid string literal %s: %w", action, err)
+			}
+			nodes = append(nodes, jsonPathText(literal))
+		default:
+			path, err := parseJSONPath(action)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathValue{path: path})
+		}
+	}
+
+	if inRange {
+		return nil, "", fmt.Errorf("missing {end} for {range}")
+	}
+
+	return nodes, "", nil
+}
+
+func findJSONPathActionEnd(text string, start int) (int, error) {
+	var quote rune
+
+	for i := start + 1; i < len(text); i++ {
+		c := rune(text[i])
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unclosed action in jsonpath expression %q", text)
+}
+
+func parseJSONPath(expression string) (jsonPath, error) {
+	var (
+		path jsonPath
+		rest = expression
+	)
+
+	if strings.HasPrefix(rest, "$") {
+		path.fromRoot = true
+		rest = rest[1:]
+	}
+
+	for rest != "" {
+		var recursive bool
+
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, ".") {
+				recursive = true
+				rest = rest[1:]
+			}
+
+			if rest == "" || rest[0] == '.' {
+				if recursive {
+					return jsonPath{}, fmt.Errorf("recursive descent requires a field in jsonpath %q", expression)
+				}
+				continue
+			}
+			if rest[0] == '[' {
+				break
+			}
+
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			name := rest[:end]
+			rest = rest[end:]
+
+			if name == "*" {
+				path.segments = append(path.segments, jsonPathSegment{kind: jsonPathSegmentWildcard, recursive: recursive})
+			} else {
+				path.segments = append(path.segments, jsonPathSegment{kind: jsonPathSegmentField, name: name, recursive: recursive})
+			}
+
+			continue
+		case '[':
+		default:
+			return jsonPath{}, fmt.Errorf("unexpected character %q in jsonpath %q", rest[0], expression)
+		}
+
+		end := strings.IndexRune(rest, ']')
+		if end < 0 {
+			return jsonPath{}, fmt.Errorf("unclosed bracket in jsonpath %q", expression)
+		}
+
+		segment, err := parseJSONPathBracket(strings.TrimSpace(rest[1:end]))
+		if err != nil {
+			return jsonPath{}, fmt.Errorf("%w in jsonpath %q", err, expression)
+		}
+		segment.recursive = recursive
+
+		path.segments = append(path.segments, segment)
+		rest = rest[end+1:]
+	}
+
+	return path, nil
+}
+
+func parseJSONPathBracket(content string) (jsonPathSegment, error) {
+	switch {
+	case content == "*":
+		return jsonPathSegment{kind: jsonPathSegmentWildcard}, nil
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, `"`):
+		if len(content) < 2 || content[len(content)-1] != content[0] {
+			return jsonPathSegment{}, fmt.Errorf("invalid quoted field %s", content)
+		}
+		return jsonPathSegment{kind: jsonPathSegmentField, name: content[1 : len(content)-1]}, nil
+	case strings.Contains(content, ":"):
+		segment := jsonPathSegment{kind: jsonPathSegmentSlice}
+
+		startRaw, endRaw, _ := strings.Cut(content, ":")
+
+		if startRaw = strings.TrimSpace(startRaw); startRaw != "" {
+			start, err := strconv.Atoi(startRaw)
+			if err != nil {
+				return jsonPathSegment{}, fmt.Errorf("invalid slice start %q", startRaw)
+			}
+			segment.start, segment.hasStart = start, true
+		}
+		if endRaw = strings.TrimSpace(endRaw); endRaw != "" {
+			end, err := strconv.Atoi(endRaw)
+			if err != nil {
+				return jsonPathSegment{}, fmt.Errorf("invalid slice end %q", endRaw)
+			}
+			segment.end, segment.hasEnd = end, true
+		}
+
+		return segment, nil
+	default:
+		index, err := strconv.Atoi(content)
+		if err != nil {
+			return jsonPathSegment{}, fmt.Errorf("invalid array index %q", content)
+		}
+		return jsonPathSegment{kind: jsonPathSegmentIndex, index: index}, nil
+	}
+}
+
+func executeJSONPath(w io.Writer, nodes []jsonPathNode, root, current any) error {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case jsonPathText:
+			fmt.Fprint(w, string(n))
+		case jsonPathValue:
+			results := n.path.evaluate(root, current)
+
+			var texts []string
+			for _, r := range results {
+				text, err := jsonPathFormat(r)
+				if err != nil {
+					return err
+				}
+				texts = append(texts, text)
+			}
+
+			fmt.Fprint(w, strings.Join(texts, " "))
+		case jsonPathRange:
+			elems := n.path.evaluate(root, current)
+			if len(elems) == 1 {
+				if list, ok := elems[0].([]any); ok {
+					elems = list
+				}
+			}
+
+			for _, elem := range elems {
+				err := executeJSONPath(w, n.body, root, elem)
+				if err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unsupported jsonpath node: %T", node)
+		}
+	}
+
+	return nil
+}
+
+func (p jsonPath) evaluate(root, current any) []any {
+	results := []any{current}
+	if p.fromRoot {
+		results = []any{root}
+	}
+
+	for _, segment := range p.segments {
+		var next []any
+
+		for _, r := range results {
+			if segment.recursive {
+				for _, descendant := range jsonPathDescendants(r) {
+					next = append(next, segment.apply(descendant)...)
+				}
+				continue
+			}
+			next = append(next, segment.apply(r)...)
+		}
+
+		results = next
+	}
+
+	return results
+}
+
+func (s jsonPathSegment) apply(value any) []any {
+	switch s.kind {
+	case jsonPathSegmentField:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v, ok := m[s.name]
+		if !ok {
+			return nil
+		}
+		return []any{v}
+	case jsonPathSegmentWildcard:
+		switch v := value.(type) {
+		case []any:
+			return v
+		case map[string]any:
+			var res []any
+			for _, k := range sortedKeys(v) {
+				res = append(res, v[k])
+			}
+			return res
+		}
+		return nil
+	case jsonPathSegmentIndex:
+		list, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		index := s.index
+		if index < 0 {
+			index += len(list)
+		}
+		if index < 0 || index >= len(list) {
+			return nil
+		}
+		return []any{list[index]}
+	case jsonPathSegmentSlice:
+		list, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		start, end := 0, len(list)
+		if s.hasStart {
+			start = clampIndex(s.start, len(list))
+		}
+		if s.hasEnd {
+			end = clampIndex(s.end, len(list))
+		}
+		if start >= end {
+			return nil
+		}
+		return list[start:end]
+	}
+
+	return nil
+}
+
+func clampIndex(index, length int) int {
+	if index < 0 {
+		index += length
+	}
+	return max(0, min(index, length))
+}
+
+// jsonPathDescendants returns the given value and all of its descendants in document order.
+func jsonPathDescendants(value any) []any {
+	res := []any{value}
+
+	switch v := value.(type) {
+	case []any:
+		for _, elem := range v {
+			res = append(res, jsonPathDescendants(elem)...)
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(v) {
+			res = append(res, jsonPathDescendants(v[k])...)
+		}
+	}
+
+	return res
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonPathFormat(value any) (string, error) {
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
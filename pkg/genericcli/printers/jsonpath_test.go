@@ -0,0 +1,226 @@
+package printers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestJSONPathPrinter_Print(t *testing.T) {
+	type nic struct {
+		Name string `json:"name"`
+		MAC  string `json:"mac"`
+	}
+	type machine struct {
+		ID   string            `json:"id"`
+		Size int               `json:"size"`
+		Nics []nic             `json:"nics"`
+		Tags map[string]string `json:"tags,omitempty"`
+	}
+
+	m := machine{
+		ID:   "1",
+		Size: 42,
+		Nics: []nic{{Name: "eth0", MAC: "aa"}, {Name: "eth1", MAC: "bb"}, {Name: "eth2", MAC: "cc"}},
+		Tags: map[string]string{"b": "2", "a": "1"},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		data    any
+		want    string
+		wantErr error
+	}{
+		{
+			name: "simple field",
+			expr: "{.id}",
+			data: m,
+			want: "1\n",
+		},
+		{
+			name: "field from root with surrounding text",
+			expr: "id: {$.id} size: {.size}",
+			data: m,
+			want: "id: 1 size: 42\n",
+		},
+		{
+			name: "multiple entities",
+			expr: "{.id}",
+			data: []machine{{ID: "1"}, {ID: "2"}},
+			want: "1\n2\n",
+		},
+		{
+			name: "also works with list of pointers",
+			expr: "{.id}",
+			data: []*machine{{ID: "1"}, {ID: "2"}},
+			want: "1\n2\n",
+		},
+		{
+			name: "index",
+			expr: "{.nics[0].name} {.nics[-1].name}",
+			data: m,
+			want: "eth0 eth2\n",
+		},
+		{
+			name: "bracket notation",
+			expr: "{.nics[1]['mac']}",
+			data: m,
+			want: "bb\n",
+		},
+		{
+			name: "slice",
+			expr: "{.nics[1:].name}",
+			data: m,
+			want: "eth1 eth2\n",
+		},
+		{
+			name: "wildcard",
+			expr: "{.nics[*].mac}",
+			data: m,
+			want: "aa bb cc\n",
+		},
+		{
+			name: "wildcard on map",
+			expr: "{.tags.*}",
+			data: m,
+			want: "1 2\n",
+		},
+		{
+			name: "recursive descent",
+			expr: "{..name}",
+			data: m,
+			want: "eth0 eth1 eth2\n",
+		},
+		{
+			name: "range with literals",
+			expr: `{range .nics[*]}{.name}{"\t"}{.mac}{"\n"}{end}`,
+			data: m,
+			want: "eth0\taa\neth1\tbb\neth2\tcc\n\n",
+		},
+		{
+			name: "non-string values are printed as json",
+			expr: "{.nics[0]}",
+			data: m,
+			want: "{\"mac\":\"aa\",\"name\":\"eth0\"}\n",
+		},
+		{
+			name: "missing fields are omitted",
+			expr: "{.foo}",
+			data: m,
+			want: "",
+		},
+		{
+			name:    "unclosed action",
+			expr:    "{.id",
+			data:    m,
+			wantErr: fmt.Errorf(`unclosed action in jsonpath expression "{.id"`),
+		},
+		{
+			name:    "range without end",
+			expr:    "{range .nics[*]}{.name}",
+			data:    m,
+			wantErr: fmt.Errorf("missing {end} for {range}"),
+		},
+		{
+			name:    "invalid index",
+			expr:    "{.nics[a]}",
+			data:    m,
+			wantErr: fmt.Errorf(`%w in jsonpath ".nics[a]"`, errors.New(`invalid array index "a"`)),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := NewJSONPathPrinter(tt.expr).WithOut(&out)
+
+			err := p.Print(tt.data)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestCustomColumnsPrinter_Print(t *testing.T) {
+	type machine struct {
+		ID   string   `json:"id"`
+		Name string   `json:"name,omitempty"`
+		Tags []string `json:"tags,omitempty"`
+	}
+
+	tests := []struct {
+		name      string
+		spec      string
+		noHeaders bool
+		data      any
+		want      string
+		wantErr   error
+	}{
+		{
+			name: "jsonpath and template columns",
+			spec: "ID:.id,NAME:{.name},UPPER:{{ .name | upper }}",
+			data: []machine{{ID: "1", Name: "a"}, {ID: "2", Name: "b"}},
+			want: `ID   NAME   UPPER
+1    a      A
+2    b      B
+`,
+		},
+		{
+			name:      "without headers and missing values",
+			spec:      "ID:.id,NAME:.name",
+			noHeaders: true,
+			data:      machine{ID: "1"},
+			want: `1   <none>
+`,
+		},
+		{
+			name: "commas in expressions",
+			spec: `ID:.id,TAGS:{{ join "," .tags }},FIRST:{.tags[0]}`,
+			data: machine{ID: "1", Tags: []string{"a", "b"}},
+			want: `ID   TAGS   FIRST
+1    a,b    a
+`,
+		},
+		{
+			name:    "unterminated template",
+			spec:    "ID:.id,NAME:{{ .name ",
+			data:    machine{ID: "1"},
+			wantErr: fmt.Errorf("unterminated expression in custom columns: NAME:{{ .name "),
+		},
+		{
+			name:    "invalid spec",
+			spec:    "ID",
+			data:    machine{ID: "1"},
+			wantErr: fmt.Errorf("custom column must be in the form <header>:<expression>, found: ID"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := NewCustomColumnsPrinter(tt.spec).WithOut(&out)
+			if tt.noHeaders {
+				p = p.WithNoHeaders()
+			}
+
+			err := p.Print(tt.data)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(tt.want, out.String()); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// NDJSONPrinter prints data as newline delimited JSON, slices are printed with one element per line.
+//
+// Elements are encoded and written one by one, so the printer can also be used to stream elements of a list as they arrive.
+type NDJSONPrinter struct {
+	out                        io.Writer
+	disableDefaultErrorPrinter bool
+}
+
+func NewNDJSONPrinter() *NDJSONPrinter {
+	return &NDJSONPrinter{
+		out: os.Stdout,
+	}
+}
+
+func (p *NDJSONPrinter) WithOut(out io.Writer) *NDJSONPrinter {
+	p.out = out
+	return p
+}
+
+func (p *NDJSONPrinter) WithDisableDefaultErrorPrinter() *NDJSONPrinter {
+	p.disableDefaultErrorPrinter = true
+	return p
+}
+
+func (p *NDJSONPrinter) Print(data any) error {
+	if err, ok := data.(error); ok && !p.disableDefaultErrorPrinter {
+		fmt.Fprintf(p.out, "%s\n", err)
+		return nil
+	}
+
+	if !isSlice(data) {
+		return p.PrintElement(data)
+	}
+
+	v := reflect.ValueOf(data)
+	for i := range v.Len() {
+		err := p.PrintElement(v.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrintElement prints a single element in a separate line.
+func (p *NDJSONPrinter) PrintElement(elem any) error {
+	// the encoder terminates every value with a newline and does not buffer the output
+	return json.NewEncoder(p.out).Encode(elem)
+}
@@ -0,0 +1,45 @@
+package printers_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/genericcli/printers"
+)
+
+func TestNDJSONPrintSlice(t *testing.T) {
+	type machine struct {
+		ID   string `json:"id"`
+		Size int    `json:"size"`
+	}
+
+	buffer := new(bytes.Buffer)
+	printer := printers.NewNDJSONPrinter().WithOut(buffer)
+	err := printer.Print([]*machine{{ID: "1", Size: 1}, {ID: "2", Size: 2}})
+	if err != nil {
+		t.Error(err)
+	}
+	got := buffer.String()
+	want := `{"id":"1","size":1}
+{"id":"2","size":2}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestNDJSONPrintError(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	printer := printers.NewNDJSONPrinter().WithOut(buffer)
+	err := printer.Print(fmt.Errorf("Test"))
+	if err != nil {
+		t.Error(err)
+	}
+	got := buffer.String()
+	want := "Test\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
@@ -3,3 +3,10 @@ package printers
 type Printer interface {
 	Print(data any) error
 }
+
+// StreamPrinter is a printer that can print the elements of a list one by one, such that lists do not need to be held in memory as a whole.
+type StreamPrinter interface {
+	Printer
+	// PrintElement prints a single element of a list.
+	PrintElement(elem any) error
+}
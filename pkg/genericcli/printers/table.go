@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/metal-stack/metal-lib/pkg/multisort"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 	"github.com/olekukonko/tablewriter"
 )
@@ -31,6 +32,12 @@ type TablePrinterConfig struct {
 	CustomPadding *string
 	// DisableDefaultErrorPrinter disables the default error printer when the given print data is of type error.
 	DisableDefaultErrorPrinter bool
+	// Columns selects and orders the printed columns by their ColumnID, prints all columns when empty.
+	Columns []string
+	// SortBy sorts the rows by the values of the given columns, referenced by their ColumnID.
+	SortBy multisort.Keys
+	// Footer is printed below the table if not empty, e.g. for showing quota information.
+	Footer string
 }
 
 func NewTablePrinter(config *TablePrinterConfig) *TablePrinter {
@@ -51,6 +58,54 @@ func (p *TablePrinter) WithOut(out io.Writer) *TablePrinter {
 	return p
 }
 
+// WithColumns selects and orders the printed columns and sorts the rows by column, see TablePrinterConfig.
+func (p *TablePrinter) WithColumns(columns []string, sortBy multisort.Keys) *TablePrinter {
+	p.c.Columns = columns
+	p.c.SortBy = sortBy
+	return p
+}
+
+// WithFooter prints the given footer below the table, see TablePrinterConfig.
+func (p *TablePrinter) WithFooter(footer string) *TablePrinter {
+	p.c.Footer = footer
+	return p
+}
+
+// WithAdditionalColumns appends the columns returned by the given function to the columns of ToHeaderAndRows, e.g. for
+// showing labels. The function receives the printed data and has to return a row for every row of the table in the same order,
+// returning no header leaves the table untouched.
+func (p *TablePrinter) WithAdditionalColumns(fn func(data any) ([]string, [][]string, error)) *TablePrinter {
+	toHeaderAndRows := p.c.ToHeaderAndRows
+	p.c.ToHeaderAndRows = func(data any, wide bool) ([]string, [][]string, error) {
+		if toHeaderAndRows == nil {
+			return nil, nil, fmt.Errorf("missing to header and rows function in printer configuration")
+		}
+
+		header, rows, err := toHeaderAndRows(data, wide)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		additionalHeader, additionalRows, err := fn(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(additionalHeader) == 0 {
+			return header, rows, nil
+		}
+		if len(additionalRows) != len(rows) {
+			return nil, nil, fmt.Errorf("additional columns contain %d rows, but the table has %d rows", len(additionalRows), len(rows))
+		}
+
+		for i := range rows {
+			rows[i] = append(rows[i], additionalRows[i]...)
+		}
+
+		return append(header, additionalHeader...), rows, nil
+	}
+	return p
+}
+
 // MutateTable can be used to alter the table element. Try not to do it all the time but rather propose an API change in this project.
 func (p *TablePrinter) MutateTable(mutateFn func(table *tablewriter.Table)) {
 	mutateFn(p.table)
@@ -71,6 +126,11 @@ func (p *TablePrinter) Print(data any) error {
 		return err
 	}
 
+	header, rows, err = SortAndSelectColumns(header, rows, p.c.Columns, p.c.SortBy)
+	if err != nil {
+		return err
+	}
+
 	if !p.c.NoHeaders {
 		p.table.SetHeader(header)
 	}
@@ -78,6 +138,10 @@ func (p *TablePrinter) Print(data any) error {
 
 	p.table.Render()
 
+	if p.c.Footer != "" {
+		fmt.Fprintf(p.c.Out, "\n%s\n", p.c.Footer)
+	}
+
 	return nil
 }
 
@@ -219,6 +219,31 @@ func TestTablePrinterWithPadding(t *testing.T) {
 	}
 }
 
+func TestTablePrinterWithFooter(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	printer := printers.NewTablePrinter(&printers.TablePrinterConfig{
+		Out: buffer,
+		ToHeaderAndRows: func(data any, wide bool) ([]string, [][]string, error) {
+			return []string{"a", "b"}, [][]string{
+				{"1", "2"},
+			}, nil
+		},
+	}).WithFooter("1/20 machines used in project x")
+	err := printer.Print("test")
+	if err != nil {
+		t.Error(err)
+	}
+	got := buffer.String()
+	want := `A   B 
+1   2   
+
+1/20 machines used in project x
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
 func TestTableFailsWithMissingTOHeaderAndRows(t *testing.T) {
 	buffer := new(bytes.Buffer)
 	printer := printers.NewTablePrinter(&printers.TablePrinterConfig{
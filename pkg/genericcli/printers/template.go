@@ -2,7 +2,6 @@ package printers
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -54,37 +53,16 @@ func (p *TemplatePrinter) Print(data any) error {
 
 	// first we transform the input to a struct which has fields with the same name as in the json struct.
 	// this is handy for template rendering as the output of -o json|yaml can be used as the input for the template.
-	raw, err := json.Marshal(data)
+	elems, err := toGenericJSON(data)
 	if err != nil {
 		return err
 	}
 
-	if isSlice(data) {
-		var d []any
-		err = json.Unmarshal(raw, &d)
+	for _, elem := range elems {
+		err = p.print(elem)
 		if err != nil {
 			return err
 		}
-
-		for _, elem := range d {
-			err = p.print(elem)
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
-	}
-
-	var d any
-	err = json.Unmarshal(raw, &d)
-	if err != nil {
-		return err
-	}
-
-	err = p.print(d)
-	if err != nil {
-		return err
 	}
 
 	return nil
@@ -0,0 +1,65 @@
+package genericcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Context describes the active context of a cli, e.g. the control plane the cli is talking to.
+type Context struct {
+	Name string
+	// Protected requires destructive commands to be confirmed by typing the name of the context,
+	// which is intended for production environments.
+	Protected bool
+}
+
+// protectedContextEnv returns the name of the environment variable that confirms destructive commands in a protected
+// context for automation, it has to contain the name of the context, e.g. METALCTL_CONFIRM_CONTEXT=prod.
+func (c *CmdsConfig[C, U, R]) protectedContextEnv() string {
+	prefix := strings.ToUpper(strings.ReplaceAll(c.BinaryName, "-", "_"))
+	if prefix == "" {
+		return "CONFIRM_CONTEXT"
+	}
+	return prefix + "_CONFIRM_CONTEXT"
+}
+
+// confirmProtectedContext asks the user to type the name of the active context before a destructive command is run
+// in a protected context. In contrast to other confirmations, this cannot be skipped with the --yes flag.
+func (c *CmdsConfig[C, U, R]) confirmProtectedContext(cmd DefaultCmd) error {
+	if c.ActiveContext == nil || (cmd != DeleteCmd && cmd != UndoCmd) {
+		return nil
+	}
+
+	ctx, err := c.ActiveContext()
+	if err != nil {
+		return fmt.Errorf("unable to determine active context: %w", err)
+	}
+	if ctx == nil || !ctx.Protected {
+		return nil
+	}
+
+	env := c.protectedContextEnv()
+	if os.Getenv(env) == ctx.Name {
+		return nil
+	}
+
+	var in io.Reader = os.Stdin
+	if c.In != nil {
+		in = c.In
+	}
+
+	if f, ok := in.(*os.File); ok && !isatty.IsTerminal(f.Fd()) {
+		return NewError(ErrorKindValidation, fmt.Errorf("context %q is protected and input is not a terminal, set %s=%s to confirm non-interactively", ctx.Name, env, ctx.Name))
+	}
+
+	return PromptCustom(&PromptConfig{
+		Message:         fmt.Sprintf("context %q is protected, type the name of the context to %s %s:", ctx.Name, cmd, c.Plural),
+		AcceptedAnswers: []string{ctx.Name},
+		In:              c.In,
+		Out:             c.Out,
+	})
+}
@@ -0,0 +1,125 @@
+package genericcli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmProtectedContext(t *testing.T) {
+	prod := func() (*Context, error) {
+		return &Context{Name: "prod", Protected: true}, nil
+	}
+
+	tests := []struct {
+		name          string
+		cmd           DefaultCmd
+		activeContext func() (*Context, error)
+		env           string
+		input         string
+		wantOut       string
+		wantErr       string
+	}{
+		{
+			name:  "no active context configured",
+			cmd:   DeleteCmd,
+			input: "",
+		},
+		{
+			name: "unprotected context",
+			cmd:  DeleteCmd,
+			activeContext: func() (*Context, error) {
+				return &Context{Name: "dev"}, nil
+			},
+		},
+		{
+			name:          "not a destructive command",
+			cmd:           ApplyCmd,
+			activeContext: prod,
+		},
+		{
+			name:          "confirmed by typing the context name",
+			cmd:           DeleteCmd,
+			activeContext: prod,
+			input:         "prod\n",
+			wantOut:       `context "prod" is protected, type the name of the context to delete machines: `,
+		},
+		{
+			name:          "wrong context name",
+			cmd:           UndoCmd,
+			activeContext: prod,
+			input:         "y\n",
+			wantOut:       `context "prod" is protected, type the name of the context to undo machines: `,
+			wantErr:       `aborting due to given answer ("y")`,
+		},
+		{
+			name:          "confirmed through the environment",
+			cmd:           DeleteCmd,
+			activeContext: prod,
+			env:           "prod",
+		},
+		{
+			name:          "environment confirms another context",
+			cmd:           DeleteCmd,
+			activeContext: prod,
+			env:           "dev",
+			input:         "\n",
+			wantOut:       `context "prod" is protected, type the name of the context to delete machines: `,
+			wantErr:       `aborting due to given answer ("")`,
+		},
+		{
+			name: "error determining the active context",
+			cmd:  DeleteCmd,
+			activeContext: func() (*Context, error) {
+				return nil, errors.New("no config")
+			},
+			wantErr: "unable to determine active context: no config",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("METALCTL_CONFIRM_CONTEXT", tt.env)
+
+			var out bytes.Buffer
+
+			c := &CmdsConfig[any, any, any]{
+				BinaryName:    "metalctl",
+				Plural:        "machines",
+				ActiveContext: tt.activeContext,
+				In:            strings.NewReader(tt.input),
+				Out:           &out,
+			}
+
+			err := c.confirmProtectedContext(tt.cmd)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
+
+func TestConfirmProtectedContextNonInteractive(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	require.NoError(t, err)
+	defer f.Close()
+
+	c := &CmdsConfig[any, any, any]{
+		BinaryName: "cloud-ctl",
+		ActiveContext: func() (*Context, error) {
+			return &Context{Name: "prod", Protected: true}, nil
+		},
+		In: f,
+	}
+
+	err = c.confirmProtectedContext(DeleteCmd)
+	require.EqualError(t, err, `context "prod" is protected and input is not a terminal, set CLOUD_CTL_CONFIRM_CONTEXT=prod to confirm non-interactively`)
+	require.Equal(t, ExitCodeValidation, ExitCode(err))
+}
@@ -0,0 +1,268 @@
+package genericcli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/spf13/afero"
+)
+
+const defaultReadCacheTTL = 24 * time.Hour
+
+// ReadCache caches the results of list and describe operations per context on the file system, such that recent data
+// can still be inspected when the api is unreachable, e.g. operators need the inventory during an incident of the control plane.
+type ReadCache struct {
+	// Dir is the directory of the cache, typically a subdirectory of the user's cache directory.
+	Dir string
+	// Context is the name of the context the cached data belongs to, as entities with the same id differ between contexts.
+	Context string
+	// Entity is the name of the cached entity, e.g. "machine".
+	Entity string
+	// TTL is the maximum age of cached data that is served, defaults to 24 hours.
+	TTL time.Duration
+	// Offline serves the data from the cache without contacting the api. Otherwise, the cache is only used if the api is unreachable.
+	Offline bool
+	// ListFilter contains the filter flags of list operations by name, results of different filters are cached separately,
+	// such that the results of a filter are never shown for another filter or the unfiltered list.
+	ListFilter map[string]string
+	// Out is where the notice about serving stale data is written to, defaults to stderr.
+	Out io.Writer
+}
+
+type readCacheEntry struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ReadCacheDir returns the default directory of the read cache for the given binary in the user's cache directory.
+func ReadCacheDir(binaryName string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, binaryName, "read-cache"), nil
+}
+
+// IsUnreachable returns true if the given error indicates that the api could not be reached, as opposed to errors
+// returned by the api itself.
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr.Code() == connect.CodeUnavailable
+	}
+
+	var httpErr *httperrors.HTTPErrorResponse
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *ReadCache) path(key string) string {
+	name := c.Context
+	if name == "" {
+		name = "default"
+	}
+
+	return filepath.Join(c.Dir, completionCacheKeySanitizer.ReplaceAllString(name, "_"), completionCacheKeySanitizer.ReplaceAllString(c.Entity, "_"), key+".json")
+}
+
+func (c *ReadCache) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return defaultReadCacheTTL
+	}
+	return c.TTL
+}
+
+func (c *ReadCache) out() io.Writer {
+	if c.Out == nil {
+		return os.Stderr
+	}
+	return c.Out
+}
+
+func (c *ReadCache) read(fs afero.Fs, key string) (*readCacheEntry, error) {
+	raw, err := afero.ReadFile(fs, c.path(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var entry readCacheEntry
+	err = json.Unmarshal(raw, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse read cache: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (c *ReadCache) write(fs afero.Fs, now time.Time, key string, data any) error {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(readCacheEntry{
+		Timestamp: now,
+		Data:      rawData,
+	})
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+
+	err = fs.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	err = afero.WriteFile(fs, tmp, raw, 0600)
+	if err != nil {
+		return err
+	}
+
+	return fs.Rename(tmp, path)
+}
+
+// listKey returns the cache key of a list operation with the list filter of the cache.
+func (c *ReadCache) listKey() string {
+	if c == nil || len(c.ListFilter) == 0 {
+		return "list"
+	}
+
+	var filter []string
+	for _, name := range slices.Sorted(maps.Keys(c.ListFilter)) {
+		filter = append(filter, name, c.ListFilter[name])
+	}
+
+	return "list-" + readCacheHash(filter)
+}
+
+// describeKey returns the cache key of the describe operation of the given id.
+func (c *ReadCache) describeKey(id ...string) string {
+	return "get-" + readCacheHash(id)
+}
+
+// readCacheHash hashes the given values unambiguously, e.g. the ids ("a", "b") and ("a_b") result in different hashes.
+func readCacheHash(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+
+	// the json encoding of the values separates and escapes them
+	raw, _ := json.Marshal(values)
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:16])
+}
+
+// cachedRead fetches data through the given function and caches it if a read cache is configured. If the api is unreachable
+// or the cache is offline, the cached data is returned and a notice is printed that the data is stale.
+func cachedRead[T any](c *ReadCache, fs afero.Fs, clock Clock, key string, fetch func() (T, error)) (T, error) {
+	var zero T
+
+	if c == nil {
+		return fetch()
+	}
+
+	if !c.Offline {
+		resp, err := fetch()
+		if err == nil {
+			// failing to write the cache must not break the operation
+			_ = c.write(fs, clock.Now(), key, resp)
+			return resp, nil
+		}
+
+		if !IsUnreachable(err) {
+			return zero, err
+		}
+
+		cached, ok := readCached[T](c, fs, clock, key)
+		if !ok {
+			return zero, err
+		}
+
+		fmt.Fprintf(c.out(), "WARNING: api is unreachable (%s), showing stale data of context %q from %s (%s ago)\n", err, c.Context, cached.timestamp.Format(time.RFC3339), cached.age)
+
+		return cached.data, nil
+	}
+
+	cached, ok := readCached[T](c, fs, clock, key)
+	if !ok {
+		return zero, NewError(ErrorKindNotFound, fmt.Errorf("no cached data of %s in context %q within the last %s, run the command without --cached while the api is reachable", c.Entity, c.Context, c.ttl()))
+	}
+
+	fmt.Fprintf(c.out(), "WARNING: showing cached data of context %q from %s (%s ago), which may be stale\n", c.Context, cached.timestamp.Format(time.RFC3339), cached.age)
+
+	return cached.data, nil
+}
+
+type cachedData[T any] struct {
+	data      T
+	timestamp time.Time
+	age       time.Duration
+}
+
+// readCached returns the cached data of the given key if it is not older than the ttl of the cache.
+func readCached[T any](c *ReadCache, fs afero.Fs, clock Clock, key string) (*cachedData[T], bool) {
+	entry, err := c.read(fs, key)
+	if err != nil {
+		return nil, false
+	}
+
+	age := clock.Now().Sub(entry.Timestamp)
+	if age > c.ttl() {
+		return nil, false
+	}
+
+	var data T
+	err = json.Unmarshal(entry.Data, &data)
+	if err != nil {
+		return nil, false
+	}
+
+	return &cachedData[T]{
+		data:      data,
+		timestamp: entry.Timestamp,
+		age:       age.Round(time.Second),
+	}, true
+}
+
+// WithReadCache caches the results of list and describe operations, which are served when the api is unreachable or
+// the cache is offline, see ReadCache. Streaming of list results is disabled when a read cache is configured.
+func (a *MultiArgGenericCLI[C, U, R]) WithReadCache(cache *ReadCache) *MultiArgGenericCLI[C, U, R] {
+	a.readCache = cache
+	return a
+}
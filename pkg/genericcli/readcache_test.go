@@ -0,0 +1,188 @@
+package genericcli
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCache(t *testing.T) {
+	var (
+		now   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock = NewFakeClock(now, 0)
+		out   bytes.Buffer
+		cache = &ReadCache{Dir: "/cache", Context: "prod", Entity: "test", TTL: time.Hour, Out: &out}
+		items = []*testResponse{{ID: "1", Name: "one"}, {ID: "2", Name: "two"}}
+	)
+
+	unreachable := connect.NewError(connect.CodeUnavailable, fmt.Errorf("connection refused"))
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return(items, nil).Once()
+		mock.On("Get", "1").Return(items[0], nil).Once()
+		mock.On("List").Return(nil, unreachable).Once()
+		mock.On("Get", "1").Return(nil, unreachable).Once()
+		mock.On("Get", "2").Return(nil, unreachable).Once()
+		mock.On("Get", "3").Return(nil, fmt.Errorf("not found")).Once()
+	}, nil).WithClock(clock).WithReadCache(cache)
+
+	// the api is reachable, results are cached
+	got, err := cli.List()
+	require.NoError(t, err)
+	require.Equal(t, items, got)
+
+	_, err = cli.Describe("1")
+	require.NoError(t, err)
+	require.Empty(t, out.String())
+
+	clock.Advance(30 * time.Minute)
+
+	// the api is unreachable, cached results are served
+	got, err = cli.List()
+	require.NoError(t, err)
+	if diff := cmp.Diff(items, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+	require.Equal(t, "WARNING: api is unreachable (unavailable: connection refused), showing stale data of context \"prod\" from 2024-01-01T00:00:00Z (30m0s ago)\n", out.String())
+
+	described, err := cli.Describe("1")
+	require.NoError(t, err)
+	require.Equal(t, items[0], described)
+
+	_, err = cli.Describe("2")
+	require.EqualError(t, err, "unavailable: connection refused", "uncached entities fail with the original error")
+
+	_, err = cli.Describe("3")
+	require.EqualError(t, err, "not found", "api errors are not served from cache")
+
+	// offline serves from cache without contacting the api
+	out.Reset()
+	cache.Offline = true
+
+	got, err = cli.List()
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "WARNING: showing cached data of context \"prod\" from 2024-01-01T00:00:00Z (30m0s ago), which may be stale\n", out.String())
+
+	// expired entries are not served
+	clock.Advance(time.Hour)
+
+	_, err = cli.List()
+	require.EqualError(t, err, "no cached data of test in context \"prod\" within the last 1h0m0s, run the command without --cached while the api is reachable")
+	require.Equal(t, ErrorKindNotFound, ClassifyError(err))
+
+	// caches are separated per context
+	cache.Context = "dev"
+	clock.Advance(-time.Hour)
+
+	_, err = cli.List()
+	require.Error(t, err)
+}
+
+func TestReadCacheListFilter(t *testing.T) {
+	var (
+		clock = NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+		cache = &ReadCache{Dir: "/cache", Context: "prod", Entity: "test", Out: &bytes.Buffer{}}
+		a     = []*testResponse{{ID: "1", Name: "one"}}
+		b     = []*testResponse{{ID: "2", Name: "two"}}
+	)
+
+	unreachable := connect.NewError(connect.CodeUnavailable, fmt.Errorf("connection refused"))
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("List").Return(a, nil).Once()
+		mock.On("List").Return(b, nil).Once()
+		mock.On("List").Return(nil, unreachable).Times(3)
+	}, nil).WithClock(clock).WithReadCache(cache)
+
+	cache.ListFilter = map[string]string{"project": "a"}
+	_, err := cli.List()
+	require.NoError(t, err)
+
+	cache.ListFilter = map[string]string{"project": "b"}
+	_, err = cli.List()
+	require.NoError(t, err)
+
+	got, err := cli.List()
+	require.NoError(t, err)
+	require.Equal(t, b, got)
+
+	cache.ListFilter = map[string]string{"project": "a"}
+	got, err = cli.List()
+	require.NoError(t, err)
+	require.Equal(t, a, got)
+
+	// the results of a filter are never presented as the unfiltered list
+	cache.ListFilter = nil
+	_, err = cli.List()
+	require.EqualError(t, err, "unavailable: connection refused")
+}
+
+func TestReadCacheKeys(t *testing.T) {
+	c := &ReadCache{}
+
+	require.NotEqual(t, c.describeKey("a", "b"), c.describeKey("a_b"))
+	require.Equal(t, c.describeKey("a", "b"), c.describeKey("a", "b"))
+
+	require.Equal(t, "list", c.listKey())
+	require.Equal(t, "list", (*ReadCache)(nil).listKey())
+
+	c.ListFilter = map[string]string{"project": "a", "name": "b"}
+	key := c.listKey()
+	require.Equal(t, key, (&ReadCache{ListFilter: map[string]string{"name": "b", "project": "a"}}).listKey())
+	require.NotEqual(t, key, (&ReadCache{ListFilter: map[string]string{"project": "a"}}).listKey())
+	require.NotEqual(t, key, (&ReadCache{ListFilter: map[string]string{"project": "a\"", "name": "b"}}).listKey())
+}
+
+func TestListFilter(t *testing.T) {
+	defer viper.Reset()
+
+	cmd := &cobra.Command{Use: "list"}
+	cmd.Flags().String("project", "", "")
+	cmd.Flags().String("name", "", "")
+	cmd.Flags().Bool("cached", false, "")
+	cmd.Flags().StringSlice("sort-by", []string{}, "")
+	cmd.PersistentFlags().String("output-format", "table", "")
+
+	require.NoError(t, cmd.ParseFlags([]string{"--project", "a", "--cached", "--sort-by", "name", "--output-format", "yaml"}))
+	viper.Set("name", "b")
+
+	require.Equal(t, map[string]string{"project": "a", "name": "b"}, listFilter(cmd))
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "connect unavailable", err: connect.NewError(connect.CodeUnavailable, fmt.Errorf("down")), want: true},
+		{name: "connect not found", err: connect.NewError(connect.CodeNotFound, fmt.Errorf("missing")), want: false},
+		{name: "bad gateway", err: httperrors.NewHTTPError(http.StatusBadGateway, fmt.Errorf("bad gateway")), want: true},
+		{name: "http not found", err: httperrors.NotFound(fmt.Errorf("missing")), want: false},
+		{name: "connection refused", err: fmt.Errorf("wrapped: %w", &netError{}), want: true},
+		{name: "other", err: fmt.Errorf("something"), want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsUnreachable(tt.err))
+		})
+	}
+}
+
+type netError struct{}
+
+func (*netError) Error() string   { return "connection refused" }
+func (*netError) Timeout() bool   { return false }
+func (*netError) Temporary() bool { return false }
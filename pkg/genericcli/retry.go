@@ -0,0 +1,90 @@
+package genericcli
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy defines how failed operations of the generic cli are retried, e.g. on transient api errors like
+// too many requests or service unavailable.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of an operation including the first one, defaults to 3.
+	MaxAttempts int
+	// InitialBackoff is the duration to wait before the first retry, it doubles with every further retry. Defaults to 500ms.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum duration to wait between two attempts, defaults to 30s.
+	MaxBackoff time.Duration
+	// Retryable decides whether an operation is retried on the given error. If nil, all errors are retried.
+	// Already exists errors are never retried as they are required for applying entities.
+	Retryable func(err error) bool
+
+	sleep func(time.Duration)
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil {
+		return 1
+	}
+	if p.MaxAttempts <= 0 {
+		return 3
+	}
+	return p.MaxAttempts
+}
+
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if p == nil || err == nil || attempt >= p.maxAttempts() {
+		return false
+	}
+	if errors.Is(err, AlreadyExistsError()) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return true
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	var (
+		initial = p.InitialBackoff
+		max     = p.MaxBackoff
+	)
+
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 30 * time.Second
+	}
+
+	d := initial
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+
+	return min(d, max)
+}
+
+func (p *RetryPolicy) wait(attempt int) {
+	sleep := time.Sleep
+	if p.sleep != nil {
+		sleep = p.sleep
+	}
+
+	sleep(p.backoff(attempt))
+}
+
+// retry calls the given function until it succeeds or the retry policy gives up and returns the number of attempts.
+func retry[T any](p *RetryPolicy, fn func() (T, error)) (T, int, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if !p.shouldRetry(attempt, err) {
+			return result, attempt, err
+		}
+
+		p.wait(attempt)
+	}
+}
@@ -0,0 +1,89 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+var errTooManyRequests = errors.New("too many requests")
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, p.backoff(attempt))
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
+
+func TestApplyFromFileWithRetry(t *testing.T) {
+	const testFile = "/apply.yaml"
+
+	fileMockFn := func(fs afero.Fs) {
+		require.NoError(t, afero.WriteFile(fs, testFile, []byte(`---
+id: "1"
+name: one
+---
+id: "2"
+name: two
+`), 0755))
+	}
+
+	var waits []time.Duration
+
+	policy := &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		Retryable: func(err error) bool {
+			return errors.Is(err, errTooManyRequests)
+		},
+		sleep: func(d time.Duration) {
+			waits = append(waits, d)
+		},
+	}
+
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(nil, errTooManyRequests).Twice()
+		mock.On("Create", &testCreate{ID: "1", Name: "one"}).Return(&testResponse{ID: "1", Name: "one"}, nil).Once()
+		mock.On("Create", &testCreate{ID: "2", Name: "two"}).Return(nil, fmt.Errorf("invalid name")).Once()
+	}, fileMockFn).WithRetry(policy)
+
+	got, err := cli.ApplyFromFile(testFile)
+	if diff := cmp.Diff(fmt.Errorf("error creating entity: invalid name"), err, testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("error diff (+got -want):\n %s", diff)
+	}
+
+	want := BulkResults[*testResponse]{
+		{
+			Action:   BulkCreated,
+			Attempts: 3,
+			Result:   &testResponse{ID: "1", Name: "one"},
+		},
+		{
+			Action:   BulkErrorOnCreate,
+			Attempts: 1,
+			Error:    fmt.Errorf("error creating entity: invalid name"),
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreTypes(time.Duration(0)), testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+}
@@ -0,0 +1,391 @@
+package genericcli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+var yamlDocumentSeparator = regexp.MustCompile(`^---\s*(#.*)?$`)
+
+var (
+	supportedSchemaKeywords = []string{
+		"type", "properties", "required", "additionalProperties", "items", "enum", "const",
+		"minLength", "maxLength", "pattern", "minimum", "maximum", "minItems", "maxItems",
+	}
+	// annotationSchemaKeywords do not have an effect on the validation and can therefore be ignored
+	annotationSchemaKeywords = []string{
+		"$schema", "$id", "$comment", "title", "description", "default", "examples", "deprecated", "readOnly", "writeOnly", "format",
+	}
+)
+
+// JSONSchema validates yaml or json documents against a JSON schema, which is typically embedded into the CLI binary.
+//
+// Only a subset of the JSON schema specification is supported: type, properties, required, additionalProperties,
+// items, enum, const, minLength, maxLength, pattern, minimum, maximum, minItems and maxItems. Schemas containing other
+// validation keywords like $ref, allOf, anyOf or oneOf are rejected by NewJSONSchema, such that documents are never
+// reported as valid because of an ignored keyword.
+type JSONSchema struct {
+	root map[string]any
+}
+
+type schemaViolation struct {
+	path    []string
+	message string
+}
+
+// NewJSONSchema parses the given JSON schema, which can also be given in yaml format.
+func NewJSONSchema(raw []byte) (*JSONSchema, error) {
+	var root map[string]any
+	err := sigsyaml.Unmarshal(raw, &root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse json schema: %w", err)
+	}
+
+	err = checkSchemaKeywords(root, "$")
+	if err != nil {
+		return nil, fmt.Errorf("unsupported json schema: %w", err)
+	}
+
+	return &JSONSchema{root: root}, nil
+}
+
+// checkSchemaKeywords returns an error if the given schema or one of its sub-schemas contains a keyword that is not supported.
+func checkSchemaKeywords(schema map[string]any, path string) error {
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+
+	for _, k := range keys {
+		if slices.Contains(annotationSchemaKeywords, k) {
+			continue
+		}
+		if !slices.Contains(supportedSchemaKeywords, k) {
+			errs = append(errs, fmt.Errorf("keyword %q at %s is not supported", k, path))
+			continue
+		}
+
+		switch k {
+		case "properties":
+			properties, ok := schema[k].(map[string]any)
+			if !ok {
+				errs = append(errs, fmt.Errorf("properties at %s must be an object", path))
+				continue
+			}
+
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				sub, ok := properties[name].(map[string]any)
+				if !ok {
+					errs = append(errs, fmt.Errorf("property %q at %s must be a schema", name, path))
+					continue
+				}
+				errs = append(errs, checkSchemaKeywords(sub, path+".properties."+name))
+			}
+		case "items", "additionalProperties":
+			switch sub := schema[k].(type) {
+			case map[string]any:
+				errs = append(errs, checkSchemaKeywords(sub, path+"."+k))
+			case bool:
+				if k == "items" {
+					errs = append(errs, fmt.Errorf("items at %s must be a schema", path))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("%s at %s must be a schema", k, path))
+			}
+		case "pattern":
+			pattern, _ := schema[k].(string)
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("invalid pattern at %s: %w", path, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateYAML validates all documents of the given multi-document yaml against the schema.
+// The returned errors contain the document index and the line number of the violation.
+func (s *JSONSchema) ValidateYAML(raw []byte) error {
+	var errs []error
+
+	for i, doc := range splitYAMLDocuments(raw) {
+		errs = append(errs, s.validateDocument(i, doc)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateDocument validates a single document, the index and the line of the document are only used for the error messages.
+func (s *JSONSchema) validateDocument(index int, doc yamlDocument) []error {
+	var data any
+	err := sigsyaml.Unmarshal(doc.raw, &data)
+	if err != nil {
+		return []error{fmt.Errorf("document %d (line %d): %w", index, doc.line, err)}
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	var (
+		errs       []error
+		violations []schemaViolation
+	)
+
+	validateSchema(s.root, data, nil, &violations)
+
+	for _, v := range violations {
+		path := "$"
+		if len(v.path) > 0 {
+			path = "$." + strings.Join(v.path, ".")
+		}
+
+		errs = append(errs, fmt.Errorf("document %d (line %d): %s: %s", index, doc.line+lineOfPath(doc.raw, v.path), path, v.message))
+	}
+
+	return errs
+}
+
+type yamlDocument struct {
+	// line is the line number at which the document starts in the file, starting at 1
+	line int
+	raw  []byte
+}
+
+func splitYAMLDocuments(raw []byte) []yamlDocument {
+	var (
+		docs    []yamlDocument
+		current = yamlDocument{line: 1}
+		scanner = bufio.NewScanner(bytes.NewReader(raw))
+		lineNo  = 0
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), len(raw)+1)
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+
+		if yamlDocumentSeparator.Match(line) {
+			docs = append(docs, current)
+			current = yamlDocument{line: lineNo + 1}
+			continue
+		}
+
+		current.raw = append(current.raw, line...)
+		current.raw = append(current.raw, '\n')
+	}
+
+	docs = append(docs, current)
+
+	return slices.DeleteFunc(docs, func(d yamlDocument) bool {
+		return len(bytes.TrimSpace(d.raw)) == 0
+	})
+}
+
+// lineOfPath returns the line offset of the given path inside the document, zero if it cannot be determined.
+func lineOfPath(raw []byte, path []string) int {
+	if len(path) == 0 {
+		return 0
+	}
+
+	var b strings.Builder
+	b.WriteString("$")
+	for _, p := range path {
+		if _, err := strconv.Atoi(p); err == nil {
+			fmt.Fprintf(&b, "[%s]", p)
+			continue
+		}
+		fmt.Fprintf(&b, ".%s", p)
+	}
+
+	yamlPath, err := yaml.PathString(b.String())
+	if err != nil {
+		return 0
+	}
+
+	file, err := parser.ParseBytes(raw, 0)
+	if err != nil {
+		return 0
+	}
+
+	node, err := yamlPath.FilterFile(file)
+	if err != nil || node == nil || node.GetToken() == nil {
+		return 0
+	}
+
+	return node.GetToken().Position.Line - 1
+}
+
+func validateSchema(schema map[string]any, data any, path []string, violations *[]schemaViolation) {
+	violate := func(format string, args ...any) {
+		*violations = append(*violations, schemaViolation{path: slices.Clone(path), message: fmt.Sprintf(format, args...)})
+	}
+
+	if t, ok := schema["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []any:
+			for _, tt := range t {
+				if s, ok := tt.(string); ok {
+					types = append(types, s)
+				}
+			}
+		}
+
+		if !slices.ContainsFunc(types, func(t string) bool { return schemaTypeMatches(t, data) }) {
+			violate("expected type %s, got %s", strings.Join(types, " or "), schemaTypeOf(data))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(data) }) {
+			var values []string
+			for _, e := range enum {
+				values = append(values, fmt.Sprint(e))
+			}
+			violate("value %v is not one of: %s", data, strings.Join(values, "|"))
+		}
+	}
+
+	if c, ok := schema["const"]; ok && fmt.Sprint(c) != fmt.Sprint(data) {
+		violate("value %v must be %v", data, c)
+	}
+
+	switch data := data.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, ok := data[name]; !ok {
+						violate("missing required property %q", name)
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if propertySchema, ok := properties[k].(map[string]any); ok {
+				validateSchema(propertySchema, data[k], append(path, k), violations)
+				continue
+			}
+
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					*violations = append(*violations, schemaViolation{path: append(slices.Clone(path), k), message: "unknown property"})
+				}
+			case map[string]any:
+				validateSchema(additional, data[k], append(path, k), violations)
+			}
+		}
+
+	case []any:
+		if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(data)) < min {
+			violate("must contain at least %v items", min)
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(data)) > max {
+			violate("must contain at most %v items", max)
+		}
+
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range data {
+				validateSchema(items, item, append(path, strconv.Itoa(i)), violations)
+			}
+		}
+
+	case string:
+		length := float64(len([]rune(data)))
+		if min, ok := schemaNumber(schema["minLength"]); ok && length < min {
+			violate("must be at least %v characters long", min)
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && length > max {
+			violate("must be at most %v characters long", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				violate("invalid pattern in schema: %s", err)
+			} else if !re.MatchString(data) {
+				violate("value %q does not match pattern %q", data, pattern)
+			}
+		}
+
+	case float64:
+		if min, ok := schemaNumber(schema["minimum"]); ok && data < min {
+			violate("must be greater than or equal to %v", min)
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && data > max {
+			violate("must be less than or equal to %v", max)
+		}
+	}
+}
+
+func schemaNumber(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func schemaTypeMatches(t string, data any) bool {
+	switch t {
+	case "integer":
+		f, ok := data.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := data.(float64)
+		return ok
+	default:
+		return t == schemaTypeOf(data)
+	}
+}
+
+func schemaTypeOf(data any) string {
+	switch data.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", data)
+	}
+}
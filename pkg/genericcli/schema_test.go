@@ -0,0 +1,209 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["id"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "string", "minLength": 1},
+    "name": {"type": "string", "pattern": "^[a-z]+$"},
+    "size": {"type": "integer", "minimum": 1},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+func TestNewJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{
+			name:   "supported keywords and annotations",
+			schema: testSchema,
+		},
+		{
+			name: "unsupported keywords",
+			schema: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "machine",
+  "type": "object",
+  "allOf": [{"required": ["id"]}],
+  "properties": {
+    "id": {"$ref": "#/$defs/id"},
+    "tags": {"type": "array", "items": {"oneOf": [{"type": "string"}, {"type": "integer"}]}}
+  },
+  "$defs": {"id": {"type": "string"}}
+}`,
+			wantErr: `unsupported json schema: keyword "$defs" at $ is not supported
+keyword "allOf" at $ is not supported
+keyword "$ref" at $.properties.id is not supported
+keyword "oneOf" at $.properties.tags.items is not supported`,
+		},
+		{
+			name:    "invalid pattern",
+			schema:  `{"type": "string", "pattern": "["}`,
+			wantErr: "unsupported json schema: invalid pattern at $: error parsing regexp: missing closing ]: `[`",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJSONSchema([]byte(tt.schema))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestJSONSchema_ValidateYAML(t *testing.T) {
+	schema, err := NewJSONSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr error
+	}{
+		{
+			name: "valid documents",
+			raw: `---
+id: "1"
+name: one
+size: 3
+---
+id: "2"
+tags:
+  - a
+`,
+		},
+		{
+			name: "violations with line numbers",
+			raw: `---
+id: "1"
+name: One
+---
+# second document
+id: "2"
+size: 1.5
+tags:
+  - a
+  - 2
+foo: bar
+---
+name: three
+`,
+			wantErr: errors.Join(
+				errors.New(`document 0 (line 3): $.name: value "One" does not match pattern "^[a-z]+$"`),
+				errors.New(`document 1 (line 11): $.foo: unknown property`),
+				errors.New(`document 1 (line 7): $.size: expected type integer, got number`),
+				errors.New(`document 1 (line 10): $.tags.1: expected type string, got number`),
+				errors.New(`document 2 (line 13): $: missing required property "id"`),
+			),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateYAML([]byte(tt.raw))
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+type validatingTestCRUD struct {
+	testCRUD
+}
+
+func (v validatingTestCRUD) Validate(r *testResponse) error {
+	if r.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+func TestMultiOperationValidation(t *testing.T) {
+	const testFile = "/apply.yaml"
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, testFile, []byte(`---
+id: "1"
+name: one
+---
+id: "2"
+`), 0755))
+
+	cli := NewGenericMultiArgCLI[*testCreate, *testUpdate, *testResponse](validatingTestCRUD{}).WithFS(fs)
+
+	// no operation must be performed as the second document is invalid, the nil client of the test crud would panic otherwise
+	_, err := cli.ApplyFromFile(testFile)
+	require.EqualError(t, err, "validation failed: document 1: name must not be empty")
+}
+
+func TestMultiOperationSchemaValidation(t *testing.T) {
+	schema, err := NewJSONSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "yaml",
+			file: "/apply.yaml",
+			content: `---
+# unknown fields are dropped when decoding, so they have to be validated before
+id: "1"
+foo: bar
+`,
+			wantErr: "schema validation failed: document 0 (line 4): $.foo: unknown property",
+		},
+		{
+			name:    "ndjson",
+			file:    "/apply.ndjson",
+			content: `{"id":"1","foo":"bar"}`,
+			wantErr: "schema validation failed: document 0 (line 2): $.foo: unknown property",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		for _, streaming := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s streaming=%t", tt.name, streaming), func(t *testing.T) {
+				fs := afero.NewMemMapFs()
+				require.NoError(t, afero.WriteFile(fs, tt.file, []byte(tt.content), 0755))
+
+				// no operation must be performed as the document is invalid, the nil client of the test crud would panic otherwise
+				cli := NewGenericMultiArgCLI[*testCreate, *testUpdate, *testResponse](testCRUD{}).WithFS(fs).WithSchema(schema)
+				if streaming {
+					cli = cli.WithStreaming()
+				}
+
+				wantErr := tt.wantErr
+				if streaming {
+					wantErr = "aborting bulk operation: " + wantErr
+				}
+
+				_, err := cli.ApplyFromFile(tt.file)
+				require.EqualError(t, err, wantErr)
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package genericcli
+
+import (
+	"strings"
+
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+	"github.com/spf13/viper"
+)
+
+// SortPreferences persists the sort order chosen by the user per resource, such that it does not need to be passed on every list call.
+type SortPreferences interface {
+	// SortKeys returns the preferred sort keys of the resource in --sort-by syntax, empty if no preference is stored.
+	SortKeys(resource string) ([]string, error)
+	// SetSortKeys stores the preferred sort keys of the resource in --sort-by syntax, empty keys remove the preference.
+	SetSortKeys(resource string, keys []string) error
+}
+
+// ViperSortPreferences stores the sort preferences in the viper config file of the cli below the given key,
+// e.g. "sort-by-defaults.machine: [size, id:desc]".
+type ViperSortPreferences struct {
+	v   *viper.Viper
+	key string
+}
+
+// NewViperSortPreferences returns sort preferences that are stored in the config file of the global viper instance
+// below the key "sort-by-defaults".
+func NewViperSortPreferences() *ViperSortPreferences {
+	return &ViperSortPreferences{
+		v:   viper.GetViper(),
+		key: "sort-by-defaults",
+	}
+}
+
+// WithViper sets the viper instance, which reads and writes the config file.
+func (p *ViperSortPreferences) WithViper(v *viper.Viper) *ViperSortPreferences {
+	p.v = v
+	return p
+}
+
+// WithKey sets the key in the config file below which the preferences are stored.
+func (p *ViperSortPreferences) WithKey(key string) *ViperSortPreferences {
+	p.key = key
+	return p
+}
+
+func (p *ViperSortPreferences) SortKeys(resource string) ([]string, error) {
+	return p.v.GetStringSlice(p.key + "." + resource), nil
+}
+
+func (p *ViperSortPreferences) SetSortKeys(resource string, keys []string) error {
+	preferences := p.v.GetStringMap(p.key)
+	if len(keys) == 0 {
+		delete(preferences, resource)
+	} else {
+		preferences[resource] = keys
+	}
+
+	p.v.Set(p.key, preferences)
+
+	return p.v.WriteConfig()
+}
+
+// formatSortKeys formats the given keys in --sort-by syntax.
+func formatSortKeys(keys multisort.Keys) []string {
+	var res []string
+	for _, key := range keys {
+		res = append(res, key.ID+":"+key.Direction().String())
+	}
+	return res
+}
+
+// resolveSortKeys returns the sort keys of a list command. Explicitly passed sort keys take precedence over
+// the preference of the user, which takes precedence over the defaults of the command.
+func resolveSortKeys(flagKeys []string, preferences SortPreferences, resource string, defaults multisort.Keys) (multisort.Keys, error) {
+	if len(flagKeys) > 0 {
+		return parseSortKeys(flagKeys)
+	}
+
+	if preferences != nil {
+		preferred, err := preferences.SortKeys(resource)
+		if err != nil {
+			return nil, err
+		}
+		if len(preferred) > 0 {
+			return parseSortKeys(preferred)
+		}
+	}
+
+	return defaults, nil
+}
+
+func sortDefaultHelp(keys multisort.Keys) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	return " (default " + strings.Join(formatSortKeys(keys), ",") + ")"
+}
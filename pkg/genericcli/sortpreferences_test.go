@@ -0,0 +1,92 @@
+package genericcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/multisort"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSortKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("sort-by-defaults:\n  machine:\n  - size\n  - id:desc\n"), 0600))
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+
+	preferences := NewViperSortPreferences().WithViper(v)
+	defaults := multisort.Keys{{ID: "name"}}
+
+	tests := []struct {
+		name     string
+		flagKeys []string
+		resource string
+		want     multisort.Keys
+	}{
+		{
+			name:     "flag takes precedence",
+			flagKeys: []string{"name:desc"},
+			resource: "machine",
+			want:     multisort.Keys{{ID: "name", Descending: true}},
+		},
+		{
+			name:     "preference takes precedence over defaults",
+			resource: "machine",
+			want:     multisort.Keys{{ID: "size"}, {ID: "id", Descending: true}},
+		},
+		{
+			name:     "defaults without preference",
+			resource: "network",
+			want:     defaults,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSortKeys(tt.flagKeys, preferences, tt.resource, defaults)
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestViperSortPreferencesSetSortKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("current: prod\n"), 0600))
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+
+	preferences := NewViperSortPreferences().WithViper(v)
+
+	require.NoError(t, preferences.SetSortKeys("machine", formatSortKeys(multisort.Keys{{ID: "size"}, {ID: "id", Descending: true}})))
+
+	reread := viper.New()
+	reread.SetConfigFile(path)
+	require.NoError(t, reread.ReadInConfig())
+
+	got, err := NewViperSortPreferences().WithViper(reread).SortKeys("machine")
+	require.NoError(t, err)
+	require.Equal(t, []string{"size:asc", "id:desc"}, got)
+	require.Equal(t, "prod", reread.GetString("current"))
+
+	require.NoError(t, preferences.SetSortKeys("machine", nil))
+
+	got, err = preferences.SortKeys("machine")
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestSortDefaultHelp(t *testing.T) {
+	require.Equal(t, "", sortDefaultHelp(nil))
+	require.Equal(t, " (default size:asc,id:desc)", sortDefaultHelp(multisort.Keys{{ID: "size"}, {ID: "id", Descending: true}}))
+}
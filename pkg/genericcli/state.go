@@ -0,0 +1,210 @@
+package genericcli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+type (
+	// bulkStateHeader is the first document of a state file and describes the bulk operation.
+	bulkStateHeader struct {
+		From      string `json:"from"`
+		Operation string `json:"operation"`
+	}
+
+	// bulkStateEntry records a document of a bulk operation that was processed successfully.
+	// Every entry is appended as a separate yaml document to the state file, such that the file does not have to be
+	// rewritten for every processed document.
+	bulkStateEntry struct {
+		Document int        `json:"document"`
+		ID       []string   `json:"id,omitempty"`
+		Hash     string     `json:"hash"`
+		Action   BulkAction `json:"action"`
+	}
+
+	bulkStateFile struct {
+		fs     afero.Fs
+		path   string
+		hashes map[string]bool
+	}
+)
+
+// openBulkState opens the state file at the given path. If resume is false, a previously existing state is discarded.
+func openBulkState(fs afero.Fs, path string, resume bool, from string, op string) (*bulkStateFile, error) {
+	if path == "" {
+		return nil, errors.New("a state file is required for resuming a bulk operation")
+	}
+
+	s := &bulkStateFile{
+		fs:     fs,
+		path:   path,
+		hashes: map[string]bool{},
+	}
+
+	if resume {
+		header, entries, err := readBulkState(fs, path)
+		if err == nil {
+			if header.Operation != op {
+				return nil, fmt.Errorf("state file %s was written by a %s operation and cannot be resumed with %s", path, header.Operation, op)
+			}
+
+			for _, e := range entries {
+				s.hashes[e.Hash] = true
+			}
+
+			return s, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	raw, err := yaml.Marshal(bulkStateHeader{
+		From:      from,
+		Operation: op,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = afero.WriteFile(fs, path, append([]byte("---\n"), raw...), 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	return s, nil
+}
+
+// readBulkState reads the header and the completed entries of a state file.
+//
+// If the last entry cannot be parsed, the previous run was most likely interrupted while writing it, so it is dropped
+// and the corresponding document is processed again. Any other parse error renders the state file unusable.
+func readBulkState(fs afero.Fs, path string) (*bulkStateHeader, []bulkStateEntry, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("unable to read state file: %w", err)
+	}
+	defer f.Close()
+
+	var docs [][]byte
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read state file: %w", err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, nil, fmt.Errorf("state file %s is corrupted, it does not contain a header", path)
+	}
+
+	var header bulkStateHeader
+	err = yaml.UnmarshalStrict(docs[0], &header)
+	if err == nil && header.Operation == "" {
+		err = errors.New("operation is missing")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("state file %s is corrupted, unable to parse header: %w", path, err)
+	}
+
+	var entries []bulkStateEntry
+	for i, doc := range docs[1:] {
+		var entry bulkStateEntry
+		err = yaml.UnmarshalStrict(doc, &entry)
+		if err == nil && entry.Hash == "" {
+			err = errors.New("hash is missing")
+		}
+		if err != nil {
+			if i == len(docs)-2 {
+				fmt.Fprintf(os.Stderr, "ignoring incomplete last entry of state file %s\n", path)
+				break
+			}
+			return nil, nil, fmt.Errorf("state file %s is corrupted, unable to parse entry %d: %w", path, i, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return &header, entries, nil
+}
+
+func (s *bulkStateFile) completed(hash string) bool {
+	return s.hashes[hash]
+}
+
+// add appends the given entry to the state file.
+func (s *bulkStateFile) add(entry bulkStateEntry) error {
+	raw, err := yaml.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := s.fs.OpenFile(s.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open state file: %w", err)
+	}
+
+	_, err = f.Write(append([]byte("---\n"), raw...))
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("unable to write state file: %w", err)
+	}
+
+	s.hashes[entry.Hash] = true
+
+	return nil
+}
+
+// remove deletes the state file, which is done when all documents were processed successfully.
+func (s *bulkStateFile) remove() error {
+	err := s.fs.Remove(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func documentHash(doc any) (string, error) {
+	raw, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,149 @@
+package genericcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkStateFile(t *testing.T) {
+	const path = "/state/apply.yaml.state"
+
+	fs := afero.NewMemMapFs()
+
+	s, err := openBulkState(fs, path, false, "/apply.yaml", "applying")
+	require.NoError(t, err)
+
+	require.NoError(t, s.add(bulkStateEntry{Document: 0, ID: []string{"1"}, Hash: "a", Action: BulkCreated}))
+	require.NoError(t, s.add(bulkStateEntry{Document: 1, ID: []string{"2"}, Hash: "b", Action: BulkUpdated}))
+	require.True(t, s.completed("a"))
+	require.False(t, s.completed("c"))
+
+	raw, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	require.Equal(t, `---
+from: /apply.yaml
+operation: applying
+---
+action: created
+document: 0
+hash: a
+id:
+- "1"
+---
+action: updated
+document: 1
+hash: b
+id:
+- "2"
+`, string(raw), "entries are appended without rewriting the file")
+
+	resumed, err := openBulkState(fs, path, true, "/apply.yaml", "applying")
+	require.NoError(t, err)
+	require.True(t, resumed.completed("a"))
+	require.True(t, resumed.completed("b"))
+
+	require.NoError(t, resumed.add(bulkStateEntry{Document: 2, Hash: "c", Action: BulkCreated}))
+
+	_, entries, err := readBulkState(fs, path)
+	require.NoError(t, err)
+	if diff := cmp.Diff([]bulkStateEntry{
+		{Document: 0, ID: []string{"1"}, Hash: "a", Action: BulkCreated},
+		{Document: 1, ID: []string{"2"}, Hash: "b", Action: BulkUpdated},
+		{Document: 2, Hash: "c", Action: BulkCreated},
+	}, entries); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	_, err = openBulkState(fs, path, true, "/apply.yaml", "deleting")
+	require.EqualError(t, err, "state file /state/apply.yaml.state was written by a applying operation and cannot be resumed with deleting")
+
+	// not resuming discards the previous state
+	fresh, err := openBulkState(fs, path, false, "/apply.yaml", "applying")
+	require.NoError(t, err)
+	require.False(t, fresh.completed("a"))
+
+	_, entries, err = readBulkState(fs, path)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, fresh.remove())
+	require.NoError(t, fresh.remove(), "removing a missing state file is not an error")
+
+	// resuming without a state file starts from scratch
+	missing, err := openBulkState(fs, path, true, "/apply.yaml", "applying")
+	require.NoError(t, err)
+	require.False(t, missing.completed("a"))
+}
+
+func TestBulkStateFileCorrupted(t *testing.T) {
+	const path = "/apply.yaml.state"
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "interrupted write of the last entry",
+			content: `---
+from: /apply.yaml
+operation: applying
+---
+action: created
+document: 0
+hash: a
+---
+action: crea`,
+			want: []string{"a"},
+		},
+		{
+			name: "corrupted entry in the middle",
+			content: `---
+from: /apply.yaml
+operation: applying
+---
+action: [
+---
+action: created
+document: 1
+hash: b
+`,
+			wantErr: "state file /apply.yaml.state is corrupted, unable to parse entry 0: error converting YAML to JSON: yaml: line 1: did not find expected node content",
+		},
+		{
+			name:    "missing header",
+			content: "   \n",
+			wantErr: "state file /apply.yaml.state is corrupted, it does not contain a header",
+		},
+		{
+			name: "invalid header",
+			content: `---
+from: /apply.yaml
+`,
+			wantErr: "state file /apply.yaml.state is corrupted, unable to parse header: operation is missing",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, path, []byte(tt.content), 0600))
+
+			s, err := openBulkState(fs, path, true, "/apply.yaml", "applying")
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, hash := range tt.want {
+				require.True(t, s.completed(hash))
+			}
+			require.Len(t, s.hashes, len(tt.want))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package genericcli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/metal-stack/metal-lib/pkg/genericcli"
+
+// ExecuteWithTracing executes the root command within a span for the entire cli invocation, which carries the
+// command path, the number of flags that were set and the exit status. Spans of the generated commands become
+// children of this span, see WithTracing. If the tracer provider is nil, the global tracer provider is used,
+// which is typically configured with an OTLP exporter by the cli:
+//
+//	if err := genericcli.ExecuteWithTracing(ctx, rootCmd, tp); err != nil {
+//		os.Exit(genericcli.ExitCode(err))
+//	}
+func ExecuteWithTracing(ctx context.Context, root *cobra.Command, tp trace.TracerProvider) error {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	ctx, span := tp.Tracer(tracerName).Start(ctx, root.Name())
+	defer span.End()
+
+	cmd, err := root.ExecuteContextC(ctx)
+	if cmd != nil {
+		span.SetName(cmd.CommandPath())
+		span.SetAttributes(
+			attribute.String("cli.command", cmd.CommandPath()),
+			attribute.Int("cli.flags", cmd.Flags().NFlag()),
+		)
+	}
+
+	span.SetAttributes(attribute.Int("cli.exit_status", ExitCode(err)))
+	recordSpanError(span, err)
+
+	return err
+}
+
+// traceRunE enables tracing of the generic cli with the context of the command, such that the calls of the
+// CRUD implementation are traced as children of the span created by ExecuteWithTracing.
+func traceRunE[C any, U any, R any](cmd *cobra.Command, c *CmdsConfig[C, U, R]) {
+	runE := cmd.RunE
+	if runE == nil {
+		return
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if ctx := cmd.Context(); ctx != nil && trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			c.MultiArgGenericCLI = c.MultiArgGenericCLI.WithTracing(ctx)
+		}
+
+		return runE(cmd, args)
+	}
+}
+
+// startSpan starts a child span of the traced context, the returned function ends the span and records the given error.
+func (a *MultiArgGenericCLI[C, U, R]) startSpan(name string, attrs ...attribute.KeyValue) func(error) {
+	if a.tracer == nil {
+		return func(error) {}
+	}
+
+	_, span := a.tracer.Start(a.traceCtx, name, trace.WithAttributes(attrs...))
+
+	return func(err error) {
+		recordSpanError(span, err)
+		span.End()
+	}
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
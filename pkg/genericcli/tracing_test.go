@@ -0,0 +1,161 @@
+package genericcli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type recordedSpan struct {
+	noop.Span
+
+	tp     *recordingTracerProvider
+	sc     trace.SpanContext
+	Name   string
+	Parent string
+	Attrs  map[string]string
+	Status codes.Code
+	Ended  bool
+}
+
+func (s *recordedSpan) SpanContext() trace.SpanContext { return s.sc }
+
+func (s *recordedSpan) TracerProvider() trace.TracerProvider { return s.tp }
+
+func (s *recordedSpan) SetName(name string) { s.Name = name }
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) { s.Status = code }
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	for _, attr := range kv {
+		s.Attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) { s.Ended = true }
+
+type recordingTracerProvider struct {
+	noop.TracerProvider
+
+	lock  sync.Mutex
+	spans []*recordedSpan
+}
+
+func (p *recordingTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{tp: p}
+}
+
+type recordingTracer struct {
+	noop.Tracer
+
+	tp *recordingTracerProvider
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	t.tp.lock.Lock()
+	defer t.tp.lock.Unlock()
+
+	span := &recordedSpan{
+		tp:    t.tp,
+		Name:  name,
+		Attrs: map[string]string{},
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1},
+			SpanID:  trace.SpanID{byte(len(t.tp.spans) + 1)},
+		}),
+	}
+
+	if parent, ok := trace.SpanFromContext(ctx).(*recordedSpan); ok {
+		span.Parent = parent.Name
+	}
+
+	config := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(config.Attributes()...)
+
+	t.tp.spans = append(t.tp.spans, span)
+
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func TestExecuteWithTracing(t *testing.T) {
+	cli := newMockCLI(t, func(mock *mockTestClient) {
+		mock.On("Get", "1").Return(&testResponse{ID: "1"}, nil)
+		mock.On("Get", "2").Return(nil, fmt.Errorf("not found"))
+	}, nil)
+
+	c := &CmdsConfig[*testCreate, *testUpdate, *testResponse]{MultiArgGenericCLI: cli}
+
+	newRoot := func(ids ...string) *cobra.Command {
+		describe := &cobra.Command{
+			Use: "describe",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				for _, id := range ids {
+					if _, err := c.MultiArgGenericCLI.Describe(id); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}
+		describe.Flags().Bool("force", false, "")
+		traceRunE(describe, c)
+
+		root := &cobra.Command{Use: "cli"}
+		root.AddCommand(describe)
+		root.SetArgs([]string{"describe", "--force"})
+
+		return root
+	}
+
+	tp := &recordingTracerProvider{}
+
+	err := ExecuteWithTracing(context.Background(), newRoot("1"), tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*recordedSpan{
+		{
+			Name:  "cli describe",
+			Attrs: map[string]string{"cli.command": "cli describe", "cli.flags": "1", "cli.exit_status": "0"},
+			Ended: true,
+		},
+		{
+			Name: "get",
+			// the root span is named after the executed command once the execution finished
+			Parent: "cli",
+			Attrs:  map[string]string{"id": "[1]"},
+			Ended:  true,
+		},
+	}
+	if diff := cmp.Diff(want, tp.spans, cmp.FilterPath(func(p cmp.Path) bool {
+		return p.Last().String() == ".Span" || p.Last().String() == ".tp" || p.Last().String() == ".sc"
+	}, cmp.Ignore())); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	tp = &recordingTracerProvider{}
+
+	err = ExecuteWithTracing(context.Background(), newRoot("2"), tp)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if len(tp.spans) != 2 {
+		t.Fatalf("expected two spans, got %d", len(tp.spans))
+	}
+	if tp.spans[0].Status != codes.Error || tp.spans[0].Attrs["cli.exit_status"] != "1" {
+		t.Errorf("expected root span to record the error, got %+v", tp.spans[0])
+	}
+	if tp.spans[1].Status != codes.Error {
+		t.Errorf("expected get span to record the error, got %+v", tp.spans[1])
+	}
+}
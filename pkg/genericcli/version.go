@@ -0,0 +1,155 @@
+package genericcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/metal-stack/v"
+	"github.com/spf13/cobra"
+)
+
+// Version describes the build of a client or a server.
+type Version struct {
+	Name             string `json:"name" yaml:"name"`
+	Version          string `json:"version" yaml:"version"`
+	Revision         string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	GitSHA1          string `json:"git_sha1,omitempty" yaml:"git_sha1,omitempty"`
+	BuildDate        string `json:"build_date,omitempty" yaml:"build_date,omitempty"`
+	MinClientVersion string `json:"min_client_version,omitempty" yaml:"min_client_version,omitempty"`
+}
+
+// VersionInfo contains the version of the client and of the servers it talks to.
+type VersionInfo struct {
+	Client  Version   `json:"client" yaml:"client"`
+	Servers []Version `json:"servers,omitempty" yaml:"servers,omitempty"`
+}
+
+// VersionCmdConfig contains the configuration for the version command.
+type VersionCmdConfig struct {
+	// Name of the client, e.g. metalctl.
+	Name string
+	// ServerVersions returns the versions of the servers, e.g. by calling their version endpoints, optional.
+	ServerVersions func() ([]Version, error)
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+	// Err defines the output writer for warnings about version mismatches, will default to os.stderr
+	Err io.Writer
+}
+
+// ClientVersion returns the version of the client with the given name from the build information, see github.com/metal-stack/v.
+func ClientVersion(name string) Version {
+	return Version{
+		Name:      name,
+		Version:   v.Version,
+		Revision:  v.Revision,
+		GitSHA1:   v.GitSHA1,
+		BuildDate: v.BuildDate,
+	}
+}
+
+// NewVersionCmd returns a command that prints the version of the client and of the servers.
+// A warning is printed if the client is older than a server requires or its version differs from the server version.
+// The output format is configured by the flags added by AddPrinterFlags.
+func NewVersionCmd(c *VersionCmdConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "print the client and server version information",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := &VersionInfo{
+				Client: ClientVersion(c.Name),
+			}
+
+			var serverErr error
+			if c.ServerVersions != nil {
+				info.Servers, serverErr = c.ServerVersions()
+			}
+
+			p, err := PrinterFromViper(&PrinterConfig{
+				ToHeaderAndRows: versionTable,
+				Out:             c.Out,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = p.Print(info)
+			if err != nil {
+				return err
+			}
+
+			if serverErr != nil {
+				return fmt.Errorf("unable to retrieve server version: %w", serverErr)
+			}
+
+			errOut := c.Err
+			if errOut == nil {
+				errOut = os.Stderr
+			}
+			for _, warning := range versionWarnings(info) {
+				fmt.Fprintf(errOut, "WARNING: %s\n", warning)
+			}
+
+			return nil
+		},
+	}
+}
+
+// versionWarnings compares the client version with the server versions, development builds without a semantic version are not compared.
+func versionWarnings(info *VersionInfo) []string {
+	client, err := semver.NewVersion(info.Client.Version)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, server := range info.Servers {
+		if server.MinClientVersion != "" {
+			minimum, err := semver.NewVersion(server.MinClientVersion)
+			if err == nil && client.LessThan(minimum) {
+				warnings = append(warnings, fmt.Sprintf("client version %s is older than the minimum client version %s required by %s, please update the client", info.Client.Version, server.MinClientVersion, server.Name))
+				continue
+			}
+		}
+
+		serverVersion, err := semver.NewVersion(server.Version)
+		if err != nil {
+			continue
+		}
+
+		if client.Major() != serverVersion.Major() || client.Minor() != serverVersion.Minor() {
+			warnings = append(warnings, fmt.Sprintf("client version %s does not match version %s of %s", info.Client.Version, server.Version, server.Name))
+		}
+	}
+
+	return warnings
+}
+
+func versionTable(data any, wide bool) ([]string, [][]string, error) {
+	info, ok := data.(*VersionInfo)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported type for version table: %T", data)
+	}
+
+	header := []string{"", "Name", "Version", "Build Date"}
+	if wide {
+		header = append(header, "Revision", "Git SHA1", "Min Client Version")
+	}
+
+	row := func(kind string, version Version) []string {
+		r := []string{kind, version.Name, version.Version, version.BuildDate}
+		if wide {
+			r = append(r, version.Revision, version.GitSHA1, version.MinClientVersion)
+		}
+		return r
+	}
+
+	rows := [][]string{row("Client", info.Client)}
+	for _, server := range info.Servers {
+		rows = append(rows, row("Server", server))
+	}
+
+	return header, rows, nil
+}
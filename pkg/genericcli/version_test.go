@@ -0,0 +1,82 @@
+package genericcli
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		info *VersionInfo
+		want []string
+	}{
+		{
+			name: "matching versions",
+			info: &VersionInfo{
+				Client:  Version{Name: "metalctl", Version: "v0.15.2"},
+				Servers: []Version{{Name: "metal-api", Version: "v0.15.0", MinClientVersion: "v0.15.0"}},
+			},
+		},
+		{
+			name: "client older than minimum",
+			info: &VersionInfo{
+				Client:  Version{Name: "metalctl", Version: "v0.14.1"},
+				Servers: []Version{{Name: "metal-api", Version: "v0.15.0", MinClientVersion: "v0.15.0"}},
+			},
+			want: []string{"client version v0.14.1 is older than the minimum client version v0.15.0 required by metal-api, please update the client"},
+		},
+		{
+			name: "different minor versions",
+			info: &VersionInfo{
+				Client: Version{Name: "metalctl", Version: "v0.16.0"},
+				Servers: []Version{
+					{Name: "metal-api", Version: "v0.15.0"},
+					{Name: "masterdata-api", Version: "v0.16.3"},
+				},
+			},
+			want: []string{"client version v0.16.0 does not match version v0.15.0 of metal-api"},
+		},
+		{
+			name: "development build",
+			info: &VersionInfo{
+				Client:  Version{Name: "metalctl", Version: "devel"},
+				Servers: []Version{{Name: "metal-api", Version: "v0.15.0", MinClientVersion: "v0.15.0"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := versionWarnings(tt.info)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionTable(t *testing.T) {
+	info := &VersionInfo{
+		Client:  Version{Name: "metalctl", Version: "v0.15.2", BuildDate: "2024-01-01T00:00:00+00:00", GitSHA1: "abcdef12"},
+		Servers: []Version{{Name: "metal-api", Version: "v0.15.0", MinClientVersion: "v0.15.0"}},
+	}
+
+	header, rows, err := versionTable(info, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "Name", "Version", "Build Date"}, header)
+
+	want := [][]string{
+		{"Client", "metalctl", "v0.15.2", "2024-01-01T00:00:00+00:00"},
+		{"Server", "metal-api", "v0.15.0", ""},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	_, rows, err = versionTable(info, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Client", "metalctl", "v0.15.2", "2024-01-01T00:00:00+00:00", "", "abcdef12", ""}, rows[0])
+}
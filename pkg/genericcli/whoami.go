@@ -0,0 +1,186 @@
+package genericcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/metal-stack/metal-lib/jwt/sec"
+	"github.com/spf13/cobra"
+)
+
+// WhoAmI describes the user of the active context of a cli.
+type WhoAmI struct {
+	Subject   string     `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Name      string     `json:"name,omitempty" yaml:"name,omitempty"`
+	EMail     string     `json:"email,omitempty" yaml:"email,omitempty"`
+	Issuer    string     `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	Tenant    string     `json:"tenant,omitempty" yaml:"tenant,omitempty"`
+	Groups    []string   `json:"groups,omitempty" yaml:"groups,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	Context   string     `json:"context,omitempty" yaml:"context,omitempty"`
+	Project   string     `json:"project,omitempty" yaml:"project,omitempty"`
+}
+
+// WhoAmICmdConfig contains the configuration for the whoami command, either Token or WhoAmI is required.
+type WhoAmICmdConfig struct {
+	// Token returns the token of the active context, which is decoded without validation.
+	Token func() (string, error)
+	// WhoAmI retrieves the user from an API instead of decoding the token, e.g. when the token is opaque.
+	WhoAmI func() (*WhoAmI, error)
+	// Context returns the name of the active context and the active project, optional.
+	Context func() (context string, project string, err error)
+	// Plugin processes the groups of the token according to the metal-stack group conventions,
+	// if nil all groups and roles of the token are shown.
+	Plugin *sec.Plugin
+	// Clock is used for showing the remaining validity of the token, defaults to the system clock.
+	Clock Clock
+	// Out defines the output writer for the printer, will default to os.stdout
+	Out io.Writer
+}
+
+// NewWhoAmICmd returns a command that prints the user of the active context, which is useful for support tickets.
+// The output format is configured by the flags added by AddPrinterFlags.
+func NewWhoAmICmd(c *WhoAmICmdConfig) *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "shows the current user of the active context",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			whoami, err := c.whoami()
+			if err != nil {
+				return err
+			}
+
+			clock := c.Clock
+			if clock == nil {
+				clock = realClock{}
+			}
+
+			p, err := PrinterFromViper(&PrinterConfig{
+				ToHeaderAndRows: whoAmITable(clock.Now()),
+				Out:             c.Out,
+			})
+			if err != nil {
+				return err
+			}
+
+			return p.Print(whoami)
+		},
+	}
+}
+
+func (c *WhoAmICmdConfig) whoami() (*WhoAmI, error) {
+	var (
+		whoami *WhoAmI
+		err    error
+	)
+
+	switch {
+	case c.WhoAmI != nil:
+		whoami, err = c.WhoAmI()
+	case c.Token != nil:
+		whoami, err = c.fromToken()
+	default:
+		return nil, errors.New("either a token or a whoami function must be configured")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Context != nil {
+		whoami.Context, whoami.Project, err = c.Context()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine active context: %w", err)
+		}
+	}
+
+	return whoami, nil
+}
+
+func (c *WhoAmICmdConfig) fromToken() (*WhoAmI, error) {
+	token, err := c.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, errors.New("no token found in the active context, please login first")
+	}
+
+	user, claims, err := sec.ParseTokenUnvalidatedUnfiltered(token)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := user.Groups
+	if c.Plugin != nil {
+		processed, _, err := c.Plugin.ParseTokenUnvalidated(token)
+		if err != nil {
+			return nil, err
+		}
+		groups = processed.Groups
+	}
+
+	whoami := &WhoAmI{
+		Subject: user.Subject,
+		Name:    user.Name,
+		EMail:   user.EMail,
+		Issuer:  user.Issuer,
+		Tenant:  user.Tenant,
+	}
+
+	for _, g := range groups {
+		whoami.Groups = append(whoami.Groups, string(g))
+	}
+
+	if claims.ExpiresAt != 0 {
+		expiresAt := time.Unix(claims.ExpiresAt, 0)
+		whoami.ExpiresAt = &expiresAt
+	}
+
+	return whoami, nil
+}
+
+func whoAmITable(now time.Time) func(data any, wide bool) ([]string, [][]string, error) {
+	return func(data any, wide bool) ([]string, [][]string, error) {
+		whoami, ok := data.(*WhoAmI)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported type for whoami table: %T", data)
+		}
+
+		expires := ""
+		if whoami.ExpiresAt != nil {
+			expires = whoami.ExpiresAt.Format(time.RFC3339)
+			if remaining := whoami.ExpiresAt.Sub(now); remaining > 0 {
+				expires += fmt.Sprintf(" (in %s)", remaining.Truncate(time.Second))
+			} else {
+				expires += " (expired)"
+			}
+		}
+
+		rows := [][]string{
+			{"Subject", whoami.Subject},
+			{"Name", whoami.Name},
+			{"Email", whoami.EMail},
+			{"Tenant", whoami.Tenant},
+			{"Groups", strings.Join(whoami.Groups, "\n")},
+			{"Expires", expires},
+			{"Context", whoami.Context},
+			{"Project", whoami.Project},
+		}
+		if wide {
+			rows = append(rows, []string{"Issuer", whoami.Issuer})
+		}
+
+		var filtered [][]string
+		for _, row := range rows {
+			if row[1] != "" {
+				filtered = append(filtered, row)
+			}
+		}
+
+		return []string{"Key", "Value"}, filtered, nil
+	}
+}
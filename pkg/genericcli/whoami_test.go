@@ -0,0 +1,64 @@
+package genericcli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	libjwt "github.com/metal-stack/metal-lib/jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoAmIFromToken(t *testing.T) {
+	expiresAt := time.Unix(1557410799, 0)
+
+	token, err := libjwt.GenerateToken("tnnt", []string{"tnnt_kaas-all-all-admin"}, time.Unix(1557381999, 0), expiresAt)
+	require.NoError(t, err)
+
+	c := &WhoAmICmdConfig{
+		Token: func() (string, error) {
+			return token, nil
+		},
+		Context: func() (string, string, error) {
+			return "prod", "my-project", nil
+		},
+	}
+
+	got, err := c.whoami()
+	require.NoError(t, err)
+
+	require.NotNil(t, got.ExpiresAt)
+	require.True(t, expiresAt.Equal(*got.ExpiresAt))
+	require.NotEmpty(t, got.Groups)
+	require.Equal(t, "prod", got.Context)
+	require.Equal(t, "my-project", got.Project)
+
+	_, err = (&WhoAmICmdConfig{}).whoami()
+	require.EqualError(t, err, "either a token or a whoami function must be configured")
+}
+
+func TestWhoAmITable(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(90 * time.Minute)
+
+	header, rows, err := whoAmITable(now)(&WhoAmI{
+		Subject:   "user@example.com",
+		Tenant:    "tnnt",
+		Groups:    []string{"maas-all-all-admin", "kaas-all-all-admin"},
+		ExpiresAt: &expiresAt,
+		Issuer:    "https://dex.example.com",
+	}, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"Key", "Value"}, header)
+
+	want := [][]string{
+		{"Subject", "user@example.com"},
+		{"Tenant", "tnnt"},
+		{"Groups", "maas-all-all-admin\nkaas-all-all-admin"},
+		{"Expires", "2024-01-01T01:30:00Z (in 1h30m0s)"},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+}
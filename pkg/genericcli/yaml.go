@@ -1,9 +1,13 @@
 package genericcli
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"os"
 
 	"github.com/google/go-cmp/cmp"
@@ -14,9 +18,14 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// streamBufferSize is the amount of bytes that is inspected for detecting whether a stream contains JSON or YAML documents
+const streamBufferSize = 4096
+
 // MultiDocumentYAML offers functions on multidocument YAML files
 type MultiDocumentYAML[D any] struct {
 	fs afero.Fs
+	// lookupEnv is set when environment variables in the input are substituted before decoding
+	lookupEnv func(string) (string, bool)
 }
 
 func NewMultiDocumentYAML[D any]() *MultiDocumentYAML[D] {
@@ -25,21 +34,48 @@ func NewMultiDocumentYAML[D any]() *MultiDocumentYAML[D] {
 	}
 }
 
-// ReadAll reads all documents from a multi-document YAML from a given path
+// WithEnvSubstitution substitutes references to environment variables like ${VAR} or ${VAR:-default} in the input
+// before the documents are decoded, see SubstituteEnv.
+func (m *MultiDocumentYAML[D]) WithEnvSubstitution() *MultiDocumentYAML[D] {
+	m.lookupEnv = os.LookupEnv
+	return m
+}
+
+// ReadAll reads all documents from a multi-document YAML from a given path.
+// Besides YAML, a JSON array or a stream of JSON documents (e.g. NDJSON) is accepted as input.
 func (m *MultiDocumentYAML[D]) ReadAll(from string) ([]D, error) {
 	err := validateFrom(m.fs, from)
 	if err != nil {
 		return nil, err
 	}
 
-	reader, err := getReader(m.fs, from)
+	reader, err := m.reader(from)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.decodeAll(reader)
+}
+
+// readRaw reads the unparsed content from a given path, which allows validating it before decoding
+func (m *MultiDocumentYAML[D]) readRaw(from string) ([]byte, error) {
+	err := validateFrom(m.fs, from)
 	if err != nil {
 		return nil, err
 	}
 
+	reader, err := m.reader(from)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+func (m *MultiDocumentYAML[D]) decodeAll(reader io.Reader) ([]D, error) {
 	var docs []D
 
-	dec := utilyaml.NewYAMLToJSONDecoder(reader)
+	dec := newDocumentDecoder(reader)
 
 	for {
 		var data D
@@ -62,6 +98,161 @@ func (m *MultiDocumentYAML[D]) ReadAll(from string) ([]D, error) {
 	return docs, nil
 }
 
+// Stream returns an iterator over the documents of a multi-document YAML, a JSON array or a stream of JSON documents
+// (e.g. NDJSON) from a given path. In contrast to ReadAll, the documents are decoded one by one, such that huge files can be processed
+// with constant memory. Iteration stops after the first error.
+func (m *MultiDocumentYAML[D]) Stream(from string) iter.Seq2[D, error] {
+	return func(yield func(D, error) bool) {
+		var zero D
+
+		err := validateFrom(m.fs, from)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		reader, err := m.reader(from)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		if closer, ok := reader.(io.Closer); ok && from != "-" {
+			defer closer.Close()
+		}
+
+		dec := newDocumentDecoder(reader)
+
+		for {
+			var data D
+
+			err := dec.Decode(&data)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				yield(zero, fmt.Errorf("decode error: %w", err))
+				return
+			}
+
+			if pointer.IsZero(data) {
+				continue
+			}
+
+			if !yield(data, nil) {
+				return
+			}
+		}
+	}
+}
+
+// streamRaw returns an iterator over the undecoded documents from a given path, which allows validating every document
+// before it is decoded. JSON input is converted into yaml documents, such that line numbers match the ones of ValidateYAML
+// for the whole input.
+func (m *MultiDocumentYAML[D]) streamRaw(from string) iter.Seq2[yamlDocument, error] {
+	return func(yield func(yamlDocument, error) bool) {
+		err := validateFrom(m.fs, from)
+		if err != nil {
+			yield(yamlDocument{}, err)
+			return
+		}
+
+		reader, err := m.reader(from)
+		if err != nil {
+			yield(yamlDocument{}, err)
+			return
+		}
+
+		if closer, ok := reader.(io.Closer); ok && from != "-" {
+			defer closer.Close()
+		}
+
+		buffered := bufio.NewReaderSize(reader, streamBufferSize)
+
+		if isJSONStream(buffered) {
+			streamJSONDocuments(buffered, yield)
+			return
+		}
+
+		streamYAMLDocuments(buffered, yield)
+	}
+}
+
+// streamYAMLDocuments splits a multi-document yaml in the same way as splitYAMLDocuments without reading it into memory.
+func streamYAMLDocuments(r *bufio.Reader, yield func(yamlDocument, error) bool) {
+	var (
+		current = yamlDocument{line: 1}
+		lineNo  = 0
+	)
+
+	emit := func() bool {
+		if len(bytes.TrimSpace(current.raw)) == 0 {
+			return true
+		}
+		return yield(current, nil)
+	}
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNo++
+			trimmed := bytes.TrimRight(line, "\r\n")
+
+			if yamlDocumentSeparator.Match(trimmed) {
+				if !emit() {
+					return
+				}
+				current = yamlDocument{line: lineNo + 1}
+			} else {
+				current.raw = append(current.raw, trimmed...)
+				current.raw = append(current.raw, '\n')
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			emit()
+			return
+		}
+		if err != nil {
+			yield(yamlDocument{}, err)
+			return
+		}
+	}
+}
+
+// streamJSONDocuments converts every JSON document into a yaml document, see jsonToYAMLDocuments.
+func streamJSONDocuments(r *bufio.Reader, yield func(yamlDocument, error) bool) {
+	var (
+		dec  = newDocumentDecoder(r)
+		line = 2
+	)
+
+	for {
+		var doc json.RawMessage
+
+		err := dec.Decode(&doc)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				yield(yamlDocument{}, fmt.Errorf("decode error: %w", err))
+			}
+			return
+		}
+
+		converted, err := yaml.JSONToYAML(doc)
+		if err != nil {
+			yield(yamlDocument{}, fmt.Errorf("decode error: %w", err))
+			return
+		}
+
+		if !yield(yamlDocument{line: line, raw: converted}, nil) {
+			return
+		}
+
+		// every converted document is preceded by a document separator
+		line += bytes.Count(converted, []byte("\n")) + 1
+	}
+}
+
 // ReadOne reads exactly one document from a multi-document YAML from a given path, returns an error if there are no or more than one documents in it
 func (m *MultiDocumentYAML[D]) ReadOne(from string) (D, error) {
 	var zero D
@@ -90,12 +281,12 @@ func (m *MultiDocumentYAML[D]) ReadIndex(from string, index int) (D, error) {
 		return zero, err
 	}
 
-	reader, err := getReader(m.fs, from)
+	reader, err := m.reader(from)
 	if err != nil {
 		return zero, err
 	}
 
-	dec := utilyaml.NewYAMLToJSONDecoder(reader)
+	dec := newDocumentDecoder(reader)
 
 	count := 0
 	for {
@@ -120,6 +311,119 @@ func (m *MultiDocumentYAML[D]) ReadIndex(from string, index int) (D, error) {
 	}
 }
 
+// documentDecoder decodes documents from a multi-document YAML, a stream of JSON documents (e.g. NDJSON)
+// or the elements of a JSON array, which allows passing the output of tools like jq or API dumps.
+type documentDecoder struct {
+	reader *bufio.Reader
+	array  *json.Decoder
+	docs   *utilyaml.YAMLOrJSONDecoder
+}
+
+func newDocumentDecoder(reader io.Reader) *documentDecoder {
+	return &documentDecoder{
+		reader: bufio.NewReaderSize(reader, streamBufferSize),
+	}
+}
+
+func (d *documentDecoder) Decode(into any) error {
+	if d.array == nil && d.docs == nil {
+		if isJSONArray(d.reader) {
+			d.array = json.NewDecoder(d.reader)
+			if _, err := d.array.Token(); err != nil {
+				return err
+			}
+		} else {
+			d.docs = utilyaml.NewYAMLOrJSONDecoder(d.reader, streamBufferSize)
+		}
+	}
+
+	if d.docs != nil {
+		return d.docs.Decode(into)
+	}
+
+	if !d.array.More() {
+		return io.EOF
+	}
+
+	return d.array.Decode(into)
+}
+
+// isJSONArray returns true if the first non-whitespace character of the reader opens a JSON array.
+func isJSONArray(r *bufio.Reader) bool {
+	for i := 1; i <= streamBufferSize; i++ {
+		peeked, err := r.Peek(i)
+		if err != nil {
+			return false
+		}
+
+		switch peeked[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// isJSONStream returns true if the first non-whitespace character of the reader starts a JSON document or a JSON array.
+func isJSONStream(r *bufio.Reader) bool {
+	for i := 1; i <= streamBufferSize; i++ {
+		peeked, err := r.Peek(i)
+		if err != nil {
+			return false
+		}
+
+		switch peeked[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{', '[':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// isJSON returns true if the given input consists of JSON documents instead of YAML.
+func isJSON(raw []byte) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+// jsonToYAMLDocuments converts JSON documents into a multi-document YAML, such that it can be validated against a schema.
+func jsonToYAMLDocuments(raw []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		dec = newDocumentDecoder(bytes.NewReader(raw))
+	)
+
+	for {
+		var doc any
+
+		err := dec.Decode(&doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return buf.Bytes(), nil
+			}
+			return nil, fmt.Errorf("decode error: %w", err)
+		}
+
+		converted, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString("---\n")
+		buf.Write(converted)
+	}
+}
+
 // YamlIsEqual returns true if a yaml equal in content.
 func YamlIsEqual(x []byte, y []byte) (bool, error) {
 	var xParsed any
@@ -137,6 +441,20 @@ func YamlIsEqual(x []byte, y []byte) (bool, error) {
 	return cmp.Equal(xParsed, yParsed), nil
 }
 
+// reader opens the given path and applies the environment substitution if enabled
+func (m *MultiDocumentYAML[D]) reader(from string) (io.Reader, error) {
+	reader, err := getReader(m.fs, from)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.lookupEnv == nil {
+		return reader, nil
+	}
+
+	return newEnvSubstReader(reader, m.lookupEnv), nil
+}
+
 func getReader(fs afero.Fs, from string) (io.Reader, error) {
 	var reader io.Reader
 	var err error
@@ -65,6 +65,86 @@ func Test_ReadAll(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "parsing yaml with anchors and merge keys",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(`
+---
+defaults: &defaults
+  labels:
+    - shared
+id: a
+<<: *defaults
+---
+id: &id b
+labels:
+  - *id
+`), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"shared"},
+				},
+				{
+					ID:     "b",
+					Labels: []string{"b"},
+				},
+			},
+		},
+		{
+			name: "parsing json array",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(`
+[
+  {"id": "a", "labels": ["a"]},
+  {"id": "b", "labels": ["b"]}
+]`), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"a"},
+				},
+				{
+					ID:     "b",
+					Labels: []string{"b"},
+				},
+			},
+		},
+		{
+			name: "parsing ndjson",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(`{"id":"a","labels":["a"]}
+{"id":"b","labels":["b"]}
+`), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"a"},
+				},
+				{
+					ID:     "b",
+					Labels: []string{"b"},
+				},
+			},
+		},
+		{
+			name: "parsing single json document",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(`{
+  "id": "a",
+  "labels": ["a"]
+}`), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"a"},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -312,3 +392,91 @@ func Test_YamlIsEqual(t *testing.T) {
 		})
 	}
 }
+
+func Test_Stream(t *testing.T) {
+	const testFile = "/test.yaml"
+
+	tests := []struct {
+		name    string
+		mockFn  func(fs afero.Fs)
+		want    []testYAML
+		wantErr error
+	}{
+		{
+			name: "streaming multi-document yaml",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(testYAMLRaw), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"a"},
+				},
+				{
+					ID:     "b",
+					Labels: []string{"b"},
+				},
+			},
+		},
+		{
+			name: "streaming ndjson",
+			mockFn: func(fs afero.Fs) {
+				require.NoError(t, afero.WriteFile(fs, testFile, []byte(`{"id":"a","labels":["a"]}
+{"id":"b","labels":["b"]}
+`), 0755))
+			},
+			want: []testYAML{
+				{
+					ID:     "a",
+					Labels: []string{"a"},
+				},
+				{
+					ID:     "b",
+					Labels: []string{"b"},
+				},
+			},
+		},
+		{
+			name:    "file does not exist",
+			wantErr: fmt.Errorf("file does not exist: /test.yaml"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			m := MultiDocumentYAML[testYAML]{
+				fs: afero.NewMemMapFs(),
+			}
+
+			if tt.mockFn != nil {
+				tt.mockFn(m.fs)
+			}
+
+			var (
+				got []testYAML
+				err error
+			)
+			for doc, docErr := range m.Stream(testFile) {
+				if docErr != nil {
+					err = docErr
+					break
+				}
+				got = append(got, doc)
+			}
+
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_jsonToYAMLDocuments(t *testing.T) {
+	got, err := jsonToYAMLDocuments([]byte(`[{"id": "a"}, {"id": "b", "labels": ["b"]}]`))
+	require.NoError(t, err)
+	require.Equal(t, "---\nid: a\n---\nid: b\nlabels:\n- b\n", string(got))
+}
@@ -0,0 +1,118 @@
+package multisort
+
+import (
+	"cmp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CompareNatural compares strings in natural order, i.e. numbers contained in the strings are compared by their value,
+// e.g. machine-01 < machine-2 < machine-10.
+func CompareNatural(a string, b string, descending bool) CompareResult {
+	return WithCompareFunc(func() int {
+		return naturalCompare(a, b)
+	}, descending)
+}
+
+// CompareSemver compares strings as semantic versions, e.g. v1.9.0 < v1.10.0. A leading "v" is optional.
+// Strings that are no valid semantic versions are sorted after valid versions and compared in natural order among each other.
+func CompareSemver(a string, b string, descending bool) CompareResult {
+	return WithCompareFunc(func() int {
+		return semverCompare(a, b)
+	}, descending)
+}
+
+// CompareCaseInsensitive compares strings regardless of their case.
+func CompareCaseInsensitive(a string, b string, descending bool) CompareResult {
+	return WithCompareFunc(func() int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}, descending)
+}
+
+// Natural returns a compare function for a FieldMap entry, which compares the strings returned by get in natural order.
+func Natural[E any](get func(E) string) CompareFn[E] {
+	return func(a E, b E, descending bool) CompareResult {
+		return CompareNatural(get(a), get(b), descending)
+	}
+}
+
+// Semver returns a compare function for a FieldMap entry, which compares the strings returned by get as semantic versions.
+func Semver[E any](get func(E) string) CompareFn[E] {
+	return func(a E, b E, descending bool) CompareResult {
+		return CompareSemver(get(a), get(b), descending)
+	}
+}
+
+// CaseInsensitive returns a compare function for a FieldMap entry, which compares the strings returned by get regardless of their case.
+func CaseInsensitive[E any](get func(E) string) CompareFn[E] {
+	return func(a E, b E, descending bool) CompareResult {
+		return CompareCaseInsensitive(get(a), get(b), descending)
+	}
+}
+
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		chunkA, restA := nextChunk(a)
+		chunkB, restB := nextChunk(b)
+
+		var r int
+		if isDigit(chunkA[0]) && isDigit(chunkB[0]) {
+			r = compareNumeric(chunkA, chunkB)
+		} else {
+			r = strings.Compare(chunkA, chunkB)
+		}
+
+		if r != 0 {
+			return r
+		}
+
+		a, b = restA, restB
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
+
+// nextChunk splits off the leading run of either digits or non-digits.
+func nextChunk(s string) (string, string) {
+	digit := isDigit(s[0])
+
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digit {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+// compareNumeric compares two strings of digits by their value without being limited in size.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+
+	if r := cmp.Compare(len(a), len(b)); r != 0 {
+		return r
+	}
+
+	return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func semverCompare(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return naturalCompare(a, b)
+	}
+}
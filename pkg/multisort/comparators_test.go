@@ -0,0 +1,71 @@
+package multisort
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComparators(t *testing.T) {
+	type image struct {
+		Name    string
+		Version string
+	}
+
+	fields := FieldMap[image]{
+		"name": Natural(func(i image) string {
+			return i.Name
+		}),
+		"version": Semver(func(i image) string {
+			return i.Version
+		}),
+		"name-case-insensitive": CaseInsensitive(func(i image) string {
+			return i.Name
+		}),
+	}
+
+	tests := []struct {
+		name string
+		keys Keys
+		data []image
+		want []image
+	}{
+		{
+			name: "natural",
+			keys: Keys{{ID: "name"}},
+			data: []image{{Name: "machine-10"}, {Name: "machine-2"}, {Name: "machine-01"}, {Name: "machine"}, {Name: "machine-2a"}},
+			want: []image{{Name: "machine"}, {Name: "machine-01"}, {Name: "machine-2"}, {Name: "machine-2a"}, {Name: "machine-10"}},
+		},
+		{
+			name: "natural descending",
+			keys: Keys{{ID: "name", Descending: true}},
+			data: []image{{Name: "fw-1.9"}, {Name: "fw-1.10"}, {Name: "fw-1.2"}},
+			want: []image{{Name: "fw-1.10"}, {Name: "fw-1.9"}, {Name: "fw-1.2"}},
+		},
+		{
+			name: "semver",
+			keys: Keys{{ID: "version"}},
+			data: []image{{Version: "v1.10.0"}, {Version: "latest"}, {Version: "1.9.1"}, {Version: "v1.10.0-rc.1"}, {Version: "stable"}},
+			want: []image{{Version: "1.9.1"}, {Version: "v1.10.0-rc.1"}, {Version: "v1.10.0"}, {Version: "latest"}, {Version: "stable"}},
+		},
+		{
+			name: "case-insensitive with semver as secondary key",
+			keys: Keys{{ID: "name-case-insensitive"}, {ID: "version"}},
+			data: []image{{Name: "Ubuntu", Version: "24.4"}, {Name: "debian", Version: "12"}, {Name: "ubuntu", Version: "22.4"}},
+			want: []image{{Name: "debian", Version: "12"}, {Name: "ubuntu", Version: "22.4"}, {Name: "Ubuntu", Version: "24.4"}},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(fields, nil).SortBy(tt.data, tt.keys...)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, tt.data); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
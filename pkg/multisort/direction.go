@@ -0,0 +1,48 @@
+package multisort
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Direction is the direction in which a sort key is sorted.
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+// DirectionValues returns all available sort directions.
+func DirectionValues() []Direction {
+	return []Direction{Ascending, Descending}
+}
+
+// ParseDirection returns the sort direction for the given string, long forms like "ascending" and "descending" are accepted as well.
+func ParseDirection(s string) (Direction, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "asc", "ascending":
+		return Ascending, nil
+	case "desc", "descending":
+		return Descending, nil
+	default:
+		return "", fmt.Errorf("unsupported sort direction: %s", s)
+	}
+}
+
+func (d Direction) String() string {
+	return string(d)
+}
+
+// IsDescending returns true if the direction is descending.
+func (d Direction) IsDescending() bool {
+	return d == Descending
+}
+
+// Direction returns the sort direction of the key.
+func (k Key) Direction() Direction {
+	if k.Descending {
+		return Descending
+	}
+	return Ascending
+}
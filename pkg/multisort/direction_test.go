@@ -0,0 +1,35 @@
+package multisort
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestParseDirection(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Direction
+		wantErr error
+	}{
+		{input: "asc", want: Ascending},
+		{input: "ascending", want: Ascending},
+		{input: "DESC", want: Descending},
+		{input: " descending ", want: Descending},
+		{input: "up", wantErr: errors.New("unsupported sort direction: up")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDirection(tt.input)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
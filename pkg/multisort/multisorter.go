@@ -1,6 +1,7 @@
 package multisort
 
 import (
+	"container/heap"
 	"fmt"
 	"slices"
 	"sort"
@@ -31,6 +32,11 @@ func New[E any](fields FieldMap[E], defaultSortKeys Keys) *Sorter[E] {
 	}
 }
 
+// DefaultKeys returns the sort keys that are used when sorting without explicit sort keys.
+func (s *Sorter[E]) DefaultKeys() Keys {
+	return s.defaultSortKeys
+}
+
 // SortBy sorts the given data by the given sort keys.
 func (s *Sorter[E]) SortBy(data []E, keys ...Key) error {
 	if len(keys) == 0 {
@@ -46,7 +52,69 @@ func (s *Sorter[E]) SortBy(data []E, keys ...Key) error {
 		return err
 	}
 
-	slices.SortStableFunc(data, func(a, b E) int {
+	slices.SortStableFunc(data, s.compareFunc(keys))
+
+	return nil
+}
+
+// SortTop returns the first k elements of the given data sorted by the given sort keys without sorting the entire data,
+// which is considerably faster for large data when only few elements are displayed. The order is stable and the given
+// data is not modified. If no keys are given, the default sort keys are used.
+func (s *Sorter[E]) SortTop(data []E, keys Keys, k int) ([]E, error) {
+	if len(keys) == 0 {
+		keys = s.defaultSortKeys
+	}
+
+	err := s.validate(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	if len(keys) == 0 {
+		return slices.Clone(data[:min(k, len(data))]), nil
+	}
+
+	var (
+		compare = s.compareFunc(keys)
+		// ties are broken by the index in the original data in order to keep the sort stable
+		less = func(a, b indexed[E]) bool {
+			if r := compare(a.e, b.e); r != 0 {
+				return r < 0
+			}
+			return a.i < b.i
+		}
+		h = &boundedHeap[E]{greater: func(a, b indexed[E]) bool { return less(b, a) }}
+	)
+
+	for i, e := range data {
+		item := indexed[E]{i: i, e: e}
+
+		if h.Len() < k {
+			heap.Push(h, item)
+			continue
+		}
+
+		// the root is the greatest element of the heap, which is replaced if the current element is smaller
+		if less(item, h.items[0]) {
+			h.items[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+
+	result := make([]E, h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(h).(indexed[E]).e
+	}
+
+	return result, nil
+}
+
+func (s *Sorter[E]) compareFunc(keys Keys) func(a, b E) int {
+	return func(a, b E) int {
 		for _, key := range keys {
 			f := s.fields[key.ID]
 
@@ -58,9 +126,28 @@ func (s *Sorter[E]) SortBy(data []E, keys ...Key) error {
 			}
 		}
 		return 0
-	})
+	}
+}
 
-	return nil
+type indexed[E any] struct {
+	i int
+	e E
+}
+
+// boundedHeap is a max-heap, which holds the smallest elements seen so far with the greatest of them at the root.
+type boundedHeap[E any] struct {
+	items   []indexed[E]
+	greater func(a, b indexed[E]) bool
+}
+
+func (h *boundedHeap[E]) Len() int           { return len(h.items) }
+func (h *boundedHeap[E]) Less(i, j int) bool { return h.greater(h.items[i], h.items[j]) }
+func (h *boundedHeap[E]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *boundedHeap[E]) Push(x any)         { h.items = append(h.items, x.(indexed[E])) }
+func (h *boundedHeap[E]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
 }
 
 // AvailableKeys returns the available sort keys that this sorter has been initialized with.
@@ -388,3 +388,95 @@ func TestSortByWithStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestSortTop(t *testing.T) {
+	type machine struct {
+		ID      string
+		Project string
+	}
+
+	fields := FieldMap[machine]{
+		"id": func(a, b machine, descending bool) CompareResult {
+			return Compare(a.ID, b.ID, descending)
+		},
+		"project": func(a, b machine, descending bool) CompareResult {
+			return Compare(a.Project, b.Project, descending)
+		},
+	}
+
+	data := []machine{
+		{ID: "004", Project: "B"},
+		{ID: "001", Project: "B"},
+		{ID: "002", Project: "A"},
+		{ID: "005", Project: "A"},
+		{ID: "003", Project: "A"},
+	}
+
+	tests := []struct {
+		name    string
+		keys    Keys
+		k       int
+		want    []machine
+		wantErr error
+	}{
+		{
+			name: "top 2 by default keys",
+			k:    2,
+			want: []machine{{ID: "001", Project: "B"}, {ID: "002", Project: "A"}},
+		},
+		{
+			name: "top 3 by project is stable",
+			keys: Keys{{ID: "project"}},
+			k:    3,
+			want: []machine{{ID: "002", Project: "A"}, {ID: "005", Project: "A"}, {ID: "003", Project: "A"}},
+		},
+		{
+			name: "top 2 descending",
+			keys: Keys{{ID: "id", Descending: true}},
+			k:    2,
+			want: []machine{{ID: "005", Project: "A"}, {ID: "004", Project: "B"}},
+		},
+		{
+			name: "k exceeds length",
+			keys: Keys{{ID: "project"}, {ID: "id"}},
+			k:    10,
+			want: []machine{
+				{ID: "002", Project: "A"},
+				{ID: "003", Project: "A"},
+				{ID: "005", Project: "A"},
+				{ID: "001", Project: "B"},
+				{ID: "004", Project: "B"},
+			},
+		},
+		{
+			name: "k is zero",
+			k:    0,
+			want: nil,
+		},
+		{
+			name:    "unknown key",
+			keys:    Keys{{ID: "foo"}},
+			k:       2,
+			wantErr: errors.New("sort key does not exist: foo"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]machine(nil), data...)
+
+			got, err := New(fields, Keys{{ID: "id"}}).SortTop(input, tt.keys, tt.k)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			if diff := cmp.Diff(data, input); diff != "" {
+				t.Errorf("input was modified, diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package multisort
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewFromTags creates a new multisorter with the fields derived from struct tags, see FieldMapFromTags.
+// The given fields are added to the derived fields and take precedence, such that derived compare funcs can be overridden.
+func NewFromTags[E any](tag string, fields FieldMap[E], defaultSortKeys Keys) (*Sorter[E], error) {
+	derived, err := FieldMapFromTags[E](tag)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, fn := range fields {
+		derived[id] = fn
+	}
+
+	return New(derived, defaultSortKeys), nil
+}
+
+// FieldMapFromTags derives compare funcs for the exported fields of the struct E (or pointer to a struct) that have the given tag.
+// The sort key is the tag value up to the first comma, such that existing tags like json can be used as well.
+// Fields with an empty tag value or "-" are skipped.
+//
+// Supported are strings, numbers, booleans, time.Time and pointers to these types, nil pointers are sorted first.
+// Fields of other types are skipped, compare funcs for these can be passed to NewFromTags.
+func FieldMapFromTags[E any](tag string) (FieldMap[E], error) {
+	t := reflect.TypeOf((*E)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sort keys can only be derived from structs, got %s", t)
+	}
+
+	fields := FieldMap[E]{}
+
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		id, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if id == "" || id == "-" {
+			continue
+		}
+
+		if _, ok := fields[id]; ok {
+			return nil, fmt.Errorf("duplicate sort key %q in field %s", id, f.Name)
+		}
+
+		compareFn, err := compareFuncForType(f.Type)
+		if err != nil {
+			// fields like slices and maps cannot be sorted without a custom compare func
+			continue
+		}
+
+		index := f.Index
+		fields[id] = func(a E, b E, descending bool) CompareResult {
+			return WithCompareFunc(func() int {
+				return compareFn(fieldByIndex(a, index), fieldByIndex(b, index))
+			}, descending)
+		}
+	}
+
+	return fields, nil
+}
+
+// fieldByIndex returns the field of the given struct or an invalid value if a pointer on the way to the field is nil.
+func fieldByIndex(e any, index []int) reflect.Value {
+	v := reflect.ValueOf(e)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	f, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}
+	}
+
+	return f
+}
+
+func compareFuncForType(t reflect.Type) (func(a, b reflect.Value) int, error) {
+	if t.Kind() == reflect.Pointer {
+		elemFn, err := compareFuncForType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return func(a, b reflect.Value) int {
+			aNil := !a.IsValid() || a.IsNil()
+			bNil := !b.IsValid() || b.IsNil()
+
+			switch {
+			case aNil && bNil:
+				return 0
+			case aNil:
+				return -1
+			case bNil:
+				return 1
+			default:
+				return elemFn(a.Elem(), b.Elem())
+			}
+		}, nil
+	}
+
+	var fn func(a, b reflect.Value) int
+
+	switch t.Kind() {
+	case reflect.String:
+		fn = func(a, b reflect.Value) int {
+			return cmp.Compare(a.String(), b.String())
+		}
+	case reflect.Bool:
+		fn = func(a, b reflect.Value) int {
+			return cmp.Compare(boolToInt(a.Bool()), boolToInt(b.Bool()))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fn = func(a, b reflect.Value) int {
+			return cmp.Compare(a.Int(), b.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fn = func(a, b reflect.Value) int {
+			return cmp.Compare(a.Uint(), b.Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		fn = func(a, b reflect.Value) int {
+			return cmp.Compare(a.Float(), b.Float())
+		}
+	case reflect.Struct:
+		if t != timeType {
+			return nil, fmt.Errorf("unsupported type for sorting: %s", t)
+		}
+		fn = func(a, b reflect.Value) int {
+			return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type for sorting: %s", t)
+	}
+
+	// fields of embedded nil pointers are invalid and sorted first
+	return func(a, b reflect.Value) int {
+		switch {
+		case !a.IsValid() && !b.IsValid():
+			return 0
+		case !a.IsValid():
+			return -1
+		case !b.IsValid():
+			return 1
+		default:
+			return fn(a, b)
+		}
+	}, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package multisort
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+)
+
+func TestNewFromTags(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type meta struct {
+		Created time.Time `sort:"created"`
+	}
+
+	type machine struct {
+		*meta
+		ID        string            `sort:"id"`
+		Size      *string           `sort:"size"`
+		Cores     int               `sort:"cores"`
+		Allocated bool              `sort:"allocated"`
+		Labels    map[string]string `sort:"labels"`
+		Ignored   string            `sort:"-"`
+		Untagged  string
+	}
+
+	size := func(s string) *string { return &s }
+
+	a := &machine{meta: &meta{Created: now}, ID: "a", Size: size("c1-large"), Cores: 4, Allocated: true}
+	b := &machine{meta: &meta{Created: now.Add(time.Hour)}, ID: "b", Size: size("c1-medium"), Cores: 8}
+	c := &machine{ID: "c", Cores: 4}
+
+	sorter, err := NewFromTags(
+		"sort",
+		FieldMap[*machine]{
+			"id": func(a, b *machine, descending bool) CompareResult {
+				// reversed on purpose to show that the derived compare func is overridden
+				return Compare(b.ID, a.ID, descending)
+			},
+		},
+		Keys{{ID: "cores"}, {ID: "size"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if diff := cmp.Diff([]string{"allocated", "cores", "created", "id", "size"}, sorter.AvailableKeys()); diff != "" {
+		t.Errorf("diff (+got -want):\n %s", diff)
+	}
+
+	tests := []struct {
+		name string
+		keys Keys
+		want []*machine
+	}{
+		{
+			name: "default keys with nil pointer first",
+			want: []*machine{c, a, b},
+		},
+		{
+			name: "embedded time descending",
+			keys: Keys{{ID: "created", Descending: true}},
+			want: []*machine{b, a, c},
+		},
+		{
+			name: "bool",
+			keys: Keys{{ID: "allocated"}, {ID: "cores"}},
+			want: []*machine{c, b, a},
+		},
+		{
+			name: "override",
+			keys: Keys{{ID: "id"}},
+			want: []*machine{c, b, a},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			data := []*machine{b, c, a}
+
+			err := sorter.SortBy(data, tt.keys...)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if diff := cmp.Diff(tt.want, data, cmp.AllowUnexported(machine{})); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestFieldMapFromTagsWithoutStruct(t *testing.T) {
+	_, err := FieldMapFromTags[string]("sort")
+	if diff := cmp.Diff(errors.New("sort keys can only be derived from structs, got string"), err, testcommon.ErrorStringComparer()); diff != "" {
+		t.Errorf("error diff (+got -want):\n %s", diff)
+	}
+}
@@ -50,6 +50,25 @@ func SafeDerefOrDefault[T any](t *T, defaultValue T) T {
 	return *t
 }
 
+// DerefOr returns the value from the passed pointer or the default value for a nil pointer.
+// In contrast to SafeDerefOrDefault, a zero value is returned as is.
+func DerefOr[T any](t *T, defaultValue T) T {
+	if t == nil {
+		return defaultValue
+	}
+
+	return *t
+}
+
+// EqualDeref returns true if both pointers are nil or point to equal values.
+func EqualDeref[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
 // IsZero returns true if the passed value equals its zero value.
 func IsZero[T any](t T) bool {
 	var zero T
@@ -117,3 +117,39 @@ func TestSafeDerefOrDefault(t *testing.T) {
 		t.Errorf("SafeDerefOrDefault() = %s", diff)
 	}
 }
+
+func TestDerefOr(t *testing.T) {
+	testString := "test"
+	var testStringZero string
+	testStringDefault := "default"
+	gotString := DerefOr(&testString, testStringDefault)
+	if diff := cmp.Diff(gotString, testString); diff != "" {
+		t.Errorf("DerefOr() = %s", diff)
+	}
+	gotString = DerefOr(nil, testStringDefault)
+	if diff := cmp.Diff(gotString, testStringDefault); diff != "" {
+		t.Errorf("DerefOr() = %s", diff)
+	}
+	gotString = DerefOr(&testStringZero, testStringDefault)
+	if diff := cmp.Diff(gotString, testStringZero); diff != "" {
+		t.Errorf("DerefOr() = %s", diff)
+	}
+}
+
+func TestEqualDeref(t *testing.T) {
+	if !EqualDeref[string](nil, nil) {
+		t.Errorf("EqualDeref() of nil pointers should be true")
+	}
+	if EqualDeref(Pointer("a"), nil) {
+		t.Errorf("EqualDeref() of value and nil pointer should be false")
+	}
+	if EqualDeref(nil, Pointer("a")) {
+		t.Errorf("EqualDeref() of nil pointer and value should be false")
+	}
+	if !EqualDeref(Pointer("a"), Pointer("a")) {
+		t.Errorf("EqualDeref() of equal values should be true")
+	}
+	if EqualDeref(Pointer("a"), Pointer("b")) {
+		t.Errorf("EqualDeref() of different values should be false")
+	}
+}
@@ -15,3 +15,70 @@ func FirstOrZero[T any](t []T) T {
 func WrapInSlice[T any](t T) []T {
 	return []T{t}
 }
+
+// SafeDerefSlice returns the values of the given pointers, nil pointers result in zero values.
+func SafeDerefSlice[T any](ts []*T) []T {
+	if ts == nil {
+		return nil
+	}
+
+	res := make([]T, 0, len(ts))
+	for _, t := range ts {
+		res = append(res, SafeDeref(t))
+	}
+
+	return res
+}
+
+// PointerSlice returns a slice of pointers to copies of the given values.
+func PointerSlice[T any](ts []T) []*T {
+	if ts == nil {
+		return nil
+	}
+
+	res := make([]*T, 0, len(ts))
+	for _, t := range ts {
+		res = append(res, Pointer(t))
+	}
+
+	return res
+}
+
+// SafeDerefMap returns a map with the values of the given pointers, nil pointers result in zero values.
+func SafeDerefMap[K comparable, T any](m map[K]*T) map[K]T {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[K]T, len(m))
+	for k, t := range m {
+		res[k] = SafeDeref(t)
+	}
+
+	return res
+}
+
+// PointerMap returns a map with pointers to copies of the given values.
+func PointerMap[K comparable, T any](m map[K]T) map[K]*T {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[K]*T, len(m))
+	for k, t := range m {
+		res[k] = Pointer(t)
+	}
+
+	return res
+}
+
+// FirstNonNil returns the first pointer that is not nil or nil if all given pointers are nil.
+func FirstNonNil[T any](ts ...*T) *T {
+	for _, t := range ts {
+		if t != nil {
+			return t
+		}
+	}
+
+	return nil
+}
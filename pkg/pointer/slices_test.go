@@ -0,0 +1,62 @@
+package pointer
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSafeDerefSlice(t *testing.T) {
+	got := SafeDerefSlice([]*string{Pointer("a"), nil, Pointer("c")})
+	if diff := cmp.Diff([]string{"a", "", "c"}, got); diff != "" {
+		t.Errorf("SafeDerefSlice() = %s", diff)
+	}
+
+	if got := SafeDerefSlice[string](nil); got != nil {
+		t.Errorf("SafeDerefSlice() of nil slice should be nil, got %v", got)
+	}
+}
+
+func TestPointerSlice(t *testing.T) {
+	values := []string{"a", "b"}
+
+	got := PointerSlice(values)
+	if diff := cmp.Diff([]*string{Pointer("a"), Pointer("b")}, got); diff != "" {
+		t.Errorf("PointerSlice() = %s", diff)
+	}
+
+	values[0] = "modified"
+	if *got[0] != "a" {
+		t.Errorf("PointerSlice() should point to copies of the values")
+	}
+
+	if got := PointerSlice[string](nil); got != nil {
+		t.Errorf("PointerSlice() of nil slice should be nil, got %v", got)
+	}
+}
+
+func TestSafeDerefMap(t *testing.T) {
+	got := SafeDerefMap(map[string]*int{"a": Pointer(1), "b": nil})
+	if diff := cmp.Diff(map[string]int{"a": 1, "b": 0}, got); diff != "" {
+		t.Errorf("SafeDerefMap() = %s", diff)
+	}
+}
+
+func TestPointerMap(t *testing.T) {
+	got := PointerMap(map[string]int{"a": 1})
+	if diff := cmp.Diff(map[string]*int{"a": Pointer(1)}, got); diff != "" {
+		t.Errorf("PointerMap() = %s", diff)
+	}
+}
+
+func TestFirstNonNil(t *testing.T) {
+	b := Pointer("b")
+
+	if got := FirstNonNil(nil, b, Pointer("c")); got != b {
+		t.Errorf("FirstNonNil() = %v, want %v", got, b)
+	}
+
+	if got := FirstNonNil[string](nil, nil); got != nil {
+		t.Errorf("FirstNonNil() of nil pointers should be nil, got %v", got)
+	}
+}
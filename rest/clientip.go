@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseTrustedProxies parses the given ip addresses or cidr ranges into prefixes that can be passed to ClientIP.
+func ParseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	var res []netip.Prefix
+
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+
+		if strings.Contains(p, "/") {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+			}
+			res = append(res, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		addr = addr.Unmap()
+		res = append(res, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return res, nil
+}
+
+// ClientIP returns the ip address of the client that issued the given request.
+//
+// Forwarding headers are only evaluated if the request was received from one of the trusted proxies. In this case
+// the hops of the Forwarded header (RFC 7239) or, if not present, the X-Forwarded-For header are walked from right
+// to left and the first address that is not a trusted proxy is returned. The X-Real-Ip header is used when neither
+// of these headers is set. Otherwise the address of the peer is returned.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	return ClientIPFromHeader(r.Header, r.RemoteAddr, trustedProxies)
+}
+
+// ClientIPFromHeader returns the ip address of the client like ClientIP for cases where no http request is available,
+// e.g. in connect interceptors. The remote address is the address of the peer with an optional port.
+func ClientIPFromHeader(header http.Header, remoteAddr string, trustedProxies []netip.Prefix) string {
+	remote, ok := parseNode(remoteAddr)
+	if !ok {
+		return remoteAddr
+	}
+
+	if !isTrusted(remote, trustedProxies) {
+		return remote.String()
+	}
+
+	hops, ok := forwardedHops(header)
+	if !ok {
+		if realIP, ok := parseNode(header.Get("X-Real-Ip")); ok {
+			return realIP.String()
+		}
+		return remote.String()
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseNode(hops[i])
+		if !ok {
+			// the client address is unknown or obfuscated, so the last known hop is the closest we get
+			break
+		}
+
+		client = hop
+
+		if !isTrusted(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// forwardedHops returns the client addresses of the Forwarded header and falls back to the X-Forwarded-For header.
+func forwardedHops(header http.Header) ([]string, bool) {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []string
+
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				for _, pair := range strings.Split(element, ";") {
+					key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+					if !found || !strings.EqualFold(key, "for") {
+						continue
+					}
+					hops = append(hops, strings.Trim(value, `"`))
+				}
+			}
+		}
+
+		return hops, len(hops) > 0
+	}
+
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops, len(hops) > 0
+}
+
+// parseNode parses an ip address with an optional port, ipv6 addresses with port are enclosed in brackets.
+func parseNode(node string) (netip.Addr, bool) {
+	node = strings.TrimSpace(node)
+
+	if addr, err := netip.ParseAddr(node); err == nil {
+		return addr.Unmap(), true
+	}
+
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(node, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	for _, p := range trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/testcommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "2001:db8::1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     map[string][]string
+		want       string
+	}{
+		{
+			name:       "untrusted peer ignores headers",
+			remoteAddr: "1.2.3.4:1234",
+			header: map[string][]string{
+				"X-Forwarded-For": {"5.6.7.8"},
+				"X-Real-Ip":       {"5.6.7.8"},
+			},
+			want: "1.2.3.4",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "x-real-ip",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string][]string{"X-Real-Ip": {"5.6.7.8"}},
+			want:       "5.6.7.8",
+		},
+		{
+			name:       "x-forwarded-for skips trusted proxies from the right",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string][]string{"X-Forwarded-For": {"6.6.6.6, 5.6.7.8", "10.0.0.2"}},
+			want:       "5.6.7.8",
+		},
+		{
+			name:       "x-forwarded-for with only trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "forwarded takes precedence",
+			remoteAddr: "10.0.0.1:1234",
+			header: map[string][]string{
+				"Forwarded":       {`for=5.6.7.8;proto=https, for="10.0.0.2:8080";by=10.0.0.1`},
+				"X-Forwarded-For": {"6.6.6.6"},
+			},
+			want: "5.6.7.8",
+		},
+		{
+			name:       "forwarded with ipv6 and port",
+			remoteAddr: "[2001:db8::1]:443",
+			header:     map[string][]string{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}},
+			want:       "2001:db8:cafe::17",
+		},
+		{
+			name:       "forwarded with obfuscated client",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string][]string{"Forwarded": {"for=unknown, for=10.0.0.2"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "ipv4 mapped ipv6 peer",
+			remoteAddr: "[::ffff:1.2.3.4]:1234",
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "invalid remote address",
+			remoteAddr: "pipe",
+			want:       "pipe",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, values := range tt.header {
+				for _, v := range values {
+					r.Header.Add(key, v)
+				}
+			}
+
+			got := ClientIP(r, trusted)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    []netip.Prefix
+		wantErr error
+	}{
+		{
+			name:    "addresses and ranges",
+			proxies: []string{"10.0.0.1", " 192.168.1.7/16", "::1"},
+			want: []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.1/32"),
+				netip.MustParsePrefix("192.168.0.0/16"),
+				netip.MustParsePrefix("::1/128"),
+			},
+		},
+		{
+			name:    "invalid",
+			proxies: []string{"foo"},
+			wantErr: fmt.Errorf(`invalid trusted proxy "foo": %w`, errors.New(`ParseAddr("foo"): unable to parse IP`)),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrustedProxies(tt.proxies)
+			if diff := cmp.Diff(tt.wantErr, err, testcommon.ErrorStringComparer()); diff != "" {
+				t.Errorf("error diff (+got -want):\n %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b netip.Prefix) bool { return a == b })); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
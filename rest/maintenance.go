@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/metal-stack/security"
+)
+
+const (
+	// DefaultMaintenanceMessage is returned to the clients when maintenance mode is enabled without a message.
+	DefaultMaintenanceMessage = "the service is currently under maintenance, please try again later"
+	// DefaultMaintenanceRetryAfter is the default duration sent to the clients in the Retry-After header.
+	DefaultMaintenanceRetryAfter = 5 * time.Minute
+)
+
+// Maintenance can be toggled at runtime in order to reject all requests with 503 Service Unavailable,
+// e.g. to drain a control plane during an upgrade. Allow-listed routes and users are still served.
+type Maintenance struct {
+	enabled atomic.Bool
+
+	mu      sync.RWMutex
+	message string
+
+	log           *slog.Logger
+	retryAfter    time.Duration
+	allowedRoutes []string
+	allowedUsers  []string
+}
+
+// NewMaintenance returns a maintenance toggle, which is disabled initially.
+func NewMaintenance(log *slog.Logger) *Maintenance {
+	return &Maintenance{
+		log:        log,
+		retryAfter: DefaultMaintenanceRetryAfter,
+	}
+}
+
+// WithRetryAfter sets the duration that clients are asked to wait before retrying.
+func (m *Maintenance) WithRetryAfter(retryAfter time.Duration) *Maintenance {
+	m.retryAfter = retryAfter
+	return m
+}
+
+// WithAllowedRoutes allows requests during maintenance whose route or request path starts with one of the given prefixes,
+// e.g. health endpoints that are queried by the load balancer.
+func (m *Maintenance) WithAllowedRoutes(prefixes ...string) *Maintenance {
+	m.allowedRoutes = append(m.allowedRoutes, prefixes...)
+	return m
+}
+
+// WithAllowedUsers allows requests during maintenance from the given users, which are matched by their email, name or subject.
+// This requires the UserAuth filter to run before the maintenance filter.
+func (m *Maintenance) WithAllowedUsers(users ...string) *Maintenance {
+	m.allowedUsers = append(m.allowedUsers, users...)
+	return m
+}
+
+// Enable enables maintenance mode with the given message returned to the clients.
+func (m *Maintenance) Enable(message string) {
+	m.mu.Lock()
+	m.message = message
+	m.mu.Unlock()
+
+	if !m.enabled.Swap(true) {
+		m.log.Info("maintenance mode enabled", "message", message)
+	}
+}
+
+// Disable disables maintenance mode.
+func (m *Maintenance) Disable() {
+	if m.enabled.Swap(false) {
+		m.log.Info("maintenance mode disabled")
+	}
+}
+
+// Enabled returns true if maintenance mode is enabled.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Message returns the message that is returned to the clients during maintenance.
+func (m *Maintenance) Message() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.message == "" {
+		return DefaultMaintenanceMessage
+	}
+	return m.message
+}
+
+// WatchFile enables maintenance mode as long as the given file exists, its content is used as the message.
+// The file is checked in the given interval until the context is done, such that maintenance can be toggled
+// by creating and removing the file, e.g. from a mounted config map.
+func (m *Maintenance) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	m.checkFile(path)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkFile(path)
+		}
+	}
+}
+
+func (m *Maintenance) checkFile(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			// keep the current state as it is unknown whether the file was removed
+			m.log.Error("unable to read maintenance file", "path", path, "error", err)
+			return
+		}
+		m.Disable()
+		return
+	}
+
+	m.Enable(strings.TrimSpace(string(content)))
+}
+
+// Filter returns a filter that rejects all requests that are not allow-listed while maintenance mode is enabled.
+func (m *Maintenance) Filter() restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if !m.Enabled() || m.allowed(req) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		if m.retryAfter > 0 {
+			resp.AddHeader("Retry-After", strconv.Itoa(int(m.retryAfter.Round(time.Second).Seconds())))
+		}
+
+		err := resp.WriteHeaderAndEntity(http.StatusServiceUnavailable, httperrors.NewHTTPError(http.StatusServiceUnavailable, errors.New(m.Message())))
+		if err != nil {
+			GetLoggerFromContext(req.Request, m.log).Error("error sending response", "error", err)
+		}
+	}
+}
+
+func (m *Maintenance) allowed(req *restful.Request) bool {
+	for _, prefix := range m.allowedRoutes {
+		if strings.HasPrefix(req.SelectedRoutePath(), prefix) || strings.HasPrefix(req.Request.URL.Path, prefix) {
+			return true
+		}
+	}
+
+	if len(m.allowedUsers) == 0 {
+		return false
+	}
+
+	usr := security.GetUserFromContext(req.Request.Context())
+	if usr == nil {
+		return false
+	}
+
+	return slices.ContainsFunc(m.allowedUsers, func(allowed string) bool {
+		return allowed != "" && (allowed == usr.EMail || allowed == usr.Name || allowed == usr.Subject)
+	})
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceFilter(t *testing.T) {
+	m := NewMaintenance(slog.Default()).
+		WithRetryAfter(2 * time.Minute).
+		WithAllowedRoutes("/v1/health").
+		WithAllowedUsers("admin@example.com")
+
+	ws := new(restful.WebService)
+	ws.Path("/v1").Produces(restful.MIME_JSON)
+	ws.Route(ws.GET("/health").To(func(req *restful.Request, resp *restful.Response) {
+		_ = resp.WriteHeaderAndEntity(http.StatusOK, nil)
+	}))
+	ws.Route(ws.GET("/machine").To(func(req *restful.Request, resp *restful.Response) {
+		_ = resp.WriteHeaderAndEntity(http.StatusOK, nil)
+	}))
+
+	container := restful.NewContainer()
+	container.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if email := req.HeaderParameter("X-User"); email != "" {
+			req.Request = req.Request.WithContext(security.PutUserInContext(req.Request.Context(), &security.User{EMail: email}))
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	container.Filter(m.Filter())
+	container.Add(ws)
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		path           string
+		user           string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{
+			name:       "disabled",
+			path:       "/v1/machine",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:           "enabled rejects requests",
+			enabled:        true,
+			path:           "/v1/machine",
+			user:           "user@example.com",
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: "120",
+		},
+		{
+			name:       "enabled allows allow-listed routes",
+			enabled:    true,
+			path:       "/v1/health",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "enabled allows allow-listed users",
+			enabled:    true,
+			path:       "/v1/machine",
+			user:       "admin@example.com",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.enabled {
+				m.Enable("upgrading")
+			} else {
+				m.Disable()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != "" {
+				req.Header.Set("X-User", tt.user)
+			}
+			w := httptest.NewRecorder()
+
+			container.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			require.Equal(t, tt.wantRetryAfter, w.Header().Get("Retry-After"))
+			if tt.wantStatus == http.StatusServiceUnavailable {
+				require.Contains(t, w.Body.String(), "upgrading")
+			}
+		})
+	}
+}
+
+func TestMaintenanceCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance")
+	m := NewMaintenance(slog.Default())
+
+	m.checkFile(path)
+	require.False(t, m.Enabled())
+
+	require.NoError(t, os.WriteFile(path, []byte("database migration\n"), 0600))
+
+	m.checkFile(path)
+	require.True(t, m.Enabled())
+	require.Equal(t, "database migration", m.Message())
+
+	require.NoError(t, os.Remove(path))
+
+	m.checkFile(path)
+	require.False(t, m.Enabled())
+}
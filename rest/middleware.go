@@ -10,7 +10,6 @@ import (
 	"time"
 
 	restful "github.com/emicklei/go-restful/v3"
-	"github.com/google/uuid"
 	"github.com/metal-stack/metal-lib/httperrors"
 	"github.com/metal-stack/security"
 )
@@ -20,6 +19,7 @@ type Key int
 const (
 	RequestLoggerKey Key = iota
 	RequestIDKey
+	bearerTokenKey
 )
 
 type loggingResponseWriter struct {
@@ -54,10 +54,7 @@ func RequestLoggerFilter(logger *slog.Logger) restful.FilterFunction {
 		// perhaps a reverseproxy in front generates a unique header for some sort
 		// of opentracing support?
 
-		requestID := req.HeaderParameter("X-Request-Id")
-		if requestID == "" {
-			requestID = uuid.NewString()
-		}
+		requestID := incomingRequestID(rq.Context(), rq.Header)
 
 		fields := []any{
 			"rqid", requestID,
@@ -78,7 +75,7 @@ func RequestLoggerFilter(logger *slog.Logger) restful.FilterFunction {
 		requestLogger := logger.With(fields...)
 
 		enrichedContext := context.WithValue(req.Request.Context(), RequestLoggerKey, requestLogger)
-		enrichedContext = context.WithValue(enrichedContext, RequestIDKey, requestID)
+		enrichedContext = ContextWithRequestID(enrichedContext, requestID)
 		req.Request = req.Request.WithContext(enrichedContext)
 
 		t := time.Now()
@@ -106,7 +103,10 @@ func RequestLoggerFilter(logger *slog.Logger) restful.FilterFunction {
 	}
 }
 
-func UserAuth(ug security.UserGetter, fallbackLogger *slog.Logger) restful.FilterFunction {
+// AuthFailureHook is called when the user of a request cannot be authenticated.
+type AuthFailureHook func(req *restful.Request, err error)
+
+func UserAuth(ug security.UserGetter, fallbackLogger *slog.Logger, failureHooks ...AuthFailureHook) restful.FilterFunction {
 	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 		log := GetLoggerFromContext(req.Request, fallbackLogger)
 
@@ -119,6 +119,10 @@ func UserAuth(ug security.UserGetter, fallbackLogger *slog.Logger) restful.Filte
 				log.Error("cannot get user from request", "error", err)
 			}
 
+			for _, hook := range failureHooks {
+				hook(req, err)
+			}
+
 			err = resp.WriteHeaderAndEntity(http.StatusForbidden, httperrors.NewHTTPError(http.StatusForbidden, err))
 			if err != nil {
 				log.Error("error sending response", "error", err)
@@ -128,6 +132,9 @@ func UserAuth(ug security.UserGetter, fallbackLogger *slog.Logger) restful.Filte
 
 		rq := req.Request
 		ctx := security.PutUserInContext(rq.Context(), usr)
+		if token, ok := bearerToken(rq.Header); ok {
+			ctx = PutBearerTokenInContext(ctx, token)
+		}
 
 		log = log.With("useremail", usr.EMail, "username", usr.Name, "usertenant", usr.Tenant)
 		ctx = context.WithValue(ctx, RequestLoggerKey, log)
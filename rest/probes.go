@@ -0,0 +1,212 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/metal-stack/metal-lib/pkg/healthstatus"
+)
+
+// DefaultProbeTimeout is the default duration after which the checks of a probe are cancelled.
+const DefaultProbeTimeout = 5 * time.Second
+
+// Probes serves the standardized /health, /ready and /version endpoints of a service.
+//
+// Health checks tell whether the service is alive, e.g. it should be restarted when they fail. Readiness checks tell
+// whether the service is able to serve requests, e.g. its database is reachable, and include the health checks.
+// Both endpoints respond with 503 Service Unavailable if a check fails and report the result of every check.
+type Probes struct {
+	log     *slog.Logger
+	name    string
+	timeout time.Duration
+	version *VersionOpts
+
+	health []healthstatus.HealthCheck
+	ready  []healthstatus.HealthCheck
+}
+
+type funcCheck struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// CheckFunc returns a health check with the given name, which is healthy as long as the given function returns no error.
+func CheckFunc(name string, fn func(ctx context.Context) error) healthstatus.HealthCheck {
+	return &funcCheck{name: name, fn: fn}
+}
+
+func (c *funcCheck) ServiceName() string {
+	return c.name
+}
+
+func (c *funcCheck) Check(ctx context.Context) (healthstatus.HealthResult, error) {
+	err := c.fn(ctx)
+	if err != nil {
+		return healthstatus.HealthResult{
+			Status:  healthstatus.HealthStatusUnhealthy,
+			Message: err.Error(),
+		}, err
+	}
+
+	return healthstatus.HealthResult{
+		Status: healthstatus.HealthStatusHealthy,
+	}, nil
+}
+
+// NewProbes returns the probes of the service with the given name, which is reported by the version endpoint.
+// Without any checks, the service is always healthy and ready.
+func NewProbes(log *slog.Logger, name string) *Probes {
+	return &Probes{
+		log:     log,
+		name:    name,
+		timeout: DefaultProbeTimeout,
+		version: &VersionOpts{},
+	}
+}
+
+// WithHealthCheck adds a check with the given name to the health and readiness endpoints.
+func (p *Probes) WithHealthCheck(name string, fn func(ctx context.Context) error) *Probes {
+	return p.WithHealthChecks(CheckFunc(name, fn))
+}
+
+// WithHealthChecks adds the given checks to the health and readiness endpoints.
+func (p *Probes) WithHealthChecks(checks ...healthstatus.HealthCheck) *Probes {
+	p.health = append(p.health, checks...)
+	return p
+}
+
+// WithReadinessCheck adds a check with the given name to the readiness endpoint.
+func (p *Probes) WithReadinessCheck(name string, fn func(ctx context.Context) error) *Probes {
+	return p.WithReadinessChecks(CheckFunc(name, fn))
+}
+
+// WithReadinessChecks adds the given checks to the readiness endpoint.
+func (p *Probes) WithReadinessChecks(checks ...healthstatus.HealthCheck) *Probes {
+	p.ready = append(p.ready, checks...)
+	return p
+}
+
+// WithTimeout sets the duration after which the checks of a probe are cancelled, defaults to DefaultProbeTimeout.
+func (p *Probes) WithTimeout(timeout time.Duration) *Probes {
+	p.timeout = timeout
+	return p
+}
+
+// WithVersion sets additional information returned by the version endpoint, the base path of the options is ignored.
+func (p *Probes) WithVersion(opts *VersionOpts) *Probes {
+	p.version = opts
+	return p
+}
+
+// Health evaluates the health checks.
+func (p *Probes) Health(ctx context.Context) (HealthResponse, bool) {
+	return p.check(ctx, "health", p.health)
+}
+
+// Ready evaluates the health and readiness checks.
+func (p *Probes) Ready(ctx context.Context) (HealthResponse, bool) {
+	return p.check(ctx, "ready", append(append([]healthstatus.HealthCheck{}, p.health...), p.ready...))
+}
+
+func (p *Probes) check(ctx context.Context, probe string, checks []healthstatus.HealthCheck) (HealthResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	result, err := healthstatus.Grouped(p.log, p.name, checks...).Check(ctx)
+	if err != nil {
+		p.log.Error("probe failed", "probe", probe, "status", result.Status, "error", err)
+	}
+
+	return resultToResponse(result), err == nil
+}
+
+// Handler returns a handler serving the probes at /health, /ready and /version relative to the given base path,
+// which can be registered at a plain http.ServeMux:
+//
+//	mux.Handle("/", probes.Handler("/"))
+func (p *Probes) Handler(basePath string) http.Handler {
+	basePath = strings.TrimSuffix(basePath, "/")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+basePath+"/health", func(w http.ResponseWriter, r *http.Request) {
+		p.writeProbe(r.Context(), w, p.Health)
+	})
+	mux.HandleFunc("GET "+basePath+"/ready", func(w http.ResponseWriter, r *http.Request) {
+		p.writeProbe(r.Context(), w, p.Ready)
+	})
+	mux.HandleFunc("GET "+basePath+"/version", func(w http.ResponseWriter, r *http.Request) {
+		p.writeJSON(w, http.StatusOK, newVersionInfo(p.name, p.version))
+	})
+
+	return mux
+}
+
+// WebService returns a go-restful webservice serving the probes at /health, /ready and /version relative to the given base path.
+func (p *Probes) WebService(basePath string) *restful.WebService {
+	ws := new(restful.WebService)
+	ws.
+		Path(strings.TrimSuffix(basePath, "/")).
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	tags := []string{"probes"}
+
+	ws.Route(ws.GET("/health").To(func(request *restful.Request, response *restful.Response) {
+		p.writeProbe(request.Request.Context(), response, p.Health)
+	}).
+		Operation("probeHealth").
+		Doc("evaluates the health checks of the service").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", HealthResponse{}).
+		Returns(http.StatusServiceUnavailable, "Unhealthy", HealthResponse{}).
+		DefaultReturns("Error", httperrors.HTTPErrorResponse{}))
+
+	ws.Route(ws.GET("/ready").To(func(request *restful.Request, response *restful.Response) {
+		p.writeProbe(request.Request.Context(), response, p.Ready)
+	}).
+		Operation("probeReady").
+		Doc("evaluates the health and readiness checks of the service").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", HealthResponse{}).
+		Returns(http.StatusServiceUnavailable, "Not ready", HealthResponse{}).
+		DefaultReturns("Error", httperrors.HTTPErrorResponse{}))
+
+	ws.Route(ws.GET("/version").To(func(request *restful.Request, response *restful.Response) {
+		p.writeJSON(response, http.StatusOK, newVersionInfo(p.name, p.version))
+	}).
+		Operation("probeVersion").
+		Doc("returns the current version information of the service").
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Returns(http.StatusOK, "OK", version{}).
+		DefaultReturns("Error", httperrors.HTTPErrorResponse{}))
+
+	return ws
+}
+
+func (p *Probes) writeProbe(ctx context.Context, w http.ResponseWriter, probe func(context.Context) (HealthResponse, bool)) {
+	resp, ok := probe(ctx)
+
+	code := http.StatusOK
+	if !ok {
+		code = http.StatusServiceUnavailable
+	}
+
+	p.writeJSON(w, code, resp)
+}
+
+func (p *Probes) writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", restful.MIME_JSON)
+	w.WriteHeader(code)
+
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		p.log.Error("error writing response", "error", err)
+	}
+}
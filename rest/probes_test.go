@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/metal-lib/pkg/healthstatus"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbes(t *testing.T) {
+	var dbErr error
+
+	probes := NewProbes(slog.Default(), "metal-api").
+		WithHealthCheck("self", func(ctx context.Context) error {
+			return nil
+		}).
+		WithReadinessCheck("database", func(ctx context.Context) error {
+			return dbErr
+		}).
+		WithVersion(&VersionOpts{MinClientVersion: "v0.1.0", ReleaseVersion: pointer.Pointer("v1.0.0")})
+
+	servers := map[string]http.Handler{
+		"mux":     probes.Handler("/api/"),
+		"restful": restful.NewContainer().Add(probes.WebService("/api/")),
+	}
+
+	for name, server := range servers {
+		server := server
+		t.Run(name, func(t *testing.T) {
+			get := func(path string, body any) int {
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				require.NoError(t, json.NewDecoder(w.Body).Decode(body))
+				return w.Code
+			}
+
+			dbErr = nil
+
+			var health HealthResponse
+			require.Equal(t, http.StatusOK, get("/api/health", &health))
+			require.Equal(t, healthstatus.HealthStatusHealthy, health.Status)
+			require.Len(t, health.Services, 1)
+
+			var ready HealthResponse
+			require.Equal(t, http.StatusOK, get("/api/ready", &ready))
+			require.Equal(t, healthstatus.HealthStatusHealthy, ready.Status)
+
+			dbErr = fmt.Errorf("connection refused")
+
+			require.Equal(t, http.StatusOK, get("/api/health", &health), "readiness checks do not affect health")
+
+			ready = HealthResponse{}
+			require.Equal(t, http.StatusServiceUnavailable, get("/api/ready", &ready))
+
+			want := HealthResponse{
+				Status:  healthstatus.HealthStatusPartiallyUnhealthy,
+				Message: "connection refused",
+				Services: map[string]HealthResponse{
+					"self":     {Status: healthstatus.HealthStatusHealthy},
+					"database": {Status: healthstatus.HealthStatusUnhealthy, Message: "connection refused"},
+				},
+			}
+			if diff := cmp.Diff(want, ready); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+
+			var v version
+			require.Equal(t, http.StatusOK, get("/api/version", &v))
+			require.Equal(t, "metal-api", v.Name)
+			require.Equal(t, "v0.1.0", v.MinimumClientVersion)
+			require.Equal(t, pointer.Pointer("v1.0.0"), v.ReleaseVersion)
+		})
+	}
+}
+
+func TestProbesWithoutChecks(t *testing.T) {
+	resp, ok := NewProbes(slog.Default(), "test").Ready(context.Background())
+	require.True(t, ok)
+	require.Equal(t, healthstatus.HealthStatusHealthy, resp.Status)
+}
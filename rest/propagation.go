@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/metal-stack/security"
+)
+
+const (
+	// ImpersonateUserHeader carries the subject of the impersonated user.
+	ImpersonateUserHeader = "Impersonate-User"
+	// ImpersonateGroupHeader carries the groups of the impersonated user, it is set once per group.
+	ImpersonateGroupHeader = "Impersonate-Group"
+	// ImpersonateExtraHeaderPrefix is the prefix for further attributes of the impersonated user.
+	ImpersonateExtraHeaderPrefix = "Impersonate-Extra-"
+)
+
+type propagation struct {
+	impersonate  bool
+	serviceToken string
+}
+
+// PropagationOption configures how the identity of the incoming request is propagated to outgoing requests.
+type PropagationOption func(p *propagation)
+
+// WithImpersonation authenticates outgoing requests with the given service token and passes the user of the
+// incoming request in impersonation headers instead of forwarding the token of the user. The service has to be
+// allowed to impersonate users by the receiving side.
+func WithImpersonation(serviceToken string) PropagationOption {
+	return func(p *propagation) {
+		p.impersonate = true
+		p.serviceToken = serviceToken
+	}
+}
+
+// PutBearerTokenInContext stores the bearer token of the incoming request in the context, this is done by UserAuth.
+func PutBearerTokenInContext(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, bearerTokenKey, token)
+}
+
+// GetBearerTokenFromContext returns the bearer token of the incoming request or an empty string if it is not present.
+func GetBearerTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(bearerTokenKey).(string)
+	return token
+}
+
+// PropagateIdentity sets the headers for the identity of the incoming request contained in the given context to
+// the header of an outgoing request. By default the bearer token of the incoming request is forwarded.
+//
+// The request id of the incoming request is always forwarded, such that the audit chain stays intact.
+func PropagateIdentity(ctx context.Context, header http.Header, opts ...PropagationOption) {
+	p := &propagation{}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		header.Set(RequestIDHeader, requestID)
+	}
+
+	if !p.impersonate {
+		if token := GetBearerTokenFromContext(ctx); token != "" {
+			header.Set("Authorization", "Bearer "+token)
+		}
+		return
+	}
+
+	if p.serviceToken != "" {
+		header.Set("Authorization", "Bearer "+p.serviceToken)
+	}
+
+	// never pass impersonation headers of the incoming request on to the next hop
+	for key := range header {
+		if strings.HasPrefix(key, "Impersonate-") {
+			header.Del(key)
+		}
+	}
+
+	usr := security.GetUserFromContext(ctx)
+	if usr == nil {
+		return
+	}
+
+	header.Set(ImpersonateUserHeader, usr.Subject)
+	for _, g := range usr.Groups {
+		header.Add(ImpersonateGroupHeader, string(g))
+	}
+	for key, value := range map[string]string{
+		"Issuer": usr.Issuer,
+		"Name":   usr.Name,
+		"Email":  usr.EMail,
+		"Tenant": usr.Tenant,
+	} {
+		if value != "" {
+			header.Set(ImpersonateExtraHeaderPrefix+key, value)
+		}
+	}
+}
+
+// PropagateToRequest propagates the identity of the incoming request contained in the given context to the outgoing request.
+func PropagateToRequest(ctx context.Context, req *http.Request, opts ...PropagationOption) {
+	PropagateIdentity(ctx, req.Header, opts...)
+}
+
+type propagatingTransport struct {
+	next http.RoundTripper
+	opts []PropagationOption
+}
+
+// NewPropagatingTransport returns a http.RoundTripper that propagates the identity contained in the context of every
+// outgoing request, i.e. requests have to be created with http.NewRequestWithContext from the incoming request context.
+// If next is nil, http.DefaultTransport is used.
+func NewPropagatingTransport(next http.RoundTripper, opts ...PropagationOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &propagatingTransport{next: next, opts: opts}
+}
+
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a round tripper must not modify the given request
+	req = req.Clone(req.Context())
+	PropagateIdentity(req.Context(), req.Header, t.opts...)
+	return t.next.RoundTrip(req)
+}
+
+type propagatingInterceptor struct {
+	opts []PropagationOption
+}
+
+// NewPropagatingInterceptor returns a connect client interceptor that propagates the identity contained in the
+// context of outgoing unary and streaming calls.
+func NewPropagatingInterceptor(opts ...PropagationOption) connect.Interceptor {
+	return &propagatingInterceptor{opts: opts}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *propagatingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			PropagateIdentity(ctx, req.Header(), i.opts...)
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *propagatingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, s connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, s)
+		PropagateIdentity(ctx, conn.RequestHeader(), i.opts...)
+		return conn
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *propagatingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+func bearerToken(header http.Header) (string, bool) {
+	scheme, token, found := strings.Cut(header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/metal-stack/security"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagateIdentity(t *testing.T) {
+	usr := &security.User{
+		Issuer:  "https://issuer",
+		Subject: "1234",
+		Name:    "achim",
+		EMail:   "achim@metal-stack.io",
+		Groups:  []security.ResourceAccess{"tnnt-all-all-admin", "tnnt-all-all-view"},
+		Tenant:  "tnnt",
+	}
+
+	ctx := security.PutUserInContext(context.Background(), usr)
+	ctx = PutBearerTokenInContext(ctx, "user-token")
+	ctx = context.WithValue(ctx, RequestIDKey, "request-1")
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header http.Header
+		opts   []PropagationOption
+		want   http.Header
+	}{
+		{
+			name:   "forward token",
+			ctx:    ctx,
+			header: http.Header{},
+			want: http.Header{
+				"Authorization": {"Bearer user-token"},
+				"X-Request-Id":  {"request-1"},
+			},
+		},
+		{
+			name:   "impersonation",
+			ctx:    ctx,
+			header: http.Header{"Impersonate-User": {"someone-else"}, "Impersonate-Extra-Foo": {"bar"}},
+			opts:   []PropagationOption{WithImpersonation("service-token")},
+			want: http.Header{
+				"Authorization":            {"Bearer service-token"},
+				"X-Request-Id":             {"request-1"},
+				"Impersonate-User":         {"1234"},
+				"Impersonate-Group":        {"tnnt-all-all-admin", "tnnt-all-all-view"},
+				"Impersonate-Extra-Issuer": {"https://issuer"},
+				"Impersonate-Extra-Name":   {"achim"},
+				"Impersonate-Extra-Email":  {"achim@metal-stack.io"},
+				"Impersonate-Extra-Tenant": {"tnnt"},
+			},
+		},
+		{
+			name:   "empty context",
+			ctx:    context.Background(),
+			header: http.Header{},
+			want:   http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			PropagateIdentity(tt.ctx, tt.header, tt.opts...)
+
+			if diff := cmp.Diff(tt.want, tt.header); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func TestPropagatingTransport(t *testing.T) {
+	var got http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	ctx := PutBearerTokenInContext(context.Background(), "user-token")
+	ctx = context.WithValue(ctx, RequestIDKey, "request-1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: NewPropagatingTransport(nil)}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, "Bearer user-token", got.Get("Authorization"))
+	require.Equal(t, "request-1", got.Get("X-Request-Id"))
+	require.Empty(t, req.Header, "the original request must not be modified")
+}
+
+func TestBearerToken(t *testing.T) {
+	token, ok := bearerToken(http.Header{"Authorization": {"bearer abc"}})
+	require.True(t, ok)
+	require.Equal(t, "abc", token)
+
+	_, ok = bearerToken(http.Header{"Authorization": {"Basic abc"}})
+	require.False(t, ok)
+
+	_, ok = bearerToken(http.Header{})
+	require.False(t, ok)
+}
@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"connectrpc.com/connect"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the id of the request, which is forwarded to outgoing requests such that the
+// audit trail of downstream services references the originating request.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength limits the length of request ids taken from incoming requests.
+const maxRequestIDLength = 128
+
+// NewRequestID returns a new request id, which is a UUIDv7 such that request ids are sortable by time.
+func NewRequestID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// ContextWithRequestID returns a context carrying the given request id.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request id carried by the given context.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(RequestIDKey).(string)
+	return requestID, ok && requestID != ""
+}
+
+// requestIDFromHeader returns the request id of the given header or a new one if the header does not contain a valid one.
+// Request ids of clients are only accepted if they consist of printable ascii characters and are not too long,
+// such that they can be logged safely.
+func requestIDFromHeader(header http.Header) string {
+	requestID := header.Get(RequestIDHeader)
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return NewRequestID()
+	}
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return NewRequestID()
+		}
+	}
+	return requestID
+}
+
+// incomingRequestID returns the request id of the context if present and otherwise the one of the header.
+func incomingRequestID(ctx context.Context, header http.Header) string {
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		return requestID
+	}
+	return requestIDFromHeader(header)
+}
+
+// RequestIDMiddleware takes the request id from the X-Request-Id header or generates a new one, stores it in the
+// request context and sets it on the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := incomingRequestID(r.Context(), r.Header)
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), requestID)))
+	})
+}
+
+// RequestIDFilter is the go-restful equivalent of RequestIDMiddleware. It has to be added before RequestLoggerFilter
+// and the auditing filter in order to share the request id with them.
+func RequestIDFilter() restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		requestID := incomingRequestID(req.Request.Context(), req.Request.Header)
+
+		resp.Header().Set(RequestIDHeader, requestID)
+		req.Request = req.Request.WithContext(ContextWithRequestID(req.Request.Context(), requestID))
+
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+type requestIDInterceptor struct{}
+
+// NewRequestIDInterceptor returns the connect equivalent of RequestIDMiddleware. For clients, the request id of the
+// context is forwarded to the server.
+func NewRequestIDInterceptor() connect.Interceptor {
+	return &requestIDInterceptor{}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *requestIDInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			if requestID, ok := RequestIDFromContext(ctx); ok {
+				req.Header().Set(RequestIDHeader, requestID)
+			}
+			return next(ctx, req)
+		}
+
+		requestID := incomingRequestID(ctx, req.Header())
+
+		resp, err := next(ContextWithRequestID(ctx, requestID), req)
+		if err != nil {
+			var connectErr *connect.Error
+			if errors.As(err, &connectErr) {
+				connectErr.Meta().Set(RequestIDHeader, requestID)
+			}
+			return nil, err
+		}
+
+		resp.Header().Set(RequestIDHeader, requestID)
+
+		return resp, nil
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *requestIDInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, s connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, s)
+		if requestID, ok := RequestIDFromContext(ctx); ok {
+			conn.RequestHeader().Set(RequestIDHeader, requestID)
+		}
+		return conn
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *requestIDInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		requestID := incomingRequestID(ctx, conn.RequestHeader())
+
+		conn.ResponseHeader().Set(RequestIDHeader, requestID)
+
+		return next(ContextWithRequestID(ctx, requestID), conn)
+	}
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	restful "github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestID(t *testing.T) {
+	id, err := uuid.Parse(NewRequestID())
+	require.NoError(t, err)
+	require.Equal(t, uuid.Version(7), id.Version())
+}
+
+func TestRequestIDFromHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantSame bool
+	}{
+		{name: "valid id is taken", header: "abc-123", wantSame: true},
+		{name: "missing id is generated", header: ""},
+		{name: "id with control characters is replaced", header: "abc\n123"},
+		{name: "id with spaces is replaced", header: "abc 123"},
+		{name: "too long id is replaced", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestIDFromHeader(http.Header{RequestIDHeader: {tt.header}})
+			if tt.wantSame {
+				require.Equal(t, tt.header, got)
+				return
+			}
+			require.NotEqual(t, tt.header, got)
+			_, err := uuid.Parse(got)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var got string
+
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = RequestIDFromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "request-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, "request-1", got)
+	require.Equal(t, "request-1", w.Header().Get(RequestIDHeader))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.NotEmpty(t, got)
+	require.Equal(t, got, w.Header().Get(RequestIDHeader))
+}
+
+func TestRequestIDFilter(t *testing.T) {
+	var got string
+
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/").Filter(RequestIDFilter()).To(func(req *restful.Request, resp *restful.Response) {
+		got, _ = RequestIDFromContext(req.Request.Context())
+	}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "request-1")
+	w := httptest.NewRecorder()
+	container.ServeHTTP(w, r)
+
+	require.Equal(t, "request-1", got)
+	require.Equal(t, "request-1", w.Header().Get(RequestIDHeader))
+}
+
+func TestRequestIDInterceptor(t *testing.T) {
+	var got string
+
+	unary := NewRequestIDInterceptor().WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		got, _ = RequestIDFromContext(ctx)
+		return connect.NewResponse(&struct{}{}), nil
+	})
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(RequestIDHeader, "request-1")
+
+	resp, err := unary(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "request-1", got)
+	require.Equal(t, "request-1", resp.Header().Get(RequestIDHeader))
+
+	failing := NewRequestIDInterceptor().WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, nil)
+	})
+
+	_, err = failing(ContextWithRequestID(context.Background(), "request-2"), connect.NewRequest(&struct{}{}))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	require.Equal(t, "request-2", connectErr.Meta().Get(RequestIDHeader))
+}
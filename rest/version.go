@@ -41,15 +41,7 @@ func NewVersion(name string, opts *VersionOpts) *restful.WebService {
 
 	tags := []string{"version"}
 
-	vi := version{
-		Name:                 name,
-		Version:              v.Version,
-		Revision:             v.Revision,
-		BuildDate:            v.BuildDate,
-		Gitsha1:              v.GitSHA1,
-		MinimumClientVersion: opts.MinClientVersion,
-		ReleaseVersion:       opts.ReleaseVersion,
-	}
+	vi := newVersionInfo(name, opts)
 
 	ws.Route(
 		ws.GET("/").
@@ -64,3 +56,15 @@ func NewVersion(name string, opts *VersionOpts) *restful.WebService {
 
 	return ws
 }
+
+func newVersionInfo(name string, opts *VersionOpts) version {
+	return version{
+		Name:                 name,
+		Version:              v.Version,
+		Revision:             v.Revision,
+		BuildDate:            v.BuildDate,
+		Gitsha1:              v.GitSHA1,
+		MinimumClientVersion: opts.MinClientVersion,
+		ReleaseVersion:       opts.ReleaseVersion,
+	}
+}